@@ -0,0 +1,561 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	"storage-tui/internal/app"
+	"storage-tui/internal/audit"
+	"storage-tui/internal/azure"
+	"storage-tui/internal/cli"
+	"storage-tui/internal/config"
+	"storage-tui/internal/keychain"
+	"storage-tui/internal/logging"
+	"storage-tui/internal/metrics"
+	"storage-tui/internal/patterns"
+	"storage-tui/internal/recording"
+	"storage-tui/internal/remote"
+	"storage-tui/internal/syncjobs"
+	"storage-tui/internal/usage"
+)
+
+func main() {
+	configPath := flag.String("config", "", "config file (default: storage-tui/config.yaml in the user config directory)")
+	provider := flag.String("provider", "", "data provider: mock")
+	mockFixture := flag.String("mock-fixture", "", "YAML or JSON file of subscriptions, accounts, containers, and blobs for the mock provider to serve")
+	mockScale := flag.Int("mock-scale", 0, "have the mock provider serve this many generated storage accounts, about a thousand blobs each, to measure paging and drawing")
+	recordPath := flag.String("record", "", "write every answer of the provider to this file, for -replay")
+	replayPath := flag.String("replay", "", "answer from a file written with -record instead of a provider, offline")
+	profile := flag.String("profile", "", "start with this profile from the config file")
+	theme := flag.String("theme", "", "color theme: dark, light, or solarized")
+	noMouse := flag.Bool("no-mouse", false, "disable mouse support")
+	watchInterval := flag.Duration("watch-interval", 0, "how often watch mode (W) re-lists the container (default 30s)")
+	watchEvents := flag.Bool("watch-events", false, "have watch mode (W) follow blobs created and deleted as storage events arrive, instead of re-listing")
+	exactSizes := flag.Bool("exact-sizes", false, "show exact byte counts instead of rounded sizes")
+	timeDisplay := flag.String("time", "", "timestamp display: utc, local, or relative")
+	icons := flag.String("icons", "off", "item icons: nerd (Nerd Font glyphs), ascii, or off")
+	layout := flag.String("layout", "", "pane layout: stacked (preview below contents) or columns (preview to the right)")
+	restore := flag.Bool("restore", false, "restore the previous session of the profile without asking")
+	subscription := flag.String("subscription", "", "start in this subscription (ID or name)")
+	account := flag.String("account", "", "start at this storage account")
+	container := flag.String("container", "", "start listing this container of -account")
+	prefix := flag.String("prefix", "", "list only the blobs of -container whose names start with this prefix")
+	previewMemory := flag.String("preview-memory", "", "previewed blob content kept in memory, e.g. 32 MB (default 16 MB)")
+	diskCache := flag.Bool("cache", false, "keep listings, properties, and previews on disk for instant relaunches and offline browsing")
+	logLevel := flag.String("log-level", "", "least severe records written to the log file: debug, info, warn, or error (default info)")
+	pprofAddr := flag.String("pprof", "", "serve net/http/pprof and a metrics page (/debug/metrics) on this loopback address, e.g. localhost:6060")
+	pprofPublic := flag.Bool("pprof-public", false, "let -pprof take a non-loopback address; its heap and goroutine dumps can hold keys and signed URLs")
+	demo := flag.Bool("demo", false, "walk through browsing the built-in mock data, pressing the keys as a script, then quit; fails if an operation fails")
+	demoPace := flag.Duration("demo-pace", 0, "how long -demo shows each step (default 2s)")
+	serveAddr := flag.String("serve", "", "run as a daemon answering remote profiles on this address, e.g. :7443, instead of the browser")
+	serveCert := flag.String("serve-cert", "", "TLS certificate of the daemon (PEM); without it, -serve only takes a loopback address")
+	serveKey := flag.String("serve-key", "", "TLS key of -serve-cert (PEM)")
+	serveToken := flag.String("serve-token-secret", "", "keychain secret holding the token clients must present (default: $"+tokenEnv+")")
+	flag.Usage = func() {
+		out := flag.CommandLine.Output()
+		fmt.Fprintf(out, "Usage: %s [flags] [command]\n\nWithout a command, the interactive browser starts.\n\n", os.Args[0])
+		fmt.Fprint(out, cli.Usage)
+		fmt.Fprintln(out, "\nFlags:")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() > 0 && !cli.IsCommand(flag.Arg(0)) {
+		fmt.Fprintf(os.Stderr, "unknown command %q (available: ls, cp, sync, syncd, sas, secret, iac, completion)\n", flag.Arg(0))
+		os.Exit(2)
+	}
+
+	start := app.StartLocation{Subscription: *subscription, Account: *account, Container: *container, Prefix: *prefix}
+	switch {
+	case start.Container != "" && start.Account == "":
+		fmt.Fprintln(os.Stderr, "-container needs -account")
+		os.Exit(2)
+	case start.Prefix != "" && start.Container == "":
+		fmt.Fprintln(os.Stderr, "-prefix needs -container")
+		os.Exit(2)
+	case start.Subscription != "" && start.Account == "":
+		fmt.Fprintln(os.Stderr, "-subscription needs -account")
+		os.Exit(2)
+	case *recordPath != "" && *replayPath != "":
+		fmt.Fprintln(os.Stderr, "-record and -replay cannot be used together")
+		os.Exit(2)
+	case *demo && (start != app.StartLocation{} || *replayPath != ""):
+		fmt.Fprintln(os.Stderr, "-demo starts at the top of the built-in mock data; it cannot be used with -account or -replay")
+		os.Exit(2)
+	}
+
+	path := *configPath
+	if path == "" {
+		var err error
+		if path, err = config.DefaultPath(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	_, statErr := os.Stat(path)
+	firstRun := errors.Is(statErr, os.ErrNotExist)
+	cfg, err := config.Load(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// Flags given on the command line override the config file.
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "provider":
+			cfg.Provider = *provider
+		case "mock-fixture":
+			cfg.MockFixture = *mockFixture
+		case "mock-scale":
+			cfg.MockScale = *mockScale
+		case "profile":
+			cfg.Profile = *profile
+		case "theme":
+			cfg.Theme = *theme
+		case "no-mouse":
+			mouse := !*noMouse
+			cfg.Mouse = &mouse
+		case "watch-interval":
+			cfg.WatchInterval = *watchInterval
+		case "watch-events":
+			cfg.WatchEvents = *watchEvents
+		case "exact-sizes":
+			cfg.ExactSizes = *exactSizes
+		case "time":
+			cfg.Time = *timeDisplay
+		case "icons":
+			cfg.Icons = *icons
+		case "layout":
+			cfg.Layout = *layout
+		case "restore":
+			cfg.Restore = *restore
+		case "preview-memory":
+			cfg.PreviewMemory = *previewMemory
+		case "cache":
+			cfg.Cache.Enabled = *diskCache
+		case "log-level":
+			cfg.LogLevel = *logLevel
+		}
+	})
+	switch {
+	case *demo && cfg.Provider != "" && cfg.Provider != "mock":
+		fmt.Fprintln(os.Stderr, "-demo needs the built-in mock data; drop -provider (or provider in the config file)")
+		os.Exit(2)
+	case *demo && (cfg.MockFixture != "" || cfg.MockScale != 0):
+		fmt.Fprintln(os.Stderr, "-demo needs the built-in mock data; drop -mock-fixture and -mock-scale (or mockFixture/mockScale in the config file)")
+		os.Exit(2)
+	}
+
+	level, err := logging.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	// A log file that cannot be opened leaves the records to the log viewer.
+	log := logging.New(nil, level)
+	logPath, err := logging.DefaultPath()
+	if err == nil {
+		var logFile *logging.RotatingFile
+		if logFile, err = logging.OpenFile(logPath); err == nil {
+			defer logFile.Close()
+			log = logging.New(logFile, level)
+		}
+	}
+	if err != nil {
+		log.Warn("log file unavailable", "error", err)
+	}
+	// Without the audit log, uploads and signed URLs are refused.
+	var auditLog *audit.Log
+	auditPath, err := audit.DefaultPath()
+	if err == nil {
+		if auditLog, err = audit.Open(auditPath); err == nil {
+			defer auditLog.Close()
+		}
+	}
+	if err != nil {
+		log.Warn("audit log unavailable", "error", err)
+	}
+
+	var registry *metrics.Registry
+	if *pprofAddr != "" {
+		registry = metrics.New()
+		if err := serveDebug(*pprofAddr, *pprofPublic, registry); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		log.Info("serving pprof and metrics", "address", *pprofAddr)
+	}
+
+	subscriptionPatterns, err := patterns.Compile(cfg.Tree.Subscriptions.Include, cfg.Tree.Subscriptions.Exclude)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tree.subscriptions in %s: %v\n", path, err)
+		os.Exit(1)
+	}
+	accountPatterns, err := patterns.Compile(cfg.Tree.Accounts.Include, cfg.Tree.Accounts.Exclude)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tree.accounts in %s: %v\n", path, err)
+		os.Exit(1)
+	}
+
+	var jobs []syncjobs.Job
+	for _, job := range cfg.SyncJobs {
+		jobs = append(jobs, syncjobs.Job(job))
+	}
+	if err := syncjobs.Validate(jobs); err != nil {
+		fmt.Fprintf(os.Stderr, "syncJobs in %s: %v\n", path, err)
+		os.Exit(1)
+	}
+
+	var profiles []app.Profile
+	for name, p := range cfg.Profiles {
+		profiles = append(profiles, app.Profile{
+			Name:          name,
+			Tenant:        p.Tenant,
+			Cloud:         p.Cloud,
+			Credentials:   p.Credentials,
+			Subscriptions: p.Subscriptions,
+			Network: azure.Network{
+				Proxy:              p.Proxy,
+				CABundle:           p.CABundle,
+				InsecureSkipVerify: p.InsecureSkipVerify,
+			},
+		})
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	// Remote profiles reach the provider through a daemon; the daemon itself
+	// talks to the provider.
+	remotes := make(map[string]config.Remote)
+	for name, p := range cfg.Profiles {
+		if p.Remote != nil {
+			remotes[name] = *p.Remote
+		}
+	}
+	var names []string
+	for _, p := range profiles {
+		names = append(names, p.Name)
+	}
+	var current app.Profile
+	if cfg.Profile != "" {
+		if _, ok := cfg.Profiles[cfg.Profile]; !ok {
+			fmt.Fprintf(os.Stderr, "unknown profile %q in %s\n", cfg.Profile, path)
+			os.Exit(1)
+		}
+		for _, p := range profiles {
+			if p.Name == cfg.Profile {
+				current = p
+			}
+		}
+	}
+
+	dial := func(profile app.Profile) (azure.Provider, error) {
+		if settings, ok := remotes[profile.Name]; ok && *serveAddr == "" {
+			log.Info("connecting to daemon", "profile", profile.Name, "address", settings.Address)
+			return dialRemote(settings)
+		}
+		// The mock provider makes no requests, but the network settings are
+		// still checked, so a bad proxy or CA bundle shows up on any provider.
+		if _, err := profile.Network.Client(); err != nil {
+			return nil, err
+		}
+		if profile.Network.InsecureSkipVerify {
+			log.Warn("TLS certificate verification disabled", "profile", profile.Name)
+		}
+		switch cfg.Provider {
+		case "", "mock":
+			// The mock provider needs no sign-in, so every profile sees the
+			// same data, narrowed by its subscription filter.
+			if cfg.MockFixture != "" && cfg.MockScale != 0 {
+				return nil, errors.New("mockFixture and mockScale cannot both be set")
+			}
+			mock := azure.NewMockProvider()
+			if cfg.MockFixture != "" {
+				fixture, err := azure.LoadFixture(cfg.MockFixture)
+				if err != nil {
+					return nil, err
+				}
+				mock = fixture
+			}
+			if cfg.MockScale != 0 {
+				start := time.Now()
+				scaled, err := azure.NewScaleProvider(cfg.MockScale)
+				if err != nil {
+					return nil, err
+				}
+				mock = scaled
+				accounts, containers, blobs := mock.Size()
+				log.Info("mock provider generated data", "accounts", accounts, "containers", containers, "blobs", blobs, "took", time.Since(start))
+			}
+			if faults := cfg.MockFaults; faults != nil {
+				err := mock.InjectFaults(azure.Faults{
+					Latency:      faults.Latency,
+					Jitter:       faults.Jitter,
+					ErrorRate:    faults.ErrorRate,
+					ThrottleRate: faults.ThrottleRate,
+					RetryAfter:   faults.RetryAfter,
+					HugeListing:  faults.HugeListing,
+					Seed:         faults.Seed,
+				})
+				if err != nil {
+					return nil, err
+				}
+				log.Warn("mock provider injecting faults", "profile", profile.Name)
+			}
+			return mock, nil
+		}
+		return nil, fmt.Errorf("unknown provider %q (available: mock)", cfg.Provider)
+	}
+	// A recording is played back whatever the profile, and a recording
+	// being made takes the answers of each profile switched to.
+	var recorded *recording.Recording
+	if *recordPath != "" {
+		if recorded, err = recording.Create(*recordPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer recorded.Close()
+		log.Info("recording provider answers", "file", *recordPath)
+	}
+	connect := func(profile app.Profile) (azure.Provider, error) {
+		if *replayPath != "" {
+			return recording.Replay(*replayPath)
+		}
+		provider, err := dial(profile)
+		if err != nil || recorded == nil {
+			return provider, err
+		}
+		return recorded.Record(provider), nil
+	}
+	data, err := connect(current)
+	if err != nil {
+		log.Error("connect failed", "provider", cfg.Provider, "profile", current.Name, "error", err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *serveAddr != "" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		err := serveRemote(ctx, *serveAddr, *serveCert, *serveKey, *serveToken, data, log)
+		stop()
+		if err != nil {
+			log.Error("daemon stopped", "error", err)
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Commands run against the same provider and profile as the browser.
+	if flag.NArg() > 0 {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		err := cli.Run(ctx, cli.Env{
+			Provider:             data,
+			Subscriptions:        current.Subscriptions,
+			SubscriptionPatterns: subscriptionPatterns,
+			AccountPatterns:      accountPatterns,
+			Profiles:             names,
+			Flags:                flag.CommandLine,
+			AzCopy:               cfg.AzCopy,
+			SyncJobs:             jobs,
+			Audit:                auditLog,
+			Stdin:                os.Stdin,
+			Stdout:               os.Stdout,
+			Stderr:               os.Stderr,
+		}, flag.Args())
+		stop()
+		if err != nil && !errors.Is(err, flag.ErrHelp) {
+			log.Error("command failed", "args", flag.Args(), "error", err)
+		}
+		switch {
+		case errors.Is(err, flag.ErrHelp):
+			return
+		case errors.Is(err, cli.ErrUsage):
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		case err != nil:
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Without a config file, the browser starts with the setup wizard, which
+	// writes one. The file holds only its choices, not the flags of this run.
+	// A demo skips it.
+	var setup func(app.SetupChoices) error
+	if firstRun && !*demo {
+		setup = func(choices app.SetupChoices) error {
+			profile := choices.Profile
+			saved := config.Config{
+				Provider: choices.Provider,
+				Theme:    choices.Theme,
+				Profile:  profile.Name,
+				Profiles: map[string]config.Profile{profile.Name: {
+					Tenant:        profile.Tenant,
+					Cloud:         profile.Cloud,
+					Credentials:   profile.Credentials,
+					Subscriptions: profile.Subscriptions,
+				}},
+			}
+			if err := config.Save(path, saved); err != nil {
+				return err
+			}
+			log.Info("saved setup", "config", path)
+			cfg.Provider = choices.Provider
+			return nil
+		}
+	}
+
+	// Usage metrics count the kind of provider, never what it serves.
+	providerKind := func(profile app.Profile) string {
+		_, remote := remotes[profile.Name]
+		switch {
+		case *replayPath != "":
+			return "replay"
+		case remote:
+			return "remote"
+		case cfg.MockScale != 0:
+			return "mock-scale"
+		case cfg.MockFixture != "":
+			return "mock-fixture"
+		case cfg.Provider == "":
+			return "mock"
+		}
+		return cfg.Provider
+	}
+	usageEndpoint := cfg.UsageEndpoint
+	if usageEndpoint == "" {
+		usageEndpoint = usage.DefaultEndpoint
+	}
+
+	log.Info("starting", "provider", cfg.Provider, "profile", current.Name, "config", path)
+	ui := app.New(data, app.Options{
+		Theme:          cfg.Theme,
+		DisableMouse:   cfg.Mouse != nil && !*cfg.Mouse,
+		WatchInterval:  cfg.WatchInterval,
+		WatchEvents:    cfg.WatchEvents,
+		ExactSizes:     cfg.ExactSizes,
+		TimeDisplay:    cfg.Time,
+		Icons:          cfg.Icons,
+		RestoreSession: cfg.Restore,
+		PreviewMemory:  cfg.PreviewMemory,
+		DiskCache:      cfg.Cache.Enabled,
+		DiskCacheDir:   cfg.Cache.Dir,
+		DiskCacheSize:  cfg.Cache.MaxSize,
+		Layout:         cfg.Layout,
+		Ratios: app.LayoutRatios{
+			Tree:     cfg.Ratios.Tree,
+			Contents: cfg.Ratios.Contents,
+			Preview:  cfg.Ratios.Preview,
+		},
+		Keys:          cfg.Keys,
+		Start:         start,
+		Profiles:      profiles,
+		Profile:       current.Name,
+		Connect:       connect,
+		Setup:         setup,
+		Providers:     []string{"mock"},
+		Subscriptions: subscriptionPatterns,
+		Accounts:      accountPatterns,
+		Log:           log,
+		Audit:         auditLog,
+		Metrics:       registry,
+		SyncJobs:      jobs,
+		Demo:          *demo,
+		DemoPace:      *demoPace,
+		UsageEndpoint: usageEndpoint,
+		ProviderKind:  providerKind,
+	})
+	if err := ui.Run(); err != nil {
+		log.Error("exited", "error", err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	log.Info("exited")
+}
+
+// serveDebug serves the pprof handlers and the metrics page on addr in the
+// background, on a mux of their own rather than the default one. Profiles
+// and dumps can hold credentials, so addr must be a loopback address unless
+// public is set.
+func serveDebug(addr string, public bool, registry *metrics.Registry) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("pprof: %w", err)
+	}
+	if tcp, ok := listener.Addr().(*net.TCPAddr); !public && (!ok || !tcp.IP.IsLoopback()) {
+		listener.Close()
+		return errors.New("pprof: only a loopback address is allowed, such as localhost:6060, unless -pprof-public is given")
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/metrics", registry.Handler())
+	go http.Serve(listener, mux)
+	return nil
+}
+
+// tokenEnv holds the daemon token when no keychain secret is named.
+const tokenEnv = "STORAGE_TUI_TOKEN"
+
+// daemonToken reads the daemon token from the keychain secret, or from
+// tokenEnv when secret is empty.
+func daemonToken(secret string) (string, error) {
+	if secret != "" {
+		return keychain.Get(context.Background(), secret)
+	}
+	if token := os.Getenv(tokenEnv); token != "" {
+		return token, nil
+	}
+	return "", fmt.Errorf("no daemon token: name a keychain secret or set %s", tokenEnv)
+}
+
+// dialRemote connects to the daemon of a remote profile.
+func dialRemote(settings config.Remote) (azure.Provider, error) {
+	token, err := daemonToken(settings.TokenSecret)
+	if err != nil {
+		return nil, err
+	}
+	return remote.Dial(context.Background(), remote.Options{
+		Address:   settings.Address,
+		Token:     token,
+		CABundle:  settings.CABundle,
+		Plaintext: settings.Plaintext,
+	})
+}
+
+// serveRemote answers remote profiles with provider on addr until ctx is
+// done.
+func serveRemote(ctx context.Context, addr, certFile, keyFile, tokenSecret string, provider azure.Provider, log *logging.Log) error {
+	token, err := daemonToken(tokenSecret)
+	if err != nil {
+		return err
+	}
+	var tlsConfig *tls.Config
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("serve: %w", err)
+		}
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	}
+	listener, err := remote.Listen(addr, tlsConfig)
+	if err != nil {
+		return err
+	}
+	log.Info("serving remote profiles", "address", listener.Addr().String(), "tls", tlsConfig != nil)
+	fmt.Fprintf(os.Stderr, "Serving remote profiles on %s; interrupt to stop.\n", listener.Addr())
+	return remote.Serve(ctx, listener, provider, remote.ServeOptions{Token: token, TLS: tlsConfig, Log: log.Logger})
+}
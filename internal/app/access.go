@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"storage-tui/internal/azure"
+)
+
+// dataRole is a built-in role that grants access to blob data, and what it
+// lets its principals do.
+type dataRole struct {
+	name   string
+	grants string
+}
+
+// dataRoles are the blob data roles, most powerful first.
+var dataRoles = []dataRole{
+	{"Storage Blob Data Owner", "read, write, and set access control"},
+	{"Storage Blob Data Contributor", "read, write, and delete"},
+	{"Storage Blob Data Reader", "read"},
+}
+
+// keyRoles are management roles whose principals can list the account keys
+// and so reach the data with Shared Key.
+var keyRoles = []string{"Owner", "Contributor", "Storage Account Contributor"}
+
+// openAccessModal shows who holds data roles on the selected container, or
+// the container the selection is in, or else on the selected account, to
+// answer who can read it without the portal.
+func (a *App) openAccessModal() {
+	ref, ok := a.selectedRef()
+	if !ok || ref.Account == "" {
+		ref = a.contentSource
+	}
+	if ref.Account == "" {
+		a.flashErr("Select an account or container to show who has access.")
+		return
+	}
+	account, container := ref.Account, ref.Container
+	name := account
+	if container != "" {
+		name += "/" + container
+	}
+	a.showPropertiesDialog("Access", name, func(ctx context.Context) ([]propertySection, error) {
+		assignments, err := a.provider.ListRoleAssignments(ctx, account, container)
+		return accessSections(assignments), err
+	})
+}
+
+// accessSections lists the principals of each data role, then those of the
+// other roles, which reach the data only through the account keys if at all.
+func accessSections(assignments []azure.RoleAssignment) []propertySection {
+	var sections []propertySection
+	for _, role := range dataRoles {
+		section := propertySection{Title: fmt.Sprintf("%s (%s)", role.name, role.grants)}
+		for _, assignment := range assignments {
+			if strings.EqualFold(assignment.Role, role.name) {
+				section.Items = append(section.Items, assignmentItems(assignment, assignedOn(assignment))...)
+			}
+		}
+		sections = append(sections, section)
+	}
+
+	others := propertySection{Title: "Other roles"}
+	for _, assignment := range assignments {
+		if slices.ContainsFunc(dataRoles, func(role dataRole) bool { return strings.EqualFold(assignment.Role, role.name) }) {
+			continue
+		}
+		value := fmt.Sprintf("%s, %s", assignment.Role, assignedOn(assignment))
+		if slices.ContainsFunc(keyRoles, func(role string) bool { return strings.EqualFold(assignment.Role, role) }) {
+			value += "; can list the account keys"
+		}
+		others.Items = append(others.Items, assignmentItems(assignment, value)...)
+	}
+	return append(sections, others)
+}
+
+// assignmentItems lists the principal of an assignment with value, and the
+// condition narrowing it, if any, on a line of its own as conditions run
+// long.
+func assignmentItems(assignment azure.RoleAssignment, value string) []property {
+	items := []property{{assignment.Principal, value}}
+	if assignment.Condition != "" {
+		items = append(items, property{"", "if " + assignment.Condition})
+	}
+	return items
+}
+
+// assignedOn describes whom an assignment is for and where it is made, such
+// as "Group, on resource group storage".
+func assignedOn(assignment azure.RoleAssignment) string {
+	return fmt.Sprintf("%s, on %s", orNA(assignment.PrincipalType), scopeLevel(assignment.Scope))
+}
+
+// scopeLevel shortens a scope resource ID to the level it names, such as
+// "subscription sub-prod" or "container backups".
+func scopeLevel(scope string) string {
+	parts := strings.Split(strings.Trim(scope, "/"), "/")
+	if len(parts) < 2 || len(parts)%2 != 0 {
+		return scope
+	}
+	kind, name := parts[len(parts)-2], parts[len(parts)-1]
+	switch strings.ToLower(kind) {
+	case "subscriptions":
+		return "subscription " + name
+	case "resourcegroups":
+		return "resource group " + name
+	case "storageaccounts":
+		return "account " + name
+	case "containers":
+		return "container " + name
+	}
+	return scope
+}
@@ -2,14 +2,32 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
+	"storage-tui/internal/archive"
+	"storage-tui/internal/audit"
 	"storage-tui/internal/azure"
+	"storage-tui/internal/bookmarks"
+	"storage-tui/internal/cache"
+	"storage-tui/internal/dirsync"
+	"storage-tui/internal/logging"
+	"storage-tui/internal/metrics"
+	"storage-tui/internal/patterns"
+	"storage-tui/internal/prefs"
+	"storage-tui/internal/searches"
+	"storage-tui/internal/syncjobs"
+	"storage-tui/internal/usage"
 )
 
 type itemKind int
@@ -21,13 +39,26 @@ const (
 	kindAccount
 	kindContainer
 	kindBlob
+	kindLoadMore
+	kindFolder
+	// kindEntry is a file inside an archive blob.
+	kindEntry
 )
 
+// streamPageSize is how many blobs each of the first pages of a listing
+// holds, so the first rows show up quickly even in huge containers.
+const streamPageSize = 100
+
+// loadWorkers is how many subscriptions have their accounts listed at once
+// when the tree loads.
+const loadWorkers = 8
+
 type pane int
 
 const (
 	paneAccounts pane = iota
 	paneContents
+	paneCompare
 	panePreview
 )
 
@@ -43,44 +74,414 @@ type itemRef struct {
 	SizeBytes        int64
 	Modified         time.Time
 	ContentType      string
+	ETag             string
+	// Tags lists the index tags a tag search matched, as key=value pairs.
+	Tags string
+	// Prefix is the full path of a virtual folder, ending in "/".
+	Prefix string
+	// Archive is the archive blob a file or folder is inside of.
+	Archive string
+}
+
+// Options configures optional App behavior.
+type Options struct {
+	// Theme names one of the built-in color themes. Empty selects the default.
+	Theme string
+	// DisableMouse turns off click-to-focus, click selection, and wheel
+	// scrolling.
+	DisableMouse bool
+	// WatchInterval is how often watch mode re-lists the container. Zero
+	// selects the default.
+	WatchInterval time.Duration
+	// WatchEvents has watch mode follow the blobs created and deleted as the
+	// provider pushes them, instead of re-listing, where it can.
+	WatchEvents bool
+	// ExactSizes shows byte counts with thousands separators instead of
+	// rounded KB/MB/GB values.
+	ExactSizes bool
+	// Icons prefixes items with type icons: "nerd" for Nerd Font glyphs
+	// (falling back to ASCII on terminals that cannot show them), "ascii",
+	// or "off" (the default).
+	Icons string
+	// TimeDisplay renders timestamps as "utc" (the default), "local", or
+	// "relative" ("3 hours ago").
+	TimeDisplay string
+	// RestoreSession restores the expanded tree nodes, the last selected
+	// location, and how it was listed, as saved for the profile when the
+	// previous session quit, without asking first as it otherwise does.
+	RestoreSession bool
+	// PreviewMemory caps the previewed blob content kept in memory, such as
+	// "32 MB". Empty selects 16 MB.
+	PreviewMemory string
+	// DiskCache keeps listings, blob properties, and previews on disk in
+	// DiskCacheDir, or storage-tui in the user cache directory, so containers
+	// seen before show at once and can be browsed offline. DiskCacheSize caps
+	// it, such as "512 MB"; empty selects 256 MB.
+	DiskCache     bool
+	DiskCacheDir  string
+	DiskCacheSize string
+	// Layout is "stacked" (the default), with the preview below the contents
+	// table, or "columns", with the preview to its right.
+	Layout string
+	// Ratios sizes the panes relative to each other. Zero values keep the
+	// sizes of the layout.
+	Ratios LayoutRatios
+	// Start is where the tree and contents open once the subscriptions are
+	// loaded, instead of the top of the tree.
+	Start StartLocation
+	// Keys moves bindings to other keys, from the label shown in the help to
+	// a key such as "ctrl-f" or "f5".
+	Keys map[string]string
+	// Profiles are the environments the profiles dialog switches between.
+	Profiles []Profile
+	// Profile names the profile provider is signed in with.
+	Profile string
+	// Connect signs in with a profile when the profiles dialog switches to
+	// it. Nil keeps the provider and only applies the subscription filter.
+	Connect func(Profile) (azure.Provider, error)
+	// Setup opens the first-run setup wizard once the tree has loaded and
+	// saves its choices. Nil skips the wizard.
+	Setup func(SetupChoices) error
+	// Providers lists the providers the setup wizard offers, the current one
+	// first.
+	Providers []string
+	// Subscriptions and Accounts hide the subscriptions, by ID or name, and
+	// the accounts, by name, that their patterns leave out of the tree and
+	// searches.
+	Subscriptions patterns.Set
+	Accounts      patterns.Set
+	// Log receives provider calls, status messages, and errors, and feeds
+	// the log viewer. Nil keeps the records only for the viewer.
+	Log *logging.Log
+	// Audit records setting changes and is what the audit page shows, along
+	// with what the cp and sas commands record. Nil refuses the changes.
+	Audit *audit.Log
+	// Metrics counts provider calls, cache hits, and draws for the -pprof
+	// metrics page. Nil counts nothing.
+	Metrics *metrics.Registry
+	// SyncJobs are synced on their schedules while the browser runs, and
+	// listed with their runs on the jobs page.
+	SyncJobs []syncjobs.Job
+	// Demo walks through browsing the built-in mock data, as a script presses
+	// the keys, then quits; Run fails if an operation failed on the way. The
+	// session is neither restored nor saved. DemoPace is how long each step
+	// shows; zero selects 2 seconds.
+	Demo     bool
+	DemoPace time.Duration
+	// UsageEndpoint is where usage metrics, once the user turns them on, are
+	// reported. Empty keeps the counts on the machine.
+	UsageEndpoint string
+	// ProviderKind names the kind of provider a profile browses, such as mock
+	// or remote, for usage metrics. Nil leaves providers uncounted.
+	ProviderKind func(Profile) string
 }
 
 type App struct {
-	provider            azure.Provider
+	provider          azure.Provider
+	switcher          *switchableProvider
+	profiles          []Profile
+	profile           Profile
+	connect           func(Profile) (azure.Provider, error)
+	log               *logging.Log
+	metrics           *metrics.Registry
+	audit             *audit.Log
+	drawStart         time.Time
+	lastDraw          time.Time
+	drawPending       bool
+	shownSubs         patterns.Set
+	shownAccts        patterns.Set
+	logView           *tview.TextView
+	logLevel          slog.Level
+	logOpen           bool
+	statsView         *tview.TextView
+	statsOpen         bool
+	statsSource       itemRef
+	stats             containerStats
+	statsSeq          int
+	statsCancel       context.CancelFunc
+	lifecycleView     *tview.Table
+	lifecycleOpen     bool
+	lifecycleSeq      int
+	lifecycleAccount  string
+	lifecycleRules    []azure.LifecycleRule
+	lifecycleLoaded   bool
+	lifecycleForm     *tview.Form
+	lifecycleFormOpen bool
+	changeFeedView    *tview.Table
+	changeFeedOpen    bool
+	changeFeedSource  itemRef
+	changeFeedWindow  int
+	changeFeedRows    []azure.ChangeFeedEvent
+	changeFeedSeq     int
+	auditView         *tview.Table
+	auditOpen         bool
+	auditSeq          int
+	auditEntries      []audit.Entry
+	// undo is what the latest change that can be undone wrote over or
+	// deleted, until u restores it.
+	undo                *undoable
+	failures            []failure
+	failuresUnseen      int
+	failuresView        *tview.Flex
+	failuresTable       *tview.Table
+	failuresDetail      *tview.TextView
+	failuresOpen        bool
+	callsView           *tview.Flex
+	callsTable          *tview.Table
+	callsDetail         *tview.TextView
+	callsOpen           bool
+	callsFailedOnly     bool
+	callsShown          []providerCall
+	syncJobs            *syncjobs.Scheduler
+	syncJobsCancel      context.CancelFunc
+	pendingSyncJobs     []syncjobs.Job
+	syncJobsView        *tview.Flex
+	syncJobsTable       *tview.Table
+	syncRunsTable       *tview.Table
+	syncJobsOpen        bool
+	syncPlanView        *tview.Flex
+	syncPlanText        *tview.TextView
+	syncPlanInput       *tview.InputField
+	syncPlanOpen        bool
+	syncPlan            dirsync.Plan
+	corsView            *tview.Table
+	corsOpen            bool
+	corsAccount         string
+	corsRules           []azure.CORSRule
+	corsLoaded          bool
+	corsSeq             int
+	corsForm            *tview.Form
+	corsFormOpen        bool
+	corsEditing         int
+	wormView            *tview.TextView
+	wormOpen            bool
+	wormAccount         string
+	wormContainer       string
+	wormState           azure.ContainerImmutability
+	wormLoaded          bool
+	wormSeq             int
+	wormForm            *tview.Form
+	wormFormOpen        bool
+	saveSetup           func(SetupChoices) error
+	setupProviders      []string
+	setupForm           *tview.Form
+	setupChoices        SetupChoices
+	setupStartTheme     theme
+	setupPending        bool
+	setupOpen           bool
+	profilesList        *tview.List
+	profilesOpen        bool
 	app                 *tview.Application
 	pages               *tview.Pages
 	accounts            *tview.TreeView
 	contents            *tview.Table
+	compare             *tview.Table
+	contentsRow         *tview.Flex
+	mainColumn          *tview.Flex
+	body                *tview.Flex
+	layout              layoutMode
+	ratios              LayoutRatios
 	preview             *tview.TextView
 	details             *tview.TextView
 	searchForm          *tview.Form
 	searchInput         *tview.InputField
+	searchCase          *tview.Checkbox
+	searchWord          *tview.Checkbox
 	searchOpen          bool
+	bookmarks           *bookmarks.Store
+	bookmarksErr        error
+	sessionPath         string
+	sessionErr          error
+	sessionOffer        *savedSession
+	demoStop            chan struct{}
+	usage               *usage.Counter
+	usagePath           string
+	usageEndpoint       string
+	providerKind        func(Profile) string
+	usageView           *tview.TextView
+	usageOpen           bool
+	demoCaption         string
+	demoErr             error
+	prefs               prefs.Prefs
+	prefsPath           string
+	prefsErr            error
+	bookmarksList       *tview.List
+	bookmarksOpen       bool
+	keys                []binding
+	helpView            *tview.TextView
+	helpOpen            bool
+	confirmDialog       *tview.Flex
+	propertiesView      *tview.TextView
+	propertiesOpen      bool
+	propertiesSeq       int
+	propertySections    []propertySection
+	marks               map[rune]mark
+	findInput           *tview.InputField
+	findResults         *tview.Table
+	findView            *tview.Flex
+	findOpen            bool
+	findRefs            []itemRef
+	findJob             *job
+	findSeq             int
+	findProgress        findProgress
+	findRun             *searchRun
+	tagsForm            *tview.Form
+	tagsInput           *tview.InputField
+	tagsOpen            bool
+	tagsAccount         itemRef
+	tagsExpression      string
+	filterForm          *tview.Form
+	filterInputs        []*tview.InputField
+	filterOpen          bool
+	contentsFilter      *blobFilter
+	listPrefix          string
+	prefixForm          *tview.Form
+	prefixInput         *tview.InputField
+	prefixOpen          bool
+	pipeForm            *tview.Form
+	pipeInput           *tview.InputField
+	pipeOpen            bool
+	pipeRef             itemRef
+	pipeCommand         string
+	exportForm          *tview.Form
+	exportFormat        *tview.DropDown
+	exportInput         *tview.InputField
+	exportOpen          bool
+	saveForm            *tview.Form
+	saveInput           *tview.InputField
+	saveOpen            bool
+	saveRef             itemRef
+	iacForm             *tview.Form
+	iacFormat           *tview.DropDown
+	iacInput            *tview.InputField
+	iacOpen             bool
+	iacRef              itemRef
+	filterBefore        *blobFilter
+	filterSeq           int
+	filterStats         map[string]folderStat
+	grepInput           *tview.InputField
+	grepResults         *tview.Table
+	grepView            *tview.Flex
+	grepOpen            bool
+	grepScope           itemRef
+	grepFilter          *blobFilter
+	grepMatches         []grepMatch
+	grepJob             *job
+	grepSeq             int
+	grepProgress        grepProgress
+	grepRun             *searchRun
+	searches            *searches.Store
+	searchesErr         error
+	searchesList        *tview.List
+	searchesOpen        bool
+	saveSearchForm      *tview.Form
+	saveSearchInput     *tview.InputField
+	saveSearchOpen      bool
+	saveSearchReturn    tview.Primitive
+	pendingSearch       searches.Search
+	history             []*searchRun
+	historyRuns         *tview.Table
+	historyResults      *tview.Table
+	historyView         *tview.Flex
+	historyOpen         bool
+	markPending         rune
+	detailItems         []property
+	screen              tcell.Screen
+	confirmOpen         bool
+	typeAheadOn         bool
+	typeAheadBuffer     string
+	typeAheadAt         time.Time
+	statusHints         *tview.TextView
+	statusInfo          *tview.TextView
+	flashText           string
+	flashError          bool
+	flashSeq            int
+	theme               theme
+	paneTitles          map[pane]string
+	loading             map[pane]int
+	spinnerFrame        int
+	spinnerStop         chan struct{}
+	pendingNodes        map[*tview.TreeNode][]func(error)
+	failedNodes         map[*tview.TreeNode]bool
+	contentsSeq         int
+	contentsTarget      itemRef
+	contentsLoading     bool
+	contentsWaiters     []func(error)
+	contentsMarker      string
+	contentsPaging      bool
+	enumCancel          context.CancelFunc
+	contentsCtx         context.Context
+	contentsCancel      context.CancelFunc
+	prefetch            *prefetchedPage
+	watchInterval       time.Duration
+	exactSizes          bool
+	timeMode            timeMode
+	icons               iconStyle
+	watchStop           chan struct{}
+	watchBusy           bool
+	watchMarks          map[string]watchChange
+	watchEvents         bool
+	watchPush           bool
+	watchPushTarget     itemRef
+	watchPushCancel     context.CancelFunc
 	root                *tview.TreeNode
 	rootRef             itemRef
 	contentRefs         []itemRef
+	listedBlobs         []itemRef
+	blobNames           nameIndex
+	folderView          bool
+	folderPrefix        string
+	archive             *archiveView
+	folderStats         map[string]folderStat
+	folderStatsSource   itemRef
+	folderStatsLoading  bool
+	folderStatsSeq      int
+	folderStatsCancel   context.CancelFunc
+	extraColumns        bool
+	propsCache          map[string]azure.BlobProperties
+	containerProps      map[string]azure.ContainerProperties
+	accountInfo         map[string]azure.Account
+	accountMetrics      map[string]azure.AccountMetrics
+	serviceProps        map[string]azure.BlobServiceProperties
+	columnPending       map[string]bool
+	columnSlots         chan struct{}
+	contentSource       itemRef
+	compareRefs         []itemRef
+	compareSource       itemRef
+	splitOpen           bool
 	activePane          pane
 	loadingTree         bool
 	loadingContents     bool
+	loadingCompare      bool
 	lastDetails         string
+	detailsSeq          int
+	detailsRef          itemRef
+	detailsCancels      []context.CancelFunc
+	detailsPending      map[string]bool
+	detailsErrs         map[string]error
 	lastPreview         string
 	previewFull         string
 	previewSearch       string
 	previewSearchable   bool
+	previewSeq          int
+	previewCancel       context.CancelFunc
+	previewCache        *previewCache
+	disk                *cache.Cache
+	contentsCachedAt    time.Time
+	throttledUntil      time.Time
 	subscriptionEnabled map[string]bool
 }
 
-func New(provider azure.Provider) *App {
+func New(provider azure.Provider, opts Options) *App {
 	application := tview.NewApplication()
 	pages := tview.NewPages()
 	accounts := tview.NewTreeView()
 	contents := tview.NewTable()
+	compare := tview.NewTable()
 	preview := tview.NewTextView()
 	details := tview.NewTextView()
 
-	header := tview.NewTextView().
-		SetTextAlign(tview.AlignCenter).
-		SetText("Azure Storage Explorer TUI  q: quit | r: refresh | tab: switch pane | enter/right: expand or collapse | left: parent or collapse | space: toggle subscription | /: search | esc: clear search")
+	statusBar, statusHints, statusInfo := newStatusBar()
 
 	rootRef := itemRef{Kind: kindRoot, Name: "Subscriptions"}
 	root := tview.NewTreeNode(rootRef.Name).
@@ -89,29 +490,128 @@ func New(provider azure.Provider) *App {
 		SetExpanded(true)
 
 	accounts.SetRoot(root).SetCurrentNode(root)
-	accounts.SetBorder(true).SetTitle("Subscriptions")
-	contents.SetBorder(true).SetTitle("Contents")
+	accounts.SetBorder(true)
+	contents.SetBorder(true)
 	contents.SetSelectable(true, false)
-	preview.SetBorder(true).SetTitle("Preview")
+	compare.SetBorder(true)
+	compare.SetSelectable(true, false)
+	preview.SetBorder(true)
+	preview.SetDynamicColors(true)
 	preview.SetWrap(true)
 	preview.SetWordWrap(true)
 	preview.SetScrollable(true)
 	details.SetBorder(true).SetTitle("Details")
 
+	icons, iconsOK := iconStyleByName(opts.Icons)
+	arrangement, layoutOK := layoutModeByName(opts.Layout)
+	keys := newKeymap()
+	keysErr := rebindKeys(keys, opts.Keys)
+	if keysErr != nil {
+		keys = newKeymap()
+	}
+	log := opts.Log
+	if log == nil {
+		log = logging.New(nil, slog.LevelInfo)
+	}
+	switcher := &switchableProvider{current: provider, log: log, metrics: opts.Metrics, calls: &callLog{}, arm: newLimiter(armInterval), blob: newLimiter(blobInterval)}
 	a := &App{
-		provider:            provider,
+		provider:            switcher,
+		switcher:            switcher,
+		profiles:            opts.Profiles,
+		connect:             opts.Connect,
+		log:                 log,
+		metrics:             opts.Metrics,
+		audit:               opts.Audit,
+		shownSubs:           opts.Subscriptions,
+		shownAccts:          opts.Accounts,
+		saveSetup:           opts.Setup,
+		setupProviders:      opts.Providers,
+		setupPending:        opts.Setup != nil,
+		logLevel:            slog.LevelDebug,
 		app:                 application,
 		pages:               pages,
 		accounts:            accounts,
 		contents:            contents,
+		compare:             compare,
 		preview:             preview,
 		details:             details,
+		statusHints:         statusHints,
+		statusInfo:          statusInfo,
 		root:                root,
 		rootRef:             rootRef,
 		activePane:          paneAccounts,
 		contentRefs:         nil,
 		subscriptionEnabled: make(map[string]bool),
+		paneTitles:          make(map[pane]string),
+		loading:             make(map[pane]int),
+		pendingNodes:        make(map[*tview.TreeNode][]func(error)),
+		failedNodes:         make(map[*tview.TreeNode]bool),
+		marks:               make(map[rune]mark),
+		watchInterval:       opts.WatchInterval,
+		watchEvents:         opts.WatchEvents,
+		exactSizes:          opts.ExactSizes,
+		icons:               icons,
+		layout:              arrangement,
+		keys:                keys,
+		ratios:              opts.Ratios,
+		pendingSyncJobs:     opts.SyncJobs,
+	}
+	if a.watchInterval <= 0 {
+		a.watchInterval = defaultWatchInterval
+	}
+	a.contentsCtx, a.contentsCancel = context.WithCancel(context.Background())
+	switcher.onThrottle = a.showThrottled
+	disk, diskErr := openDiskCache(opts)
+	a.disk, switcher.disk, switcher.profile = disk, disk, opts.Profile
+	switcher.onOffline = a.showOffline
+	previewMemory, previewMemoryErr := parsePreviewMemory(opts.PreviewMemory)
+	a.previewCache = newPreviewCache(previewMemory)
+	a.propsCache = make(map[string]azure.BlobProperties)
+	a.containerProps = make(map[string]azure.ContainerProperties)
+	a.accountInfo = make(map[string]azure.Account)
+	a.accountMetrics = make(map[string]azure.AccountMetrics)
+	a.serviceProps = make(map[string]azure.BlobServiceProperties)
+	a.detailsPending = make(map[string]bool)
+	a.detailsErrs = make(map[string]error)
+	a.columnPending = make(map[string]bool)
+	a.columnSlots = make(chan struct{}, columnWorkers)
+	for _, profile := range opts.Profiles {
+		if profile.Name == opts.Profile {
+			a.profile = profile
+		}
+	}
+
+	bookmarksPath, err := bookmarks.DefaultPath()
+	if err == nil {
+		a.bookmarks, err = bookmarks.Load(bookmarksPath)
+	} else {
+		a.bookmarks, _ = bookmarks.Load("")
+	}
+	a.bookmarksErr = err
+
+	searchesPath, err := searches.DefaultPath()
+	if err == nil {
+		a.searches, err = searches.Load(searchesPath)
+	} else {
+		a.searches, _ = searches.Load("")
 	}
+	a.searchesErr = err
+
+	if path, err := prefs.DefaultPath(); err == nil {
+		a.prefsPath = path
+		a.prefs, a.prefsErr = prefs.Load(path)
+	}
+	// A demo's keys are not the user's, so it counts no usage.
+	a.usageEndpoint = opts.UsageEndpoint
+	a.providerKind = opts.ProviderKind
+	if !opts.Demo {
+		a.startUsage()
+	}
+
+	a.setPaneTitle(paneAccounts, "Subscriptions")
+	a.setPaneTitle(paneContents, "Contents")
+	a.setPaneTitle(paneCompare, "Compare")
+	a.setPaneTitle(panePreview, "Preview")
 
 	a.accounts.SetChangedFunc(func(node *tview.TreeNode) {
 		if a.loadingTree {
@@ -127,35 +627,6 @@ func New(provider azure.Provider) *App {
 		a.onTreeSelected(node)
 	})
 
-	a.accounts.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyRight:
-			a.expandTreeNode(a.accounts.GetCurrentNode(), true)
-			return nil
-		case tcell.KeyLeft:
-			node := a.accounts.GetCurrentNode()
-			if node == nil {
-				return nil
-			}
-			if node.IsExpanded() && len(node.GetChildren()) > 0 {
-				node.SetExpanded(false)
-				return nil
-			}
-			parent := a.parentNode(node)
-			if parent != nil {
-				a.accounts.SetCurrentNode(parent)
-			}
-			return nil
-		case tcell.KeyRune:
-			if event.Rune() == ' ' {
-				if a.toggleSelectedSubscription() {
-					return nil
-				}
-			}
-		}
-		return event
-	})
-
 	a.contents.SetSelectionChangedFunc(func(row, column int) {
 		if a.loadingContents {
 			return
@@ -170,95 +641,239 @@ func New(provider azure.Provider) *App {
 		a.onContentSelected(row)
 	})
 
+	a.compare.SetSelectionChangedFunc(func(row, column int) {
+		if a.loadingCompare {
+			return
+		}
+		a.onCompareChanged(row)
+	})
+
+	a.compare.SetSelectedFunc(func(row, column int) {
+		if a.loadingCompare {
+			return
+		}
+		a.onCompareSelected(row)
+	})
+
 	a.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if a.searchOpen {
+		if a.modalOpen() {
 			if event.Key() == tcell.KeyCtrlC {
 				a.app.Stop()
 				return nil
 			}
-			return event
-		}
-		switch event.Key() {
-		case tcell.KeyCtrlC:
-			a.app.Stop()
-			return nil
-		case tcell.KeyTAB, tcell.KeyBacktab:
-			a.cyclePane(event.Key() == tcell.KeyBacktab)
-			return nil
-		case tcell.KeyEsc:
-			if a.activePane == panePreview && a.previewSearch != "" {
-				a.clearSearch()
-				return nil
-			}
-		}
-		switch event.Rune() {
-		case 'q':
-			a.app.Stop()
-			return nil
-		case 'r':
-			a.reload()
-			return nil
-		case '/':
-			if a.activePane == panePreview && a.previewSearchable {
-				a.openSearchModal()
+			if event.Key() == tcell.KeyCtrlS && a.saveSearchFromDialog() {
 				return nil
 			}
+			return event
 		}
-		return event
+		return a.handleKey(event)
 	})
 
-	mainColumn := tview.NewFlex().
-		SetDirection(tview.FlexRow).
-		AddItem(contents, 0, 2, true).
-		AddItem(preview, 0, 1, false)
+	contentsRow := tview.NewFlex().
+		AddItem(contents, 0, 1, true)
+	a.contentsRow = contentsRow
 
-	body := tview.NewFlex().
-		AddItem(accounts, 0, 1, true).
-		AddItem(mainColumn, 0, 3, false)
+	a.mainColumn = tview.NewFlex().SetDirection(tview.FlexRow)
+	a.body = tview.NewFlex()
+	a.applyLayout()
 
 	layout := tview.NewFlex().
 		SetDirection(tview.FlexRow).
-		AddItem(header, 1, 0, false).
-		AddItem(body, 0, 1, true).
-		AddItem(details, 7, 0, false)
+		AddItem(a.body, 0, 1, true).
+		AddItem(details, 7, 0, false).
+		AddItem(statusBar, 1, 0, false)
 
 	a.pages.AddPage("main", layout, true, true)
 	a.setupSearchModal()
+	a.setupBookmarksModal()
+	a.setupFindModal()
+	a.setupTagsModal()
+	a.setupFilterModal()
+	a.setupGrepModal()
+	a.setupSearchesModal()
+	a.setupHistoryModal()
+	a.setupPrefixModal()
+	a.setupPipeModal()
+	a.setupExportModal()
+	a.setupSaveModal()
+	a.setupIaCModal()
+	a.setupProfilesModal()
+	a.setupLogModal()
+	a.setupStatsModal()
+	a.setupLifecycleModal()
+	a.setupChangeFeedModal()
+	a.setupAuditModal()
+	a.setupFailuresModal()
+	a.setupCallsModal()
+	a.setupUsageModal()
+	a.setupSyncJobsModal()
+	a.setupCORSModal()
+	a.setupImmutabilityModal()
+	a.setupSetupWizard()
+	a.setupHelpModal()
+	a.setupPropertiesModal()
+
+	themeName := opts.Theme
+	if themeName == "" {
+		themeName = defaultThemeName
+	}
+	selected, ok := themeByName(themeName)
+	a.applyTheme(selected)
+	a.setupMouse(!opts.DisableMouse)
+	mode, timeOK := timeModeByName(opts.TimeDisplay)
+	a.timeMode = mode
+	a.trackScreen()
+
 	a.app.SetRoot(a.pages, true).SetFocus(accounts)
-	a.reload()
+	var saved savedSession
+	if !opts.Demo {
+		saved = a.startSession(opts.RestoreSession)
+	}
+	if opts.Start != (StartLocation{}) {
+		// A location asked for on the command line wins over the session.
+		a.sessionOffer = nil
+	}
+	a.reloadWith(saved.tree, contentsPosition{}, func() {
+		a.restoreSessionView(saved.view)
+		if opts.Start != (StartLocation{}) {
+			a.openStartLocation(opts.Start)
+		}
+		a.openPendingSetup()
+		a.offerSession()
+		if opts.Demo && a.demoStop == nil {
+			remap := opts.Keys
+			if keysErr != nil {
+				remap = nil
+			}
+			a.demoStop = make(chan struct{})
+			go a.runDemo(opts.DemoPace, remap, a.demoStop)
+		}
+	})
+	if a.sessionErr != nil {
+		a.flashErr(fmt.Sprintf("Error loading the saved session: %v", a.sessionErr))
+	}
+	if a.prefsErr != nil {
+		a.flashErr(fmt.Sprintf("Error loading preferences: %v", a.prefsErr))
+	}
+	if !iconsOK {
+		a.flashErr(fmt.Sprintf("Unknown icon style %q, icons are off (available: nerd, ascii, off).", opts.Icons))
+	}
+	if !timeOK {
+		a.flashErr(fmt.Sprintf("Unknown time display %q, using %s (available: %s).", opts.TimeDisplay, mode, strings.Join(timeModeNames, ", ")))
+	}
+	if keysErr != nil {
+		a.flashErr(fmt.Sprintf("Key bindings: %v; using the default keys.", keysErr))
+	}
+	switch {
+	case diskErr != nil && disk == nil:
+		a.flashErr(fmt.Sprintf("Disk cache: %v; not caching.", diskErr))
+	case diskErr != nil:
+		a.flashErr(fmt.Sprintf("Disk cache size: %v; using %s.", diskErr, a.formatSize(defaultDiskCacheSize)))
+	}
+	if previewMemoryErr != nil {
+		a.flashErr(fmt.Sprintf("Preview memory: %v; using %s.", previewMemoryErr, a.formatSize(defaultPreviewMemory)))
+	}
+	if !layoutOK {
+		a.flashErr(fmt.Sprintf("Unknown layout %q, using %s (available: %s).", opts.Layout, arrangement, strings.Join(layoutModeNames, ", ")))
+	}
+	if !ok {
+		a.flashErr(fmt.Sprintf("Unknown theme %q, using %s (available: %s).", themeName, selected.name, strings.Join(themeNames(), ", ")))
+	}
+
+	return a
+}
 
+// NewWithScreen is New drawing on screen instead of the terminal, such as a
+// tcell.SimulationScreen that tests feed keys to and read back.
+func NewWithScreen(provider azure.Provider, opts Options, screen tcell.Screen) *App {
+	a := New(provider, opts)
+	a.app.SetScreen(screen)
 	return a
 }
 
 func (a *App) Run() error {
-	return a.app.Run()
+	a.startSyncJobs(a.pendingSyncJobs)
+	defer a.stopSyncJobs()
+	err := a.app.Run()
+	if a.demoStop != nil {
+		close(a.demoStop)
+	}
+	if err != nil {
+		return err
+	}
+	if a.demoErr != nil {
+		return a.demoErr
+	}
+	err = a.saveSession()
+	a.saveUsage()
+	return err
+}
+
+// Stop ends Run as quitting does, from any goroutine.
+func (a *App) Stop() {
+	a.app.Stop()
 }
 
+// QueueUpdate runs f on the UI goroutine, between draws, and waits for it.
+// Run must be running, or about to.
+func (a *App) QueueUpdate(f func()) {
+	a.app.QueueUpdate(f)
+}
+
+// reload rebuilds the subscription tree, then expands the nodes that were
+// expanded and restores the selection and the contents position.
 func (a *App) reload() {
-	if err := a.loadSubscriptions(); err != nil {
-		a.showSubscriptionsError(err)
-		return
-	}
-	a.showEmptyContents("Select a container to view blobs.")
-	a.refreshDetails()
+	a.reloadWith(a.saveTreeState(), a.saveContentsPosition(), nil)
+}
+
+func (a *App) reloadWith(tree treeState, position contentsPosition, done func()) {
+	a.forgetContainerProps("")
+	clear(a.accountMetrics)
+	clear(a.serviceProps)
+	a.loadSubscriptions(func(err error) {
+		if err != nil {
+			a.recordFailure("list subscriptions", a.profile.Name, err, a.reload)
+			a.showSubscriptionsError(err)
+			return
+		}
+		a.showEmptyContents("Select a container to view blobs.")
+		a.restoreTreeState(tree, func() {
+			a.whenContentsLoaded(func(err error) {
+				if err == nil {
+					a.restoreContentsPosition(position)
+				}
+				if done != nil {
+					done()
+				}
+			})
+		})
+		a.refreshDetails()
+	})
 }
 
 func (a *App) setupSearchModal() {
 	input := tview.NewInputField().
 		SetLabel("Find: ").
 		SetFieldWidth(0)
+	matchCase := tview.NewCheckbox().SetLabel("Match case ")
+	wholeWord := tview.NewCheckbox().SetLabel("Whole words ")
+	cancel := func() {
+		a.clearSearch()
+		a.closeSearchModal()
+	}
 	form := tview.NewForm().
 		AddFormItem(input).
+		AddFormItem(matchCase).
+		AddFormItem(wholeWord).
 		AddButton("Search", func() {
 			a.applySearch(input.GetText())
 			a.closeSearchModal()
 		}).
-		AddButton("Cancel", func() {
-			a.clearSearch()
-			a.closeSearchModal()
-		})
+		AddButton("Cancel", cancel)
+	form.SetItemPadding(0)
 	form.SetBorder(true).SetTitle("Search Preview")
 	form.SetButtonsAlign(tview.AlignRight)
+	form.SetCancelFunc(cancel)
 
 	input.SetDoneFunc(func(key tcell.Key) {
 		switch key {
@@ -272,13 +887,22 @@ func (a *App) setupSearchModal() {
 	})
 
 	a.searchInput = input
+	a.searchCase = matchCase
+	a.searchWord = wholeWord
 	a.searchForm = form
-	a.pages.AddPage("search", centerModal(form, 7, 60), true, false)
+	a.pages.AddPage("search", centerModal(form, 9, 60), true, false)
+}
+
+func (a *App) modalOpen() bool {
+	return a.searchOpen || a.bookmarksOpen || a.helpOpen || a.confirmOpen || a.propertiesOpen || a.findOpen || a.tagsOpen || a.filterOpen || a.grepOpen || a.searchesOpen || a.saveSearchOpen || a.historyOpen || a.prefixOpen || a.pipeOpen || a.exportOpen || a.saveOpen || a.iacOpen || a.profilesOpen || a.logOpen || a.statsOpen || a.lifecycleOpen || a.changeFeedOpen || a.auditOpen || a.failuresOpen || a.callsOpen || a.usageOpen || a.syncJobsOpen || a.corsOpen || a.wormOpen || a.setupOpen
 }
 
 func (a *App) openSearchModal() {
 	a.searchOpen = true
 	a.searchInput.SetText(a.previewSearch)
+	a.searchCase.SetChecked(a.prefs.PreviewSearch.CaseSensitive)
+	a.searchWord.SetChecked(a.prefs.PreviewSearch.WholeWord)
+	a.searchForm.SetFocus(0)
 	a.pages.ShowPage("search")
 	a.app.SetFocus(a.searchInput)
 }
@@ -298,11 +922,14 @@ func (a *App) togglePane() {
 }
 
 func (a *App) cyclePane(reverse bool) {
-	var order []pane
+	order := []pane{paneAccounts, paneContents, panePreview}
+	if a.splitOpen {
+		order = []pane{paneAccounts, paneContents, paneCompare, panePreview}
+	}
 	if reverse {
-		order = []pane{panePreview, paneContents, paneAccounts}
-	} else {
-		order = []pane{paneAccounts, paneContents, panePreview}
+		for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+			order[i], order[j] = order[j], order[i]
+		}
 	}
 	for i, paneID := range order {
 		if paneID == a.activePane {
@@ -315,40 +942,107 @@ func (a *App) cyclePane(reverse bool) {
 }
 
 func (a *App) setActivePane(target pane) {
+	if target != a.activePane {
+		a.usage.Pane(paneNames[target])
+	}
 	a.activePane = target
 	if target == paneAccounts {
 		a.app.SetFocus(a.accounts)
 	} else if target == paneContents {
 		a.app.SetFocus(a.contents)
+	} else if target == paneCompare {
+		a.app.SetFocus(a.compare)
 	} else {
 		a.app.SetFocus(a.preview)
 	}
 	a.refreshDetails()
+	a.updatePaneBorders()
+	a.refreshStatus()
 }
 
-func (a *App) loadSubscriptions() error {
-	ctx := context.Background()
-	subscriptions, err := a.provider.ListSubscriptions(ctx)
-	if err != nil {
-		return err
+// subscriptionListing is the result of loading the subscription tree: every
+// subscription plus the accounts of the enabled ones.
+type subscriptionListing struct {
+	subscriptions []azure.Subscription
+	enabled       map[string]bool
+	accounts      map[string][]azure.Account
+}
+
+func (a *App) loadSubscriptions(done func(error)) {
+	previous := make(map[string]bool, len(a.subscriptionEnabled))
+	for id, enabled := range a.subscriptionEnabled {
+		previous[id] = enabled
 	}
+	profile := a.profile
 
+	runAsync(a, paneAccounts, func(ctx context.Context) (subscriptionListing, error) {
+		listed, err := a.provider.ListSubscriptions(ctx)
+		if err != nil {
+			return subscriptionListing{}, err
+		}
+		var subscriptions []azure.Subscription
+		for _, subscription := range listed {
+			if profile.showsSubscription(subscription) && a.shownSubs.Shows(subscription.ID, subscription.Name) {
+				subscriptions = append(subscriptions, subscription)
+			}
+		}
+		listing := subscriptionListing{
+			subscriptions: subscriptions,
+			enabled:       mergeSubscriptionSelections(previous, subscriptions),
+			accounts:      make(map[string][]azure.Account),
+		}
+		var enabled []azure.Subscription
+		for _, subscription := range subscriptions {
+			if listing.enabled[subscription.ID] {
+				enabled = append(enabled, subscription)
+			}
+		}
+		// Subscriptions are listed side by side so startup does not wait on
+		// each in turn.
+		var mu sync.Mutex
+		err = forEach(ctx, loadWorkers, enabled, func(ctx context.Context, subscription azure.Subscription) error {
+			accounts, err := a.provider.ListAccounts(ctx, subscription.ID)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			listing.accounts[subscription.ID] = a.shownAccounts(accounts)
+			mu.Unlock()
+			return nil
+		})
+		if err != nil {
+			return subscriptionListing{}, err
+		}
+		return listing, nil
+	}, func(listing subscriptionListing, err error) {
+		if err != nil {
+			done(err)
+			return
+		}
+		a.fillSubscriptions(listing)
+		done(nil)
+	})
+}
+
+func (a *App) fillSubscriptions(listing subscriptionListing) {
 	a.loadingTree = true
 	a.root.ClearChildren()
 	a.root.SetExpanded(true)
-	a.accounts.SetTitle("Subscriptions")
-	a.subscriptionEnabled = a.mergeSubscriptionSelections(subscriptions)
+	a.pendingNodes = make(map[*tview.TreeNode][]func(error))
+	a.failedNodes = make(map[*tview.TreeNode]bool)
+	a.setPaneTitle(paneAccounts, "Subscriptions")
+	a.subscriptionEnabled = listing.enabled
 
-	if len(subscriptions) == 0 {
+	if len(listing.subscriptions) == 0 {
 		ref := itemRef{Kind: kindNone, Name: "No subscriptions found."}
-		node := tview.NewTreeNode(ref.Name).SetReference(ref).SetSelectable(true)
+		node := a.newTreeNode(ref.Name, ref)
 		a.root.AddChild(node)
 		a.accounts.SetCurrentNode(node)
 		a.loadingTree = false
-		return nil
+		return
 	}
 
-	for _, subscription := range subscriptions {
+	for _, subscription := range listing.subscriptions {
 		enabled := a.isSubscriptionEnabled(subscription.ID)
 		ref := itemRef{
 			Kind:             kindSubscription,
@@ -356,11 +1050,9 @@ func (a *App) loadSubscriptions() error {
 			SubscriptionID:   subscription.ID,
 			SubscriptionName: subscription.Name,
 		}
-		node := tview.NewTreeNode(subscriptionLabel(ref.Name, enabled)).SetReference(ref).SetSelectable(true)
+		node := a.newTreeNode(a.subscriptionLabel(ref, enabled), ref)
 		if enabled {
-			if err := a.loadAccountsForSubscription(node, ref); err != nil {
-				return err
-			}
+			a.fillAccounts(node, ref, listing.accounts[subscription.ID])
 			node.SetExpanded(true)
 		}
 		a.root.AddChild(node)
@@ -373,14 +1065,23 @@ func (a *App) loadSubscriptions() error {
 		a.accounts.SetCurrentNode(a.root)
 	}
 	a.loadingTree = false
+}
 
-	return nil
+// shownAccounts drops the accounts the account patterns hide.
+func (a *App) shownAccounts(accounts []azure.Account) []azure.Account {
+	var shown []azure.Account
+	for _, account := range accounts {
+		if a.shownAccts.Shows(account.Name) {
+			shown = append(shown, account)
+		}
+	}
+	return shown
 }
 
-func (a *App) mergeSubscriptionSelections(subscriptions []azure.Subscription) map[string]bool {
+func mergeSubscriptionSelections(previous map[string]bool, subscriptions []azure.Subscription) map[string]bool {
 	next := make(map[string]bool, len(subscriptions))
 	for _, subscription := range subscriptions {
-		enabled, ok := a.subscriptionEnabled[subscription.ID]
+		enabled, ok := previous[subscription.ID]
 		if !ok {
 			enabled = true
 		}
@@ -389,11 +1090,11 @@ func (a *App) mergeSubscriptionSelections(subscriptions []azure.Subscription) ma
 	return next
 }
 
-func subscriptionLabel(name string, enabled bool) string {
+func (a *App) subscriptionLabel(subscription itemRef, enabled bool) string {
 	if enabled {
-		return fmt.Sprintf("(x) %s", name)
+		return fmt.Sprintf("(x) %s", a.itemLabel(subscription))
 	}
-	return fmt.Sprintf("( ) %s", name)
+	return fmt.Sprintf("( ) %s", a.itemLabel(subscription))
 }
 
 func (a *App) isSubscriptionEnabled(subscriptionID string) bool {
@@ -424,9 +1125,11 @@ func (a *App) toggleSubscription(node *tview.TreeNode, subscription itemRef) {
 	enabled := a.isSubscriptionEnabled(subscription.SubscriptionID)
 	enabled = !enabled
 	a.subscriptionEnabled[subscription.SubscriptionID] = enabled
-	node.SetText(subscriptionLabel(subscription.Name, enabled))
+	node.SetText(a.subscriptionLabel(subscription, enabled))
 
 	if !enabled {
+		delete(a.pendingNodes, node)
+		delete(a.failedNodes, node)
 		node.ClearChildren()
 		node.SetExpanded(false)
 		if a.activePane == paneAccounts {
@@ -436,34 +1139,109 @@ func (a *App) toggleSubscription(node *tview.TreeNode, subscription itemRef) {
 		return
 	}
 
-	if err := a.loadAccountsForSubscription(node, subscription); err != nil {
-		a.showTreeLoadError("accounts", err)
-		errorRef := itemRef{
-			Kind:             kindNone,
-			Name:             "Error loading accounts.",
-			SubscriptionID:   subscription.SubscriptionID,
-			SubscriptionName: subscription.SubscriptionName,
+	if a.activePane == paneAccounts {
+		a.updateDetails(subscription)
+	}
+	a.loadChildren(node, subscription, func(err error) {
+		if err != nil || !a.isSubscriptionEnabled(subscription.SubscriptionID) {
+			return
 		}
-		node.ClearChildren()
-		node.AddChild(tview.NewTreeNode(errorRef.Name).SetReference(errorRef).SetSelectable(true))
+		if a.accounts.GetCurrentNode() == node {
+			a.showEmptyContents("Select an account to view containers.")
+		}
+	})
+}
+
+// loadChildren fetches the children of a subscription, account, or container
+// node in the background. The node is expanded right away with a "Loading…"
+// placeholder child that is replaced by the result, or by an error node that
+// the next expand retries. Callers asking for a node that is already loading
+// are queued behind the request in flight.
+func (a *App) loadChildren(node *tview.TreeNode, ref itemRef, done func(error)) {
+	a.fetchChildren(node, ref, false, done)
+}
+
+// fetchChildren backs loadChildren. With refresh set, the current children
+// stay in place while loading and are merged with the result, and a failed
+// refresh keeps them instead of showing an error node.
+func (a *App) fetchChildren(node *tview.TreeNode, ref itemRef, refresh bool, done func(error)) {
+	if waiters, ok := a.pendingNodes[node]; ok {
+		a.pendingNodes[node] = append(waiters, done)
 		return
 	}
-
-	node.SetExpanded(true)
-	if a.activePane == paneAccounts {
-		a.updateDetails(subscription)
+	a.pendingNodes[node] = []func(error){done}
+	delete(a.failedNodes, node)
+	if !refresh {
+		node.ClearChildren()
+		node.AddChild(a.newTreeNode("Loading…", placeholderRef(ref, "Loading…")))
+		node.SetExpanded(true)
 	}
-	a.showEmptyContents("Select an account to view containers.")
+
+	var scope string
+	switch ref.Kind {
+	case kindSubscription:
+		scope = "accounts"
+	case kindAccount:
+		scope = "containers"
+	default:
+		scope = "blobs"
+	}
+
+	runAsync(a, paneAccounts, func(ctx context.Context) (func(), error) {
+		switch ref.Kind {
+		case kindSubscription:
+			accounts, err := a.provider.ListAccounts(ctx, ref.SubscriptionID)
+			accounts = a.shownAccounts(accounts)
+			return func() { a.fillAccounts(node, ref, accounts) }, err
+		case kindAccount:
+			containers, err := a.provider.ListContainers(ctx, ref.Account)
+			return func() { a.fillContainers(node, ref, containers) }, err
+		default:
+			blobs, err := a.provider.ListBlobs(ctx, ref.Account, ref.Container)
+			return func() { a.fillBlobChildren(node, ref, blobs) }, err
+		}
+	}, func(fill func(), err error) {
+		waiters, ok := a.pendingNodes[node]
+		if !ok {
+			return
+		}
+		delete(a.pendingNodes, node)
+		a.recordFailure("list "+scope, refTarget(ref), err, func() {
+			a.fetchChildren(node, ref, refresh, nil)
+		})
+		if err != nil && refresh {
+			a.flashErr(fmt.Sprintf("Error refreshing %s: %v", scope, err))
+		} else if err != nil {
+			a.failedNodes[node] = true
+			node.ClearChildren()
+			name := fmt.Sprintf("Error loading %s.", scope)
+			node.AddChild(a.newTreeNode(name, placeholderRef(ref, name)))
+			a.showTreeLoadError(scope, err)
+		} else {
+			fill()
+		}
+		for _, waiter := range waiters {
+			if waiter != nil {
+				waiter(err)
+			}
+		}
+	})
 }
 
-func (a *App) loadAccountsForSubscription(node *tview.TreeNode, subscription itemRef) error {
-	ctx := context.Background()
-	accounts, err := a.provider.ListAccounts(ctx, subscription.SubscriptionID)
-	if err != nil {
-		return err
+// placeholderRef builds a placeholder reference below parent that keeps the
+// parent's location so Details can still show where it lives.
+func placeholderRef(parent itemRef, name string) itemRef {
+	return itemRef{
+		Kind:             kindNone,
+		Name:             name,
+		SubscriptionID:   parent.SubscriptionID,
+		SubscriptionName: parent.SubscriptionName,
+		Account:          parent.Account,
+		Container:        parent.Container,
 	}
+}
 
-	node.ClearChildren()
+func (a *App) fillAccounts(node *tview.TreeNode, subscription itemRef, accounts []azure.Account) {
 	if len(accounts) == 0 {
 		ref := itemRef{
 			Kind:             kindNone,
@@ -471,11 +1249,11 @@ func (a *App) loadAccountsForSubscription(node *tview.TreeNode, subscription ite
 			SubscriptionID:   subscription.SubscriptionID,
 			SubscriptionName: subscription.SubscriptionName,
 		}
-		child := tview.NewTreeNode(ref.Name).SetReference(ref).SetSelectable(true)
-		node.AddChild(child)
-		return nil
+		a.setChildren(node, []*tview.TreeNode{a.newTreeNode(ref.Name, ref)})
+		return
 	}
 
+	children := make([]*tview.TreeNode, 0, len(accounts))
 	for _, account := range accounts {
 		ref := itemRef{
 			Kind:             kindAccount,
@@ -485,20 +1263,13 @@ func (a *App) loadAccountsForSubscription(node *tview.TreeNode, subscription ite
 			Account:          account.Name,
 			Region:           account.Region,
 		}
-		child := tview.NewTreeNode(account.Name).SetReference(ref).SetSelectable(true)
-		node.AddChild(child)
+		a.accountInfo[account.Name] = account
+		children = append(children, a.newTreeNode(a.itemLabel(ref), ref))
 	}
-
-	return nil
+	a.setChildren(node, children)
 }
 
-func (a *App) loadContainers(node *tview.TreeNode, account itemRef) error {
-	ctx := context.Background()
-	containers, err := a.provider.ListContainers(ctx, account.Account)
-	if err != nil {
-		return err
-	}
-
+func (a *App) fillContainers(node *tview.TreeNode, account itemRef, containers []azure.Container) {
 	if len(containers) == 0 {
 		ref := itemRef{
 			Kind:             kindNone,
@@ -507,11 +1278,11 @@ func (a *App) loadContainers(node *tview.TreeNode, account itemRef) error {
 			SubscriptionName: account.SubscriptionName,
 			Account:          account.Account,
 		}
-		child := tview.NewTreeNode(ref.Name).SetReference(ref).SetSelectable(true)
-		node.AddChild(child)
-		return nil
+		a.setChildren(node, []*tview.TreeNode{a.newTreeNode(ref.Name, ref)})
+		return
 	}
 
+	children := make([]*tview.TreeNode, 0, len(containers))
 	for _, container := range containers {
 		ref := itemRef{
 			Kind:             kindContainer,
@@ -522,20 +1293,12 @@ func (a *App) loadContainers(node *tview.TreeNode, account itemRef) error {
 			Container:        container.Name,
 			PublicAccess:     container.PublicAccess,
 		}
-		child := tview.NewTreeNode(container.Name).SetReference(ref).SetSelectable(true)
-		node.AddChild(child)
+		children = append(children, a.newTreeNode(a.itemLabel(ref), ref))
 	}
-
-	return nil
+	a.setChildren(node, children)
 }
 
-func (a *App) loadBlobChildren(node *tview.TreeNode, container itemRef) error {
-	ctx := context.Background()
-	blobs, err := a.provider.ListBlobs(ctx, container.Account, container.Container)
-	if err != nil {
-		return err
-	}
-
+func (a *App) fillBlobChildren(node *tview.TreeNode, container itemRef, blobs []azure.Blob) {
 	if len(blobs) == 0 {
 		ref := itemRef{
 			Kind:             kindNone,
@@ -545,73 +1308,64 @@ func (a *App) loadBlobChildren(node *tview.TreeNode, container itemRef) error {
 			Account:          container.Account,
 			Container:        container.Container,
 		}
-		child := tview.NewTreeNode(ref.Name).SetReference(ref).SetSelectable(true)
-		node.AddChild(child)
-		return nil
+		a.setChildren(node, []*tview.TreeNode{a.newTreeNode(ref.Name, ref)})
+		return
 	}
 
+	children := make([]*tview.TreeNode, 0, len(blobs))
 	for _, blob := range blobs {
-		ref := itemRef{
-			Kind:             kindBlob,
-			Name:             blob.Name,
-			SubscriptionID:   container.SubscriptionID,
-			SubscriptionName: container.SubscriptionName,
-			Account:          container.Account,
-			Container:        container.Container,
-			SizeBytes:        blob.SizeBytes,
-			Modified:         blob.Modified,
-			ContentType:      blob.ContentType,
-		}
-		child := tview.NewTreeNode(blob.Name).SetReference(ref).SetSelectable(true)
-		node.AddChild(child)
+		ref := blobRef(container, blob)
+		children = append(children, a.newTreeNode(a.itemLabel(ref), ref))
 	}
-
-	return nil
+	a.setChildren(node, children)
 }
 
 func (a *App) expandTreeNode(node *tview.TreeNode, toggle bool) {
+	a.expandNode(node, toggle, nil)
+}
+
+// expandNode expands (or toggles) a node, loading its children first when it
+// has none. done, if set, runs once the node is expanded or loading failed.
+func (a *App) expandNode(node *tview.TreeNode, toggle bool, done func(error)) {
+	finish := func(err error) {
+		if done != nil {
+			done(err)
+		}
+	}
 	if node == nil {
+		finish(errors.New("nothing selected"))
 		return
 	}
 	ref, ok := node.GetReference().(itemRef)
 	if !ok {
+		finish(errors.New("nothing selected"))
 		return
 	}
 
 	switch ref.Kind {
 	case kindSubscription:
 		if !a.isSubscriptionEnabled(ref.SubscriptionID) {
+			finish(fmt.Errorf("subscription %s is disabled", ref.Name))
 			return
 		}
-		if len(node.GetChildren()) == 0 {
-			if err := a.loadAccountsForSubscription(node, ref); err != nil {
-				a.showTreeLoadError("accounts", err)
-				return
-			}
-		}
-	case kindAccount:
-		if len(node.GetChildren()) == 0 {
-			if err := a.loadContainers(node, ref); err != nil {
-				a.showTreeLoadError("containers", err)
-				return
-			}
-		}
-	case kindContainer:
-		if len(node.GetChildren()) == 0 {
-			if err := a.loadBlobChildren(node, ref); err != nil {
-				a.showTreeLoadError("blobs", err)
-				return
-			}
-		}
+	case kindAccount, kindContainer:
 	default:
+		finish(nil)
 		return
 	}
 
-	if toggle {
-		node.SetExpanded(!node.IsExpanded())
-	} else {
-		node.SetExpanded(true)
+	_, pending := a.pendingNodes[node]
+	if !pending && !a.failedNodes[node] && len(node.GetChildren()) > 0 {
+		if toggle {
+			node.SetExpanded(!node.IsExpanded())
+		} else {
+			node.SetExpanded(true)
+		}
+		finish(nil)
+		return
 	}
+
+	a.loadChildren(node, ref, finish)
 }
 
 func (a *App) parentNode(target *tview.TreeNode) *tview.TreeNode {
@@ -621,6 +1375,28 @@ func (a *App) parentNode(target *tview.TreeNode) *tview.TreeNode {
 	return a.findParentNode(target, a.root, nil)
 }
 
+func (a *App) newTreeNode(text string, ref itemRef) *tview.TreeNode {
+	node := tview.NewTreeNode(text).SetReference(ref).SetSelectable(true)
+	a.styleTreeNode(node)
+	return node
+}
+
+func (a *App) collapseOrParent() {
+	node := a.accounts.GetCurrentNode()
+	if node == nil {
+		return
+	}
+	if node.IsExpanded() && len(node.GetChildren()) > 0 {
+		node.SetExpanded(false)
+		return
+	}
+	parent := a.parentNode(node)
+	if parent != nil {
+		a.accounts.SetCurrentNode(parent)
+		a.onTreeChanged(parent)
+	}
+}
+
 func (a *App) findParentNode(target, node, parent *tview.TreeNode) *tview.TreeNode {
 	if node == target {
 		return parent
@@ -644,9 +1420,10 @@ func (a *App) onTreeChanged(node *tview.TreeNode) {
 
 	switch ref.Kind {
 	case kindContainer:
-		if err := a.showBlobs(ref); err != nil {
-			a.showLoadError("blobs", err)
-			return
+		// The tree reports programmatic selection changes on its next
+		// draw as well, so skip containers that are already listed.
+		if a.contentsTarget.Kind != kindContainer || !sameContainer(a.contentsTarget, ref) {
+			a.showBlobs(ref)
 		}
 	case kindBlob:
 		a.updatePreview(ref)
@@ -697,51 +1474,301 @@ func (a *App) addContentRow(ref itemRef, name, details string) {
 	a.contents.SetCell(row, 1, detailCell)
 }
 
-func (a *App) showBlobs(container itemRef) error {
-	ctx := context.Background()
-	blobs, err := a.provider.ListBlobs(ctx, container.Account, container.Container)
-	if err != nil {
-		return err
+// showBlobs lists the container into the contents table in the background.
+// A newer listing, or replacing the contents, discards the result. With the
+// disk cache on, the listing it kept shows at once and stays when listing
+// fails.
+func (a *App) showBlobs(container itemRef) {
+	if !sameContainer(a.contentSource, container) {
+		a.listPrefix = ""
+	}
+	prefix := a.listPrefix
+	profile := a.profile.Name
+	a.cancelContentsLoad()
+	a.contentsSeq++
+	seq := a.contentsSeq
+	a.contentsLoading = true
+	a.contentsTarget = container
+	a.watchMarks = nil
+
+	cachedPage, cachedAt, fromCache := a.loadCachedListing(container, prefix)
+	if fromCache {
+		a.fillBlobs(container, cachedPage)
+		a.contentsCachedAt = cachedAt
+		a.setPaneTitle(paneContents, a.contentsTitle())
+	} else {
+		a.loadingContents = true
+		a.contents.Clear()
+		a.contentRefs = nil
+		loadingRef := itemRef{Kind: kindNone, Name: "Loading blobs…"}
+		a.addContentRow(loadingRef, loadingRef.Name, "")
+		a.contents.Select(0, 0)
+		a.loadingContents = false
+		a.setPaneTitle(paneContents, fmt.Sprintf("Contents: %s/%s", container.Account, container.Name))
+	}
+
+	// The first rows are listed in small pages, each shown as it arrives,
+	// until a full page is loaded; "Load more" continues from there. Over a
+	// cached listing, the fresh one replaces it once complete instead.
+	ctx, cancel := context.WithCancel(a.contentsCtx)
+	a.contentsPaging = true
+	a.enumCancel = cancel
+	a.startLoading(paneContents)
+
+	go func() {
+		defer cancel()
+		var err error
+		var listed azure.BlobPage
+		marker, loaded := "", 0
+		for first := true; first || (marker != "" && loaded < blobPageSize); first = false {
+			var page azure.BlobPage
+			page, err = a.provider.ListBlobsPage(ctx, container.Account, container.Container, azure.ListBlobsOptions{
+				Marker:     marker,
+				MaxResults: min(streamPageSize, blobPageSize-loaded),
+				Prefix:     prefix,
+			})
+			if err == nil {
+				err = ctx.Err()
+			}
+			if err != nil {
+				break
+			}
+			marker = page.NextMarker
+			loaded += len(page.Blobs)
+			listed.Blobs = append(listed.Blobs, page.Blobs...)
+			listed.NextMarker = marker
+			if fromCache {
+				continue
+			}
+			a.queueUpdate(func() {
+				if seq != a.contentsSeq {
+					return
+				}
+				if first {
+					a.fillBlobs(container, page)
+				} else {
+					a.appendBlobs(container, page)
+				}
+			})
+		}
+		if err == nil {
+			a.storeListing(profile, container, prefix, listed)
+		}
+
+		a.app.QueueUpdateDraw(func() {
+			a.stopLoading(paneContents)
+			if seq != a.contentsSeq {
+				return
+			}
+			a.contentsLoading = false
+			a.contentsPaging = false
+			a.enumCancel = nil
+			waiters := a.contentsWaiters
+			a.contentsWaiters = nil
+			switch {
+			case fromCache && err == nil:
+				a.replaceCachedListing(container, listed)
+			case fromCache && errors.Is(err, context.Canceled):
+				a.renderContents(true)
+				a.flash("Listing stopped; showing the cached listing.")
+				err = nil
+			case fromCache:
+				a.recordFailure("list blobs", refTarget(container), err, func() { a.showBlobs(container) })
+				a.renderContents(true)
+				a.flashErr(fmt.Sprintf("Offline: showing the listing cached %s (%v).", a.formatTimestamp(a.contentsCachedAt), err))
+				err = nil
+			case err != nil && loaded == 0:
+				a.recordFailure("list blobs", refTarget(container), err, func() { a.showBlobs(container) })
+				a.showLoadError("blobs", err)
+			case errors.Is(err, context.Canceled):
+				a.renderContents(true)
+				a.flash(fmt.Sprintf("Listing stopped after %s blobs.", groupDigits(int64(loaded))))
+				err = nil
+			case err != nil:
+				a.recordFailure("list more blobs", refTarget(container), err, func() { a.showBlobs(container) })
+				a.renderContents(true)
+				a.flashErr(fmt.Sprintf("Error loading more blobs: %v", err))
+				err = nil
+			default:
+				a.renderContents(true)
+			}
+			for _, waiter := range waiters {
+				waiter(err)
+			}
+			a.followWatchEvents()
+		})
+	}()
+}
+
+// replaceCachedListing swaps the cached listing shown for the fresh one,
+// keeping the selection.
+func (a *App) replaceCachedListing(container itemRef, page azure.BlobPage) {
+	before := a.listedBlobs
+	a.contentsCachedAt = time.Time{}
+	a.contentsMarker = page.NextMarker
+	a.listedBlobs = nil
+	for _, blob := range page.Blobs {
+		a.listedBlobs = append(a.listedBlobs, blobRef(container, blob))
+	}
+	if !unchangedListing(before, a.listedBlobs, a.contentsMarker != "") {
+		a.invalidateFolderStats()
+		if a.folderView {
+			a.loadFolderStats(container)
+		}
 	}
+	a.renderContents(true)
+}
+
+// whenContentsLoaded runs callback once the listing in flight has been
+// applied, or immediately when nothing is loading.
+func (a *App) whenContentsLoaded(callback func(error)) {
+	if !a.contentsLoading {
+		callback(nil)
+		return
+	}
+	a.contentsWaiters = append(a.contentsWaiters, callback)
+}
+
+// cancelContentsLoad abandons the listing in the contents table. Requests
+// made for it through contentsCtx are canceled, so they neither pile up nor
+// outlive it.
+func (a *App) cancelContentsLoad() {
+	a.contentsCancel()
+	a.contentsCtx, a.contentsCancel = context.WithCancel(context.Background())
+	a.contentsSeq++
+	a.contentsLoading = false
+	a.contentsWaiters = nil
+	a.contentsTarget = itemRef{}
+	a.contentsMarker = ""
+	a.contentsPaging = false
+	a.contentsCachedAt = time.Time{}
+	if a.enumCancel != nil {
+		a.enumCancel()
+		a.enumCancel = nil
+	}
+}
+
+func sameContainer(left, right itemRef) bool {
+	return left.SubscriptionID == right.SubscriptionID &&
+		left.Account == right.Account &&
+		left.Container == right.Container
+}
+
+func (a *App) fillBlobs(container itemRef, page azure.BlobPage) {
+	if !sameContainer(a.contentSource, container) {
+		a.folderPrefix = ""
+		a.archive = nil
+		a.propsCache = make(map[string]azure.BlobProperties)
+	}
+	a.contentSource = container
+	a.contentsMarker = page.NextMarker
+	a.listedBlobs = nil
+	for _, blob := range page.Blobs {
+		a.listedBlobs = append(a.listedBlobs, blobRef(container, blob))
+	}
+	a.invalidateFolderStats()
+
+	a.setPreviewContent("Select a blob to preview.", false)
+	a.renderContents(false)
+	if a.folderView {
+		a.loadFolderStats(container)
+	}
+}
+
+// renderContents rebuilds the contents table from the listed blobs, as a
+// flat list or one virtual-folder level. With keep set, the selected row and
+// the scroll offset survive the rebuild.
+func (a *App) renderContents(keep bool) {
+	row, _ := a.contents.GetSelection()
+	selected, _ := a.contentRef(row)
+	offset, _ := a.contents.GetOffset()
+	container := a.contentSource
 
 	a.loadingContents = true
 	a.contents.Clear()
 	a.contentRefs = nil
-
-	for _, blob := range blobs {
-		ref := itemRef{
-			Kind:             kindBlob,
-			Name:             blob.Name,
-			SubscriptionID:   container.SubscriptionID,
-			SubscriptionName: container.SubscriptionName,
-			Account:          container.Account,
-			Container:        container.Container,
-			SizeBytes:        blob.SizeBytes,
-			Modified:         blob.Modified,
-			ContentType:      blob.ContentType,
+	switch {
+	case container.Kind == kindAccount:
+		a.addTagMatchRows()
+	case a.archive != nil:
+		a.addArchiveRows()
+	case a.folderView:
+		a.addFolderLevelRows(container)
+	default:
+		for _, ref := range a.filteredBlobs() {
+			a.addContentRow(ref, a.contentLabel(ref), a.formatContentDetails(ref))
 		}
-		a.addContentRow(ref, blob.Name, formatContentDetails(ref))
+	}
+	if len(a.contentRefs) == 0 {
+		a.addEmptyContainerRow(container)
+	}
+	if a.archive == nil {
+		a.addExtraColumns()
+		a.addLoadMoreRow(container)
 	}
 
-	if len(blobs) == 0 {
-		ref := itemRef{
-			Kind:             kindNone,
-			Name:             "No blobs in container.",
-			SubscriptionID:   container.SubscriptionID,
-			SubscriptionName: container.SubscriptionName,
-			Account:          container.Account,
-			Container:        container.Container,
+	if keep {
+		for index, ref := range a.contentRefs {
+			if ref.Kind == selected.Kind && ref.Name == selected.Name && ref.Prefix == selected.Prefix {
+				row = index
+				break
+			}
 		}
-		a.addContentRow(ref, ref.Name, "")
+		if row >= len(a.contentRefs) {
+			row = len(a.contentRefs) - 1
+		}
+	} else {
+		row, offset = 0, 0
 	}
-
-	a.contents.Select(0, 0)
+	a.contents.Select(row, 0)
+	a.contents.SetOffset(offset, 0)
 	a.loadingContents = false
-	a.contents.SetTitle(fmt.Sprintf("Contents: %s/%s", container.Account, container.Name))
-	a.setPreviewContent("Select a blob to preview.", false)
+
+	a.setPaneTitle(paneContents, a.contentsTitle())
+	a.markDifferences()
 	a.refreshContentSelection()
+}
 
-	return nil
+func (a *App) contentsTitle() string {
+	container := a.contentSource
+	if container.Kind == kindAccount {
+		return fmt.Sprintf("Tags: %s  %s  %d blobs", container.Account, a.tagsExpression, len(a.listedBlobs))
+	}
+	if a.archive != nil {
+		return fmt.Sprintf("Contents: %s/%s/%s!/%s  %d files", container.Account, container.Name, a.archive.blob.Name, a.archive.prefix, len(a.archive.reader.Entries))
+	}
+	title := fmt.Sprintf("Contents: %s/%s", container.Account, container.Name)
+	if a.listPrefix != "" {
+		title += fmt.Sprintf("  prefix: %s", a.listPrefix)
+	}
+	if a.folderView {
+		title += "/" + a.folderPrefix
+	}
+	if a.contentsFilter != nil {
+		title += fmt.Sprintf("  filter: %s  %d of %d blobs", a.contentsFilter.summary(), len(a.filteredBlobs()), len(a.listedBlobs))
+	}
+	if !a.contentsCachedAt.IsZero() {
+		title += "  cached " + a.formatTimestamp(a.contentsCachedAt)
+	}
+	return title
+}
+
+func (a *App) addEmptyContainerRow(container itemRef) {
+	ref := itemRef{
+		Kind:             kindNone,
+		Name:             "No blobs in container.",
+		SubscriptionID:   container.SubscriptionID,
+		SubscriptionName: container.SubscriptionName,
+		Account:          container.Account,
+		Container:        container.Container,
+	}
+	switch {
+	case a.contentsFilter != nil && len(a.listedBlobs) > 0:
+		ref.Name = "No listed blobs match the filter."
+	case a.listPrefix != "":
+		ref.Name = fmt.Sprintf("No blobs start with %s.", a.listPrefix)
+	}
+	a.addContentRow(ref, ref.Name, "")
 }
 
 func (a *App) onContentChanged(row int) {
@@ -749,6 +1776,11 @@ func (a *App) onContentChanged(row int) {
 	if !ok {
 		return
 	}
+	if ref.Kind == kindLoadMore && a.takePrefetchedPage() {
+		return
+	}
+	a.prefetchNear(row)
+	a.fetchVisibleColumns()
 	a.updatePreview(ref)
 	if a.activePane == paneContents || a.activePane == panePreview {
 		a.updateDetails(ref)
@@ -761,8 +1793,21 @@ func (a *App) onContentSelected(row int) {
 		return
 	}
 
-	if ref.Kind == kindBlob {
+	switch ref.Kind {
+	case kindBlob:
+		if a.contentSource.Kind == kindAccount {
+			a.openTagMatch(ref)
+			return
+		}
+		if archive.Supported(ref.Name) {
+			a.openArchive(ref)
+			return
+		}
 		a.setActivePane(paneContents)
+	case kindLoadMore:
+		a.loadMoreBlobs()
+	case kindFolder:
+		a.enterFolder(ref)
 	}
 }
 
@@ -778,14 +1823,43 @@ func (a *App) contentRef(row int) (itemRef, bool) {
 	return a.contentRefs[row], true
 }
 
+// selectedRef returns the item selected in the focused pane. The preview pane
+// reports the contents selection it is showing.
+func (a *App) selectedRef() (itemRef, bool) {
+	switch a.activePane {
+	case paneAccounts:
+		node := a.accounts.GetCurrentNode()
+		if node == nil {
+			return itemRef{}, false
+		}
+		ref, ok := node.GetReference().(itemRef)
+		return ref, ok
+	case paneCompare:
+		row, _ := a.compare.GetSelection()
+		return a.compareRef(row)
+	default:
+		row, _ := a.contents.GetSelection()
+		return a.contentRef(row)
+	}
+}
+
 func (a *App) refreshDetails() {
 	if a.activePane == paneAccounts {
 		a.onTreeChanged(a.accounts.GetCurrentNode())
 		return
 	}
 
-	row, _ := a.contents.GetSelection()
-	ref, ok := a.contentRef(row)
+	var (
+		ref itemRef
+		ok  bool
+	)
+	if a.activePane == paneCompare {
+		row, _ := a.compare.GetSelection()
+		ref, ok = a.compareRef(row)
+	} else {
+		row, _ := a.contents.GetSelection()
+		ref, ok = a.contentRef(row)
+	}
 	if !ok {
 		a.setDetailsText("No selection.")
 		return
@@ -794,113 +1868,172 @@ func (a *App) refreshDetails() {
 }
 
 func (a *App) showEmptyContents(message string) {
+	a.cancelContentsLoad()
 	a.loadingContents = true
 	a.contents.Clear()
 	a.contentRefs = nil
+	a.listedBlobs = nil
+	a.contentSource = itemRef{}
 	ref := itemRef{Kind: kindNone, Name: message}
 	a.addContentRow(ref, ref.Name, "")
 	a.contents.Select(0, 0)
 	a.loadingContents = false
-	a.contents.SetTitle("Contents")
+	a.setPaneTitle(paneContents, "Contents")
 	a.setPreviewContent("Select a blob to preview.", false)
+	a.markDifferences()
 }
 
 func (a *App) showLoadError(scope string, err error) {
+	a.cancelContentsLoad()
 	message := fmt.Sprintf("Error loading %s: %v", scope, err)
 	a.setDetailsText(message)
+	a.flashErr(message)
 	a.setPreviewContent("Unable to load data.", false)
 
 	a.loadingContents = true
 	a.contents.Clear()
 	a.contentRefs = nil
+	a.listedBlobs = nil
+	a.contentSource = itemRef{}
 	ref := itemRef{Kind: kindNone, Name: "Error loading data."}
 	a.addContentRow(ref, ref.Name, "")
 	a.contents.Select(0, 0)
 	a.loadingContents = false
-	a.contents.SetTitle("Contents")
+	a.setPaneTitle(paneContents, "Contents")
+	a.markDifferences()
 }
 
 func (a *App) showSubscriptionsError(err error) {
 	a.loadingTree = true
 	a.root.ClearChildren()
 	ref := itemRef{Kind: kindNone, Name: "Error loading subscriptions."}
-	node := tview.NewTreeNode(ref.Name).SetReference(ref).SetSelectable(true)
+	node := a.newTreeNode(ref.Name, ref)
 	a.root.AddChild(node)
 	a.accounts.SetCurrentNode(node)
 	a.loadingTree = false
 	a.showTreeLoadError("subscriptions", err)
+	a.openPendingSetup()
 }
 
 func (a *App) showTreeLoadError(scope string, err error) {
 	message := fmt.Sprintf("Error loading %s: %v", scope, err)
 	a.setDetailsText(message)
+	a.flashErr(message)
 	a.setPreviewContent("Unable to load data.", false)
 }
 
 func (a *App) updateDetails(ref itemRef) {
-	var text string
+	if ref != a.detailsRef {
+		a.stopDetailsFetch()
+		a.detailsRef = ref
+	}
+	var items []property
 	switch ref.Kind {
 	case kindRoot:
-		text = "Select a subscription to browse accounts."
+		a.setDetailsText("Select a subscription to browse accounts.")
+		return
 	case kindNone:
-		text = ref.Name
+		a.setDetailsText(ref.Name)
+		return
+	case kindLoadMore:
+		a.setDetailsText(fmt.Sprintf("%s\nPress enter to load the next %d blobs, or L to load all.", ref.Name, blobPageSize))
+		return
+	case kindFolder:
+		if ref.Archive != "" && ref.Name == ".." && a.archive != nil && a.archive.prefix == "" {
+			a.setDetailsText(fmt.Sprintf("Leave %s\nPress enter or backspace to go back to the container.", ref.Archive))
+			return
+		}
+		if ref.Name == ".." {
+			a.setDetailsText(fmt.Sprintf("Parent folder: /%s\nPress enter or backspace to go up.", ref.Prefix))
+			return
+		}
+		if ref.Archive != "" {
+			items = a.archiveDetails(ref)
+			break
+		}
+		items = a.folderDetails(ref)
+	case kindEntry:
+		items = a.archiveDetails(ref)
 	case kindSubscription:
 		status := "enabled"
 		if !a.isSubscriptionEnabled(ref.SubscriptionID) {
 			status = "disabled"
 		}
-		text = fmt.Sprintf("Subscription: %s\nStatus: %s", ref.Name, status)
+		items = []property{{"Subscription", ref.Name}, {"Status", status}}
 	case kindAccount:
-		lines := []string{fmt.Sprintf("Account: %s", ref.Name)}
+		items = []property{{"Account", ref.Name}}
 		if ref.SubscriptionName != "" {
-			lines = append(lines, fmt.Sprintf("Subscription: %s", ref.SubscriptionName))
+			items = append(items, property{"Subscription", ref.SubscriptionName})
 		}
 		if ref.Region != "" {
-			lines = append(lines, fmt.Sprintf("Region: %s", ref.Region))
+			items = append(items, property{"Region", ref.Region})
 		}
-		text = strings.Join(lines, "\n")
-	case kindContainer:
-		lines := []string{
-			fmt.Sprintf("Container: %s", ref.Name),
-			fmt.Sprintf("Account: %s", ref.Account),
+		if account, ok := a.accountInfo[ref.Account]; ok {
+			items = append(items, accountDetailItems(account)...)
 		}
+		items = append(items, a.serviceDetailItems(ref)...)
+		items = append(items, a.accountMetricItems(ref)...)
+	case kindContainer:
+		items = []property{{"Container", ref.Name}, {"Account", ref.Account}}
 		if ref.SubscriptionName != "" {
-			lines = append(lines, fmt.Sprintf("Subscription: %s", ref.SubscriptionName))
+			items = append(items, property{"Subscription", ref.SubscriptionName})
 		}
 		if ref.PublicAccess != "" {
-			lines = append(lines, fmt.Sprintf("Public access: %s", ref.PublicAccess))
+			items = append(items, property{"Public access", ref.PublicAccess})
 		}
-		text = strings.Join(lines, "\n")
+		items = append(items, a.containerDetailItems(ref)...)
 	case kindBlob:
-		lines := []string{
-			fmt.Sprintf("Blob: %s", ref.Name),
-			fmt.Sprintf("Account: %s", ref.Account),
-			fmt.Sprintf("Container: %s", ref.Container),
-		}
+		items = []property{{"Blob", ref.Name}, {"Account", ref.Account}, {"Container", ref.Container}}
 		if ref.SubscriptionName != "" {
-			lines = append(lines, fmt.Sprintf("Subscription: %s", ref.SubscriptionName))
+			items = append(items, property{"Subscription", ref.SubscriptionName})
+		}
+		if ref.Tags != "" {
+			// Tag matches carry no size or times.
+			items = append(items, property{"Matched tags", ref.Tags})
+		} else {
+			items = append(items, property{"Size", a.formatSize(ref.SizeBytes)})
+			items = append(items, property{"Modified", a.formatTimestamp(ref.Modified)})
 		}
-		lines = append(lines, fmt.Sprintf("Size: %s", formatBytes(ref.SizeBytes)))
-		lines = append(lines, fmt.Sprintf("Modified: %s", formatTime(ref.Modified)))
 		if ref.ContentType != "" {
-			lines = append(lines, fmt.Sprintf("Content type: %s", ref.ContentType))
+			items = append(items, property{"Content type", ref.ContentType})
 		}
-		text = strings.Join(lines, "\n")
+		items = append(items, a.blobDetailItems(ref)...)
 	default:
-		text = "No selection."
+		a.setDetailsText("No selection.")
+		return
 	}
 
-	a.setDetailsText(text)
+	_, _, width, height := a.details.GetInnerRect()
+	a.showDetailsText(strings.Join(detailLines(items, width, height), "\n"))
+	a.detailItems = items
+}
+
+// detailLines puts each item on its own line while they fit in height lines,
+// and otherwise as many side by side as fit in width.
+func detailLines(items []property, width, height int) []string {
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		text := fmt.Sprintf("%s: %s", item.Name, item.Value)
+		last := len(lines) - 1
+		if len(items) > height && last >= 0 && utf8.RuneCountInString(lines[last]+"  "+text) <= width {
+			lines[last] += "  " + text
+			continue
+		}
+		lines = append(lines, text)
+	}
+	return lines
 }
 
 func (a *App) updatePreview(ref itemRef) {
 	var text string
 	searchable := false
-	switch ref.Kind {
-	case kindBlob:
-		text = previewForBlob(ref)
-		searchable = true
-	case kindNone:
+	switch {
+	case ref.Kind == kindBlob && a.contentSource.Kind == kindAccount:
+		text = fmt.Sprintf("File: %s\nContainer: %s\n\nPress enter to open the blob in its container.", ref.Name, ref.Container)
+	case ref.Kind == kindBlob, ref.Kind == kindEntry:
+		a.previewBlob(ref)
+		return
+	case ref.Kind == kindNone:
 		text = "No preview available."
 	default:
 		text = "Select a blob to preview."
@@ -908,14 +2041,34 @@ func (a *App) updatePreview(ref itemRef) {
 	a.setPreviewContent(text, searchable)
 }
 
-func formatContentDetails(ref itemRef) string {
+func (a *App) formatContentDetails(ref itemRef) string {
+	if ref.Kind == kindFolder && ref.Archive != "" {
+		return a.formatArchiveFolderDetails(ref)
+	}
+	if ref.Kind == kindFolder {
+		return a.formatFolderDetails(ref)
+	}
+	if ref.Kind == kindEntry {
+		return fmt.Sprintf("%s | %s", a.formatSize(ref.SizeBytes), a.formatTimestamp(ref.Modified))
+	}
 	if ref.Kind != kindBlob {
 		return ""
 	}
-	return fmt.Sprintf("%s | %s | %s", ref.ContentType, formatBytes(ref.SizeBytes), formatTime(ref.Modified))
+	return fmt.Sprintf("%s | %s | %s", ref.ContentType, a.formatSize(ref.SizeBytes), a.formatTimestamp(ref.Modified))
 }
 
+// setDetailsText shows a message in Details, stopping the fetches of what it
+// showed before.
 func (a *App) setDetailsText(text string) {
+	a.stopDetailsFetch()
+	a.detailsRef = itemRef{}
+	a.showDetailsText(text)
+}
+
+// showDetailsText sets the text of Details. updateDetails records the
+// name/value items behind the text afterwards so they can be copied as JSON.
+func (a *App) showDetailsText(text string) {
+	a.detailItems = nil
 	if text == a.lastDetails {
 		return
 	}
@@ -932,12 +2085,20 @@ func (a *App) setPreviewText(text string) {
 }
 
 func (a *App) setPreviewContent(text string, searchable bool) {
+	a.stopPreviewFetch()
 	a.previewFull = text
 	a.previewSearchable = searchable
 	a.applyPreviewFilter()
 }
 
+// applySearch filters the preview by term with the options checked in the
+// dialog, which become the defaults of later launches when they change.
 func (a *App) applySearch(term string) {
+	options := prefs.PreviewSearch{CaseSensitive: a.searchCase.IsChecked(), WholeWord: a.searchWord.IsChecked()}
+	if options != a.prefs.PreviewSearch {
+		a.prefs.PreviewSearch = options
+		a.savePrefs()
+	}
 	trimmed := strings.TrimSpace(term)
 	if trimmed == "" {
 		a.clearSearch()
@@ -956,23 +2117,37 @@ func (a *App) clearSearch() {
 }
 
 func (a *App) applyPreviewFilter() {
-	display := a.previewFull
+	display := tview.Escape(a.previewFull)
 	if a.previewSearchable && a.previewSearch != "" {
-		display = filterPreviewText(a.previewFull, a.previewSearch)
+		match := newTextMatcher(a.previewSearch, a.prefs.PreviewSearch)
+		display = highlightMatches(filterPreviewText(a.previewFull, a.previewSearch, match), match, a.theme)
 	}
 	a.setPreviewText(display)
 	a.updatePreviewTitle()
+	a.refreshStatus()
 }
 
 func (a *App) updatePreviewTitle() {
 	title := "Preview"
 	if a.previewSearchable && a.previewSearch != "" {
-		title = fmt.Sprintf("Preview (filter: %s)", a.previewSearch)
+		title = fmt.Sprintf("Preview (filter: %s)", a.previewSearchLabel())
 	}
-	a.preview.SetTitle(title)
+	a.setPaneTitle(panePreview, title)
 }
 
-func filterPreviewText(full, term string) string {
+// previewSearchLabel is the preview search term with the options it uses.
+func (a *App) previewSearchLabel() string {
+	label := a.previewSearch
+	if a.prefs.PreviewSearch.CaseSensitive {
+		label += ", match case"
+	}
+	if a.prefs.PreviewSearch.WholeWord {
+		label += ", whole words"
+	}
+	return label
+}
+
+func filterPreviewText(full, term string, match textMatcher) string {
 	if term == "" {
 		return full
 	}
@@ -990,10 +2165,9 @@ func filterPreviewText(full, term string) string {
 		start = headerEnd + 1
 	}
 
-	lowerTerm := strings.ToLower(term)
 	var matches []string
 	for _, line := range lines[start:] {
-		if strings.Contains(strings.ToLower(line), lowerTerm) {
+		if len(match.indexes(line)) > 0 {
 			matches = append(matches, line)
 		}
 	}
@@ -1015,73 +2189,61 @@ func filterPreviewText(full, term string) string {
 	return builder.String()
 }
 
-func centerModal(primitive tview.Primitive, height, width int) tview.Primitive {
-	row := tview.NewFlex().
-		SetDirection(tview.FlexRow).
-		AddItem(nil, 0, 1, false).
-		AddItem(primitive, height, 0, true).
-		AddItem(nil, 0, 1, false)
-
-	return tview.NewFlex().
-		AddItem(nil, 0, 1, false).
-		AddItem(row, width, 0, true).
-		AddItem(nil, 0, 1, false)
+// textMatcher finds a search term in text, ignoring case unless asked to
+// match it, and optionally only where the term is a whole word.
+type textMatcher struct {
+	re        *regexp.Regexp
+	wholeWord bool
 }
 
-func previewForBlob(ref itemRef) string {
-	header := fmt.Sprintf("File: %s\nContent-Type: %s\nSize: %s\n\n", ref.Name, ref.ContentType, formatBytes(ref.SizeBytes))
-	switch ref.ContentType {
-	case "text/plain":
-		return header + sampleTextPreview(ref.Name)
-	case "text/html":
-		return header + sampleHTMLPreview(ref.Name)
-	case "image/jpeg", "image/svg+xml":
-		return header + "Binary content preview not available."
-	default:
-		return header + "Preview not available for this content type."
+func newTextMatcher(term string, options prefs.PreviewSearch) textMatcher {
+	expression := regexp.QuoteMeta(term)
+	if !options.CaseSensitive {
+		expression = "(?i)" + expression
 	}
+	return textMatcher{re: regexp.MustCompile(expression), wholeWord: options.WholeWord}
 }
 
-func sampleTextPreview(name string) string {
-	switch {
-	case strings.HasSuffix(name, ".log"):
-		return "2024-05-11T03:12:00Z INFO job=ingest msg=\"started\"\n2024-05-11T03:12:02Z INFO job=ingest msg=\"completed\"\n"
-	case name == "robots.txt":
-		return "User-agent: *\nDisallow: /private\n"
-	default:
-		return fmt.Sprintf("Preview placeholder for %s.\n", name)
+// indexes returns the byte ranges of the matches in text.
+func (m textMatcher) indexes(text string) [][]int {
+	spans := m.re.FindAllStringIndex(text, -1)
+	if !m.wholeWord {
+		return spans
+	}
+	words := spans[:0]
+	for _, span := range spans {
+		before, _ := utf8.DecodeLastRuneInString(text[:span[0]])
+		after, _ := utf8.DecodeRuneInString(text[span[1]:])
+		if !wordRune(before) && !wordRune(after) {
+			words = append(words, span)
+		}
 	}
+	return words
 }
 
-func sampleHTMLPreview(name string) string {
-	if name == "index.html" {
-		return "<!doctype html>\n<html>\n  <head>\n    <title>Storage Preview</title>\n  </head>\n  <body>\n    <h1>Hello from storage-tui</h1>\n    <p>This is mock HTML content.</p>\n  </body>\n</html>\n"
-	}
-	return "<!doctype html>\n<html>\n  <body>\n    <p>Mock HTML preview.</p>\n  </body>\n</html>\n"
+func wordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
 }
 
-func formatTime(value time.Time) string {
-	if value.IsZero() {
-		return "n/a"
+// savePrefs writes the preferences for later launches.
+func (a *App) savePrefs() {
+	if a.prefsPath == "" {
+		return
+	}
+	if err := prefs.Save(a.prefsPath, a.prefs); err != nil {
+		a.flashErr(fmt.Sprintf("Error saving preferences: %v", err))
 	}
-	return value.UTC().Format(time.RFC3339)
 }
 
-func formatBytes(value int64) string {
-	const (
-		kb = 1024
-		mb = 1024 * kb
-		gb = 1024 * mb
-	)
+func centerModal(primitive tview.Primitive, height, width int) tview.Primitive {
+	row := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(nil, 0, 1, false).
+		AddItem(primitive, height, 0, true).
+		AddItem(nil, 0, 1, false)
 
-	switch {
-	case value >= gb:
-		return fmt.Sprintf("%.2f GB", float64(value)/float64(gb))
-	case value >= mb:
-		return fmt.Sprintf("%.2f MB", float64(value)/float64(mb))
-	case value >= kb:
-		return fmt.Sprintf("%.2f KB", float64(value)/float64(kb))
-	default:
-		return fmt.Sprintf("%d B", value)
-	}
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(row, width, 0, true).
+		AddItem(nil, 0, 1, false)
 }
@@ -0,0 +1,302 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"storage-tui/internal/app"
+	"storage-tui/internal/app/apptest"
+	"storage-tui/internal/audit"
+	"storage-tui/internal/azure"
+	"storage-tui/internal/syncjobs"
+)
+
+// startAt runs the browser on the mock data, listing account/container.
+func startAt(t *testing.T, provider azure.Provider, account, container string) *apptest.Harness {
+	t.Helper()
+	h := apptest.Start(t, provider, app.Options{Start: app.StartLocation{Account: account, Container: container}})
+	h.WaitFor("Contents: " + account + "/" + container)
+	return h
+}
+
+func TestStartLocationListsContainer(t *testing.T) {
+	h := startAt(t, azure.NewMockProvider(), "acme-dev", "site")
+	h.WaitForLine("docs/guide/usage.html", "12.57 KB")
+	h.WaitFor("Blob: css/main.css")
+}
+
+func TestFolderNavigation(t *testing.T) {
+	h := startAt(t, azure.NewMockProvider(), "acme-dev", "site")
+	h.WaitFor("index.html")
+
+	h.Press("F")
+	h.WaitForLine("docs/", "3 blobs")
+	h.Press("down", "enter")
+	h.WaitFor("Contents: acme-dev/site/docs/")
+	h.WaitForLine("guide/", "2 blobs")
+
+	h.Press("backspace")
+	h.WaitFor("Contents: acme-dev/site/")
+	h.WaitFor("Folder: docs/")
+}
+
+func TestTypeAheadInTree(t *testing.T) {
+	h := apptest.Start(t, azure.NewMockProvider(), app.Options{})
+	h.WaitFor("Development")
+	h.Press("down", "enter")
+	h.WaitFor("telemetry")
+
+	// Bound letters go to the jump once / opens it, so a doesn't open the
+	// audit log and q doesn't quit.
+	h.Press("/")
+	h.Type("acme-p")
+	h.WaitFor("Account: acme-prod")
+	if h.Contains("Audit log") {
+		t.Fatalf("typing a name opened the audit log:\n%s", h.Text())
+	}
+	h.Press("/")
+	h.Type("q")
+	h.WaitFor("Jump: no match")
+	h.Press("esc")
+	h.WaitFor("Jump canceled.")
+}
+
+func TestFilterByName(t *testing.T) {
+	h := startAt(t, azure.NewMockProvider(), "acme-dev", "site")
+	h.WaitFor("css/main.css")
+
+	h.Press("/")
+	h.Type("guide")
+	h.WaitFor("Filter blobs  2 of 9 shown")
+	h.Press("enter")
+	h.WaitForGone("Filter blobs")
+	h.WaitFor("docs/guide/install.html", "docs/guide/usage.html")
+	h.WaitForGone("css/main.css")
+}
+
+func TestFindBlobsAcrossContainers(t *testing.T) {
+	h := startAt(t, azure.NewMockProvider(), "acme-dev", "site")
+
+	h.Press("f")
+	h.Type("usage")
+	h.Press("enter")
+	h.WaitFor("1 matches", "(done)")
+	h.WaitForLine("docs/guide/usage.html", "acme-dev/site")
+
+	h.Press("esc")
+	h.WaitForGone("Find blobs")
+}
+
+// failingListing fails to list blobs until it is told to recover.
+type failingListing struct {
+	*azure.MockProvider
+	recovered atomic.Bool
+}
+
+func (p *failingListing) ListBlobsPage(ctx context.Context, account, container string, opts azure.ListBlobsOptions) (azure.BlobPage, error) {
+	if !p.recovered.Load() {
+		return azure.BlobPage{}, errors.New("connection reset by peer")
+	}
+	return p.MockProvider.ListBlobsPage(ctx, account, container, opts)
+}
+
+func TestListingErrorIsRetriedFromFailures(t *testing.T) {
+	provider := &failingListing{MockProvider: azure.NewMockProvider()}
+	h := apptest.Start(t, provider, app.Options{Start: app.StartLocation{Account: "acme-dev", Container: "site"}})
+	h.WaitFor("Error loading blobs: connection reset by peer")
+
+	h.Press("!")
+	h.WaitForLine("list blobs", "az://acme-dev/site", "connection reset by peer")
+
+	provider.recovered.Store(true)
+	h.Press("enter")
+	h.WaitForGone("Failures")
+	h.WaitForLine("docs/guide/usage.html", "12.57 KB")
+}
+
+func TestProviderCallsPage(t *testing.T) {
+	h := startAt(t, azure.NewMockProvider(), "acme-dev", "site")
+	h.WaitFor("index.html")
+
+	h.Press("D")
+	h.WaitForLine("list blobs page", "account=acme-dev container=site", "9 listed")
+	h.WaitForLine("list subscriptions", "2 listed")
+
+	h.Press("esc")
+	h.WaitForGone("Provider calls")
+}
+
+func TestDemoRunsThrough(t *testing.T) {
+	h := apptest.Start(t, azure.NewMockProvider(), app.Options{Demo: true, DemoPace: 20 * time.Millisecond})
+	// Only the end of the script quits, so the demo got through it.
+	if err := h.Wait(); err != nil {
+		t.Fatalf("demo: %v", err)
+	}
+}
+
+func TestDemoFailsOnFailedOperation(t *testing.T) {
+	provider := &failingListing{MockProvider: azure.NewMockProvider()}
+	h := apptest.Start(t, provider, app.Options{Demo: true, DemoPace: 20 * time.Millisecond})
+	err := h.Wait()
+	if err == nil || !strings.Contains(err.Error(), "connection reset by peer") {
+		t.Fatalf("demo error = %v, want the failed listing", err)
+	}
+}
+
+func TestUsageMetricsAreOptIn(t *testing.T) {
+	t.Setenv("DO_NOT_TRACK", "")
+	kind := func(app.Profile) string { return "mock" }
+	h := apptest.Start(t, azure.NewMockProvider(), app.Options{ProviderKind: kind, Start: app.StartLocation{Account: "acme-dev", Container: "site"}})
+	h.WaitFor("Contents: acme-dev/site")
+	if h.Contains("usage metrics on") {
+		t.Fatalf("usage metrics on without being turned on:\n%s", h.Text())
+	}
+
+	h.Press("U")
+	h.WaitFor("Usage metrics are off")
+	h.Press("u")
+	h.WaitFor("Usage metrics are on", `"mock": 1`)
+	h.Press("esc")
+	h.WaitFor("usage metrics on (U)")
+
+	h.Press("tab", "F")
+	h.WaitFor("Folder view on.")
+	h.Stop()
+
+	data, err := os.ReadFile(filepath.Join(os.Getenv("XDG_CACHE_HOME"), "storage-tui", "usage.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := string(data)
+	for _, want := range []string{`"preview": 1`, `"Contents: toggle virtual folders with blob counts and sizes": 1`} {
+		if !strings.Contains(counts, want) {
+			t.Errorf("usage counts lack %s:\n%s", want, counts)
+		}
+	}
+	for _, name := range []string{"acme-dev", "site", "index.html"} {
+		if strings.Contains(counts, name) {
+			t.Errorf("usage counts name %q:\n%s", name, counts)
+		}
+	}
+}
+
+func TestUsageMetricsTurnOff(t *testing.T) {
+	t.Setenv("DO_NOT_TRACK", "")
+	h := startAt(t, azure.NewMockProvider(), "acme-dev", "site")
+	h.Press("U", "u", "esc")
+	h.WaitFor("usage metrics on (U)")
+
+	h.Press("U", "u")
+	h.WaitFor("Usage metrics are off")
+	h.Press("esc")
+	h.WaitForGone("usage metrics on (U)")
+	h.Stop()
+
+	path := filepath.Join(os.Getenv("XDG_CACHE_HOME"), "storage-tui", "usage.json")
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("usage counts kept after turning metrics off: %v", err)
+	}
+}
+
+func TestSyncJobPlans(t *testing.T) {
+	dir := t.TempDir()
+	job := syncjobs.Job{Name: "docs", Source: "az://acme-dev/site/docs", Destination: filepath.Join(dir, "docs"), Every: time.Hour}
+	h := apptest.Start(t, azure.NewMockProvider(), app.Options{
+		Start:    app.StartLocation{Account: "acme-dev", Container: "site"},
+		SyncJobs: []syncjobs.Job{job},
+	})
+	h.WaitForLine("docs/guide/usage.html", "12.57 KB")
+	h.Press("J")
+	h.WaitForLine("docs", "3 to download")
+
+	// The last run's plan lists what it downloaded.
+	h.Press("p")
+	h.WaitFor("Plan of sync job docs, run of")
+	h.WaitForLine("download", "new", "guide/usage.html")
+
+	plan := filepath.Join(dir, "plan.json")
+	h.Press("ctrl-u")
+	h.Type(plan)
+	h.Press("enter")
+	h.WaitFor("Wrote the plan's 3 actions to")
+	data, err := os.ReadFile(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"name": "guide/usage.html"`) {
+		t.Errorf("exported plan:\n%s", data)
+	}
+
+	// A dry run plans against the files the run left; the mock lists sizes
+	// its content does not have, so each is planned again.
+	h.Press("d")
+	h.WaitFor("Plan of sync job docs, dry run")
+	h.WaitFor("size differs")
+	h.Press("esc")
+	h.WaitForGone("Plan of sync job docs")
+}
+
+func TestUndoSyncJob(t *testing.T) {
+	ctx := context.Background()
+	provider := azure.NewMockProvider()
+	for name, content := range map[string]string{"undo/a.txt": "old a", "undo/gone.txt": "gone"} {
+		if _, err := provider.UploadBlob(ctx, "acme-prod", "public", name, strings.NewReader(content), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir := t.TempDir()
+	local := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(local, []byte("new a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(local, later, later); err != nil {
+		t.Fatal(err)
+	}
+	log, err := audit.Open(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { log.Close() })
+	job := syncjobs.Job{Name: "up", Source: dir, Destination: "az://acme-prod/public/undo", Every: time.Hour, Delete: true}
+	h := apptest.Start(t, provider, app.Options{
+		Start:    app.StartLocation{Account: "acme-prod", Container: "public"},
+		SyncJobs: []syncjobs.Job{job},
+		Audit:    log,
+	})
+	// acme-prod keeps previous versions and deleted blobs, so the run's
+	// overwrite and delete can be undone.
+	h.WaitFor("u: undo sync job up")
+	h.Press("u")
+	h.WaitFor("Undid sync job up")
+	for name, want := range map[string]string{"undo/a.txt": "old a", "undo/gone.txt": "gone"} {
+		reader, err := provider.OpenBlob(ctx, "acme-prod", "public", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(reader)
+		reader.Close()
+		if string(data) != want {
+			t.Errorf("%s holds %q after the undo, want %q", name, data, want)
+		}
+	}
+	h.Press("u")
+	h.WaitFor("Nothing to undo")
+
+	// The audit log offers the same for the sync, after asking.
+	h.Press("a")
+	h.WaitForLine("restore", "az://acme-prod/public", "undo of sync job up")
+	h.Press("u")
+	h.WaitFor("This entry names no blobs")
+	h.Press("down", "u")
+	h.WaitFor("Undo the sync of")
+	h.Press("esc")
+	h.WaitForGone("Undo the sync of")
+}
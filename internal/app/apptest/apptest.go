@@ -0,0 +1,242 @@
+// Package apptest runs the browser on a simulated screen, so tests can press
+// keys and check what it draws, end to end, without a terminal.
+package apptest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"storage-tui/internal/app"
+	"storage-tui/internal/azure"
+)
+
+// Width and Height are the size of the simulated screen Start makes.
+const (
+	Width  = 120
+	Height = 40
+)
+
+// Timeout is how long the Wait helpers wait before failing the test.
+var Timeout = 5 * time.Second
+
+// pollInterval is how often the Wait helpers look at the screen.
+const pollInterval = 10 * time.Millisecond
+
+// Harness is a running browser on a simulated screen.
+type Harness struct {
+	t      testing.TB
+	App    *app.App
+	Screen tcell.SimulationScreen
+	done   chan error
+	closed bool
+}
+
+// Start runs the browser on provider with opts, on a simulated screen of
+// Width by Height cells, until the test ends. The user config and cache
+// directories point into a temporary directory, so the session, bookmarks,
+// and preferences of the test neither read nor change the user's.
+func Start(t testing.TB, provider azure.Provider, opts app.Options) *Harness {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home+"/config")
+	t.Setenv("XDG_CACHE_HOME", home+"/cache")
+
+	// The browser initializes the screen, which sets it to 80 by 25, so
+	// it is sized afterwards.
+	screen := tcell.NewSimulationScreen("UTF-8")
+	h := &Harness{
+		t:      t,
+		App:    app.NewWithScreen(provider, opts, screen),
+		Screen: screen,
+		done:   make(chan error, 1),
+	}
+	screen.SetSize(Width, Height)
+	go func() {
+		h.done <- h.App.Run()
+	}()
+	t.Cleanup(h.Stop)
+	return h
+}
+
+// Stop ends the browser and waits for Run to return, failing the test on
+// its error. Start has it called when the test ends.
+func (h *Harness) Stop() {
+	if h.closed {
+		return
+	}
+	h.closed = true
+	h.App.Stop()
+	select {
+	case err := <-h.done:
+		if err != nil {
+			h.t.Errorf("run: %v", err)
+		}
+	case <-time.After(Timeout):
+		h.t.Errorf("run did not return within %s of stopping", Timeout)
+	}
+}
+
+// Wait waits for the browser to quit by itself, such as at the end of a
+// demo, and returns the error of Run.
+func (h *Harness) Wait() error {
+	h.t.Helper()
+	select {
+	case err := <-h.done:
+		h.closed = true
+		return err
+	case <-time.After(Timeout):
+		h.t.Fatalf("run did not return within %s", Timeout)
+		return nil
+	}
+}
+
+// Press presses keys in order, each a single character, such as "j" or
+// "/", or a key name, such as "enter", "esc", "ctrl-f", or "f5", as the
+// help writes them.
+func (h *Harness) Press(keys ...string) {
+	h.t.Helper()
+	for _, name := range keys {
+		key, ch, mod, err := parseKey(name)
+		if err != nil {
+			h.t.Fatal(err)
+		}
+		h.Screen.InjectKey(key, ch, mod)
+	}
+}
+
+// Type types text, one key a character, such as into a dialog's field.
+func (h *Harness) Type(text string) {
+	for _, ch := range text {
+		h.Screen.InjectKey(tcell.KeyRune, ch, tcell.ModNone)
+	}
+}
+
+// parseKey reads a key as Press takes it. Key names are matched to tcell's
+// ignoring case; a few are spelled as the help spells them.
+func parseKey(name string) (tcell.Key, rune, tcell.ModMask, error) {
+	if runes := []rune(name); len(runes) == 1 {
+		return tcell.KeyRune, runes[0], tcell.ModNone, nil
+	}
+	switch strings.ToLower(name) {
+	case "shift-tab":
+		return tcell.KeyBacktab, 0, tcell.ModShift, nil
+	case "space":
+		return tcell.KeyRune, ' ', tcell.ModNone, nil
+	}
+	for key, keyName := range tcell.KeyNames {
+		if strings.EqualFold(keyName, name) {
+			mod := tcell.ModNone
+			if key >= tcell.KeyCtrlA && key <= tcell.KeyCtrlZ {
+				mod = tcell.ModCtrl
+			}
+			return key, 0, mod, nil
+		}
+	}
+	return 0, 0, 0, fmt.Errorf("apptest: unknown key %q", name)
+}
+
+// Lines returns the screen as last drawn, one string a row, with trailing
+// blanks trimmed. The screen is read on the UI goroutine, as draws write it
+// in place.
+func (h *Harness) Lines() []string {
+	var cells []tcell.SimCell
+	var width, height int
+	read := func() {
+		cells, width, height = h.Screen.GetContents()
+		cells = append([]tcell.SimCell(nil), cells...)
+	}
+	if h.closed {
+		read()
+	} else {
+		h.App.QueueUpdate(read)
+	}
+	lines := make([]string, height)
+	for y := range height {
+		var line strings.Builder
+		for x := range width {
+			cell := cells[y*width+x]
+			if len(cell.Runes) == 0 {
+				line.WriteByte(' ')
+				continue
+			}
+			line.WriteString(string(cell.Runes))
+		}
+		lines[y] = strings.TrimRight(line.String(), " ")
+	}
+	return lines
+}
+
+// Text returns the screen as last drawn, its rows joined by newlines.
+func (h *Harness) Text() string {
+	return strings.Join(h.Lines(), "\n")
+}
+
+// Contains reports whether the screen shows text, within a row.
+func (h *Harness) Contains(text string) bool {
+	for _, line := range h.Lines() {
+		if strings.Contains(line, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// WaitFor waits until the screen shows each of texts, failing the test with
+// the screen when it does not within Timeout. Calls run in the background,
+// so this is how tests wait for what they started.
+func (h *Harness) WaitFor(texts ...string) {
+	h.t.Helper()
+	h.waitUntil(fmt.Sprintf("screen to show %q", texts), func() bool {
+		for _, text := range texts {
+			if !h.Contains(text) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// WaitForGone waits until the screen no longer shows text.
+func (h *Harness) WaitForGone(text string) {
+	h.t.Helper()
+	h.waitUntil(fmt.Sprintf("screen to stop showing %q", text), func() bool {
+		return !h.Contains(text)
+	})
+}
+
+// WaitForLine waits until a row of the screen holds all of texts, such as a
+// blob's name and its size on the same row.
+func (h *Harness) WaitForLine(texts ...string) {
+	h.t.Helper()
+	h.waitUntil(fmt.Sprintf("a row to show %q", texts), func() bool {
+		for _, line := range h.Lines() {
+			found := true
+			for _, text := range texts {
+				if !strings.Contains(line, text) {
+					found = false
+					break
+				}
+			}
+			if found {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (h *Harness) waitUntil(what string, done func() bool) {
+	h.t.Helper()
+	deadline := time.Now().Add(Timeout)
+	for !done() {
+		if time.Now().After(deadline) {
+			h.t.Fatalf("timed out after %s waiting for the %s:\n%s", Timeout, what, h.Text())
+		}
+		time.Sleep(pollInterval)
+	}
+}
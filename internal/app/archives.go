@@ -0,0 +1,312 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"storage-tui/internal/archive"
+)
+
+// archiveView is an archive blob opened in the contents table, with the
+// folder of it being shown.
+type archiveView struct {
+	blob   itemRef
+	reader *archive.Archive
+	prefix string
+}
+
+// openArchive lists the files of an archive blob in the background and
+// shows them in the contents table like folders. Reaching another container
+// closes it.
+func (a *App) openArchive(ref itemRef) {
+	container := a.contentSource
+	a.flash(fmt.Sprintf("Reading %s…", ref.Name))
+	runAsyncWith(a, a.contentsCtx, paneContents, func(ctx context.Context) (*archive.Archive, error) {
+		return archive.Open(ctx, a.provider, ref.Account, ref.Container, ref.Name, ref.SizeBytes)
+	}, func(reader *archive.Archive, err error) {
+		if !sameContainer(a.contentSource, container) {
+			return
+		}
+		if err != nil {
+			a.recordFailure("open archive", refTarget(ref), err, func() { a.openArchive(ref) })
+			a.flashErr(fmt.Sprintf("Error opening archive: %v", err))
+			return
+		}
+		a.archive = &archiveView{blob: ref, reader: reader}
+		a.renderContents(false)
+		a.flash(fmt.Sprintf("%d files in %s; backspace at the top leaves it.", len(reader.Entries), ref.Name))
+	})
+}
+
+// closeArchive goes back to the listing the archive was opened from and
+// selects the archive blob.
+func (a *App) closeArchive() {
+	blob := a.archive.blob
+	a.archive = nil
+	a.renderContents(false)
+	for row, ref := range a.contentRefs {
+		if ref.Kind == kindBlob && ref.Name == blob.Name {
+			a.contents.Select(row, 0)
+			break
+		}
+	}
+}
+
+// archiveUp goes to the parent folder of the archive, or out of it from its
+// top, selecting the folder just left.
+func (a *App) archiveUp() {
+	left := a.archive.prefix
+	if left == "" {
+		a.closeArchive()
+		return
+	}
+	a.archive.prefix = parentPrefix(left)
+	a.renderContents(false)
+	for row, ref := range a.contentRefs {
+		if ref.Kind == kindFolder && ref.Prefix == left && ref.Name != ".." {
+			a.contents.Select(row, 0)
+			break
+		}
+	}
+}
+
+// addArchiveRows adds the rows of the open archive folder: a parent row,
+// which leaves the archive from its top, the sub-folders, then the files.
+func (a *App) addArchiveRows() {
+	view := a.archive
+	parent := a.archiveFolderRef(parentPrefix(view.prefix))
+	parent.Name = ".."
+	a.addContentRow(parent, parent.Name, "")
+
+	var folders []string
+	seen := make(map[string]bool)
+	var files []itemRef
+	for _, entry := range view.reader.Entries {
+		rest, ok := strings.CutPrefix(entry.Name, view.prefix)
+		if !ok {
+			continue
+		}
+		if index := strings.Index(rest, "/"); index >= 0 {
+			folder := view.prefix + rest[:index+1]
+			if !seen[folder] {
+				seen[folder] = true
+				folders = append(folders, folder)
+			}
+			continue
+		}
+		files = append(files, a.entryRef(entry))
+	}
+	sort.Strings(folders)
+	for _, folder := range folders {
+		ref := a.archiveFolderRef(folder)
+		a.addContentRow(ref, a.itemLabel(ref), a.formatContentDetails(ref))
+	}
+	for _, ref := range files {
+		relative := ref
+		relative.Name = strings.TrimPrefix(ref.Name, view.prefix)
+		a.addContentRow(ref, a.itemLabel(relative), a.formatContentDetails(ref))
+	}
+}
+
+func (a *App) archiveFolderRef(prefix string) itemRef {
+	ref := folderRef(a.archive.blob, prefix)
+	ref.Archive = a.archive.blob.Name
+	return ref
+}
+
+// entryRef is a file of the open archive. It carries the archive's ETag, so
+// its cached preview goes when the archive changes.
+func (a *App) entryRef(entry archive.Entry) itemRef {
+	blob := a.archive.blob
+	contentType := mime.TypeByExtension(path.Ext(entry.Name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return itemRef{
+		Kind:             kindEntry,
+		Name:             entry.Name,
+		SubscriptionID:   blob.SubscriptionID,
+		SubscriptionName: blob.SubscriptionName,
+		Account:          blob.Account,
+		Container:        blob.Container,
+		SizeBytes:        entry.Size,
+		Modified:         entry.Modified,
+		ContentType:      contentType,
+		ETag:             blob.ETag,
+		Archive:          blob.Name,
+	}
+}
+
+// archiveFolderStat totals the files under prefix in the open archive.
+func (a *App) archiveFolderStat(prefix string) folderStat {
+	var stat folderStat
+	for _, entry := range a.archive.reader.Entries {
+		if strings.HasPrefix(entry.Name, prefix) {
+			stat.Count++
+			stat.Bytes += entry.Size
+		}
+	}
+	return stat
+}
+
+func (a *App) formatArchiveFolderDetails(ref itemRef) string {
+	if ref.Name == ".." || a.archive == nil {
+		return ""
+	}
+	stat := a.archiveFolderStat(ref.Prefix)
+	return fmt.Sprintf("%s files | %s", groupDigits(int64(stat.Count)), a.formatSize(stat.Bytes))
+}
+
+func (a *App) archiveDetails(ref itemRef) []property {
+	if ref.Kind == kindEntry {
+		return []property{
+			{"File", ref.Name},
+			{"Archive", ref.Archive},
+			{"Account", ref.Account},
+			{"Container", ref.Container},
+			{"Size", a.formatSize(ref.SizeBytes)},
+			{"Modified", a.formatTimestamp(ref.Modified)},
+		}
+	}
+	items := []property{{"Folder", ref.Prefix}, {"Archive", ref.Archive}, {"Account", ref.Account}, {"Container", ref.Container}}
+	if a.archive != nil {
+		stat := a.archiveFolderStat(ref.Prefix)
+		items = append(items, property{"Files", groupDigits(int64(stat.Count))}, property{"Total size", a.formatSize(stat.Bytes)})
+	}
+	return items
+}
+
+// contentName names a blob, or an archive file as archive!/file.
+func contentName(ref itemRef) string {
+	if ref.Archive != "" {
+		return ref.Archive + "!/" + ref.Name
+	}
+	return ref.Name
+}
+
+// contentOpener returns how to read a blob, or a file of the open archive,
+// from a background goroutine.
+func (a *App) contentOpener(ref itemRef) func(ctx context.Context) (io.ReadCloser, error) {
+	if ref.Kind != kindEntry {
+		return func(ctx context.Context) (io.ReadCloser, error) {
+			return a.provider.OpenBlob(ctx, ref.Account, ref.Container, ref.Name)
+		}
+	}
+	if a.archive == nil || a.archive.blob.Name != ref.Archive || !sameContainer(a.archive.blob, ref) {
+		return func(context.Context) (io.ReadCloser, error) {
+			return nil, fmt.Errorf("archive %s is no longer open", ref.Archive)
+		}
+	}
+	reader := a.archive.reader
+	return func(ctx context.Context) (io.ReadCloser, error) {
+		return reader.OpenEntry(ctx, ref.Name)
+	}
+}
+
+func (a *App) setupSaveModal() {
+	input := tview.NewInputField().
+		SetLabel("File: ").
+		SetFieldWidth(0)
+	form := tview.NewForm().
+		AddFormItem(input).
+		AddButton("Save", func() {
+			a.applySave(input.GetText())
+		}).
+		AddButton("Cancel", a.closeSaveModal)
+	form.SetBorder(true)
+	form.SetButtonsAlign(tview.AlignRight)
+	form.SetCancelFunc(a.closeSaveModal)
+
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEnter:
+			a.applySave(input.GetText())
+			return nil
+		case tcell.KeyEsc:
+			a.closeSaveModal()
+			return nil
+		}
+		return event
+	})
+
+	a.saveForm = form
+	a.saveInput = input
+	a.pages.AddPage("save", centerModal(form, 7, 76), true, false)
+}
+
+// openSaveModal asks where to save the selected blob, or archive file, on
+// disk.
+func (a *App) openSaveModal() {
+	ref, ok := a.selectedRef()
+	if !ok || (ref.Kind != kindBlob && ref.Kind != kindEntry) {
+		a.flashErr("Select a blob, or a file in an archive, to save it.")
+		return
+	}
+	a.saveRef = ref
+	a.saveOpen = true
+	a.saveForm.SetTitle(fmt.Sprintf("Save %s/%s/%s to", ref.Account, ref.Container, contentName(ref)))
+	a.saveInput.SetText(path.Base(ref.Name))
+	a.pages.ShowPage("save")
+	a.app.SetFocus(a.saveInput)
+}
+
+func (a *App) closeSaveModal() {
+	a.pages.HidePage("save")
+	a.saveOpen = false
+	a.setActivePane(a.activePane)
+}
+
+func (a *App) applySave(file string) {
+	file = strings.TrimSpace(file)
+	if file == "" {
+		a.flashErr("Name the file to save to.")
+		return
+	}
+	a.closeSaveModal()
+	a.saveContent(a.saveRef, file)
+}
+
+// saveContent writes the blob or archive file to file in the background. A
+// file that exists already is left alone.
+func (a *App) saveContent(ref itemRef, file string) {
+	open := a.contentOpener(ref)
+	a.flash(fmt.Sprintf("Saving %s…", contentName(ref)))
+	runAsync(a, paneContents, func(ctx context.Context) (int64, error) {
+		reader, err := open(ctx)
+		if err != nil {
+			return 0, err
+		}
+		defer reader.Close()
+		out, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return 0, err
+		}
+		written, err := io.Copy(out, reader)
+		err = errors.Join(err, out.Close())
+		if err != nil {
+			os.Remove(file)
+		}
+		return written, err
+	}, func(written int64, err error) {
+		if errors.Is(err, os.ErrExist) {
+			a.flashErr(fmt.Sprintf("%s exists already; pick another name.", file))
+			return
+		}
+		if err != nil {
+			a.recordFailure("save", refTarget(ref), err, func() { a.saveContent(ref, file) })
+			a.flashErr(fmt.Sprintf("Save failed: %v", err))
+			return
+		}
+		a.flash(fmt.Sprintf("Saved %s to %s (%s).", contentName(ref), file, a.formatSize(written)))
+	})
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+const (
+	spinnerInterval = 100 * time.Millisecond
+	// maxDrawsPerSecond caps how often updates queued with queueUpdate
+	// redraw the screen.
+	maxDrawsPerSecond = 30
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// runAsync runs fetch on a background goroutine while the target pane shows a
+// spinner, then hands the result to apply on the UI goroutine. fetch must not
+// touch widgets or App state that the UI goroutine mutates.
+func runAsync[T any](a *App, target pane, fetch func(ctx context.Context) (T, error), apply func(T, error)) {
+	runAsyncWith(a, context.Background(), target, fetch, apply)
+}
+
+// runAsyncWith is runAsync with fetch bound to ctx, so canceling ctx stops
+// the request. apply still runs, with whatever fetch returned.
+func runAsyncWith[T any](a *App, ctx context.Context, target pane, fetch func(ctx context.Context) (T, error), apply func(T, error)) {
+	a.startLoading(target)
+	go func() {
+		result, err := fetch(ctx)
+		a.app.QueueUpdateDraw(func() {
+			a.stopLoading(target)
+			apply(result, err)
+		})
+	}()
+}
+
+func (a *App) startLoading(target pane) {
+	a.loading[target]++
+	a.renderPaneTitle(target)
+	if a.spinnerStop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	a.spinnerStop = stop
+	go func() {
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				a.queueUpdate(a.advanceSpinner)
+			}
+		}
+	}()
+}
+
+func (a *App) stopLoading(target pane) {
+	if a.loading[target] > 0 {
+		a.loading[target]--
+	}
+	a.renderPaneTitle(target)
+	for _, count := range a.loading {
+		if count > 0 {
+			return
+		}
+	}
+	if a.spinnerStop != nil {
+		close(a.spinnerStop)
+		a.spinnerStop = nil
+	}
+}
+
+func (a *App) advanceSpinner() {
+	a.spinnerFrame = (a.spinnerFrame + 1) % len(spinnerFrames)
+	for target, count := range a.loading {
+		if count > 0 {
+			a.renderPaneTitle(target)
+		}
+	}
+}
+
+// setPaneTitle records the pane's title; the spinner is appended while the
+// pane is loading.
+func (a *App) setPaneTitle(target pane, title string) {
+	a.paneTitles[target] = title
+	a.renderPaneTitle(target)
+}
+
+func (a *App) renderPaneTitle(target pane) {
+	box := a.paneBox(target)
+	if box == nil {
+		return
+	}
+	title := a.paneTitles[target]
+	if a.loading[target] > 0 {
+		title += " " + spinnerFrames[a.spinnerFrame]
+	}
+	box.SetTitle(title)
+}
+
+func (a *App) paneBox(target pane) *tview.Box {
+	switch target {
+	case paneAccounts:
+		return a.accounts.Box
+	case paneContents:
+		return a.contents.Box
+	case paneCompare:
+		return a.compare.Box
+	case panePreview:
+		return a.preview.Box
+	}
+	return nil
+}
+
+// queueUpdate runs f on the UI goroutine like QueueUpdateDraw, but redraws
+// at most maxDrawsPerSecond times however many updates arrive, so streamed
+// pages, matches, and progress ticks are drawn in batches rather than each
+// on its own. Like QueueUpdateDraw, it must not be called on the UI
+// goroutine.
+func (a *App) queueUpdate(f func()) {
+	a.app.QueueUpdate(func() {
+		f()
+		a.scheduleDraw()
+	})
+}
+
+// scheduleDraw draws once a frame of maxDrawsPerSecond has passed since the
+// last draw, unless a draw is already due.
+func (a *App) scheduleDraw() {
+	if a.drawPending {
+		return
+	}
+	a.drawPending = true
+	delay := time.Second/maxDrawsPerSecond - time.Since(a.lastDraw)
+	time.AfterFunc(max(delay, 0), func() {
+		a.app.QueueUpdateDraw(func() {
+			a.drawPending = false
+		})
+	})
+}
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"storage-tui/internal/audit"
+)
+
+// auditEntries is what reading the audit file found.
+type auditEntries struct {
+	entries []audit.Entry
+	skipped int
+}
+
+func (a *App) setupAuditModal() {
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true)
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc || event.Rune() == 'a' || event.Rune() == 'q':
+			a.closeAuditModal()
+			return nil
+		case event.Rune() == 'u':
+			row, _ := table.GetSelection()
+			if row >= 1 && row <= len(a.auditEntries) {
+				a.confirmRestore(a.auditEntries[len(a.auditEntries)-row])
+			}
+			return nil
+		}
+		return event
+	})
+
+	a.auditView = table
+	a.pages.AddPage("audit", centerModal(table, 26, 120), true, false)
+}
+
+// openAuditModal shows the audit log, latest entry first: every upload,
+// signed URL, and change of settings, who made it, when, and how it ended.
+func (a *App) openAuditModal() {
+	if a.audit == nil {
+		a.flashErr("The audit log is unavailable; see the log viewer (O).")
+		return
+	}
+	path := a.audit.Path()
+	a.auditSeq++
+	seq := a.auditSeq
+	a.auditOpen = true
+	a.auditEntries = nil
+	a.auditView.Clear()
+	a.auditView.SetCell(0, 0, tview.NewTableCell("Reading the audit log…").SetSelectable(false))
+	a.auditView.SetTitle(fmt.Sprintf("Audit log: %s  esc: close", path))
+	a.pages.ShowPage("audit")
+	a.app.SetFocus(a.auditView)
+
+	runAsync(a, a.activePane, func(context.Context) (auditEntries, error) {
+		entries, skipped, err := audit.Read(path)
+		return auditEntries{entries, skipped}, err
+	}, func(read auditEntries, err error) {
+		if !a.auditOpen || seq != a.auditSeq {
+			return
+		}
+		a.auditView.Clear()
+		if err != nil {
+			a.auditView.SetCell(0, 0, tview.NewTableCell(tview.Escape(fmt.Sprintf("Error reading the audit log: %v", err))).SetSelectable(false))
+			return
+		}
+		status := fmt.Sprintf("%d entries", len(read.entries))
+		if read.skipped > 0 {
+			status += fmt.Sprintf(", %d unreadable lines skipped", read.skipped)
+		}
+		a.auditView.SetTitle(fmt.Sprintf("Audit log: %s  %s | u: undo the selected upload or sync | esc: close", path, status))
+		a.auditEntries = read.entries
+		a.renderAudit(read.entries)
+	})
+}
+
+func (a *App) closeAuditModal() {
+	a.auditSeq++
+	a.pages.HidePage("audit")
+	a.auditOpen = false
+	a.setActivePane(a.activePane)
+}
+
+// renderAudit lists the entries latest first, failures in the failure color
+// with their error in place of the detail.
+func (a *App) renderAudit(entries []audit.Entry) {
+	table := a.auditView
+	if len(entries) == 0 {
+		table.SetCell(0, 0, tview.NewTableCell("Nothing audited yet: uploads (cp), signed URLs (sas), and lifecycle (l), CORS (o), and immutability (K) changes are recorded here.").SetSelectable(false))
+		return
+	}
+	for column, title := range []string{"Time", "User", "Identity", "Action", "Target", "Result", "Detail"} {
+		table.SetCell(0, column, tview.NewTableCell(title).SetAttributes(tcell.AttrBold).SetSelectable(false))
+	}
+	for i := range entries {
+		entry := entries[len(entries)-1-i]
+		color, detail := a.theme.text, entry.Detail
+		if entry.Result != audit.ResultOK {
+			color = a.theme.failure
+			detail = entry.Error
+		}
+		for column, text := range []string{a.formatTimestamp(entry.Time), entry.User, entry.Identity, entry.Action, entry.Target, entry.Result, detail} {
+			cell := tview.NewTableCell(tview.Escape(text)).SetTextColor(color)
+			if column == 4 {
+				cell.SetMaxWidth(40)
+			}
+			table.SetCell(i+1, column, cell)
+		}
+	}
+	table.Select(1, 0)
+	table.ScrollToBeginning()
+}
+
+// auditedChange makes change in the background and records it, as entry
+// describes it, in the audit log; what names what changes in the messages,
+// such as "the CORS rules of acme-dev". Without the audit log the change is
+// refused, like the uploads and signed URLs of the commands. done runs once
+// the change is made.
+func (a *App) auditedChange(entry audit.Entry, what string, change func(ctx context.Context) error, done func()) {
+	if a.audit == nil {
+		a.flashErr(fmt.Sprintf("Changing %s is refused: the audit log could not be opened.", what))
+		return
+	}
+	original := entry
+	entry.Result = audit.ResultOK
+	if provider, ok := a.provider.(identityProvider); ok {
+		entry.Identity = provider.Identity()
+	}
+	a.flash(fmt.Sprintf("Saving %s…", what))
+
+	// The fetch reports whether the change was recorded; err is the change's.
+	runAsync(a, a.activePane, func(ctx context.Context) (error, error) {
+		err := change(ctx)
+		if err != nil {
+			entry.Result, entry.Error = audit.ResultFailed, err.Error()
+		}
+		return a.audit.Record(entry), err
+	}, func(recordErr, err error) {
+		switch {
+		case err != nil:
+			a.recordFailure("save", what, err, func() { a.auditedChange(original, what, change, done) })
+			a.flashErr(fmt.Sprintf("Saving %s: %v", what, err))
+			return
+		case recordErr != nil:
+			a.flashErr(fmt.Sprintf("Saved %s, but not to the audit log: %v", what, recordErr))
+		default:
+			a.flash(fmt.Sprintf("Saved %s.", what))
+		}
+		done()
+	})
+}
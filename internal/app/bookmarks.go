@@ -0,0 +1,122 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"storage-tui/internal/bookmarks"
+)
+
+func (a *App) setupBookmarksModal() {
+	list := tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true)
+	list.SetBorder(true).SetTitle("Bookmarks  enter: jump | d: delete | esc: close")
+
+	list.SetSelectedFunc(func(index int, _ string, _ string, _ rune) {
+		items := a.bookmarks.List()
+		if index < 0 || index >= len(items) {
+			return
+		}
+		a.closeBookmarksModal()
+		a.jumpToBookmark(items[index])
+	})
+
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc || event.Rune() == '\'':
+			a.closeBookmarksModal()
+			return nil
+		case event.Rune() == 'd':
+			if err := a.bookmarks.Remove(list.GetCurrentItem()); err != nil {
+				a.flashErr(fmt.Sprintf("Error saving bookmarks: %v", err))
+			}
+			a.fillBookmarksList()
+			return nil
+		}
+		return event
+	})
+
+	a.bookmarksList = list
+	a.pages.AddPage("bookmarks", centerModal(list, 15, 70), true, false)
+}
+
+func (a *App) openBookmarksModal() {
+	if a.bookmarksErr != nil {
+		a.flashErr(fmt.Sprintf("Error loading bookmarks: %v", a.bookmarksErr))
+	}
+	a.bookmarksOpen = true
+	a.fillBookmarksList()
+	a.pages.ShowPage("bookmarks")
+	a.app.SetFocus(a.bookmarksList)
+}
+
+func (a *App) closeBookmarksModal() {
+	a.pages.HidePage("bookmarks")
+	a.bookmarksOpen = false
+	a.setActivePane(a.activePane)
+}
+
+func (a *App) fillBookmarksList() {
+	current := a.bookmarksList.GetCurrentItem()
+	a.bookmarksList.Clear()
+	items := a.bookmarks.List()
+	if len(items) == 0 {
+		a.bookmarksList.AddItem("No bookmarks. Press m on a container or blob to add one.", "", 0, nil)
+		return
+	}
+	for _, item := range items {
+		a.bookmarksList.AddItem(item.Label(), "", 0, nil)
+	}
+	if current >= len(items) {
+		current = len(items) - 1
+	}
+	a.bookmarksList.SetCurrentItem(current)
+}
+
+// toggleBookmark bookmarks the selected container or blob, or removes the
+// bookmark when it already exists.
+func (a *App) toggleBookmark() {
+	ref, ok := a.selectedRef()
+	if !ok || (ref.Kind != kindContainer && ref.Kind != kindBlob) {
+		a.flashErr("Select a container or blob to bookmark.")
+		return
+	}
+
+	mark := bookmarks.Bookmark{
+		SubscriptionID:   ref.SubscriptionID,
+		SubscriptionName: ref.SubscriptionName,
+		Account:          ref.Account,
+		Container:        ref.Container,
+	}
+	if ref.Kind == kindBlob {
+		mark.Blob = ref.Name
+	}
+
+	added, err := a.bookmarks.Toggle(mark)
+	if err != nil {
+		a.flashErr(fmt.Sprintf("Error saving bookmarks: %v", err))
+		return
+	}
+	if added {
+		a.flash(fmt.Sprintf("Bookmarked %s.", mark.Label()))
+	} else {
+		a.flash(fmt.Sprintf("Removed bookmark %s.", mark.Label()))
+	}
+}
+
+func (a *App) jumpToBookmark(mark bookmarks.Bookmark) {
+	loc := location{
+		SubscriptionID: mark.SubscriptionID,
+		Account:        mark.Account,
+		Container:      mark.Container,
+		Blob:           mark.Blob,
+	}
+	a.revealLocation(loc, func(err error) {
+		if err != nil {
+			a.flashErr(fmt.Sprintf("Unable to open bookmark %s: %v", mark.Label(), err))
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// browserCommands open a URL in the default browser; the first one found is
+// used.
+var browserCommands = [][]string{
+	{"xdg-open"},
+	{"open"},
+	{"wslview"},
+	{"explorer.exe"},
+}
+
+// openWebsite opens the static website of the selected account, or the
+// account the selection or the listing is in, in a browser.
+func (a *App) openWebsite() {
+	ref, ok := a.selectedRef()
+	if !ok || ref.Account == "" {
+		ref = a.contentSource
+	}
+	if ref.Account == "" {
+		a.flashErr("Select an account to open its static website.")
+		return
+	}
+	endpoint := a.accountInfo[ref.Account].WebEndpoint
+	if endpoint == "" {
+		a.flashErr(fmt.Sprintf("Static website hosting is off for %s.", ref.Account))
+		return
+	}
+	if err := openInBrowser(endpoint); err != nil {
+		a.flashErr(fmt.Sprintf("Could not open %s: %v", endpoint, err))
+		return
+	}
+	a.flash(fmt.Sprintf("Opened %s.", endpoint))
+}
+
+func openInBrowser(url string) error {
+	for _, args := range browserCommands {
+		path, err := exec.LookPath(args[0])
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, append(args[1:], url)...)
+		if err := cmd.Start(); err != nil {
+			continue
+		}
+		// The opener hands the URL over and exits; wait for it so it does
+		// not linger as a zombie.
+		go cmd.Wait()
+		return nil
+	}
+	return fmt.Errorf("no browser command found")
+}
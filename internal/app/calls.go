@@ -0,0 +1,301 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// maxCalls caps how many provider calls the calls page keeps; the oldest go
+// first.
+const maxCalls = 500
+
+// slowCall is how long a call takes before the calls page marks it slow.
+const slowCall = time.Second
+
+// providerCall is a call the browser made of the provider, kept for the
+// calls page to show why a pane is slow or empty.
+type providerCall struct {
+	seq      int64
+	time     time.Time
+	name     string
+	args     []string
+	duration time.Duration
+	// count is how many items a listing returned, and bytes how much of a
+	// blob was read by the time its reader was closed; -1 when the call
+	// returns neither.
+	count int
+	bytes int64
+	err   error
+}
+
+// result describes what the call returned, for the calls page.
+func (c providerCall) result(a *App) string {
+	switch {
+	case c.err != nil:
+		return ""
+	case c.count >= 0:
+		return fmt.Sprintf("%d listed", c.count)
+	case c.bytes >= 0:
+		return a.formatSize(c.bytes)
+	}
+	return ""
+}
+
+// callLog keeps the latest provider calls. Calls are made on background
+// goroutines, so it is guarded.
+type callLog struct {
+	mu      sync.Mutex
+	seq     int64
+	calls   []providerCall
+	changed func()
+}
+
+// add keeps call, returning its sequence number for update.
+func (l *callLog) add(call providerCall) int64 {
+	l.mu.Lock()
+	l.seq++
+	call.seq = l.seq
+	l.calls = append(l.calls, call)
+	if len(l.calls) > maxCalls {
+		l.calls = append(l.calls[:0], l.calls[len(l.calls)-maxCalls:]...)
+	}
+	changed := l.changed
+	l.mu.Unlock()
+	if changed != nil {
+		changed()
+	}
+	return call.seq
+}
+
+// setBytes records how much of a blob the call numbered seq read, unless
+// the call has been dropped since.
+func (l *callLog) setBytes(seq, bytes int64) {
+	l.mu.Lock()
+	for i := len(l.calls) - 1; i >= 0; i-- {
+		if l.calls[i].seq == seq {
+			l.calls[i].bytes = bytes
+			break
+		}
+	}
+	changed := l.changed
+	l.mu.Unlock()
+	if changed != nil {
+		changed()
+	}
+}
+
+// recent returns the kept calls, oldest first.
+func (l *callLog) recent() []providerCall {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]providerCall(nil), l.calls...)
+}
+
+// notify calls changed, on the calling goroutine, after each call is kept
+// or updated. Nil stops the calls.
+func (l *callLog) notify(changed func()) {
+	l.mu.Lock()
+	l.changed = changed
+	l.mu.Unlock()
+}
+
+// newProviderCall makes a call from what logCall is given: arguments in
+// key and value pairs, of which count is the call's result. Arguments left
+// empty are left out.
+func newProviderCall(name string, start time.Time, duration time.Duration, err error, args []any) providerCall {
+	call := providerCall{time: start, name: name, duration: duration, count: -1, bytes: -1, err: err}
+	for i := 0; i+1 < len(args); i += 2 {
+		key := fmt.Sprint(args[i])
+		var text string
+		switch value := args[i+1].(type) {
+		case int:
+			if key == "count" {
+				call.count = value
+				continue
+			}
+			text = fmt.Sprint(value)
+		case time.Time:
+			if value.IsZero() {
+				continue
+			}
+			text = value.Format(time.RFC3339)
+		default:
+			text = fmt.Sprint(value)
+		}
+		if text != "" {
+			call.args = append(call.args, key+"="+text)
+		}
+	}
+	return call
+}
+
+// countingReader counts what is read of a blob, and reports it on Close.
+type countingReader struct {
+	io.ReadCloser
+	read   atomic.Int64
+	closed func(bytes int64)
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read.Add(int64(n))
+	return n, err
+}
+
+func (r *countingReader) Close() error {
+	err := r.ReadCloser.Close()
+	if r.closed != nil {
+		r.closed(r.read.Load())
+		r.closed = nil
+	}
+	return err
+}
+
+func (a *App) setupCallsModal() {
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	detail := tview.NewTextView().
+		SetWordWrap(true)
+	detail.SetBorder(true).SetTitle("Call")
+	table.SetSelectionChangedFunc(func(row, _ int) {
+		a.showCallDetail(row - 1)
+	})
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc || event.Rune() == 'D' || event.Rune() == 'q':
+			a.closeCallsModal()
+			return nil
+		case event.Rune() == 'e':
+			a.callsFailedOnly = !a.callsFailedOnly
+			a.renderCalls()
+			return nil
+		}
+		return event
+	})
+
+	view := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(detail, 7, 0, false)
+	view.SetBorder(true)
+
+	a.callsView = view
+	a.callsTable = table
+	a.callsDetail = detail
+	a.pages.AddPage("calls", centerModal(view, 30, 120), true, false)
+}
+
+// openCallsModal lists the latest provider calls, newest first, with how
+// long each took, what it returned, and its error. Calls made while it is
+// open are added as they come.
+func (a *App) openCallsModal() {
+	a.callsOpen = true
+	a.renderCalls()
+	a.switcher.calls.notify(func() {
+		// Calls are also made on the UI goroutine, which must not wait on
+		// its own update queue.
+		go a.queueUpdate(func() {
+			if a.callsOpen {
+				a.renderCalls()
+			}
+		})
+	})
+	a.pages.ShowPage("calls")
+	a.app.SetFocus(a.callsTable)
+}
+
+func (a *App) closeCallsModal() {
+	a.switcher.calls.notify(nil)
+	a.pages.HidePage("calls")
+	a.callsOpen = false
+	a.setActivePane(a.activePane)
+}
+
+func (a *App) renderCalls() {
+	table := a.callsTable
+	row, _ := table.GetSelection()
+	table.Clear()
+
+	a.callsShown = a.callsShown[:0]
+	calls := a.switcher.calls.recent()
+	var slow int
+	for i := len(calls) - 1; i >= 0; i-- {
+		call := calls[i]
+		if call.duration >= slowCall {
+			slow++
+		}
+		if a.callsFailedOnly && call.err == nil {
+			continue
+		}
+		a.callsShown = append(a.callsShown, call)
+	}
+	filter := "e: failed only"
+	if a.callsFailedOnly {
+		filter = "e: all calls"
+	}
+	a.callsView.SetTitle(fmt.Sprintf("Provider calls (%d, %d slow)  %s | esc: close", len(calls), slow, filter))
+
+	if len(a.callsShown) == 0 {
+		table.SetCell(0, 0, tview.NewTableCell("No calls yet.").SetSelectable(false))
+		a.callsDetail.SetText("")
+		return
+	}
+	for column, title := range []string{"Time", "Call", "Arguments", "Duration", "Result", "Error"} {
+		table.SetCell(0, column, tview.NewTableCell(title).SetAttributes(tcell.AttrBold).SetSelectable(false))
+	}
+	for index, call := range a.callsShown {
+		color := a.theme.text
+		switch {
+		case errors.Is(call.err, context.Canceled):
+			color = a.theme.muted
+		case call.err != nil:
+			color = a.theme.failure
+		case call.duration >= slowCall:
+			color = a.theme.changed
+		}
+		errText := ""
+		if call.err != nil {
+			errText = call.err.Error()
+		}
+		table.SetCell(index+1, 0, tview.NewTableCell(a.formatTimestamp(call.time)))
+		table.SetCell(index+1, 1, tview.NewTableCell(tview.Escape(call.name)).SetTextColor(color))
+		table.SetCell(index+1, 2, tview.NewTableCell(tview.Escape(strings.Join(call.args, " "))).SetMaxWidth(50))
+		table.SetCell(index+1, 3, tview.NewTableCell(call.duration.Round(time.Millisecond).String()).SetAlign(tview.AlignRight).SetTextColor(color))
+		table.SetCell(index+1, 4, tview.NewTableCell(call.result(a)).SetAlign(tview.AlignRight))
+		table.SetCell(index+1, 5, tview.NewTableCell(tview.Escape(errText)).SetMaxWidth(40))
+	}
+	row = min(max(row, 1), len(a.callsShown))
+	table.Select(row, 0)
+	a.showCallDetail(row - 1)
+}
+
+// showCallDetail shows the call on row index in full, which the table cuts
+// short.
+func (a *App) showCallDetail(index int) {
+	if index < 0 || index >= len(a.callsShown) {
+		return
+	}
+	call := a.callsShown[index]
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s at %s took %s", call.name, a.formatTimestamp(call.time), call.duration.Round(time.Millisecond))
+	if result := call.result(a); result != "" {
+		fmt.Fprintf(&builder, ", returning %s", result)
+	}
+	builder.WriteString("\n")
+	for _, arg := range call.args {
+		fmt.Fprintf(&builder, "  %s\n", arg)
+	}
+	if call.err != nil {
+		fmt.Fprintf(&builder, "%v\n", call.err)
+	}
+	a.callsDetail.SetText(builder.String()).ScrollToBeginning()
+}
@@ -0,0 +1,194 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"storage-tui/internal/azure"
+)
+
+// changeFeedLimit caps how many of the latest events the change feed page
+// reads for a window.
+const changeFeedLimit = 2000
+
+// changeFeedWindows are the time windows the change feed page shows, from
+// now back; [ and ] step through them.
+var changeFeedWindows = []struct {
+	span  time.Duration
+	label string
+}{
+	{time.Hour, "last hour"},
+	{6 * time.Hour, "last 6 hours"},
+	{24 * time.Hour, "last 24 hours"},
+	{7 * 24 * time.Hour, "last 7 days"},
+	{30 * 24 * time.Hour, "last 30 days"},
+}
+
+// changeFeedEvents spell out the event types, dropping the Blob prefix.
+var changeFeedEvents = map[string]string{
+	"BlobCreated":           "created",
+	"BlobDeleted":           "deleted",
+	"BlobPropertiesUpdated": "properties updated",
+	"BlobSnapshotCreated":   "snapshot created",
+	"BlobTierChanged":       "tier changed",
+}
+
+func (a *App) setupChangeFeedModal() {
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true)
+	table.SetSelectedFunc(func(row, _ int) {
+		a.jumpToChange(row - 1)
+	})
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc || event.Rune() == 'c' || event.Rune() == 'q':
+			a.closeChangeFeedModal()
+			return nil
+		case event.Rune() == '[' && a.changeFeedWindow > 0:
+			a.changeFeedWindow--
+			a.loadChangeFeed()
+			return nil
+		case event.Rune() == ']' && a.changeFeedWindow < len(changeFeedWindows)-1:
+			a.changeFeedWindow++
+			a.loadChangeFeed()
+			return nil
+		}
+		return event
+	})
+
+	a.changeFeedView = table
+	a.changeFeedWindow = 2
+	a.pages.AddPage("changefeed", centerModal(table, 26, 120), true, false)
+}
+
+// openChangeFeedModal lists the blob changes the change feed of the selected
+// account, or of the account the selection or the listing is in, logged in
+// the time window, latest first.
+func (a *App) openChangeFeedModal() {
+	ref, ok := a.selectedRef()
+	if !ok || ref.Account == "" {
+		ref = a.contentSource
+	}
+	if ref.Account == "" {
+		a.flashErr("Select an account to browse its change feed.")
+		return
+	}
+	a.changeFeedSource = itemRef{Kind: kindAccount, SubscriptionID: ref.SubscriptionID, Account: ref.Account}
+	a.changeFeedOpen = true
+	a.pages.ShowPage("changefeed")
+	a.app.SetFocus(a.changeFeedView)
+	a.loadChangeFeed()
+}
+
+// loadChangeFeed reads the events of the current window in the background.
+func (a *App) loadChangeFeed() {
+	a.changeFeedSeq++
+	seq := a.changeFeedSeq
+	account := a.changeFeedSource.Account
+	window := changeFeedWindows[a.changeFeedWindow]
+	a.changeFeedRows = nil
+	a.changeFeedView.Clear()
+	a.changeFeedView.SetCell(0, 0, tview.NewTableCell("Reading the change feed…").SetSelectable(false))
+	a.setChangeFeedTitle("reading…")
+
+	opts := azure.ChangeFeedOptions{Start: time.Now().Add(-window.span), MaxEvents: changeFeedLimit}
+	runAsync(a, a.activePane, func(ctx context.Context) ([]azure.ChangeFeedEvent, error) {
+		return a.provider.ListChangeFeedEvents(ctx, account, opts)
+	}, func(events []azure.ChangeFeedEvent, err error) {
+		if !a.changeFeedOpen || seq != a.changeFeedSeq {
+			return
+		}
+		a.changeFeedView.Clear()
+		if err != nil {
+			a.setChangeFeedTitle("error")
+			a.changeFeedView.SetCell(0, 0, tview.NewTableCell(tview.Escape(fmt.Sprintf("Error reading the change feed: %v", err))).SetSelectable(false))
+			return
+		}
+		status := fmt.Sprintf("%d events", len(events))
+		if len(events) == 1 {
+			status = "1 event"
+		}
+		if len(events) >= changeFeedLimit {
+			status = fmt.Sprintf("latest %d events", len(events))
+		}
+		a.setChangeFeedTitle(status)
+		a.renderChangeFeed(events)
+	})
+}
+
+func (a *App) setChangeFeedTitle(status string) {
+	window := changeFeedWindows[a.changeFeedWindow]
+	a.changeFeedView.SetTitle(fmt.Sprintf("Change feed: %s, %s  %s | [/]: window | enter: jump | esc: close", a.changeFeedSource.Account, window.label, status))
+}
+
+// renderChangeFeed lists the events latest first, colored by what they did
+// to the blob.
+func (a *App) renderChangeFeed(events []azure.ChangeFeedEvent) {
+	table := a.changeFeedView
+	if len(events) == 0 {
+		table.SetCell(0, 0, tview.NewTableCell("No changes in this window.").SetSelectable(false))
+		return
+	}
+	for column, title := range []string{"Time", "Event", "Blob", "Size", "API"} {
+		table.SetCell(0, column, tview.NewTableCell(title).SetAttributes(tcell.AttrBold).SetSelectable(false))
+	}
+	a.changeFeedRows = make([]azure.ChangeFeedEvent, 0, len(events))
+	for i := len(events) - 1; i >= 0; i-- {
+		a.changeFeedRows = append(a.changeFeedRows, events[i])
+	}
+	for index, event := range a.changeFeedRows {
+		name, ok := changeFeedEvents[event.EventType]
+		if !ok {
+			name = event.EventType
+		}
+		color := a.theme.changed
+		switch event.EventType {
+		case "BlobCreated":
+			color = a.theme.added
+		case "BlobDeleted":
+			color = a.theme.failure
+		}
+		size := ""
+		if event.EventType != "BlobDeleted" {
+			size = a.formatSize(event.ContentLength)
+		}
+		row := index + 1
+		table.SetCell(row, 0, tview.NewTableCell(a.formatTimestamp(event.Time)))
+		table.SetCell(row, 1, tview.NewTableCell(name).SetTextColor(color))
+		table.SetCell(row, 2, tview.NewTableCell(tview.Escape(event.Container+"/"+event.Blob)).SetMaxWidth(50))
+		table.SetCell(row, 3, tview.NewTableCell(size).SetAlign(tview.AlignRight))
+		table.SetCell(row, 4, tview.NewTableCell(event.API).SetTextColor(a.theme.muted))
+	}
+	table.Select(1, 0)
+	table.ScrollToBeginning()
+}
+
+// jumpToChange closes the page and reveals the blob the event changed, which
+// may since have been deleted.
+func (a *App) jumpToChange(index int) {
+	if index < 0 || index >= len(a.changeFeedRows) {
+		return
+	}
+	event := a.changeFeedRows[index]
+	source := a.changeFeedSource
+	a.closeChangeFeedModal()
+	loc := location{SubscriptionID: source.SubscriptionID, Account: source.Account, Container: event.Container, Blob: event.Blob}
+	a.revealLocation(loc, func(err error) {
+		if err != nil {
+			a.flashErr(fmt.Sprintf("Change feed: %v", err))
+		}
+	})
+}
+
+func (a *App) closeChangeFeedModal() {
+	a.changeFeedSeq++
+	a.pages.HidePage("changefeed")
+	a.changeFeedOpen = false
+	a.setActivePane(a.activePane)
+}
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// clipboardCommands are tried in order before falling back to the terminal's
+// OSC 52 clipboard sequence, which not every terminal honors.
+var clipboardCommands = [][]string{
+	{"pbcopy"},
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+	{"clip.exe"},
+}
+
+// trackScreen remembers the screen the application draws on, which is needed
+// for the OSC 52 fallback, and times each draw for the metrics and for
+// queueUpdate.
+func (a *App) trackScreen() {
+	a.app.SetBeforeDrawFunc(func(screen tcell.Screen) bool {
+		a.screen = screen
+		a.drawStart = time.Now()
+		return false
+	})
+	a.app.SetAfterDrawFunc(func(tcell.Screen) {
+		a.lastDraw = time.Now()
+		a.metrics.ObserveDraw(a.lastDraw.Sub(a.drawStart))
+	})
+}
+
+// copyDetails copies what Details shows, or the full property set while the
+// properties dialog is open, as plain text or JSON.
+func (a *App) copyDetails(asJSON bool) {
+	if a.propertiesOpen {
+		if a.propertySections == nil {
+			a.flashErr("Properties are still loading.")
+			return
+		}
+		text := propertySectionsText(a.propertySections)
+		if asJSON {
+			text = propertySectionsJSON(a.propertySections)
+		}
+		a.copyToClipboard(text, "properties")
+		return
+	}
+
+	text := a.lastDetails
+	if asJSON {
+		text = propertiesJSON(a.detailItems, a.lastDetails)
+	}
+	a.copyToClipboard(text, "details")
+}
+
+func (a *App) copyToClipboard(text, what string) {
+	if err := copyWithCommand(text); err == nil {
+		a.flash(fmt.Sprintf("Copied %s to the clipboard.", what))
+		return
+	}
+	if a.screen == nil {
+		a.flashErr("No clipboard available.")
+		return
+	}
+	a.screen.SetClipboard([]byte(text))
+	a.flash(fmt.Sprintf("Copied %s to the clipboard via the terminal.", what))
+}
+
+func copyWithCommand(text string) error {
+	for _, args := range clipboardCommands {
+		path, err := exec.LookPath(args[0])
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, args[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("no clipboard command found")
+}
+
+func propertySectionsText(sections []propertySection) string {
+	var builder strings.Builder
+	for i, section := range sections {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(section.Title + "\n")
+		for _, item := range section.Items {
+			builder.WriteString(fmt.Sprintf("  %s: %s\n", item.Name, item.Value))
+		}
+	}
+	return builder.String()
+}
+
+// propertySectionsJSON renders sections as one object per section title.
+func propertySectionsJSON(sections []propertySection) string {
+	object := make(map[string]map[string]string, len(sections))
+	for _, section := range sections {
+		values := make(map[string]string, len(section.Items))
+		for _, item := range section.Items {
+			values[item.Name] = item.Value
+		}
+		object[section.Title] = values
+	}
+	return marshalIndent(object)
+}
+
+// propertiesJSON renders items as an object, or message when there are none.
+func propertiesJSON(items []property, message string) string {
+	if items == nil {
+		return marshalIndent(map[string]string{"message": message})
+	}
+	values := make(map[string]string, len(items))
+	for _, item := range items {
+		values[item.Name] = item.Value
+	}
+	return marshalIndent(values)
+}
+
+func marshalIndent(value any) string {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data) + "\n"
+}
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/rivo/tview"
+
+	"storage-tui/internal/azure"
+)
+
+// columnWorkers is how many blob properties the extra columns fetch at once.
+const columnWorkers = 8
+
+// blobColumns are the extra contents columns of a blob. Listings do not carry
+// the lease and tags, so each blob's properties are fetched on their own.
+type blobColumns struct {
+	tier  string
+	lease string
+	tags  string
+}
+
+func columnsOf(props azure.BlobProperties) blobColumns {
+	tags := make([]string, 0, len(props.Tags))
+	for key, value := range props.Tags {
+		tags = append(tags, key+"="+value)
+	}
+	sort.Strings(tags)
+	return blobColumns{tier: props.AccessTier, lease: props.LeaseState, tags: strings.Join(tags, ", ")}
+}
+
+func (c blobColumns) cells() []string {
+	return []string{c.tier, c.lease, c.tags}
+}
+
+// toggleExtraColumns shows or hides the tier, lease, and tags columns of the
+// listed blobs.
+func (a *App) toggleExtraColumns() {
+	if a.contentSource.Kind != kindContainer {
+		a.flashErr("Select a container before adding columns.")
+		return
+	}
+	a.extraColumns = !a.extraColumns
+	a.renderContents(true)
+	if !a.extraColumns {
+		a.flash("Tier, lease, and tags columns off.")
+		return
+	}
+	a.flash("Tier, lease, and tags columns on.")
+}
+
+// addExtraColumns fills the extra columns of every blob row, from properties
+// already fetched or with placeholders until they arrive.
+func (a *App) addExtraColumns() {
+	if !a.extraColumns || a.contentSource.Kind != kindContainer {
+		return
+	}
+	for row, ref := range a.contentRefs {
+		if ref.Kind == kindBlob {
+			columns := blobColumns{tier: "…", lease: "…", tags: "…"}
+			if props, ok := a.propsCache[previewKey(ref)]; ok {
+				columns = columnsOf(props)
+			}
+			a.setExtraColumns(row, columns)
+		}
+	}
+}
+
+func (a *App) setExtraColumns(row int, columns blobColumns) {
+	for i, text := range columns.cells() {
+		a.contents.SetCell(row, 2+i, tview.NewTableCell(tview.Escape(text)).SetMaxWidth(30))
+	}
+}
+
+// fetchVisibleColumns fetches the properties of the blob rows on screen, and
+// a screen either side as the offset only follows the selection on the next
+// draw. Each row is filled in as its properties arrive; rows already fetched
+// or on their way are skipped, and replacing the listing cancels the rest.
+func (a *App) fetchVisibleColumns() {
+	if !a.extraColumns || a.contentSource.Kind != kindContainer {
+		return
+	}
+	offset, _ := a.contents.GetOffset()
+	_, _, _, height := a.contents.GetInnerRect()
+	height = max(height, 1)
+	first := max(offset-height, 0)
+	last := min(offset+2*height, len(a.contentRefs))
+	ctx := a.contentsCtx
+
+	for row := first; row < last; row++ {
+		ref := a.contentRefs[row]
+		key := previewKey(ref)
+		if ref.Kind != kindBlob || a.columnPending[key] {
+			continue
+		}
+		if _, ok := a.propsCache[key]; ok {
+			continue
+		}
+		a.columnPending[key] = true
+
+		go func() {
+			props, err := a.fetchColumns(ctx, ref)
+			a.queueUpdate(func() {
+				delete(a.columnPending, key)
+				if err != nil {
+					if ctx.Err() == nil {
+						a.fillExtraColumns(key, row, blobColumns{tier: "?", lease: "?", tags: "?"})
+					}
+					return
+				}
+				a.propsCache[key] = props
+				a.fillExtraColumns(key, row, columnsOf(props))
+			})
+		}()
+	}
+}
+
+// fetchColumns reads the blob's properties once one of the columnWorkers
+// slots is free.
+func (a *App) fetchColumns(ctx context.Context, ref itemRef) (azure.BlobProperties, error) {
+	select {
+	case a.columnSlots <- struct{}{}:
+	case <-ctx.Done():
+		return azure.BlobProperties{}, ctx.Err()
+	}
+	defer func() { <-a.columnSlots }()
+	return a.provider.GetBlobProperties(ctx, ref.Account, ref.Container, ref.Name)
+}
+
+// fillExtraColumns sets the columns on the blob's row, which may have moved
+// since the fetch started.
+func (a *App) fillExtraColumns(key string, row int, columns blobColumns) {
+	if !a.extraColumns || a.contentSource.Kind != kindContainer {
+		return
+	}
+	if row >= len(a.contentRefs) || previewKey(a.contentRefs[row]) != key {
+		row = -1
+		for index, ref := range a.contentRefs {
+			if ref.Kind == kindBlob && previewKey(ref) == key {
+				row = index
+				break
+			}
+		}
+		if row < 0 {
+			return
+		}
+	}
+	a.setExtraColumns(row, columns)
+}
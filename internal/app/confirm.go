@@ -0,0 +1,122 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const confirmWidth = 64
+
+// confirmation describes a question asked by the confirm dialog.
+type confirmation struct {
+	Title   string
+	Message string
+	// Action labels the button that confirms, e.g. "Delete".
+	Action string
+	// Danger draws the dialog in the theme's failure color, for actions that
+	// destroy or overwrite data.
+	Danger bool
+	// RequireText, when set, keeps the action button disabled until the user
+	// has typed it, usually the name of the resource at stake.
+	RequireText string
+	// Back gets the focus once the dialog closes, for questions asked from
+	// another dialog; nil returns to the active pane.
+	Back      tview.Primitive
+	OnConfirm func()
+}
+
+// confirm asks a yes/no question in a modal dialog. Cancel has the initial
+// focus so an accidental enter does nothing; when text must be typed first,
+// the input field has it instead. Esc cancels.
+func (a *App) confirm(c confirmation) {
+	message := tview.NewTextView().
+		SetText(c.Message).
+		SetWordWrap(true)
+	message.SetBorderPadding(1, 0, 1, 1)
+	lines := len(tview.WordWrap(c.Message, confirmWidth-4))
+
+	form := tview.NewForm()
+	form.SetButtonsAlign(tview.AlignRight)
+	form.SetBorderPadding(1, 0, 1, 1)
+	if c.RequireText != "" {
+		form.AddInputField(fmt.Sprintf("Type %s to confirm: ", c.RequireText), "", 0, nil, nil)
+	}
+
+	dialog := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(message, lines+1, 0, false).
+		AddItem(form, 0, 1, true)
+	dialog.SetBorder(true).SetTitle(c.Title)
+
+	closeDialog := func() {
+		a.pages.RemovePage("confirm")
+		a.confirmOpen = false
+		if c.Back != nil {
+			a.app.SetFocus(c.Back)
+			return
+		}
+		a.setActivePane(a.activePane)
+	}
+	accept := func() {
+		closeDialog()
+		if c.OnConfirm != nil {
+			c.OnConfirm()
+		}
+	}
+	form.AddButton(c.Action, accept)
+	form.AddButton("Cancel", closeDialog)
+	form.SetCancelFunc(closeDialog)
+
+	a.styleConfirm(dialog, message, form, c.Danger)
+
+	height := lines + 5
+	focus := form.GetFormItemCount() + 1
+	if c.RequireText != "" {
+		height += 2
+		focus = 0
+		action := form.GetButton(0)
+		action.SetDisabled(true)
+		input := form.GetFormItem(0).(*tview.InputField)
+		input.SetChangedFunc(func(text string) {
+			action.SetDisabled(text != c.RequireText)
+		})
+		// Enter confirms once the text matches and is ignored until then,
+		// instead of moving on to the buttons.
+		input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+			if event.Key() != tcell.KeyEnter {
+				return event
+			}
+			if !action.IsDisabled() {
+				accept()
+			}
+			return nil
+		})
+	}
+	form.SetFocus(focus)
+
+	a.confirmOpen = true
+	a.confirmDialog = dialog
+	a.pages.AddPage("confirm", centerModal(dialog, height, confirmWidth), true, true)
+	a.app.SetFocus(form)
+}
+
+func (a *App) styleConfirm(dialog *tview.Flex, message *tview.TextView, form *tview.Form, danger bool) {
+	t := a.theme
+	accent := t.selectionBg
+	if danger {
+		accent = t.failure
+	}
+	dialog.SetBackgroundColor(t.background)
+	dialog.SetBorderColor(accent)
+	dialog.SetTitleColor(accent)
+	message.SetBackgroundColor(t.background)
+	message.SetTextColor(t.text)
+	form.SetBackgroundColor(t.background)
+	form.SetLabelColor(t.text).
+		SetFieldBackgroundColor(t.selectionBg).
+		SetFieldTextColor(t.selectionFg).
+		SetButtonStyle(tcell.StyleDefault.Foreground(t.text).Background(t.background)).
+		SetButtonActivatedStyle(tcell.StyleDefault.Foreground(t.selectionFg).Background(accent)).
+		SetButtonDisabledStyle(tcell.StyleDefault.Foreground(t.muted).Background(t.background))
+}
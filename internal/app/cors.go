@@ -0,0 +1,261 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"storage-tui/internal/audit"
+	"storage-tui/internal/azure"
+)
+
+// corsFields label the CORS rule form's inputs, in order.
+var corsFields = []string{"Allowed origins", "Allowed methods", "Allowed headers", "Exposed headers", "Max age (seconds)"}
+
+func (a *App) setupCORSModal() {
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true)
+	table.SetSelectedFunc(func(row, _ int) {
+		a.openCORSForm(row - 1)
+	})
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		row, _ := table.GetSelection()
+		switch {
+		case event.Key() == tcell.KeyEsc || event.Rune() == 'o' || event.Rune() == 'q':
+			a.closeCORSModal()
+			return nil
+		case event.Rune() == 'n':
+			a.openCORSForm(-1)
+			return nil
+		case event.Rune() == 'd':
+			a.removeCORSRule(row - 1)
+			return nil
+		}
+		return event
+	})
+
+	form := tview.NewForm()
+	for _, label := range corsFields {
+		form.AddInputField(label+": ", "", 0, nil, nil)
+	}
+	form.AddButton("Save", a.saveCORSForm).
+		AddButton("Cancel", a.closeCORSForm)
+	form.SetBorder(true)
+	form.SetButtonsAlign(tview.AlignRight)
+	form.SetCancelFunc(a.closeCORSForm)
+
+	a.corsView = table
+	a.corsForm = form
+	a.pages.AddPage("cors", centerModal(table, 16, 120), true, false)
+	a.pages.AddPage("cors-rule", centerModal(form, 15, 90), true, false)
+}
+
+// openCORSModal shows the CORS rules of the blob service of the selected
+// account, or of the account the selection or the listing is in. Rules can
+// be added, edited, and removed there; each change replaces the account's
+// rules and is recorded in the audit log.
+func (a *App) openCORSModal() {
+	ref, ok := a.selectedRef()
+	if !ok || ref.Account == "" {
+		ref = a.contentSource
+	}
+	if ref.Account == "" {
+		a.flashErr("Select an account to show its CORS rules.")
+		return
+	}
+	a.corsAccount = ref.Account
+	a.corsOpen = true
+	a.pages.ShowPage("cors")
+	a.app.SetFocus(a.corsView)
+	a.loadCORSRules()
+}
+
+// loadCORSRules reads the account's rules in the background.
+func (a *App) loadCORSRules() {
+	a.corsSeq++
+	seq := a.corsSeq
+	account := a.corsAccount
+	a.corsLoaded = false
+	a.corsRules = nil
+	a.corsView.Clear()
+	a.corsView.SetCell(0, 0, tview.NewTableCell("Loading CORS rules…").SetSelectable(false))
+	a.corsView.SetTitle(fmt.Sprintf("CORS rules: %s  esc: close", account))
+
+	runAsync(a, a.activePane, func(ctx context.Context) (azure.BlobServiceProperties, error) {
+		return a.provider.GetBlobServiceProperties(ctx, account)
+	}, func(props azure.BlobServiceProperties, err error) {
+		if !a.corsOpen || seq != a.corsSeq {
+			return
+		}
+		a.corsView.Clear()
+		if err != nil {
+			a.corsView.SetCell(0, 0, tview.NewTableCell(tview.Escape(fmt.Sprintf("Error loading CORS rules: %v", err))).SetSelectable(false))
+			return
+		}
+		a.corsLoaded = true
+		a.corsRules = props.CORS
+		a.corsView.SetTitle(fmt.Sprintf("CORS rules: %s  %d of %d | n: new | enter: edit | d: remove | esc: close", account, len(props.CORS), azure.MaxCORSRules))
+		a.renderCORSRules()
+	})
+}
+
+func (a *App) renderCORSRules() {
+	table := a.corsView
+	if len(a.corsRules) == 0 {
+		table.SetCell(0, 0, tview.NewTableCell("The blob service has no CORS rules, so browsers on other origins are turned away. n adds one.").SetSelectable(false))
+		return
+	}
+	for column, title := range []string{"#", "Allowed origins", "Methods", "Allowed headers", "Exposed headers", "Max age"} {
+		table.SetCell(0, column, tview.NewTableCell(title).SetAttributes(tcell.AttrBold).SetSelectable(false))
+	}
+	for i, rule := range a.corsRules {
+		cells := []string{strconv.Itoa(i + 1), strings.Join(rule.AllowedOrigins, ", "), strings.Join(rule.AllowedMethods, ", "),
+			strings.Join(rule.AllowedHeaders, ", "), strings.Join(rule.ExposedHeaders, ", "), fmt.Sprintf("%ds", rule.MaxAgeSeconds)}
+		for column, text := range cells {
+			table.SetCell(i+1, column, tview.NewTableCell(tview.Escape(text)).SetMaxWidth(36))
+		}
+	}
+	table.Select(1, 0)
+}
+
+func (a *App) closeCORSModal() {
+	a.corsSeq++
+	a.pages.HidePage("cors")
+	a.corsOpen = false
+	a.setActivePane(a.activePane)
+}
+
+// openCORSForm edits the rule at index, or a new rule when index is
+// negative. A new rule starts out allowing GET, HEAD, and OPTIONS with any
+// request header for an hour.
+func (a *App) openCORSForm(index int) {
+	if !a.corsLoaded {
+		return
+	}
+	rule := azure.CORSRule{AllowedMethods: []string{"GET", "HEAD", "OPTIONS"}, AllowedHeaders: []string{"*"}, MaxAgeSeconds: 3600}
+	title := fmt.Sprintf("New CORS rule for %s", a.corsAccount)
+	switch {
+	case index >= len(a.corsRules):
+		return
+	case index >= 0:
+		rule = a.corsRules[index]
+		title = fmt.Sprintf("CORS rule %d of %s", index+1, a.corsAccount)
+	case len(a.corsRules) >= azure.MaxCORSRules:
+		a.flashErr(fmt.Sprintf("The blob service keeps at most %d CORS rules; edit or remove one.", azure.MaxCORSRules))
+		return
+	}
+	values := []string{strings.Join(rule.AllowedOrigins, ", "), strings.Join(rule.AllowedMethods, ", "),
+		strings.Join(rule.AllowedHeaders, ", "), strings.Join(rule.ExposedHeaders, ", "), strconv.Itoa(rule.MaxAgeSeconds)}
+	for i, value := range values {
+		a.corsForm.GetFormItem(i).(*tview.InputField).SetText(value)
+	}
+	a.corsEditing = index
+	a.corsFormOpen = true
+	a.corsForm.SetTitle(title + "  comma-separated | esc: cancel")
+	a.corsForm.SetFocus(0)
+	a.pages.ShowPage("cors-rule")
+	a.app.SetFocus(a.corsForm)
+}
+
+func (a *App) closeCORSForm() {
+	a.pages.HidePage("cors-rule")
+	a.corsFormOpen = false
+	a.app.SetFocus(a.corsView)
+}
+
+// saveCORSForm checks the rule in the form and, once it passes, saves the
+// account's rules with it in place.
+func (a *App) saveCORSForm() {
+	field := func(i int) string {
+		return a.corsForm.GetFormItem(i).(*tview.InputField).GetText()
+	}
+	maxAge, err := strconv.Atoi(strings.TrimSpace(field(4)))
+	if err != nil {
+		a.flashErr(fmt.Sprintf("Max age %q is not a number of seconds.", field(4)))
+		return
+	}
+	rule := azure.CORSRule{
+		AllowedOrigins: splitList(field(0)),
+		AllowedMethods: splitList(strings.ToUpper(field(1))),
+		AllowedHeaders: splitList(field(2)),
+		ExposedHeaders: splitList(field(3)),
+		MaxAgeSeconds:  maxAge,
+	}
+	rules := slices.Clone(a.corsRules)
+	detail := fmt.Sprintf("added rule %d", len(rules)+1)
+	if a.corsEditing >= 0 {
+		rules[a.corsEditing] = rule
+		detail = fmt.Sprintf("changed rule %d", a.corsEditing+1)
+	} else {
+		rules = append(rules, rule)
+	}
+	if err := azure.ValidateCORSRules(rules); err != nil {
+		a.flashErr(fmt.Sprintf("CORS: %v", err))
+		return
+	}
+	a.closeCORSForm()
+	a.applyCORSRules(rules, fmt.Sprintf("%s: %s", detail, describeCORSRule(rule)))
+}
+
+// removeCORSRule removes the rule at index once confirmed.
+func (a *App) removeCORSRule(index int) {
+	if !a.corsLoaded || index < 0 || index >= len(a.corsRules) {
+		return
+	}
+	rule := a.corsRules[index]
+	a.confirm(confirmation{
+		Title:   "Remove CORS rule",
+		Message: fmt.Sprintf("Remove CORS rule %d of %s? Browsers on %s will no longer be let in by it.", index+1, a.corsAccount, strings.Join(rule.AllowedOrigins, ", ")),
+		Action:  "Remove",
+		Danger:  true,
+		Back:    a.corsView,
+		OnConfirm: func() {
+			rules := slices.Delete(slices.Clone(a.corsRules), index, index+1)
+			a.applyCORSRules(rules, fmt.Sprintf("removed rule %d: %s", index+1, describeCORSRule(rule)))
+		},
+	})
+}
+
+// applyCORSRules replaces the account's CORS rules and records the change,
+// described by detail, in the audit log.
+func (a *App) applyCORSRules(rules []azure.CORSRule, detail string) {
+	editor, ok := a.provider.(azure.CORSEditor)
+	if !ok {
+		a.flashErr("This provider cannot change CORS rules.")
+		return
+	}
+	account := a.corsAccount
+	entry := audit.Entry{Action: "cors", Target: "az://" + account, Detail: detail}
+	a.auditedChange(entry, "the CORS rules of "+account, func(ctx context.Context) error {
+		return editor.SetCORSRules(ctx, account, rules)
+	}, func() {
+		delete(a.serviceProps, account)
+		if a.corsOpen && a.corsAccount == account {
+			a.loadCORSRules()
+		}
+	})
+}
+
+// describeCORSRule sums up a rule for the audit log, such as
+// "https://app.example.com: GET, HEAD".
+func describeCORSRule(rule azure.CORSRule) string {
+	return fmt.Sprintf("%s: %s", strings.Join(rule.AllowedOrigins, ", "), strings.Join(rule.AllowedMethods, ", "))
+}
+
+// splitList splits a comma-separated list, dropping blank items.
+func splitList(text string) []string {
+	var items []string
+	for _, item := range strings.Split(text, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,143 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// defaultDemoPace is how long the demo dwells on each step.
+const defaultDemoPace = 2 * time.Second
+
+// demoInput is a key pressed, as the help names it, or text typed, in a step
+// of the demo.
+type demoInput struct {
+	key  string
+	text string
+}
+
+// demoStep is one step of the demo: what it shows, captioned in place of the
+// key hints, and the keys that show it.
+type demoStep struct {
+	caption string
+	inputs  []demoInput
+}
+
+func press(keys ...string) []demoInput {
+	inputs := make([]demoInput, len(keys))
+	for i, key := range keys {
+		inputs[i] = demoInput{key: key}
+	}
+	return inputs
+}
+
+func typing(text string) []demoInput {
+	return []demoInput{{text: text}}
+}
+
+func inputs(parts ...[]demoInput) []demoInput {
+	var all []demoInput
+	for _, part := range parts {
+		all = append(all, part...)
+	}
+	return all
+}
+
+// demoScript walks through the built-in mock data, starting at the top of
+// the tree with Development selected.
+var demoScript = []demoStep{
+	{"Expand an account to list its containers", press("down", "enter")},
+	{"Jump to a container by typing its name", inputs(press("/"), typing("site"))},
+	{"Select a blob to preview it", press("tab", "down", "down", "down")},
+	{"Search inside the preview", inputs(press("tab", "/"), typing("title"), press("enter"))},
+	{"Clear the search and go back to the contents", press("esc", "shift-tab")},
+	{"Group blobs into virtual folders", press("F")},
+	{"Open a folder", press("down", "enter")},
+	{"Back to the flat listing", press("backspace", "F")},
+	{"Filter the listing by name", inputs(press("/"), typing("guide"), press("enter"))},
+	{"Show every property of the selected blob", press("i")},
+	{"Close the properties and clear the filter", press("esc", "esc")},
+	{"Find blobs by name across subscriptions", inputs(press("f"), typing("usage"), press("enter"))},
+	{"Close the search", press("esc")},
+}
+
+// runDemo plays the demo script with pace between steps, typing a key every
+// fifth of it, then quits. Keys go through the same queue as the
+// terminal's, and the user's key bindings apply to them. Run returns an
+// error if an operation failed along the way, so the demo doubles as a smoke
+// test.
+func (a *App) runDemo(pace time.Duration, remap map[string]string, stop <-chan struct{}) {
+	if pace <= 0 {
+		pace = defaultDemoPace
+	}
+	wait := func(d time.Duration) bool {
+		select {
+		case <-stop:
+			return false
+		case <-time.After(d):
+			return true
+		}
+	}
+	send := func(key tcell.Key, ch rune) bool {
+		mod := tcell.ModNone
+		if key >= tcell.KeyCtrlA && key <= tcell.KeyCtrlZ {
+			mod = tcell.ModCtrl
+		}
+		a.app.QueueEvent(tcell.NewEventKey(key, ch, mod))
+		return wait(pace / 5)
+	}
+
+	if !wait(pace) {
+		return
+	}
+	for i, step := range demoScript {
+		caption := fmt.Sprintf("Demo %d/%d: %s", i+1, len(demoScript), step.caption)
+		a.queueUpdate(func() {
+			a.demoCaption = caption
+			a.refreshStatus()
+		})
+		for _, input := range step.inputs {
+			for _, ch := range input.text {
+				if !send(tcell.KeyRune, ch) {
+					return
+				}
+			}
+			if input.key == "" {
+				continue
+			}
+			key, ch, err := demoKey(input.key, remap)
+			if err != nil {
+				a.app.QueueUpdate(func() {
+					a.demoErr = fmt.Errorf("demo: %w", err)
+					a.app.Stop()
+				})
+				return
+			}
+			if !send(key, ch) {
+				return
+			}
+		}
+		if !wait(pace) {
+			return
+		}
+	}
+	a.app.QueueUpdate(func() {
+		if count := len(a.failures); count > 0 {
+			last := a.failures[count-1]
+			a.demoErr = fmt.Errorf("demo: %d operations failed, the last %s %s: %w", count, last.operation, last.target, last.err)
+		}
+		a.app.Stop()
+	})
+}
+
+// demoKey reads a key of the demo script, as moved by remap.
+func demoKey(name string, remap map[string]string) (tcell.Key, rune, error) {
+	if to, ok := remap[name]; ok {
+		name = to
+	}
+	if name == "shift-tab" {
+		return tcell.KeyBacktab, 0, nil
+	}
+	return parseKey(name)
+}
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"storage-tui/internal/azure"
+	"storage-tui/internal/cache"
+)
+
+// defaultDiskCacheSize caps the disk cache when Options.DiskCacheSize is
+// empty.
+const defaultDiskCacheSize = 256 << 20
+
+// cachedListing is the first load of a container, as kept on disk.
+type cachedListing struct {
+	Blobs      []azure.Blob `json:"blobs"`
+	NextMarker string       `json:"nextMarker,omitempty"`
+}
+
+// openDiskCache opens the disk cache Options asks for, or returns nil when it
+// is off. An invalid size keeps the default.
+func openDiskCache(opts Options) (*cache.Cache, error) {
+	if !opts.DiskCache {
+		return nil, nil
+	}
+	size := int64(defaultDiskCacheSize)
+	var sizeErr error
+	if opts.DiskCacheSize != "" {
+		if size, sizeErr = parseSize(opts.DiskCacheSize); sizeErr != nil {
+			size = defaultDiskCacheSize
+		}
+	}
+	dir := opts.DiskCacheDir
+	if dir == "" {
+		var err error
+		if dir, err = cache.DefaultDir(); err != nil {
+			return nil, err
+		}
+	}
+	disk, err := cache.Open(dir, size)
+	if err != nil {
+		return nil, err
+	}
+	return disk, sizeErr
+}
+
+// cached stores what call returns under kind and key for the active profile.
+// When the call fails, other than by being canceled, the stored copy is
+// returned instead, so what was seen before can still be browsed offline.
+func cached[T any](ctx context.Context, s *switchableProvider, kind, key string, call func() (T, error)) (T, error) {
+	result, err := call()
+	if s.disk == nil || ctx.Err() != nil {
+		return result, err
+	}
+	key = s.cacheScope() + "\x00" + key
+	if err == nil {
+		if storeErr := s.disk.Store(kind, key, result); storeErr != nil && s.log != nil {
+			s.log.Warn("disk cache store failed", "kind", kind, "error", storeErr)
+		}
+		return result, nil
+	}
+	var stored T
+	saved, ok := s.disk.Load(kind, key, &stored)
+	if !ok {
+		return result, err
+	}
+	if s.log != nil {
+		s.log.Warn(kind+" served from the disk cache", "saved", saved, "error", err)
+	}
+	if s.onOffline != nil {
+		s.onOffline(saved)
+	}
+	return stored, nil
+}
+
+// listingKey names the first load of the container listed by prefix.
+func listingKey(profile string, container itemRef, prefix string) string {
+	return fmt.Sprintf("%s\x00%s/%s\x00%s", profile, container.Account, container.Container, prefix)
+}
+
+// loadCachedListing returns the first load of the container as the disk cache
+// last saw it.
+func (a *App) loadCachedListing(container itemRef, prefix string) (azure.BlobPage, time.Time, bool) {
+	if a.disk == nil {
+		return azure.BlobPage{}, time.Time{}, false
+	}
+	var listing cachedListing
+	saved, ok := a.disk.Load("listings", listingKey(a.profile.Name, container, prefix), &listing)
+	a.metrics.ObserveCache("listings on disk", ok)
+	return azure.BlobPage{Blobs: listing.Blobs, NextMarker: listing.NextMarker}, saved, ok
+}
+
+// storeListing keeps the first load of the container for the next launch. It
+// runs off the UI goroutine.
+func (a *App) storeListing(profile string, container itemRef, prefix string, page azure.BlobPage) {
+	if a.disk == nil {
+		return
+	}
+	listing := cachedListing{Blobs: page.Blobs, NextMarker: page.NextMarker}
+	if err := a.disk.Store("listings", listingKey(profile, container, prefix), listing); err != nil {
+		a.log.Warn("disk cache store failed", "kind", "listings", "error", err)
+	}
+}
+
+// loadCachedPreview returns the preview text kept on disk for key, which
+// names the blob's version, so a changed blob misses.
+func (a *App) loadCachedPreview(key string) (string, bool) {
+	if a.disk == nil {
+		return "", false
+	}
+	var text string
+	_, ok := a.disk.Load("previews", key, &text)
+	a.metrics.ObserveCache("previews on disk", ok)
+	return text, ok
+}
+
+func (a *App) storeCachedPreview(key, text string) {
+	if a.disk == nil {
+		return
+	}
+	if err := a.disk.Store("previews", key, text); err != nil {
+		a.log.Warn("disk cache store failed", "kind", "previews", "error", err)
+	}
+}
+
+// showOffline says that a failed call was answered from the disk cache.
+// Provider calls report it from their own goroutines.
+func (a *App) showOffline(saved time.Time) {
+	go a.app.QueueUpdateDraw(func() {
+		a.flashErr(fmt.Sprintf("Offline: showing what was cached %s.", a.formatTimestamp(saved)))
+	})
+}
@@ -0,0 +1,232 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"storage-tui/internal/export"
+	"storage-tui/internal/report"
+)
+
+// exportFormats lists the listing formats, then the report formats, which
+// add where the listing comes from and what it adds up to.
+var exportFormats = slices.Concat(export.Formats, report.Formats)
+
+// exportFormatForPath returns the format a file extension names, or "" for
+// none.
+func exportFormatForPath(path string) string {
+	if format := export.FormatForPath(path); format != "" {
+		return format
+	}
+	return report.FormatForPath(path)
+}
+
+func (a *App) setupExportModal() {
+	format := tview.NewDropDown().
+		SetLabel("Format: ").
+		SetOptions(exportFormats, nil)
+	input := tview.NewInputField().
+		SetLabel("File: ").
+		SetFieldWidth(0)
+	form := tview.NewForm().
+		AddFormItem(format).
+		AddFormItem(input).
+		AddButton("Export", func() {
+			a.applyExport()
+		}).
+		AddButton("Cancel", a.closeExportModal)
+	form.SetBorder(true)
+	form.SetButtonsAlign(tview.AlignRight)
+	form.SetCancelFunc(a.closeExportModal)
+
+	// Picking another format renames the file to match, unless it was given
+	// an extension of its own.
+	format.SetSelectedFunc(func(option string, _ int) {
+		path := input.GetText()
+		extension := filepath.Ext(path)
+		if extension == "" || exportFormatForPath(path) != "" {
+			input.SetText(strings.TrimSuffix(path, extension) + "." + option)
+		}
+	})
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEnter:
+			a.applyExport()
+			return nil
+		case tcell.KeyEsc:
+			a.closeExportModal()
+			return nil
+		}
+		return event
+	})
+
+	a.exportForm = form
+	a.exportFormat = format
+	a.exportInput = input
+	a.pages.AddPage("export", centerModal(form, 9, 76), true, false)
+}
+
+// openExportModal asks where to write the blobs the contents table shows:
+// the listed blobs the filter lets through, within the open folder in folder
+// view.
+func (a *App) openExportModal() {
+	blobs := a.exportedBlobs()
+	if len(blobs) == 0 {
+		a.flashErr("No blobs listed to export.")
+		return
+	}
+	a.exportOpen = true
+	a.exportForm.SetTitle(fmt.Sprintf("Export %d blobs", len(blobs)))
+	format, _ := a.exportFormat.GetCurrentOption()
+	if format < 0 {
+		format = 0
+		a.exportFormat.SetCurrentOption(0)
+	}
+	name := a.contentSource.Container
+	if name == "" {
+		name = a.contentSource.Account
+	}
+	a.exportInput.SetText(name + "." + exportFormats[format])
+	a.exportForm.SetFocus(1)
+	a.pages.ShowPage("export")
+	a.app.SetFocus(a.exportInput)
+}
+
+func (a *App) closeExportModal() {
+	a.pages.HidePage("export")
+	a.exportOpen = false
+	a.setActivePane(paneContents)
+}
+
+func (a *App) exportedBlobs() []itemRef {
+	prefix := ""
+	if a.folderView {
+		prefix = a.folderPrefix
+	}
+	var blobs []itemRef
+	for _, ref := range a.filteredBlobs() {
+		if strings.HasPrefix(ref.Name, prefix) {
+			blobs = append(blobs, ref)
+		}
+	}
+	return blobs
+}
+
+// applyExport fetches the tier and tags of every exported blob, which the
+// listing does not return, then writes the file.
+func (a *App) applyExport() {
+	path := strings.TrimSpace(a.exportInput.GetText())
+	if path == "" {
+		a.flashErr("Name the file to export to.")
+		return
+	}
+	// An extension typed over the one the format put there wins.
+	format := exportFormatForPath(path)
+	if format == "" {
+		_, format = a.exportFormat.GetCurrentOption()
+	}
+	blobs := a.exportedBlobs()
+	a.closeExportModal()
+	a.runExport(path, format, blobs)
+}
+
+// runExport writes blobs to path in format, in the background.
+func (a *App) runExport(path, format string, blobs []itemRef) {
+	a.flash(fmt.Sprintf("Exporting %d blobs…", len(blobs)))
+	summary := a.exportReport()
+
+	runAsync(a, paneContents, func(ctx context.Context) (int, error) {
+		rows := make([]export.Blob, 0, len(blobs))
+		for _, ref := range blobs {
+			props, err := a.provider.GetBlobProperties(ctx, ref.Account, ref.Container, ref.Name)
+			if err != nil {
+				return 0, fmt.Errorf("%s: %w", ref.Name, err)
+			}
+			rows = append(rows, export.Blob{
+				Account:     ref.Account,
+				Container:   ref.Container,
+				Name:        ref.Name,
+				SizeBytes:   props.SizeBytes,
+				Modified:    props.Modified,
+				ContentType: props.ContentType,
+				Tier:        props.AccessTier,
+				Tags:        props.Tags,
+			})
+		}
+		var data bytes.Buffer
+		var err error
+		if slices.Contains(report.Formats, format) {
+			summary.Blobs = rows
+			err = report.Write(&data, format, summary)
+		} else {
+			err = export.Write(&data, format, rows)
+		}
+		if err != nil {
+			return 0, err
+		}
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, data.Bytes(), 0o644); err != nil {
+			return 0, err
+		}
+		return len(rows), os.Rename(tmp, path)
+	}, func(count int, err error) {
+		if err != nil {
+			a.recordFailure("export", path, err, func() { a.runExport(path, format, blobs) })
+			a.flashErr(fmt.Sprintf("Export failed: %v", err))
+			return
+		}
+		a.flash(fmt.Sprintf("Exported %d blobs to %s.", count, path))
+	})
+}
+
+// exportReport describes the contents table for a report: where its
+// listing comes from and what narrows it. The blobs are added once their
+// tiers and tags are fetched.
+func (a *App) exportReport() report.Report {
+	source := a.contentSource
+	// The report is written in the background, so it formats with copies of
+	// the display settings. A relative time would be wrong by the time the
+	// report is read, so those are written in UTC.
+	exact, mode := a.exactSizes, a.timeMode
+	if mode == timeRelative {
+		mode = timeUTC
+	}
+	r := report.Report{
+		Generated:  time.Now(),
+		Scope:      a.listedScopePrefix(),
+		Partial:    a.contentsMarker != "",
+		FormatSize: func(size int64) string { return formatSizeAs(size, exact) },
+		FormatTime: mode.format,
+	}
+	if source.SubscriptionName != "" {
+		r.Location = append(r.Location, report.Field{Name: "Subscription", Value: source.SubscriptionName})
+	}
+	r.Location = append(r.Location, report.Field{Name: "Account", Value: source.Account})
+	if source.Kind == kindAccount {
+		r.Title = fmt.Sprintf("Blobs of %s tagged %s", source.Account, a.tagsExpression)
+		r.Location = append(r.Location, report.Field{Name: "Tag search", Value: a.tagsExpression})
+	} else {
+		r.Title = fmt.Sprintf("Blobs of %s/%s", source.Account, source.Container)
+		r.Location = append(r.Location, report.Field{Name: "Container", Value: source.Container})
+	}
+	if a.listPrefix != "" {
+		r.Location = append(r.Location, report.Field{Name: "Prefix", Value: a.listPrefix})
+	}
+	if a.folderView && a.folderPrefix != "" {
+		r.Title += "/" + a.folderPrefix
+		r.Location = append(r.Location, report.Field{Name: "Folder", Value: a.folderPrefix})
+	}
+	if a.contentsFilter != nil {
+		r.Location = append(r.Location, report.Field{Name: "Filter", Value: a.contentsFilter.summary()})
+	}
+	return r
+}
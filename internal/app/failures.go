@@ -0,0 +1,197 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// maxFailures caps how many failures the failures page keeps; the oldest go
+// first.
+const maxFailures = 200
+
+// failure is an operation that failed, kept so it can be reviewed and
+// retried after the status bar and Details have moved on.
+type failure struct {
+	time time.Time
+	// operation names what failed, such as "list blobs", and target where,
+	// such as az://acme-dev/logs.
+	operation string
+	target    string
+	err       error
+	// retry runs the operation again; nil when it cannot be repeated from
+	// the failures page.
+	retry func()
+}
+
+// recordFailure keeps a failed operation for the failures page. Canceled
+// operations are not failures and are not kept.
+func (a *App) recordFailure(operation, target string, err error, retry func()) {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	a.failures = append(a.failures, failure{time: time.Now(), operation: operation, target: target, err: err, retry: retry})
+	if len(a.failures) > maxFailures {
+		a.failures = a.failures[len(a.failures)-maxFailures:]
+	}
+	a.failuresUnseen++
+	if a.failuresOpen {
+		a.renderFailures()
+	}
+	a.refreshStatus()
+}
+
+// refTarget names the location of ref in failures, such as
+// az://acme-dev/logs.
+func refTarget(ref itemRef) string {
+	switch {
+	case ref.Account == "":
+		return ref.SubscriptionName
+	case ref.Container == "":
+		return "az://" + ref.Account
+	case ref.Kind == kindBlob, ref.Kind == kindEntry:
+		return fmt.Sprintf("az://%s/%s/%s", ref.Account, ref.Container, contentName(ref))
+	}
+	return fmt.Sprintf("az://%s/%s", ref.Account, ref.Container)
+}
+
+func (a *App) setupFailuresModal() {
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	detail := tview.NewTextView().
+		SetWordWrap(true)
+	detail.SetBorder(true).SetTitle("Error")
+	table.SetSelectionChangedFunc(func(row, _ int) {
+		a.showFailureDetail(row - 1)
+	})
+	table.SetSelectedFunc(func(row, _ int) {
+		a.retryFailure(row - 1)
+	})
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		row, _ := table.GetSelection()
+		switch {
+		case event.Key() == tcell.KeyEsc || event.Rune() == '!' || event.Rune() == 'q':
+			a.closeFailuresModal()
+			return nil
+		case event.Rune() == 'r':
+			a.retryFailure(row - 1)
+			return nil
+		case event.Rune() == 'd':
+			a.dismissFailure(row - 1)
+			return nil
+		case event.Rune() == 'D':
+			a.failures = nil
+			a.renderFailures()
+			return nil
+		}
+		return event
+	})
+
+	view := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(detail, 7, 0, false)
+	view.SetBorder(true).SetTitle("Failures  enter/r: retry | d: dismiss | D: dismiss all | esc: close")
+
+	a.failuresView = view
+	a.failuresTable = table
+	a.failuresDetail = detail
+	a.pages.AddPage("failures", centerModal(view, 26, 120), true, false)
+}
+
+// openFailuresModal lists the operations that failed since launch, latest
+// first, with the full error of the selected one.
+func (a *App) openFailuresModal() {
+	a.failuresOpen = true
+	a.failuresUnseen = 0
+	a.renderFailures()
+	a.pages.ShowPage("failures")
+	a.app.SetFocus(a.failuresTable)
+	a.refreshStatus()
+}
+
+func (a *App) closeFailuresModal() {
+	a.pages.HidePage("failures")
+	a.failuresOpen = false
+	a.setActivePane(a.activePane)
+}
+
+// failureAt returns the failure on row index of the page, which lists them
+// latest first.
+func (a *App) failureAt(index int) (failure, bool) {
+	if index < 0 || index >= len(a.failures) {
+		return failure{}, false
+	}
+	return a.failures[len(a.failures)-1-index], true
+}
+
+func (a *App) renderFailures() {
+	table := a.failuresTable
+	row, _ := table.GetSelection()
+	table.Clear()
+	if len(a.failures) == 0 {
+		table.SetCell(0, 0, tview.NewTableCell("Nothing has failed.").SetSelectable(false))
+		a.failuresDetail.SetText("")
+		return
+	}
+	for column, title := range []string{"Time", "Operation", "Target", "Error"} {
+		table.SetCell(0, column, tview.NewTableCell(title).SetAttributes(tcell.AttrBold).SetSelectable(false))
+	}
+	for index := range a.failures {
+		failure, _ := a.failureAt(index)
+		color := a.theme.failure
+		if failure.retry == nil {
+			color = a.theme.muted
+		}
+		table.SetCell(index+1, 0, tview.NewTableCell(a.formatTimestamp(failure.time)))
+		table.SetCell(index+1, 1, tview.NewTableCell(tview.Escape(failure.operation)).SetTextColor(color))
+		table.SetCell(index+1, 2, tview.NewTableCell(tview.Escape(failure.target)).SetMaxWidth(40))
+		table.SetCell(index+1, 3, tview.NewTableCell(tview.Escape(failure.err.Error())).SetMaxWidth(50))
+	}
+	table.Select(min(max(row, 1), len(a.failures)), 0)
+	a.showFailureDetail(min(max(row, 1), len(a.failures)) - 1)
+}
+
+// showFailureDetail shows the full error of the failure on row index, which
+// the table cuts short.
+func (a *App) showFailureDetail(index int) {
+	failure, ok := a.failureAt(index)
+	if !ok {
+		return
+	}
+	text := fmt.Sprintf("%s %s at %s:\n%v", failure.operation, failure.target, a.formatTimestamp(failure.time), failure.err)
+	if failure.retry == nil {
+		text += "\n\nThis operation cannot be retried from here."
+	}
+	a.failuresDetail.SetText(text).ScrollToBeginning()
+}
+
+// retryFailure closes the page and runs the failure on row index again. It
+// leaves the list, and comes back as a new failure if it fails again.
+func (a *App) retryFailure(index int) {
+	failure, ok := a.failureAt(index)
+	switch {
+	case !ok:
+		return
+	case failure.retry == nil:
+		a.flashErr(fmt.Sprintf("%s cannot be retried from here.", failure.operation))
+		return
+	}
+	a.dismissFailure(index)
+	a.closeFailuresModal()
+	a.flash(fmt.Sprintf("Retrying %s %s…", failure.operation, failure.target))
+	failure.retry()
+}
+
+func (a *App) dismissFailure(index int) {
+	if index < 0 || index >= len(a.failures) {
+		return
+	}
+	position := len(a.failures) - 1 - index
+	a.failures = append(a.failures[:position], a.failures[position+1:]...)
+	a.renderFailures()
+}
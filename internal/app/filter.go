@@ -0,0 +1,400 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// filterDelay is how long typing in the filter dialog pauses before the
+// contents are filtered again.
+const filterDelay = 150 * time.Millisecond
+
+// filterFields are the values of the filter form as typed.
+type filterFields struct {
+	Name        string
+	After       string
+	Before      string
+	MinSize     string
+	MaxSize     string
+	ContentType string
+}
+
+// blobFilter narrows the listed blobs shown in the contents table. Only the
+// pages listed so far are filtered; loading more pages filters those too.
+type blobFilter struct {
+	fields filterFields
+	// name matches lowercase names; substring is the lowercase text of a
+	// plain name pattern, without wildcards or re:.
+	name        func(string) bool
+	substring   string
+	after       time.Time
+	before      time.Time
+	minSize     int64
+	maxSize     int64
+	contentType string
+}
+
+var filterLabels = []string{"Name: ", "Modified after: ", "Modified before: ", "Min size: ", "Max size: ", "Content type: "}
+
+// filterNames name the fields in summaries, in the order of filterLabels.
+var filterNames = []string{"name", "after", "before", "min", "max", "type"}
+
+func (a *App) setupFilterModal() {
+	form := tview.NewForm()
+	for _, label := range filterLabels {
+		input := tview.NewInputField().
+			SetLabel(label).
+			SetFieldWidth(0)
+		input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+			switch event.Key() {
+			case tcell.KeyEnter:
+				a.applyFilterForm()
+				return nil
+			case tcell.KeyEsc:
+				a.cancelFilterModal()
+				return nil
+			}
+			return event
+		})
+		input.SetChangedFunc(func(string) {
+			a.scheduleFilterPreview()
+		})
+		form.AddFormItem(input)
+		a.filterInputs = append(a.filterInputs, input)
+	}
+	a.filterInputs[0].SetPlaceholder("report, *.log, or re:^logs/2024-0[56]")
+	a.filterInputs[1].SetPlaceholder("2024-05-01, 2024-05-01 12:00, or 7d")
+	a.filterInputs[3].SetPlaceholder("512, 10 KB, 1.5 MB")
+	a.filterInputs[5].SetPlaceholder("text/, image/*, application/json")
+	form.AddButton("Apply", a.applyFilterForm).
+		AddButton("Clear", func() {
+			a.closeFilterModal()
+			a.clearContentsFilter()
+		}).
+		AddButton("Cancel", a.cancelFilterModal)
+	form.SetItemPadding(0)
+	form.SetBorder(true).SetTitle("Filter blobs")
+	form.SetButtonsAlign(tview.AlignRight)
+	form.SetCancelFunc(a.cancelFilterModal)
+
+	a.filterForm = form
+	a.pages.AddPage("filter", centerModal(form, 12, 72), true, false)
+}
+
+// openFilterModal edits the filter of the listed container, starting from the
+// active one. The contents follow the dialog as it is typed in; cancelling
+// puts the filter back as it was.
+func (a *App) openFilterModal() {
+	if a.contentSource.Kind != kindContainer {
+		a.flashErr("List a container to filter its blobs.")
+		return
+	}
+	var fields filterFields
+	if a.contentsFilter != nil {
+		fields = a.contentsFilter.fields
+	}
+	for i, value := range fields.values() {
+		a.filterInputs[i].SetText(value)
+	}
+	a.filterSeq++
+	a.filterBefore = a.contentsFilter
+	a.updateFilterTitle("")
+	a.filterOpen = true
+	a.filterForm.SetFocus(0)
+	a.pages.ShowPage("filter")
+	a.app.SetFocus(a.filterForm)
+}
+
+func (a *App) closeFilterModal() {
+	a.pages.HidePage("filter")
+	a.filterOpen = false
+	a.filterSeq++
+	a.setActivePane(paneContents)
+}
+
+// cancelFilterModal closes the dialog and undoes what typing in it filtered.
+func (a *App) cancelFilterModal() {
+	a.closeFilterModal()
+	if a.contentsFilter != a.filterBefore {
+		a.contentsFilter = a.filterBefore
+		a.renderContents(true)
+	}
+}
+
+// scheduleFilterPreview filters the contents by the dialog once typing
+// pauses for filterDelay.
+func (a *App) scheduleFilterPreview() {
+	a.filterSeq++
+	seq := a.filterSeq
+	time.AfterFunc(filterDelay, func() {
+		a.app.QueueUpdateDraw(func() {
+			if seq == a.filterSeq && a.filterOpen {
+				a.previewFilterForm()
+			}
+		})
+	})
+}
+
+// previewFilterForm filters the contents by the dialog as typed so far. While
+// a field does not parse, the last valid filter stays and the dialog title
+// says what is wrong.
+func (a *App) previewFilterForm() {
+	filter, err := a.compileFilterFields(a.filterFormFields())
+	if err != nil {
+		a.updateFilterTitle(err.Error())
+		return
+	}
+	a.contentsFilter = filter
+	a.renderContents(true)
+	a.updateFilterTitle("")
+}
+
+// updateFilterTitle shows how many listed blobs the dialog lets through, or
+// problem when there is one.
+func (a *App) updateFilterTitle(problem string) {
+	title := fmt.Sprintf("Filter blobs  %d of %d shown", len(a.filteredBlobs()), len(a.listedBlobs))
+	if problem != "" {
+		title = "Filter blobs  " + problem
+	}
+	a.filterForm.SetTitle(tview.Escape(title))
+}
+
+func (a *App) applyFilterForm() {
+	filter, err := a.compileFilterFields(a.filterFormFields())
+	if err != nil {
+		a.flashErr(fmt.Sprintf("Filter: %v", err))
+		return
+	}
+	a.closeFilterModal()
+	a.contentsFilter = filter
+	a.renderContents(true)
+}
+
+// filterFormFields reads the filter dialog.
+func (a *App) filterFormFields() filterFields {
+	var values []string
+	for _, input := range a.filterInputs {
+		values = append(values, input.GetText())
+	}
+	return filterFields{values[0], values[1], values[2], values[3], values[4], values[5]}
+}
+
+// compileFilterFields compiles fields with times read in the displayed zone.
+func (a *App) compileFilterFields(fields filterFields) (*blobFilter, error) {
+	location := time.UTC
+	if a.timeMode == timeLocal {
+		location = time.Local
+	}
+	return compileFilter(fields, location, time.Now())
+}
+
+// clearContentsFilter shows every listed blob again.
+func (a *App) clearContentsFilter() bool {
+	if a.contentsFilter == nil {
+		return false
+	}
+	a.contentsFilter = nil
+	a.renderContents(true)
+	a.flash("Filter cleared.")
+	return true
+}
+
+// filteredBlobs returns the listed blobs the active filter lets through,
+// from the name index of the listing.
+func (a *App) filteredBlobs() []itemRef {
+	if a.contentsFilter == nil {
+		return a.listedBlobs
+	}
+	a.blobNames.sync(a.listedBlobs)
+	return a.blobNames.filter(a.contentsFilter)
+}
+
+// fieldValues returns the typed fields of f, which may be nil.
+func (f *blobFilter) fieldValues() filterFields {
+	if f == nil {
+		return filterFields{}
+	}
+	return f.fields
+}
+
+func (f filterFields) values() []string {
+	return []string{f.Name, f.After, f.Before, f.MinSize, f.MaxSize, f.ContentType}
+}
+
+// compileFilter parses the form values. Times without a zone are read in
+// location; ages such as 7d count back from now. It returns nil when every
+// field is empty.
+func compileFilter(fields filterFields, location *time.Location, now time.Time) (*blobFilter, error) {
+	filter := &blobFilter{fields: fields, minSize: -1, maxSize: -1}
+	empty := true
+	var err error
+	if strings.TrimSpace(fields.Name) != "" {
+		if filter.name, err = blobMatcher(fields.Name); err != nil {
+			return nil, err
+		}
+		if pattern := strings.TrimSpace(fields.Name); !strings.HasPrefix(pattern, "re:") && !strings.ContainsAny(pattern, "*?[") {
+			filter.substring = strings.ToLower(pattern)
+		}
+		empty = false
+	}
+	if value := strings.TrimSpace(fields.After); value != "" {
+		if filter.after, err = parseFilterTime(value, location, now); err != nil {
+			return nil, err
+		}
+		empty = false
+	}
+	if value := strings.TrimSpace(fields.Before); value != "" {
+		if filter.before, err = parseFilterTime(value, location, now); err != nil {
+			return nil, err
+		}
+		empty = false
+	}
+	if value := strings.TrimSpace(fields.MinSize); value != "" {
+		if filter.minSize, err = parseSize(value); err != nil {
+			return nil, err
+		}
+		empty = false
+	}
+	if value := strings.TrimSpace(fields.MaxSize); value != "" {
+		if filter.maxSize, err = parseSize(value); err != nil {
+			return nil, err
+		}
+		empty = false
+	}
+	if filter.minSize >= 0 && filter.maxSize >= 0 && filter.minSize > filter.maxSize {
+		return nil, errors.New("min size is larger than max size")
+	}
+	if !filter.after.IsZero() && !filter.before.IsZero() && !filter.after.Before(filter.before) {
+		return nil, errors.New("modified after must be earlier than modified before")
+	}
+	if value := strings.ToLower(strings.TrimSpace(fields.ContentType)); value != "" {
+		if _, err := path.Match(value, ""); err != nil {
+			return nil, fmt.Errorf("invalid content type pattern %q", value)
+		}
+		filter.contentType = value
+		empty = false
+	}
+	if empty {
+		return nil, nil
+	}
+	return filter, nil
+}
+
+func (f *blobFilter) matches(ref itemRef) bool {
+	return f.matchesLower(ref, strings.ToLower(ref.Name))
+}
+
+// matchesLower is matches for a blob whose name is already lowercase in
+// lower.
+func (f *blobFilter) matchesLower(ref itemRef, lower string) bool {
+	if f.name != nil && !f.name(lower) {
+		return false
+	}
+	if !f.after.IsZero() && ref.Modified.Before(f.after) {
+		return false
+	}
+	if !f.before.IsZero() && !ref.Modified.Before(f.before) {
+		return false
+	}
+	if f.minSize >= 0 && ref.SizeBytes < f.minSize {
+		return false
+	}
+	if f.maxSize >= 0 && ref.SizeBytes > f.maxSize {
+		return false
+	}
+	if f.contentType != "" {
+		contentType := strings.ToLower(ref.ContentType)
+		if strings.ContainsAny(f.contentType, "*?[") {
+			ok, _ := path.Match(f.contentType, contentType)
+			return ok
+		}
+		return strings.HasPrefix(contentType, f.contentType)
+	}
+	return true
+}
+
+// describe lists the fields that are set, as typed.
+func (f filterFields) describe() string {
+	var parts []string
+	for i, value := range f.values() {
+		if value = strings.TrimSpace(value); value != "" {
+			parts = append(parts, filterNames[i]+" "+value)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// summary describes the filter for the contents title.
+func (f *blobFilter) summary() string {
+	var parts []string
+	if f.name != nil {
+		parts = append(parts, "name "+strings.TrimSpace(f.fields.Name))
+	}
+	if !f.after.IsZero() {
+		parts = append(parts, "after "+strings.TrimSpace(f.fields.After))
+	}
+	if !f.before.IsZero() {
+		parts = append(parts, "before "+strings.TrimSpace(f.fields.Before))
+	}
+	if f.minSize >= 0 {
+		parts = append(parts, ">= "+formatBytes(f.minSize))
+	}
+	if f.maxSize >= 0 {
+		parts = append(parts, "<= "+formatBytes(f.maxSize))
+	}
+	if f.contentType != "" {
+		parts = append(parts, "type "+f.contentType)
+	}
+	return strings.Join(parts, ", ")
+}
+
+var filterAgePattern = regexp.MustCompile(`^(\d+)\s*([mhdw])$`)
+
+var filterTimeLayouts = []string{"2006-01-02", "2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02T15:04:05"}
+
+// parseFilterTime reads a date, a date and time, an RFC 3339 timestamp, or an
+// age in minutes, hours, days, or weeks (30m, 12h, 7d, 2w).
+func parseFilterTime(value string, location *time.Location, now time.Time) (time.Time, error) {
+	if match := filterAgePattern.FindStringSubmatch(strings.ToLower(value)); match != nil {
+		count, _ := strconv.Atoi(match[1])
+		unit := map[string]time.Duration{"m": time.Minute, "h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour}[match[2]]
+		return now.Add(-time.Duration(count) * unit), nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	for _, layout := range filterTimeLayouts {
+		if parsed, err := time.ParseInLocation(layout, value, location); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected 2024-05-01, 2024-05-01 12:00, or an age like 7d", value)
+}
+
+var sizePattern = regexp.MustCompile(`(?i)^(\d+(?:\.\d+)?)\s*([kmgt]?)(?:i?b)?$`)
+
+// parseSize reads a byte count with an optional K, M, G, or T unit. Units are
+// powers of 1024, as in the displayed sizes.
+func parseSize(value string) (int64, error) {
+	match := sizePattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0, fmt.Errorf("invalid size %q, expected e.g. 512, 10 KB, or 1.5 MB", value)
+	}
+	number, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	shift := 0
+	if match[2] != "" {
+		shift = strings.Index("kmgt", strings.ToLower(match[2])) + 1
+	}
+	return int64(number * float64(int64(1)<<(10*shift))), nil
+}
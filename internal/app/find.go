@@ -0,0 +1,329 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"storage-tui/internal/azure"
+)
+
+const (
+	// findWorkers is how many containers are scanned at once.
+	findWorkers = 4
+	// findLimit stops a search once this many blobs matched.
+	findLimit = 5000
+)
+
+// findProgress counts what a blob search has covered so far.
+type findProgress struct {
+	containers int
+	scanned    int
+	failures   int
+	running    bool
+	outcome    string
+}
+
+func (a *App) setupFindModal() {
+	input := tview.NewInputField().
+		SetLabel("Blob name or pattern: ").
+		SetFieldWidth(0)
+	results := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(0, 0)
+	view := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(input, 1, 0, true).
+		AddItem(results, 0, 1, false)
+	view.SetBorder(true)
+
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEnter:
+			a.startFind(input.GetText())
+			return nil
+		case tcell.KeyEsc:
+			a.stopOrCloseFind()
+			return nil
+		case tcell.KeyDown, tcell.KeyTab:
+			if len(a.findRefs) > 0 {
+				a.app.SetFocus(results)
+			}
+			return nil
+		}
+		return event
+	})
+	results.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		row, _ := results.GetSelection()
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			a.stopOrCloseFind()
+			return nil
+		case event.Key() == tcell.KeyTab || event.Key() == tcell.KeyBacktab || event.Rune() == '/',
+			event.Key() == tcell.KeyUp && row == 0:
+			a.app.SetFocus(input)
+			return nil
+		}
+		return event
+	})
+	results.SetSelectedFunc(func(row, _ int) {
+		a.jumpToFindResult(row)
+	})
+
+	a.findInput = input
+	a.findResults = results
+	a.findView = view
+	a.updateFindTitle()
+	a.pages.AddPage("find", centerModal(view, 24, 100), true, false)
+}
+
+// openFindModal shows the blob search. The previous pattern and results
+// stay until the next search starts.
+func (a *App) openFindModal() {
+	a.findOpen = true
+	a.pages.ShowPage("find")
+	a.app.SetFocus(a.findInput)
+}
+
+func (a *App) closeFindModal() {
+	a.pages.HidePage("find")
+	a.findOpen = false
+	a.setActivePane(a.activePane)
+}
+
+// stopOrCloseFind stops a running search, or closes the dialog when none is
+// running.
+func (a *App) stopOrCloseFind() {
+	if a.findProgress.running {
+		a.findJob.stop()
+		return
+	}
+	a.closeFindModal()
+}
+
+// startFind searches every container of the enabled subscriptions for blobs
+// whose names match pattern. Containers are listed by the job's producer and
+// scanned by its workers; matches stream into the results table.
+func (a *App) startFind(pattern string) {
+	match, err := blobMatcher(pattern)
+	if err != nil {
+		a.flashErr(fmt.Sprintf("Find: %v", err))
+		return
+	}
+	var subscriptions []itemRef
+	for _, node := range a.root.GetChildren() {
+		ref, ok := node.GetReference().(itemRef)
+		if ok && ref.Kind == kindSubscription && a.isSubscriptionEnabled(ref.SubscriptionID) {
+			subscriptions = append(subscriptions, ref)
+		}
+	}
+	if len(subscriptions) == 0 {
+		a.flashErr("Find: no subscriptions are selected.")
+		return
+	}
+
+	a.findJob.stop()
+	a.findSeq++
+	seq := a.findSeq
+	a.findRefs = nil
+	a.findResults.Clear()
+	a.findProgress = findProgress{running: true}
+	a.findRun = a.recordSearch("find", pattern, "selected subscriptions")
+	a.updateFindTitle()
+
+	// update applies a worker's result on the UI goroutine unless a newer
+	// search replaced this one.
+	update := func(apply func()) {
+		a.queueUpdate(func() {
+			if seq == a.findSeq {
+				apply()
+				a.updateFindTitle()
+			}
+		})
+	}
+
+	a.findJob = startJob(a, findWorkers, func(ctx context.Context, queue func(itemRef) bool) error {
+		for _, subscription := range subscriptions {
+			accounts, err := a.provider.ListAccounts(ctx, subscription.SubscriptionID)
+			if err != nil {
+				update(func() { a.findProgress.failures++ })
+				continue
+			}
+			for _, account := range a.shownAccounts(accounts) {
+				containers, err := a.provider.ListContainers(ctx, account.Name)
+				if err != nil {
+					update(func() { a.findProgress.failures++ })
+					continue
+				}
+				update(func() { a.findProgress.containers += len(containers) })
+				for _, container := range containers {
+					ref := itemRef{
+						Kind:             kindContainer,
+						Name:             container.Name,
+						SubscriptionID:   subscription.SubscriptionID,
+						SubscriptionName: subscription.SubscriptionName,
+						Account:          account.Name,
+						Container:        container.Name,
+					}
+					if !queue(ref) {
+						return ctx.Err()
+					}
+				}
+			}
+		}
+		return nil
+	}, func(ctx context.Context, container itemRef) {
+		marker := ""
+		for {
+			page, err := a.provider.ListBlobsPage(ctx, container.Account, container.Container, azure.ListBlobsOptions{
+				Marker:     marker,
+				MaxResults: blobPageSize,
+			})
+			if err != nil {
+				if ctx.Err() == nil {
+					update(func() { a.findProgress.failures++ })
+				}
+				return
+			}
+			var matches []itemRef
+			for _, blob := range page.Blobs {
+				if match(blob.Name) {
+					matches = append(matches, blobRef(container, blob))
+				}
+			}
+			if len(matches) > 0 {
+				update(func() { a.addFindResults(matches) })
+			}
+			if page.NextMarker == "" {
+				break
+			}
+			marker = page.NextMarker
+		}
+		update(func() { a.findProgress.scanned++ })
+	}, func(err error) {
+		if seq != a.findSeq {
+			return
+		}
+		a.findJob = nil
+		a.findProgress.running = false
+		switch {
+		case len(a.findRefs) >= findLimit:
+			a.findProgress.outcome = fmt.Sprintf("stopped at %d matches", findLimit)
+		case errors.Is(err, context.Canceled):
+			a.findProgress.outcome = "stopped"
+		case err != nil:
+			a.findProgress.outcome = fmt.Sprintf("error: %v", err)
+		default:
+			a.findProgress.outcome = "done"
+		}
+		a.finishSearch(a.findRun, a.findProgress.outcome)
+		a.updateFindTitle()
+	})
+}
+
+func (a *App) addFindResults(matches []itemRef) {
+	for _, ref := range matches {
+		if len(a.findRefs) >= findLimit {
+			a.findJob.stop()
+			return
+		}
+		row := len(a.findRefs)
+		a.findRefs = append(a.findRefs, ref)
+		a.addSearchResults(a.findRun, searchResult{
+			ref:    ref,
+			where:  fmt.Sprintf("%s/%s", ref.Account, ref.Container),
+			detail: a.formatSize(ref.SizeBytes),
+		})
+		a.findResults.SetCell(row, 0, tview.NewTableCell(tview.Escape(a.itemLabel(ref))).SetExpansion(1))
+		a.findResults.SetCell(row, 1, tview.NewTableCell(tview.Escape(fmt.Sprintf("%s/%s", ref.Account, ref.Container))).
+			SetTextColor(a.theme.muted))
+		a.findResults.SetCell(row, 2, tview.NewTableCell(a.formatSize(ref.SizeBytes)).
+			SetAlign(tview.AlignRight))
+	}
+}
+
+func (a *App) updateFindTitle() {
+	progress := a.findProgress
+	status := fmt.Sprintf("%d matches, %d of %d containers scanned", len(a.findRefs), progress.scanned, progress.containers)
+	if progress.failures > 0 {
+		status += fmt.Sprintf(", %d failed", progress.failures)
+	}
+	switch {
+	case progress.running:
+		status += "…  esc: stop"
+	case progress.outcome != "":
+		status += " (" + progress.outcome + ")  enter: jump | esc: close"
+	default:
+		status = "enter: search the selected subscriptions | esc: close"
+	}
+	a.findView.SetTitle("Find blobs  " + status)
+}
+
+// jumpToFindResult closes the dialog and reveals the matched blob, listing
+// its container as far as needed.
+func (a *App) jumpToFindResult(row int) {
+	if row < 0 || row >= len(a.findRefs) {
+		return
+	}
+	ref := a.findRefs[row]
+	a.closeFindModal()
+	loc := location{SubscriptionID: ref.SubscriptionID, Account: ref.Account, Container: ref.Container, Blob: ref.Name}
+	a.revealLocation(loc, func(err error) {
+		if err != nil {
+			a.flashErr(fmt.Sprintf("Find: %v", err))
+		}
+	})
+}
+
+// blobMatcher matches blob names case-insensitively. Patterns starting with
+// re: are regular expressions searched for anywhere in the name; patterns with
+// *, ?, or [ are globs matched against the whole name or its last segment;
+// anything else matches as a substring.
+func blobMatcher(pattern string) (func(string) bool, error) {
+	pattern = strings.TrimSpace(pattern)
+	if expression, ok := strings.CutPrefix(pattern, "re:"); ok {
+		if expression == "" {
+			return nil, errors.New("enter a regular expression after re:")
+		}
+		re, err := regexp.Compile("(?i)" + expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %v", expression, regexpReason(err))
+		}
+		return re.MatchString, nil
+	}
+	pattern = strings.ToLower(pattern)
+	if pattern == "" {
+		return nil, errors.New("enter a blob name or pattern")
+	}
+	if !strings.ContainsAny(pattern, "*?[") {
+		return func(name string) bool {
+			return strings.Contains(strings.ToLower(name), pattern)
+		}, nil
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q", pattern)
+	}
+	return func(name string) bool {
+		name = strings.ToLower(name)
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}, nil
+}
+
+// regexpReason trims the expression regexp repeats in its errors.
+func regexpReason(err error) string {
+	var syntaxErr *syntax.Error
+	if errors.As(err, &syntaxErr) {
+		return string(syntaxErr.Code)
+	}
+	return err.Error()
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"strings"
+
+	"storage-tui/internal/azure"
+)
+
+// openNetworkModal shows the firewall of the selected account, or of the
+// account the selection or the listing is in, and its private endpoint
+// connections, to tell why calls from this machine are turned away.
+func (a *App) openNetworkModal() {
+	ref, ok := a.selectedRef()
+	if !ok || ref.Account == "" {
+		ref = a.contentSource
+	}
+	if ref.Account == "" {
+		a.flashErr("Select an account to show its network rules.")
+		return
+	}
+	account := ref.Account
+	a.showPropertiesDialog("Network rules", account, func(ctx context.Context) ([]propertySection, error) {
+		rules, err := a.provider.GetNetworkRules(ctx, account)
+		return networkSections(rules), err
+	})
+}
+
+func networkSections(rules azure.NetworkRules) []propertySection {
+	access := propertySection{Title: "Public network access", Items: []property{
+		{"Reachable from", reachableFrom(rules)},
+		{"Public network access", rules.PublicNetworkAccess},
+		{"Default action", rules.DefaultAction},
+		{"Bypass", orNA(strings.Join(rules.Bypass, ", "))},
+	}}
+	ips := propertySection{Title: "IP rules"}
+	for _, rule := range rules.IPRules {
+		ips.Items = append(ips.Items, property{rule, "allow"})
+	}
+	subnets := propertySection{Title: "Virtual network rules"}
+	for _, rule := range rules.VirtualNetworkRules {
+		subnets.Items = append(subnets.Items, property{subnetName(rule.Subnet), rule.State})
+	}
+	endpoints := propertySection{Title: "Private endpoint connections"}
+	for _, endpoint := range rules.PrivateEndpoints {
+		status := endpoint.Status
+		if endpoint.Description != "" {
+			status += ": " + endpoint.Description
+		}
+		endpoints.Items = append(endpoints.Items, property{endpoint.Name, status})
+	}
+	return []propertySection{access, ips, subnets, endpoints}
+}
+
+// reachableFrom sums up where data-plane calls may come from.
+func reachableFrom(rules azure.NetworkRules) string {
+	switch {
+	case strings.EqualFold(rules.PublicNetworkAccess, "Disabled"):
+		return "private endpoints only"
+	case strings.EqualFold(rules.PublicNetworkAccess, "SecuredByPerimeter"):
+		return "what the network security perimeter allows"
+	case strings.EqualFold(rules.DefaultAction, "Deny"):
+		if len(rules.PrivateEndpoints) > 0 {
+			return "listed IP ranges, subnets, and private endpoints"
+		}
+		return "listed IP ranges and subnets only"
+	}
+	return "all networks"
+}
+
+// subnetName shortens a subnet resource ID to virtual network/subnet.
+func subnetName(id string) string {
+	parts := strings.Split(strings.Trim(id, "/"), "/")
+	for i := 0; i+3 < len(parts); i++ {
+		if strings.EqualFold(parts[i], "virtualNetworks") && strings.EqualFold(parts[i+2], "subnets") {
+			return parts[i+1] + "/" + parts[i+3]
+		}
+	}
+	return id
+}
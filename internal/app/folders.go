@@ -0,0 +1,318 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"storage-tui/internal/azure"
+)
+
+// folderStat aggregates the blobs under one virtual-folder prefix.
+type folderStat struct {
+	Count int
+	Bytes int64
+}
+
+// toggleFolderView switches the contents table between the flat listing and
+// virtual folders split on "/".
+func (a *App) toggleFolderView() {
+	if a.contentSource.Kind != kindContainer {
+		a.flashErr("Select a container before switching to folder view.")
+		return
+	}
+	a.folderView = !a.folderView
+	a.folderPrefix = ""
+	a.renderContents(false)
+	if !a.folderView {
+		a.flash("Folder view off.")
+		return
+	}
+	a.loadFolderStats(a.contentSource)
+	a.flash("Folder view on.")
+}
+
+// addFolderLevelRows adds the rows of the current folder level: a parent row,
+// the sub-folders, then the blobs directly under the prefix. Folders come from
+// the loaded blobs and, once counted, from the whole container.
+func (a *App) addFolderLevelRows(container itemRef) {
+	prefix := a.folderPrefix
+	if prefix != "" {
+		parent := folderRef(container, parentPrefix(prefix))
+		parent.Name = ".."
+		a.addContentRow(parent, parent.Name, "")
+	}
+
+	seen := make(map[string]bool)
+	var folders []string
+	addFolder := func(name string) {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			return
+		}
+		index := strings.Index(rest, "/")
+		if index < 0 {
+			return
+		}
+		folder := prefix + rest[:index+1]
+		if !seen[folder] {
+			seen[folder] = true
+			folders = append(folders, folder)
+		}
+	}
+	blobs := a.filteredBlobs()
+	a.filterStats = nil
+	if a.contentsFilter != nil {
+		a.filterStats = make(map[string]folderStat)
+	}
+	for _, ref := range blobs {
+		addFolder(ref.Name)
+		if a.filterStats != nil {
+			addFolderStats(a.filterStats, azure.Blob{Name: ref.Name, SizeBytes: ref.SizeBytes})
+		}
+	}
+	// Counted folders may hold no blob the filter lets through.
+	if a.folderStatsReady(container) && a.contentsFilter == nil {
+		for folder := range a.folderStats {
+			addFolder(folder)
+		}
+	}
+	sort.Strings(folders)
+	for _, folder := range folders {
+		ref := folderRef(container, folder)
+		a.addContentRow(ref, a.contentLabel(ref), a.formatContentDetails(ref))
+	}
+
+	for _, ref := range blobs {
+		rest, ok := strings.CutPrefix(ref.Name, prefix)
+		if ok && !strings.Contains(rest, "/") {
+			a.addContentRow(ref, a.contentLabel(ref), a.formatContentDetails(ref))
+		}
+	}
+}
+
+func folderRef(container itemRef, prefix string) itemRef {
+	return itemRef{
+		Kind:             kindFolder,
+		Name:             folderName(prefix),
+		SubscriptionID:   container.SubscriptionID,
+		SubscriptionName: container.SubscriptionName,
+		Account:          container.Account,
+		Container:        container.Container,
+		Prefix:           prefix,
+	}
+}
+
+// folderName is the last segment of prefix, keeping its trailing slash.
+func folderName(prefix string) string {
+	trimmed := strings.TrimSuffix(prefix, "/")
+	return trimmed[strings.LastIndex(trimmed, "/")+1:] + "/"
+}
+
+func parentPrefix(prefix string) string {
+	trimmed := strings.TrimSuffix(prefix, "/")
+	return trimmed[:strings.LastIndex(trimmed, "/")+1]
+}
+
+// contentLabel is itemLabel for the contents table, where blobs in folder view
+// are shown relative to the current folder.
+func (a *App) contentLabel(ref itemRef) string {
+	if ref.Kind != kindBlob || !a.folderView {
+		return a.itemLabel(ref)
+	}
+	relative := ref
+	relative.Name = strings.TrimPrefix(ref.Name, a.folderPrefix)
+	return a.itemLabel(relative)
+}
+
+func (a *App) enterFolder(ref itemRef) {
+	if ref.Name == ".." {
+		a.folderUp()
+		return
+	}
+	if ref.Archive != "" {
+		a.archive.prefix = ref.Prefix
+		a.renderContents(false)
+		return
+	}
+	a.folderPrefix = ref.Prefix
+	a.renderContents(false)
+}
+
+// folderUp goes to the parent folder and selects the folder just left. It
+// reports false when there is no parent to go to.
+func (a *App) folderUp() bool {
+	if a.archive != nil {
+		a.archiveUp()
+		return true
+	}
+	if !a.folderView || a.folderPrefix == "" {
+		return false
+	}
+	left := a.folderPrefix
+	a.folderPrefix = parentPrefix(left)
+	a.renderContents(false)
+	for row, ref := range a.contentRefs {
+		if ref.Kind == kindFolder && ref.Prefix == left && ref.Name != ".." {
+			a.contents.Select(row, 0)
+			break
+		}
+	}
+	return true
+}
+
+// selectBlob selects the named blob in the contents table, opening its
+// folder first in folder view. It reports whether the blob is listed.
+func (a *App) selectBlob(name string) bool {
+	if a.contentsFilter != nil {
+		for _, ref := range a.listedBlobs {
+			if ref.Name == name && !a.contentsFilter.matches(ref) {
+				a.contentsFilter = nil
+				a.renderContents(true)
+				a.flash("Filter cleared to show " + name)
+				break
+			}
+		}
+	}
+	if a.folderView {
+		if prefix := name[:strings.LastIndex(name, "/")+1]; prefix != a.folderPrefix {
+			a.folderPrefix = prefix
+			a.renderContents(false)
+		}
+	}
+	for row, ref := range a.contentRefs {
+		if ref.Kind == kindBlob && ref.Name == name {
+			a.contents.Select(row, 0)
+			return true
+		}
+	}
+	return false
+}
+
+func (a *App) folderStatsReady(container itemRef) bool {
+	return a.folderStats != nil && sameContainer(a.folderStatsSource, container)
+}
+
+// invalidateFolderStats drops the counted aggregates, and discards a count
+// still running, after the listing changed.
+func (a *App) invalidateFolderStats() {
+	if a.folderStatsCancel != nil {
+		a.folderStatsCancel()
+		a.folderStatsCancel = nil
+	}
+	a.folderStatsSeq++
+	a.folderStats = nil
+	a.folderStatsSource = itemRef{}
+	a.folderStatsLoading = false
+}
+
+// loadFolderStats counts the blobs and bytes under every folder of the
+// container in the background, listing it in full. Folder rows show their
+// aggregates once the count is done.
+func (a *App) loadFolderStats(container itemRef) {
+	if sameContainer(a.folderStatsSource, container) && (a.folderStats != nil || a.folderStatsLoading) {
+		return
+	}
+	a.folderStatsSeq++
+	seq := a.folderStatsSeq
+	a.folderStats = nil
+	a.folderStatsSource = container
+	a.folderStatsLoading = true
+	prefix := a.listPrefix
+	ctx, cancel := context.WithCancel(context.Background())
+	a.folderStatsCancel = cancel
+
+	runAsyncWith(a, ctx, paneContents, func(ctx context.Context) (map[string]folderStat, error) {
+		stats := make(map[string]folderStat)
+		marker := ""
+		for {
+			page, err := a.provider.ListBlobsPage(ctx, container.Account, container.Container, azure.ListBlobsOptions{
+				Marker:     marker,
+				MaxResults: blobPageSize,
+				Prefix:     prefix,
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, blob := range page.Blobs {
+				addFolderStats(stats, blob)
+			}
+			if page.NextMarker == "" {
+				return stats, nil
+			}
+			marker = page.NextMarker
+		}
+	}, func(stats map[string]folderStat, err error) {
+		if seq != a.folderStatsSeq {
+			return
+		}
+		a.folderStatsLoading = false
+		if err != nil {
+			a.flashErr(fmt.Sprintf("Error counting folders: %v", err))
+			return
+		}
+		a.folderStats = stats
+		if a.folderView && sameContainer(a.contentSource, container) {
+			a.renderContents(true)
+		}
+	})
+}
+
+// addFolderStats adds blob to the aggregate of every folder it is under.
+func addFolderStats(stats map[string]folderStat, blob azure.Blob) {
+	for index := strings.Index(blob.Name, "/"); index >= 0; {
+		prefix := blob.Name[:index+1]
+		stat := stats[prefix]
+		stat.Count++
+		stat.Bytes += blob.SizeBytes
+		stats[prefix] = stat
+
+		next := strings.Index(blob.Name[index+1:], "/")
+		if next < 0 {
+			break
+		}
+		index += next + 1
+	}
+}
+
+func (a *App) formatFolderDetails(ref itemRef) string {
+	if ref.Name == ".." {
+		return ""
+	}
+	if a.filterStats != nil {
+		stat := a.filterStats[ref.Prefix]
+		return fmt.Sprintf("%s matching | %s", groupDigits(int64(stat.Count)), a.formatSize(stat.Bytes))
+	}
+	if !a.folderStatsReady(a.contentSource) {
+		if a.folderStatsLoading {
+			return "counting…"
+		}
+		return ""
+	}
+	stat := a.folderStats[ref.Prefix]
+	return fmt.Sprintf("%s blobs | %s", groupDigits(int64(stat.Count)), a.formatSize(stat.Bytes))
+}
+
+func (a *App) folderDetails(ref itemRef) []property {
+	items := []property{{"Folder", ref.Prefix}, {"Account", ref.Account}, {"Container", ref.Container}}
+	if ref.SubscriptionName != "" {
+		items = append(items, property{"Subscription", ref.SubscriptionName})
+	}
+	if a.filterStats != nil {
+		stat := a.filterStats[ref.Prefix]
+		items = append(items,
+			property{"Matching blobs", groupDigits(int64(stat.Count))},
+			property{"Matching size", a.formatSize(stat.Bytes)})
+	}
+	switch {
+	case a.folderStatsReady(a.contentSource):
+		stat := a.folderStats[ref.Prefix]
+		items = append(items,
+			property{"Blobs", groupDigits(int64(stat.Count))},
+			property{"Total size", a.formatSize(stat.Bytes)})
+	case a.folderStatsLoading:
+		items = append(items, property{"Blobs", "counting…"})
+	}
+	return items
+}
@@ -0,0 +1,184 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type timeMode int
+
+const (
+	timeUTC timeMode = iota
+	timeLocal
+	timeRelative
+)
+
+var timeModeNames = []string{"utc", "local", "relative"}
+
+func (m timeMode) String() string {
+	return timeModeNames[m]
+}
+
+func timeModeByName(name string) (timeMode, bool) {
+	if name == "" {
+		return timeUTC, true
+	}
+	for i, candidate := range timeModeNames {
+		if strings.EqualFold(candidate, name) {
+			return timeMode(i), true
+		}
+	}
+	return timeUTC, false
+}
+
+// cycleTimeMode switches timestamps between UTC, local time, and relative.
+func (a *App) cycleTimeMode() {
+	a.timeMode = (a.timeMode + 1) % timeMode(len(timeModeNames))
+	a.redrawFormatting()
+	a.flash(fmt.Sprintf("Times: %s", a.timeMode))
+}
+
+// formatTimestamp renders a timestamp in the current time mode.
+func (a *App) formatTimestamp(value time.Time) string {
+	return a.timeMode.format(value)
+}
+
+func (mode timeMode) format(value time.Time) string {
+	if value.IsZero() {
+		return "n/a"
+	}
+	switch mode {
+	case timeLocal:
+		return value.Local().Format("2006-01-02 15:04:05 MST")
+	case timeRelative:
+		return relativeTime(value, time.Now())
+	}
+	return formatTime(value)
+}
+
+// toggleExactSizes switches between rounded sizes and exact byte counts.
+func (a *App) toggleExactSizes() {
+	a.exactSizes = !a.exactSizes
+	a.redrawFormatting()
+	if a.exactSizes {
+		a.flash("Sizes: exact bytes")
+	} else {
+		a.flash("Sizes: rounded")
+	}
+}
+
+// formatSize renders a byte count the way the user asked for.
+func (a *App) formatSize(value int64) string {
+	return formatSizeAs(value, a.exactSizes)
+}
+
+func formatSizeAs(value int64, exact bool) string {
+	if exact {
+		return groupDigits(value) + " B"
+	}
+	return formatBytes(value)
+}
+
+// redrawFormatting re-renders every size and time already on screen after a
+// display setting changed.
+func (a *App) redrawFormatting() {
+	for row, ref := range a.contentRefs {
+		if a.contentSource.Kind != kindAccount && (ref.Kind == kindBlob || ref.Kind == kindFolder) {
+			a.contents.GetCell(row, 1).SetText(a.formatContentDetails(ref))
+		}
+	}
+	for row, ref := range a.compareRefs {
+		if ref.Kind == kindBlob {
+			a.compare.GetCell(row, 1).SetText(a.formatContentDetails(ref))
+		}
+	}
+
+	switch a.activePane {
+	case paneAccounts:
+		a.onTreeChanged(a.accounts.GetCurrentNode())
+	case paneCompare:
+		row, _ := a.compare.GetSelection()
+		a.onCompareChanged(row)
+	default:
+		a.refreshContentSelection()
+	}
+}
+
+func formatTime(value time.Time) string {
+	if value.IsZero() {
+		return "n/a"
+	}
+	return value.UTC().Format(time.RFC3339)
+}
+
+func formatBytes(value int64) string {
+	const (
+		kb = 1024
+		mb = 1024 * kb
+		gb = 1024 * mb
+	)
+
+	switch {
+	case value >= gb:
+		return fmt.Sprintf("%.2f GB", float64(value)/float64(gb))
+	case value >= mb:
+		return fmt.Sprintf("%.2f MB", float64(value)/float64(mb))
+	case value >= kb:
+		return fmt.Sprintf("%.2f KB", float64(value)/float64(kb))
+	default:
+		return fmt.Sprintf("%d B", value)
+	}
+}
+
+// relativeTime describes value relative to now in its largest whole unit,
+// e.g. "3 hours ago".
+func relativeTime(value, now time.Time) string {
+	delta := now.Sub(value)
+	suffix := "ago"
+	if delta < 0 {
+		delta = -delta
+		suffix = "from now"
+	}
+
+	units := []struct {
+		name string
+		size time.Duration
+	}{
+		{"year", 365 * 24 * time.Hour},
+		{"month", 30 * 24 * time.Hour},
+		{"day", 24 * time.Hour},
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+	}
+	for _, unit := range units {
+		if delta < unit.size {
+			continue
+		}
+		count := int(delta / unit.size)
+		if count == 1 {
+			return fmt.Sprintf("1 %s %s", unit.name, suffix)
+		}
+		return fmt.Sprintf("%d %ss %s", count, unit.name, suffix)
+	}
+	return "just now"
+}
+
+// groupDigits formats value with thousands separators, e.g. 1,048,576.
+func groupDigits(value int64) string {
+	digits := strconv.FormatInt(value, 10)
+	sign := ""
+	if value < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	head := len(digits) % 3
+	if head == 0 {
+		head = 3
+	}
+	grouped := digits[:head]
+	for i := head; i < len(digits); i += 3 {
+		grouped += "," + digits[i:i+3]
+	}
+	return sign + grouped
+}
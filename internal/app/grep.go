@@ -0,0 +1,408 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"storage-tui/internal/azure"
+)
+
+const (
+	// grepWorkers is how many blobs are read at once.
+	grepWorkers = 4
+	// grepMaxBytes skips blobs larger than this instead of downloading them.
+	grepMaxBytes = 16 << 20
+	// grepLimit stops a search once this many lines matched.
+	grepLimit = 2000
+	// grepBlobLimit caps the lines reported for one blob.
+	grepBlobLimit = 100
+	// grepLineWidth truncates matched lines in the results.
+	grepLineWidth = 200
+)
+
+// grepMatch is one matching line of a blob.
+type grepMatch struct {
+	blob itemRef
+	line int
+	text string
+}
+
+// grepProgress counts what a content search has covered so far.
+type grepProgress struct {
+	blobs    int
+	scanned  int
+	skipped  int
+	failures int
+	running  bool
+	outcome  string
+}
+
+func (a *App) setupGrepModal() {
+	input := tview.NewInputField().
+		SetLabel("Text or re:pattern: ").
+		SetFieldWidth(0)
+	results := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(0, 0)
+	view := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(input, 1, 0, true).
+		AddItem(results, 0, 1, false)
+	view.SetBorder(true)
+
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEnter:
+			a.startGrep(input.GetText())
+			return nil
+		case tcell.KeyEsc:
+			a.stopOrCloseGrep()
+			return nil
+		case tcell.KeyDown, tcell.KeyTab:
+			if len(a.grepMatches) > 0 {
+				a.app.SetFocus(results)
+			}
+			return nil
+		}
+		return event
+	})
+	results.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		row, _ := results.GetSelection()
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			a.stopOrCloseGrep()
+			return nil
+		case event.Key() == tcell.KeyTab || event.Key() == tcell.KeyBacktab || event.Rune() == '/',
+			event.Key() == tcell.KeyUp && row == 0:
+			a.app.SetFocus(input)
+			return nil
+		}
+		return event
+	})
+	results.SetSelectedFunc(func(row, _ int) {
+		a.jumpToGrepMatch(row)
+	})
+
+	a.grepInput = input
+	a.grepResults = results
+	a.grepView = view
+	a.pages.AddPage("grep", centerModal(view, 24, 110), true, false)
+}
+
+// openGrepModal searches inside the text blobs of the listed container, or of
+// the open folder or listing prefix, that the contents filter lets through.
+func (a *App) openGrepModal() {
+	if a.contentSource.Kind != kindContainer {
+		a.flashErr("List a container to search inside its blobs.")
+		return
+	}
+	scope := a.contentSource
+	scope.Prefix = a.listedScopePrefix()
+	a.showGrep(scope, a.contentsFilter)
+}
+
+// showGrep opens the dialog for scope and filter. Results of the previous
+// search stay while both are unchanged.
+func (a *App) showGrep(scope itemRef, filter *blobFilter) {
+	if !sameContainer(scope, a.grepScope) || scope.Prefix != a.grepScope.Prefix || filter.fieldValues() != a.grepFilter.fieldValues() {
+		a.grepJob.stop()
+		a.grepSeq++
+		a.grepMatches = nil
+		a.grepResults.Clear()
+		a.grepProgress = grepProgress{}
+		a.grepScope = scope
+		a.grepFilter = filter
+	}
+	a.updateGrepTitle()
+	a.grepOpen = true
+	a.pages.ShowPage("grep")
+	a.app.SetFocus(a.grepInput)
+}
+
+func (a *App) closeGrepModal() {
+	a.pages.HidePage("grep")
+	a.grepOpen = false
+	a.setActivePane(a.activePane)
+}
+
+// stopOrCloseGrep stops a running search, or closes the dialog when none is
+// running.
+func (a *App) stopOrCloseGrep() {
+	if a.grepProgress.running {
+		a.grepJob.stop()
+		return
+	}
+	a.closeGrepModal()
+}
+
+// startGrep lists the scope by pages and reads every text blob small enough,
+// a few at a time, reporting matching lines as they are found. Blobs that are
+// too large or look binary are skipped and counted; blobs the filter rejects
+// are left out.
+func (a *App) startGrep(pattern string) {
+	match, err := lineMatcher(pattern)
+	if err != nil {
+		a.flashErr(fmt.Sprintf("Grep: %v", err))
+		return
+	}
+	scope := a.grepScope
+	filter := a.grepFilter
+
+	a.grepJob.stop()
+	a.grepSeq++
+	seq := a.grepSeq
+	a.grepMatches = nil
+	a.grepResults.Clear()
+	a.grepProgress = grepProgress{running: true}
+	a.grepRun = a.recordSearch("grep", pattern, a.grepScopeLabel())
+	a.updateGrepTitle()
+
+	update := func(apply func()) {
+		a.queueUpdate(func() {
+			if seq == a.grepSeq {
+				apply()
+				a.updateGrepTitle()
+			}
+		})
+	}
+
+	a.grepJob = startJob(a, grepWorkers, func(ctx context.Context, queue func(itemRef) bool) error {
+		marker := ""
+		for {
+			page, err := a.provider.ListBlobsPage(ctx, scope.Account, scope.Container, azure.ListBlobsOptions{
+				Marker:     marker,
+				MaxResults: blobPageSize,
+				Prefix:     scope.Prefix,
+			})
+			if err != nil {
+				return err
+			}
+			var blobs []itemRef
+			skipped := 0
+			for _, blob := range page.Blobs {
+				if filter != nil && !filter.matches(blobRef(scope, blob)) {
+					continue
+				}
+				if blob.SizeBytes > grepMaxBytes || !textContent(blob.Name, blob.ContentType) {
+					skipped++
+					continue
+				}
+				blobs = append(blobs, blobRef(scope, blob))
+			}
+			update(func() {
+				a.grepProgress.blobs += len(blobs)
+				a.grepProgress.skipped += skipped
+			})
+			for _, blob := range blobs {
+				if !queue(blob) {
+					return ctx.Err()
+				}
+			}
+			if page.NextMarker == "" {
+				return nil
+			}
+			marker = page.NextMarker
+		}
+	}, func(ctx context.Context, blob itemRef) {
+		matches, binary, err := a.grepBlob(ctx, blob, match)
+		if err != nil {
+			if ctx.Err() == nil {
+				update(func() { a.grepProgress.failures++ })
+			}
+			return
+		}
+		update(func() {
+			if binary {
+				a.grepProgress.skipped++
+			} else {
+				a.grepProgress.scanned++
+			}
+			a.addGrepMatches(matches)
+		})
+	}, func(err error) {
+		if seq != a.grepSeq {
+			return
+		}
+		a.grepJob = nil
+		a.grepProgress.running = false
+		switch {
+		case len(a.grepMatches) >= grepLimit:
+			a.grepProgress.outcome = fmt.Sprintf("stopped at %d matches", grepLimit)
+		case errors.Is(err, context.Canceled):
+			a.grepProgress.outcome = "stopped"
+		case err != nil:
+			a.grepProgress.outcome = fmt.Sprintf("error: %v", err)
+		default:
+			a.grepProgress.outcome = "done"
+		}
+		a.finishSearch(a.grepRun, a.grepProgress.outcome)
+		a.updateGrepTitle()
+	})
+}
+
+// grepBlob streams one blob and returns its matching lines. Content with NUL
+// bytes near the start is reported as binary instead.
+func (a *App) grepBlob(ctx context.Context, blob itemRef, match func(string) bool) ([]grepMatch, bool, error) {
+	reader, err := a.provider.OpenBlob(ctx, blob.Account, blob.Container, blob.Name)
+	if err != nil {
+		return nil, false, err
+	}
+	defer reader.Close()
+
+	buffered := bufio.NewReader(reader)
+	if head, _ := buffered.Peek(512); bytes.IndexByte(head, 0) >= 0 {
+		return nil, true, nil
+	}
+	scanner := bufio.NewScanner(buffered)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	var matches []grepMatch
+	for line := 1; scanner.Scan(); line++ {
+		if ctx.Err() != nil {
+			return nil, false, ctx.Err()
+		}
+		text := scanner.Text()
+		if !match(text) {
+			continue
+		}
+		matches = append(matches, grepMatch{blob: blob, line: line, text: truncateLine(text)})
+		if len(matches) >= grepBlobLimit {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, false, err
+	}
+	return matches, false, nil
+}
+
+func (a *App) addGrepMatches(matches []grepMatch) {
+	for _, match := range matches {
+		if len(a.grepMatches) >= grepLimit {
+			a.grepJob.stop()
+			return
+		}
+		row := len(a.grepMatches)
+		a.grepMatches = append(a.grepMatches, match)
+		a.addSearchResults(a.grepRun, searchResult{
+			ref:    match.blob,
+			where:  fmt.Sprintf("line %d", match.line),
+			detail: match.text,
+		})
+		a.grepResults.SetCell(row, 0, tview.NewTableCell(tview.Escape(fmt.Sprintf("%s:%d", match.blob.Name, match.line))).
+			SetTextColor(a.theme.accent))
+		a.grepResults.SetCell(row, 1, tview.NewTableCell(tview.Escape(match.text)).SetExpansion(1))
+	}
+}
+
+// grepScopeLabel names the container or folder searched, and the filter in
+// effect.
+func (a *App) grepScopeLabel() string {
+	scope := fmt.Sprintf("%s/%s", a.grepScope.Account, a.grepScope.Container)
+	if a.grepScope.Prefix != "" {
+		scope += "/" + a.grepScope.Prefix
+	}
+	if a.grepFilter != nil {
+		scope += "  filter: " + a.grepFilter.summary()
+	}
+	return scope
+}
+
+func (a *App) updateGrepTitle() {
+	scope := a.grepScopeLabel()
+	progress := a.grepProgress
+	status := fmt.Sprintf("%d matches, %d of %d blobs read", len(a.grepMatches), progress.scanned, progress.blobs)
+	if progress.skipped > 0 {
+		status += fmt.Sprintf(", %d skipped", progress.skipped)
+	}
+	if progress.failures > 0 {
+		status += fmt.Sprintf(", %d failed", progress.failures)
+	}
+	switch {
+	case progress.running:
+		status += "…  esc: stop"
+	case progress.outcome != "":
+		status += " (" + progress.outcome + ")  enter: jump | esc: close"
+	default:
+		status = "enter: search text blobs | esc: close"
+	}
+	a.grepView.SetTitle(fmt.Sprintf("Grep %s  %s", scope, status))
+}
+
+// jumpToGrepMatch closes the dialog and reveals the blob of the match.
+func (a *App) jumpToGrepMatch(row int) {
+	if row < 0 || row >= len(a.grepMatches) {
+		return
+	}
+	ref := a.grepMatches[row].blob
+	a.closeGrepModal()
+	loc := location{SubscriptionID: ref.SubscriptionID, Account: ref.Account, Container: ref.Container, Blob: ref.Name}
+	a.revealLocation(loc, func(err error) {
+		if err != nil {
+			a.flashErr(fmt.Sprintf("Grep: %v", err))
+		}
+	})
+}
+
+// lineMatcher matches lines containing pattern or, with a re: prefix, lines
+// the regular expression matches, both case-insensitively.
+func lineMatcher(pattern string) (func(string) bool, error) {
+	if expression, ok := strings.CutPrefix(pattern, "re:"); ok {
+		if expression == "" {
+			return nil, errors.New("enter a regular expression after re:")
+		}
+		re, err := regexp.Compile("(?i)" + expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %v", expression, regexpReason(err))
+		}
+		return re.MatchString, nil
+	}
+	if pattern == "" {
+		return nil, errors.New("enter the text to search for")
+	}
+	lower := strings.ToLower(pattern)
+	return func(line string) bool {
+		return strings.Contains(strings.ToLower(line), lower)
+	}, nil
+}
+
+// textContent reports whether a blob looks like text worth reading, from its
+// content type or, for generic types, its extension.
+func textContent(name, contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	if index := strings.Index(contentType, ";"); index >= 0 {
+		contentType = strings.TrimSpace(contentType[:index])
+	}
+	switch {
+	case strings.HasPrefix(contentType, "text/"),
+		strings.HasSuffix(contentType, "+json"), strings.HasSuffix(contentType, "+xml"):
+		return true
+	case contentType == "application/json", contentType == "application/xml",
+		contentType == "application/javascript", contentType == "application/x-ndjson",
+		contentType == "application/yaml", contentType == "application/x-yaml":
+		return true
+	case contentType == "", contentType == "application/octet-stream":
+		switch strings.ToLower(path.Ext(name)) {
+		case ".txt", ".log", ".csv", ".tsv", ".json", ".ndjson", ".xml", ".yaml", ".yml", ".md", ".ini", ".conf":
+			return true
+		}
+	}
+	return false
+}
+
+// truncateLine shortens a matched line for the results table.
+func truncateLine(line string) string {
+	line = strings.TrimSpace(line)
+	runes := []rune(line)
+	if len(runes) > grepLineWidth {
+		return string(runes[:grepLineWidth]) + "…"
+	}
+	return line
+}
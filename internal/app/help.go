@@ -0,0 +1,70 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+func (a *App) setupHelpModal() {
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetWrap(false)
+	view.SetBorder(true).SetTitle("Keybindings  esc/?: close")
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc || event.Rune() == '?' || event.Rune() == 'q' {
+			a.closeHelpModal()
+			return nil
+		}
+		return event
+	})
+
+	a.helpView = view
+	a.pages.AddPage("help", centerModal(view, 30, 64), true, false)
+}
+
+func (a *App) openHelpModal() {
+	a.helpOpen = true
+	a.helpView.SetText(a.helpText())
+	a.helpView.ScrollToBeginning()
+	a.pages.ShowPage("help")
+	a.app.SetFocus(a.helpView)
+}
+
+func (a *App) closeHelpModal() {
+	a.pages.HidePage("help")
+	a.helpOpen = false
+	a.setActivePane(a.activePane)
+}
+
+// helpText renders the keymap registry grouped in registry order.
+func (a *App) helpText() string {
+	var groups []string
+	byGroup := make(map[string][]binding)
+	width := 0
+	for _, b := range a.keys {
+		if _, ok := byGroup[b.group]; !ok {
+			groups = append(groups, b.group)
+		}
+		byGroup[b.group] = append(byGroup[b.group], b)
+		if len(b.label) > width {
+			width = len(b.label)
+		}
+	}
+
+	var builder strings.Builder
+	for i, group := range groups {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(fmt.Sprintf("[::b]%s[::-]\n", group))
+		for _, b := range byGroup[group] {
+			label := fmt.Sprintf("%-*s", width, b.label)
+			builder.WriteString(fmt.Sprintf("  %s%s[-]  %s\n", colorTag(a.theme.accent), tview.Escape(label), b.help))
+		}
+	}
+	return builder.String()
+}
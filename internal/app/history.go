@@ -0,0 +1,233 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// historyLimit is how many searches the history keeps, newest first.
+const historyLimit = 10
+
+// searchRun is one find, grep, or tags search and the results it produced.
+// Runs keep their results after a newer search replaces the ones shown in
+// the search dialogs.
+type searchRun struct {
+	kind    string
+	pattern string
+	scope   string
+	started time.Time
+	outcome string
+	results []searchResult
+}
+
+// searchResult is one row of a search run: the blob it found, where it lives
+// or matched, and a detail such as the size, tags, or matching line.
+type searchResult struct {
+	ref    itemRef
+	where  string
+	detail string
+}
+
+func (a *App) setupHistoryModal() {
+	runs := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(0, 0)
+	runs.SetBorder(true).SetTitle("Searches")
+	results := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(0, 0)
+	results.SetBorder(true).SetTitle("Results")
+	view := tview.NewFlex().
+		AddItem(runs, 0, 2, true).
+		AddItem(results, 0, 3, false)
+	view.SetBorder(true).SetTitle("Search history  enter: results, then jump | tab: switch | d: forget | esc: close")
+
+	runs.SetSelectionChangedFunc(func(row, _ int) {
+		a.fillHistoryResults(row)
+	})
+	runs.SetSelectedFunc(func(row, _ int) {
+		if row < len(a.history) && len(a.history[row].results) > 0 {
+			a.app.SetFocus(results)
+		}
+	})
+	runs.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		row, _ := runs.GetSelection()
+		switch {
+		case event.Key() == tcell.KeyEsc || event.Rune() == 'H':
+			a.closeHistoryModal()
+			return nil
+		case event.Key() == tcell.KeyTab || event.Key() == tcell.KeyRight:
+			a.app.SetFocus(results)
+			return nil
+		case event.Rune() == 'd':
+			a.forgetSearchRun(row)
+			return nil
+		}
+		return event
+	})
+	results.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc || event.Rune() == 'H':
+			a.closeHistoryModal()
+			return nil
+		case event.Key() == tcell.KeyTab || event.Key() == tcell.KeyBacktab || event.Key() == tcell.KeyLeft:
+			a.app.SetFocus(runs)
+			return nil
+		}
+		return event
+	})
+	results.SetSelectedFunc(func(row, _ int) {
+		run, _ := runs.GetSelection()
+		a.openHistoryResult(run, row)
+	})
+
+	a.historyRuns = runs
+	a.historyResults = results
+	a.historyView = view
+	a.pages.AddPage("history", centerModal(view, 24, 110), true, false)
+}
+
+func (a *App) openHistoryModal() {
+	a.historyOpen = true
+	a.fillHistoryRuns()
+	a.pages.ShowPage("history")
+	a.app.SetFocus(a.historyRuns)
+}
+
+func (a *App) closeHistoryModal() {
+	a.pages.HidePage("history")
+	a.historyOpen = false
+	a.setActivePane(a.activePane)
+}
+
+// recordSearch starts a history entry for a search, dropping the oldest once
+// the history is full.
+func (a *App) recordSearch(kind, pattern, scope string) *searchRun {
+	run := &searchRun{kind: kind, pattern: pattern, scope: scope, started: time.Now()}
+	a.history = append([]*searchRun{run}, a.history...)
+	if len(a.history) > historyLimit {
+		a.history = a.history[:historyLimit]
+	}
+	a.historyChanged(nil)
+	return run
+}
+
+// addSearchResults appends results to a run and shows them if the history
+// dialog is on that run.
+func (a *App) addSearchResults(run *searchRun, results ...searchResult) {
+	if run == nil {
+		return
+	}
+	run.results = append(run.results, results...)
+	a.historyChanged(run)
+}
+
+// finishSearch records how a run ended.
+func (a *App) finishSearch(run *searchRun, outcome string) {
+	if run == nil {
+		return
+	}
+	run.outcome = outcome
+	a.historyChanged(run)
+}
+
+// historyChanged redraws the open history dialog. A nil run means the list of
+// runs itself changed.
+func (a *App) historyChanged(run *searchRun) {
+	if !a.historyOpen {
+		return
+	}
+	row, _ := a.historyRuns.GetSelection()
+	if run == nil {
+		a.fillHistoryRuns()
+		return
+	}
+	for i, item := range a.history {
+		if item == run {
+			a.setHistoryRunCells(i)
+			if i == row {
+				a.fillHistoryResults(row)
+			}
+			return
+		}
+	}
+}
+
+func (a *App) fillHistoryRuns() {
+	row, _ := a.historyRuns.GetSelection()
+	a.historyRuns.Clear()
+	if len(a.history) == 0 {
+		a.historyRuns.SetCell(0, 0, tview.NewTableCell("No searches yet.").SetSelectable(false))
+		a.historyResults.Clear()
+		return
+	}
+	for i := range a.history {
+		a.setHistoryRunCells(i)
+	}
+	if row >= len(a.history) {
+		row = len(a.history) - 1
+	}
+	a.historyRuns.Select(row, 0)
+	a.fillHistoryResults(row)
+}
+
+func (a *App) setHistoryRunCells(row int) {
+	run := a.history[row]
+	status := run.outcome
+	if status == "" {
+		status = "running…"
+	}
+	a.historyRuns.SetCell(row, 0, tview.NewTableCell(run.started.Format("15:04:05")).SetTextColor(a.theme.muted))
+	a.historyRuns.SetCell(row, 1, tview.NewTableCell(run.kind))
+	a.historyRuns.SetCell(row, 2, tview.NewTableCell(tview.Escape(run.pattern)).SetExpansion(1))
+	a.historyRuns.SetCell(row, 3, tview.NewTableCell(strconv.Itoa(len(run.results))).SetAlign(tview.AlignRight))
+	a.historyRuns.SetCell(row, 4, tview.NewTableCell(tview.Escape(status)).SetTextColor(a.theme.muted))
+}
+
+func (a *App) fillHistoryResults(row int) {
+	a.historyResults.Clear()
+	if row < 0 || row >= len(a.history) {
+		return
+	}
+	run := a.history[row]
+	a.historyResults.SetTitle(tview.Escape(fmt.Sprintf("%s %s in %s", run.kind, run.pattern, run.scope)))
+	if len(run.results) == 0 {
+		a.historyResults.SetCell(0, 0, tview.NewTableCell("No results.").SetSelectable(false))
+		return
+	}
+	for i, result := range run.results {
+		a.historyResults.SetCell(i, 0, tview.NewTableCell(tview.Escape(a.itemLabel(result.ref))))
+		a.historyResults.SetCell(i, 1, tview.NewTableCell(tview.Escape(result.where)).SetTextColor(a.theme.muted))
+		a.historyResults.SetCell(i, 2, tview.NewTableCell(tview.Escape(result.detail)).SetExpansion(1))
+	}
+}
+
+// openHistoryResult closes the dialog and reveals the blob of a result,
+// listing its container as far as needed.
+func (a *App) openHistoryResult(run, row int) {
+	if run < 0 || run >= len(a.history) || row < 0 || row >= len(a.history[run].results) {
+		return
+	}
+	ref := a.history[run].results[row].ref
+	a.closeHistoryModal()
+	loc := location{SubscriptionID: ref.SubscriptionID, Account: ref.Account, Container: ref.Container, Blob: ref.Name}
+	a.revealLocation(loc, func(err error) {
+		if err != nil {
+			a.flashErr(fmt.Sprintf("History: %v", err))
+		}
+	})
+}
+
+// forgetSearchRun drops a run from the history. A running search keeps
+// going; it just no longer records here.
+func (a *App) forgetSearchRun(row int) {
+	if row < 0 || row >= len(a.history) {
+		return
+	}
+	a.history = append(a.history[:row], a.history[row+1:]...)
+	a.fillHistoryRuns()
+}
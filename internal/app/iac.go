@@ -0,0 +1,136 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"storage-tui/internal/iac"
+)
+
+func (a *App) setupIaCModal() {
+	format := tview.NewDropDown().
+		SetLabel("Format: ").
+		SetOptions(iac.Formats, nil)
+	input := tview.NewInputField().
+		SetLabel("File: ").
+		SetFieldWidth(0)
+	form := tview.NewForm().
+		AddFormItem(format).
+		AddFormItem(input).
+		AddButton("Write", func() {
+			a.applyIaC()
+		}).
+		AddButton("Cancel", a.closeIaCModal)
+	form.SetBorder(true)
+	form.SetButtonsAlign(tview.AlignRight)
+	form.SetCancelFunc(a.closeIaCModal)
+
+	// Picking another format gives the file its extension.
+	format.SetSelectedFunc(func(option string, _ int) {
+		path := input.GetText()
+		input.SetText(strings.TrimSuffix(path, filepath.Ext(path)) + iac.Extension(option))
+	})
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEnter:
+			a.applyIaC()
+			return nil
+		case tcell.KeyEsc:
+			a.closeIaCModal()
+			return nil
+		}
+		return event
+	})
+
+	a.iacForm = form
+	a.iacFormat = format
+	a.iacInput = input
+	a.pages.AddPage("iac", centerModal(form, 9, 76), true, false)
+}
+
+// openIaCModal asks where to write the selected account, or the container
+// of the selection, as Terraform or Bicep.
+func (a *App) openIaCModal() {
+	ref, ok := a.selectedRef()
+	if !ok || ref.Account == "" {
+		ref = a.contentSource
+	}
+	if ref.Account == "" {
+		a.flashErr("Select an account or container to describe as code.")
+		return
+	}
+	a.iacRef = ref
+	a.iacOpen = true
+	name := ref.Account
+	if ref.Container != "" {
+		name += "/" + ref.Container
+	}
+	a.iacForm.SetTitle("Describe " + name + " as code")
+	format, option := a.iacFormat.GetCurrentOption()
+	if format < 0 {
+		option = iac.Formats[0]
+		a.iacFormat.SetCurrentOption(0)
+	}
+	a.iacInput.SetText(strings.NewReplacer("/", "-", "$", "").Replace(name) + iac.Extension(option))
+	a.iacForm.SetFocus(1)
+	a.pages.ShowPage("iac")
+	a.app.SetFocus(a.iacInput)
+}
+
+func (a *App) closeIaCModal() {
+	a.pages.HidePage("iac")
+	a.iacOpen = false
+	a.setActivePane(a.activePane)
+}
+
+func (a *App) applyIaC() {
+	path := strings.TrimSpace(a.iacInput.GetText())
+	if path == "" {
+		a.flashErr("Name the file to write to.")
+		return
+	}
+	_, format := a.iacFormat.GetCurrentOption()
+	a.closeIaCModal()
+	a.runIaC(a.iacRef, path, format)
+}
+
+// runIaC reads what describes the account and writes it to path, in the
+// background.
+func (a *App) runIaC(ref itemRef, path, format string) {
+	account, ok := a.accountInfo[ref.Account]
+	if !ok {
+		a.flashErr(fmt.Sprintf("Account %s is not loaded yet.", ref.Account))
+		return
+	}
+	a.flash(fmt.Sprintf("Describing %s…", ref.Account))
+
+	runAsync(a, a.activePane, func(ctx context.Context) (struct{}, error) {
+		described, err := iac.Collect(ctx, a.provider, account, ref.Container)
+		if err != nil {
+			return struct{}{}, err
+		}
+		var data bytes.Buffer
+		if err := iac.Write(&data, format, described); err != nil {
+			return struct{}{}, err
+		}
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, data.Bytes(), 0o644); err != nil {
+			return struct{}{}, err
+		}
+		return struct{}{}, os.Rename(tmp, path)
+	}, func(_ struct{}, err error) {
+		if err != nil {
+			a.recordFailure("describe as code", path, err, func() { a.runIaC(ref, path, format) })
+			a.flashErr(fmt.Sprintf("Describing %s failed: %v", ref.Account, err))
+			return
+		}
+		a.flash(fmt.Sprintf("Wrote %s as %s to %s.", ref.Account, format, path))
+	})
+}
@@ -0,0 +1,169 @@
+package app
+
+import (
+	"os"
+	"path"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+type iconStyle int
+
+const (
+	iconsOff iconStyle = iota
+	iconsASCII
+	iconsNerd
+)
+
+// blobFamily groups blobs by what they contain, for icons and row colors.
+type blobFamily int
+
+const (
+	familyOther blobFamily = iota
+	familyImage
+	familyText
+	familyArchive
+	familyData
+)
+
+type iconSet struct {
+	subscription string
+	account      string
+	container    string
+	folder       string
+	families     map[blobFamily]string
+}
+
+var nerdIcons = iconSet{
+	subscription: "\uf0c2",
+	account:      "\uf1c0",
+	container:    "\uf187",
+	folder:       "\uf07b",
+	families: map[blobFamily]string{
+		familyOther:   "\uf15b",
+		familyImage:   "\uf1c5",
+		familyText:    "\uf15c",
+		familyArchive: "\uf1c6",
+		familyData:    "\uf1c9",
+	},
+}
+
+var asciiIcons = iconSet{
+	subscription: "[S]",
+	account:      "[A]",
+	container:    "[C]",
+	folder:       "[D]",
+	families: map[blobFamily]string{
+		familyOther:   "[F]",
+		familyImage:   "[I]",
+		familyText:    "[T]",
+		familyArchive: "[Z]",
+		familyData:    "[J]",
+	},
+}
+
+// iconStyleByName parses Options.Icons. Nerd-font glyphs fall back to ASCII
+// when the terminal is unlikely to render them.
+func iconStyleByName(name string) (iconStyle, bool) {
+	switch strings.ToLower(name) {
+	case "", "off", "none":
+		return iconsOff, true
+	case "ascii":
+		return iconsASCII, true
+	case "nerd":
+		if !unicodeTerminal() {
+			return iconsASCII, true
+		}
+		return iconsNerd, true
+	}
+	return iconsOff, false
+}
+
+// unicodeTerminal reports whether the locale is UTF-8 and the terminal is not
+// the Linux console, which has no glyphs outside its console font.
+func unicodeTerminal() bool {
+	switch os.Getenv("TERM") {
+	case "linux", "dumb":
+		return false
+	}
+	locale := os.Getenv("LC_ALL")
+	if locale == "" {
+		locale = os.Getenv("LC_CTYPE")
+	}
+	if locale == "" {
+		locale = os.Getenv("LANG")
+	}
+	locale = strings.ToUpper(locale)
+	return strings.Contains(locale, "UTF-8") || strings.Contains(locale, "UTF8")
+}
+
+// itemLabel is the text shown for ref in the tree and tables: its name,
+// prefixed with an icon when icons are on.
+func (a *App) itemLabel(ref itemRef) string {
+	icon := a.icon(ref)
+	if icon == "" {
+		return ref.Name
+	}
+	return icon + " " + ref.Name
+}
+
+func (a *App) icon(ref itemRef) string {
+	var set iconSet
+	switch a.icons {
+	case iconsNerd:
+		set = nerdIcons
+	case iconsASCII:
+		set = asciiIcons
+	default:
+		return ""
+	}
+
+	var icon string
+	switch ref.Kind {
+	case kindSubscription:
+		icon = set.subscription
+	case kindAccount:
+		icon = set.account
+	case kindContainer:
+		icon = set.container
+	case kindFolder:
+		icon = set.folder
+	case kindBlob, kindEntry:
+		icon = set.families[classifyBlob(ref)]
+	default:
+		return ""
+	}
+	return tview.Escape(icon)
+}
+
+// classifyBlob picks a blob's family from its content type, falling back to
+// the file extension.
+func classifyBlob(ref itemRef) blobFamily {
+	contentType := strings.ToLower(ref.ContentType)
+	switch {
+	case strings.HasPrefix(contentType, "image/"):
+		return familyImage
+	case strings.Contains(contentType, "json"), strings.Contains(contentType, "csv"),
+		strings.Contains(contentType, "xml"),
+		strings.Contains(contentType, "parquet"), strings.Contains(contentType, "avro"):
+		return familyData
+	case strings.Contains(contentType, "zip"), strings.Contains(contentType, "tar"),
+		strings.Contains(contentType, "gzip"), strings.Contains(contentType, "compressed"):
+		return familyArchive
+	case strings.HasPrefix(contentType, "text/"):
+		return familyText
+	}
+
+	switch strings.ToLower(path.Ext(ref.Name)) {
+	case ".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp", ".bmp":
+		return familyImage
+	case ".txt", ".log", ".md", ".html", ".htm", ".css", ".js":
+		return familyText
+	case ".zip", ".tar", ".gz", ".tgz", ".7z", ".bz2", ".xz", ".bak":
+		return familyArchive
+	case ".json", ".csv", ".tsv", ".xml", ".parquet", ".avro", ".ndjson", ".yaml", ".yml":
+		return familyData
+	}
+	return familyOther
+}
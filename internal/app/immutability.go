@@ -0,0 +1,262 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"storage-tui/internal/audit"
+	"storage-tui/internal/azure"
+)
+
+func (a *App) setupImmutabilityModal() {
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetWrap(false)
+	view.SetBorder(true)
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc || event.Rune() == 'K' || event.Rune() == 'q':
+			a.closeImmutabilityModal()
+			return nil
+		case event.Rune() == 's':
+			a.openWORMForm()
+			return nil
+		case event.Rune() == 'L':
+			a.lockImmutabilityPolicy()
+			return nil
+		}
+		return event
+	})
+
+	form := tview.NewForm().
+		AddInputField("Retention (days): ", "", 10, tview.InputFieldInteger, nil).
+		AddCheckbox("Allow protected append writes: ", false, nil).
+		AddButton("Save", a.saveWORMForm).
+		AddButton("Cancel", a.closeWORMForm)
+	form.SetBorder(true)
+	form.SetButtonsAlign(tview.AlignRight)
+	form.SetCancelFunc(a.closeWORMForm)
+
+	a.wormView = view
+	a.wormForm = form
+	a.pages.AddPage("immutability", centerModal(view, 14, 90), true, false)
+	a.pages.AddPage("immutability-policy", centerModal(form, 9, 70), true, false)
+}
+
+// openImmutabilityModal shows the time-based retention policy and the legal
+// holds of the selected container, or the container the selection is in.
+// The policy can be set or extended there, and locked.
+func (a *App) openImmutabilityModal() {
+	ref, ok := a.selectedRef()
+	if !ok || ref.Container == "" {
+		ref = a.contentSource
+	}
+	if ref.Container == "" {
+		a.flashErr("Select a container to show its immutability policy.")
+		return
+	}
+	a.wormAccount, a.wormContainer = ref.Account, ref.Container
+	a.wormOpen = true
+	a.pages.ShowPage("immutability")
+	a.app.SetFocus(a.wormView)
+	a.loadImmutability()
+}
+
+// loadImmutability reads the container's policy and legal holds in the
+// background.
+func (a *App) loadImmutability() {
+	a.wormSeq++
+	seq := a.wormSeq
+	account, container := a.wormAccount, a.wormContainer
+	a.wormLoaded = false
+	a.wormView.SetTitle(fmt.Sprintf("Immutability: %s/%s  esc: close", account, container))
+	a.wormView.SetText("Loading the immutability policy…")
+
+	runAsync(a, a.activePane, func(ctx context.Context) (azure.ContainerImmutability, error) {
+		return a.provider.GetContainerImmutability(ctx, account, container)
+	}, func(immutability azure.ContainerImmutability, err error) {
+		if !a.wormOpen || seq != a.wormSeq {
+			return
+		}
+		if err != nil {
+			a.wormView.SetText(tview.Escape(fmt.Sprintf("Error loading the immutability policy: %v", err)))
+			return
+		}
+		a.wormLoaded = true
+		a.wormState = immutability
+		hints := "s: set"
+		switch immutability.Policy.State {
+		case azure.ImmutabilityUnlocked:
+			hints = "s: change | L: lock"
+		case azure.ImmutabilityLocked:
+			hints = "s: extend"
+		}
+		a.wormView.SetTitle(fmt.Sprintf("Immutability: %s/%s  %s | esc: close", account, container, hints))
+		a.wormView.SetText(a.formatPropertySections(immutabilitySections(immutability)))
+		a.wormView.ScrollToBeginning()
+	})
+}
+
+// immutabilitySections describe the policy, spelling out what its state
+// allows, and the legal holds.
+func immutabilitySections(immutability azure.ContainerImmutability) []propertySection {
+	policy := immutability.Policy
+	retention := propertySection{Title: "Time-based retention"}
+	if policy.State != "" {
+		state := "unlocked: can still be shortened, changed, or deleted"
+		if policy.State == azure.ImmutabilityLocked {
+			state = "locked: can only be extended, never shortened or deleted"
+		}
+		retention.Items = []property{
+			{"State", state},
+			{"Retention", fmt.Sprintf("%d days after each blob was last written", policy.PeriodDays)},
+			{"Protected append writes", yesNo(policy.AllowProtectedAppendWrites)},
+		}
+	}
+	holds := propertySection{Title: "Legal holds"}
+	for _, tag := range immutability.LegalHoldTags {
+		holds.Items = append(holds.Items, property{tag, "protects every blob until cleared"})
+	}
+	return []propertySection{retention, holds}
+}
+
+func (a *App) closeImmutabilityModal() {
+	a.wormSeq++
+	a.pages.HidePage("immutability")
+	a.wormOpen = false
+	a.setActivePane(a.activePane)
+}
+
+// openWORMForm edits the retention period, starting from the current one or
+// a year for a new policy.
+func (a *App) openWORMForm() {
+	if !a.wormLoaded {
+		return
+	}
+	policy := a.wormState.Policy
+	days, title := 365, "New immutability policy"
+	switch policy.State {
+	case azure.ImmutabilityUnlocked:
+		days, title = policy.PeriodDays, "Immutability policy, unlocked"
+	case azure.ImmutabilityLocked:
+		days, title = policy.PeriodDays, "Locked policy: extend only"
+	}
+	a.wormForm.GetFormItem(0).(*tview.InputField).SetText(strconv.Itoa(days))
+	checkbox := a.wormForm.GetFormItem(1).(*tview.Checkbox)
+	checkbox.SetChecked(policy.AllowProtectedAppendWrites)
+	checkbox.SetDisabled(policy.State == azure.ImmutabilityLocked)
+	a.wormFormOpen = true
+	a.wormForm.SetTitle(fmt.Sprintf("%s: %s/%s  esc: cancel", title, a.wormAccount, a.wormContainer))
+	a.wormForm.SetFocus(0)
+	a.pages.ShowPage("immutability-policy")
+	a.app.SetFocus(a.wormForm)
+}
+
+func (a *App) closeWORMForm() {
+	a.pages.HidePage("immutability-policy")
+	a.wormFormOpen = false
+	a.app.SetFocus(a.wormView)
+}
+
+// saveWORMForm checks the period in the form and sets the policy to it. As
+// an extension of a locked policy is as permanent as the lock, it is
+// confirmed first.
+func (a *App) saveWORMForm() {
+	text := a.wormForm.GetFormItem(0).(*tview.InputField).GetText()
+	allowAppend := a.wormForm.GetFormItem(1).(*tview.Checkbox).IsChecked()
+	days, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil {
+		a.flashErr(fmt.Sprintf("Retention %q is not a number of days.", text))
+		return
+	}
+	current := a.wormState.Policy
+	if err := azure.ValidateImmutabilityPolicy(current, days, allowAppend); err != nil {
+		a.flashErr(fmt.Sprintf("Immutability: %v", err))
+		return
+	}
+	a.closeWORMForm()
+	detail := fmt.Sprintf("set to %d days, protected append writes %s", days, yesNo(allowAppend))
+	if current.State == "" {
+		detail = "created, " + detail
+	} else {
+		detail = fmt.Sprintf("%s policy of %d days %s", strings.ToLower(current.State), current.PeriodDays, detail)
+	}
+	set := func() {
+		account, container := a.wormAccount, a.wormContainer
+		a.changeImmutability("immutability-policy", detail, func(ctx context.Context, editor azure.ImmutabilityEditor) error {
+			return editor.SetImmutabilityPolicy(ctx, account, container, days, allowAppend)
+		})
+	}
+	if current.State != azure.ImmutabilityLocked {
+		set()
+		return
+	}
+	a.confirm(confirmation{
+		Title: "Extend locked immutability policy",
+		Message: fmt.Sprintf("Extend the locked policy of %s/%s from %d to %d days? This cannot be undone: the policy can never be shortened again, and blobs stay protected for %d days after they were last written.",
+			a.wormAccount, a.wormContainer, current.PeriodDays, days, days),
+		Action:    "Extend",
+		Danger:    true,
+		Back:      a.wormView,
+		OnConfirm: set,
+	})
+}
+
+// lockImmutabilityPolicy locks the unlocked policy once the container's name
+// has been typed, as nothing can undo it.
+func (a *App) lockImmutabilityPolicy() {
+	policy := a.wormState.Policy
+	switch {
+	case !a.wormLoaded:
+		return
+	case policy.State == "":
+		a.flashErr("There is no policy to lock; set one with s first.")
+		return
+	case policy.State == azure.ImmutabilityLocked:
+		a.flashErr("The policy is already locked.")
+		return
+	}
+	account, container := a.wormAccount, a.wormContainer
+	a.confirm(confirmation{
+		Title: "Lock immutability policy",
+		Message: fmt.Sprintf("Lock the immutability policy of %s/%s? This cannot be undone. No one, account owners included, can then change or delete a blob until %d days after it was last written; the policy can only be extended, never shortened or deleted, and the container and account cannot be deleted while it holds blobs.",
+			account, container, policy.PeriodDays),
+		Action:      "Lock",
+		Danger:      true,
+		RequireText: container,
+		Back:        a.wormView,
+		OnConfirm: func() {
+			detail := fmt.Sprintf("locked policy of %d days", policy.PeriodDays)
+			a.changeImmutability("immutability-lock", detail, func(ctx context.Context, editor azure.ImmutabilityEditor) error {
+				return editor.LockImmutabilityPolicy(ctx, account, container)
+			})
+		},
+	})
+}
+
+// changeImmutability makes a change to the container's policy and records
+// it in the audit log as action, then shows the policy as it now stands.
+func (a *App) changeImmutability(action, detail string, change func(ctx context.Context, editor azure.ImmutabilityEditor) error) {
+	editor, ok := a.provider.(azure.ImmutabilityEditor)
+	if !ok {
+		a.flashErr("This provider cannot change immutability policies.")
+		return
+	}
+	account, container := a.wormAccount, a.wormContainer
+	entry := audit.Entry{Action: action, Target: fmt.Sprintf("az://%s/%s", account, container), Detail: detail}
+	what := fmt.Sprintf("the immutability policy of %s/%s", account, container)
+	a.auditedChange(entry, what, func(ctx context.Context) error {
+		return change(ctx, editor)
+	}, func() {
+		delete(a.containerProps, account+"/"+container)
+		if a.wormOpen && a.wormAccount == account && a.wormContainer == container {
+			a.loadImmutability()
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// job is a cancelable background task whose work items are spread over a
+// pool of worker goroutines.
+type job struct {
+	cancel context.CancelFunc
+}
+
+// startJob runs produce on its own goroutine to queue work items, and hands
+// each item to one of workers goroutines running work. Neither may touch
+// widgets; results reach the UI through queueUpdate. finish runs on the
+// UI goroutine once every item is done, with produce's error, or
+// context.Canceled when the job was canceled.
+func startJob[T any](a *App, workers int, produce func(ctx context.Context, queue func(T) bool) error, work func(ctx context.Context, item T), finish func(error)) *job {
+	ctx, cancel := context.WithCancel(context.Background())
+	items := make(chan T)
+
+	var group sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for item := range items {
+				if ctx.Err() != nil {
+					continue
+				}
+				work(ctx, item)
+			}
+		}()
+	}
+
+	go func() {
+		err := produce(ctx, func(item T) bool {
+			select {
+			case items <- item:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+		close(items)
+		group.Wait()
+		if ctx.Err() != nil && (err == nil || errors.Is(err, context.Canceled)) {
+			err = context.Canceled
+		}
+		cancel()
+		a.app.QueueUpdateDraw(func() {
+			finish(err)
+		})
+	}()
+	return &job{cancel: cancel}
+}
+
+func (j *job) stop() {
+	if j != nil {
+		j.cancel()
+	}
+}
+
+// forEach runs work on every item, at most workers at a time, and waits for
+// them. The first error cancels the items still running and is returned.
+func forEach[T any](ctx context.Context, workers int, items []T, work func(ctx context.Context, item T) error) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	slots := make(chan struct{}, workers)
+	var group sync.WaitGroup
+	for _, item := range items {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			defer func() { <-slots }()
+			if err := work(ctx, item); err != nil {
+				cancel(err)
+			}
+		}()
+	}
+	group.Wait()
+	return context.Cause(ctx)
+}
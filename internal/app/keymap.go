@@ -0,0 +1,522 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// binding is one entry of the keymap registry. The registry drives key
+// dispatch, the status bar hints, and the help overlay, so a key only has to
+// be declared once.
+type binding struct {
+	key    tcell.Key
+	ch     rune
+	label  string
+	help   string
+	group  string
+	panes  []pane
+	hint   bool
+	action func(a *App) bool
+}
+
+const (
+	groupGlobal    = "Global"
+	groupTree      = "Subscriptions"
+	groupContents  = "Contents"
+	groupPreview   = "Preview"
+	groupSearch    = "Search dialog"
+	groupBookmarks = "Bookmarks dialog"
+	groupProps     = "Properties dialog"
+	groupFind      = "Find dialog"
+	groupFilter    = "Filter dialog"
+	groupGrep      = "Grep dialog"
+	groupSearches  = "Saved searches"
+	groupHistory   = "Search history"
+	groupProfiles  = "Profiles dialog"
+	groupLog       = "Log viewer"
+	groupStats     = "Statistics dialog"
+	groupLifecycle = "Lifecycle rules"
+	groupChanges   = "Change feed"
+	groupAudit     = "Audit log"
+	groupCORS      = "CORS rules"
+	groupWORM      = "Immutability"
+	groupFailures  = "Failures"
+	groupCalls     = "Provider calls"
+	groupUsage     = "Usage metrics"
+	groupSyncJobs  = "Sync jobs"
+	groupSetup     = "Setup wizard"
+)
+
+// newKeymap lists every binding in display order. Actions report whether they
+// consumed the event; unconsumed events fall through to the focused widget.
+// Bindings without an action document keys handled by the widgets themselves.
+func newKeymap() []binding {
+	return []binding{
+		{key: tcell.KeyRune, ch: '?', label: "?", help: "help", group: groupGlobal, hint: true, action: func(a *App) bool {
+			a.openHelpModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'q', label: "q", help: "quit", group: groupGlobal, hint: true, action: func(a *App) bool {
+			a.app.Stop()
+			return true
+		}},
+		{key: tcell.KeyCtrlC, label: "ctrl-c", help: "quit", group: groupGlobal, action: func(a *App) bool {
+			a.app.Stop()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'r', label: "r", help: "refresh everything", group: groupGlobal, hint: true, action: func(a *App) bool {
+			a.reload()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'R', label: "R", help: "refresh focused", group: groupGlobal, panes: []pane{paneAccounts, paneContents, panePreview}, hint: true, action: func(a *App) bool {
+			a.refreshFocused()
+			return true
+		}},
+		{key: tcell.KeyTab, label: "tab", help: "next pane", group: groupGlobal, hint: true, action: func(a *App) bool {
+			a.cyclePane(false)
+			return true
+		}},
+		{key: tcell.KeyBacktab, label: "shift-tab", help: "previous pane", group: groupGlobal, action: func(a *App) bool {
+			a.cyclePane(true)
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'm', label: "m", help: "bookmark selection", group: groupGlobal, panes: []pane{paneAccounts, paneContents, paneCompare}, hint: true, action: func(a *App) bool {
+			a.toggleBookmark()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: '\'', label: "'", help: "bookmarks", group: groupGlobal, hint: true, action: func(a *App) bool {
+			a.openBookmarksModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'M', label: "M<letter>", help: "set a mark on the selected node or row", group: groupGlobal, panes: []pane{paneAccounts, paneContents, panePreview}, action: func(a *App) bool {
+			a.startMark('M')
+			return true
+		}},
+		{key: tcell.KeyRune, ch: '`', label: "`<letter>", help: "jump to a mark", group: groupGlobal, action: func(a *App) bool {
+			a.startMark('`')
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'f', label: "f", help: "find blobs by name across the selected subscriptions", group: groupGlobal, hint: true, action: func(a *App) bool {
+			a.openFindModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 't', label: "t", help: "find blobs by index tags in the selected account", group: groupGlobal, panes: []pane{paneAccounts, paneContents, panePreview}, action: func(a *App) bool {
+			a.openTagsModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'S', label: "S", help: "saved searches and filters", group: groupGlobal, action: func(a *App) bool {
+			a.openSearchesModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'H', label: "H", help: "search history: earlier find, grep, and tags results", group: groupGlobal, action: func(a *App) bool {
+			a.openHistoryModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'P', label: "P", help: "switch profile", group: groupGlobal, action: func(a *App) bool {
+			a.openProfilesModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'O', label: "O", help: "log of provider calls, messages, and errors", group: groupGlobal, action: func(a *App) bool {
+			a.openLogModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'a', label: "a", help: "audit log of uploads, signed URLs, and setting changes: who, what, when, and the result", group: groupGlobal, action: func(a *App) bool {
+			a.openAuditModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'i', label: "i", help: "properties", group: groupGlobal, panes: []pane{paneAccounts, paneContents, paneCompare, panePreview}, action: func(a *App) bool {
+			a.openPropertiesModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'I', label: "I", help: "container statistics: blob count and size in total and per top-level prefix", group: groupGlobal, panes: []pane{paneAccounts, paneContents, panePreview}, action: func(a *App) bool {
+			a.openStatsModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'l', label: "l", help: "lifecycle management rules of the selected account; add simple rules", group: groupGlobal, panes: []pane{paneAccounts, paneContents, panePreview}, action: func(a *App) bool {
+			a.openLifecycleModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'w', label: "w", help: "open the static website of the selected account in a browser", group: groupGlobal, panes: []pane{paneAccounts, paneContents, panePreview}, action: func(a *App) bool {
+			a.openWebsite()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'N', label: "N", help: "network rules: public access, IP and subnet rules, private endpoints of the selected account", group: groupGlobal, panes: []pane{paneAccounts, paneContents, panePreview}, action: func(a *App) bool {
+			a.openNetworkModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'A', label: "A", help: "access: who holds blob data roles on the selected container or account", group: groupGlobal, panes: []pane{paneAccounts, paneContents, panePreview}, action: func(a *App) bool {
+			a.openAccessModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'c', label: "c", help: "change feed of the selected account: created, updated, and deleted blobs", group: groupGlobal, panes: []pane{paneAccounts, paneContents, panePreview}, action: func(a *App) bool {
+			a.openChangeFeedModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'o', label: "o", help: "CORS rules of the selected account's blob service: view, add, edit, remove", group: groupGlobal, panes: []pane{paneAccounts, paneContents, panePreview}, action: func(a *App) bool {
+			a.openCORSModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'X', label: "X", help: "object replication policies of the selected account, and whether the selected blob has been copied", group: groupGlobal, panes: []pane{paneAccounts, paneContents, panePreview}, action: func(a *App) bool {
+			a.openReplicationModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'K', label: "K", help: "immutability policy and legal holds of the selected container: set, extend, lock", group: groupGlobal, panes: []pane{paneAccounts, paneContents, panePreview}, action: func(a *App) bool {
+			a.openImmutabilityModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'x', label: "x", help: "pipe the selected blob's, or archive file's, content to a shell command and preview its output", group: groupGlobal, panes: []pane{paneAccounts, paneContents, panePreview}, action: func(a *App) bool {
+			a.openPipeModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'e', label: "e", help: "write the selected account, or container, with its lifecycle rules as Terraform or Bicep", group: groupGlobal, panes: []pane{paneAccounts, paneContents, panePreview}, action: func(a *App) bool {
+			a.openIaCModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: '!', label: "!", help: "operations that failed since launch, with the full error: retry or dismiss", group: groupGlobal, action: func(a *App) bool {
+			a.openFailuresModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'D', label: "D", help: "debug: the latest provider calls with their arguments, duration, result size, and error", group: groupGlobal, action: func(a *App) bool {
+			a.openCallsModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'U', label: "U", help: "usage metrics: what is counted, where it goes, and turn them on or off", group: groupGlobal, action: func(a *App) bool {
+			a.openUsageModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'J', label: "J", help: "sync jobs of the config file: when each last and next runs, the runs since launch, and run one now", group: groupGlobal, action: func(a *App) bool {
+			a.openSyncJobsModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'u', label: "u", help: "undo the blobs the last sync job run wrote over or deleted, where the account keeps previous versions or deleted blobs", group: groupGlobal, action: func(a *App) bool {
+			a.undoLast()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'y', label: "y", help: "copy details as text", group: groupGlobal, action: func(a *App) bool {
+			a.copyDetails(false)
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'Y', label: "Y", help: "copy details as JSON", group: groupGlobal, action: func(a *App) bool {
+			a.copyDetails(true)
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'B', label: "B", help: "toggle exact byte sizes", group: groupGlobal, action: func(a *App) bool {
+			a.toggleExactSizes()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'Z', label: "Z", help: "cycle UTC, local, and relative times", group: groupGlobal, action: func(a *App) bool {
+			a.cycleTimeMode()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'T', label: "T", help: "next color theme", group: groupGlobal, action: func(a *App) bool {
+			a.cycleTheme()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'V', label: "V", help: "preview below or beside the contents", group: groupGlobal, action: func(a *App) bool {
+			a.toggleLayout()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: '|', label: "|", help: "split view", group: groupGlobal, hint: true, action: func(a *App) bool {
+			a.toggleSplit()
+			return true
+		}},
+
+		{key: tcell.KeyEnter, label: "enter", help: "expand or collapse", group: groupTree, panes: []pane{paneAccounts}, hint: true},
+		{key: tcell.KeyRight, label: "right", help: "expand or collapse", group: groupTree, panes: []pane{paneAccounts}, action: func(a *App) bool {
+			a.expandTreeNode(a.accounts.GetCurrentNode(), true)
+			return true
+		}},
+		{key: tcell.KeyLeft, label: "left", help: "collapse or go to parent", group: groupTree, panes: []pane{paneAccounts}, hint: true, action: func(a *App) bool {
+			a.collapseOrParent()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: ' ', label: "space", help: "toggle subscription", group: groupTree, panes: []pane{paneAccounts}, hint: true, action: func(a *App) bool {
+			return a.toggleSelectedSubscription()
+		}},
+		{key: tcell.KeyRune, ch: '/', label: "/", help: "jump to the next node matching the name typed next", group: groupTree, panes: []pane{paneAccounts}, hint: true, action: func(a *App) bool {
+			a.startTypeAhead()
+			return true
+		}},
+
+		{key: tcell.KeyEnter, label: "enter", help: "focus blob, open folder or .zip/.tar.gz archive, or load more", group: groupContents, panes: []pane{paneContents, paneCompare}},
+		{key: tcell.KeyRune, ch: 'L', label: "L", help: "load all remaining blobs", group: groupContents, panes: []pane{paneContents}, hint: true, action: func(a *App) bool {
+			a.confirmLoadAllBlobs()
+			return true
+		}},
+
+		{key: tcell.KeyRune, ch: 'F', label: "F", help: "toggle virtual folders with blob counts and sizes", group: groupContents, panes: []pane{paneContents, panePreview}, hint: true, action: func(a *App) bool {
+			a.toggleFolderView()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'C', label: "C", help: "toggle tier, lease, and tags columns, fetched for the rows in view", group: groupContents, panes: []pane{paneContents, panePreview}, action: func(a *App) bool {
+			a.toggleExtraColumns()
+			return true
+		}},
+		{key: tcell.KeyBackspace, label: "backspace", help: "folder view or archive: go up to the parent folder, or out of the archive", group: groupContents, panes: []pane{paneContents}, action: func(a *App) bool {
+			return a.folderUp()
+		}},
+		{key: tcell.KeyEsc, label: "esc", help: "stop listing or load all, keeping the blobs listed so far", group: groupContents, panes: []pane{paneContents, panePreview}, action: func(a *App) bool {
+			return a.cancelEnumeration()
+		}},
+		{key: tcell.KeyRune, ch: '/', label: "/", help: "filter blobs by name, modified time, size, or content type", group: groupContents, panes: []pane{paneContents}, hint: true, action: func(a *App) bool {
+			a.openFilterModal()
+			return true
+		}},
+		{key: tcell.KeyEsc, label: "esc", help: "clear the filter", group: groupContents, panes: []pane{paneContents}, action: func(a *App) bool {
+			return a.clearContentsFilter()
+		}},
+		{key: tcell.KeyRune, ch: 'p', label: "p", help: "list only blobs whose names start with a prefix", group: groupContents, panes: []pane{paneContents}, action: func(a *App) bool {
+			a.openPrefixModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'E', label: "E", help: "export the blobs shown to CSV, JSON, or NDJSON, or as an HTML or Markdown report", group: groupContents, panes: []pane{paneContents}, action: func(a *App) bool {
+			a.openExportModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 's', label: "s", help: "save the selected blob, or file in an archive, to a local file", group: groupContents, panes: []pane{paneContents, panePreview}, action: func(a *App) bool {
+			a.openSaveModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'g', label: "g", help: "grep inside the text blobs of the container or folder", group: groupContents, panes: []pane{paneContents, panePreview}, action: func(a *App) bool {
+			a.openGrepModal()
+			return true
+		}},
+		{key: tcell.KeyRune, ch: 'W', label: "W", help: "watch: re-list periodically, or follow storage events, and mark new or changed blobs", group: groupContents, panes: []pane{paneContents, panePreview}, action: func(a *App) bool {
+			a.toggleWatch()
+			return true
+		}},
+
+		{key: tcell.KeyRune, ch: '/', label: "/", help: "search preview", group: groupPreview, panes: []pane{panePreview}, hint: true, action: func(a *App) bool {
+			if !a.previewSearchable {
+				return false
+			}
+			a.openSearchModal()
+			return true
+		}},
+		{key: tcell.KeyEsc, label: "esc", help: "clear search", group: groupPreview, panes: []pane{panePreview}, hint: true, action: func(a *App) bool {
+			if a.previewSearch == "" {
+				return false
+			}
+			a.clearSearch()
+			return true
+		}},
+
+		{key: tcell.KeyEnter, label: "enter", help: "apply search", group: groupSearch},
+		{key: tcell.KeyTab, label: "tab/space", help: "move to the match case and whole words options, and toggle them", group: groupSearch},
+		{key: tcell.KeyEsc, label: "esc", help: "cancel and clear search", group: groupSearch},
+
+		{key: tcell.KeyEnter, label: "enter", help: "jump to bookmark", group: groupBookmarks},
+		{key: tcell.KeyRune, ch: 'd', label: "d", help: "delete bookmark", group: groupBookmarks},
+		{key: tcell.KeyEsc, label: "esc", help: "close", group: groupBookmarks},
+
+		{key: tcell.KeyUp, label: "up/down", help: "scroll", group: groupProps},
+		{key: tcell.KeyRune, ch: 'y', label: "y", help: "copy properties as text", group: groupProps},
+		{key: tcell.KeyRune, ch: 'Y', label: "Y", help: "copy properties as JSON", group: groupProps},
+		{key: tcell.KeyEsc, label: "esc", help: "close", group: groupProps},
+
+		{key: tcell.KeyEnter, label: "enter", help: "search, or jump to the selected match", group: groupFind},
+		{key: tcell.KeyTab, label: "tab", help: "switch between the pattern and the results", group: groupFind},
+		{key: tcell.KeyEsc, label: "esc", help: "stop the running search, or close", group: groupFind},
+
+		{key: tcell.KeyEnter, label: "enter", help: "apply the filter", group: groupFilter},
+		{key: tcell.KeyTab, label: "tab", help: "next field", group: groupFilter},
+		{key: tcell.KeyEsc, label: "esc", help: "close, undoing the filtering typed so far", group: groupFilter},
+
+		{key: tcell.KeyEnter, label: "enter", help: "search, or jump to the blob of the selected line", group: groupGrep},
+		{key: tcell.KeyTab, label: "tab", help: "switch between the pattern and the results", group: groupGrep},
+		{key: tcell.KeyEsc, label: "esc", help: "stop the running search, or close", group: groupGrep},
+
+		{key: tcell.KeyCtrlS, label: "ctrl-s", help: "in the filter, find, grep, or tags dialog: save it under a name", group: groupSearches},
+		{key: tcell.KeyEnter, label: "enter/1-9", help: "run the selected search", group: groupSearches},
+		{key: tcell.KeyRune, ch: 'd', label: "d", help: "delete the selected search", group: groupSearches},
+		{key: tcell.KeyEsc, label: "esc", help: "close", group: groupSearches},
+
+		{key: tcell.KeyEnter, label: "enter", help: "show the results of the selected search, or jump to the selected result", group: groupHistory},
+		{key: tcell.KeyTab, label: "tab", help: "switch between the searches and their results", group: groupHistory},
+		{key: tcell.KeyRune, ch: 'd', label: "d", help: "forget the selected search", group: groupHistory},
+		{key: tcell.KeyEsc, label: "esc", help: "close", group: groupHistory},
+
+		{key: tcell.KeyEnter, label: "enter", help: "switch to the selected profile", group: groupProfiles},
+		{key: tcell.KeyEsc, label: "esc", help: "close", group: groupProfiles},
+
+		{key: tcell.KeyRune, ch: 'd', label: "d/i/w/e", help: "show debug, info, warn, or error records and above", group: groupLog},
+		{key: tcell.KeyEsc, label: "esc", help: "close", group: groupLog},
+
+		{key: tcell.KeyUp, label: "up/down", help: "scroll", group: groupStats},
+		{key: tcell.KeyEsc, label: "esc", help: "stop the running count, or close", group: groupStats},
+
+		{key: tcell.KeyUp, label: "up/down", help: "scroll", group: groupLifecycle},
+		{key: tcell.KeyRune, ch: 'n', label: "n", help: "add a rule that moves blobs under a prefix to Cool, deletes them, or both, some days after modification", group: groupLifecycle},
+		{key: tcell.KeyEsc, label: "esc", help: "close, or cancel the rule being added", group: groupLifecycle},
+
+		{key: tcell.KeyRune, ch: '[', label: "[/]", help: "shorter or longer time window: last hour, 6 hours, 24 hours, 7 days, 30 days", group: groupChanges},
+		{key: tcell.KeyEnter, label: "enter", help: "jump to the changed blob", group: groupChanges},
+		{key: tcell.KeyEsc, label: "esc", help: "close", group: groupChanges},
+
+		{key: tcell.KeyUp, label: "up/down", help: "scroll", group: groupAudit},
+		{key: tcell.KeyRune, ch: 'u', label: "u", help: "undo the selected upload or sync: restore the blobs it wrote over or deleted", group: groupAudit},
+		{key: tcell.KeyEsc, label: "esc", help: "close", group: groupAudit},
+
+		{key: tcell.KeyRune, ch: 'n', label: "n", help: "add a rule", group: groupCORS},
+		{key: tcell.KeyEnter, label: "enter", help: "edit the selected rule", group: groupCORS},
+		{key: tcell.KeyRune, ch: 'd', label: "d", help: "remove the selected rule, once confirmed", group: groupCORS},
+		{key: tcell.KeyEsc, label: "esc", help: "close, or cancel the rule being edited", group: groupCORS},
+
+		{key: tcell.KeyRune, ch: 's', label: "s", help: "set the retention period, or extend it once locked", group: groupWORM},
+		{key: tcell.KeyRune, ch: 'L', label: "L", help: "lock the unlocked policy, once the container's name is typed; cannot be undone", group: groupWORM},
+		{key: tcell.KeyEsc, label: "esc", help: "close", group: groupWORM},
+
+		{key: tcell.KeyEnter, label: "enter/r", help: "retry the selected operation", group: groupFailures},
+		{key: tcell.KeyRune, ch: 'd', label: "d", help: "dismiss the selected failure", group: groupFailures},
+		{key: tcell.KeyRune, ch: 'D', label: "D", help: "dismiss every failure", group: groupFailures},
+		{key: tcell.KeyEsc, label: "esc", help: "close", group: groupFailures},
+
+		{key: tcell.KeyRune, ch: 'e', label: "e", help: "show only failed calls, or all again", group: groupCalls},
+		{key: tcell.KeyEsc, label: "esc", help: "close", group: groupCalls},
+
+		{key: tcell.KeyRune, ch: 'u', label: "u", help: "turn usage metrics on or off; off deletes the counts not yet sent", group: groupUsage},
+		{key: tcell.KeyEsc, label: "esc", help: "close", group: groupUsage},
+
+		{key: tcell.KeyEnter, label: "enter/r", help: "run the selected job now", group: groupSyncJobs},
+		{key: tcell.KeyRune, ch: 'd', label: "d", help: "dry run: plan the selected job without carrying it out, to review or export", group: groupSyncJobs},
+		{key: tcell.KeyRune, ch: 'p', label: "p", help: "show the plan of the selected job's last run, to review or export", group: groupSyncJobs},
+		{key: tcell.KeyTab, label: "tab", help: "switch between the jobs and their runs", group: groupSyncJobs},
+		{key: tcell.KeyEsc, label: "esc", help: "close", group: groupSyncJobs},
+
+		{key: tcell.KeyTab, label: "tab", help: "next field or button", group: groupSetup},
+		{key: tcell.KeyEnter, label: "enter", help: "open a choice or press a button (Back, Next, Save)", group: groupSetup},
+		{key: tcell.KeyEsc, label: "esc", help: "skip setup for this launch", group: groupSetup},
+	}
+}
+
+// rebindKeys moves bindings to the keys in remap, from the label a binding
+// shows in the help, without a trailing <letter>, to a key such as "F",
+// "ctrl-f", or "f5". Only bindings the app dispatches itself can move, and
+// not onto a key another of them already uses.
+func rebindKeys(keys []binding, remap map[string]string) error {
+	var problems []string
+	var moved []int
+	for from, to := range remap {
+		key, ch, err := parseKey(to)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		found := false
+		for i := range keys {
+			if keys[i].action != nil && strings.TrimSuffix(keys[i].label, "<letter>") == from {
+				keys[i].key, keys[i].ch = key, ch
+				keys[i].label = strings.Replace(keys[i].label, from, to, 1)
+				moved = append(moved, i)
+				found = true
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("no key %q to rebind", from))
+		}
+	}
+	for _, i := range moved {
+		b := keys[i]
+		for j, other := range keys {
+			if j != i && other.action != nil && b.key == other.key && b.ch == other.ch && b.sharesPane(other) {
+				problems = append(problems, fmt.Sprintf("%s would be bound to both %q and %q", b.label, b.help, other.help))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// parseKey reads a single character or a key name such as "ctrl-f", "f5", or
+// "pgdn", case-insensitively.
+func parseKey(name string) (tcell.Key, rune, error) {
+	if runes := []rune(name); len(runes) == 1 {
+		return tcell.KeyRune, runes[0], nil
+	}
+	for key, keyName := range tcell.KeyNames {
+		if strings.EqualFold(keyName, name) {
+			return key, 0, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("unknown key %q", name)
+}
+
+func (b binding) matches(event *tcell.EventKey) bool {
+	if event.Key() != b.key {
+		return false
+	}
+	return b.key != tcell.KeyRune || event.Rune() == b.ch
+}
+
+// sharesPane reports whether both bindings can apply in some pane.
+func (b binding) sharesPane(other binding) bool {
+	if len(b.panes) == 0 || len(other.panes) == 0 {
+		return true
+	}
+	for _, candidate := range b.panes {
+		if other.appliesTo(candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b binding) appliesTo(target pane) bool {
+	if len(b.panes) == 0 {
+		return true
+	}
+	for _, candidate := range b.panes {
+		if candidate == target {
+			return true
+		}
+	}
+	return false
+}
+
+// handleKey dispatches an event through the keymap registry. In the tree,
+// runes no binding wants start a type-ahead jump, and once a jump is in
+// progress every printable rune extends it. After M or `, the next key names
+// the mark register instead.
+func (a *App) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	if a.markPending != 0 {
+		a.finishMark(event)
+		return nil
+	}
+	if a.activePane == paneAccounts && a.typeAheadActive() && a.typeAheadKey(event) {
+		return nil
+	}
+	for _, b := range a.keys {
+		if b.action == nil || !b.matches(event) || !b.appliesTo(a.activePane) {
+			continue
+		}
+		if b.action(a) {
+			a.usage.Action(b.group + ": " + b.help)
+			return nil
+		}
+	}
+	return event
+}
+
+// paneHints returns the bindings worth advertising in the status bar for the
+// given pane: pane specific keys first, then global ones.
+func (a *App) paneHints(target pane) []binding {
+	var specific, global []binding
+	for _, b := range a.keys {
+		if !b.hint || !b.appliesTo(target) {
+			continue
+		}
+		if len(b.panes) == 0 {
+			global = append(global, b)
+		} else {
+			specific = append(specific, b)
+		}
+	}
+	return append(specific, global...)
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+)
+
+// layoutMode places the preview pane below the contents table (stacked) or to
+// its right, giving three columns on wide terminals.
+type layoutMode int
+
+const (
+	layoutStacked layoutMode = iota
+	layoutColumns
+)
+
+var layoutModeNames = []string{"stacked", "columns"}
+
+func (m layoutMode) String() string {
+	return layoutModeNames[m]
+}
+
+func layoutModeByName(name string) (layoutMode, bool) {
+	if name == "" {
+		return layoutStacked, true
+	}
+	for i, candidate := range layoutModeNames {
+		if strings.EqualFold(candidate, name) {
+			return layoutMode(i), true
+		}
+	}
+	return layoutStacked, false
+}
+
+// LayoutRatios are the relative sizes of the tree, contents, and preview
+// panes. In the stacked layout the tree is sized against the contents and
+// preview together.
+type LayoutRatios struct {
+	Tree     int
+	Contents int
+	Preview  int
+}
+
+// forLayout returns the ratios to use in mode, filling unset ones with the
+// built-in sizes: 1:2:1 stacked and 1:2:2 in columns.
+func (r LayoutRatios) forLayout(mode layoutMode) LayoutRatios {
+	defaults := LayoutRatios{Tree: 1, Contents: 2, Preview: 1}
+	if mode == layoutColumns {
+		defaults.Preview = 2
+	}
+	if r.Tree <= 0 {
+		r.Tree = defaults.Tree
+	}
+	if r.Contents <= 0 {
+		r.Contents = defaults.Contents
+	}
+	if r.Preview <= 0 {
+		r.Preview = defaults.Preview
+	}
+	return r
+}
+
+// applyLayout arranges the tree, contents, and preview panes for the current
+// layout mode.
+func (a *App) applyLayout() {
+	a.body.Clear()
+	a.mainColumn.Clear()
+	ratios := a.ratios.forLayout(a.layout)
+	a.body.AddItem(a.accounts, 0, ratios.Tree, true)
+	switch a.layout {
+	case layoutColumns:
+		a.body.AddItem(a.contentsRow, 0, ratios.Contents, false).
+			AddItem(a.preview, 0, ratios.Preview, false)
+	default:
+		a.mainColumn.AddItem(a.contentsRow, 0, ratios.Contents, true).
+			AddItem(a.preview, 0, ratios.Preview, false)
+		a.body.AddItem(a.mainColumn, 0, ratios.Contents+ratios.Preview, false)
+	}
+}
+
+// toggleLayout switches between the stacked and three-column layouts.
+func (a *App) toggleLayout() {
+	a.layout = (a.layout + 1) % layoutMode(len(layoutModeNames))
+	a.applyLayout()
+	a.setActivePane(a.activePane)
+	a.flash(fmt.Sprintf("Layout: %s", a.layout))
+}
@@ -0,0 +1,305 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"storage-tui/internal/audit"
+	"storage-tui/internal/azure"
+)
+
+// lifecycleActions spell out the actions of lifecycle rules.
+var lifecycleActions = map[string]string{
+	"tierToCool":    "move to Cool",
+	"tierToCold":    "move to Cold",
+	"tierToArchive": "move to Archive",
+	"delete":        "delete",
+}
+
+func (a *App) setupLifecycleModal() {
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true)
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc || event.Rune() == 'l' || event.Rune() == 'q':
+			a.closeLifecycleModal()
+			return nil
+		case event.Rune() == 'n':
+			a.openLifecycleForm()
+			return nil
+		}
+		return event
+	})
+
+	form := tview.NewForm().
+		AddInputField("Name: ", "", 0, nil, nil).
+		AddInputField("Prefix (blank for all): ", "", 0, nil, nil).
+		AddInputField("Move to Cool after (days): ", "", 8, tview.InputFieldInteger, nil).
+		AddInputField("Delete after (days): ", "", 8, tview.InputFieldInteger, nil).
+		AddButton("Save", a.saveLifecycleForm).
+		AddButton("Cancel", a.closeLifecycleForm)
+	form.SetBorder(true)
+	form.SetButtonsAlign(tview.AlignRight)
+	form.SetCancelFunc(a.closeLifecycleForm)
+
+	a.lifecycleView = table
+	a.lifecycleForm = form
+	a.pages.AddPage("lifecycle", centerModal(table, 24, 110), true, false)
+	a.pages.AddPage("lifecycle-rule", centerModal(form, 13, 80), true, false)
+}
+
+// openLifecycleModal shows the lifecycle management rules of the selected
+// account, or of the account the selection or the listing is in, one line
+// per action, so blobs that changed tier or went away can be traced to the
+// rule that did it. Simple rules can be added there.
+func (a *App) openLifecycleModal() {
+	ref, ok := a.selectedRef()
+	if !ok || ref.Account == "" {
+		ref = a.contentSource
+	}
+	if ref.Account == "" {
+		a.flashErr("Select an account to show its lifecycle rules.")
+		return
+	}
+	a.lifecycleAccount = ref.Account
+	a.lifecycleOpen = true
+	a.pages.ShowPage("lifecycle")
+	a.app.SetFocus(a.lifecycleView)
+	a.loadLifecycleRules()
+}
+
+// loadLifecycleRules reads the account's policy in the background.
+func (a *App) loadLifecycleRules() {
+	a.lifecycleSeq++
+	seq := a.lifecycleSeq
+	account := a.lifecycleAccount
+	a.lifecycleLoaded = false
+	a.lifecycleRules = nil
+	a.lifecycleView.Clear()
+	a.lifecycleView.SetCell(0, 0, tview.NewTableCell("Loading lifecycle rules…").SetSelectable(false))
+	a.lifecycleView.SetTitle(fmt.Sprintf("Lifecycle rules: %s  esc: close", account))
+
+	runAsync(a, a.activePane, func(ctx context.Context) (azure.LifecyclePolicy, error) {
+		return a.provider.GetLifecyclePolicy(ctx, account)
+	}, func(policy azure.LifecyclePolicy, err error) {
+		if !a.lifecycleOpen || seq != a.lifecycleSeq {
+			return
+		}
+		a.lifecycleView.Clear()
+		if err != nil {
+			a.lifecycleView.SetCell(0, 0, tview.NewTableCell(tview.Escape(fmt.Sprintf("Error loading lifecycle rules: %v", err))).SetSelectable(false))
+			return
+		}
+		a.lifecycleLoaded = true
+		a.lifecycleRules = policy.Rules
+		title := "Lifecycle rules: " + account
+		if !policy.LastModified.IsZero() {
+			title += ", changed " + a.formatTimestamp(policy.LastModified)
+		}
+		a.lifecycleView.SetTitle(title + "  n: new rule | esc: close")
+		a.renderLifecycleRules(policy.Rules)
+	})
+}
+
+func (a *App) closeLifecycleModal() {
+	a.lifecycleSeq++
+	a.pages.HidePage("lifecycle")
+	a.lifecycleOpen = false
+	a.setActivePane(a.activePane)
+}
+
+// renderLifecycleRules lists each action of each rule on its own row, with
+// the rule's name, status, and filters on its first.
+func (a *App) renderLifecycleRules(rules []azure.LifecycleRule) {
+	table := a.lifecycleView
+	if len(rules) == 0 {
+		table.SetCell(0, 0, tview.NewTableCell("The account has no lifecycle management policy.").SetSelectable(false))
+		return
+	}
+	for column, title := range []string{"Rule", "Status", "Applies to", "Action"} {
+		table.SetCell(0, column, tview.NewTableCell(title).SetAttributes(tcell.AttrBold).SetSelectable(false))
+	}
+	row := 1
+	for _, rule := range rules {
+		status, color := "enabled", a.theme.text
+		if !rule.Enabled {
+			status, color = "disabled", a.theme.muted
+		}
+		cells := []string{rule.Name, status, lifecycleFilters(rule)}
+		if len(rule.Actions) == 0 {
+			rule.Actions = []azure.LifecycleAction{{}}
+		}
+		for _, action := range rule.Actions {
+			for column, text := range append(cells, lifecycleAction(action)) {
+				cell := tview.NewTableCell(tview.Escape(text)).SetTextColor(color)
+				if column == 2 {
+					cell.SetMaxWidth(40)
+				}
+				table.SetCell(row, column, cell)
+			}
+			cells = []string{"", "", ""}
+			row++
+		}
+	}
+	table.Select(1, 0)
+	table.ScrollToBeginning()
+}
+
+// lifecycleFilters describes the blobs a rule selects, such as
+// "blockBlob; prefix logs/; tags "retain" = 'no'".
+func lifecycleFilters(rule azure.LifecycleRule) string {
+	parts := []string{strings.Join(rule.BlobTypes, ", ")}
+	if len(rule.PrefixMatch) > 0 {
+		parts = append(parts, "prefix "+strings.Join(rule.PrefixMatch, ", "))
+	}
+	if len(rule.TagMatch) > 0 {
+		conditions := make([]string, 0, len(rule.TagMatch))
+		for _, condition := range rule.TagMatch {
+			conditions = append(conditions, fmt.Sprintf("%q %s '%s'", condition.Key, condition.Operator, condition.Value))
+		}
+		parts = append(parts, "tags "+strings.Join(conditions, " AND "))
+	}
+	return strings.TrimPrefix(strings.Join(parts, "; "), "; ")
+}
+
+// lifecycleAction describes an action, such as "move to Cool 30 days after
+// modification" or "versions: delete 90 days after creation".
+func lifecycleAction(action azure.LifecycleAction) string {
+	if action.Action == "" {
+		return "none"
+	}
+	name, ok := lifecycleActions[action.Action]
+	if !ok {
+		name = action.Action
+	}
+	days := fmt.Sprintf("%d days", action.Days)
+	if action.Days == 1 {
+		days = "1 day"
+	}
+	text := fmt.Sprintf("%s %s after %s", name, days, action.Since)
+	switch action.Target {
+	case "snapshot":
+		return "snapshots: " + text
+	case "version":
+		return "versions: " + text
+	}
+	return text
+}
+
+// openLifecycleForm starts a new rule for block blobs: a prefix, and the
+// days after modification at which its blobs move to Cool and are deleted.
+func (a *App) openLifecycleForm() {
+	if !a.lifecycleLoaded {
+		return
+	}
+	if len(a.lifecycleRules) >= azure.MaxLifecycleRules {
+		a.flashErr(fmt.Sprintf("A lifecycle policy holds at most %d rules.", azure.MaxLifecycleRules))
+		return
+	}
+	for i := range 4 {
+		a.lifecycleForm.GetFormItem(i).(*tview.InputField).SetText("")
+	}
+	a.lifecycleFormOpen = true
+	a.lifecycleForm.SetTitle(fmt.Sprintf("New lifecycle rule for %s  esc: cancel", a.lifecycleAccount))
+	a.lifecycleForm.SetFocus(0)
+	a.pages.ShowPage("lifecycle-rule")
+	a.app.SetFocus(a.lifecycleForm)
+}
+
+func (a *App) closeLifecycleForm() {
+	a.pages.HidePage("lifecycle-rule")
+	a.lifecycleFormOpen = false
+	a.app.SetFocus(a.lifecycleView)
+}
+
+// saveLifecycleForm checks the rule in the form and adds it to the policy.
+// A rule that deletes blobs is confirmed first, as blobs already past its
+// age go within a day.
+func (a *App) saveLifecycleForm() {
+	field := func(i int) string {
+		return strings.TrimSpace(a.lifecycleForm.GetFormItem(i).(*tview.InputField).GetText())
+	}
+	rule := azure.LifecycleRule{Name: field(0), Enabled: true, BlobTypes: []string{"blockBlob"}}
+	if prefix := field(1); prefix != "" {
+		rule.PrefixMatch = []string{prefix}
+	}
+	deleteDays := -1
+	for _, step := range []struct {
+		field  int
+		action string
+	}{{2, "tierToCool"}, {3, "delete"}} {
+		text := field(step.field)
+		if text == "" {
+			continue
+		}
+		days, err := strconv.Atoi(text)
+		if err != nil {
+			a.flashErr(fmt.Sprintf("%q is not a number of days.", text))
+			return
+		}
+		rule.Actions = append(rule.Actions, azure.LifecycleAction{Target: "base blob", Action: step.action, Since: "modification", Days: days})
+		if step.action == "delete" {
+			deleteDays = days
+		}
+	}
+	if len(rule.Actions) == 0 {
+		a.flashErr("Give the rule the days to move blobs to Cool, to delete them, or both.")
+		return
+	}
+	rules := append(slices.Clone(a.lifecycleRules), rule)
+	if err := azure.ValidateLifecycleRules(rules); err != nil {
+		a.flashErr(fmt.Sprintf("Lifecycle: %v", err))
+		return
+	}
+	a.closeLifecycleForm()
+
+	actions := make([]string, 0, len(rule.Actions))
+	for _, action := range rule.Actions {
+		actions = append(actions, lifecycleAction(action))
+	}
+	detail := fmt.Sprintf("added rule %s: %s; %s", rule.Name, lifecycleFilters(rule), strings.Join(actions, ", "))
+	if deleteDays < 0 {
+		a.applyLifecycleRules(rules, detail)
+		return
+	}
+	blobs := "every block blob"
+	if len(rule.PrefixMatch) > 0 {
+		blobs = "block blobs under " + rule.PrefixMatch[0]
+	}
+	a.confirm(confirmation{
+		Title: "Add deleting lifecycle rule",
+		Message: fmt.Sprintf("Add rule %s to %s? It deletes %s %d days after they were last modified, and those already older go within a day; unless soft delete or versioning keeps them, they cannot be restored.",
+			rule.Name, a.lifecycleAccount, blobs, deleteDays),
+		Action:    "Add",
+		Danger:    true,
+		Back:      a.lifecycleView,
+		OnConfirm: func() { a.applyLifecycleRules(rules, detail) },
+	})
+}
+
+// applyLifecycleRules replaces the rules of the account's lifecycle policy
+// and records the change, described by detail, in the audit log.
+func (a *App) applyLifecycleRules(rules []azure.LifecycleRule, detail string) {
+	editor, ok := a.provider.(azure.LifecycleEditor)
+	if !ok {
+		a.flashErr("This provider cannot change lifecycle policies.")
+		return
+	}
+	account := a.lifecycleAccount
+	entry := audit.Entry{Action: "lifecycle", Target: "az://" + account, Detail: detail}
+	a.auditedChange(entry, "the lifecycle policy of "+account, func(ctx context.Context) error {
+		return editor.SetLifecyclePolicy(ctx, account, rules)
+	}, func() {
+		if a.lifecycleOpen && a.lifecycleAccount == account {
+			a.loadLifecycleRules()
+		}
+	})
+}
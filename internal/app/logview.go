@@ -0,0 +1,107 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// logLevelKeys pick the least severe level the log viewer shows.
+var logLevelKeys = map[rune]slog.Level{
+	'd': slog.LevelDebug,
+	'i': slog.LevelInfo,
+	'w': slog.LevelWarn,
+	'e': slog.LevelError,
+}
+
+func (a *App) setupLogModal() {
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetWrap(false)
+	view.SetBorder(true)
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc || event.Rune() == 'O' || event.Rune() == 'q' {
+			a.closeLogModal()
+			return nil
+		}
+		if level, ok := logLevelKeys[event.Rune()]; ok {
+			a.logLevel = level
+			a.renderLog()
+			return nil
+		}
+		return event
+	})
+
+	a.logView = view
+	a.pages.AddPage("log", centerModal(view, 30, 120), true, false)
+}
+
+// openLogModal shows the latest log records, newest at the bottom. Records
+// logged while it is open are added as they come.
+func (a *App) openLogModal() {
+	a.logOpen = true
+	a.renderLog()
+	a.log.Notify(func() {
+		// Records are also logged on the UI goroutine, which must not wait
+		// on its own update queue.
+		go a.queueUpdate(func() {
+			if a.logOpen {
+				a.renderLog()
+			}
+		})
+	})
+	a.pages.ShowPage("log")
+	a.app.SetFocus(a.logView)
+}
+
+func (a *App) closeLogModal() {
+	a.log.Notify(nil)
+	a.pages.HidePage("log")
+	a.logOpen = false
+	a.setActivePane(a.activePane)
+}
+
+func (a *App) renderLog() {
+	// Keep the reader's place unless they are following the newest records.
+	row, _ := a.logView.GetScrollOffset()
+	_, _, _, height := a.logView.GetInnerRect()
+	following := row+height >= a.logView.GetOriginalLineCount()
+
+	var builder strings.Builder
+	shown := 0
+	for _, record := range a.log.Recent() {
+		if record.Level < a.logLevel {
+			continue
+		}
+		shown++
+		fmt.Fprintf(&builder, "%s %s%-5s[-] %s", a.formatTimestamp(record.Time), colorTag(a.logLevelColor(record.Level)), record.Level, tview.Escape(record.Message))
+		if record.Attrs != "" {
+			fmt.Fprintf(&builder, "  %s%s[-]", colorTag(a.theme.muted), tview.Escape(record.Attrs))
+		}
+		builder.WriteString("\n")
+	}
+	if shown == 0 {
+		builder.WriteString("Nothing logged at this level yet.\n")
+	}
+	a.logView.SetTitle(fmt.Sprintf("Log: %s and above (%d)  d/i/w/e: level | esc: close", strings.ToLower(a.logLevel.String()), shown))
+	a.logView.SetText(builder.String())
+	if following {
+		a.logView.ScrollToEnd()
+	}
+}
+
+func (a *App) logLevelColor(level slog.Level) tcell.Color {
+	switch {
+	case level >= slog.LevelError:
+		return a.theme.failure
+	case level >= slog.LevelWarn:
+		return a.theme.changed
+	case level >= slog.LevelInfo:
+		return a.theme.text
+	}
+	return a.theme.muted
+}
@@ -0,0 +1,187 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// mark is a position remembered in a single-letter register for the session:
+// a tree node, or a row of the contents table.
+type mark struct {
+	pane pane
+	ref  itemRef
+}
+
+// startMark waits for the register letter that completes a set (M) or jump
+// (`) command.
+func (a *App) startMark(command rune) {
+	a.markPending = command
+	if command == 'M' {
+		a.flash("Set mark: press a letter (esc cancels).")
+	} else {
+		a.flash("Jump to mark: press a letter (esc cancels).")
+	}
+}
+
+// finishMark consumes the key after M or `; anything but a letter cancels.
+func (a *App) finishMark(event *tcell.EventKey) {
+	command := a.markPending
+	a.markPending = 0
+	name := event.Rune()
+	if event.Key() != tcell.KeyRune || !isMarkName(name) {
+		a.flash("Mark canceled.")
+		return
+	}
+	if command == 'M' {
+		a.setMark(name)
+	} else {
+		a.jumpToMark(name)
+	}
+}
+
+func isMarkName(name rune) bool {
+	return (name >= 'a' && name <= 'z') || (name >= 'A' && name <= 'Z')
+}
+
+func (a *App) setMark(name rune) {
+	m := mark{pane: a.activePane}
+	switch a.activePane {
+	case paneAccounts:
+		node := a.accounts.GetCurrentNode()
+		if node == nil {
+			a.flashErr("Nothing to mark.")
+			return
+		}
+		m.ref, _ = node.GetReference().(itemRef)
+	default:
+		row, _ := a.contents.GetSelection()
+		ref, ok := a.contentRef(row)
+		if !ok || (ref.Kind != kindBlob && ref.Kind != kindFolder) {
+			a.flashErr("Select a blob or folder to mark.")
+			return
+		}
+		m.pane = paneContents
+		m.ref = ref
+	}
+	if m.ref.Kind == kindRoot || m.ref.Kind == kindNone {
+		a.flashErr("Nothing to mark.")
+		return
+	}
+	a.marks[name] = m
+	a.flash(fmt.Sprintf("Mark %c set on %s.", name, markTarget(m.ref)))
+}
+
+func (a *App) jumpToMark(name rune) {
+	m, ok := a.marks[name]
+	if !ok {
+		a.flashErr(fmt.Sprintf("Mark %c is not set.", name))
+		return
+	}
+
+	if m.pane == paneAccounts {
+		if node := a.findTreeNode(m.ref); node != nil {
+			a.selectTreeNode(node, true)
+			a.flash(fmt.Sprintf("Jumped to mark %c.", name))
+			return
+		}
+		if m.ref.Kind != kindContainer {
+			a.flashErr(fmt.Sprintf("Mark %c: %s is no longer in the tree.", name, markTarget(m.ref)))
+			return
+		}
+	}
+
+	container := m.ref
+	if m.pane == paneContents && sameContainer(a.contentSource, container) && !a.contentsLoading && a.selectMarkedRow(m) {
+		a.setActivePane(paneContents)
+		a.flash(fmt.Sprintf("Jumped to mark %c.", name))
+		return
+	}
+	loc := location{SubscriptionID: container.SubscriptionID, Account: container.Account, Container: container.Container}
+	a.revealLocation(loc, func(err error) {
+		if err != nil {
+			a.flashErr(fmt.Sprintf("Mark %c: %v", name, err))
+			return
+		}
+		if m.pane == paneAccounts {
+			a.flash(fmt.Sprintf("Jumped to mark %c.", name))
+			return
+		}
+		a.whenContentsLoaded(func(err error) {
+			switch {
+			case err != nil:
+				a.flashErr(fmt.Sprintf("Mark %c: %v", name, err))
+			case a.selectMarkedRow(m):
+				a.setActivePane(paneContents)
+				a.flash(fmt.Sprintf("Jumped to mark %c.", name))
+			default:
+				a.flashErr(fmt.Sprintf("Mark %c: %s is not listed.", name, markTarget(m.ref)))
+			}
+		})
+	})
+}
+
+// selectMarkedRow selects the marked blob or folder in the contents table,
+// which must already list the mark's container.
+func (a *App) selectMarkedRow(m mark) bool {
+	if m.ref.Kind == kindBlob {
+		return a.selectBlob(m.ref.Name)
+	}
+	if !a.folderView {
+		return false
+	}
+	a.folderPrefix = parentPrefix(m.ref.Prefix)
+	a.renderContents(false)
+	for row, ref := range a.contentRefs {
+		if ref.Kind == kindFolder && ref.Prefix == m.ref.Prefix && ref.Name != ".." {
+			a.contents.Select(row, 0)
+			return true
+		}
+	}
+	return false
+}
+
+// findTreeNode looks up the loaded tree node for ref, collapsed or not.
+func (a *App) findTreeNode(target itemRef) *tview.TreeNode {
+	var found *tview.TreeNode
+	a.root.Walk(func(node, parent *tview.TreeNode) bool {
+		if found != nil {
+			return false
+		}
+		ref, ok := node.GetReference().(itemRef)
+		if ok && ref.Kind == target.Kind && ref.SubscriptionID == target.SubscriptionID &&
+			ref.Account == target.Account && ref.Container == target.Container && ref.Name == target.Name {
+			found = node
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// selectTreeNode expands the ancestors of node and makes it current, moving
+// the focus to the tree when focus is set.
+func (a *App) selectTreeNode(node *tview.TreeNode, focus bool) {
+	path := a.accounts.GetPath(node)
+	for _, ancestor := range path[:len(path)-1] {
+		ancestor.SetExpanded(true)
+	}
+	a.accounts.SetCurrentNode(node)
+	a.onTreeChanged(node)
+	if focus {
+		a.setActivePane(paneAccounts)
+	}
+}
+
+func markTarget(ref itemRef) string {
+	switch ref.Kind {
+	case kindContainer:
+		return fmt.Sprintf("%s/%s", ref.Account, ref.Container)
+	case kindBlob:
+		return fmt.Sprintf("%s/%s/%s", ref.Account, ref.Container, ref.Name)
+	case kindFolder:
+		return fmt.Sprintf("%s/%s/%s", ref.Account, ref.Container, ref.Prefix)
+	}
+	return ref.Name
+}
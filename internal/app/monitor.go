@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"storage-tui/internal/azure"
+)
+
+// metricsSpan and metricsInterval are how far back the account metrics in
+// Details reach and how much time each point of their sparklines covers.
+const (
+	metricsSpan     = 24 * time.Hour
+	metricsInterval = 2 * time.Hour
+)
+
+// sparkBars are the bar heights of a sparkline, lowest first.
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// accountMetricItems returns the Details lines of the account's capacity,
+// transactions, and egress over the last metricsSpan, each with a sparkline.
+// They are fetched like container properties, and again when the account is
+// refreshed or an interval newer than the last point has ended.
+func (a *App) accountMetricItems(ref itemRef) []property {
+	key := "metrics:" + ref.Account
+	metrics, ok := a.accountMetrics[ref.Account]
+	switch {
+	case ok && !metricsStale(metrics):
+		items := []property{{"Capacity", "n/a"}, {"Transactions 24h", "n/a"}, {"Egress 24h", "n/a"}}
+		if points := metrics.UsedCapacity; len(points) > 0 {
+			items[0].Value = formatBytes(int64(points[len(points)-1].Value)) + "  " + sparkline(points)
+		}
+		if points := metrics.Transactions; len(points) > 0 {
+			items[1].Value = groupDigits(int64(sumPoints(points))) + "  " + sparkline(points)
+		}
+		if points := metrics.Egress; len(points) > 0 {
+			items[2].Value = formatBytes(int64(sumPoints(points))) + "  " + sparkline(points)
+		}
+		return items
+	case a.detailsErrs[key] != nil:
+		return []property{{"Metrics", fmt.Sprintf("error: %v", a.detailsErrs[key])}}
+	}
+	fetchDetailProperties(a, ref, key, func(ctx context.Context) (azure.AccountMetrics, error) {
+		return a.provider.GetAccountMetrics(ctx, ref.Account, metricsSpan, metricsInterval)
+	}, func(metrics azure.AccountMetrics) {
+		a.accountMetrics[ref.Account] = metrics
+	})
+	return []property{{"Metrics", "loading…"}}
+}
+
+// metricsStale reports whether a newer interval than the metrics' last point
+// has ended since they were fetched.
+func metricsStale(metrics azure.AccountMetrics) bool {
+	points := metrics.Transactions
+	if len(points) == 0 {
+		return false
+	}
+	return time.Since(points[len(points)-1].Time) >= 2*metricsInterval
+}
+
+func sumPoints(points []azure.MetricPoint) float64 {
+	total := 0.0
+	for _, point := range points {
+		total += point.Value
+	}
+	return total
+}
+
+// sparkline draws the points as bars scaled between their lowest and highest
+// values; points that are all the same draw as the lowest bar.
+func sparkline(points []azure.MetricPoint) string {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, point := range points {
+		low, high = min(low, point.Value), max(high, point.Value)
+	}
+	var builder strings.Builder
+	for _, point := range points {
+		bar := 0
+		if high > low {
+			bar = int((point.Value - low) / (high - low) * float64(len(sparkBars)-1))
+		}
+		builder.WriteRune(sparkBars[bar])
+	}
+	return builder.String()
+}
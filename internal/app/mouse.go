@@ -0,0 +1,120 @@
+package app
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// setupMouse keeps activePane in sync with focus changes made by mouse clicks
+// and keeps clicks from reaching the main page while a dialog is open. The
+// widgets handle row selection and wheel scrolling themselves.
+func (a *App) setupMouse(enabled bool) {
+	a.app.EnableMouse(enabled)
+	if !enabled {
+		return
+	}
+
+	for _, id := range []pane{paneAccounts, paneContents, paneCompare, panePreview} {
+		target := id
+		a.paneBox(id).SetFocusFunc(func() {
+			a.onPaneFocused(target)
+		})
+	}
+
+	a.app.SetMouseCapture(func(event *tcell.EventMouse, action tview.MouseAction) (*tcell.EventMouse, tview.MouseAction) {
+		modal := a.openModalBox()
+		if modal == nil {
+			return event, action
+		}
+		x, y := event.Position()
+		if !modal.InRect(x, y) {
+			return nil, action
+		}
+		return event, action
+	})
+}
+
+// onPaneFocused records a focus change that did not go through setActivePane.
+func (a *App) onPaneFocused(target pane) {
+	if a.modalOpen() || a.activePane == target {
+		return
+	}
+	a.activePane = target
+	a.refreshDetails()
+	a.updatePaneBorders()
+	a.refreshStatus()
+}
+
+func (a *App) openModalBox() *tview.Box {
+	switch {
+	case a.saveSearchOpen:
+		return a.saveSearchForm.Box
+	case a.searchOpen:
+		return a.searchForm.Box
+	case a.bookmarksOpen:
+		return a.bookmarksList.Box
+	case a.helpOpen:
+		return a.helpView.Box
+	case a.confirmOpen:
+		return a.confirmDialog.Box
+	case a.propertiesOpen:
+		return a.propertiesView.Box
+	case a.findOpen:
+		return a.findView.Box
+	case a.tagsOpen:
+		return a.tagsForm.Box
+	case a.filterOpen:
+		return a.filterForm.Box
+	case a.grepOpen:
+		return a.grepView.Box
+	case a.searchesOpen:
+		return a.searchesList.Box
+	case a.historyOpen:
+		return a.historyView.Box
+	case a.prefixOpen:
+		return a.prefixForm.Box
+	case a.pipeOpen:
+		return a.pipeForm.Box
+	case a.exportOpen:
+		return a.exportForm.Box
+	case a.saveOpen:
+		return a.saveForm.Box
+	case a.iacOpen:
+		return a.iacForm.Box
+	case a.profilesOpen:
+		return a.profilesList.Box
+	case a.logOpen:
+		return a.logView.Box
+	case a.statsOpen:
+		return a.statsView.Box
+	case a.lifecycleFormOpen:
+		return a.lifecycleForm.Box
+	case a.lifecycleOpen:
+		return a.lifecycleView.Box
+	case a.changeFeedOpen:
+		return a.changeFeedView.Box
+	case a.auditOpen:
+		return a.auditView.Box
+	case a.failuresOpen:
+		return a.failuresView.Box
+	case a.callsOpen:
+		return a.callsView.Box
+	case a.usageOpen:
+		return a.usageView.Box
+	case a.syncPlanOpen:
+		return a.syncPlanView.Box
+	case a.syncJobsOpen:
+		return a.syncJobsView.Box
+	case a.corsFormOpen:
+		return a.corsForm.Box
+	case a.corsOpen:
+		return a.corsView.Box
+	case a.wormFormOpen:
+		return a.wormForm.Box
+	case a.wormOpen:
+		return a.wormView.Box
+	case a.setupOpen:
+		return a.setupForm.Box
+	}
+	return nil
+}
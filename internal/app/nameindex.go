@@ -0,0 +1,85 @@
+package app
+
+import (
+	"slices"
+	"strings"
+)
+
+// nameIndex keeps the lowercase names of the listed blobs and the last
+// filter's matches, so filtering as the filter is typed stays quick over large
+// listings: names are lowered once, a filter is only run again over blobs
+// listed since, and typing more of a name searches only the previous matches.
+type nameIndex struct {
+	blobs []itemRef
+	lower []string
+	// last is the filter shown last, which let through shown, at shownAt in
+	// blobs, out of the first searched blobs.
+	last     *blobFilter
+	shown    []itemRef
+	shownAt  []int
+	searched int
+}
+
+// sync indexes blobs. Blobs appended to the indexed listing are added to it;
+// any other listing is indexed anew.
+func (x *nameIndex) sync(blobs []itemRef) {
+	if len(blobs) < len(x.lower) || len(blobs) > 0 && len(x.lower) > 0 && &blobs[0] != &x.blobs[0] {
+		x.lower = x.lower[:0]
+		x.last, x.shown, x.shownAt, x.searched = nil, nil, nil, 0
+	}
+	for _, ref := range blobs[len(x.lower):] {
+		x.lower = append(x.lower, strings.ToLower(ref.Name))
+	}
+	x.blobs = blobs
+}
+
+// filter returns the indexed blobs f lets through.
+func (x *nameIndex) filter(f *blobFilter) []itemRef {
+	if f != x.last {
+		candidates := x.shownAt
+		narrows := x.last != nil && f.narrows(x.last)
+		x.last, x.shown, x.shownAt = f, nil, nil
+		if narrows {
+			x.shownAt = make([]int, 0, len(candidates))
+			for _, i := range candidates {
+				x.match(i)
+			}
+		} else {
+			x.searched = 0
+		}
+	}
+	for i := x.searched; i < len(x.lower); i++ {
+		x.match(i)
+	}
+	x.searched = len(x.lower)
+
+	// The matches are copied out once they are all known, which is most of
+	// the work for a loose filter over a large listing.
+	if len(x.shownAt) == len(x.blobs) {
+		return x.blobs
+	}
+	x.shown = slices.Grow(x.shown, len(x.shownAt)-len(x.shown))
+	for _, i := range x.shownAt[len(x.shown):] {
+		x.shown = append(x.shown, x.blobs[i])
+	}
+	return x.shown
+}
+
+func (x *nameIndex) match(i int) {
+	if x.last.matchesLower(x.blobs[i], x.lower[i]) {
+		x.shownAt = append(x.shownAt, i)
+	}
+}
+
+// narrows reports whether f lets through only blobs that previous does: the
+// same filter with more of a plain name typed, or a name added to it.
+func (f *blobFilter) narrows(previous *blobFilter) bool {
+	if !f.after.Equal(previous.after) || !f.before.Equal(previous.before) || f.minSize != previous.minSize ||
+		f.maxSize != previous.maxSize || f.contentType != previous.contentType {
+		return false
+	}
+	if previous.name == nil {
+		return true
+	}
+	return previous.substring != "" && strings.Contains(f.substring, previous.substring)
+}
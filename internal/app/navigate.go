@@ -0,0 +1,167 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// location identifies a container, or a blob inside a container, somewhere in
+// the subscription tree. An empty SubscriptionID matches any subscription.
+type location struct {
+	SubscriptionID string
+	Account        string
+	Container      string
+	Blob           string
+}
+
+// StartLocation names where the app opens. The subscription is matched by ID
+// or name and may be left out; without a container the account is selected.
+// Prefix lists the container by that name prefix.
+type StartLocation struct {
+	Subscription string
+	Account      string
+	Container    string
+	Prefix       string
+}
+
+// openStartLocation reveals start in the loaded tree.
+func (a *App) openStartLocation(start StartLocation) {
+	loc := location{Account: start.Account, Container: start.Container}
+	if start.Subscription != "" {
+		for _, node := range a.root.GetChildren() {
+			ref, ok := node.GetReference().(itemRef)
+			if ok && ref.Kind == kindSubscription &&
+				(ref.SubscriptionID == start.Subscription || strings.EqualFold(ref.SubscriptionName, start.Subscription)) {
+				loc.SubscriptionID = ref.SubscriptionID
+			}
+		}
+		if loc.SubscriptionID == "" {
+			a.flashErr(fmt.Sprintf("Start: subscription %q not found.", start.Subscription))
+			return
+		}
+	}
+	a.revealLocation(loc, func(err error) {
+		if err != nil {
+			a.flashErr(fmt.Sprintf("Start: %v", err))
+			return
+		}
+		if start.Container == "" {
+			return
+		}
+		a.whenContentsLoaded(func(err error) {
+			if err == nil && start.Prefix != "" {
+				a.setListPrefix(start.Prefix)
+			}
+			// A dialog opened while the container loaded keeps the focus;
+			// closing it goes to the contents.
+			if a.modalOpen() {
+				a.activePane = paneContents
+				return
+			}
+			a.setActivePane(paneContents)
+		})
+	})
+}
+
+// revealLocation expands the tree down to the location's container, selects
+// it and, when a blob is given, selects that blob in the contents table.
+// Without a container only the account is selected.
+// Loading happens in the background; done runs once the location is shown or
+// could not be found.
+func (a *App) revealLocation(loc location, done func(error)) {
+	var candidates []*tview.TreeNode
+	for _, node := range a.root.GetChildren() {
+		ref, ok := node.GetReference().(itemRef)
+		if !ok || ref.Kind != kindSubscription {
+			continue
+		}
+		if loc.SubscriptionID != "" && ref.SubscriptionID != loc.SubscriptionID {
+			continue
+		}
+		candidates = append(candidates, node)
+	}
+
+	var try func(index int)
+	try = func(index int) {
+		if index >= len(candidates) {
+			done(fmt.Errorf("account %q not found", loc.Account))
+			return
+		}
+		node := candidates[index]
+		ref, _ := node.GetReference().(itemRef)
+		if !a.isSubscriptionEnabled(ref.SubscriptionID) {
+			a.subscriptionEnabled[ref.SubscriptionID] = true
+			node.SetText(a.subscriptionLabel(ref, true))
+		}
+		a.expandNode(node, false, func(err error) {
+			if err != nil {
+				try(index + 1)
+				return
+			}
+			accountNode := findChild(node, func(child itemRef) bool {
+				return child.Kind == kindAccount && child.Account == loc.Account
+			})
+			if accountNode == nil {
+				try(index + 1)
+				return
+			}
+			if loc.Container == "" {
+				a.accounts.SetCurrentNode(accountNode)
+				a.setActivePane(paneAccounts)
+				done(nil)
+				return
+			}
+			a.revealInAccount(accountNode, loc, done)
+		})
+	}
+	try(0)
+}
+
+func (a *App) revealInAccount(accountNode *tview.TreeNode, loc location, done func(error)) {
+	a.expandNode(accountNode, false, func(err error) {
+		if err != nil {
+			done(err)
+			return
+		}
+		containerNode := findChild(accountNode, func(ref itemRef) bool {
+			return ref.Kind == kindContainer && ref.Container == loc.Container
+		})
+		if containerNode == nil {
+			done(fmt.Errorf("container %q not found in %s", loc.Container, loc.Account))
+			return
+		}
+
+		a.accounts.SetCurrentNode(containerNode)
+		a.setActivePane(paneAccounts)
+		if loc.Blob == "" {
+			done(nil)
+			return
+		}
+		a.whenContentsLoaded(func(err error) {
+			if err != nil {
+				done(err)
+				return
+			}
+			a.listUntil(loc.Blob, func(found bool) {
+				if !found {
+					done(fmt.Errorf("blob %q not found in %s/%s", loc.Blob, loc.Account, loc.Container))
+					return
+				}
+				a.setActivePane(paneContents)
+				done(nil)
+			})
+		})
+	})
+}
+
+func findChild(node *tview.TreeNode, match func(itemRef) bool) *tview.TreeNode {
+	for _, child := range node.GetChildren() {
+		ref, ok := child.GetReference().(itemRef)
+		if ok && match(ref) {
+			return child
+		}
+	}
+	return nil
+}
@@ -0,0 +1,333 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"storage-tui/internal/azure"
+)
+
+const (
+	// blobPageSize is how many blobs one contents page holds.
+	blobPageSize = 500
+	// prefetchRows is how close to the last loaded row the selection gets
+	// before the next page is fetched in the background.
+	prefetchRows = 50
+)
+
+// prefetchedPage is the next page of the listing, fetched ahead of the
+// selection reaching it.
+type prefetchedPage struct {
+	seq    int
+	marker string
+	page   azure.BlobPage
+	ready  bool
+	// wanted is set when "Load more" was chosen while the page was still on
+	// its way, so it is appended as soon as it arrives.
+	wanted bool
+}
+
+func blobRef(container itemRef, blob azure.Blob) itemRef {
+	return itemRef{
+		Kind:             kindBlob,
+		Name:             blob.Name,
+		SubscriptionID:   container.SubscriptionID,
+		SubscriptionName: container.SubscriptionName,
+		Account:          container.Account,
+		Container:        container.Container,
+		SizeBytes:        blob.SizeBytes,
+		Modified:         blob.Modified,
+		ContentType:      blob.ContentType,
+		ETag:             blob.ETag,
+	}
+}
+
+// addLoadMoreRow appends the "Load more" row while the listing has more pages
+// and its first rows are no longer streaming in.
+func (a *App) addLoadMoreRow(container itemRef) {
+	if a.contentsMarker == "" || a.contentsLoading {
+		return
+	}
+	ref := itemRef{
+		Kind:             kindLoadMore,
+		Name:             fmt.Sprintf("Load more (%d shown)", a.loadedBlobCount()),
+		SubscriptionID:   container.SubscriptionID,
+		SubscriptionName: container.SubscriptionName,
+		Account:          container.Account,
+		Container:        container.Container,
+	}
+	a.addContentRow(ref, ref.Name, "")
+}
+
+func (a *App) loadedBlobCount() int {
+	return len(a.listedBlobs)
+}
+
+// loadMoreBlobs fetches the next page of the listed container.
+func (a *App) loadMoreBlobs() {
+	a.fetchRemainingBlobs(blobPageSize)
+}
+
+// confirmLoadAllBlobs warns before enumerating the rest of the container.
+func (a *App) confirmLoadAllBlobs() {
+	if a.contentsMarker == "" {
+		a.flash("All blobs are already loaded.")
+		return
+	}
+	container := a.contentSource
+	message := fmt.Sprintf("%d blobs of %s/%s are loaded. Loading the rest enumerates the whole container, which can take a long time and use a lot of memory for large containers.",
+		a.loadedBlobCount(), container.Account, container.Container)
+	a.confirm(confirmation{
+		Title:   "Load all blobs",
+		Message: message,
+		Action:  "Load all",
+		OnConfirm: func() {
+			a.enumerateBlobs(blobPageSize)
+		},
+	})
+}
+
+// fetchRemainingBlobs appends the next page to the contents table.
+func (a *App) fetchRemainingBlobs(pageSize int) {
+	if a.contentsMarker == "" || a.contentsPaging {
+		return
+	}
+	if a.takePrefetchedPage() {
+		a.flash(fmt.Sprintf("%d blobs loaded.", a.loadedBlobCount()))
+		return
+	}
+	if p := a.currentPrefetch(); p != nil && pageSize == blobPageSize {
+		p.wanted = true
+		return
+	}
+	container := a.contentSource
+	prefix := a.listPrefix
+	marker := a.contentsMarker
+	seq := a.contentsSeq
+	a.contentsPaging = true
+
+	runAsyncWith(a, a.contentsCtx, paneContents, func(ctx context.Context) (azure.BlobPage, error) {
+		return a.provider.ListBlobsPage(ctx, container.Account, container.Container, azure.ListBlobsOptions{
+			Marker:     marker,
+			MaxResults: pageSize,
+			Prefix:     prefix,
+		})
+	}, func(page azure.BlobPage, err error) {
+		if seq != a.contentsSeq {
+			return
+		}
+		a.contentsPaging = false
+		if err != nil {
+			a.flashErr(fmt.Sprintf("Error loading more blobs: %v", err))
+			return
+		}
+		a.appendBlobs(container, page)
+		a.flash(fmt.Sprintf("%d blobs loaded.", a.loadedBlobCount()))
+	})
+}
+
+// prefetchNear fetches the next page in the background once the selection
+// is within prefetchRows of the last row, so scrolling on does not wait for
+// it. Failures are left for "Load more" to report.
+func (a *App) prefetchNear(row int) {
+	if a.contentsMarker == "" || a.contentsPaging || a.contentsLoading || a.contentSource.Kind != kindContainer ||
+		row < len(a.contentRefs)-prefetchRows || a.currentPrefetch() != nil {
+		return
+	}
+	container := a.contentSource
+	prefix := a.listPrefix
+	p := &prefetchedPage{seq: a.contentsSeq, marker: a.contentsMarker}
+	a.prefetch = p
+	ctx := a.contentsCtx
+
+	go func() {
+		page, err := a.provider.ListBlobsPage(ctx, container.Account, container.Container, azure.ListBlobsOptions{
+			Marker:     p.marker,
+			MaxResults: blobPageSize,
+			Prefix:     prefix,
+		})
+		a.app.QueueUpdateDraw(func() {
+			if a.prefetch != p {
+				return
+			}
+			if err != nil {
+				a.prefetch = nil
+				if p.wanted {
+					a.fetchRemainingBlobs(blobPageSize)
+				}
+				return
+			}
+			p.page, p.ready = page, true
+			if p.wanted && a.takePrefetchedPage() {
+				a.flash(fmt.Sprintf("%d blobs loaded.", a.loadedBlobCount()))
+			}
+		})
+	}()
+}
+
+// currentPrefetch returns the prefetch of the listing's next page, ready or
+// on its way.
+func (a *App) currentPrefetch() *prefetchedPage {
+	p := a.prefetch
+	if p == nil || p.seq != a.contentsSeq || p.marker != a.contentsMarker {
+		return nil
+	}
+	return p
+}
+
+// takePrefetchedPage appends the prefetched page when it has arrived. The
+// selection keeps its row, which moves it from "Load more" onto the first
+// blob of the page.
+func (a *App) takePrefetchedPage() bool {
+	p := a.currentPrefetch()
+	if p == nil || !p.ready || a.contentsPaging {
+		return false
+	}
+	a.prefetch = nil
+	a.appendBlobs(a.contentSource, p.page)
+	return true
+}
+
+// listUntil selects the named blob, first loading further pages of the
+// listed container until it shows up or the listing ends. Listings are sorted
+// by name, so paging stops early once the names go past it. A listing prefix
+// the blob does not start with is dropped first.
+func (a *App) listUntil(name string, done func(found bool)) {
+	if !strings.HasPrefix(name, a.listPrefix) {
+		a.setListPrefix("")
+		a.flash("Prefix cleared.")
+		a.whenContentsLoaded(func(err error) {
+			if err != nil {
+				done(false)
+				return
+			}
+			a.listUntil(name, done)
+		})
+		return
+	}
+	if a.selectBlob(name) {
+		done(true)
+		return
+	}
+	last := len(a.listedBlobs) - 1
+	if a.contentsMarker == "" || a.contentsPaging || (last >= 0 && a.listedBlobs[last].Name > name) {
+		done(false)
+		return
+	}
+	container := a.contentSource
+	prefix := a.listPrefix
+	marker := a.contentsMarker
+	seq := a.contentsSeq
+	a.contentsPaging = true
+
+	runAsyncWith(a, a.contentsCtx, paneContents, func(ctx context.Context) (azure.BlobPage, error) {
+		return a.provider.ListBlobsPage(ctx, container.Account, container.Container, azure.ListBlobsOptions{
+			Marker:     marker,
+			MaxResults: blobPageSize,
+			Prefix:     prefix,
+		})
+	}, func(page azure.BlobPage, err error) {
+		if seq != a.contentsSeq {
+			done(false)
+			return
+		}
+		a.contentsPaging = false
+		if err != nil {
+			a.flashErr(fmt.Sprintf("Error loading more blobs: %v", err))
+			done(false)
+			return
+		}
+		a.appendBlobs(container, page)
+		a.listUntil(name, done)
+	})
+}
+
+// enumerateBlobs lists every remaining page, appending each one as it arrives
+// and counting progress in the contents title. Canceling keeps the pages
+// listed so far.
+func (a *App) enumerateBlobs(pageSize int) {
+	if a.contentsMarker == "" || a.contentsPaging {
+		return
+	}
+	container := a.contentSource
+	prefix := a.listPrefix
+	marker := a.contentsMarker
+	seq := a.contentsSeq
+	ctx, cancel := context.WithCancel(a.contentsCtx)
+	a.contentsPaging = true
+	a.enumCancel = cancel
+	a.showEnumerationProgress(container)
+	a.startLoading(paneContents)
+
+	go func() {
+		defer cancel()
+		var err error
+		for marker != "" {
+			var page azure.BlobPage
+			page, err = a.provider.ListBlobsPage(ctx, container.Account, container.Container, azure.ListBlobsOptions{
+				Marker:     marker,
+				MaxResults: pageSize,
+				Prefix:     prefix,
+			})
+			if err == nil {
+				err = ctx.Err()
+			}
+			if err != nil {
+				break
+			}
+			marker = page.NextMarker
+			a.queueUpdate(func() {
+				if seq != a.contentsSeq {
+					return
+				}
+				a.appendBlobs(container, page)
+				a.showEnumerationProgress(container)
+			})
+		}
+
+		a.app.QueueUpdateDraw(func() {
+			a.stopLoading(paneContents)
+			if seq != a.contentsSeq {
+				return
+			}
+			a.contentsPaging = false
+			a.enumCancel = nil
+			a.setPaneTitle(paneContents, a.contentsTitle())
+			switch {
+			case errors.Is(err, context.Canceled):
+				a.flash(fmt.Sprintf("Listing canceled after %s blobs.", groupDigits(int64(a.loadedBlobCount()))))
+			case err != nil:
+				a.flashErr(fmt.Sprintf("Error loading more blobs: %v", err))
+			default:
+				a.flash(fmt.Sprintf("Listed all %s blobs.", groupDigits(int64(a.loadedBlobCount()))))
+			}
+		})
+	}()
+}
+
+func (a *App) showEnumerationProgress(container itemRef) {
+	a.setPaneTitle(paneContents, fmt.Sprintf("%s  Listed %s blobs…  esc: cancel",
+		a.contentsTitle(), groupDigits(int64(a.loadedBlobCount()))))
+}
+
+// cancelEnumeration stops a running load-all listing, or the first pages of
+// a listing still streaming in.
+func (a *App) cancelEnumeration() bool {
+	if a.enumCancel == nil {
+		return false
+	}
+	a.enumCancel()
+	return true
+}
+
+// appendBlobs adds the page's blobs to the listing, keeping the current
+// selection.
+func (a *App) appendBlobs(container itemRef, page azure.BlobPage) {
+	a.contentsMarker = page.NextMarker
+	for _, blob := range page.Blobs {
+		a.listedBlobs = append(a.listedBlobs, blobRef(container, blob))
+	}
+	a.renderContents(true)
+}
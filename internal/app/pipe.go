@@ -0,0 +1,158 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// pipeOutputBytes caps the command output the preview keeps; the command
+// still runs to the end.
+const pipeOutputBytes = 1 << 20
+
+func (a *App) setupPipeModal() {
+	input := tview.NewInputField().
+		SetLabel("Command: | ").
+		SetFieldWidth(0).
+		SetPlaceholder("jq '.items | length'   or   grep ERROR | wc -l")
+	form := tview.NewForm().
+		AddFormItem(input).
+		AddButton("Run", func() {
+			a.applyPipe(input.GetText())
+		}).
+		AddButton("Cancel", a.closePipeModal)
+	form.SetBorder(true)
+	form.SetButtonsAlign(tview.AlignRight)
+
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEnter:
+			a.applyPipe(input.GetText())
+			return nil
+		case tcell.KeyEsc:
+			a.closePipeModal()
+			return nil
+		}
+		return event
+	})
+	form.SetCancelFunc(a.closePipeModal)
+
+	a.pipeInput = input
+	a.pipeForm = form
+	a.pages.AddPage("pipe", centerModal(form, 7, 90), true, false)
+}
+
+// openPipeModal asks for the shell pipeline the selected blob's content is
+// streamed through, starting from the last one run.
+func (a *App) openPipeModal() {
+	ref, ok := a.selectedRef()
+	if !ok || (ref.Kind != kindBlob && ref.Kind != kindEntry) {
+		a.flashErr("Select a blob to pipe its content to a command.")
+		return
+	}
+	a.pipeRef = ref
+	a.pipeOpen = true
+	a.pipeForm.SetTitle(fmt.Sprintf("Pipe %s/%s/%s to", ref.Account, ref.Container, contentName(ref)))
+	a.pipeInput.SetText(a.pipeCommand)
+	a.pages.ShowPage("pipe")
+	a.app.SetFocus(a.pipeInput)
+}
+
+func (a *App) closePipeModal() {
+	a.pages.HidePage("pipe")
+	a.pipeOpen = false
+	a.setActivePane(a.activePane)
+}
+
+func (a *App) applyPipe(command string) {
+	command = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(command), "|"))
+	if command == "" {
+		a.flashErr("Type the command to pipe the blob to.")
+		return
+	}
+	a.closePipeModal()
+	a.pipeCommand = command
+	a.pipeBlob(a.pipeRef, command)
+}
+
+// pipeBlob streams the whole blob to command on the shell and shows what the
+// command prints, its errors included, in the preview. Previewing another
+// blob cancels the command.
+func (a *App) pipeBlob(ref itemRef, command string) {
+	header := fmt.Sprintf("File: %s\n$ %s\n\n", contentName(ref), command)
+	a.setPreviewContent(header+"Running…", false)
+	open := a.contentOpener(ref)
+	ctx, cancel := context.WithCancel(context.Background())
+	a.previewCancel = cancel
+	seq := a.previewSeq
+
+	runAsyncWith(a, ctx, panePreview, func(ctx context.Context) (string, error) {
+		return a.runPipe(ctx, open, command)
+	}, func(output string, err error) {
+		if seq != a.previewSeq {
+			return
+		}
+		var exit *exec.ExitError
+		switch {
+		case errors.As(err, &exit):
+			output += fmt.Sprintf("\n[%s]", exit)
+		case err != nil:
+			a.recordFailure("pipe", refTarget(ref), err, func() { a.pipeBlob(ref, command) })
+			a.setPreviewContent(header+fmt.Sprintf("Error piping the blob: %v", err), false)
+			return
+		}
+		if output == "" {
+			output = "(no output)"
+		}
+		a.setPreviewContent(header+output, true)
+	})
+}
+
+// runPipe runs command with the blob on its standard input and returns its
+// output, both streams interleaved, up to pipeOutputBytes.
+func (a *App) runPipe(ctx context.Context, open func(context.Context) (io.ReadCloser, error), command string) (string, error) {
+	reader, err := open(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	shell := []string{"sh", "-c", command}
+	if runtime.GOOS == "windows" {
+		shell = []string{"cmd", "/C", command}
+	}
+	cmd := exec.CommandContext(ctx, shell[0], shell[1:]...)
+	output := &cappedBuffer{limit: pipeOutputBytes}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = reader, output, output
+	err = cmd.Run()
+	text := string(bytes.ToValidUTF8(output.Bytes(), []byte("�")))
+	if output.cut {
+		text += fmt.Sprintf("\n… (first %s of the output)", a.formatSize(pipeOutputBytes))
+	}
+	return text, err
+}
+
+// cappedBuffer keeps the first limit bytes written to it and drops the rest,
+// while still accepting them so the writer is not cut off.
+type cappedBuffer struct {
+	bytes.Buffer
+	limit int
+	cut   bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); len(p) > room {
+		b.cut = true
+		b.Buffer.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+func (a *App) setupPrefixModal() {
+	input := tview.NewInputField().
+		SetLabel("Name prefix: ").
+		SetFieldWidth(0).
+		SetPlaceholder("logs/2024-05/ (empty lists every blob)")
+	form := tview.NewForm().
+		AddFormItem(input).
+		AddButton("List", func() {
+			a.applyListPrefix(input.GetText())
+		}).
+		AddButton("Cancel", a.closePrefixModal)
+	form.SetBorder(true)
+	form.SetButtonsAlign(tview.AlignRight)
+
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEnter:
+			a.applyListPrefix(input.GetText())
+			return nil
+		case tcell.KeyEsc:
+			a.closePrefixModal()
+			return nil
+		}
+		return event
+	})
+	form.SetCancelFunc(a.closePrefixModal)
+
+	a.prefixInput = input
+	a.prefixForm = form
+	a.pages.AddPage("prefix", centerModal(form, 7, 76), true, false)
+}
+
+// openPrefixModal asks for the name prefix the listed container is listed
+// by. Unlike the contents filter, the prefix goes to the service, so only
+// matching blobs are enumerated.
+func (a *App) openPrefixModal() {
+	if a.contentSource.Kind != kindContainer {
+		a.flashErr("List a container to list its blobs by prefix.")
+		return
+	}
+	a.prefixOpen = true
+	a.prefixForm.SetTitle(fmt.Sprintf("List %s/%s by prefix", a.contentSource.Account, a.contentSource.Container))
+	a.prefixInput.SetText(a.listPrefix)
+	a.pages.ShowPage("prefix")
+	a.app.SetFocus(a.prefixInput)
+}
+
+func (a *App) closePrefixModal() {
+	a.pages.HidePage("prefix")
+	a.prefixOpen = false
+	a.setActivePane(paneContents)
+}
+
+func (a *App) applyListPrefix(prefix string) {
+	a.closePrefixModal()
+	a.setListPrefix(strings.TrimLeft(prefix, "/"))
+}
+
+// setListPrefix lists the container again, starting from the first page of
+// the blobs whose names start with prefix. An empty prefix lists every blob.
+func (a *App) setListPrefix(prefix string) {
+	if prefix == a.listPrefix {
+		return
+	}
+	a.listPrefix = prefix
+	if a.folderView && !strings.HasPrefix(a.folderPrefix, prefix) && !strings.HasPrefix(prefix, a.folderPrefix) {
+		a.folderPrefix = ""
+	}
+	a.showBlobs(a.contentSource)
+}
+
+// listedScopePrefix is the name prefix that narrows what the contents show:
+// the open folder in folder view or the listing prefix, whichever is longer.
+func (a *App) listedScopePrefix() string {
+	if a.folderView && len(a.folderPrefix) > len(a.listPrefix) {
+		return a.folderPrefix
+	}
+	return a.listPrefix
+}
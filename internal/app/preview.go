@@ -0,0 +1,180 @@
+package app
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	// previewDelay is how long the selection has to rest on a blob before
+	// its content is fetched, so arrowing through a listing does not read
+	// every blob passed.
+	previewDelay = 150 * time.Millisecond
+	// previewBytes is how much of a blob the preview reads.
+	previewBytes = 64 << 10
+	// defaultPreviewMemory is how much previewed content is kept when
+	// Options.PreviewMemory is empty.
+	defaultPreviewMemory = 16 << 20
+)
+
+// previewBlob shows the blob's header at once and its content once the
+// selection settles, unless it is cached in memory or on disk. Moving on, or
+// any other preview, cancels the fetch.
+func (a *App) previewBlob(ref itemRef) {
+	header := fmt.Sprintf("File: %s\nContent-Type: %s\nSize: %s\nModified: %s\n\n", contentName(ref), ref.ContentType, a.formatSize(ref.SizeBytes), a.formatTimestamp(ref.Modified))
+	key := previewKey(ref)
+	text, ok := a.previewCache.get(key)
+	a.metrics.ObserveCache("previews in memory", ok)
+	if ok {
+		a.setPreviewContent(header+text, true)
+		return
+	}
+	if text, ok := a.loadCachedPreview(key); ok {
+		a.previewCache.add(key, text)
+		a.setPreviewContent(header+text, true)
+		return
+	}
+	a.setPreviewContent(header+"Loading preview…", false)
+	open := a.contentOpener(ref)
+	ctx, cancel := context.WithCancel(context.Background())
+	a.previewCancel = cancel
+	seq := a.previewSeq
+
+	time.AfterFunc(previewDelay, func() {
+		if ctx.Err() != nil {
+			return
+		}
+		a.app.QueueUpdateDraw(func() {
+			if seq != a.previewSeq {
+				return
+			}
+			runAsyncWith(a, ctx, panePreview, func(ctx context.Context) (string, error) {
+				text, err := a.readPreview(ctx, ref, open)
+				if err == nil {
+					a.storeCachedPreview(key, text)
+				}
+				return text, err
+			}, func(text string, err error) {
+				if seq != a.previewSeq {
+					return
+				}
+				if err != nil {
+					a.recordFailure("preview", refTarget(ref), err, func() { a.previewBlob(ref) })
+					a.setPreviewContent(header+fmt.Sprintf("Error loading preview: %v", err), false)
+					return
+				}
+				a.previewCache.add(key, text)
+				a.setPreviewContent(header+text, true)
+			})
+		})
+	})
+}
+
+// readPreview reads the start of the blob or archive file. Content with NUL
+// bytes near the start is not shown, as in grep.
+func (a *App) readPreview(ctx context.Context, ref itemRef, open func(context.Context) (io.ReadCloser, error)) (string, error) {
+	reader, err := open(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	// A small memory budget reads less, so the preview still fits in it.
+	limit := min(previewBytes, a.previewCache.budget)
+	head, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return "", err
+	}
+	if bytes.IndexByte(head[:min(len(head), 512)], 0) >= 0 {
+		return "Binary content preview not available.", nil
+	}
+	if int64(len(head)) <= limit {
+		return string(bytes.ToValidUTF8(head, []byte("�"))), nil
+	}
+	text := string(bytes.ToValidUTF8(head[:limit], []byte("�")))
+	return text + fmt.Sprintf("\n… (first %s of %s)", a.formatSize(limit), a.formatSize(ref.SizeBytes)), nil
+}
+
+// stopPreviewFetch drops the content fetch of the previous preview.
+func (a *App) stopPreviewFetch() {
+	a.previewSeq++
+	if a.previewCancel != nil {
+		a.previewCancel()
+		a.previewCancel = nil
+	}
+}
+
+// parsePreviewMemory reads Options.PreviewMemory, falling back to the default
+// when it is empty or invalid.
+func parsePreviewMemory(value string) (int64, error) {
+	if value == "" {
+		return defaultPreviewMemory, nil
+	}
+	budget, err := parseSize(value)
+	if err == nil && budget == 0 {
+		err = errors.New("the budget must be more than 0 bytes")
+	}
+	if err != nil {
+		return defaultPreviewMemory, err
+	}
+	return budget, nil
+}
+
+// previewKey names a blob's content as listed; a blob that changed since
+// misses the cache. A refreshed listing with the same ETag keeps the cached
+// preview, so the blob is not read again.
+func previewKey(ref itemRef) string {
+	return fmt.Sprintf("%s/%s/%s\x00%s", ref.Account, ref.Container, contentName(ref), blobVersion(ref))
+}
+
+// previewCache keeps the content of recent previews, dropping the least
+// recently shown once their total size passes budget.
+type previewCache struct {
+	budget int64
+	used   int64
+	order  *list.List
+	items  map[string]*list.Element
+}
+
+type previewEntry struct {
+	key  string
+	text string
+}
+
+func newPreviewCache(budget int64) *previewCache {
+	return &previewCache{budget: budget, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *previewCache) get(key string) (string, bool) {
+	element, ok := c.items[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*previewEntry).text, true
+}
+
+func (c *previewCache) add(key, text string) {
+	if element, ok := c.items[key]; ok {
+		c.remove(element)
+	}
+	if int64(len(text)) > c.budget {
+		return
+	}
+	c.items[key] = c.order.PushFront(&previewEntry{key: key, text: text})
+	c.used += int64(len(text))
+	for c.used > c.budget {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *previewCache) remove(element *list.Element) {
+	entry := c.order.Remove(element).(*previewEntry)
+	delete(c.items, entry.key)
+	c.used -= int64(len(entry.text))
+}
@@ -0,0 +1,541 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"storage-tui/internal/azure"
+	"storage-tui/internal/cache"
+	"storage-tui/internal/logging"
+	"storage-tui/internal/metrics"
+)
+
+// Profile is a named environment: how to sign in and which subscriptions to
+// show.
+type Profile struct {
+	Name        string
+	Tenant      string
+	Cloud       string
+	Credentials string
+	// Subscriptions lists the subscription IDs or names to show; empty shows
+	// every subscription the credentials can see.
+	Subscriptions []string
+	Network       azure.Network
+}
+
+// showsSubscription reports whether the profile's subscription filter lets
+// subscription through.
+func (p Profile) showsSubscription(subscription azure.Subscription) bool {
+	if len(p.Subscriptions) == 0 {
+		return true
+	}
+	for _, name := range p.Subscriptions {
+		if name == subscription.ID || strings.EqualFold(name, subscription.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// switchableProvider forwards to the provider of the active profile. The
+// provider is swapped on the UI goroutine while listings may still be running
+// in the background, so access is guarded.
+type switchableProvider struct {
+	mu      sync.RWMutex
+	current azure.Provider
+	// log records each call at debug level, and failed calls as warnings;
+	// metrics counts them and their latencies.
+	log     *logging.Log
+	metrics *metrics.Registry
+	// arm and blob space out Resource Manager and storage calls; onThrottle
+	// hears of each throttled call before it is retried.
+	arm        *limiter
+	blob       *limiter
+	onThrottle func(delay time.Duration)
+	// retryWait waits out the delay before a throttled call is retried;
+	// nil sleeps, and tests keep the delays without waiting.
+	retryWait func(ctx context.Context, delay time.Duration) error
+	// disk keeps listings and properties for the profile, and answers for
+	// them when a call fails; onOffline hears of each such answer.
+	disk      *cache.Cache
+	profile   string
+	onOffline func(saved time.Time)
+	// calls keeps the latest calls for the calls page.
+	calls *callLog
+}
+
+func (s *switchableProvider) get() azure.Provider {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+func (s *switchableProvider) set(provider azure.Provider, profile string) {
+	s.mu.Lock()
+	s.current = provider
+	s.profile = profile
+	s.mu.Unlock()
+}
+
+// cacheScope keeps the disk cache entries of each profile apart.
+func (s *switchableProvider) cacheScope() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.profile
+}
+
+// logCall records a provider call that started at start, in the log, the
+// metrics, and the calls page, returning its number there. Canceled calls
+// are not failures.
+func (s *switchableProvider) logCall(call string, start time.Time, err error, args ...any) int64 {
+	duration := time.Since(start)
+	seq := s.calls.add(newProviderCall(call, start, duration, err, args))
+	if errors.Is(err, context.Canceled) {
+		s.metrics.ObserveCall(call, duration, nil)
+	} else {
+		s.metrics.ObserveCall(call, duration, err)
+	}
+	if s.log == nil {
+		return seq
+	}
+	args = append(args, "duration", duration.Round(time.Millisecond))
+	if err != nil && !errors.Is(err, context.Canceled) {
+		s.log.Warn(call+" failed", append(args, "error", err)...)
+		return seq
+	}
+	s.log.Debug(call, args...)
+	return seq
+}
+
+// countRead has the calls page show how much of the blob the call numbered
+// seq read, once reader is closed.
+func (s *switchableProvider) countRead(reader io.ReadCloser, seq int64) io.ReadCloser {
+	if reader == nil {
+		return nil
+	}
+	return &countingReader{ReadCloser: reader, closed: func(bytes int64) {
+		s.calls.setBytes(seq, bytes)
+	}}
+}
+
+func (s *switchableProvider) ListSubscriptions(ctx context.Context) ([]azure.Subscription, error) {
+	start := time.Now()
+	subscriptions, err := cached(ctx, s, "subscriptions", "", func() ([]azure.Subscription, error) {
+		return limited(ctx, s, s.arm, "list subscriptions", func() ([]azure.Subscription, error) {
+			return s.get().ListSubscriptions(ctx)
+		})
+	})
+	s.logCall("list subscriptions", start, err, "count", len(subscriptions))
+	return subscriptions, err
+}
+
+func (s *switchableProvider) ListAccounts(ctx context.Context, subscriptionID string) ([]azure.Account, error) {
+	start := time.Now()
+	accounts, err := cached(ctx, s, "accounts", subscriptionID, func() ([]azure.Account, error) {
+		return limited(ctx, s, s.arm, "list accounts", func() ([]azure.Account, error) {
+			return s.get().ListAccounts(ctx, subscriptionID)
+		})
+	})
+	s.logCall("list accounts", start, err, "subscription", subscriptionID, "count", len(accounts))
+	return accounts, err
+}
+
+func (s *switchableProvider) ListContainers(ctx context.Context, account string) ([]azure.Container, error) {
+	start := time.Now()
+	containers, err := cached(ctx, s, "containers", account, func() ([]azure.Container, error) {
+		return limited(ctx, s, s.blob, "list containers", func() ([]azure.Container, error) {
+			return s.get().ListContainers(ctx, account)
+		})
+	})
+	s.logCall("list containers", start, err, "account", account, "count", len(containers))
+	return containers, err
+}
+
+func (s *switchableProvider) ListBlobs(ctx context.Context, account, container string) ([]azure.Blob, error) {
+	start := time.Now()
+	blobs, err := limited(ctx, s, s.blob, "list blobs", func() ([]azure.Blob, error) {
+		return s.get().ListBlobs(ctx, account, container)
+	})
+	s.logCall("list blobs", start, err, "account", account, "container", container, "count", len(blobs))
+	return blobs, err
+}
+
+func (s *switchableProvider) ListBlobsPage(ctx context.Context, account, container string, opts azure.ListBlobsOptions) (azure.BlobPage, error) {
+	start := time.Now()
+	page, err := limited(ctx, s, s.blob, "list blobs page", func() (azure.BlobPage, error) {
+		return s.get().ListBlobsPage(ctx, account, container, opts)
+	})
+	s.logCall("list blobs page", start, err, "account", account, "container", container, "prefix", opts.Prefix, "marker", opts.Marker, "count", len(page.Blobs))
+	return page, err
+}
+
+func (s *switchableProvider) GetContainerProperties(ctx context.Context, account, container string) (azure.ContainerProperties, error) {
+	start := time.Now()
+	props, err := cached(ctx, s, "container-properties", account+"/"+container, func() (azure.ContainerProperties, error) {
+		return limited(ctx, s, s.blob, "get container properties", func() (azure.ContainerProperties, error) {
+			return s.get().GetContainerProperties(ctx, account, container)
+		})
+	})
+	s.logCall("get container properties", start, err, "account", account, "container", container)
+	return props, err
+}
+
+func (s *switchableProvider) GetContainerImmutability(ctx context.Context, account, container string) (azure.ContainerImmutability, error) {
+	start := time.Now()
+	immutability, err := cached(ctx, s, "container-immutability", account+"/"+container, func() (azure.ContainerImmutability, error) {
+		return limited(ctx, s, s.arm, "get container immutability", func() (azure.ContainerImmutability, error) {
+			return s.get().GetContainerImmutability(ctx, account, container)
+		})
+	})
+	s.logCall("get container immutability", start, err, "account", account, "container", container)
+	return immutability, err
+}
+
+func (s *switchableProvider) SetImmutabilityPolicy(ctx context.Context, account, container string, days int, allowAppend bool) error {
+	editor, ok := s.get().(azure.ImmutabilityEditor)
+	if !ok {
+		return errors.New("this provider cannot change immutability policies")
+	}
+	start := time.Now()
+	_, err := limited(ctx, s, s.arm, "set immutability policy", func() (struct{}, error) {
+		return struct{}{}, editor.SetImmutabilityPolicy(ctx, account, container, days, allowAppend)
+	})
+	s.logCall("set immutability policy", start, err, "account", account, "container", container, "days", days, "allow_append", allowAppend)
+	return err
+}
+
+func (s *switchableProvider) LockImmutabilityPolicy(ctx context.Context, account, container string) error {
+	editor, ok := s.get().(azure.ImmutabilityEditor)
+	if !ok {
+		return errors.New("this provider cannot change immutability policies")
+	}
+	start := time.Now()
+	_, err := limited(ctx, s, s.arm, "lock immutability policy", func() (struct{}, error) {
+		return struct{}{}, editor.LockImmutabilityPolicy(ctx, account, container)
+	})
+	s.logCall("lock immutability policy", start, err, "account", account, "container", container)
+	return err
+}
+
+func (s *switchableProvider) GetBlobProperties(ctx context.Context, account, container, blob string) (azure.BlobProperties, error) {
+	start := time.Now()
+	props, err := cached(ctx, s, "blob-properties", account+"/"+container+"/"+blob, func() (azure.BlobProperties, error) {
+		return limited(ctx, s, s.blob, "get blob properties", func() (azure.BlobProperties, error) {
+			return s.get().GetBlobProperties(ctx, account, container, blob)
+		})
+	})
+	s.logCall("get blob properties", start, err, "account", account, "container", container, "blob", blob)
+	return props, err
+}
+
+func (s *switchableProvider) GetBlobServiceProperties(ctx context.Context, account string) (azure.BlobServiceProperties, error) {
+	start := time.Now()
+	props, err := cached(ctx, s, "blob-service-properties", account, func() (azure.BlobServiceProperties, error) {
+		return limited(ctx, s, s.arm, "get blob service properties", func() (azure.BlobServiceProperties, error) {
+			return s.get().GetBlobServiceProperties(ctx, account)
+		})
+	})
+	s.logCall("get blob service properties", start, err, "account", account)
+	return props, err
+}
+
+func (s *switchableProvider) SetCORSRules(ctx context.Context, account string, rules []azure.CORSRule) error {
+	editor, ok := s.get().(azure.CORSEditor)
+	if !ok {
+		return errors.New("this provider cannot change CORS rules")
+	}
+	start := time.Now()
+	_, err := limited(ctx, s, s.arm, "set cors rules", func() (struct{}, error) {
+		return struct{}{}, editor.SetCORSRules(ctx, account, rules)
+	})
+	s.logCall("set cors rules", start, err, "account", account, "rules", len(rules))
+	return err
+}
+
+func (s *switchableProvider) GetLifecyclePolicy(ctx context.Context, account string) (azure.LifecyclePolicy, error) {
+	start := time.Now()
+	policy, err := cached(ctx, s, "lifecycle-policies", account, func() (azure.LifecyclePolicy, error) {
+		return limited(ctx, s, s.arm, "get lifecycle policy", func() (azure.LifecyclePolicy, error) {
+			return s.get().GetLifecyclePolicy(ctx, account)
+		})
+	})
+	s.logCall("get lifecycle policy", start, err, "account", account, "rules", len(policy.Rules))
+	return policy, err
+}
+
+func (s *switchableProvider) SetLifecyclePolicy(ctx context.Context, account string, rules []azure.LifecycleRule) error {
+	editor, ok := s.get().(azure.LifecycleEditor)
+	if !ok {
+		return errors.New("this provider cannot change lifecycle policies")
+	}
+	start := time.Now()
+	_, err := limited(ctx, s, s.arm, "set lifecycle policy", func() (struct{}, error) {
+		return struct{}{}, editor.SetLifecyclePolicy(ctx, account, rules)
+	})
+	s.logCall("set lifecycle policy", start, err, "account", account, "rules", len(rules))
+	return err
+}
+
+func (s *switchableProvider) GetNetworkRules(ctx context.Context, account string) (azure.NetworkRules, error) {
+	start := time.Now()
+	rules, err := cached(ctx, s, "network-rules", account, func() (azure.NetworkRules, error) {
+		return limited(ctx, s, s.arm, "get network rules", func() (azure.NetworkRules, error) {
+			return s.get().GetNetworkRules(ctx, account)
+		})
+	})
+	s.logCall("get network rules", start, err, "account", account)
+	return rules, err
+}
+
+func (s *switchableProvider) ListRoleAssignments(ctx context.Context, account, container string) ([]azure.RoleAssignment, error) {
+	start := time.Now()
+	assignments, err := cached(ctx, s, "role-assignments", account+"/"+container, func() ([]azure.RoleAssignment, error) {
+		return limited(ctx, s, s.arm, "list role assignments", func() ([]azure.RoleAssignment, error) {
+			return s.get().ListRoleAssignments(ctx, account, container)
+		})
+	})
+	s.logCall("list role assignments", start, err, "account", account, "container", container, "count", len(assignments))
+	return assignments, err
+}
+
+func (s *switchableProvider) ListReplicationPolicies(ctx context.Context, account string) ([]azure.ReplicationPolicy, error) {
+	start := time.Now()
+	policies, err := cached(ctx, s, "replication-policies", account, func() ([]azure.ReplicationPolicy, error) {
+		return limited(ctx, s, s.arm, "list replication policies", func() ([]azure.ReplicationPolicy, error) {
+			return s.get().ListReplicationPolicies(ctx, account)
+		})
+	})
+	s.logCall("list replication policies", start, err, "account", account, "count", len(policies))
+	return policies, err
+}
+
+func (s *switchableProvider) ListChangeFeedEvents(ctx context.Context, account string, opts azure.ChangeFeedOptions) ([]azure.ChangeFeedEvent, error) {
+	start := time.Now()
+	events, err := limited(ctx, s, s.blob, "list change feed events", func() ([]azure.ChangeFeedEvent, error) {
+		return s.get().ListChangeFeedEvents(ctx, account, opts)
+	})
+	s.logCall("list change feed events", start, err, "account", account, "start", opts.Start, "end", opts.End, "count", len(events))
+	return events, err
+}
+
+func (s *switchableProvider) GetAccountMetrics(ctx context.Context, account string, span, interval time.Duration) (azure.AccountMetrics, error) {
+	start := time.Now()
+	series, err := cached(ctx, s, "account-metrics", account, func() (azure.AccountMetrics, error) {
+		return limited(ctx, s, s.arm, "get account metrics", func() (azure.AccountMetrics, error) {
+			return s.get().GetAccountMetrics(ctx, account, span, interval)
+		})
+	})
+	s.logCall("get account metrics", start, err, "account", account, "span", span)
+	return series, err
+}
+
+func (s *switchableProvider) FindBlobsByTags(ctx context.Context, account, expression string) ([]azure.TaggedBlob, error) {
+	start := time.Now()
+	blobs, err := limited(ctx, s, s.blob, "find blobs by tags", func() ([]azure.TaggedBlob, error) {
+		return s.get().FindBlobsByTags(ctx, account, expression)
+	})
+	s.logCall("find blobs by tags", start, err, "account", account, "expression", expression, "count", len(blobs))
+	return blobs, err
+}
+
+func (s *switchableProvider) OpenBlob(ctx context.Context, account, container, blob string) (io.ReadCloser, error) {
+	start := time.Now()
+	reader, err := limited(ctx, s, s.blob, "open blob", func() (io.ReadCloser, error) {
+		return s.get().OpenBlob(ctx, account, container, blob)
+	})
+	seq := s.logCall("open blob", start, err, "account", account, "container", container, "blob", blob)
+	return s.countRead(reader, seq), err
+}
+
+// UploadBlob writes through the active provider once the limiter lets it;
+// the content is read as it goes, so a throttled upload is not retried.
+func (s *switchableProvider) UploadBlob(ctx context.Context, account, container, blob string, r io.Reader, contentType string) (azure.Blob, error) {
+	uploader, ok := s.get().(azure.Uploader)
+	if !ok {
+		return azure.Blob{}, errors.New("this provider cannot upload blobs")
+	}
+	start := time.Now()
+	if err := s.blob.wait(ctx); err != nil {
+		return azure.Blob{}, err
+	}
+	uploaded, err := uploader.UploadBlob(ctx, account, container, blob, r, contentType)
+	s.logCall("upload blob", start, err, "account", account, "container", container, "blob", blob, "bytes", uploaded.SizeBytes)
+	return uploaded, err
+}
+
+func (s *switchableProvider) DeleteBlob(ctx context.Context, account, container, blob string) error {
+	deleter, ok := s.get().(azure.Deleter)
+	if !ok {
+		return errors.New("this provider cannot delete blobs")
+	}
+	start := time.Now()
+	_, err := limited(ctx, s, s.blob, "delete blob", func() (struct{}, error) {
+		return struct{}{}, deleter.DeleteBlob(ctx, account, container, blob)
+	})
+	s.logCall("delete blob", start, err, "account", account, "container", container, "blob", blob)
+	return err
+}
+
+func (s *switchableProvider) UndeleteBlob(ctx context.Context, account, container, blob, etag string) error {
+	restorer, ok := s.get().(azure.Restorer)
+	if !ok {
+		return errors.New("this provider cannot restore blobs")
+	}
+	start := time.Now()
+	_, err := limited(ctx, s, s.blob, "undelete blob", func() (struct{}, error) {
+		return struct{}{}, restorer.UndeleteBlob(ctx, account, container, blob, etag)
+	})
+	s.logCall("undelete blob", start, err, "account", account, "container", container, "blob", blob, "etag", etag)
+	return err
+}
+
+func (s *switchableProvider) RestoreVersion(ctx context.Context, account, container, blob, etag string) error {
+	restorer, ok := s.get().(azure.Restorer)
+	if !ok {
+		return errors.New("this provider cannot restore blobs")
+	}
+	start := time.Now()
+	_, err := limited(ctx, s, s.blob, "restore version", func() (struct{}, error) {
+		return struct{}{}, restorer.RestoreVersion(ctx, account, container, blob, etag)
+	})
+	s.logCall("restore version", start, err, "account", account, "container", container, "blob", blob, "etag", etag)
+	return err
+}
+
+func (s *switchableProvider) OpenBlobRange(ctx context.Context, account, container, blob string, offset, length int64) (io.ReadCloser, error) {
+	start := time.Now()
+	reader, err := limited(ctx, s, s.blob, "open blob range", func() (io.ReadCloser, error) {
+		return azure.OpenRange(ctx, s.get(), account, container, blob, offset, length)
+	})
+	seq := s.logCall("open blob range", start, err, "account", account, "container", container, "blob", blob, "offset", offset, "length", length)
+	return s.countRead(reader, seq), err
+}
+
+func (s *switchableProvider) WatchBlobEvents(ctx context.Context, account, container string, send func(azure.BlobEvent)) error {
+	subscriber, ok := s.get().(azure.EventSubscriber)
+	if !ok {
+		return errors.New("this provider cannot push storage events")
+	}
+	start := time.Now()
+	err := subscriber.WatchBlobEvents(ctx, account, container, send)
+	s.logCall("watch blob events", start, err, "account", account, "container", container)
+	return err
+}
+
+func (s *switchableProvider) Identity() string {
+	if provider, ok := s.get().(identityProvider); ok {
+		return provider.Identity()
+	}
+	return "unknown"
+}
+
+func (a *App) setupProfilesModal() {
+	list := tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true)
+	list.SetBorder(true).SetTitle("Profiles  enter: switch | esc: close")
+
+	list.SetSelectedFunc(func(index int, _ string, _ string, _ rune) {
+		if index < 0 || index >= len(a.profiles) {
+			return
+		}
+		a.closeProfilesModal()
+		a.switchProfile(a.profiles[index])
+	})
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc || event.Rune() == 'P' {
+			a.closeProfilesModal()
+			return nil
+		}
+		return event
+	})
+
+	a.profilesList = list
+	a.pages.AddPage("profiles", centerModal(list, 12, 80), true, false)
+}
+
+func (a *App) openProfilesModal() {
+	a.profilesOpen = true
+	a.profilesList.Clear()
+	if len(a.profiles) == 0 {
+		a.profilesList.AddItem("No profiles. Add them under profiles: in config.yaml.", "", 0, nil)
+	}
+	for i, profile := range a.profiles {
+		marker := "  "
+		if profile.Name == a.profile.Name {
+			marker = "* "
+			a.profilesList.SetCurrentItem(i)
+		}
+		a.profilesList.AddItem(tview.Escape(marker+profileLabel(profile)), "", 0, nil)
+	}
+	a.pages.ShowPage("profiles")
+	a.app.SetFocus(a.profilesList)
+}
+
+func (a *App) closeProfilesModal() {
+	a.pages.HidePage("profiles")
+	a.profilesOpen = false
+	a.setActivePane(a.activePane)
+}
+
+func profileLabel(profile Profile) string {
+	parts := []string{profile.Name}
+	for _, part := range []string{profile.Cloud, profile.Tenant, profile.Credentials} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(profile.Subscriptions) > 0 {
+		parts = append(parts, "subscriptions: "+strings.Join(profile.Subscriptions, ", "))
+	}
+	if profile.Network.Proxy != "" {
+		parts = append(parts, "proxy: "+profile.Network.Proxy)
+	}
+	if profile.Network.InsecureSkipVerify {
+		parts = append(parts, "unverified TLS")
+	}
+	return strings.Join(parts, "  ")
+}
+
+// switchProfile signs in with the profile and rebuilds the tree from its
+// subscriptions. Searches, listings, and watch mode of the previous profile
+// stop.
+func (a *App) switchProfile(profile Profile) {
+	provider := a.switcher.get()
+	if a.connect != nil {
+		var err error
+		if provider, err = a.connect(profile); err != nil {
+			a.flashErr(fmt.Sprintf("Profile %s: %v", profile.Name, err))
+			return
+		}
+	}
+	a.switcher.set(provider, profile.Name)
+	// The previous profile keeps its session; the new one offers its own.
+	if err := a.saveSession(); err != nil {
+		a.flashErr(err.Error())
+	}
+	a.profile = profile
+	a.countProvider()
+	if saved, ok := a.loadSession(profile.Name); ok {
+		a.sessionOffer = &saved
+	}
+	a.findJob.stop()
+	a.grepJob.stop()
+	if a.watchStop != nil {
+		a.toggleWatch()
+	}
+	a.cancelContentsLoad()
+	a.subscriptionEnabled = make(map[string]bool)
+	a.reloadWith(treeState{}, contentsPosition{}, func() {
+		a.flash(fmt.Sprintf("Profile: %s", profile.Name))
+		a.offerSession()
+	})
+}
@@ -0,0 +1,501 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"storage-tui/internal/azure"
+)
+
+// property is one name/value line of the properties dialog.
+type property struct {
+	Name  string
+	Value string
+}
+
+// propertySection groups properties under a heading such as "Metadata".
+type propertySection struct {
+	Title string
+	Items []property
+}
+
+func (a *App) setupPropertiesModal() {
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetWrap(false)
+	view.SetBorder(true)
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc || event.Rune() == 'i' || event.Rune() == 'q':
+			a.closePropertiesModal()
+			return nil
+		case event.Rune() == 'y' || event.Rune() == 'Y':
+			a.copyDetails(event.Rune() == 'Y')
+			return nil
+		}
+		return event
+	})
+
+	a.propertiesView = view
+	a.pages.AddPage("properties", centerModal(view, 26, 80), true, false)
+}
+
+// openPropertiesModal shows every property the provider knows for the
+// selected blob or container, fetched in the background, or the settings of
+// the selected account as its listing returned them.
+func (a *App) openPropertiesModal() {
+	ref, ok := a.selectedRef()
+	if !ok || (ref.Kind != kindBlob && ref.Kind != kindContainer && ref.Kind != kindAccount) {
+		a.flashErr("Select an account, container, or blob to show its properties.")
+		return
+	}
+	if ref.Kind == kindAccount {
+		sections := accountPropertySections(ref, a.accountInfo[ref.Account])
+		a.showPropertiesDialog("Properties", ref.Name, func(context.Context) ([]propertySection, error) {
+			return sections, nil
+		})
+		return
+	}
+	a.showPropertiesDialog("Properties", ref.Name, func(ctx context.Context) ([]propertySection, error) {
+		if ref.Kind == kindContainer {
+			props, err := a.provider.GetContainerProperties(ctx, ref.Account, ref.Container)
+			return a.containerPropertySections(ref, props), err
+		}
+		props, err := a.provider.GetBlobProperties(ctx, ref.Account, ref.Container, ref.Name)
+		return a.blobPropertySections(ref, props), err
+	})
+}
+
+// showPropertiesDialog opens the properties dialog on the sections fetch
+// returns for name, fetched in the background. what titles the dialog.
+func (a *App) showPropertiesDialog(what, name string, fetch func(ctx context.Context) ([]propertySection, error)) {
+	a.propertiesSeq++
+	seq := a.propertiesSeq
+	a.propertiesOpen = true
+	a.propertySections = nil
+	a.propertiesView.SetTitle(fmt.Sprintf("%s: %s  y/Y: copy text/JSON | esc: close", what, name))
+	a.propertiesView.SetText(fmt.Sprintf("Loading %s…", strings.ToLower(what)))
+	a.pages.ShowPage("properties")
+	a.app.SetFocus(a.propertiesView)
+
+	runAsync(a, a.activePane, fetch, func(sections []propertySection, err error) {
+		if !a.propertiesOpen || seq != a.propertiesSeq {
+			return
+		}
+		if err != nil {
+			a.propertiesView.SetText(tview.Escape(fmt.Sprintf("Error loading %s: %v", strings.ToLower(what), err)))
+			return
+		}
+		a.propertySections = sections
+		a.propertiesView.SetText(a.formatPropertySections(sections))
+		a.propertiesView.ScrollToBeginning()
+	})
+}
+
+func (a *App) closePropertiesModal() {
+	a.pages.HidePage("properties")
+	a.propertiesOpen = false
+	a.setActivePane(a.activePane)
+}
+
+// blobDetailItems returns the Details lines of the blob's full properties.
+// Listings do not carry them, so until they are fetched, which starts once
+// the selection rests on the blob, a line says they are loading.
+func (a *App) blobDetailItems(ref itemRef) []property {
+	key := previewKey(ref)
+	props, ok := a.propsCache[key]
+	switch {
+	case ok:
+		items := []property{
+			{"Access tier", orNA(props.AccessTier)},
+			{"Lease", orNA(strings.Trim(props.LeaseState+", "+props.LeaseStatus, ", "))},
+			{"ETag", orNA(props.ETag)},
+			{"Content-MD5", orNA(props.ContentMD5)},
+			{"Encryption", blobEncryption(props)},
+			{"Created", a.formatTimestamp(props.Created)},
+		}
+		if summary := replicationSummary(props); summary != "" {
+			items = append(items, property{"Object replication", summary})
+		}
+		return items
+	case a.detailsErrs[key] != nil:
+		return []property{{"Properties", fmt.Sprintf("error: %v", a.detailsErrs[key])}}
+	}
+	fetchDetailProperties(a, ref, key, func(ctx context.Context) (azure.BlobProperties, error) {
+		return a.provider.GetBlobProperties(ctx, ref.Account, ref.Container, ref.Name)
+	}, func(props azure.BlobProperties) {
+		a.propsCache[key] = props
+	})
+	return []property{{"Properties", "loading…"}}
+}
+
+// containerDetailItems returns the Details lines of the container's full
+// properties, fetched like those of a blob. They are kept until the
+// container's account is refreshed.
+func (a *App) containerDetailItems(ref itemRef) []property {
+	key := ref.Account + "/" + ref.Container
+	props, ok := a.containerProps[key]
+	switch {
+	case ok:
+		metadata := mapSection("Metadata", props.Metadata)
+		pairs := make([]string, 0, len(metadata.Items))
+		for _, item := range metadata.Items {
+			pairs = append(pairs, item.Name+"="+item.Value)
+		}
+		immutability := "none"
+		switch {
+		case props.HasImmutabilityPolicy && props.HasLegalHold:
+			immutability = "policy, legal hold"
+		case props.HasImmutabilityPolicy:
+			immutability = "policy"
+		case props.HasLegalHold:
+			immutability = "legal hold"
+		}
+		return []property{
+			{"Last modified", a.formatTimestamp(props.LastModified)},
+			{"Lease", orNA(strings.Trim(props.LeaseState+", "+props.LeaseStatus, ", "))},
+			{"Immutability", immutability},
+			{"Metadata", orNA(strings.Join(pairs, ", "))},
+		}
+	case a.detailsErrs[key] != nil:
+		return []property{{"Properties", fmt.Sprintf("error: %v", a.detailsErrs[key])}}
+	}
+	fetchDetailProperties(a, ref, key, func(ctx context.Context) (azure.ContainerProperties, error) {
+		return a.provider.GetContainerProperties(ctx, ref.Account, ref.Container)
+	}, func(props azure.ContainerProperties) {
+		a.containerProps[key] = props
+	})
+	return []property{{"Properties", "loading…"}}
+}
+
+// accountDetailItems returns the Details lines of the account's settings,
+// which its listing carries.
+func accountDetailItems(account azure.Account) []property {
+	return []property{
+		{"SKU", orNA(account.SKU)},
+		{"Replication", orNA(replication(account.SKU))},
+		{"Kind", orNA(account.Kind)},
+		{"Hierarchical namespace", yesNo(account.HierarchicalNamespace)},
+		{"Minimum TLS", orNA(account.MinimumTLSVersion)},
+		{"Endpoints", orNA(strings.Trim(account.PrimaryEndpoint+", "+account.SecondaryEndpoint, ", "))},
+	}
+}
+
+// serviceDetailItems returns the Details lines of the account's blob service
+// settings: soft delete, versioning, the change feed, CORS, and the static
+// website when it is on. They are fetched like container properties and kept until
+// the account is refreshed.
+func (a *App) serviceDetailItems(ref itemRef) []property {
+	key := "service:" + ref.Account
+	props, ok := a.serviceProps[ref.Account]
+	switch {
+	case ok:
+		versioning := "off"
+		if props.Versioning {
+			versioning = "on"
+		}
+		softDelete := "off"
+		if props.BlobSoftDelete.Enabled || props.ContainerSoftDelete.Enabled {
+			softDelete = fmt.Sprintf("blobs %s, containers %s", retention(props.BlobSoftDelete), retention(props.ContainerSoftDelete))
+		}
+		cors := "off"
+		if len(props.CORS) > 0 {
+			cors = fmt.Sprintf("%d of %d rules", len(props.CORS), azure.MaxCORSRules)
+		}
+		items := []property{
+			{"Soft delete", softDelete},
+			{"Versioning", versioning},
+			{"Change feed", retention(props.ChangeFeed)},
+			{"CORS", cors},
+		}
+		if website := props.StaticWebsite; website.Enabled {
+			items = append(items, property{"Static website", strings.Join([]string{
+				"$web", "index " + orNA(website.IndexDocument), "404 " + orNA(website.ErrorDocument404Path),
+				orNA(a.accountInfo[ref.Account].WebEndpoint),
+			}, ", ")})
+		}
+		return items
+	case a.detailsErrs[key] != nil:
+		return []property{{"Blob service", fmt.Sprintf("error: %v", a.detailsErrs[key])}}
+	}
+	fetchDetailProperties(a, ref, key, func(ctx context.Context) (azure.BlobServiceProperties, error) {
+		return a.provider.GetBlobServiceProperties(ctx, ref.Account)
+	}, func(props azure.BlobServiceProperties) {
+		a.serviceProps[ref.Account] = props
+	})
+	return []property{{"Blob service", "loading…"}}
+}
+
+// retention describes how long a data protection policy keeps what it
+// saves, such as "7 days", or "off".
+func retention(policy azure.RetentionPolicy) string {
+	switch {
+	case !policy.Enabled:
+		return "off"
+	case policy.Days == 0:
+		return "forever"
+	case policy.Days == 1:
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", policy.Days)
+}
+
+// replicationNames spell out the replication part of a SKU.
+var replicationNames = map[string]string{
+	"LRS":    "locally redundant",
+	"ZRS":    "zone-redundant",
+	"GRS":    "geo-redundant",
+	"RAGRS":  "read-access geo-redundant",
+	"GZRS":   "geo-zone-redundant",
+	"RAGZRS": "read-access geo-zone-redundant",
+}
+
+// replication describes the replication of a SKU such as Standard_RAGRS.
+func replication(sku string) string {
+	_, kind, ok := strings.Cut(sku, "_")
+	if !ok {
+		return ""
+	}
+	if name, ok := replicationNames[strings.ToUpper(kind)]; ok {
+		return fmt.Sprintf("%s (%s)", kind, name)
+	}
+	return kind
+}
+
+// forgetContainerProps drops the properties kept for the account's
+// containers, or for all of them when account is empty.
+func (a *App) forgetContainerProps(account string) {
+	for key := range a.containerProps {
+		if account == "" || strings.HasPrefix(key, account+"/") {
+			delete(a.containerProps, key)
+		}
+	}
+}
+
+// fetchDetailProperties fetches properties for Details once the selection
+// has rested on ref for previewDelay, hands them to store, and shows them.
+// Details may fetch several things under different keys; a key already on
+// its way is not fetched again. Selecting anything else cancels the fetches;
+// what arrives anyway is kept.
+func fetchDetailProperties[T any](a *App, ref itemRef, key string, fetch func(ctx context.Context) (T, error), store func(T)) {
+	if a.detailsPending[key] {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a.detailsCancels = append(a.detailsCancels, cancel)
+	a.detailsPending[key] = true
+	seq := a.detailsSeq
+
+	time.AfterFunc(previewDelay, func() {
+		if ctx.Err() != nil {
+			return
+		}
+		a.app.QueueUpdateDraw(func() {
+			if seq != a.detailsSeq {
+				return
+			}
+			go func() {
+				props, err := fetch(ctx)
+				a.app.QueueUpdateDraw(func() {
+					if err == nil {
+						store(props)
+					}
+					if seq != a.detailsSeq {
+						return
+					}
+					delete(a.detailsPending, key)
+					if err != nil {
+						a.detailsErrs[key] = err
+					}
+					a.updateDetails(ref)
+				})
+			}()
+		})
+	})
+}
+
+// stopDetailsFetch drops the fetches of the previous Details and the errors
+// they ran into.
+func (a *App) stopDetailsFetch() {
+	a.detailsSeq++
+	for _, cancel := range a.detailsCancels {
+		cancel()
+	}
+	a.detailsCancels = nil
+	clear(a.detailsPending)
+	clear(a.detailsErrs)
+}
+
+func accountPropertySections(ref itemRef, account azure.Account) []propertySection {
+	general := propertySection{Title: "Account", Items: []property{
+		{"Name", ref.Account},
+		{"Subscription", ref.SubscriptionName},
+		{"Region", ref.Region},
+		{"SKU", account.SKU},
+		{"Replication", replication(account.SKU)},
+		{"Kind", account.Kind},
+		{"Hierarchical namespace", yesNo(account.HierarchicalNamespace)},
+		{"Minimum TLS version", account.MinimumTLSVersion},
+		{"Primary endpoint", account.PrimaryEndpoint},
+		{"Secondary endpoint", account.SecondaryEndpoint},
+		{"Web endpoint", account.WebEndpoint},
+	}}
+	encryption := account.Encryption
+	keys := propertySection{Title: "Encryption", Items: []property{
+		{"Keys", encryptionKeys(encryption)},
+	}}
+	if customerManaged(encryption) {
+		version := encryption.KeyVersion
+		if version == "" {
+			version = "latest, rotated automatically"
+		}
+		keys.Items = append(keys.Items,
+			property{"Key vault", encryption.KeyVaultURI},
+			property{"Key name", encryption.KeyName},
+			property{"Key version", version})
+	}
+	keys.Items = append(keys.Items, property{"Infrastructure encryption", yesNo(encryption.InfrastructureEncryption)})
+	return []propertySection{general, keys}
+}
+
+func customerManaged(encryption azure.AccountEncryption) bool {
+	return strings.EqualFold(encryption.KeySource, "Microsoft.Keyvault")
+}
+
+// encryptionKeys says who manages the keys an account encrypts with.
+func encryptionKeys(encryption azure.AccountEncryption) string {
+	switch {
+	case customerManaged(encryption):
+		return "customer-managed, in Key Vault"
+	case encryption.KeySource == "":
+		return ""
+	}
+	return "Microsoft-managed"
+}
+
+// blobEncryption describes how a blob is encrypted at rest, such as
+// "yes, scope $account-encryption-key".
+func blobEncryption(props azure.BlobProperties) string {
+	if !props.ServerEncrypted {
+		return "no"
+	}
+	if props.EncryptionScope == "" {
+		return "yes"
+	}
+	return "yes, scope " + props.EncryptionScope
+}
+
+func (a *App) containerPropertySections(ref itemRef, props azure.ContainerProperties) []propertySection {
+	general := propertySection{Title: "Container", Items: []property{
+		{"Name", props.Name},
+		{"Account", ref.Account},
+		{"Subscription", ref.SubscriptionName},
+		{"Public access", props.PublicAccess},
+		{"Last modified", a.formatTimestamp(props.LastModified)},
+		{"ETag", props.ETag},
+		{"Lease state", props.LeaseState},
+		{"Lease status", props.LeaseStatus},
+		{"Default encryption scope", props.DefaultEncryptionScope},
+		{"Immutability policy", yesNo(props.HasImmutabilityPolicy)},
+		{"Legal hold", yesNo(props.HasLegalHold)},
+	}}
+	return []propertySection{general, mapSection("Metadata", props.Metadata)}
+}
+
+func (a *App) blobPropertySections(ref itemRef, props azure.BlobProperties) []propertySection {
+	general := propertySection{Title: "Blob", Items: []property{
+		{"Name", props.Name},
+		{"Container", ref.Container},
+		{"Account", ref.Account},
+		{"Subscription", ref.SubscriptionName},
+		{"Blob type", props.BlobType},
+		{"Size", fmt.Sprintf("%s (%s bytes)", formatBytes(props.SizeBytes), groupDigits(props.SizeBytes))},
+		{"Created", a.formatTimestamp(props.Created)},
+		{"Last modified", a.formatTimestamp(props.Modified)},
+		{"ETag", props.ETag},
+		{"Access tier", props.AccessTier},
+		{"Lease state", props.LeaseState},
+		{"Lease status", props.LeaseStatus},
+		{"Server encrypted", yesNo(props.ServerEncrypted)},
+		{"Encryption scope", props.EncryptionScope},
+	}}
+	content := propertySection{Title: "Content", Items: []property{
+		{"Content type", props.ContentType},
+		{"Content MD5", props.ContentMD5},
+		{"Content encoding", props.ContentEncoding},
+		{"Cache control", props.CacheControl},
+	}}
+	return []propertySection{
+		general,
+		content,
+		mapSection("Metadata", props.Metadata),
+		mapSection("Tags", props.Tags),
+	}
+}
+
+// mapSection lists metadata or tags sorted by key.
+func mapSection(title string, values map[string]string) propertySection {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	section := propertySection{Title: title}
+	for _, key := range keys {
+		section.Items = append(section.Items, property{key, values[key]})
+	}
+	return section
+}
+
+func (a *App) formatPropertySections(sections []propertySection) string {
+	width := 0
+	for _, section := range sections {
+		for _, item := range section.Items {
+			if len(item.Name) > width {
+				width = len(item.Name)
+			}
+		}
+	}
+
+	var builder strings.Builder
+	for i, section := range sections {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(fmt.Sprintf("[::b]%s[::-]\n", tview.Escape(section.Title)))
+		if len(section.Items) == 0 {
+			builder.WriteString(fmt.Sprintf("  %s(none)[-]\n", colorTag(a.theme.muted)))
+			continue
+		}
+		for _, item := range section.Items {
+			value := item.Value
+			if value == "" {
+				value = "n/a"
+			}
+			name := fmt.Sprintf("%-*s", width, item.Name)
+			builder.WriteString(fmt.Sprintf("  %s%s[-]  %s\n", colorTag(a.theme.accent), tview.Escape(name), tview.Escape(value)))
+		}
+	}
+	return builder.String()
+}
+
+func orNA(value string) string {
+	if value == "" {
+		return "n/a"
+	}
+	return value
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,292 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+type childKey struct {
+	kind itemKind
+	name string
+}
+
+// setChildren replaces the children of node, reusing existing child nodes
+// with the same kind and name so a refresh keeps their expansion state,
+// loaded children, and selection. If the current node disappears with the
+// old children, node becomes the current node. Nodes not yet attached to the
+// tree leave the selection alone.
+func (a *App) setChildren(node *tview.TreeNode, children []*tview.TreeNode) {
+	existing := make(map[childKey]*tview.TreeNode, len(node.GetChildren()))
+	for _, child := range node.GetChildren() {
+		if ref, ok := child.GetReference().(itemRef); ok {
+			existing[childKey{ref.Kind, ref.Name}] = child
+		}
+	}
+
+	merged := make([]*tview.TreeNode, 0, len(children))
+	for _, child := range children {
+		ref, _ := child.GetReference().(itemRef)
+		if old, ok := existing[childKey{ref.Kind, ref.Name}]; ok && ref.Kind != kindNone {
+			old.SetReference(ref).SetText(child.GetText())
+			child = old
+		}
+		merged = append(merged, child)
+	}
+	node.SetChildren(merged)
+
+	if node != a.root && a.parentNode(node) == nil {
+		return
+	}
+	current := a.accounts.GetCurrentNode()
+	if current != nil && current != a.root && a.parentNode(current) == nil {
+		a.accounts.SetCurrentNode(node)
+		a.onTreeChanged(node)
+	}
+}
+
+// refreshFocused re-fetches only what the focused pane shows: the selected
+// tree node's children, or the listed container's blobs. The rest of the tree
+// keeps its expansion and selection.
+func (a *App) refreshFocused() {
+	if a.activePane != paneAccounts {
+		if a.contentSource.Kind == kindAccount || a.contentsTarget.Kind == kindAccount {
+			a.showTagMatches(a.tagsAccount, a.tagsExpression)
+			return
+		}
+		container := a.contentsTarget
+		if container.Kind != kindContainer {
+			container = a.contentSource
+		}
+		if container.Kind != kindContainer {
+			a.flashErr("Nothing to refresh here.")
+			return
+		}
+		a.refreshBlobs(container)
+		return
+	}
+
+	node := a.accounts.GetCurrentNode()
+	ref, ok := itemRef{}, false
+	if node != nil {
+		ref, ok = node.GetReference().(itemRef)
+	}
+	if ok && (ref.Kind == kindBlob || ref.Kind == kindNone) {
+		node = a.parentNode(node)
+		ref, ok = node.GetReference().(itemRef)
+	}
+	if !ok || ref.Kind == kindRoot {
+		a.reload()
+		return
+	}
+	a.refreshNode(node, ref)
+}
+
+func (a *App) refreshNode(node *tview.TreeNode, ref itemRef) {
+	var scope string
+	switch ref.Kind {
+	case kindSubscription:
+		if !a.isSubscriptionEnabled(ref.SubscriptionID) {
+			a.flashErr(fmt.Sprintf("Subscription %s is disabled.", ref.Name))
+			return
+		}
+		scope = "accounts"
+	case kindAccount:
+		scope = "containers"
+		a.forgetContainerProps(ref.Account)
+		delete(a.accountMetrics, ref.Account)
+		delete(a.serviceProps, ref.Account)
+	case kindContainer:
+		delete(a.containerProps, ref.Account+"/"+ref.Container)
+		a.refreshBlobs(ref)
+		// Blobs shown below the node are refreshed too, once loaded.
+		if len(node.GetChildren()) == 0 || a.failedNodes[node] {
+			return
+		}
+		scope = "blobs"
+	default:
+		return
+	}
+
+	if len(node.GetChildren()) == 0 || a.failedNodes[node] {
+		a.expandNode(node, false, nil)
+		return
+	}
+	before := childSignature(node)
+	a.fetchChildren(node, ref, true, func(err error) {
+		switch {
+		case err != nil || ref.Kind == kindContainer:
+		case childSignature(node) == before:
+			a.flash(fmt.Sprintf("The %s of %s are unchanged.", scope, ref.Name))
+		default:
+			a.flash(fmt.Sprintf("Refreshed %s of %s.", scope, ref.Name))
+		}
+	})
+}
+
+// refreshBlobs lists the container into the contents table again, keeping
+// the selected row and scroll offset. The status bar says when nothing
+// changed.
+func (a *App) refreshBlobs(container itemRef) {
+	position := a.saveContentsPosition()
+	var before []itemRef
+	if sameContainer(a.contentSource, container) {
+		before = a.listedBlobs
+	}
+	a.showBlobs(container)
+	a.whenContentsLoaded(func(err error) {
+		if err != nil {
+			return
+		}
+		a.restoreContentsPosition(position)
+		if before != nil && unchangedListing(before, a.listedBlobs, a.contentsMarker != "") {
+			a.flash(fmt.Sprintf("Blobs of %s/%s unchanged.", container.Account, container.Container))
+			return
+		}
+		a.flash(fmt.Sprintf("Refreshed blobs of %s/%s.", container.Account, container.Container))
+	})
+}
+
+// unchangedListing reports whether a fresh listing matches the blobs listed
+// before, by name and ETag. Only the pages loaded again are compared, and more
+// listed before counts as unchanged only while the fresh listing has more
+// pages too.
+func unchangedListing(before, after []itemRef, more bool) bool {
+	if len(after) > len(before) || (len(after) < len(before) && !more) {
+		return false
+	}
+	for i, ref := range after {
+		if ref.Name != before[i].Name || blobVersion(ref) != blobVersion(before[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// blobVersion identifies the state of a blob: its ETag, or its size and
+// modified time when the provider gave none.
+func blobVersion(ref itemRef) string {
+	if ref.ETag != "" {
+		return ref.ETag
+	}
+	return fmt.Sprintf("%d/%d", ref.SizeBytes, ref.Modified.UnixNano())
+}
+
+// childSignature lists the children of node, so a refresh can tell whether
+// they changed.
+func childSignature(node *tview.TreeNode) string {
+	var builder strings.Builder
+	for _, child := range node.GetChildren() {
+		if ref, ok := child.GetReference().(itemRef); ok {
+			fmt.Fprintf(&builder, "%d %s %s\n", ref.Kind, ref.Name, blobVersion(ref))
+		}
+	}
+	return builder.String()
+}
+
+// treeState is what a full reload restores in the rebuilt tree: the nodes
+// that were expanded, parents first, and the current node.
+type treeState struct {
+	current  itemRef
+	expanded []itemRef
+}
+
+func (a *App) saveTreeState() treeState {
+	var state treeState
+	if node := a.accounts.GetCurrentNode(); node != nil {
+		state.current, _ = node.GetReference().(itemRef)
+	}
+	a.root.Walk(func(node, parent *tview.TreeNode) bool {
+		if node == a.root {
+			return true
+		}
+		// New nodes start out expanded, so only count those with children.
+		if !node.IsExpanded() || len(node.GetChildren()) == 0 {
+			return false
+		}
+		if ref, ok := node.GetReference().(itemRef); ok && (ref.Kind == kindAccount || ref.Kind == kindContainer) {
+			state.expanded = append(state.expanded, ref)
+		}
+		return true
+	})
+	return state
+}
+
+// restoreTreeState expands the saved nodes one after another, since each may
+// need its children loaded, then selects the saved current node.
+func (a *App) restoreTreeState(state treeState, done func()) {
+	var next func(index int)
+	next = func(index int) {
+		for ; index < len(state.expanded); index++ {
+			node := a.findTreeNode(state.expanded[index])
+			if node == nil || (node.IsExpanded() && len(node.GetChildren()) > 0) {
+				continue
+			}
+			following := index + 1
+			a.expandNode(node, false, func(error) {
+				next(following)
+			})
+			return
+		}
+
+		switch state.current.Kind {
+		case kindRoot, kindNone:
+		default:
+			if node := a.findTreeNode(state.current); node != nil {
+				a.selectTreeNode(node, false)
+			}
+		}
+		done()
+	}
+	next(0)
+}
+
+// contentsPosition is the selected row and scroll offset of the contents
+// table, restored once its container is listed again.
+type contentsPosition struct {
+	container itemRef
+	prefix    string
+	selected  itemRef
+	row       int
+	offset    int
+}
+
+func (a *App) saveContentsPosition() contentsPosition {
+	row, _ := a.contents.GetSelection()
+	offset, _ := a.contents.GetOffset()
+	selected, _ := a.contentRef(row)
+	return contentsPosition{
+		container: a.contentSource,
+		prefix:    a.folderPrefix,
+		selected:  selected,
+		row:       row,
+		offset:    offset,
+	}
+}
+
+// restoreContentsPosition selects the saved row again, matched by identity
+// and otherwise by index, if the table still lists the same container.
+func (a *App) restoreContentsPosition(position contentsPosition) {
+	if position.container.Kind != kindContainer || !sameContainer(a.contentSource, position.container) {
+		return
+	}
+	if a.folderView && a.folderPrefix != position.prefix {
+		a.folderPrefix = position.prefix
+		a.renderContents(false)
+	}
+
+	row := position.row
+	for index, ref := range a.contentRefs {
+		if ref.Kind == position.selected.Kind && ref.Name == position.selected.Name && ref.Prefix == position.selected.Prefix {
+			row = index
+			break
+		}
+	}
+	if row >= len(a.contentRefs) {
+		row = len(a.contentRefs) - 1
+	}
+	a.contents.Select(row, 0)
+	a.contents.SetOffset(position.offset, 0)
+	a.refreshContentSelection()
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"storage-tui/internal/azure"
+)
+
+// openReplicationModal shows the object replication policies of the account
+// of the selection, or the listing, and when a blob is selected whether it
+// has been copied to the destination account yet.
+func (a *App) openReplicationModal() {
+	ref, ok := a.selectedRef()
+	if !ok || ref.Account == "" {
+		ref = a.contentSource
+	}
+	if ref.Account == "" {
+		a.flashErr("Select an account or blob to show its object replication.")
+		return
+	}
+	name := ref.Account
+	if ref.Kind == kindBlob {
+		name += "/" + ref.Container + "/" + ref.Name
+	}
+	a.showPropertiesDialog("Object replication", name, func(ctx context.Context) ([]propertySection, error) {
+		policies, err := a.provider.ListReplicationPolicies(ctx, ref.Account)
+		if err != nil || ref.Kind != kindBlob {
+			return replicationSections(ref.Account, policies), err
+		}
+		props, err := a.provider.GetBlobProperties(ctx, ref.Account, ref.Container, ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		blob := blobReplicationSection(ref, props, policies)
+		return append([]propertySection{blob}, replicationSections(ref.Account, policies)...), nil
+	})
+}
+
+// replicationSections lists each policy with its rules, saying which way it
+// copies for account.
+func replicationSections(account string, policies []azure.ReplicationPolicy) []propertySection {
+	if len(policies) == 0 {
+		return []propertySection{{Title: "Policies"}}
+	}
+	var sections []propertySection
+	for _, policy := range policies {
+		direction := "copies to " + policy.DestinationAccount
+		if policy.DestinationAccount == account {
+			direction = "receives from " + policy.SourceAccount
+		}
+		section := propertySection{Title: fmt.Sprintf("Policy %s (%s)", policy.ID, direction), Items: []property{
+			{"Source", policy.SourceAccount},
+			{"Destination", policy.DestinationAccount},
+		}}
+		for _, rule := range policy.Rules {
+			section.Items = append(section.Items, property{"Rule " + rule.ID, describeReplicationRule(rule)})
+		}
+		sections = append(sections, section)
+	}
+	return sections
+}
+
+// describeReplicationRule sums up a rule, such as "site to public, names
+// starting with css/, created since 2024-05-11".
+func describeReplicationRule(rule azure.ReplicationRule) string {
+	parts := []string{fmt.Sprintf("%s to %s", rule.SourceContainer, rule.DestinationContainer)}
+	if len(rule.PrefixMatch) > 0 {
+		parts = append(parts, "names starting with "+strings.Join(rule.PrefixMatch, " or "))
+	}
+	if !rule.MinCreationTime.IsZero() {
+		parts = append(parts, "created since "+rule.MinCreationTime.Format(time.DateOnly))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// blobReplicationSection tells for each rule that copies the blob whether
+// the copy is complete, failed, or still pending, or, for a copy, which
+// blob it was copied from.
+func blobReplicationSection(ref itemRef, props azure.BlobProperties, policies []azure.ReplicationPolicy) propertySection {
+	section := propertySection{Title: "Blob " + ref.Name}
+	for _, policy := range policies {
+		for _, rule := range policy.Rules {
+			switch {
+			case policy.SourceAccount == ref.Account && rule.SourceContainer == ref.Container:
+				if !replicates(rule, props) {
+					continue
+				}
+				status := "pending"
+				for _, replication := range props.Replication {
+					if replication.PolicyID == policy.ID && replication.RuleID == rule.ID {
+						status = replication.Status
+					}
+				}
+				target := policy.DestinationAccount + "/" + rule.DestinationContainer + "/" + ref.Name
+				section.Items = append(section.Items, property{"Rule " + rule.ID, fmt.Sprintf("%s, to %s", status, target)})
+			case policy.ID == props.ReplicationPolicyID && rule.DestinationContainer == ref.Container:
+				source := policy.SourceAccount + "/" + rule.SourceContainer + "/" + ref.Name
+				section.Items = append(section.Items, property{"Copy of", fmt.Sprintf("%s, by rule %s", source, rule.ID)})
+			}
+		}
+	}
+	return section
+}
+
+// replicates reports whether rule copies the blob: it is a block blob, its
+// name starts with one of the rule's prefixes, if any, and it was created
+// after the rule's minimum creation time, if any.
+func replicates(rule azure.ReplicationRule, props azure.BlobProperties) bool {
+	if props.BlobType != "" && props.BlobType != "BlockBlob" {
+		return false
+	}
+	if !rule.MinCreationTime.IsZero() && props.Created.Before(rule.MinCreationTime) {
+		return false
+	}
+	if len(rule.PrefixMatch) == 0 {
+		return true
+	}
+	for _, prefix := range rule.PrefixMatch {
+		if strings.HasPrefix(props.Name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// replicationSummary sums up the blob's replication for the Details pane,
+// such as "complete (rule site-pages)", or "" when it is neither copied nor
+// a copy as far as its properties tell.
+func replicationSummary(props azure.BlobProperties) string {
+	var parts []string
+	for _, replication := range props.Replication {
+		parts = append(parts, fmt.Sprintf("%s (rule %s)", replication.Status, replication.RuleID))
+	}
+	if props.ReplicationPolicyID != "" {
+		parts = append(parts, "copy by policy "+props.ReplicationPolicyID)
+	}
+	return strings.Join(parts, ", ")
+}
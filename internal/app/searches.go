@@ -0,0 +1,307 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"storage-tui/internal/azure"
+	"storage-tui/internal/searches"
+)
+
+func (a *App) setupSearchesModal() {
+	list := tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true)
+	list.SetBorder(true).SetTitle("Saved searches  enter/1-9: run | d: delete | esc: close")
+
+	list.SetSelectedFunc(func(index int, _ string, _ string, _ rune) {
+		items := a.searches.List()
+		if index < 0 || index >= len(items) {
+			return
+		}
+		a.closeSearchesModal()
+		a.runSavedSearch(items[index])
+	})
+
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc || event.Rune() == 'S':
+			a.closeSearchesModal()
+			return nil
+		case event.Rune() == 'd':
+			if err := a.searches.Remove(list.GetCurrentItem()); err != nil {
+				a.flashErr(fmt.Sprintf("Error saving searches: %v", err))
+			}
+			a.fillSearchesList()
+			return nil
+		}
+		return event
+	})
+
+	a.searchesList = list
+	a.pages.AddPage("searches", centerModal(list, 15, 90), true, false)
+
+	input := tview.NewInputField().
+		SetLabel("Name: ").
+		SetFieldWidth(0)
+	form := tview.NewForm().
+		AddFormItem(input).
+		AddButton("Save", func() {
+			a.confirmSaveSearch(input.GetText())
+		}).
+		AddButton("Cancel", a.closeSaveSearchModal)
+	form.SetBorder(true)
+	form.SetButtonsAlign(tview.AlignRight)
+	form.SetCancelFunc(a.closeSaveSearchModal)
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEnter:
+			a.confirmSaveSearch(input.GetText())
+			return nil
+		case tcell.KeyEsc:
+			a.closeSaveSearchModal()
+			return nil
+		}
+		return event
+	})
+
+	a.saveSearchInput = input
+	a.saveSearchForm = form
+	a.pages.AddPage("savesearch", centerModal(form, 7, 70), true, false)
+}
+
+func (a *App) openSearchesModal() {
+	if a.searchesErr != nil {
+		a.flashErr(fmt.Sprintf("Error loading searches: %v", a.searchesErr))
+	}
+	a.searchesOpen = true
+	a.fillSearchesList()
+	a.pages.ShowPage("searches")
+	a.app.SetFocus(a.searchesList)
+}
+
+func (a *App) closeSearchesModal() {
+	a.pages.HidePage("searches")
+	a.searchesOpen = false
+	a.setActivePane(a.activePane)
+}
+
+func (a *App) fillSearchesList() {
+	current := a.searchesList.GetCurrentItem()
+	a.searchesList.Clear()
+	items := a.searches.List()
+	if len(items) == 0 {
+		a.searchesList.AddItem("No saved searches. Press ctrl-s in the filter, find, grep, or tags dialog to save one.", "", 0, nil)
+		return
+	}
+	for i, item := range items {
+		shortcut := rune(0)
+		if i < 9 {
+			shortcut = rune('1' + i)
+		}
+		a.searchesList.AddItem(tview.Escape(savedSearchLabel(item)), "", shortcut, nil)
+	}
+	if current >= len(items) {
+		current = len(items) - 1
+	}
+	a.searchesList.SetCurrentItem(current)
+}
+
+func savedSearchLabel(search searches.Search) string {
+	parts := []string{search.Name, search.Kind + " in " + search.Scope()}
+	if search.Pattern != "" {
+		parts = append(parts, search.Pattern)
+	}
+	if search.Filter != nil {
+		parts = append(parts, "filter: "+filterFieldsFromSaved(search.Filter).describe())
+	}
+	return strings.Join(parts, "  ")
+}
+
+// saveSearchFromDialog asks for a name to save the definition in the open
+// filter, find, grep, or tags dialog under. It reports whether one of them
+// was open.
+func (a *App) saveSearchFromDialog() bool {
+	var search searches.Search
+	switch {
+	case a.saveSearchOpen:
+		return false
+	case a.filterOpen:
+		fields := a.filterFormFields()
+		filter, err := a.compileFilterFields(fields)
+		if err != nil || filter == nil {
+			a.flashErr("Save search: enter a valid filter first.")
+			return true
+		}
+		scope := a.contentSource
+		if a.folderView {
+			scope.Prefix = a.folderPrefix
+		}
+		search = savedSearchIn(searches.KindFilter, scope)
+		search.Filter = savedFilter(fields)
+	case a.findOpen:
+		pattern := a.findInput.GetText()
+		if _, err := blobMatcher(pattern); err != nil {
+			a.flashErr(fmt.Sprintf("Save search: %v", err))
+			return true
+		}
+		search = searches.Search{Kind: searches.KindFind, Pattern: pattern}
+	case a.grepOpen:
+		pattern := a.grepInput.GetText()
+		if _, err := lineMatcher(pattern); err != nil {
+			a.flashErr(fmt.Sprintf("Save search: %v", err))
+			return true
+		}
+		search = savedSearchIn(searches.KindGrep, a.grepScope)
+		search.Pattern = pattern
+		if a.grepFilter != nil {
+			search.Filter = savedFilter(a.grepFilter.fields)
+		}
+	case a.tagsOpen:
+		pattern := a.tagsInput.GetText()
+		if _, err := azure.ParseTagExpression(pattern); err != nil {
+			a.flashErr(fmt.Sprintf("Save search: %v", err))
+			return true
+		}
+		search = savedSearchIn(searches.KindTags, a.tagsAccount)
+		search.Pattern = pattern
+	default:
+		return false
+	}
+
+	a.pendingSearch = search
+	a.saveSearchReturn = a.app.GetFocus()
+	a.saveSearchOpen = true
+	a.saveSearchForm.SetTitle(fmt.Sprintf("Save %s search in %s", search.Kind, search.Scope()))
+	a.saveSearchInput.SetText("")
+	a.pages.ShowPage("savesearch")
+	a.app.SetFocus(a.saveSearchInput)
+	return true
+}
+
+func savedSearchIn(kind string, scope itemRef) searches.Search {
+	return searches.Search{
+		Kind:             kind,
+		SubscriptionID:   scope.SubscriptionID,
+		SubscriptionName: scope.SubscriptionName,
+		Account:          scope.Account,
+		Container:        scope.Container,
+		Prefix:           scope.Prefix,
+	}
+}
+
+func (a *App) confirmSaveSearch(name string) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		a.flashErr("Save search: enter a name.")
+		return
+	}
+	search := a.pendingSearch
+	search.Name = name
+	replaced, err := a.searches.Put(search)
+	if err != nil {
+		a.flashErr(fmt.Sprintf("Error saving searches: %v", err))
+		return
+	}
+	a.closeSaveSearchModal()
+	if replaced {
+		a.flash(fmt.Sprintf("Replaced saved search %q.", name))
+	} else {
+		a.flash(fmt.Sprintf("Saved search %q.", name))
+	}
+}
+
+// closeSaveSearchModal returns to the dialog the search was saved from.
+func (a *App) closeSaveSearchModal() {
+	a.pages.HidePage("savesearch")
+	a.saveSearchOpen = false
+	if a.saveSearchReturn != nil {
+		a.app.SetFocus(a.saveSearchReturn)
+		a.saveSearchReturn = nil
+	}
+}
+
+// runSavedSearch repeats a saved search in its scope. Filters are compiled
+// again, so ages count back from now.
+func (a *App) runSavedSearch(search searches.Search) {
+	var filter *blobFilter
+	if search.Filter != nil {
+		var err error
+		filter, err = a.compileFilterFields(filterFieldsFromSaved(search.Filter))
+		if err != nil {
+			a.flashErr(fmt.Sprintf("Search %s: %v", search.Name, err))
+			return
+		}
+	}
+
+	switch search.Kind {
+	case searches.KindFind:
+		a.openFindModal()
+		a.findInput.SetText(search.Pattern)
+		a.startFind(search.Pattern)
+		return
+	case searches.KindTags:
+		a.tagsAccount = itemRef{
+			Kind:             kindAccount,
+			Name:             search.Account,
+			SubscriptionID:   search.SubscriptionID,
+			SubscriptionName: search.SubscriptionName,
+			Account:          search.Account,
+		}
+		a.tagsExpression = search.Pattern
+		a.showTagMatches(a.tagsAccount, search.Pattern)
+		a.setActivePane(paneContents)
+		return
+	case searches.KindFilter, searches.KindGrep:
+	default:
+		a.flashErr(fmt.Sprintf("Search %s: unknown kind %q.", search.Name, search.Kind))
+		return
+	}
+
+	loc := location{SubscriptionID: search.SubscriptionID, Account: search.Account, Container: search.Container}
+	a.revealLocation(loc, func(err error) {
+		if err != nil {
+			a.flashErr(fmt.Sprintf("Search %s: %v", search.Name, err))
+			return
+		}
+		a.whenContentsLoaded(func(err error) {
+			if err != nil {
+				a.flashErr(fmt.Sprintf("Search %s: %v", search.Name, err))
+				return
+			}
+			if search.Kind == searches.KindGrep {
+				scope := a.contentSource
+				scope.Prefix = search.Prefix
+				a.showGrep(scope, filter)
+				a.grepInput.SetText(search.Pattern)
+				a.startGrep(search.Pattern)
+				return
+			}
+			if search.Prefix != "" && !a.folderView {
+				a.toggleFolderView()
+			}
+			a.folderPrefix = search.Prefix
+			a.contentsFilter = filter
+			a.renderContents(false)
+			a.setActivePane(paneContents)
+		})
+	})
+}
+
+func savedFilter(fields filterFields) *searches.Filter {
+	return &searches.Filter{
+		Name:        fields.Name,
+		After:       fields.After,
+		Before:      fields.Before,
+		MinSize:     fields.MinSize,
+		MaxSize:     fields.MaxSize,
+		ContentType: fields.ContentType,
+	}
+}
+
+func filterFieldsFromSaved(filter *searches.Filter) filterFields {
+	return filterFields{filter.Name, filter.After, filter.Before, filter.MinSize, filter.MaxSize, filter.ContentType}
+}
@@ -0,0 +1,221 @@
+package app
+
+import (
+	"fmt"
+
+	"storage-tui/internal/session"
+)
+
+var sessionKinds = map[itemKind]string{
+	kindSubscription: "subscription",
+	kindAccount:      "account",
+	kindContainer:    "container",
+	kindBlob:         "blob",
+}
+
+// savedSession is a session read from disk: the tree to restore, then the
+// view of the listed container.
+type savedSession struct {
+	tree treeState
+	view sessionView
+}
+
+// sessionView is what a saved session puts back once its tree is restored:
+// the listing options and the selected blob of the saved container.
+type sessionView struct {
+	container  itemRef
+	blob       string
+	prefix     string
+	folderView bool
+	folder     string
+	filter     filterFields
+}
+
+// startSession picks the view the first load restores: the profile's previous
+// session when Options.RestoreSession is set, otherwise none, and the previous
+// session is offered once the tree has loaded.
+func (a *App) startSession(restore bool) savedSession {
+	saved, ok := a.loadSession(a.profile.Name)
+	if !ok {
+		return savedSession{}
+	}
+	if !restore {
+		a.sessionOffer = &saved
+		return savedSession{}
+	}
+	return saved
+}
+
+// loadSession reads the session saved for profile and makes its file the one
+// saved to on quit. It reports whether there is anything to restore.
+func (a *App) loadSession(profile string) (savedSession, bool) {
+	path, err := session.DefaultPath(profile)
+	if err != nil {
+		a.sessionErr = err
+		return savedSession{}, false
+	}
+	a.sessionPath = path
+	state, err := session.Load(path)
+	if err != nil {
+		a.sessionErr = err
+		return savedSession{}, false
+	}
+	if state.Empty() {
+		return savedSession{}, false
+	}
+
+	var saved savedSession
+	for _, node := range state.Expanded {
+		if ref, ok := refFromSession(node); ok {
+			saved.tree.expanded = append(saved.tree.expanded, ref)
+		}
+	}
+	if state.Current != nil {
+		saved.tree.current, _ = refFromSession(*state.Current)
+		if saved.tree.current.Kind == kindContainer {
+			saved.view.container = saved.tree.current
+			saved.view.blob = state.Blob
+			saved.view.prefix = state.Prefix
+		}
+	}
+	saved.view.folderView = state.FolderView
+	saved.view.folder = state.Folder
+	if state.Filter != nil {
+		saved.view.filter = filterFieldsFromSaved(state.Filter)
+	}
+	return saved, true
+}
+
+// offerSession asks whether to restore the session read at startup or on a
+// profile switch, unless another dialog is open.
+func (a *App) offerSession() {
+	offer := a.sessionOffer
+	a.sessionOffer = nil
+	if offer == nil || a.modalOpen() {
+		return
+	}
+	where := offer.tree.current.Name
+	if container := offer.view.container; container.Kind == kindContainer {
+		where = container.Account + "/" + container.Container
+		if offer.view.prefix != "" {
+			where += " listed by " + offer.view.prefix
+		}
+	}
+	message := fmt.Sprintf("Reopen %s where the previous session left off?", where)
+	if filter := offer.view.filter.describe(); filter != "" {
+		message = fmt.Sprintf("Reopen %s, filtered by %s, where the previous session left off?", where, filter)
+	}
+	a.confirm(confirmation{
+		Title:   "Restore session",
+		Message: message,
+		Action:  "Restore",
+		OnConfirm: func() {
+			a.restoreTreeState(offer.tree, func() {
+				a.restoreSessionView(offer.view)
+			})
+		},
+	})
+}
+
+// restoreSessionView lists the saved container by its saved prefix once the
+// tree has selected it, then puts back the folder, filter, and selection.
+func (a *App) restoreSessionView(view sessionView) {
+	if view.container.Kind != kindContainer {
+		return
+	}
+	a.whenContentsLoaded(func(err error) {
+		if err != nil || !sameContainer(a.contentSource, view.container) {
+			return
+		}
+		if view.prefix == a.listPrefix {
+			a.applySessionView(view)
+			return
+		}
+		a.setListPrefix(view.prefix)
+		a.whenContentsLoaded(func(err error) {
+			if err == nil {
+				a.applySessionView(view)
+			}
+		})
+	})
+}
+
+func (a *App) applySessionView(view sessionView) {
+	if view.folderView && !a.folderView {
+		a.folderView = true
+		a.loadFolderStats(a.contentSource)
+	}
+	if a.folderView {
+		a.folderPrefix = view.folder
+	}
+	filter, err := a.compileFilterFields(view.filter)
+	if err != nil {
+		a.flashErr(fmt.Sprintf("Session filter: %v", err))
+	} else {
+		a.contentsFilter = filter
+	}
+	a.renderContents(false)
+	a.restoreContentsPosition(contentsPosition{
+		container: view.container,
+		prefix:    a.folderPrefix,
+		selected:  itemRef{Kind: kindBlob, Name: view.blob},
+	})
+}
+
+// saveSession writes the expanded nodes, the current location, and how it is
+// listed for the next launch of the profile.
+func (a *App) saveSession() error {
+	if a.sessionPath == "" {
+		return nil
+	}
+	tree := a.saveTreeState()
+	var saved session.State
+	for _, ref := range tree.expanded {
+		saved.Expanded = append(saved.Expanded, sessionNode(ref))
+	}
+	if _, ok := sessionKinds[tree.current.Kind]; ok {
+		current := sessionNode(tree.current)
+		saved.Current = &current
+	}
+	if tree.current.Kind == kindContainer && sameContainer(a.contentSource, tree.current) {
+		row, _ := a.contents.GetSelection()
+		if ref, ok := a.contentRef(row); ok && ref.Kind == kindBlob {
+			saved.Blob = ref.Name
+		}
+		saved.Prefix = a.listPrefix
+		saved.Folder = a.folderPrefix
+	}
+	saved.FolderView = a.folderView
+	if a.contentsFilter != nil {
+		saved.Filter = savedFilter(a.contentsFilter.fieldValues())
+	}
+	if err := session.Save(a.sessionPath, saved); err != nil {
+		return fmt.Errorf("save session: %w", err)
+	}
+	return nil
+}
+
+func sessionNode(ref itemRef) session.Node {
+	return session.Node{
+		Kind:           sessionKinds[ref.Kind],
+		Name:           ref.Name,
+		SubscriptionID: ref.SubscriptionID,
+		Account:        ref.Account,
+		Container:      ref.Container,
+	}
+}
+
+func refFromSession(node session.Node) (itemRef, bool) {
+	for kind, name := range sessionKinds {
+		if name == node.Kind {
+			return itemRef{
+				Kind:           kind,
+				Name:           node.Name,
+				SubscriptionID: node.SubscriptionID,
+				Account:        node.Account,
+				Container:      node.Container,
+			}, true
+		}
+	}
+	return itemRef{}, false
+}
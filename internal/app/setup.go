@@ -0,0 +1,170 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// SetupChoices are the answers of the first-run setup wizard.
+type SetupChoices struct {
+	Provider string
+	// Profile holds the sign-in and the subscriptions to show, saved as the
+	// profile to start with.
+	Profile Profile
+	Theme   string
+}
+
+// setupProfileName names the profile the wizard saves.
+const setupProfileName = "default"
+
+var (
+	setupClouds      = []string{"AzurePublicCloud", "AzureUSGovernment", "AzureChinaCloud"}
+	setupCredentials = []string{"cli", "env", "managed-identity"}
+)
+
+// setupSteps titles the pages of the wizard, in order.
+var setupSteps = []string{"Provider", "Sign-in", "Subscriptions", "Theme"}
+
+func (a *App) setupSetupWizard() {
+	form := tview.NewForm()
+	form.SetBorder(true)
+	form.SetButtonsAlign(tview.AlignRight)
+	form.SetCancelFunc(a.skipSetup)
+	a.setupForm = form
+}
+
+// openPendingSetup opens the wizard the first time the tree has loaded, or
+// failed to, so the subscriptions step can list what the tree found.
+func (a *App) openPendingSetup() {
+	if !a.setupPending {
+		return
+	}
+	a.setupPending = false
+	a.openSetupWizard()
+}
+
+// openSetupWizard walks through the settings a new config file needs, one
+// page at a time, starting from what the app runs with now.
+func (a *App) openSetupWizard() {
+	provider := ""
+	if len(a.setupProviders) > 0 {
+		provider = a.setupProviders[0]
+	}
+	profile := a.profile
+	profile.Name = setupProfileName
+	if profile.Cloud == "" {
+		profile.Cloud = setupClouds[0]
+	}
+	if profile.Credentials == "" {
+		profile.Credentials = setupCredentials[0]
+	}
+	a.setupChoices = SetupChoices{Provider: provider, Profile: profile, Theme: a.theme.name}
+	a.setupStartTheme = a.theme
+	a.setupOpen = true
+	a.showSetupStep(0)
+}
+
+func (a *App) showSetupStep(step int) {
+	form := a.setupForm
+	form.Clear(true)
+	form.SetTitle(fmt.Sprintf("Setup %d/%d: %s", step+1, len(setupSteps), setupSteps[step]))
+	choices := &a.setupChoices
+	height := 7
+
+	switch setupSteps[step] {
+	case "Provider":
+		form.AddDropDown("Provider: ", a.setupProviders, max(slices.Index(a.setupProviders, choices.Provider), 0), func(option string, _ int) {
+			choices.Provider = option
+		})
+	case "Sign-in":
+		form.AddInputField("Tenant: ", choices.Profile.Tenant, 0, nil, func(text string) {
+			choices.Profile.Tenant = strings.TrimSpace(text)
+		})
+		form.AddDropDown("Cloud: ", setupClouds, max(slices.Index(setupClouds, choices.Profile.Cloud), 0), func(option string, _ int) {
+			choices.Profile.Cloud = option
+		})
+		form.AddDropDown("Credentials: ", setupCredentials, max(slices.Index(setupCredentials, choices.Profile.Credentials), 0), func(option string, _ int) {
+			choices.Profile.Credentials = option
+		})
+		height = 11
+	case "Subscriptions":
+		// The tree lists every subscription the credentials can see; none
+		// checked shows them all.
+		form.SetTitle(form.GetTitle() + " to show (none checked: all)")
+		count := 0
+		for _, node := range a.root.GetChildren() {
+			ref, ok := node.GetReference().(itemRef)
+			if !ok || ref.Kind != kindSubscription {
+				continue
+			}
+			count++
+			form.AddCheckbox(ref.SubscriptionName+" ", slices.Contains(choices.Profile.Subscriptions, ref.SubscriptionID), func(checked bool) {
+				choices.Profile.Subscriptions = slices.DeleteFunc(choices.Profile.Subscriptions, func(id string) bool {
+					return id == ref.SubscriptionID
+				})
+				if checked {
+					choices.Profile.Subscriptions = append(choices.Profile.Subscriptions, ref.SubscriptionID)
+				}
+			})
+		}
+		if count == 0 {
+			form.AddTextView("", "No subscriptions are listed; every one will be shown.", 0, 1, true, false)
+			count = 1
+		}
+		height = 5 + 2*count
+	case "Theme":
+		// The theme is tried out as it is picked.
+		names := themeNames()
+		form.AddDropDown("Theme: ", names, max(slices.Index(names, choices.Theme), 0), func(option string, _ int) {
+			choices.Theme = option
+			if t, ok := themeByName(option); ok && t.name != a.theme.name {
+				a.applyTheme(t)
+			}
+		})
+	}
+
+	if step > 0 {
+		form.AddButton("Back", func() { a.showSetupStep(step - 1) })
+	}
+	if step < len(setupSteps)-1 {
+		form.AddButton("Next", func() { a.showSetupStep(step + 1) })
+	} else {
+		form.AddButton("Save", a.finishSetup)
+	}
+	form.AddButton("Skip", a.skipSetup)
+
+	a.pages.AddPage("setup", centerModal(form, height, 76), true, true)
+	a.app.SetFocus(form)
+}
+
+func (a *App) closeSetupWizard() {
+	a.pages.HidePage("setup")
+	a.setupOpen = false
+	a.setActivePane(a.activePane)
+}
+
+// skipSetup closes the wizard without writing the config file, so it opens
+// again next time.
+func (a *App) skipSetup() {
+	a.closeSetupWizard()
+	if a.theme.name != a.setupStartTheme.name {
+		a.applyTheme(a.setupStartTheme)
+	}
+	a.flash("Setup skipped; it opens again until a config file exists.")
+}
+
+// finishSetup saves the choices and switches to the profile they make.
+func (a *App) finishSetup() {
+	choices := a.setupChoices
+	if err := a.saveSetup(choices); err != nil {
+		a.flashErr(fmt.Sprintf("Setup: %v", err))
+		return
+	}
+	a.closeSetupWizard()
+	a.profiles = slices.DeleteFunc(a.profiles, func(p Profile) bool { return p.Name == choices.Profile.Name })
+	a.profiles = append(a.profiles, choices.Profile)
+	a.switchProfile(choices.Profile)
+}
@@ -0,0 +1,142 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// toggleSplit pins the listed container into a second contents table so it
+// can be compared with whatever the primary table shows next.
+func (a *App) toggleSplit() {
+	if a.splitOpen {
+		a.closeSplit()
+		return
+	}
+	if a.contentSource.Kind != kindContainer {
+		a.flashErr("Select a container before opening the split view.")
+		return
+	}
+
+	a.splitOpen = true
+	a.compareSource = a.contentSource
+	a.compareRefs = nil
+	a.loadingCompare = true
+	a.compare.Clear()
+	for _, ref := range a.listedBlobs {
+		a.addCompareRow(ref, a.itemLabel(ref), a.formatContentDetails(ref))
+	}
+	a.compare.Select(0, 0)
+	a.loadingCompare = false
+	a.setPaneTitle(paneCompare, fmt.Sprintf("Compare: %s/%s", a.compareSource.Account, a.compareSource.Name))
+	a.contentsRow.AddItem(a.compare, 0, 1, false)
+	a.markDifferences()
+	a.refreshStatus()
+}
+
+func (a *App) closeSplit() {
+	a.splitOpen = false
+	a.contentsRow.RemoveItem(a.compare)
+	a.compare.Clear()
+	a.compareRefs = nil
+	a.compareSource = itemRef{}
+	a.markDifferences()
+	if a.activePane == paneCompare {
+		a.setActivePane(paneContents)
+	}
+	a.refreshStatus()
+}
+
+func (a *App) addCompareRow(ref itemRef, name, details string) {
+	row := len(a.compareRefs)
+	a.compareRefs = append(a.compareRefs, ref)
+	nameCell := tview.NewTableCell(name).SetExpansion(1)
+	detailCell := tview.NewTableCell(details).SetAlign(tview.AlignRight)
+	a.compare.SetCell(row, 0, nameCell)
+	a.compare.SetCell(row, 1, detailCell)
+}
+
+func (a *App) compareRef(row int) (itemRef, bool) {
+	if row < 0 || row >= len(a.compareRefs) {
+		return itemRef{}, false
+	}
+	return a.compareRefs[row], true
+}
+
+func (a *App) onCompareChanged(row int) {
+	ref, ok := a.compareRef(row)
+	if !ok {
+		return
+	}
+	a.updatePreview(ref)
+	if a.activePane == paneCompare {
+		a.updateDetails(ref)
+	}
+}
+
+func (a *App) onCompareSelected(row int) {
+	ref, ok := a.compareRef(row)
+	if !ok {
+		return
+	}
+	if ref.Kind == kindBlob {
+		a.setActivePane(paneCompare)
+	}
+}
+
+// markDifferences colors blob rows by family and dims stale ones. In the
+// split view, blob names additionally use the theme's added color when the
+// blob only exists on that side and its changed color when it exists on both
+// sides with a different size or modification time. Watch mode marks are
+// drawn on top.
+func (a *App) markDifferences() {
+	if !a.splitOpen || a.contentSource.Kind != kindContainer {
+		colorDifferences(a.contents, a.contentRefs, nil, a.theme)
+		colorDifferences(a.compare, a.compareRefs, nil, a.theme)
+	} else {
+		colorDifferences(a.contents, a.contentRefs, blobsByName(a.compareRefs), a.theme)
+		colorDifferences(a.compare, a.compareRefs, blobsByName(a.listedBlobs), a.theme)
+	}
+	a.markWatchChanges()
+}
+
+func blobsByName(refs []itemRef) map[string]itemRef {
+	byName := make(map[string]itemRef, len(refs))
+	for _, ref := range refs {
+		if ref.Kind == kindBlob {
+			byName[ref.Name] = ref
+		}
+	}
+	return byName
+}
+
+func colorDifferences(table *tview.Table, refs []itemRef, other map[string]itemRef, t theme) {
+	for row, ref := range refs {
+		cell := table.GetCell(row, 0)
+		if cell == nil {
+			continue
+		}
+		color := t.text
+		var attributes tcell.AttrMask
+		if ref.Kind == kindBlob {
+			color = t.blobColor(classifyBlob(ref))
+			if t.stale(ref.Modified) {
+				attributes = tcell.AttrDim
+			}
+		}
+		if other != nil && ref.Kind == kindBlob {
+			match, ok := other[ref.Name]
+			switch {
+			case !ok:
+				color = t.added
+			case match.SizeBytes != ref.SizeBytes || !match.Modified.Equal(ref.Modified):
+				color = t.changed
+			}
+		}
+		cell.SetTextColor(color).SetAttributes(attributes)
+		if details := table.GetCell(row, 1); details != nil {
+			details.SetAttributes(attributes)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"storage-tui/internal/azure"
+	"storage-tui/internal/pricing"
+)
+
+// topLevel names the blobs of a container outside any top-level prefix in
+// its statistics.
+const topLevel = "(top level)"
+
+// containerStats is what counting a container's blobs has found so far.
+type containerStats struct {
+	statsCounts
+	running bool
+	outcome string
+	// region and sku price the blobs.
+	region string
+	sku    string
+}
+
+// statsCounts are the blob counts, bytes, and estimated monthly costs of a
+// container, in total, per top-level prefix, and per tier.
+type statsCounts struct {
+	total    folderStat
+	cost     float64
+	prefixes map[string]folderStat
+	costs    map[string]float64
+	tiers    map[string]folderStat
+	// unlisted is set when some blobs were priced with default prices.
+	unlisted bool
+}
+
+func newStatsCounts() statsCounts {
+	return statsCounts{prefixes: make(map[string]folderStat), costs: make(map[string]float64), tiers: make(map[string]folderStat)}
+}
+
+func (c *statsCounts) add(blob azure.Blob, rate float64) {
+	prefix := topLevel
+	if index := strings.Index(blob.Name, "/"); index >= 0 {
+		prefix = blob.Name[:index+1]
+	}
+	tier := blob.AccessTier
+	if tier == "" {
+		tier = "Hot"
+	}
+	cost := pricing.MonthlyCost(rate, blob.SizeBytes)
+	addStat(c.prefixes, prefix, blob.SizeBytes)
+	addStat(c.tiers, tier, blob.SizeBytes)
+	c.costs[prefix] += cost
+	c.total.Count++
+	c.total.Bytes += blob.SizeBytes
+	c.cost += cost
+}
+
+func addStat(stats map[string]folderStat, key string, bytes int64) {
+	stat := stats[key]
+	stat.Count++
+	stat.Bytes += bytes
+	stats[key] = stat
+}
+
+func (c statsCounts) clone() statsCounts {
+	c.prefixes, c.costs, c.tiers = maps.Clone(c.prefixes), maps.Clone(c.costs), maps.Clone(c.tiers)
+	return c
+}
+
+func (a *App) setupStatsModal() {
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetWrap(false)
+	view.SetBorder(true)
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc && a.stats.running:
+			a.statsCancel()
+			return nil
+		case event.Key() == tcell.KeyEsc || event.Rune() == 'I' || event.Rune() == 'q':
+			a.closeStatsModal()
+			return nil
+		}
+		return event
+	})
+
+	a.statsView = view
+	a.pages.AddPage("stats", centerModal(view, 26, 80), true, false)
+}
+
+// openStatsModal counts the blobs and bytes of the selected container, or
+// the listed one, in total, per top-level prefix, and per tier, and estimates
+// what storing them costs a month at the list prices of the account's region
+// and replication. The container is listed in full in the background and the
+// counts grow as its pages arrive; esc stops the count, keeping what it
+// found.
+func (a *App) openStatsModal() {
+	container, ok := a.selectedRef()
+	if (!ok || container.Kind != kindContainer) && a.activePane != paneAccounts {
+		container = a.contentSource
+	}
+	if container.Kind != kindContainer {
+		a.flashErr("Select a container to count its blobs.")
+		return
+	}
+
+	a.statsSeq++
+	seq := a.statsSeq
+	ctx, cancel := context.WithCancel(context.Background())
+	a.statsCancel = cancel
+	a.statsSource = container
+	account := a.accountInfo[container.Account]
+	region, sku := account.Region, account.SKU
+	a.stats = containerStats{statsCounts: newStatsCounts(), running: true, region: region, sku: sku}
+	a.statsOpen = true
+	a.renderStats()
+	a.pages.ShowPage("stats")
+	a.app.SetFocus(a.statsView)
+
+	go func() {
+		counts := newStatsCounts()
+		rates := make(map[string]float64)
+		marker := ""
+		var err error
+		for {
+			var page azure.BlobPage
+			page, err = a.provider.ListBlobsPage(ctx, container.Account, container.Container, azure.ListBlobsOptions{
+				Marker:     marker,
+				MaxResults: blobPageSize,
+			})
+			if err != nil {
+				break
+			}
+			for _, blob := range page.Blobs {
+				rate, ok := rates[blob.AccessTier]
+				if !ok {
+					var listed bool
+					rate, listed = pricing.Rate(region, sku, blob.AccessTier)
+					rates[blob.AccessTier] = rate
+					counts.unlisted = counts.unlisted || !listed
+				}
+				counts.add(blob, rate)
+			}
+			if page.NextMarker == "" {
+				break
+			}
+			marker = page.NextMarker
+			snapshot := counts.clone()
+			a.queueUpdate(func() {
+				if seq == a.statsSeq {
+					a.stats.statsCounts = snapshot
+					a.renderStats()
+				}
+			})
+		}
+		a.app.QueueUpdateDraw(func() {
+			if seq != a.statsSeq {
+				return
+			}
+			a.stats.statsCounts, a.stats.running = counts, false
+			switch {
+			case errors.Is(err, context.Canceled):
+				a.stats.outcome = "stopped"
+			case err != nil:
+				a.stats.outcome = fmt.Sprintf("error: %v", err)
+			}
+			a.renderStats()
+		})
+	}()
+}
+
+// closeStatsModal closes the statistics, stopping a count still running.
+func (a *App) closeStatsModal() {
+	if a.statsCancel != nil {
+		a.statsCancel()
+	}
+	a.statsSeq++
+	a.pages.HidePage("stats")
+	a.statsOpen = false
+	a.setActivePane(a.activePane)
+}
+
+func (a *App) renderStats() {
+	stats := a.stats
+	status := "done"
+	keys := "esc: close"
+	switch {
+	case stats.running:
+		status, keys = "counting…", "esc: stop"
+	case stats.outcome != "":
+		status = stats.outcome
+	}
+	a.statsView.SetTitle(fmt.Sprintf("Statistics: %s/%s  %s | %s", a.statsSource.Account, a.statsSource.Container, status, keys))
+
+	prefixes := make([]string, 0, len(stats.prefixes))
+	width := len("Prefix")
+	for prefix := range stats.prefixes {
+		prefixes = append(prefixes, prefix)
+		width = max(width, len(prefix))
+	}
+	// The largest prefixes come first.
+	sort.Slice(prefixes, func(i, j int) bool {
+		left, right := stats.prefixes[prefixes[i]], stats.prefixes[prefixes[j]]
+		if left.Bytes != right.Bytes {
+			return left.Bytes > right.Bytes
+		}
+		return prefixes[i] < prefixes[j]
+	})
+
+	var builder strings.Builder
+	accent := colorTag(a.theme.accent)
+	fmt.Fprintf(&builder, "%sBlobs     [-] %s\n", accent, groupDigits(int64(stats.total.Count)))
+	fmt.Fprintf(&builder, "%sTotal size[-] %s\n", accent, a.formatSize(stats.total.Bytes))
+	fmt.Fprintf(&builder, "%sEst. cost [-] %s a month\n\n", accent, formatCost(stats.cost))
+	if len(prefixes) == 0 {
+		if !stats.running {
+			fmt.Fprintf(&builder, "%sNo blobs.[-]\n", colorTag(a.theme.muted))
+		}
+		a.statsView.SetText(builder.String())
+		return
+	}
+	fmt.Fprintf(&builder, "[::b]%-*s  %10s  %12s  %6s  %11s[::-]\n", width, "Prefix", "Blobs", "Size", "Share", "Est./month")
+	for _, prefix := range prefixes {
+		stat := stats.prefixes[prefix]
+		fmt.Fprintf(&builder, "%s  %10s  %12s  %6s  %11s\n", tview.Escape(fmt.Sprintf("%-*s", width, prefix)),
+			groupDigits(int64(stat.Count)), a.formatSize(stat.Bytes), share(stat.Bytes, stats.total.Bytes), formatCost(stats.costs[prefix]))
+	}
+
+	fmt.Fprintf(&builder, "\n[::b]%-*s  %10s  %12s  %6s  %11s[::-]\n", width, "Tier", "Blobs", "Size", "Share", "Est./month")
+	for _, tier := range []string{"Hot", "Cool", "Cold", "Archive"} {
+		stat, ok := stats.tiers[tier]
+		if !ok {
+			continue
+		}
+		rate, _ := pricing.Rate(stats.region, stats.sku, tier)
+		fmt.Fprintf(&builder, "%-*s  %10s  %12s  %6s  %11s\n", width, tier,
+			groupDigits(int64(stat.Count)), a.formatSize(stat.Bytes), share(stat.Bytes, stats.total.Bytes), formatCost(pricing.MonthlyCost(rate, stat.Bytes)))
+	}
+	note := fmt.Sprintf("Estimated at approximate US dollar list prices for %s, %s.", orNA(stats.region), orNA(stats.sku))
+	if stats.unlisted {
+		note += fmt.Sprintf(" The price table lacks some of these; %s, Hot, or locally redundant prices stand in.", pricing.DefaultRegion)
+	}
+	fmt.Fprintf(&builder, "\n%s%s[-]\n", colorTag(a.theme.muted), tview.Escape(note))
+	a.statsView.SetText(builder.String())
+}
+
+// share formats part as a percentage of total.
+func share(part, total int64) string {
+	if total <= 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(part)/float64(total)*100)
+}
+
+// formatCost formats US dollars to the cent, with costs below a cent shown
+// as such rather than as nothing.
+func formatCost(dollars float64) string {
+	if dollars > 0 && dollars < 0.005 {
+		return "<$0.01"
+	}
+	return fmt.Sprintf("$%.2f", dollars)
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+const flashDuration = 4 * time.Second
+
+// identityProvider is implemented by providers that can describe the
+// credentials they are using.
+type identityProvider interface {
+	Identity() string
+}
+
+func newStatusBar() (*tview.Flex, *tview.TextView, *tview.TextView) {
+	hints := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(false)
+	info := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(false).
+		SetTextAlign(tview.AlignRight)
+	bar := tview.NewFlex().
+		AddItem(hints, 0, 3, false).
+		AddItem(info, 0, 2, false)
+	return bar, hints, info
+}
+
+// refreshStatus redraws the hint and info sides of the status bar for the
+// active pane.
+func (a *App) refreshStatus() {
+	if a.statusHints == nil {
+		return
+	}
+
+	if a.demoCaption != "" {
+		// The demo shows what it is doing where the keys doing it are hinted.
+		a.statusHints.SetText(fmt.Sprintf("%s%s[-]", colorTag(a.theme.accent), tview.Escape(a.demoCaption)))
+	} else {
+		var hints []string
+		for _, b := range a.paneHints(a.activePane) {
+			hints = append(hints, fmt.Sprintf("%s%s[-] %s", colorTag(a.theme.accent), tview.Escape(b.label), b.help))
+		}
+		a.statusHints.SetText(strings.Join(hints, "  "))
+	}
+
+	if a.flashText != "" {
+		color := a.theme.success
+		if a.flashError {
+			color = a.theme.failure
+		}
+		a.statusInfo.SetText(fmt.Sprintf("%s%s[-]", colorTag(color), tview.Escape(a.flashText)))
+		return
+	}
+
+	var info []string
+	if time.Now().Before(a.throttledUntil) {
+		info = append(info, fmt.Sprintf("%sthrottled, retrying[-]", colorTag(a.theme.changed)))
+	}
+	if a.failuresUnseen > 0 {
+		info = append(info, fmt.Sprintf("%s%d new failures (!)[-]", colorTag(a.theme.failure), a.failuresUnseen))
+	}
+	if running := a.runningSyncJobs(); running > 0 {
+		info = append(info, fmt.Sprintf("%ssyncing %d jobs (J)[-]", colorTag(a.theme.changed), running))
+	}
+	if a.previewSearchable && a.previewSearch != "" {
+		info = append(info, fmt.Sprintf("filter: %s", tview.Escape(a.previewSearchLabel())))
+	}
+	if a.splitOpen {
+		info = append(info, fmt.Sprintf("split: %s/%s", a.compareSource.Account, a.compareSource.Name))
+	}
+	switch {
+	case a.watchPush:
+		info = append(info, "watch: events")
+	case a.watchStop != nil:
+		info = append(info, fmt.Sprintf("watch: %s", a.watchInterval))
+	}
+	if a.usage != nil {
+		info = append(info, "usage metrics on (U)")
+	}
+	if a.profile.Name != "" {
+		info = append(info, fmt.Sprintf("profile: %s", tview.Escape(a.profile.Name)))
+	}
+	info = append(info, fmt.Sprintf("identity: %s", tview.Escape(a.identity())))
+	a.statusInfo.SetText(strings.Join(info, " | "))
+}
+
+func (a *App) identity() string {
+	if provider, ok := a.provider.(identityProvider); ok {
+		return provider.Identity()
+	}
+	return "unknown"
+}
+
+// flash shows a transient message in the status bar that clears itself after
+// flashDuration unless a newer message replaced it.
+func (a *App) flash(message string) {
+	a.showFlash(message, false)
+}
+
+func (a *App) flashErr(message string) {
+	a.showFlash(message, true)
+}
+
+// showFlash also logs the message, so errors stay readable in the log viewer
+// after the status bar clears.
+func (a *App) showFlash(message string, isError bool) {
+	if isError {
+		a.log.Error(message)
+	} else {
+		a.log.Info(message)
+	}
+	a.flashSeq++
+	seq := a.flashSeq
+	a.flashText = message
+	a.flashError = isError
+	a.refreshStatus()
+
+	time.AfterFunc(flashDuration, func() {
+		a.app.QueueUpdateDraw(func() {
+			if a.flashSeq != seq {
+				return
+			}
+			a.flashText = ""
+			a.refreshStatus()
+		})
+	})
+}
@@ -0,0 +1,391 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"storage-tui/internal/dirsync"
+	"storage-tui/internal/syncjobs"
+)
+
+// syncPlanShown caps the actions the plan view lists; an export has them
+// all.
+const syncPlanShown = 500
+
+// startSyncJobs runs the sync jobs of the config file on their schedules
+// until the browser quits.
+func (a *App) startSyncJobs(jobs []syncjobs.Job) {
+	if len(jobs) == 0 {
+		return
+	}
+	// Runs reach storage through the switchable provider, so they follow
+	// the profile the browser is switched to.
+	a.syncJobs = syncjobs.New(a.provider, jobs, syncjobs.Options{
+		Audit: a.audit,
+		Notify: func(run syncjobs.Run) {
+			a.queueUpdate(func() { a.onSyncRun(run) })
+		},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	a.syncJobsCancel = cancel
+	a.syncJobs.Start(ctx)
+}
+
+func (a *App) stopSyncJobs() {
+	if a.syncJobsCancel != nil {
+		a.syncJobsCancel()
+	}
+}
+
+// onSyncRun keeps a failed run for the failures page, and updates the jobs
+// page and the status bar as runs start and end.
+func (a *App) onSyncRun(run syncjobs.Run) {
+	if !run.Running() {
+		a.log.Info("sync job ran", "job", run.Job, "took", run.Finished.Sub(run.Started), "summary", run.Summary, "error", run.Err)
+		if run.Err != nil {
+			a.recordFailure("sync job", run.Job, run.Err, func() { a.runSyncJob(run.Job) })
+			a.flashErr(fmt.Sprintf("Sync job %s failed: %v", run.Job, run.Err))
+		}
+		if changed := run.Changed; len(changed.Replaced)+len(changed.Deleted) > 0 {
+			a.offerSyncUndo(run)
+		}
+	}
+	if a.syncJobsOpen {
+		a.renderSyncJobs()
+	}
+	a.refreshStatus()
+}
+
+// offerSyncUndo offers to undo what a sync job's run wrote over or deleted
+// in its container.
+func (a *App) offerSyncUndo(run syncjobs.Run) {
+	for _, status := range a.syncJobs.Statuses() {
+		if status.Job.Name != run.Job {
+			continue
+		}
+		opts, err := status.Job.Options()
+		if err != nil {
+			return
+		}
+		a.offerUndo(undoable{
+			what:      "sync job " + run.Job,
+			account:   opts.Account,
+			container: opts.Container,
+			replaced:  run.Changed.Replaced,
+			deleted:   run.Changed.Deleted,
+		})
+	}
+}
+
+// runningSyncJobs counts the jobs running now.
+func (a *App) runningSyncJobs() int {
+	if a.syncJobs == nil {
+		return 0
+	}
+	running := 0
+	for _, status := range a.syncJobs.Statuses() {
+		if !status.Last.Started.IsZero() && status.Last.Running() {
+			running++
+		}
+	}
+	return running
+}
+
+func (a *App) setupSyncJobsModal() {
+	jobs := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	jobs.SetBorder(true).SetTitle("Jobs")
+	runs := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	runs.SetBorder(true).SetTitle("Runs")
+	jobs.SetSelectedFunc(func(row, _ int) {
+		a.runSelectedSyncJob(row - 1)
+	})
+
+	view := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(jobs, 0, 1, true).
+		AddItem(runs, 0, 2, false)
+	view.SetBorder(true).SetTitle("Sync jobs  enter/r: run now | d: dry run | p: last plan | tab: jobs or runs | esc: close")
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc || event.Rune() == 'J' || event.Rune() == 'q':
+			a.closeSyncJobsModal()
+			return nil
+		case event.Key() == tcell.KeyTab:
+			if jobs.HasFocus() {
+				a.app.SetFocus(runs)
+			} else {
+				a.app.SetFocus(jobs)
+			}
+			return nil
+		case event.Rune() == 'r' && jobs.HasFocus():
+			row, _ := jobs.GetSelection()
+			a.runSelectedSyncJob(row - 1)
+			return nil
+		case event.Rune() == 'd' && jobs.HasFocus():
+			row, _ := jobs.GetSelection()
+			a.dryRunSelectedSyncJob(row - 1)
+			return nil
+		case event.Rune() == 'p' && jobs.HasFocus():
+			row, _ := jobs.GetSelection()
+			a.showLastSyncPlan(row - 1)
+			return nil
+		}
+		return event
+	})
+
+	a.syncJobsView = view
+	a.syncJobsTable = jobs
+	a.syncRunsTable = runs
+	a.pages.AddPage("syncjobs", centerModal(view, 26, 120), true, false)
+	a.setupSyncPlanModal()
+}
+
+func (a *App) setupSyncPlanModal() {
+	text := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(false)
+	input := tview.NewInputField().
+		SetLabel("Export to: ").
+		SetFieldWidth(0)
+	view := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(text, 0, 1, false).
+		AddItem(input, 1, 0, true)
+	view.SetBorder(true)
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEnter:
+			a.exportSyncPlan(input.GetText())
+			return nil
+		case tcell.KeyEsc:
+			a.closeSyncPlanModal()
+			return nil
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn:
+			// The plan scrolls while the file name is typed.
+			text.InputHandler()(event, func(tview.Primitive) {})
+			return nil
+		}
+		return event
+	})
+
+	a.syncPlanView = view
+	a.syncPlanText = text
+	a.syncPlanInput = input
+	a.pages.AddPage("syncplan", centerModal(view, 30, 120), true, false)
+}
+
+// openSyncJobsModal lists the sync jobs with their last and next runs, and
+// below them the runs since launch, latest first.
+func (a *App) openSyncJobsModal() {
+	if a.syncJobs == nil {
+		a.flashErr("No sync jobs; add them under syncJobs in the config file.")
+		return
+	}
+	a.syncJobsOpen = true
+	a.renderSyncJobs()
+	a.pages.ShowPage("syncjobs")
+	a.app.SetFocus(a.syncJobsTable)
+}
+
+func (a *App) closeSyncJobsModal() {
+	a.pages.HidePage("syncjobs")
+	a.syncJobsOpen = false
+	a.setActivePane(a.activePane)
+}
+
+func (a *App) renderSyncJobs() {
+	statuses := a.syncJobs.Statuses()
+	table := a.syncJobsTable
+	row, _ := table.GetSelection()
+	table.Clear()
+	for column, title := range []string{"Job", "Direction", "Every", "Last run", "Result", "Next run"} {
+		table.SetCell(0, column, tview.NewTableCell(title).SetAttributes(tcell.AttrBold).SetSelectable(false))
+	}
+	for index, status := range statuses {
+		last, result, color := "never", "", a.theme.muted
+		next := a.formatTimestamp(status.Next)
+		switch {
+		case status.Last.Started.IsZero():
+		case status.Last.Running():
+			last, result, color = a.formatTimestamp(status.Last.Started), "running…", a.theme.changed
+			next = ""
+		case status.Last.Err != nil:
+			last, result, color = a.formatTimestamp(status.Last.Finished), status.Last.Err.Error(), a.theme.failure
+		default:
+			last, result, color = a.formatTimestamp(status.Last.Finished), status.Last.Summary, a.theme.success
+		}
+		job := status.Job
+		table.SetCell(index+1, 0, tview.NewTableCell(tview.Escape(job.Name)))
+		table.SetCell(index+1, 1, tview.NewTableCell(fmt.Sprintf("%s %s", job.Direction(), tview.Escape(job.Remote()))).SetMaxWidth(40))
+		table.SetCell(index+1, 2, tview.NewTableCell(job.Every.String()))
+		table.SetCell(index+1, 3, tview.NewTableCell(last))
+		table.SetCell(index+1, 4, tview.NewTableCell(tview.Escape(result)).SetTextColor(color).SetMaxWidth(40))
+		table.SetCell(index+1, 5, tview.NewTableCell(next))
+	}
+	table.Select(min(max(row, 1), len(statuses)), 0)
+
+	history := a.syncJobs.History()
+	runs := a.syncRunsTable
+	runs.Clear()
+	if len(history) == 0 {
+		runs.SetCell(0, 0, tview.NewTableCell("No run has finished yet.").SetSelectable(false))
+		return
+	}
+	for column, title := range []string{"Finished", "Job", "Took", "Result"} {
+		runs.SetCell(0, column, tview.NewTableCell(title).SetAttributes(tcell.AttrBold).SetSelectable(false))
+	}
+	for index := range history {
+		run := history[len(history)-1-index]
+		result, color := run.Summary, a.theme.success
+		if run.Err != nil {
+			result, color = run.Err.Error(), a.theme.failure
+		}
+		runs.SetCell(index+1, 0, tview.NewTableCell(a.formatTimestamp(run.Finished)))
+		runs.SetCell(index+1, 1, tview.NewTableCell(tview.Escape(run.Job)))
+		runs.SetCell(index+1, 2, tview.NewTableCell(run.Finished.Sub(run.Started).Round(time.Millisecond).String()))
+		runs.SetCell(index+1, 3, tview.NewTableCell(tview.Escape(result)).SetTextColor(color))
+	}
+}
+
+// runSelectedSyncJob runs the job on row index of the jobs table now.
+func (a *App) runSelectedSyncJob(index int) {
+	statuses := a.syncJobs.Statuses()
+	if index < 0 || index >= len(statuses) {
+		return
+	}
+	a.runSyncJob(statuses[index].Job.Name)
+}
+
+// runSyncJob runs the named job ahead of its schedule, unless it is running
+// already.
+func (a *App) runSyncJob(name string) {
+	if a.syncJobs == nil {
+		return
+	}
+	if !a.syncJobs.RunNow(name) {
+		a.flashErr(fmt.Sprintf("Sync job %s is running already.", name))
+		return
+	}
+	a.flash(fmt.Sprintf("Running sync job %s…", name))
+}
+
+// dryRunSelectedSyncJob plans the job on row index of the jobs table, as its
+// next run would, and shows the plan without carrying it out.
+func (a *App) dryRunSelectedSyncJob(index int) {
+	statuses := a.syncJobs.Statuses()
+	if index < 0 || index >= len(statuses) {
+		return
+	}
+	name := statuses[index].Job.Name
+	a.flash(fmt.Sprintf("Planning sync job %s…", name))
+	runAsync(a, a.activePane, func(ctx context.Context) (dirsync.Plan, error) {
+		return a.syncJobs.DryRun(ctx, name)
+	}, func(plan dirsync.Plan, err error) {
+		if err != nil {
+			a.recordFailure("sync job dry run", name, err, func() { a.dryRunSelectedSyncJob(index) })
+			a.flashErr(fmt.Sprintf("Planning sync job %s failed: %v", name, err))
+			return
+		}
+		a.flash(fmt.Sprintf("Sync job %s would do: %s", name, plan.Summary()))
+		if a.syncJobsOpen {
+			a.openSyncPlanModal(name, "dry run", plan)
+		}
+	})
+}
+
+// showLastSyncPlan shows what the last run of the job on row index of the
+// jobs table planned, which it carries out, or did.
+func (a *App) showLastSyncPlan(index int) {
+	statuses := a.syncJobs.Statuses()
+	if index < 0 || index >= len(statuses) {
+		return
+	}
+	status := statuses[index]
+	switch {
+	case status.Last.Started.IsZero():
+		a.flashErr(fmt.Sprintf("Sync job %s has not run yet; d plans it without running it.", status.Job.Name))
+	case status.Plan.Local == "":
+		a.flashErr(fmt.Sprintf("The last run of sync job %s made no plan.", status.Job.Name))
+	default:
+		a.openSyncPlanModal(status.Job.Name, "run of "+a.formatTimestamp(status.Last.Started), status.Plan)
+	}
+}
+
+// openSyncPlanModal lists the plan's actions over the jobs, with a file to
+// export them to.
+func (a *App) openSyncPlanModal(job, kind string, plan dirsync.Plan) {
+	a.syncPlan = plan
+	a.syncPlanOpen = true
+	a.syncPlanView.SetTitle(fmt.Sprintf("Plan of sync job %s, %s  enter: export | ↑↓: scroll | esc: close", job, kind))
+	a.syncPlanText.SetText(renderSyncPlan(plan)).ScrollToBeginning()
+	a.syncPlanInput.SetText(strings.NewReplacer("/", "-", " ", "-").Replace(job) + "-plan.csv")
+	a.pages.ShowPage("syncplan")
+	a.app.SetFocus(a.syncPlanInput)
+}
+
+func (a *App) closeSyncPlanModal() {
+	a.pages.HidePage("syncplan")
+	a.syncPlanOpen = false
+	a.app.SetFocus(a.syncJobsTable)
+}
+
+// renderSyncPlan describes what the plan syncs, sums it up, and lists its
+// actions as the sync command's -dry-run does, up to syncPlanShown of them.
+func renderSyncPlan(plan dirsync.Plan) string {
+	var builder strings.Builder
+	remote := tview.Escape(strings.TrimRight(fmt.Sprintf("az://%s/%s/%s", plan.Account, plan.Container, plan.Prefix), "/"))
+	if plan.Upload {
+		fmt.Fprintf(&builder, "Upload %s to %s", tview.Escape(plan.Local), remote)
+	} else {
+		fmt.Fprintf(&builder, "Download %s to %s", remote, tview.Escape(plan.Local))
+	}
+	if plan.Delete {
+		builder.WriteString(", deleting what the source lacks")
+	}
+	fmt.Fprintf(&builder, ".\n%s\n\n", tview.Escape(plan.Summary()))
+	out := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+	for i, action := range plan.Actions {
+		if i == syncPlanShown {
+			fmt.Fprintf(out, "… and %d more; export the plan to list them all.\n", len(plan.Actions)-i)
+			break
+		}
+		fmt.Fprintf(out, "%s\t%s\t%d\t%s\n", action.Kind, action.Reason, action.Size, tview.Escape(action.Name))
+	}
+	out.Flush()
+	return builder.String()
+}
+
+// exportSyncPlan writes the plan shown to path, as csv, json, or ndjson by
+// its extension.
+func (a *App) exportSyncPlan(path string) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		a.flashErr("Name the file to export the plan to.")
+		return
+	}
+	a.closeSyncPlanModal()
+	a.saveSyncPlan(a.syncPlan, path)
+}
+
+// saveSyncPlan writes plan to path in the background.
+func (a *App) saveSyncPlan(plan dirsync.Plan, path string) {
+	runAsync(a, a.activePane, func(context.Context) (struct{}, error) {
+		return struct{}{}, plan.Save(path)
+	}, func(_ struct{}, err error) {
+		if err != nil {
+			a.recordFailure("export sync plan", path, err, func() { a.saveSyncPlan(plan, path) })
+			a.flashErr(fmt.Sprintf("Exporting the plan failed: %v", err))
+			return
+		}
+		a.flash(fmt.Sprintf("Wrote the plan's %d actions to %s.", len(plan.Actions), filepath.Clean(path)))
+	})
+}
@@ -0,0 +1,208 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"storage-tui/internal/azure"
+)
+
+func (a *App) setupTagsModal() {
+	input := tview.NewInputField().
+		SetLabel("Tag expression: ").
+		SetFieldWidth(0).
+		SetPlaceholder(`"env" = 'prod' AND @container = 'logs'`)
+	form := tview.NewForm().
+		AddFormItem(input).
+		AddButton("Find", func() {
+			a.applyTagSearch(input.GetText())
+		}).
+		AddButton("Cancel", a.closeTagsModal)
+	form.SetBorder(true)
+	form.SetButtonsAlign(tview.AlignRight)
+
+	// Handled before the form sees them, which would otherwise move focus to
+	// the buttons after the modal closed.
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEnter:
+			a.applyTagSearch(input.GetText())
+			return nil
+		case tcell.KeyEsc:
+			a.closeTagsModal()
+			return nil
+		}
+		return event
+	})
+	form.SetCancelFunc(a.closeTagsModal)
+
+	a.tagsInput = input
+	a.tagsForm = form
+	a.pages.AddPage("tags", centerModal(form, 7, 76), true, false)
+}
+
+// openTagsModal asks for a tag expression to match across every container of
+// the selected account.
+func (a *App) openTagsModal() {
+	account, ok := a.tagSearchAccount()
+	if !ok {
+		a.flashErr("Select an account, container, or blob to find blobs by tags.")
+		return
+	}
+	a.tagsAccount = account
+	a.tagsOpen = true
+	a.tagsForm.SetTitle(fmt.Sprintf("Find blobs by tags in %s", account.Account))
+	a.tagsInput.SetText(a.tagsExpression)
+	a.pages.ShowPage("tags")
+	a.app.SetFocus(a.tagsInput)
+}
+
+func (a *App) closeTagsModal() {
+	a.pages.HidePage("tags")
+	a.tagsOpen = false
+	a.setActivePane(a.activePane)
+}
+
+// tagSearchAccount is the account of the selected tree node, or of what the
+// contents table lists.
+func (a *App) tagSearchAccount() (itemRef, bool) {
+	ref := a.contentSource
+	if a.activePane == paneAccounts {
+		node := a.accounts.GetCurrentNode()
+		if node == nil {
+			return itemRef{}, false
+		}
+		ref, _ = node.GetReference().(itemRef)
+	}
+	if ref.Account == "" {
+		return itemRef{}, false
+	}
+	return itemRef{
+		Kind:             kindAccount,
+		Name:             ref.Account,
+		SubscriptionID:   ref.SubscriptionID,
+		SubscriptionName: ref.SubscriptionName,
+		Account:          ref.Account,
+	}, true
+}
+
+func (a *App) applyTagSearch(expression string) {
+	if _, err := azure.ParseTagExpression(expression); err != nil {
+		a.flashErr(fmt.Sprintf("Tags: %v", err))
+		return
+	}
+	a.closeTagsModal()
+	a.tagsExpression = expression
+	a.showTagMatches(a.tagsAccount, expression)
+	a.setActivePane(paneContents)
+}
+
+// showTagMatches lists the account's blobs matching expression into the
+// contents table, with each blob's container in its own column.
+func (a *App) showTagMatches(account itemRef, expression string) {
+	a.cancelContentsLoad()
+	a.contentsSeq++
+	seq := a.contentsSeq
+	a.contentsLoading = true
+	a.contentsTarget = account
+	a.watchMarks = nil
+
+	a.loadingContents = true
+	a.contents.Clear()
+	a.contentRefs = nil
+	loadingRef := itemRef{Kind: kindNone, Name: "Finding blobs by tags…"}
+	a.addContentRow(loadingRef, loadingRef.Name, "")
+	a.contents.Select(0, 0)
+	a.loadingContents = false
+	a.setPaneTitle(paneContents, fmt.Sprintf("Tags: %s", account.Account))
+
+	runAsyncWith(a, a.contentsCtx, paneContents, func(ctx context.Context) ([]azure.TaggedBlob, error) {
+		return a.provider.FindBlobsByTags(ctx, account.Account, expression)
+	}, func(matches []azure.TaggedBlob, err error) {
+		if seq != a.contentsSeq {
+			return
+		}
+		a.contentsLoading = false
+		waiters := a.contentsWaiters
+		a.contentsWaiters = nil
+		run := a.recordSearch("tags", expression, account.Account)
+		if err != nil {
+			a.recordFailure("find blobs by tags", refTarget(account), err, func() { a.showTagMatches(account, expression) })
+			a.showLoadError("blobs by tags", err)
+			a.finishSearch(run, fmt.Sprintf("error: %v", err))
+		} else {
+			a.fillTagMatches(account, matches)
+			for _, ref := range a.listedBlobs {
+				a.addSearchResults(run, searchResult{ref: ref, where: ref.Container, detail: ref.Tags})
+			}
+			a.finishSearch(run, "done")
+		}
+		for _, waiter := range waiters {
+			waiter(err)
+		}
+	})
+}
+
+func (a *App) fillTagMatches(account itemRef, matches []azure.TaggedBlob) {
+	a.contentSource = account
+	a.contentsMarker = ""
+	a.listedBlobs = nil
+	for _, match := range matches {
+		a.listedBlobs = append(a.listedBlobs, itemRef{
+			Kind:             kindBlob,
+			Name:             match.Name,
+			SubscriptionID:   account.SubscriptionID,
+			SubscriptionName: account.SubscriptionName,
+			Account:          account.Account,
+			Container:        match.Container,
+			Tags:             formatTags(match.Tags),
+		})
+	}
+	a.setPreviewContent("Select a blob to preview.", false)
+	a.renderContents(false)
+}
+
+// addTagMatchRows adds one row per matched blob: name, container, and the
+// tags the expression matched.
+func (a *App) addTagMatchRows() {
+	for _, ref := range a.listedBlobs {
+		row := len(a.contentRefs)
+		a.addContentRow(ref, a.itemLabel(ref), ref.Container)
+		a.contents.GetCell(row, 1).SetAlign(tview.AlignLeft)
+		a.contents.SetCell(row, 2, tview.NewTableCell(tview.Escape(ref.Tags)).SetAlign(tview.AlignRight))
+	}
+	if len(a.listedBlobs) == 0 {
+		ref := itemRef{Kind: kindNone, Name: "No blobs match the tag expression."}
+		a.addContentRow(ref, ref.Name, "")
+	}
+}
+
+// openTagMatch lists the matched blob's container and selects the blob, which
+// brings its properties and preview.
+func (a *App) openTagMatch(ref itemRef) {
+	loc := location{SubscriptionID: ref.SubscriptionID, Account: ref.Account, Container: ref.Container, Blob: ref.Name}
+	a.revealLocation(loc, func(err error) {
+		if err != nil {
+			a.flashErr(fmt.Sprintf("Tags: %v", err))
+		}
+	})
+}
+
+// formatTags renders tags as key=value pairs sorted by key.
+func formatTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+tags[key])
+	}
+	return strings.Join(pairs, ", ")
+}
@@ -0,0 +1,390 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// theme holds every color the UI draws with.
+type theme struct {
+	name        string
+	background  tcell.Color
+	text        tcell.Color
+	muted       tcell.Color
+	border      tcell.Color
+	focusBorder tcell.Color
+	title       tcell.Color
+	selectionFg tcell.Color
+	selectionBg tcell.Color
+	highlightFg tcell.Color
+	highlightBg tcell.Color
+	accent      tcell.Color
+	added       tcell.Color
+	changed     tcell.Color
+	success     tcell.Color
+	failure     tcell.Color
+	// Blob names are colored by family; other blobs use text.
+	imageFile   tcell.Color
+	textFile    tcell.Color
+	archiveFile tcell.Color
+	dataFile    tcell.Color
+	// staleAfter dims blobs not modified for this long; zero disables it.
+	staleAfter time.Duration
+}
+
+const defaultThemeName = "dark"
+
+var themes = []theme{
+	{
+		name:        "dark",
+		background:  tcell.ColorBlack,
+		text:        tcell.ColorWhite,
+		muted:       tcell.ColorGray,
+		border:      tcell.ColorWhite,
+		focusBorder: tcell.ColorYellow,
+		title:       tcell.ColorWhite,
+		selectionFg: tcell.ColorBlack,
+		selectionBg: tcell.ColorWhite,
+		highlightFg: tcell.ColorBlack,
+		highlightBg: tcell.ColorYellow,
+		accent:      tcell.ColorYellow,
+		added:       tcell.ColorGreen,
+		changed:     tcell.ColorYellow,
+		success:     tcell.ColorGreen,
+		failure:     tcell.ColorRed,
+		imageFile:   tcell.ColorFuchsia,
+		textFile:    tcell.ColorLightSteelBlue,
+		archiveFile: tcell.ColorOrange,
+		dataFile:    tcell.ColorAqua,
+		staleAfter:  365 * 24 * time.Hour,
+	},
+	{
+		name:        "light",
+		background:  tcell.ColorWhite,
+		text:        tcell.ColorBlack,
+		muted:       tcell.ColorDarkGray,
+		border:      tcell.ColorDarkGray,
+		focusBorder: tcell.ColorBlue,
+		title:       tcell.ColorBlack,
+		selectionFg: tcell.ColorWhite,
+		selectionBg: tcell.ColorBlue,
+		highlightFg: tcell.ColorBlack,
+		highlightBg: tcell.ColorLightGoldenrodYellow,
+		accent:      tcell.ColorBlue,
+		added:       tcell.ColorDarkGreen,
+		changed:     tcell.ColorDarkOrange,
+		success:     tcell.ColorDarkGreen,
+		failure:     tcell.ColorRed,
+		imageFile:   tcell.ColorPurple,
+		textFile:    tcell.ColorNavy,
+		archiveFile: tcell.ColorSaddleBrown,
+		dataFile:    tcell.ColorTeal,
+		staleAfter:  365 * 24 * time.Hour,
+	},
+	{
+		name:        "solarized",
+		background:  tcell.NewHexColor(0x002b36),
+		text:        tcell.NewHexColor(0x839496),
+		muted:       tcell.NewHexColor(0x586e75),
+		border:      tcell.NewHexColor(0x586e75),
+		focusBorder: tcell.NewHexColor(0x268bd2),
+		title:       tcell.NewHexColor(0x93a1a1),
+		selectionFg: tcell.NewHexColor(0x002b36),
+		selectionBg: tcell.NewHexColor(0x268bd2),
+		highlightFg: tcell.NewHexColor(0x002b36),
+		highlightBg: tcell.NewHexColor(0xb58900),
+		accent:      tcell.NewHexColor(0xb58900),
+		added:       tcell.NewHexColor(0x859900),
+		changed:     tcell.NewHexColor(0xcb4b16),
+		success:     tcell.NewHexColor(0x859900),
+		failure:     tcell.NewHexColor(0xdc322f),
+		imageFile:   tcell.NewHexColor(0xd33682),
+		textFile:    tcell.NewHexColor(0x268bd2),
+		archiveFile: tcell.NewHexColor(0xcb4b16),
+		dataFile:    tcell.NewHexColor(0x2aa198),
+		staleAfter:  365 * 24 * time.Hour,
+	},
+}
+
+func themeByName(name string) (theme, bool) {
+	for _, t := range themes {
+		if strings.EqualFold(t.name, name) {
+			return t, true
+		}
+	}
+	return themes[0], false
+}
+
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for _, t := range themes {
+		names = append(names, t.name)
+	}
+	return names
+}
+
+// colorTag formats a color as a tview dynamic color tag.
+func colorTag(color tcell.Color) string {
+	return fmt.Sprintf("[#%06x]", color.Hex())
+}
+
+// cycleTheme switches to the next built-in theme.
+func (a *App) cycleTheme() {
+	next := themes[0]
+	for i, t := range themes {
+		if t.name == a.theme.name {
+			next = themes[(i+1)%len(themes)]
+			break
+		}
+	}
+	a.applyTheme(next)
+	a.flash(fmt.Sprintf("Theme: %s", next.name))
+}
+
+// applyTheme restyles every widget. tview.Styles is updated as well so that
+// widgets and cells created afterwards pick up the theme.
+func (a *App) applyTheme(t theme) {
+	a.theme = t
+	tview.Styles.PrimitiveBackgroundColor = t.background
+	tview.Styles.ContrastBackgroundColor = t.selectionBg
+	tview.Styles.MoreContrastBackgroundColor = t.highlightBg
+	tview.Styles.BorderColor = t.border
+	tview.Styles.TitleColor = t.title
+	tview.Styles.GraphicsColor = t.muted
+	tview.Styles.PrimaryTextColor = t.text
+	tview.Styles.SecondaryTextColor = t.accent
+	tview.Styles.TertiaryTextColor = t.added
+	tview.Styles.InverseTextColor = t.selectionFg
+	tview.Styles.ContrastSecondaryTextColor = t.selectionFg
+
+	for _, box := range a.themedBoxes() {
+		box.SetBackgroundColor(t.background)
+		box.SetBorderColor(t.border)
+		box.SetTitleColor(t.title)
+	}
+	for _, view := range []*tview.TextView{a.preview, a.details, a.statusHints, a.statusInfo, a.helpView, a.propertiesView, a.logView, a.statsView} {
+		if view != nil {
+			view.SetTextColor(t.text)
+		}
+	}
+
+	a.accounts.SetGraphicsColor(t.muted)
+	a.root.Walk(func(node, _ *tview.TreeNode) bool {
+		a.styleTreeNode(node)
+		return true
+	})
+
+	for _, table := range []*tview.Table{a.contents, a.compare} {
+		table.SetSelectedStyle(tcell.StyleDefault.Foreground(t.selectionFg).Background(t.selectionBg))
+		restyleTable(table, t)
+	}
+	a.markDifferences()
+
+	for _, form := range []*tview.Form{a.searchForm, a.tagsForm, a.filterForm, a.saveSearchForm, a.prefixForm, a.pipeForm, a.exportForm, a.saveForm, a.iacForm, a.setupForm, a.lifecycleForm, a.corsForm, a.wormForm} {
+		if form != nil {
+			form.SetLabelColor(t.text).
+				SetFieldBackgroundColor(t.selectionBg).
+				SetFieldTextColor(t.selectionFg).
+				SetButtonBackgroundColor(t.selectionBg).
+				SetButtonTextColor(t.selectionFg)
+		}
+	}
+	for _, list := range []*tview.List{a.bookmarksList, a.searchesList, a.profilesList} {
+		if list != nil {
+			list.SetMainTextColor(t.text).
+				SetShortcutColor(t.accent).
+				SetSelectedTextColor(t.selectionFg).
+				SetSelectedBackgroundColor(t.selectionBg)
+		}
+	}
+	if a.findView != nil {
+		a.findInput.SetLabelColor(t.text).
+			SetFieldBackgroundColor(t.selectionBg).
+			SetFieldTextColor(t.selectionFg)
+		a.findResults.SetSelectedStyle(tcell.StyleDefault.Foreground(t.selectionFg).Background(t.selectionBg))
+		restyleTable(a.findResults, t)
+	}
+	if a.grepView != nil {
+		a.grepInput.SetLabelColor(t.text).
+			SetFieldBackgroundColor(t.selectionBg).
+			SetFieldTextColor(t.selectionFg)
+		a.grepResults.SetSelectedStyle(tcell.StyleDefault.Foreground(t.selectionFg).Background(t.selectionBg))
+		restyleTable(a.grepResults, t)
+	}
+	if a.historyView != nil {
+		for _, table := range []*tview.Table{a.historyRuns, a.historyResults} {
+			table.SetSelectedStyle(tcell.StyleDefault.Foreground(t.selectionFg).Background(t.selectionBg))
+			restyleTable(table, t)
+		}
+	}
+
+	a.lastPreview = ""
+	a.applyPreviewFilter()
+	a.updatePaneBorders()
+	a.refreshStatus()
+}
+
+func (a *App) themedBoxes() []*tview.Box {
+	boxes := []*tview.Box{
+		a.accounts.Box,
+		a.contents.Box,
+		a.compare.Box,
+		a.preview.Box,
+		a.details.Box,
+	}
+	if a.statusHints != nil {
+		boxes = append(boxes, a.statusHints.Box, a.statusInfo.Box)
+	}
+	if a.searchForm != nil {
+		boxes = append(boxes, a.searchForm.Box)
+	}
+	if a.bookmarksList != nil {
+		boxes = append(boxes, a.bookmarksList.Box)
+	}
+	if a.helpView != nil {
+		boxes = append(boxes, a.helpView.Box)
+	}
+	if a.propertiesView != nil {
+		boxes = append(boxes, a.propertiesView.Box)
+	}
+	if a.logView != nil {
+		boxes = append(boxes, a.logView.Box)
+	}
+	if a.statsView != nil {
+		boxes = append(boxes, a.statsView.Box)
+	}
+	if a.lifecycleView != nil {
+		boxes = append(boxes, a.lifecycleView.Box, a.lifecycleForm.Box)
+	}
+	if a.changeFeedView != nil {
+		boxes = append(boxes, a.changeFeedView.Box)
+	}
+	if a.auditView != nil {
+		boxes = append(boxes, a.auditView.Box)
+	}
+	if a.corsView != nil {
+		boxes = append(boxes, a.corsView.Box, a.corsForm.Box)
+	}
+	if a.failuresView != nil {
+		boxes = append(boxes, a.failuresView.Box, a.failuresTable.Box, a.failuresDetail.Box)
+	}
+	if a.callsView != nil {
+		boxes = append(boxes, a.callsView.Box, a.callsTable.Box, a.callsDetail.Box)
+	}
+	if a.usageView != nil {
+		boxes = append(boxes, a.usageView.Box)
+	}
+	if a.syncJobsView != nil {
+		boxes = append(boxes, a.syncJobsView.Box, a.syncJobsTable.Box, a.syncRunsTable.Box)
+	}
+	if a.wormView != nil {
+		boxes = append(boxes, a.wormView.Box, a.wormForm.Box)
+	}
+	if a.findView != nil {
+		boxes = append(boxes, a.findView.Box, a.findInput.Box, a.findResults.Box)
+	}
+	if a.tagsForm != nil {
+		boxes = append(boxes, a.tagsForm.Box)
+	}
+	if a.filterForm != nil {
+		boxes = append(boxes, a.filterForm.Box)
+	}
+	if a.prefixForm != nil {
+		boxes = append(boxes, a.prefixForm.Box)
+	}
+	if a.pipeForm != nil {
+		boxes = append(boxes, a.pipeForm.Box)
+	}
+	if a.exportForm != nil {
+		boxes = append(boxes, a.exportForm.Box)
+	}
+	if a.saveForm != nil {
+		boxes = append(boxes, a.saveForm.Box)
+	}
+	if a.iacForm != nil {
+		boxes = append(boxes, a.iacForm.Box)
+	}
+	if a.setupForm != nil {
+		boxes = append(boxes, a.setupForm.Box)
+	}
+	if a.grepView != nil {
+		boxes = append(boxes, a.grepView.Box, a.grepInput.Box, a.grepResults.Box)
+	}
+	if a.searchesList != nil {
+		boxes = append(boxes, a.searchesList.Box, a.saveSearchForm.Box)
+	}
+	if a.historyView != nil {
+		boxes = append(boxes, a.historyView.Box, a.historyRuns.Box, a.historyResults.Box)
+	}
+	if a.profilesList != nil {
+		boxes = append(boxes, a.profilesList.Box)
+	}
+	return boxes
+}
+
+// updatePaneBorders highlights the border of the focused pane.
+func (a *App) updatePaneBorders() {
+	for _, id := range []pane{paneAccounts, paneContents, paneCompare, panePreview} {
+		box := a.paneBox(id)
+		if id == a.activePane {
+			box.SetBorderColor(a.theme.focusBorder)
+		} else {
+			box.SetBorderColor(a.theme.border)
+		}
+	}
+}
+
+func (a *App) styleTreeNode(node *tview.TreeNode) {
+	node.SetTextStyle(tcell.StyleDefault.Foreground(a.theme.text).Background(a.theme.background))
+	node.SetSelectedTextStyle(tcell.StyleDefault.Foreground(a.theme.selectionFg).Background(a.theme.selectionBg))
+}
+
+// blobColor is the name color for a blob of the given family.
+func (t theme) blobColor(family blobFamily) tcell.Color {
+	switch family {
+	case familyImage:
+		return t.imageFile
+	case familyText:
+		return t.textFile
+	case familyArchive:
+		return t.archiveFile
+	case familyData:
+		return t.dataFile
+	}
+	return t.text
+}
+
+func (t theme) stale(modified time.Time) bool {
+	return t.staleAfter > 0 && !modified.IsZero() && time.Since(modified) > t.staleAfter
+}
+
+func restyleTable(table *tview.Table, t theme) {
+	for row := 0; row < table.GetRowCount(); row++ {
+		for column := 0; column < table.GetColumnCount(); column++ {
+			if cell := table.GetCell(row, column); cell != nil {
+				cell.SetTextColor(t.text).SetBackgroundColor(t.background)
+			}
+		}
+	}
+}
+
+// highlightMatches escapes text for a dynamic-color TextView and wraps every
+// occurrence match finds in the theme's highlight colors.
+func highlightMatches(text string, match textMatcher, t theme) string {
+	open := fmt.Sprintf("[#%06x:#%06x]", t.highlightFg.Hex(), t.highlightBg.Hex())
+	var builder strings.Builder
+	last := 0
+	for _, span := range match.indexes(text) {
+		builder.WriteString(tview.Escape(text[last:span[0]]))
+		builder.WriteString(open)
+		builder.WriteString(tview.Escape(text[span[0]:span[1]]))
+		builder.WriteString("[-:-]")
+		last = span[1]
+	}
+	builder.WriteString(tview.Escape(text[last:]))
+	return builder.String()
+}
@@ -0,0 +1,152 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"storage-tui/internal/azure"
+)
+
+const (
+	// armInterval and blobInterval space out Resource Manager calls
+	// (subscriptions and accounts) and storage calls, which the service
+	// throttles separately.
+	armInterval  = 100 * time.Millisecond
+	blobInterval = 10 * time.Millisecond
+	// maxInterval is the slowest a limiter gets after repeated throttling.
+	maxInterval = 2 * time.Second
+	// throttleRetries is how often a throttled call is retried before its
+	// error is returned.
+	throttleRetries = 4
+	// maxRetryDelay caps how long a retry waits, whatever the service asks.
+	maxRetryDelay = time.Minute
+)
+
+// limiter spaces out calls so they start at most one per interval. Throttled
+// calls widen the interval, and calls that go through narrow it again.
+type limiter struct {
+	mu       sync.Mutex
+	base     time.Duration
+	interval time.Duration
+	next     time.Time
+}
+
+func newLimiter(interval time.Duration) *limiter {
+	return &limiter{base: interval, interval: interval}
+}
+
+// wait blocks until the next call may start, or ctx is done.
+func (l *limiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	start := time.Now()
+	if l.next.After(start) {
+		start = l.next
+	}
+	end := start.Add(l.interval)
+	l.next = end
+	l.mu.Unlock()
+	if err := sleep(ctx, time.Until(start)); err != nil {
+		l.release(start, end)
+		return err
+	}
+	return nil
+}
+
+// release gives back the slot from start to end that a call canceled before
+// it started had reserved, so canceled calls do not hold the next ones back.
+// A slot reserved after it keeps its place, and this one stays taken.
+func (l *limiter) release(start, end time.Time) {
+	l.mu.Lock()
+	if l.next.Equal(end) {
+		l.next = start
+	}
+	l.mu.Unlock()
+}
+
+func (l *limiter) slowDown() {
+	l.mu.Lock()
+	l.interval = min(l.interval*2, maxInterval)
+	l.mu.Unlock()
+}
+
+func (l *limiter) speedUp() {
+	l.mu.Lock()
+	l.interval = max(l.interval*3/4, l.base)
+	l.mu.Unlock()
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// limited runs call once l lets it, retrying while the service throttles it:
+// after the delay the service asked for, or else after 1s, 2s, 4s, and so on.
+func limited[T any](ctx context.Context, s *switchableProvider, l *limiter, name string, call func() (T, error)) (T, error) {
+	for attempt := 0; ; attempt++ {
+		if err := l.wait(ctx); err != nil {
+			var zero T
+			return zero, err
+		}
+		result, err := call()
+		var throttled *azure.ThrottledError
+		if !errors.As(err, &throttled) {
+			if err == nil {
+				l.speedUp()
+			}
+			return result, err
+		}
+		l.slowDown()
+		if attempt == throttleRetries {
+			return result, err
+		}
+		delay := throttled.RetryAfter
+		if delay <= 0 {
+			delay = time.Second << attempt
+		}
+		delay = min(delay, maxRetryDelay)
+		if s.log != nil {
+			s.log.Warn(name+" throttled", "retry", attempt+1, "delay", delay)
+		}
+		if s.onThrottle != nil {
+			s.onThrottle(delay)
+		}
+		if err := s.waitRetry(ctx, delay); err != nil {
+			return result, err
+		}
+	}
+}
+
+// waitRetry waits out the delay before a throttled call is retried.
+func (s *switchableProvider) waitRetry(ctx context.Context, delay time.Duration) error {
+	if s.retryWait != nil {
+		return s.retryWait(ctx, delay)
+	}
+	return sleep(ctx, delay)
+}
+
+// showThrottled marks the status bar as throttled until the retry is due.
+// Provider calls report it from their own goroutines.
+func (a *App) showThrottled(delay time.Duration) {
+	go a.app.QueueUpdateDraw(func() {
+		until := time.Now().Add(delay)
+		if until.After(a.throttledUntil) {
+			a.throttledUntil = until
+		}
+		a.refreshStatus()
+		time.AfterFunc(delay, func() {
+			a.app.QueueUpdateDraw(a.refreshStatus)
+		})
+	})
+}
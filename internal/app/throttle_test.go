@@ -0,0 +1,178 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"storage-tui/internal/azure"
+)
+
+// fakeRetries makes the retries of s return at once, keeping the delays they
+// would have waited.
+func fakeRetries(s *switchableProvider) *[]time.Duration {
+	var delays []time.Duration
+	s.retryWait = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+	return &delays
+}
+
+func TestLimitedRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		throttles  int
+		retryAfter time.Duration
+		wantCalls  int
+		wantDelays []time.Duration
+		wantErr    bool
+	}{
+		{name: "not throttled", wantCalls: 1},
+		{name: "backs off exponentially", throttles: 3, wantCalls: 4, wantDelays: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}},
+		{name: "waits as asked", throttles: 2, retryAfter: 3 * time.Second, wantCalls: 3, wantDelays: []time.Duration{3 * time.Second, 3 * time.Second}},
+		{name: "caps what is asked", throttles: 1, retryAfter: time.Hour, wantCalls: 2, wantDelays: []time.Duration{maxRetryDelay}},
+		{
+			name:       "gives up after the last retry",
+			throttles:  100,
+			wantCalls:  throttleRetries + 1,
+			wantDelays: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second},
+			wantErr:    true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var throttled []time.Duration
+			s := &switchableProvider{onThrottle: func(d time.Duration) { throttled = append(throttled, d) }}
+			delays := fakeRetries(s)
+			calls := 0
+			result, err := limited(context.Background(), s, newLimiter(time.Millisecond), "test", func() (int, error) {
+				calls++
+				if calls <= test.throttles {
+					return 0, &azure.ThrottledError{RetryAfter: test.retryAfter}
+				}
+				return 42, nil
+			})
+			var throttledErr *azure.ThrottledError
+			if test.wantErr != errors.As(err, &throttledErr) || (!test.wantErr && result != 42) {
+				t.Errorf("limited = %d, %v", result, err)
+			}
+			if calls != test.wantCalls {
+				t.Errorf("called %d times, want %d", calls, test.wantCalls)
+			}
+			if !reflect.DeepEqual(*delays, test.wantDelays) {
+				t.Errorf("waited %v, want %v", *delays, test.wantDelays)
+			}
+			if !reflect.DeepEqual(throttled, test.wantDelays) {
+				t.Errorf("onThrottle heard %v, want %v", throttled, test.wantDelays)
+			}
+		})
+	}
+}
+
+func TestLimitedStopsWhenCancelled(t *testing.T) {
+	s := &switchableProvider{}
+	delays := fakeRetries(s)
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	_, err := limited(ctx, s, newLimiter(time.Millisecond), "test", func() (int, error) {
+		calls++
+		cancel()
+		return 0, &azure.ThrottledError{}
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 || len(*delays) != 1 {
+		t.Errorf("limited = %v after %d calls and waits %v, want it cancelled during the first wait", err, calls, *delays)
+	}
+}
+
+func TestLimitedPassesOtherErrors(t *testing.T) {
+	s := &switchableProvider{}
+	delays := fakeRetries(s)
+	failed := errors.New("not found")
+	l := newLimiter(time.Millisecond)
+	calls := 0
+	_, err := limited(context.Background(), s, l, "test", func() (int, error) {
+		calls++
+		return 0, failed
+	})
+	if !errors.Is(err, failed) || calls != 1 || len(*delays) != 0 {
+		t.Errorf("limited = %v after %d calls and waits %v, want the error at once", err, calls, *delays)
+	}
+	if l.interval != l.base {
+		t.Errorf("interval %s after a failure, want %s", l.interval, l.base)
+	}
+}
+
+func TestLimiterInterval(t *testing.T) {
+	l := newLimiter(100 * time.Millisecond)
+	for range 10 {
+		l.slowDown()
+	}
+	if l.interval != maxInterval {
+		t.Errorf("interval %s after throttling, want it capped at %s", l.interval, maxInterval)
+	}
+	l.speedUp()
+	if want := maxInterval * 3 / 4; l.interval != want {
+		t.Errorf("interval %s after a call went through, want %s", l.interval, want)
+	}
+	for range 20 {
+		l.speedUp()
+	}
+	if l.interval != l.base {
+		t.Errorf("interval %s after calls went through, want the base %s", l.interval, l.base)
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	l := newLimiter(interval)
+	ctx := context.Background()
+	start := time.Now()
+	for range 3 {
+		if err := l.wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 2*interval {
+		t.Errorf("three calls started within %s, want them %s apart", elapsed, interval)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := l.wait(cancelled); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait = %v, want it cancelled", err)
+	}
+}
+
+func TestLimiterReleasesCancelledWaits(t *testing.T) {
+	l := newLimiter(time.Hour)
+	ctx := context.Background()
+	if err := l.wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	next := l.next
+
+	// Calls cancelled while they wait for their slot give it back.
+	cancelled, cancel := context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+	for range 3 {
+		if err := l.wait(cancelled); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("wait = %v, want it cancelled", err)
+		}
+	}
+	if !l.next.Equal(next) {
+		t.Errorf("next call starts %s later after cancelled waits, want as before", l.next.Sub(next))
+	}
+
+	// A slot reserved after the cancelled one keeps its place.
+	start := l.next
+	end := start.Add(l.interval)
+	later := end.Add(l.interval)
+	l.next = later
+	l.release(start, end)
+	if !l.next.Equal(later) {
+		t.Errorf("release moved the next call %s, want it left in place", l.next.Sub(later))
+	}
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// typeAheadTimeout ends a type-ahead sequence after a pause in typing.
+const typeAheadTimeout = time.Second
+
+// typeAheadActive reports whether typed runes go to the type-ahead: from /
+// until the first rune, and then until a pause in typing.
+func (a *App) typeAheadActive() bool {
+	return a.typeAheadOn && (a.typeAheadBuffer == "" || time.Since(a.typeAheadAt) < typeAheadTimeout)
+}
+
+// startTypeAhead sends the runes typed next in the tree to the type-ahead
+// rather than to their bindings, so names starting with a, q, or another
+// bound letter can be typed.
+func (a *App) startTypeAhead() {
+	a.typeAheadOn = true
+	a.typeAheadBuffer = ""
+	a.flash("Jump: type a name (esc cancels).")
+}
+
+// typeAheadKey handles a key typed while the type-ahead is active, and
+// reports whether it took it: runes extend it and esc cancels it, while any
+// other key ends it and goes on to its binding.
+func (a *App) typeAheadKey(event *tcell.EventKey) bool {
+	switch event.Key() {
+	case tcell.KeyRune:
+		a.typeAhead(event.Rune())
+		return true
+	case tcell.KeyEscape:
+		a.typeAheadOn = false
+		a.flash("Jump canceled.")
+		return true
+	}
+	a.typeAheadOn = false
+	return false
+}
+
+// typeAhead extends the type-ahead buffer and selects the next visible tree
+// node whose name starts with it, falling back to names containing it.
+// Repeating a single letter cycles through the nodes starting with it.
+func (a *App) typeAhead(r rune) {
+	a.typeAheadBuffer += strings.ToLower(string(r))
+	a.typeAheadAt = time.Now()
+
+	nodes := a.visibleNodes()
+	current := 0
+	for i, node := range nodes {
+		if node == a.accounts.GetCurrentNode() {
+			current = i
+			break
+		}
+	}
+
+	buffer := a.typeAheadBuffer
+	// A fresh single letter starts searching after the current node so that
+	// pressing it again moves on; longer prefixes may match in place.
+	offset := 0
+	if len([]rune(buffer)) == 1 {
+		offset = 1
+	}
+	match := findNode(nodes, current, offset, buffer)
+	if match == nil && repeatsOneRune(buffer) {
+		buffer = string([]rune(buffer)[:1])
+		a.typeAheadBuffer = buffer
+		match = findNode(nodes, current, 1, buffer)
+	}
+
+	if match == nil {
+		a.flashErr(fmt.Sprintf("Jump: no match for %q", a.typeAheadBuffer))
+		return
+	}
+	a.flash(fmt.Sprintf("Jump: %s", a.typeAheadBuffer))
+	if match != a.accounts.GetCurrentNode() {
+		a.accounts.SetCurrentNode(match)
+		a.onTreeChanged(match)
+	}
+}
+
+// findNode searches nodes, starting offset positions after start and
+// wrapping around, first for a name prefix and then for a substring.
+func findNode(nodes []*tview.TreeNode, start, offset int, term string) *tview.TreeNode {
+	for _, match := range []func(name string) bool{
+		func(name string) bool { return strings.HasPrefix(name, term) },
+		func(name string) bool { return strings.Contains(name, term) },
+	} {
+		for i := 0; i < len(nodes); i++ {
+			node := nodes[(start+offset+i)%len(nodes)]
+			ref, ok := node.GetReference().(itemRef)
+			if !ok || ref.Kind == kindRoot || ref.Kind == kindNone {
+				continue
+			}
+			if match(strings.ToLower(ref.Name)) {
+				return node
+			}
+		}
+	}
+	return nil
+}
+
+// visibleNodes lists the tree nodes shown on screen, in display order.
+func (a *App) visibleNodes() []*tview.TreeNode {
+	var nodes []*tview.TreeNode
+	a.root.Walk(func(node, _ *tview.TreeNode) bool {
+		nodes = append(nodes, node)
+		return node.IsExpanded()
+	})
+	return nodes
+}
+
+func repeatsOneRune(text string) bool {
+	runes := []rune(text)
+	for _, r := range runes[1:] {
+		if r != runes[0] {
+			return false
+		}
+	}
+	return len(runes) > 1
+}
@@ -0,0 +1,186 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"storage-tui/internal/audit"
+	"storage-tui/internal/azure"
+)
+
+// undoable is what a change wrote over or deleted in a container, which the
+// account's versioning and soft delete may keep.
+type undoable struct {
+	// what names the change in messages, such as "sync job site".
+	what               string
+	account, container string
+	// replaced and deleted name the blobs with the ETags they had, so
+	// just those versions are restored.
+	replaced, deleted []azure.BlobVersion
+}
+
+// undoableEntry is what an audited action names as replaced or deleted, in
+// the container of its target.
+func undoableEntry(entry audit.Entry) (undoable, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(entry.Target, "az://"), "/", 3)
+	if len(parts) < 2 || len(entry.Replaced)+len(entry.Deleted) == 0 {
+		return undoable{}, false
+	}
+	return undoable{
+		what:      fmt.Sprintf("the %s of %s", entry.Action, entry.Time.UTC().Format(time.RFC3339)),
+		account:   parts[0],
+		container: parts[1],
+		replaced:  entry.Replaced,
+		deleted:   entry.Deleted,
+	}, true
+}
+
+// kept leaves what settings keep of u: replaced blobs with versioning, and
+// deleted ones with soft delete or versioning.
+func (u undoable) kept(settings azure.BlobServiceProperties) undoable {
+	if !settings.KeepsVersions() {
+		u.replaced = nil
+	}
+	if !settings.KeepsDeleted() {
+		u.deleted = nil
+	}
+	return u
+}
+
+func (u undoable) empty() bool {
+	return len(u.replaced)+len(u.deleted) == 0
+}
+
+// describe counts the blobs of u, such as "2 blobs replaced and 1 deleted".
+func (u undoable) describe() string {
+	switch {
+	case len(u.deleted) == 0:
+		return fmt.Sprintf("%d blobs replaced", len(u.replaced))
+	case len(u.replaced) == 0:
+		return fmt.Sprintf("%d blobs deleted", len(u.deleted))
+	}
+	return fmt.Sprintf("%d blobs replaced and %d deleted", len(u.replaced), len(u.deleted))
+}
+
+// withKept reads the data protection settings of u's account and calls then
+// with what of u they keep.
+func (a *App) withKept(u undoable, then func(undoable, error)) {
+	runAsync(a, a.activePane, func(ctx context.Context) (azure.BlobServiceProperties, error) {
+		return a.provider.GetBlobServiceProperties(ctx, u.account)
+	}, func(settings azure.BlobServiceProperties, err error) {
+		then(u.kept(settings), err)
+	})
+}
+
+// offerUndo offers, in the status bar, to undo a change just made with u,
+// as far as the account keeps what it wrote over or deleted. The offer
+// lasts until the next change that can be undone.
+func (a *App) offerUndo(u undoable) {
+	a.withKept(u, func(kept undoable, err error) {
+		if err != nil {
+			a.log.Warn("reading what can be undone failed", "account", u.account, "error", err)
+			return
+		}
+		if kept.empty() {
+			return
+		}
+		a.undo = &kept
+		a.flash(fmt.Sprintf("u: undo %s, %s in %s/%s.", kept.what, kept.describe(), kept.account, kept.container))
+	})
+}
+
+// undoLast restores what the change last offered to undo wrote over or
+// deleted.
+func (a *App) undoLast() {
+	if a.undo == nil {
+		a.flashErr("Nothing to undo: only a change that wrote over or deleted blobs the account keeps, such as a sync job's, can be.")
+		return
+	}
+	u := *a.undo
+	a.undo = nil
+	a.restoreBlobs(u)
+}
+
+// confirmRestore asks before restoring what an audited action wrote over or
+// deleted, which may since have changed again.
+func (a *App) confirmRestore(entry audit.Entry) {
+	u, ok := undoableEntry(entry)
+	if !ok {
+		a.flashErr("This entry names no blobs it wrote over or deleted.")
+		return
+	}
+	a.withKept(u, func(kept undoable, err error) {
+		switch {
+		case err != nil:
+			a.flashErr(fmt.Sprintf("Reading the data protection settings of %s: %v", u.account, err))
+			return
+		case kept.empty():
+			a.flashErr(fmt.Sprintf("%s keeps neither previous versions nor deleted blobs, so %s cannot be undone.", u.account, u.what))
+			return
+		}
+		message := fmt.Sprintf("Undo %s, restoring %s in %s/%s? Each goes back to the version it had before, and a blob written since is kept as a previous version.", kept.what, kept.describe(), kept.account, kept.container)
+		if dropped := len(u.replaced) + len(u.deleted) - len(kept.replaced) - len(kept.deleted); dropped > 0 {
+			message += fmt.Sprintf(" The %d blobs the account does not keep stay as they are.", dropped)
+		}
+		a.confirm(confirmation{
+			Title:     "Undo",
+			Message:   message,
+			Action:    "Restore",
+			Danger:    true,
+			Back:      a.auditView,
+			OnConfirm: func() { a.restoreBlobs(kept) },
+		})
+	})
+}
+
+// restoreBlobs brings back the versions of the blobs u replaced and the
+// blobs it deleted, as they were before it, and records the restore in the
+// audit log.
+func (a *App) restoreBlobs(u undoable) {
+	if a.audit == nil {
+		a.flashErr(fmt.Sprintf("Undoing %s is refused: the audit log could not be opened.", u.what))
+		return
+	}
+	target := fmt.Sprintf("az://%s/%s", u.account, u.container)
+	entry := audit.Entry{Action: "restore", Target: target, Detail: fmt.Sprintf("undo of %s: %s", u.what, u.describe()), Result: audit.ResultOK}
+	if provider, ok := a.provider.(identityProvider); ok {
+		entry.Identity = provider.Identity()
+	}
+	a.flash(fmt.Sprintf("Undoing %s…", u.what))
+
+	// The fetch returns the entry recorded, which tells how the restore
+	// went; err is that of recording it.
+	runAsync(a, a.activePane, func(ctx context.Context) (audit.Entry, error) {
+		if err := azure.Restore(ctx, a.provider, u.account, u.container, u.replaced, u.deleted); err != nil {
+			entry.Result, entry.Error = audit.ResultFailed, err.Error()
+		}
+		return entry, a.audit.Record(entry)
+	}, func(recorded audit.Entry, err error) {
+		if a.contentSource.Kind != kindContainer || a.contentSource.Account != u.account || a.contentSource.Container != u.container {
+			a.reportRestore(u, recorded, err)
+			return
+		}
+		// The outcome is told once the listing is refreshed, so its own
+		// message does not hide it.
+		a.refreshBlobs(a.contentSource)
+		a.whenContentsLoaded(func(error) { a.reportRestore(u, recorded, err) })
+	})
+}
+
+// reportRestore tells how restoring u went, as recorded, and whether
+// recording it failed with recordErr.
+func (a *App) reportRestore(u undoable, recorded audit.Entry, recordErr error) {
+	switch {
+	case recorded.Result == audit.ResultFailed:
+		target := fmt.Sprintf("az://%s/%s", u.account, u.container)
+		a.recordFailure("undo", target, errors.New(recorded.Error), func() { a.restoreBlobs(u) })
+		a.flashErr(fmt.Sprintf("Undoing %s: %s", u.what, recorded.Error))
+	case recordErr != nil:
+		a.flashErr(fmt.Sprintf("Undid %s, but not in the audit log: %v", u.what, recordErr))
+	default:
+		a.flash(fmt.Sprintf("Undid %s: %d blobs restored.", u.what, len(u.replaced)+len(u.deleted)))
+	}
+}
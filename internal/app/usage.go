@@ -0,0 +1,166 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"storage-tui/internal/usage"
+)
+
+// usageSendTimeout bounds how long quitting waits to report usage.
+const usageSendTimeout = 3 * time.Second
+
+// paneNames names the panes in usage metrics.
+var paneNames = map[pane]string{
+	paneAccounts: "subscriptions",
+	paneContents: "contents",
+	paneCompare:  "compare",
+	panePreview:  "preview",
+}
+
+// startUsage counts usage on top of the counts of earlier launches, if the
+// user turned usage metrics on and DO_NOT_TRACK does not ask otherwise.
+func (a *App) startUsage() {
+	path, err := usage.DefaultPath()
+	if err != nil {
+		return
+	}
+	a.usagePath = path
+	if !a.prefs.UsageMetrics || usage.DisabledByEnv() {
+		return
+	}
+	pending, err := usage.Load(path)
+	if err != nil {
+		a.log.Warn("usage counts unreadable, starting over", "error", err)
+	}
+	a.usage = usage.New(pending)
+	a.countProvider()
+}
+
+// countProvider counts browsing the kind of provider of the current profile.
+func (a *App) countProvider() {
+	if a.usage != nil && a.providerKind != nil {
+		a.usage.Provider(a.providerKind(a.profile))
+	}
+}
+
+// saveUsage keeps the counts for the next launch, or, once they have added
+// up for a day and there is an endpoint, reports them and starts over. A
+// report that fails is tried again on a later quit.
+func (a *App) saveUsage() {
+	if a.usage == nil || a.usagePath == "" {
+		return
+	}
+	report := a.usage.Report()
+	if a.usageEndpoint != "" && !report.Empty() && report.Due(time.Now()) {
+		ctx, cancel := context.WithTimeout(context.Background(), usageSendTimeout)
+		err := usage.Send(ctx, a.usageEndpoint, report)
+		cancel()
+		if err == nil {
+			a.log.Info("usage reported", "endpoint", a.usageEndpoint)
+			a.removeUsage()
+			return
+		}
+		a.log.Warn("usage report failed", "endpoint", a.usageEndpoint, "error", err)
+	}
+	if err := usage.Save(a.usagePath, report); err != nil {
+		a.log.Warn("usage counts not saved", "error", err)
+	}
+}
+
+// removeUsage deletes the counts not yet reported.
+func (a *App) removeUsage() {
+	if err := os.Remove(a.usagePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		a.log.Warn("usage counts not deleted", "error", err)
+	}
+}
+
+// toggleUsage turns usage metrics on or off for this and later launches.
+// Turning them off deletes the counts not yet reported.
+func (a *App) toggleUsage() {
+	switch {
+	case a.usage != nil:
+		a.usage = nil
+		a.prefs.UsageMetrics = false
+		a.removeUsage()
+		a.flash("Usage metrics off; counts not yet sent deleted.")
+	case usage.DisabledByEnv():
+		a.flashErr("DO_NOT_TRACK is set; usage metrics stay off.")
+		return
+	default:
+		a.prefs.UsageMetrics = true
+		a.startUsage()
+		a.flash("Usage metrics on, thank you.")
+	}
+	a.savePrefs()
+	a.renderUsage()
+	a.refreshStatus()
+}
+
+func (a *App) setupUsageModal() {
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWordWrap(true)
+	view.SetBorder(true)
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc || event.Rune() == 'U' || event.Rune() == 'q':
+			a.closeUsageModal()
+			return nil
+		case event.Rune() == 'u':
+			a.toggleUsage()
+			return nil
+		}
+		return event
+	})
+	a.usageView = view
+	a.pages.AddPage("usage", centerModal(view, 30, 100), true, false)
+}
+
+// openUsageModal shows whether usage metrics are on, what they count and
+// where it goes, and the counts not yet sent.
+func (a *App) openUsageModal() {
+	a.usageOpen = true
+	a.renderUsage()
+	a.pages.ShowPage("usage")
+	a.app.SetFocus(a.usageView)
+}
+
+func (a *App) closeUsageModal() {
+	a.pages.HidePage("usage")
+	a.usageOpen = false
+	a.setActivePane(a.activePane)
+}
+
+func (a *App) renderUsage() {
+	state, toggle := "off", "u: turn on"
+	if a.usage != nil {
+		state, toggle = "on", "u: turn off"
+	}
+	a.usageView.SetTitle(fmt.Sprintf("Usage metrics  %s | esc: close", toggle))
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Usage metrics are %s%s[-].\n\n", colorTag(a.theme.accent), state)
+	builder.WriteString("When on, storage-tui counts which panes you focus, which actions you run, named as the help names them, and which kind of provider you browse, so its maintainers can see what is used. It counts no subscription, account, container, or blob names, nothing you type, and nothing that identifies you or this machine besides its operating system.\n\n")
+	if a.usageEndpoint != "" {
+		fmt.Fprintf(&builder, "Counts add up in %s and are sent to %s about once a day, when you quit.\n", tview.Escape(a.usagePath), tview.Escape(a.usageEndpoint))
+	} else {
+		fmt.Fprintf(&builder, "No endpoint is set, so counts add up in %s and are not sent anywhere.\n", tview.Escape(a.usagePath))
+	}
+	if usage.DisabledByEnv() {
+		fmt.Fprintf(&builder, "\n%sDO_NOT_TRACK is set, so usage metrics stay off.[-]\n", colorTag(a.theme.changed))
+	}
+	if a.usage != nil {
+		data, _ := json.MarshalIndent(a.usage.Report(), "", "  ")
+		fmt.Fprintf(&builder, "\nCounted and not yet sent:\n%s\n", tview.Escape(string(data)))
+	}
+	a.usageView.SetText(builder.String()).ScrollToBeginning()
+}
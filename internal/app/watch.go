@@ -0,0 +1,301 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"storage-tui/internal/azure"
+)
+
+// defaultWatchInterval is how often watch mode re-lists the container when
+// Options.WatchInterval is not set.
+const defaultWatchInterval = 30 * time.Second
+
+type watchChange int
+
+const (
+	watchAdded watchChange = iota + 1
+	watchModified
+)
+
+// toggleWatch turns watch mode on or off. While on, the listed container is
+// re-listed every watch interval and blobs that are new or changed since the
+// previous listing are marked. With Options.WatchEvents, the blobs created
+// and deleted are followed as the provider pushes them instead.
+func (a *App) toggleWatch() {
+	if a.watchStop != nil {
+		close(a.watchStop)
+		a.watchStop = nil
+		a.watchMarks = nil
+		a.watchPush = false
+		a.stopWatchPush()
+		a.markDifferences()
+		a.flash("Watch mode off.")
+		a.refreshStatus()
+		return
+	}
+
+	stop := make(chan struct{})
+	a.watchStop = stop
+	interval := a.watchInterval
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				a.app.QueueUpdateDraw(a.watchTick)
+			}
+		}
+	}()
+	if a.watchEvents {
+		a.watchPush = true
+		a.followWatchEvents()
+		a.flash("Watch mode on, following storage events.")
+	} else {
+		a.flash(fmt.Sprintf("Watch mode on, re-listing every %s.", interval))
+	}
+	a.refreshStatus()
+}
+
+// watchTick re-lists as many blobs as are loaded now, unless the contents
+// table is busy or not showing a container.
+func (a *App) watchTick() {
+	if a.watchPush {
+		a.followWatchEvents()
+		return
+	}
+	if a.watchStop == nil || a.watchBusy || a.contentsLoading || a.contentsPaging || a.contentSource.Kind != kindContainer {
+		return
+	}
+	container := a.contentSource
+	prefix := a.listPrefix
+	previous := blobsByName(a.listedBlobs)
+	want := len(previous)
+	seq := a.contentsSeq
+	a.watchBusy = true
+
+	runAsyncWith(a, a.contentsCtx, paneContents, func(ctx context.Context) (azure.BlobPage, error) {
+		var result azure.BlobPage
+		for {
+			page, err := a.provider.ListBlobsPage(ctx, container.Account, container.Container, azure.ListBlobsOptions{
+				Marker:     result.NextMarker,
+				MaxResults: blobPageSize,
+				Prefix:     prefix,
+			})
+			if err != nil {
+				return result, err
+			}
+			result.Blobs = append(result.Blobs, page.Blobs...)
+			result.NextMarker = page.NextMarker
+			if result.NextMarker == "" || len(result.Blobs) >= want {
+				return result, nil
+			}
+		}
+	}, func(page azure.BlobPage, err error) {
+		a.watchBusy = false
+		// A newer listing or a page loaded meanwhile makes the result stale.
+		if seq != a.contentsSeq || a.watchStop == nil || a.contentsPaging || a.loadedBlobCount() != want {
+			return
+		}
+		if err != nil {
+			a.flashErr(fmt.Sprintf("Watch: error listing blobs: %v", err))
+			return
+		}
+		a.applyWatchListing(container, page, previous)
+	})
+}
+
+// applyWatchListing swaps in the new listing, keeping the selected blob and
+// scroll position, and records which blobs are new or changed.
+func (a *App) applyWatchListing(container itemRef, page azure.BlobPage, previous map[string]itemRef) {
+	marks := make(map[string]watchChange)
+	current := make(map[string]bool, len(page.Blobs))
+	for _, blob := range page.Blobs {
+		current[blob.Name] = true
+		old, ok := previous[blob.Name]
+		switch {
+		case !ok:
+			marks[blob.Name] = watchAdded
+		case old.SizeBytes != blob.SizeBytes || !old.Modified.Equal(blob.Modified):
+			marks[blob.Name] = watchModified
+		}
+	}
+	removed := 0
+	for name := range previous {
+		if !current[name] {
+			removed++
+		}
+	}
+
+	a.contentsMarker = page.NextMarker
+	a.contentsCachedAt = time.Time{}
+	a.listedBlobs = nil
+	for _, blob := range page.Blobs {
+		a.listedBlobs = append(a.listedBlobs, blobRef(container, blob))
+	}
+	a.watchMarks = marks
+	a.renderContents(true)
+	if len(marks)+removed > 0 {
+		a.invalidateFolderStats()
+		if a.folderView {
+			a.loadFolderStats(container)
+		}
+	}
+
+	var added, modified int
+	for _, change := range marks {
+		if change == watchAdded {
+			added++
+		} else {
+			modified++
+		}
+	}
+	if added+modified+removed > 0 {
+		a.flash(fmt.Sprintf("Watch: %d new, %d changed, %d removed.", added, modified, removed))
+	}
+}
+
+// markWatchChanges prefixes blobs marked by the last watch listing with + (new)
+// or * (changed) and draws them in the theme's added and changed colors.
+func (a *App) markWatchChanges() {
+	for row, ref := range a.contentRefs {
+		if ref.Kind != kindBlob {
+			continue
+		}
+		cell := a.contents.GetCell(row, 0)
+		if cell == nil {
+			continue
+		}
+		switch a.watchMarks[ref.Name] {
+		case watchAdded:
+			cell.SetText("+ " + a.contentLabel(ref)).SetTextColor(a.theme.added)
+		case watchModified:
+			cell.SetText("* " + a.contentLabel(ref)).SetTextColor(a.theme.changed)
+		default:
+			cell.SetText(a.contentLabel(ref))
+		}
+	}
+}
+
+// followWatchEvents subscribes to the events of the listed container, ending
+// the subscription to the one listed before. Should the subscription fail,
+// watch mode goes back to re-listing.
+func (a *App) followWatchEvents() {
+	if a.watchStop == nil || !a.watchPush {
+		return
+	}
+	target := a.contentSource
+	if target.Kind != kindContainer {
+		a.stopWatchPush()
+		return
+	}
+	if a.watchPushCancel != nil && sameContainer(a.watchPushTarget, target) {
+		return
+	}
+	a.stopWatchPush()
+	ctx, cancel := context.WithCancel(context.Background())
+	a.watchPushTarget = target
+	a.watchPushCancel = cancel
+
+	go func() {
+		err := a.switcher.WatchBlobEvents(ctx, target.Account, target.Container, func(event azure.BlobEvent) {
+			a.app.QueueUpdateDraw(func() {
+				if ctx.Err() == nil {
+					a.applyBlobEvent(target, event)
+				}
+			})
+		})
+		a.app.QueueUpdateDraw(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			a.stopWatchPush()
+			if err == nil {
+				err = errors.New("the provider ended the subscription")
+			}
+			a.watchPush = false
+			a.recordFailure("watch events", refTarget(target), err, func() {
+				if a.watchStop != nil {
+					a.watchPush = true
+					a.followWatchEvents()
+					a.refreshStatus()
+				}
+			})
+			a.flashErr(fmt.Sprintf("Watch: storage events failed (%v); re-listing every %s instead.", err, a.watchInterval))
+			a.refreshStatus()
+		})
+	}()
+}
+
+func (a *App) stopWatchPush() {
+	if a.watchPushCancel != nil {
+		a.watchPushCancel()
+		a.watchPushCancel = nil
+	}
+	a.watchPushTarget = itemRef{}
+}
+
+// applyBlobEvent adds, replaces, or removes the blob of event in the listing
+// and marks it, if the listing shows it: blobs past the loaded pages come
+// with the next one.
+func (a *App) applyBlobEvent(container itemRef, event azure.BlobEvent) {
+	if a.watchStop == nil || a.contentsLoading || a.contentsPaging || !sameContainer(a.contentSource, container) {
+		return
+	}
+	name := event.Blob.Name
+	if !strings.HasPrefix(name, a.listPrefix) {
+		return
+	}
+	at := slices.IndexFunc(a.listedBlobs, func(ref itemRef) bool { return ref.Name == name })
+	found := at >= 0
+	if !found {
+		// Listings come in name order, so a new blob goes where its name
+		// sorts, unless that is past the loaded pages.
+		at, _ = slices.BinarySearchFunc(a.listedBlobs, name, func(ref itemRef, name string) int {
+			return strings.Compare(ref.Name, name)
+		})
+		if event.EventType == "BlobDeleted" || at == len(a.listedBlobs) && a.contentsMarker != "" {
+			return
+		}
+	}
+
+	// A new slice, rather than an edit in place, keeps the name index right.
+	listed := make([]itemRef, 0, len(a.listedBlobs)+1)
+	listed = append(listed, a.listedBlobs[:at]...)
+	rest := a.listedBlobs[at:]
+	if found {
+		rest = rest[1:]
+	}
+	if a.watchMarks == nil {
+		a.watchMarks = make(map[string]watchChange)
+	}
+	var message string
+	switch {
+	case event.EventType == "BlobDeleted":
+		delete(a.watchMarks, name)
+		message = fmt.Sprintf("Watch: %s deleted.", name)
+	case found:
+		listed = append(listed, blobRef(container, event.Blob))
+		a.watchMarks[name] = watchModified
+		message = fmt.Sprintf("Watch: %s changed.", name)
+	default:
+		listed = append(listed, blobRef(container, event.Blob))
+		a.watchMarks[name] = watchAdded
+		message = fmt.Sprintf("Watch: %s created.", name)
+	}
+	a.listedBlobs = append(listed, rest...)
+	a.contentsCachedAt = time.Time{}
+	a.renderContents(true)
+	a.invalidateFolderStats()
+	if a.folderView {
+		a.loadFolderStats(container)
+	}
+	a.flash(message)
+}
@@ -0,0 +1,305 @@
+// Package archive lists and reads the files inside .zip, .tar, and .tar.gz
+// blobs, so the browser can open them like folders. A zip is read with
+// ranged reads of its central directory and of the one file wanted; a tar
+// has no index, so it is streamed from the start.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"storage-tui/internal/azure"
+)
+
+// format is how an archive is packed.
+type format int
+
+const (
+	formatZip format = iota
+	formatTar
+	formatTarGzip
+)
+
+// formatOf picks the format from the blob's name, reporting false for
+// those that are not archives.
+func formatOf(name string) (format, bool) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return formatZip, true
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return formatTarGzip, true
+	case strings.HasSuffix(lower, ".tar"):
+		return formatTar, true
+	}
+	return 0, false
+}
+
+// Supported reports whether the blob's name is that of an archive Open can
+// list.
+func Supported(name string) bool {
+	_, ok := formatOf(name)
+	return ok
+}
+
+// Entry is a file in an archive. Directories are not listed; they show in
+// the names of the files under them.
+type Entry struct {
+	// Name is the slash-separated path of the file inside the archive.
+	Name     string
+	Size     int64
+	Modified time.Time
+}
+
+// Archive is an archive blob with the files it holds.
+type Archive struct {
+	Account   string
+	Container string
+	Blob      string
+	Entries   []Entry
+
+	provider azure.Provider
+	format   format
+	// files finds the zip entries by name.
+	files map[string]*zip.File
+	// at reads the zip's headers; its context is set by whichever call uses
+	// it, one at a time.
+	mu sync.Mutex
+	at *blobReaderAt
+}
+
+// Open lists the archive blob of size bytes. Entries come sorted by name.
+func Open(ctx context.Context, provider azure.Provider, account, container, blob string, size int64) (*Archive, error) {
+	format, ok := formatOf(blob)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a .zip, .tar, or .tar.gz archive", blob)
+	}
+	a := &Archive{Account: account, Container: container, Blob: blob, provider: provider, format: format}
+	var err error
+	if format == formatZip {
+		err = a.listZip(ctx, size)
+	} else {
+		err = a.listTar(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", blob, err)
+	}
+	sort.Slice(a.Entries, func(i, j int) bool { return a.Entries[i].Name < a.Entries[j].Name })
+	return a, nil
+}
+
+func (a *Archive) listZip(ctx context.Context, size int64) error {
+	a.at = &blobReaderAt{provider: a.provider, account: a.Account, container: a.Container, blob: a.Blob, size: size}
+	a.mu.Lock()
+	a.at.ctx = ctx
+	reader, err := zip.NewReader(a.at, size)
+	a.at.ctx = nil
+	a.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	a.files = make(map[string]*zip.File)
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		a.files[file.Name] = file
+		a.Entries = append(a.Entries, Entry{Name: file.Name, Size: int64(file.UncompressedSize64), Modified: file.Modified})
+	}
+	return nil
+}
+
+func (a *Archive) listTar(ctx context.Context) error {
+	reader, err := a.openTar(ctx)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		a.Entries = append(a.Entries, Entry{Name: strings.TrimPrefix(header.Name, "./"), Size: header.Size, Modified: header.ModTime})
+	}
+}
+
+// tarReader reads a tar blob from the start, unpacking it as it goes.
+type tarReader struct {
+	*tar.Reader
+	closers []io.Closer
+}
+
+func (r *tarReader) Close() error {
+	var errs []error
+	for _, closer := range r.closers {
+		errs = append(errs, closer.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (a *Archive) openTar(ctx context.Context) (*tarReader, error) {
+	blob, err := a.provider.OpenBlob(ctx, a.Account, a.Container, a.Blob)
+	if err != nil {
+		return nil, err
+	}
+	if a.format == formatTar {
+		return &tarReader{Reader: tar.NewReader(blob), closers: []io.Closer{blob}}, nil
+	}
+	unzipped, err := gzip.NewReader(blob)
+	if err != nil {
+		blob.Close()
+		return nil, err
+	}
+	return &tarReader{Reader: tar.NewReader(unzipped), closers: []io.Closer{unzipped, blob}}, nil
+}
+
+// OpenEntry reads the named file of the archive. From a zip, only the
+// file's own bytes are read; from a tar, the blob is read up to the file.
+func (a *Archive) OpenEntry(ctx context.Context, name string) (io.ReadCloser, error) {
+	if a.format == formatZip {
+		return a.openZipEntry(ctx, name)
+	}
+	reader, err := a.openTar(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			reader.Close()
+			return nil, fmt.Errorf("%s holds no file %s", a.Blob, name)
+		}
+		if err != nil {
+			reader.Close()
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeReg && strings.TrimPrefix(header.Name, "./") == name {
+			return struct {
+				io.Reader
+				io.Closer
+			}{reader, reader}, nil
+		}
+	}
+}
+
+func (a *Archive) openZipEntry(ctx context.Context, name string) (io.ReadCloser, error) {
+	file, ok := a.files[name]
+	if !ok {
+		return nil, fmt.Errorf("%s holds no file %s", a.Blob, name)
+	}
+	// The file's data starts after its local header, which has to be read
+	// to know how long it is.
+	a.mu.Lock()
+	a.at.ctx = ctx
+	offset, err := file.DataOffset()
+	a.at.ctx = nil
+	a.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	data, err := azure.OpenRange(ctx, a.provider, a.Account, a.Container, a.Blob, offset, int64(file.CompressedSize64))
+	if err != nil {
+		return nil, err
+	}
+	switch file.Method {
+	case zip.Store:
+		return data, nil
+	case zip.Deflate:
+		inflated := flate.NewReader(data)
+		return struct {
+			io.Reader
+			io.Closer
+		}{inflated, closeBoth{inflated, data}}, nil
+	default:
+		data.Close()
+		return nil, fmt.Errorf("%s in %s is packed with method %d, which cannot be read", name, a.Blob, file.Method)
+	}
+}
+
+type closeBoth [2]io.Closer
+
+func (c closeBoth) Close() error {
+	return errors.Join(c[0].Close(), c[1].Close())
+}
+
+// minRead is the least a zip header read fetches, so the many small reads
+// of the central directory take a few requests rather than one each.
+const minRead = 64 << 10
+
+// blobReaderAt reads a blob at offsets with ranged reads, keeping the last
+// block read.
+type blobReaderAt struct {
+	ctx                      context.Context
+	provider                 azure.Provider
+	account, container, blob string
+	size                     int64
+	blockStart               int64
+	block                    []byte
+}
+
+func (r *blobReaderAt) ReadAt(p []byte, offset int64) (int, error) {
+	if offset >= r.size {
+		return 0, io.EOF
+	}
+	if offset < r.blockStart || offset+int64(len(p)) > r.blockStart+int64(len(r.block)) {
+		if err := r.fetch(offset, int64(len(p))); err != nil {
+			return 0, err
+		}
+	}
+	// A blob shorter than its listed size, cut short or changed since it
+	// was listed, ends before the block should.
+	if offset-r.blockStart >= int64(len(r.block)) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n := copy(p, r.block[offset-r.blockStart:])
+	switch {
+	case n == len(p):
+		return n, nil
+	case offset+int64(n) < r.size:
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, io.EOF
+}
+
+// fetch reads the block holding length bytes from offset, with at least
+// minRead of the bytes around it.
+func (r *blobReaderAt) fetch(offset, length int64) error {
+	if r.ctx == nil {
+		return errors.New("archive read outside a call")
+	}
+	start, end := offset, min(offset+max(length, minRead), r.size)
+	// Reads of the central directory go forward from the end of the file,
+	// so a block near the end takes in the rest of the file.
+	if r.size-start < minRead {
+		start = max(r.size-minRead, 0)
+		end = r.size
+	}
+	reader, err := azure.OpenRange(r.ctx, r.provider, r.account, r.container, r.blob, start, end-start)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	block, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	r.blockStart, r.block = start, block
+	return nil
+}
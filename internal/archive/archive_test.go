@@ -0,0 +1,318 @@
+package archive_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"storage-tui/internal/archive"
+	"storage-tui/internal/azure"
+)
+
+const (
+	account   = "acme-dev"
+	container = "images"
+)
+
+var modified = time.Date(2024, 6, 3, 8, 30, 0, 0, time.UTC)
+
+// counting serves the mock data and counts the reads of blobs, whole and
+// ranged.
+type counting struct {
+	*azure.MockProvider
+	opens  int
+	ranges [][2]int64
+}
+
+func (c *counting) OpenBlob(ctx context.Context, account, container, blob string) (io.ReadCloser, error) {
+	c.opens++
+	return c.MockProvider.OpenBlob(ctx, account, container, blob)
+}
+
+func (c *counting) OpenBlobRange(ctx context.Context, account, container, blob string, offset, length int64) (io.ReadCloser, error) {
+	c.ranges = append(c.ranges, [2]int64{offset, length})
+	return c.MockProvider.OpenBlobRange(ctx, account, container, blob, offset, length)
+}
+
+// reset forgets the reads so far.
+func (c *counting) reset() {
+	c.opens, c.ranges = 0, nil
+}
+
+// file is a file packed into a fixture.
+type file struct {
+	name    string
+	content string
+	method  uint16
+}
+
+// noCompression is a zip method no reader knows.
+const noCompression = 99
+
+// big is incompressible, so a zip holding it is larger than a block of
+// header reads.
+var big = func() string {
+	data := make([]byte, 200<<10)
+	rand.New(rand.NewSource(1)).Read(data)
+	return string(data)
+}()
+
+var files = []file{
+	{name: "a.txt", content: "stored as is", method: zip.Store},
+	{name: "docs/b.txt", content: strings.Repeat("deflated ", 100), method: zip.Deflate},
+	{name: "odd.bin", content: "packed oddly", method: noCompression},
+	{name: "big.bin", content: big, method: zip.Store},
+}
+
+func zipFixture(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	w.RegisterCompressor(noCompression, func(w io.Writer) (io.WriteCloser, error) {
+		return nopWriteCloser{w}, nil
+	})
+	if _, err := w.Create("docs/"); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		entry, err := w.CreateHeader(&zip.FileHeader{Name: f.name, Method: f.method, Modified: modified})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(entry, f.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func tarFixture(t *testing.T, gzipped bool) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var out io.Writer = &buf
+	var zipped *gzip.Writer
+	if gzipped {
+		zipped = gzip.NewWriter(&buf)
+		out = zipped
+	}
+	w := tar.NewWriter(out)
+	if err := w.WriteHeader(&tar.Header{Name: "./docs/", Typeflag: tar.TypeDir, Mode: 0o755, ModTime: modified}); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		name := f.name
+		if name == "docs/b.txt" {
+			name = "./" + name
+		}
+		if err := w.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(f.content)), ModTime: modified}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(w, f.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if zipped != nil {
+		if err := zipped.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+// serve uploads data as the blob name and opens it as an archive.
+func serve(t *testing.T, name string, data []byte) (*archive.Archive, *counting) {
+	t.Helper()
+	ctx := context.Background()
+	provider := &counting{MockProvider: azure.NewMockProvider()}
+	if _, err := provider.UploadBlob(ctx, account, container, name, bytes.NewReader(data), ""); err != nil {
+		t.Fatal(err)
+	}
+	a, err := archive.Open(ctx, provider, account, container, name, int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a, provider
+}
+
+func read(t *testing.T, a *archive.Archive, name string) string {
+	t.Helper()
+	reader, err := a.OpenEntry(context.Background(), name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestSupported(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"backup.zip", true},
+		{"BACKUP.ZIP", true},
+		{"logs.tar", true},
+		{"logs.tar.gz", true},
+		{"logs.tgz", true},
+		{"logs.gz", false},
+		{"zip", false},
+		{"notes.txt", false},
+	}
+	for _, test := range tests {
+		if got := archive.Supported(test.name); got != test.want {
+			t.Errorf("Supported(%q) = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestArchives(t *testing.T) {
+	tests := []struct {
+		name string
+		data func(t *testing.T) []byte
+	}{
+		{"fixture.zip", zipFixture},
+		{"fixture.tar", func(t *testing.T) []byte { return tarFixture(t, false) }},
+		{"fixture.tar.gz", func(t *testing.T) []byte { return tarFixture(t, true) }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, _ := serve(t, test.name, test.data(t))
+			want := []archive.Entry{
+				{Name: "a.txt", Size: int64(len(files[0].content)), Modified: modified},
+				{Name: "big.bin", Size: int64(len(big)), Modified: modified},
+				{Name: "docs/b.txt", Size: int64(len(files[1].content)), Modified: modified},
+				{Name: "odd.bin", Size: int64(len(files[2].content)), Modified: modified},
+			}
+			// A zip reads its times back in another location.
+			got := append([]archive.Entry(nil), a.Entries...)
+			for i := range got {
+				if got[i].Modified.Equal(modified) {
+					got[i].Modified = modified
+				}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Entries = %+v, want %+v", got, want)
+			}
+
+			for _, f := range files {
+				if f.method == noCompression && strings.HasSuffix(test.name, ".zip") {
+					continue
+				}
+				if got := read(t, a, f.name); got != f.content {
+					t.Errorf("%s holds %d bytes starting %.20q, want %d starting %.20q", f.name, len(got), got, len(f.content), f.content)
+				}
+			}
+			_, err := a.OpenEntry(context.Background(), "missing.txt")
+			if err == nil || !strings.Contains(err.Error(), "holds no file missing.txt") {
+				t.Errorf("OpenEntry of a missing name = %v", err)
+			}
+		})
+	}
+}
+
+func TestZipUnknownMethod(t *testing.T) {
+	a, _ := serve(t, "fixture.zip", zipFixture(t))
+	_, err := a.OpenEntry(context.Background(), "odd.bin")
+	if err == nil || !strings.Contains(err.Error(), "method 99") {
+		t.Errorf("OpenEntry of an unknown method = %v", err)
+	}
+}
+
+// TestZipRangedReads checks that a zip is listed from one block at its end,
+// that a file is read from its own bytes, and that headers already read are
+// not fetched again.
+func TestZipRangedReads(t *testing.T) {
+	data := zipFixture(t)
+	size := int64(len(data))
+	a, provider := serve(t, "fixture.zip", data)
+	if provider.opens != 0 || len(provider.ranges) != 1 {
+		t.Fatalf("listing read %d whole blobs and ranges %v, want one range", provider.opens, provider.ranges)
+	}
+	if at := provider.ranges[0]; at[0]+at[1] != size || at[1] >= size {
+		t.Errorf("listing read range %v of %d bytes, want a block at the end", at, size)
+	}
+
+	// The first file's header takes a block, which also holds the next
+	// file's; each file's data is one range of its packed size.
+	provider.reset()
+	read(t, a, "a.txt")
+	if len(provider.ranges) != 2 || provider.ranges[1][1] != int64(len(files[0].content)) {
+		t.Errorf("reading a.txt read ranges %v, want a header block and %d bytes", provider.ranges, len(files[0].content))
+	}
+	provider.reset()
+	read(t, a, "docs/b.txt")
+	if len(provider.ranges) != 1 || provider.ranges[0][1] >= int64(len(files[1].content)) {
+		t.Errorf("reading docs/b.txt read ranges %v, want only its deflated bytes", provider.ranges)
+	}
+	provider.reset()
+	if got := read(t, a, "big.bin"); got != big {
+		t.Error("big.bin differs")
+	}
+	if provider.opens != 0 {
+		t.Errorf("reading big.bin read %d whole blobs", provider.opens)
+	}
+}
+
+// truncated serves the mock data with ranged reads that stop at cut, without
+// an error, as a blob cut short since it was listed reads.
+type truncated struct {
+	*azure.MockProvider
+	cut int64
+}
+
+func (p *truncated) OpenBlobRange(ctx context.Context, account, container, blob string, offset, length int64) (io.ReadCloser, error) {
+	if offset >= p.cut {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+	return p.MockProvider.OpenBlobRange(ctx, account, container, blob, offset, min(length, p.cut-offset))
+}
+
+// TestZipShorterThanListed checks that a blob shorter than its listed size
+// fails to open rather than reading past what was fetched.
+func TestZipShorterThanListed(t *testing.T) {
+	data := zipFixture(t)
+	ctx := context.Background()
+	provider := &truncated{MockProvider: azure.NewMockProvider(), cut: int64(len(data) / 2)}
+	if _, err := provider.UploadBlob(ctx, account, container, "short.zip", bytes.NewReader(data), ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := archive.Open(ctx, provider, account, container, "short.zip", int64(len(data))); err == nil {
+		t.Error("opened a zip shorter than its listed size")
+	}
+}
+
+// TestTarStreams checks that a tar, having no index, is streamed from the
+// start rather than read in ranges.
+func TestTarStreams(t *testing.T) {
+	a, provider := serve(t, "fixture.tar", tarFixture(t, false))
+	if provider.opens != 1 || len(provider.ranges) != 0 {
+		t.Errorf("listing read %d whole blobs and ranges %v, want one whole", provider.opens, provider.ranges)
+	}
+	provider.reset()
+	read(t, a, "docs/b.txt")
+	if provider.opens != 1 || len(provider.ranges) != 0 {
+		t.Errorf("reading read %d whole blobs and ranges %v, want one whole", provider.opens, provider.ranges)
+	}
+}
@@ -0,0 +1,156 @@
+// Package audit keeps an append-only local record of the actions that change
+// storage or grant access to it: who ran them, on what, when, and how they
+// ended. Entries are JSON lines; the file is only ever appended to.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"storage-tui/internal/azure"
+)
+
+// Results of an action.
+const (
+	ResultOK     = "ok"
+	ResultFailed = "failed"
+)
+
+// Entry is one audited action.
+type Entry struct {
+	Time time.Time `json:"time"`
+	// User is the local account that ran the action, and Identity the
+	// credentials it reached storage with.
+	User     string `json:"user"`
+	Identity string `json:"identity,omitempty"`
+	// Action names what was done, such as upload or sas.
+	Action string `json:"action"`
+	// Target is the storage location, such as az://account/container/blob.
+	Target string `json:"target"`
+	// Detail adds what the action needs to be understood later, such as
+	// the size uploaded or the permissions granted. It holds no secrets.
+	Detail string `json:"detail,omitempty"`
+	// Replaced and Deleted name the blobs of the target's container that
+	// the action wrote over or deleted, with the ETags they had, so
+	// versioning and soft delete can bring back just those versions.
+	Replaced []azure.BlobVersion `json:"replaced,omitempty"`
+	Deleted  []azure.BlobVersion `json:"deleted,omitempty"`
+	Result   string              `json:"result"`
+	Error    string              `json:"error,omitempty"`
+}
+
+// MaxNames caps the blobs an entry names as replaced, and as deleted, so
+// the entry of a large sync stays a line Read can hold.
+const MaxNames = 1000
+
+// SetChanged names the blobs the action replaced and deleted, up to
+// MaxNames of each.
+func (e *Entry) SetChanged(replaced, deleted []azure.BlobVersion) {
+	e.Replaced = replaced[:min(len(replaced), MaxNames)]
+	e.Deleted = deleted[:min(len(deleted), MaxNames)]
+}
+
+// DefaultPath returns the audit file inside the user config directory, which
+// unlike the cache directory is not expected to be cleared.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "storage-tui", "audit.jsonl"), nil
+}
+
+// Log appends entries to an audit file held open for appending.
+type Log struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	user string
+}
+
+// Open opens the audit file at path for appending, creating it readable by
+// its owner only.
+func Open(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	name := os.Getenv("USER")
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+	return &Log{path: path, file: file, user: name}, nil
+}
+
+// Path returns the audit file's path.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Record appends entry, stamped with the time and local user when it does
+// not name them, and flushes it to disk before returning.
+func (l *Log) Record(entry Entry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	if entry.User == "" {
+		entry.User = l.user
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// One write per entry, so entries from processes sharing the file do
+	// not interleave.
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("audit log %s: %w", l.path, err)
+	}
+	return l.file.Sync()
+}
+
+// Close closes the audit file.
+func (l *Log) Close() error {
+	return l.file.Close()
+}
+
+// Read returns the entries of the audit file at path, oldest first. A
+// missing file has none; lines that do not parse are counted in skipped.
+func Read(path string) (entries []Entry, skipped int, err error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	// MaxNames blob names of up to 1 KiB each, with their ETags, twice
+	// over, fit a line.
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			skipped++
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, skipped, scanner.Err()
+}
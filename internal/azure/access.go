@@ -0,0 +1,81 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// RoleAssignment grants a principal a role on a scope, and through it on
+// everything below: a role on the subscription applies to every account in
+// it, and a role on an account to every container.
+type RoleAssignment struct {
+	// Principal is the display name, or the object ID when it has none.
+	Principal string
+	// PrincipalType is User, Group, ServicePrincipal, or ManagedIdentity.
+	PrincipalType string
+	// Role is the role definition's name, such as Storage Blob Data Reader.
+	Role string
+	// Scope is the resource ID the role is assigned on.
+	Scope string
+	// Condition narrows the assignment, such as to blobs with some index
+	// tag; empty when it applies in full.
+	Condition string
+}
+
+// mockAssignments are the role assignments of the mock subscriptions, by
+// account; scopes are relative to the account's resource group.
+var mockAssignments = map[string][]RoleAssignment{
+	"acme-dev": {
+		{Principal: "Platform Engineers", PrincipalType: "Group", Role: "Owner", Scope: ""},
+		{Principal: "Developers", PrincipalType: "Group", Role: "Storage Blob Data Contributor", Scope: "/resourceGroups/storage"},
+		{Principal: "dana@acme.example", PrincipalType: "User", Role: "Storage Blob Data Owner", Scope: "/resourceGroups/storage/providers/Microsoft.Storage/storageAccounts/acme-dev"},
+		{Principal: "telemetry-ingest", PrincipalType: "ManagedIdentity", Role: "Storage Blob Data Contributor", Scope: "/resourceGroups/storage/providers/Microsoft.Storage/storageAccounts/acme-dev/blobServices/default/containers/telemetry"},
+		{Principal: "site-deploy", PrincipalType: "ServicePrincipal", Role: "Storage Blob Data Contributor", Scope: "/resourceGroups/storage/providers/Microsoft.Storage/storageAccounts/acme-dev/blobServices/default/containers/$web"},
+	},
+	"acme-prod": {
+		{Principal: "Platform Engineers", PrincipalType: "Group", Role: "Owner", Scope: ""},
+		{Principal: "Security Auditors", PrincipalType: "Group", Role: "Storage Blob Data Reader", Scope: ""},
+		{Principal: "Operations", PrincipalType: "Group", Role: "Contributor", Scope: "/resourceGroups/storage"},
+		{Principal: "backup-agent", PrincipalType: "ManagedIdentity", Role: "Storage Blob Data Contributor", Scope: "/resourceGroups/storage/providers/Microsoft.Storage/storageAccounts/acme-prod/blobServices/default/containers/backups"},
+		{
+			Principal:     "Analytics",
+			PrincipalType: "Group",
+			Role:          "Storage Blob Data Reader",
+			Scope:         "/resourceGroups/storage/providers/Microsoft.Storage/storageAccounts/acme-prod",
+			Condition:     `@Resource[Microsoft.Storage/storageAccounts/blobServices/containers/blobs/tags:classification] StringEquals 'public'`,
+		},
+		{Principal: "cdn-origin", PrincipalType: "ServicePrincipal", Role: "Storage Blob Data Reader", Scope: "/resourceGroups/storage/providers/Microsoft.Storage/storageAccounts/acme-prod/blobServices/default/containers/public"},
+	},
+}
+
+func (m *MockProvider) ListRoleAssignments(ctx context.Context, account, container string) ([]RoleAssignment, error) {
+	if err := m.fault(ctx, "list role assignments"); err != nil {
+		return nil, err
+	}
+	subscription := ""
+	for id, accounts := range m.accounts {
+		for _, candidate := range accounts {
+			if candidate.Name == account {
+				subscription = "/subscriptions/" + id
+			}
+		}
+	}
+	if subscription == "" {
+		return nil, fmt.Errorf("account %q not found", account)
+	}
+	scope := subscription + "/resourceGroups/storage/providers/Microsoft.Storage/storageAccounts/" + account
+	if container != "" {
+		scope += "/blobServices/default/containers/" + container
+	}
+
+	var assignments []RoleAssignment
+	for _, assignment := range mockAssignments[account] {
+		assignment.Scope = subscription + assignment.Scope
+		// An assignment applies to its scope and everything below it.
+		if scope == assignment.Scope || strings.HasPrefix(scope, assignment.Scope+"/") {
+			assignments = append(assignments, assignment)
+		}
+	}
+	return assignments, nil
+}
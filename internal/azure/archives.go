@@ -0,0 +1,85 @@
+package azure
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"path"
+	"strings"
+	"time"
+)
+
+// mockArchiveFile is a file packed into a mock archive; its content comes
+// from mockContent like that of a blob.
+type mockArchiveFile struct {
+	name        string
+	contentType string
+}
+
+// mockArchives lists what each mock archive blob holds.
+var mockArchives = map[string][]mockArchiveFile{
+	"site-2024-06-03.zip": {
+		{"index.html", "text/html"},
+		{"robots.txt", "text/plain"},
+		{"css/main.css", "text/css"},
+		{"css/print.css", "text/css"},
+		{"docs/index.html", "text/html"},
+		{"docs/guide/install.html", "text/html"},
+		{"docs/guide/usage.html", "text/html"},
+		{"img/banner.png", "image/png"},
+	},
+	"logs-2024-05.tar.gz": {
+		{"README.txt", "text/plain"},
+		{"logs/2024-05-01.log", "text/plain"},
+		{"logs/2024-05-02.log", "text/plain"},
+		{"logs/2024-05-03.log", "text/plain"},
+		{"logs/archive/2024-04-30.log", "text/plain"},
+	},
+}
+
+// mockArchiveTime is when every file of the mock archives was last changed,
+// so their bytes, and sizes, are the same on every run.
+var mockArchiveTime = time.Date(2024, 6, 3, 2, 0, 0, 0, time.UTC)
+
+func isMockArchive(name string) bool {
+	_, ok := mockArchives[name]
+	return ok
+}
+
+// mockArchiveBlob lists a mock archive with the size of its content.
+func mockArchiveBlob(name, contentType string, modified time.Time) Blob {
+	return Blob{Name: name, SizeBytes: int64(len(mockArchive(name))), Modified: modified, ContentType: contentType}
+}
+
+// mockArchive packs the files of the named mock archive as a zip, or as a
+// gzipped tar.
+func mockArchive(name string) string {
+	var buffer bytes.Buffer
+	files := mockArchives[name]
+	if strings.HasSuffix(name, ".zip") {
+		writer := zip.NewWriter(&buffer)
+		for _, file := range files {
+			entry, _ := writer.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: mockArchiveTime})
+			entry.Write([]byte(mockArchiveContent(file)))
+		}
+		writer.Close()
+		return buffer.String()
+	}
+	compressed := gzip.NewWriter(&buffer)
+	writer := tar.NewWriter(compressed)
+	for _, file := range files {
+		content := mockArchiveContent(file)
+		writer.WriteHeader(&tar.Header{Name: file.name, Mode: 0o644, Size: int64(len(content)), ModTime: mockArchiveTime, Typeflag: tar.TypeReg})
+		writer.Write([]byte(content))
+	}
+	writer.Close()
+	compressed.Close()
+	return buffer.String()
+}
+
+// mockArchiveContent makes up a file's content from its base name, which
+// is what mockContent keys log days on.
+func mockArchiveContent(file mockArchiveFile) string {
+	return mockContent(Blob{Name: path.Base(file.name), ContentType: file.contentType, Modified: mockArchiveTime})
+}
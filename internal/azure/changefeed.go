@@ -0,0 +1,74 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ChangeFeedEvent is one change to a blob, as the account's change feed
+// logged it.
+type ChangeFeedEvent struct {
+	Time time.Time
+	// EventType is BlobCreated, BlobDeleted, BlobPropertiesUpdated,
+	// BlobSnapshotCreated, or BlobTierChanged.
+	EventType string
+	Container string
+	Blob      string
+	// API is the call that made the change, such as PutBlob or DeleteBlob.
+	API string
+	// ContentLength is the blob's size after the change; zero for deletions.
+	ContentLength int64
+}
+
+// ChangeFeedOptions selects the events of a change feed read.
+type ChangeFeedOptions struct {
+	// Start and End bound the event times; a zero time leaves that end open.
+	Start time.Time
+	End   time.Time
+	// MaxEvents keeps only the latest events; zero keeps every one.
+	MaxEvents int
+}
+
+// mockChanges are the changes the mock feed cycles through, from the
+// latest back.
+var mockChanges = []ChangeFeedEvent{
+	{EventType: "BlobCreated", Container: "backups", Blob: "db-2024-05-01.bak", API: "PutBlockList", ContentLength: 358717440},
+	{EventType: "BlobPropertiesUpdated", Container: "public", Blob: "index.html", API: "SetBlobProperties", ContentLength: 2214},
+	{EventType: "BlobDeleted", Container: "backups", Blob: "db-2024-04-01.bak", API: "DeleteBlob"},
+	{EventType: "BlobCreated", Container: "public", Blob: "robots.txt", API: "PutBlob", ContentLength: 58},
+	{EventType: "BlobTierChanged", Container: "backups", Blob: "db-2024-05-01.bak", API: "SetBlobTier", ContentLength: 358717440},
+	{EventType: "BlobSnapshotCreated", Container: "public", Blob: "index.html", API: "SnapshotBlob", ContentLength: 2214},
+	{EventType: "BlobCreated", Container: "public", Blob: "index.html", API: "PutBlob", ContentLength: 2214},
+	{EventType: "BlobDeleted", Container: "public", Blob: "old/landing.html", API: "DeleteBlob"},
+}
+
+func (m *MockProvider) ListChangeFeedEvents(ctx context.Context, account string, opts ChangeFeedOptions) ([]ChangeFeedEvent, error) {
+	if err := m.fault(ctx, "list change feed events"); err != nil {
+		return nil, err
+	}
+	if _, ok := m.containers[account]; !ok {
+		return nil, fmt.Errorf("account %q not found", account)
+	}
+	if account != "acme-prod" {
+		return nil, fmt.Errorf("the change feed is not enabled on account %q", account)
+	}
+
+	// The feed holds a change every 53 minutes over the 90 days it is kept.
+	const every = 53 * time.Minute
+	latest := time.Now().UTC().Truncate(time.Minute).Add(-7 * time.Minute)
+	count := int(90 * 24 * time.Hour / every)
+	var events []ChangeFeedEvent
+	for i := count - 1; i >= 0; i-- {
+		event := mockChanges[i%len(mockChanges)]
+		event.Time = latest.Add(-time.Duration(i) * every)
+		if !opts.Start.IsZero() && event.Time.Before(opts.Start) || !opts.End.IsZero() && !event.Time.Before(opts.End) {
+			continue
+		}
+		events = append(events, event)
+	}
+	if opts.MaxEvents > 0 && len(events) > opts.MaxEvents {
+		events = events[len(events)-opts.MaxEvents:]
+	}
+	return events, nil
+}
@@ -0,0 +1,86 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func (m *MockProvider) OpenBlob(ctx context.Context, account, container, blob string) (io.ReadCloser, error) {
+	if err := m.fault(ctx, "open blob"); err != nil {
+		return nil, err
+	}
+	content, err := m.content(account, container, blob)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
+// OpenBlobRange reads part of the same content OpenBlob does.
+func (m *MockProvider) OpenBlobRange(ctx context.Context, account, container, blob string, offset, length int64) (io.ReadCloser, error) {
+	if err := m.fault(ctx, "open blob range"); err != nil {
+		return nil, err
+	}
+	content, err := m.content(account, container, blob)
+	if err != nil {
+		return nil, err
+	}
+	if offset < 0 || length < 0 || offset > int64(len(content)) {
+		return nil, fmt.Errorf("range %d+%d is outside blob %q of %d bytes", offset, length, blob, len(content))
+	}
+	end := min(offset+length, int64(len(content)))
+	return io.NopCloser(strings.NewReader(content[offset:end])), nil
+}
+
+func (m *MockProvider) content(account, container, blob string) (string, error) {
+	if content, ok := m.uploads[account+"/"+container+"/"+blob]; ok {
+		return content, nil
+	}
+	for _, candidate := range m.blobs[account][container] {
+		if candidate.Name == blob {
+			return mockContent(candidate), nil
+		}
+	}
+	return "", fmt.Errorf("blob %q not found in %s/%s", blob, account, container)
+}
+
+// mockContent makes up content for a mock blob from its name and type.
+func mockContent(blob Blob) string {
+	var builder strings.Builder
+	switch {
+	case strings.HasSuffix(blob.Name, ".log"):
+		day := strings.TrimSuffix(blob.Name, ".log")
+		for hour := 0; hour < 24; hour++ {
+			fmt.Fprintf(&builder, "%sT%02d:12:00Z INFO job=ingest msg=\"started\"\n", day, hour)
+			if hour%7 == 3 {
+				fmt.Fprintf(&builder, "%sT%02d:12:01Z ERROR job=ingest msg=\"upload timed out\" attempt=%d\n", day, hour, hour%3+1)
+			}
+			fmt.Fprintf(&builder, "%sT%02d:12:02Z INFO job=ingest msg=\"completed\"\n", day, hour)
+		}
+	case strings.HasPrefix(blob.Name, "events-"):
+		var id int
+		fmt.Sscanf(blob.Name, "events-%d.json", &id)
+		status := 200
+		if id%97 == 0 {
+			status = 500
+		}
+		fmt.Fprintf(&builder, "{\"id\": %d, \"event\": \"page_view\", \"status\": %d, \"at\": %q}\n", id, status, blob.Modified.Format("2006-01-02T15:04:05Z"))
+	case blob.ContentType == "text/html":
+		fmt.Fprintf(&builder, "<!doctype html>\n<html>\n  <head>\n    <title>%s</title>\n    <link rel=\"stylesheet\" href=\"/css/main.css\">\n  </head>\n  <body>\n    <h1>%s</h1>\n    <p>Mock HTML content.</p>\n  </body>\n</html>\n", blob.Name, blob.Name)
+	case blob.ContentType == "text/css":
+		builder.WriteString("body {\n  font-family: sans-serif;\n  color: #222;\n}\n\nh1 {\n  font-size: 2rem;\n}\n")
+	case blob.Name == "robots.txt":
+		builder.WriteString("User-agent: *\nDisallow: /private\n")
+	case isMockArchive(blob.Name):
+		builder.WriteString(mockArchive(blob.Name))
+	case strings.HasPrefix(blob.ContentType, "text/"):
+		fmt.Fprintf(&builder, "Mock content of %s.\n", blob.Name)
+	default:
+		// Binary placeholder: a header followed by NUL bytes.
+		builder.WriteString("\x89MOCK\r\n\x1a\n")
+		builder.WriteString(strings.Repeat("\x00", 64))
+	}
+	return builder.String()
+}
@@ -0,0 +1,122 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// CORSRule lets web pages served from AllowedOrigins call the blob service
+// from a browser.
+type CORSRule struct {
+	// AllowedOrigins are origins such as https://app.example.com, or *.
+	AllowedOrigins []string
+	// AllowedMethods are the HTTP methods the origins may use.
+	AllowedMethods []string
+	// AllowedHeaders are the request headers the origins may send, and
+	// ExposedHeaders the response headers their scripts may read; a header
+	// ending in * stands for every header with that prefix.
+	AllowedHeaders []string
+	ExposedHeaders []string
+	// MaxAgeSeconds is how long a browser may keep the preflight answer.
+	MaxAgeSeconds int
+}
+
+// CORSMethods are the methods a CORS rule may allow.
+var CORSMethods = []string{"DELETE", "GET", "HEAD", "MERGE", "OPTIONS", "PATCH", "POST", "PUT"}
+
+// MaxCORSRules is how many CORS rules the blob service keeps.
+const MaxCORSRules = 5
+
+// CORSEditor is implemented by providers that can change CORS rules.
+type CORSEditor interface {
+	// SetCORSRules replaces the CORS rules of the account's blob service.
+	SetCORSRules(ctx context.Context, account string, rules []CORSRule) error
+}
+
+// ValidateCORSRules checks rules against the limits of the blob service, so
+// a change fails before it is sent.
+func ValidateCORSRules(rules []CORSRule) error {
+	if len(rules) > MaxCORSRules {
+		return fmt.Errorf("the blob service keeps at most %d CORS rules", MaxCORSRules)
+	}
+	for i, rule := range rules {
+		if err := validateCORSRule(rule); err != nil {
+			return fmt.Errorf("rule %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func validateCORSRule(rule CORSRule) error {
+	if len(rule.AllowedOrigins) == 0 {
+		return fmt.Errorf("allow at least one origin, or *")
+	}
+	for _, origin := range rule.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		parsed, err := url.Parse(origin)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" || strings.Trim(parsed.Path, "/") != "" {
+			return fmt.Errorf("origin %q is not * or a scheme and host, such as https://app.example.com", origin)
+		}
+	}
+	if len(rule.AllowedMethods) == 0 {
+		return fmt.Errorf("allow at least one method")
+	}
+	for _, method := range rule.AllowedMethods {
+		if !slices.Contains(CORSMethods, method) {
+			return fmt.Errorf("method %q is not one of %s", method, strings.Join(CORSMethods, ", "))
+		}
+	}
+	if len(rule.AllowedHeaders) == 0 {
+		return fmt.Errorf("allow at least one request header, or *")
+	}
+	if rule.MaxAgeSeconds < 0 {
+		return fmt.Errorf("the max age cannot be negative")
+	}
+	return nil
+}
+
+// SetCORSRules keeps the rules in memory, so they last as long as the
+// provider.
+func (m *MockProvider) SetCORSRules(ctx context.Context, account string, rules []CORSRule) error {
+	if err := m.fault(ctx, "set CORS rules"); err != nil {
+		return err
+	}
+	if _, ok := m.containers[account]; !ok {
+		return fmt.Errorf("account %q not found", account)
+	}
+	if err := ValidateCORSRules(rules); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cors == nil {
+		m.cors = make(map[string][]CORSRule)
+	}
+	m.cors[account] = slices.Clone(rules)
+	return nil
+}
+
+// corsRules returns the account's CORS rules: those set, or else the ones
+// the mock data starts with.
+func (m *MockProvider) corsRules(account string) []CORSRule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rules, ok := m.cors[account]; ok {
+		return slices.Clone(rules)
+	}
+	if account == "acme-dev" {
+		return []CORSRule{{
+			AllowedOrigins: []string{"https://dev.acme.example", "http://localhost:3000"},
+			AllowedMethods: []string{"GET", "HEAD", "OPTIONS", "PUT"},
+			AllowedHeaders: []string{"*"},
+			ExposedHeaders: []string{"x-ms-meta-*", "Content-Length"},
+			MaxAgeSeconds:  3600,
+		}}
+	}
+	return nil
+}
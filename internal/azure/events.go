@@ -0,0 +1,76 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// BlobEvent is a blob created or deleted, as the account's storage events
+// reported it.
+type BlobEvent struct {
+	Time time.Time
+	// EventType is BlobCreated or BlobDeleted.
+	EventType string
+	// Blob is the blob as it was created; only its name is set for
+	// deletions.
+	Blob Blob
+}
+
+// EventSubscriber is implemented by providers that can push the changes to
+// a container as they happen, such as from the Event Grid storage events
+// of the account delivered to a queue.
+type EventSubscriber interface {
+	// WatchBlobEvents calls send with each blob created or deleted in the
+	// container, in order, until ctx is done or the subscription fails.
+	WatchBlobEvents(ctx context.Context, account, container string, send func(BlobEvent)) error
+}
+
+// mockEventInterval is how often the mock writes a blob to acme-dev/logs.
+const mockEventInterval = 5 * time.Second
+
+// mockLiveBlobs is how many blobs the mock's writer keeps before deleting
+// the oldest.
+const mockLiveBlobs = 3
+
+// WatchBlobEvents makes up a writer appending a log blob to acme-dev/logs
+// every few seconds and deleting old ones; other containers stay quiet. The
+// events do not change the mock's listings, just as its signatures grant
+// nothing.
+func (m *MockProvider) WatchBlobEvents(ctx context.Context, account, container string, send func(BlobEvent)) error {
+	if err := m.fault(ctx, "watch blob events"); err != nil {
+		return err
+	}
+	if !m.hasContainer(account, container) {
+		return fmt.Errorf("container %s/%s not found", account, container)
+	}
+	if account != "acme-dev" || container != "logs" {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	ticker := time.NewTicker(mockEventInterval)
+	defer ticker.Stop()
+	var live []string
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			now = now.UTC().Truncate(time.Second)
+			blob := Blob{
+				Name:        "live/" + now.Format("2006-01-02T15-04-05") + ".log",
+				SizeBytes:   int64(4096 + now.Unix()%8192),
+				Modified:    now,
+				ContentType: "text/plain",
+			}
+			blob.ETag = mockBlobETag(account, container, blob)
+			send(BlobEvent{Time: now, EventType: "BlobCreated", Blob: blob})
+			live = append(live, blob.Name)
+			if len(live) > mockLiveBlobs {
+				send(BlobEvent{Time: now, EventType: "BlobDeleted", Blob: Blob{Name: live[0]}})
+				live = live[1:]
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Faults makes a mock provider misbehave the way the service can, so the
+// browser's spinners, error paths, retries, and paging can be tried out on
+// demand.
+type Faults struct {
+	// Latency is added to every call, with up to Jitter more on top.
+	Latency time.Duration
+	Jitter  time.Duration
+	// ErrorRate and ThrottleRate are the fractions of calls, from 0 to 1,
+	// that fail with a server error or are throttled.
+	ErrorRate    float64
+	ThrottleRate float64
+	// RetryAfter is how long throttled calls ask to wait; zero asks for
+	// nothing, leaving the delay to the caller.
+	RetryAfter time.Duration
+	// HugeListing adds a container named huge, holding this many blobs, to
+	// the first account.
+	HugeListing int
+	// Seed picks which calls fail and how long each takes. The same seed
+	// fails the same calls of the same sequence of calls.
+	Seed uint64
+}
+
+// hugeContainer is the container Faults.HugeListing adds.
+const hugeContainer = "huge"
+
+// hugeFolderSize is how many blobs each folder of the huge container holds.
+const hugeFolderSize = 1000
+
+// faultState is the faults a mock provider injects, with the random source
+// that decides them.
+type faultState struct {
+	Faults
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// InjectFaults makes m misbehave as faults say from now on. Call it before
+// handing m to the browser.
+func (m *MockProvider) InjectFaults(faults Faults) error {
+	var problems []error
+	if faults.Latency < 0 || faults.Jitter < 0 || faults.RetryAfter < 0 {
+		problems = append(problems, errors.New("fault latency, jitter, and retryAfter cannot be negative"))
+	}
+	if faults.ErrorRate < 0 || faults.ThrottleRate < 0 || faults.ErrorRate+faults.ThrottleRate > 1 {
+		problems = append(problems, fmt.Errorf("fault errorRate and throttleRate are fractions adding up to at most 1, not %g and %g", faults.ErrorRate, faults.ThrottleRate))
+	}
+	if faults.HugeListing < 0 {
+		problems = append(problems, fmt.Errorf("fault hugeListing cannot be negative, not %d", faults.HugeListing))
+	}
+	if faults.HugeListing > 0 && len(m.subscriptions) == 0 {
+		problems = append(problems, errors.New("fault hugeListing needs an account to add its container to"))
+	}
+	if len(problems) > 0 {
+		return errors.Join(problems...)
+	}
+
+	if faults.HugeListing > 0 {
+		if err := m.addHugeContainer(faults.HugeListing); err != nil {
+			return err
+		}
+	}
+	m.faults = &faultState{Faults: faults, rand: rand.New(rand.NewPCG(faults.Seed, faults.Seed))}
+	return nil
+}
+
+// addHugeContainer adds the huge container to the first account of the first
+// subscription, its blobs spread over folders of hugeFolderSize.
+func (m *MockProvider) addHugeContainer(count int) error {
+	accounts := m.accounts[m.subscriptions[0].ID]
+	if len(accounts) == 0 {
+		return fmt.Errorf("fault hugeListing needs an account in subscription %s to add its container to", m.subscriptions[0].ID)
+	}
+	account := accounts[0].Name
+	if m.hasContainer(account, hugeContainer) {
+		return fmt.Errorf("account %s already has a container named %s", account, hugeContainer)
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	blobs := make([]Blob, count)
+	for i := range blobs {
+		blobs[i] = Blob{
+			Name:        fmt.Sprintf("part-%04d/blob-%08d.bin", i/hugeFolderSize, i),
+			SizeBytes:   int64(512 + (i*7919)%1048576),
+			Modified:    start.Add(time.Duration(i) * time.Second),
+			ContentType: "application/octet-stream",
+			AccessTier:  "Hot",
+		}
+	}
+	m.containers[account] = append(m.containers[account], Container{Name: hugeContainer, PublicAccess: "private"})
+	if m.blobs[account] == nil {
+		m.blobs[account] = make(map[string][]Blob)
+	}
+	m.blobs[account][hugeContainer] = blobs
+	return nil
+}
+
+// fault holds up a call for the injected latency, then fails it when its
+// turn to fail has come. Without faults it returns at once, failing only
+// calls whose context is already done, as the service's would.
+func (m *MockProvider) fault(ctx context.Context, op string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f := m.faults
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	delay := f.Latency
+	if f.Jitter > 0 {
+		delay += time.Duration(f.rand.Int64N(int64(f.Jitter) + 1))
+	}
+	roll := f.rand.Float64()
+	f.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	switch {
+	case roll < f.ErrorRate:
+		return fmt.Errorf("%s: 500 Internal Server Error (injected fault)", op)
+	case roll < f.ErrorRate+f.ThrottleRate:
+		return &ThrottledError{RetryAfter: f.RetryAfter}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+)
+
+// NetworkRules are the firewall settings of an account, which decide where
+// data-plane calls may come from.
+type NetworkRules struct {
+	// PublicNetworkAccess is Enabled, Disabled, or SecuredByPerimeter.
+	PublicNetworkAccess string
+	// DefaultAction is Allow or Deny, for calls from public networks that no
+	// rule matches.
+	DefaultAction string
+	// Bypass lists traffic let through regardless, such as AzureServices.
+	Bypass []string
+	// IPRules are the addresses and CIDR ranges allowed.
+	IPRules []string
+	// VirtualNetworkRules are the subnets allowed.
+	VirtualNetworkRules []VirtualNetworkRule
+	// PrivateEndpoints are the private endpoint connections to the account.
+	PrivateEndpoints []PrivateEndpoint
+}
+
+// VirtualNetworkRule allows a subnet, named by its resource ID.
+type VirtualNetworkRule struct {
+	Subnet string
+	State  string
+}
+
+// PrivateEndpoint is a private endpoint connection and whether the account
+// approved it: Approved, Pending, Rejected, or Disconnected.
+type PrivateEndpoint struct {
+	Name        string
+	Status      string
+	Description string
+}
+
+func (m *MockProvider) GetNetworkRules(ctx context.Context, account string) (NetworkRules, error) {
+	if err := m.fault(ctx, "get network rules"); err != nil {
+		return NetworkRules{}, err
+	}
+	switch account {
+	case "acme-dev":
+		return NetworkRules{PublicNetworkAccess: "Enabled", DefaultAction: "Allow", Bypass: []string{"AzureServices"}}, nil
+	case "acme-prod":
+		return NetworkRules{
+			PublicNetworkAccess: "Enabled",
+			DefaultAction:       "Deny",
+			Bypass:              []string{"AzureServices", "Logging", "Metrics"},
+			IPRules:             []string{"203.0.113.0/24", "198.51.100.7"},
+			VirtualNetworkRules: []VirtualNetworkRule{{
+				Subnet: "/subscriptions/sub-prod/resourceGroups/network/providers/Microsoft.Network/virtualNetworks/prod-vnet/subnets/app",
+				State:  "Succeeded",
+			}},
+			PrivateEndpoints: []PrivateEndpoint{
+				{Name: "acme-prod-blob-pe", Status: "Approved", Description: "Auto-approved"},
+				{Name: "analytics-blob-pe", Status: "Pending", Description: "Requested by the analytics team"},
+			},
+		}, nil
+	}
+	return NetworkRules{}, fmt.Errorf("account %q not found", account)
+}
@@ -0,0 +1,196 @@
+package azure
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixture describes the data a mock provider serves in place of the
+// built-in acme accounts, for demos and UI tests. It is read from YAML or,
+// as YAML takes it too, JSON.
+type Fixture struct {
+	Subscriptions []FixtureSubscription `yaml:"subscriptions"`
+}
+
+type FixtureSubscription struct {
+	ID   string `yaml:"id"`
+	Name string `yaml:"name"`
+	// Accounts are the subscription's storage accounts; their names are
+	// unique across the fixture, as they are in Azure.
+	Accounts []FixtureAccount `yaml:"accounts"`
+}
+
+type FixtureAccount struct {
+	Name   string `yaml:"name"`
+	Region string `yaml:"region"`
+	// SKU and Kind default to Standard_LRS and StorageV2.
+	SKU                   string             `yaml:"sku"`
+	Kind                  string             `yaml:"kind"`
+	HierarchicalNamespace bool               `yaml:"hierarchicalNamespace"`
+	Containers            []FixtureContainer `yaml:"containers"`
+}
+
+type FixtureContainer struct {
+	Name string `yaml:"name"`
+	// PublicAccess is private, blob, or container; private by default.
+	PublicAccess string        `yaml:"publicAccess"`
+	Blobs        []FixtureBlob `yaml:"blobs"`
+}
+
+type FixtureBlob struct {
+	Name string `yaml:"name"`
+	// Size defaults to the length of Content.
+	Size        int64     `yaml:"size"`
+	Modified    time.Time `yaml:"modified"`
+	ContentType string    `yaml:"contentType"`
+	Tier        string    `yaml:"tier"`
+	// Content is what previews and reads of the blob return; without it,
+	// content is made up from the name and type as for the built-in data.
+	Content string `yaml:"content"`
+}
+
+// LoadFixture reads a fixture file and returns a mock provider serving it.
+func LoadFixture(path string) (*MockProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixture Fixture
+	if err := yaml.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	m, err := NewFixtureProvider(fixture)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// NewFixtureProvider returns a mock provider serving fixture, after
+// checking that its names are there and unique where Azure needs them to be.
+// Blobs are listed by name, as Azure lists them.
+func NewFixtureProvider(fixture Fixture) (*MockProvider, error) {
+	m := &MockProvider{
+		accounts:   make(map[string][]Account),
+		containers: make(map[string][]Container),
+		blobs:      make(map[string]map[string][]Blob),
+		uploads:    make(map[string]string),
+	}
+	var problems []error
+	subscriptions := make(map[string]bool)
+	for _, subscription := range fixture.Subscriptions {
+		switch {
+		case subscription.ID == "":
+			problems = append(problems, fmt.Errorf("subscription %q has no id", subscription.Name))
+			continue
+		case subscriptions[subscription.ID]:
+			problems = append(problems, fmt.Errorf("subscription %s is defined twice", subscription.ID))
+			continue
+		}
+		subscriptions[subscription.ID] = true
+		name := subscription.Name
+		if name == "" {
+			name = subscription.ID
+		}
+		m.subscriptions = append(m.subscriptions, Subscription{ID: subscription.ID, Name: name})
+		for _, account := range subscription.Accounts {
+			if err := m.addFixtureAccount(subscription.ID, account); err != nil {
+				problems = append(problems, err)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.Join(problems...)
+	}
+	return m, nil
+}
+
+func (m *MockProvider) addFixtureAccount(subscriptionID string, account FixtureAccount) error {
+	switch {
+	case account.Name == "":
+		return fmt.Errorf("an account of subscription %s has no name", subscriptionID)
+	case m.blobs[account.Name] != nil:
+		return fmt.Errorf("account %s is defined twice", account.Name)
+	}
+	sku, kind := account.SKU, account.Kind
+	if sku == "" {
+		sku = "Standard_LRS"
+	}
+	if kind == "" {
+		kind = "StorageV2"
+	}
+	m.accounts[subscriptionID] = append(m.accounts[subscriptionID], Account{
+		Name:                  account.Name,
+		Region:                account.Region,
+		SKU:                   sku,
+		Kind:                  kind,
+		PrimaryEndpoint:       fmt.Sprintf("https://%s.blob.core.windows.net/", account.Name),
+		HierarchicalNamespace: account.HierarchicalNamespace,
+		MinimumTLSVersion:     "TLS1_2",
+		Encryption:            AccountEncryption{KeySource: "Microsoft.Storage"},
+	})
+	m.blobs[account.Name] = make(map[string][]Blob)
+
+	var problems []error
+	for _, container := range account.Containers {
+		if container.Name == "" {
+			problems = append(problems, fmt.Errorf("a container of account %s has no name", account.Name))
+			continue
+		}
+		if _, ok := m.blobs[account.Name][container.Name]; ok {
+			problems = append(problems, fmt.Errorf("container %s/%s is defined twice", account.Name, container.Name))
+			continue
+		}
+		access := container.PublicAccess
+		if access == "" {
+			access = "private"
+		}
+		m.containers[account.Name] = append(m.containers[account.Name], Container{Name: container.Name, PublicAccess: access})
+
+		blobs := make([]Blob, 0, len(container.Blobs))
+		seen := make(map[string]bool)
+		for _, blob := range container.Blobs {
+			if blob.Name == "" || seen[blob.Name] {
+				problems = append(problems, fmt.Errorf("container %s/%s has a blob without a name, or two named %q", account.Name, container.Name, blob.Name))
+				continue
+			}
+			seen[blob.Name] = true
+			blobs = append(blobs, m.addFixtureBlob(account.Name, container.Name, blob))
+		}
+		sort.Slice(blobs, func(i, j int) bool { return blobs[i].Name < blobs[j].Name })
+		m.blobs[account.Name][container.Name] = blobs
+	}
+	return errors.Join(problems...)
+}
+
+// addFixtureBlob keeps the blob's content, when it gives one, where OpenBlob
+// looks first.
+func (m *MockProvider) addFixtureBlob(account, container string, blob FixtureBlob) Blob {
+	size := blob.Size
+	if blob.Content != "" {
+		m.uploads[account+"/"+container+"/"+blob.Name] = blob.Content
+		if size == 0 {
+			size = int64(len(blob.Content))
+		}
+	}
+	contentType := blob.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+		if blob.Content != "" && !strings.ContainsRune(blob.Content, 0) {
+			contentType = "text/plain"
+		}
+	}
+	return Blob{
+		Name:        blob.Name,
+		SizeBytes:   size,
+		Modified:    blob.Modified.UTC(),
+		ContentType: contentType,
+		AccessTier:  blob.Tier,
+	}
+}
@@ -0,0 +1,168 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// States of an immutability policy.
+const (
+	// ImmutabilityUnlocked policies protect blobs but can still be
+	// shortened, changed, or deleted, for trying out a retention period.
+	ImmutabilityUnlocked = "Unlocked"
+	// ImmutabilityLocked policies can only be extended, and never deleted
+	// while the container holds blobs; locking cannot be undone.
+	ImmutabilityLocked = "Locked"
+)
+
+// MaxImmutabilityDays is the longest retention period of a policy.
+const MaxImmutabilityDays = 146000
+
+// ImmutabilityPolicy is a container's time-based retention policy: blobs
+// cannot be changed or deleted until PeriodDays after they were last
+// written. Its State is empty when the container has none.
+type ImmutabilityPolicy struct {
+	State      string
+	PeriodDays int
+	// AllowProtectedAppendWrites lets append blobs take new blocks while
+	// what they hold stays protected.
+	AllowProtectedAppendWrites bool
+}
+
+// ContainerImmutability is what keeps a container's blobs from being
+// changed or deleted: its retention policy and legal holds.
+type ContainerImmutability struct {
+	Policy ImmutabilityPolicy
+	// LegalHoldTags name the legal holds, each of which protects the blobs
+	// until it is cleared, however long the retention period.
+	LegalHoldTags []string
+}
+
+// ImmutabilityEditor is implemented by providers that can change
+// immutability policies.
+type ImmutabilityEditor interface {
+	// SetImmutabilityPolicy creates the container's policy, unlocked, or
+	// changes it; a locked policy can only be extended.
+	SetImmutabilityPolicy(ctx context.Context, account, container string, days int, allowAppend bool) error
+	// LockImmutabilityPolicy locks the container's unlocked policy for good.
+	LockImmutabilityPolicy(ctx context.Context, account, container string) error
+}
+
+// ValidateImmutabilityPolicy checks changing current to days and
+// allowAppend against what the blob service allows, so a change fails
+// before it is sent.
+func ValidateImmutabilityPolicy(current ImmutabilityPolicy, days int, allowAppend bool) error {
+	switch {
+	case days < 1 || days > MaxImmutabilityDays:
+		return fmt.Errorf("the retention period is 1 to %d days", MaxImmutabilityDays)
+	case current.State != ImmutabilityLocked:
+		return nil
+	case days <= current.PeriodDays:
+		return fmt.Errorf("a locked policy can only be extended, beyond its %d days", current.PeriodDays)
+	case allowAppend != current.AllowProtectedAppendWrites:
+		return fmt.Errorf("a locked policy keeps its protected append writes setting")
+	}
+	return nil
+}
+
+// mockImmutability are the policies the mock containers start with, by
+// account/container.
+var mockImmutability = map[string]ImmutabilityPolicy{
+	"acme-dev/logs":     {State: ImmutabilityUnlocked, PeriodDays: 30, AllowProtectedAppendWrites: true},
+	"acme-prod/backups": {State: ImmutabilityLocked, PeriodDays: 365},
+}
+
+// mockLegalHolds are the legal holds of the mock containers.
+var mockLegalHolds = map[string][]string{
+	"acme-prod/backups": {"audit2024"},
+}
+
+func (m *MockProvider) GetContainerImmutability(ctx context.Context, account, container string) (ContainerImmutability, error) {
+	if err := m.fault(ctx, "get container immutability"); err != nil {
+		return ContainerImmutability{}, err
+	}
+	if err := m.checkContainer(account, container); err != nil {
+		return ContainerImmutability{}, err
+	}
+	key := account + "/" + container
+	return ContainerImmutability{Policy: m.immutabilityPolicy(key), LegalHoldTags: slices.Clone(mockLegalHolds[key])}, nil
+}
+
+// SetImmutabilityPolicy keeps the policy in memory, so it lasts as long as
+// the provider.
+func (m *MockProvider) SetImmutabilityPolicy(ctx context.Context, account, container string, days int, allowAppend bool) error {
+	if err := m.fault(ctx, "set immutability policy"); err != nil {
+		return err
+	}
+	if err := m.checkContainer(account, container); err != nil {
+		return err
+	}
+	key := account + "/" + container
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current := m.storedImmutability(key)
+	if err := ValidateImmutabilityPolicy(current, days, allowAppend); err != nil {
+		return err
+	}
+	state := current.State
+	if state == "" {
+		state = ImmutabilityUnlocked
+	}
+	m.storeImmutability(key, ImmutabilityPolicy{State: state, PeriodDays: days, AllowProtectedAppendWrites: allowAppend})
+	return nil
+}
+
+func (m *MockProvider) LockImmutabilityPolicy(ctx context.Context, account, container string) error {
+	if err := m.fault(ctx, "lock immutability policy"); err != nil {
+		return err
+	}
+	if err := m.checkContainer(account, container); err != nil {
+		return err
+	}
+	key := account + "/" + container
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	policy := m.storedImmutability(key)
+	switch policy.State {
+	case "":
+		return fmt.Errorf("%s has no immutability policy to lock", key)
+	case ImmutabilityLocked:
+		return fmt.Errorf("the immutability policy of %s is already locked", key)
+	}
+	policy.State = ImmutabilityLocked
+	m.storeImmutability(key, policy)
+	return nil
+}
+
+func (m *MockProvider) checkContainer(account, container string) error {
+	for _, candidate := range m.containers[account] {
+		if candidate.Name == container {
+			return nil
+		}
+	}
+	return fmt.Errorf("container %q not found in %s", container, account)
+}
+
+// immutabilityPolicy returns the policy of account/container: the one set,
+// or else the one the mock data starts with.
+func (m *MockProvider) immutabilityPolicy(key string) ImmutabilityPolicy {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.storedImmutability(key)
+}
+
+// storedImmutability and storeImmutability expect m.mu to be held.
+func (m *MockProvider) storedImmutability(key string) ImmutabilityPolicy {
+	if policy, ok := m.immutability[key]; ok {
+		return policy
+	}
+	return mockImmutability[key]
+}
+
+func (m *MockProvider) storeImmutability(key string, policy ImmutabilityPolicy) {
+	if m.immutability == nil {
+		m.immutability = make(map[string]ImmutabilityPolicy)
+	}
+	m.immutability[key] = policy
+}
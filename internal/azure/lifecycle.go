@@ -0,0 +1,193 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// LifecyclePolicy is an account's lifecycle management policy, whose rules
+// move blobs to cooler tiers and delete them as they age. An account without
+// a policy has no rules.
+type LifecyclePolicy struct {
+	LastModified time.Time
+	Rules        []LifecycleRule
+}
+
+// LifecycleRule is one rule of a lifecycle policy: the blobs its filters
+// select, and what its actions do to them.
+type LifecycleRule struct {
+	Name    string
+	Enabled bool
+	// BlobTypes, such as blockBlob, are the blob types the rule applies to.
+	BlobTypes []string
+	// PrefixMatch selects blobs whose names, container first, start with any
+	// of the prefixes; empty selects every blob.
+	PrefixMatch []string
+	// TagMatch selects blobs whose index tags pass every condition.
+	TagMatch []TagCondition
+	Actions  []LifecycleAction
+}
+
+// LifecycleAction is what a rule does to a blob, or to its snapshots or
+// previous versions, once Days have passed since Since.
+type LifecycleAction struct {
+	// Target is "base blob", "snapshot", or "version".
+	Target string
+	// Action is tierToCool, tierToCold, tierToArchive, or delete.
+	Action string
+	// Since is the time the days are counted from: modification, creation,
+	// last access, or last tier change.
+	Since string
+	Days  int
+}
+
+// MaxLifecycleRules is how many rules a lifecycle policy holds.
+const MaxLifecycleRules = 100
+
+// LifecycleEditor is implemented by providers that can change lifecycle
+// policies.
+type LifecycleEditor interface {
+	// SetLifecyclePolicy replaces the rules of the account's lifecycle
+	// policy through the management plane.
+	SetLifecyclePolicy(ctx context.Context, account string, rules []LifecycleRule) error
+}
+
+// ValidateLifecycleRules checks rules against the limits of lifecycle
+// management, so a change fails before it is sent.
+func ValidateLifecycleRules(rules []LifecycleRule) error {
+	if len(rules) > MaxLifecycleRules {
+		return fmt.Errorf("a lifecycle policy holds at most %d rules", MaxLifecycleRules)
+	}
+	names := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if names[rule.Name] {
+			return fmt.Errorf("there is already a rule named %q", rule.Name)
+		}
+		names[rule.Name] = true
+		if err := validateLifecycleRule(rule); err != nil {
+			return fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+	}
+	return nil
+}
+
+func validateLifecycleRule(rule LifecycleRule) error {
+	invalid := func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_')
+	}
+	if rule.Name == "" || len(rule.Name) > 256 || strings.ContainsFunc(rule.Name, invalid) {
+		return fmt.Errorf("the name takes up to 256 letters, digits, hyphens, and underscores")
+	}
+	if len(rule.BlobTypes) == 0 {
+		return fmt.Errorf("apply it to at least one blob type")
+	}
+	for _, prefix := range rule.PrefixMatch {
+		if strings.HasPrefix(prefix, "/") || prefix == "" {
+			return fmt.Errorf("prefix %q does not start with a container name, such as logs/", prefix)
+		}
+	}
+	if len(rule.Actions) == 0 {
+		return fmt.Errorf("give it at least one action")
+	}
+	days := map[string]int{}
+	for _, action := range rule.Actions {
+		if action.Days < 0 {
+			return fmt.Errorf("%s cannot come a negative number of days after %s", action.Action, action.Since)
+		}
+		if action.Target == "base blob" {
+			days[action.Action] = action.Days
+		}
+	}
+	if deleteDays, ok := days["delete"]; ok {
+		for _, tier := range []string{"tierToCool", "tierToCold", "tierToArchive"} {
+			if tierDays, ok := days[tier]; ok && tierDays >= deleteDays {
+				return fmt.Errorf("the delete after %d days comes before the %s after %d days", deleteDays, tier, tierDays)
+			}
+		}
+	}
+	return nil
+}
+
+// SetLifecyclePolicy keeps the rules in memory, so they last as long as the
+// provider.
+func (m *MockProvider) SetLifecyclePolicy(ctx context.Context, account string, rules []LifecycleRule) error {
+	if err := m.fault(ctx, "set lifecycle policy"); err != nil {
+		return err
+	}
+	if _, ok := m.containers[account]; !ok {
+		return fmt.Errorf("account %q not found", account)
+	}
+	if err := ValidateLifecycleRules(rules); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.lifecycle == nil {
+		m.lifecycle = make(map[string]LifecyclePolicy)
+	}
+	m.lifecycle[account] = LifecyclePolicy{LastModified: time.Now().UTC(), Rules: slices.Clone(rules)}
+	return nil
+}
+
+func (m *MockProvider) GetLifecyclePolicy(ctx context.Context, account string) (LifecyclePolicy, error) {
+	if err := m.fault(ctx, "get lifecycle policy"); err != nil {
+		return LifecyclePolicy{}, err
+	}
+	m.mu.Lock()
+	policy, ok := m.lifecycle[account]
+	m.mu.Unlock()
+	if ok {
+		return policy, nil
+	}
+	switch account {
+	case "acme-dev":
+		return LifecyclePolicy{
+			LastModified: time.Date(2024, 4, 18, 9, 30, 0, 0, time.UTC),
+			Rules: []LifecycleRule{
+				{
+					Name:        "expire-logs",
+					Enabled:     true,
+					BlobTypes:   []string{"blockBlob", "appendBlob"},
+					PrefixMatch: []string{"logs/"},
+					Actions: []LifecycleAction{
+						{Target: "base blob", Action: "tierToCool", Since: "modification", Days: 7},
+						{Target: "base blob", Action: "delete", Since: "modification", Days: 30},
+					},
+				},
+				{
+					Name:        "stale-telemetry",
+					Enabled:     false,
+					BlobTypes:   []string{"blockBlob"},
+					PrefixMatch: []string{"telemetry/"},
+					TagMatch:    []TagCondition{{Key: "retain", Operator: "=", Value: "no"}},
+					Actions: []LifecycleAction{
+						{Target: "base blob", Action: "delete", Since: "last access", Days: 90},
+					},
+				},
+			},
+		}, nil
+	case "acme-prod":
+		return LifecyclePolicy{
+			LastModified: time.Date(2024, 2, 2, 14, 0, 0, 0, time.UTC),
+			Rules: []LifecycleRule{
+				{
+					Name:        "archive-backups",
+					Enabled:     true,
+					BlobTypes:   []string{"blockBlob"},
+					PrefixMatch: []string{"backups/"},
+					Actions: []LifecycleAction{
+						{Target: "base blob", Action: "tierToCool", Since: "modification", Days: 30},
+						{Target: "base blob", Action: "tierToArchive", Since: "modification", Days: 90},
+						{Target: "base blob", Action: "delete", Since: "modification", Days: 365},
+						{Target: "snapshot", Action: "delete", Since: "creation", Days: 90},
+						{Target: "version", Action: "delete", Since: "creation", Days: 90},
+					},
+				},
+			},
+		}, nil
+	}
+	return LifecyclePolicy{}, fmt.Errorf("account %q not found", account)
+}
@@ -0,0 +1,31 @@
+package azure_test
+
+import (
+	"testing"
+	"time"
+
+	"storage-tui/internal/azure"
+	"storage-tui/internal/provider/providertest"
+)
+
+func TestMockProviderConforms(t *testing.T) {
+	providertest.Run(t, providertest.Backend{
+		New: func(t *testing.T) azure.Provider { return azure.NewMockProvider() },
+		Throttled: func(t *testing.T, retryAfter time.Duration) azure.Provider {
+			return providertest.Faulty(t, azure.Faults{ThrottleRate: 1, RetryAfter: retryAfter})
+		},
+		Failing: func(t *testing.T) azure.Provider {
+			return providertest.Faulty(t, azure.Faults{ErrorRate: 1})
+		},
+	})
+}
+
+func TestScaleProviderConforms(t *testing.T) {
+	m, err := azure.NewScaleProvider(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	providertest.Run(t, providertest.Backend{
+		New: func(t *testing.T) azure.Provider { return m },
+	})
+}
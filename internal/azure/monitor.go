@@ -0,0 +1,63 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+)
+
+// AccountMetrics is an account's Azure Monitor metrics over a span of time,
+// oldest point first.
+type AccountMetrics struct {
+	// UsedCapacity is the average number of bytes stored in each interval.
+	UsedCapacity []MetricPoint
+	// Transactions is the number of requests made in each interval.
+	Transactions []MetricPoint
+	// Egress is the number of bytes sent out in each interval.
+	Egress []MetricPoint
+}
+
+// MetricPoint is the value of a metric for the interval starting at Time.
+type MetricPoint struct {
+	Time  time.Time
+	Value float64
+}
+
+func (m *MockProvider) GetAccountMetrics(ctx context.Context, account string, span, interval time.Duration) (AccountMetrics, error) {
+	if err := m.fault(ctx, "get account metrics"); err != nil {
+		return AccountMetrics{}, err
+	}
+	if _, ok := m.containers[account]; !ok {
+		return AccountMetrics{}, fmt.Errorf("account %q not found", account)
+	}
+	if interval <= 0 || span < interval {
+		return AccountMetrics{}, fmt.Errorf("invalid metrics span %s at interval %s", span, interval)
+	}
+
+	var stored int64
+	for _, blobs := range m.blobs[account] {
+		for _, blob := range blobs {
+			stored += blob.SizeBytes
+		}
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(account))
+	phase := float64(hash.Sum32()%24) / 24 * 2 * math.Pi
+
+	// Traffic follows a daily cycle while capacity creeps up to what the
+	// account stores now.
+	count := int(span / interval)
+	end := time.Now().Truncate(interval)
+	var metrics AccountMetrics
+	for i := 0; i < count; i++ {
+		at := end.Add(-time.Duration(count-i) * interval)
+		hours := interval.Hours()
+		cycle := 1 + 0.6*math.Sin(float64(at.Hour())/24*2*math.Pi+phase)
+		metrics.UsedCapacity = append(metrics.UsedCapacity, MetricPoint{at, float64(stored) * (0.9 + 0.1*float64(i+1)/float64(count))})
+		metrics.Transactions = append(metrics.Transactions, MetricPoint{at, math.Round(400 * cycle * hours)})
+		metrics.Egress = append(metrics.Egress, MetricPoint{at, math.Round(float64(stored) / 50 * cycle * hours)})
+	}
+	return metrics, nil
+}
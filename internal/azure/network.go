@@ -0,0 +1,54 @@
+package azure
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// Network holds how a provider reaches the storage endpoints, for networks
+// behind a corporate proxy that inspects TLS.
+type Network struct {
+	// Proxy is the proxy URL, e.g. http://proxy.corp:8080. Empty uses
+	// HTTPS_PROXY, HTTP_PROXY, and NO_PROXY from the environment.
+	Proxy string
+	// CABundle is a PEM file of certificates trusted besides the system ones,
+	// such as the proxy's own root.
+	CABundle string
+	// InsecureSkipVerify accepts any server certificate.
+	InsecureSkipVerify bool
+}
+
+// Client returns an HTTP client that uses the network settings.
+func (n Network) Client() (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if n.Proxy != "" {
+		proxy, err := url.Parse(n.Proxy)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", n.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: n.InsecureSkipVerify}
+	if n.CABundle != "" {
+		pem, err := os.ReadFile(n.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("read CA bundle: %w", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no PEM certificates in CA bundle " + n.CABundle)
+		}
+		transport.TLSClientConfig.RootCAs = roots
+	}
+	return &http.Client{Transport: transport}, nil
+}
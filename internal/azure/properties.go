@@ -0,0 +1,221 @@
+package azure
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+)
+
+// ContainerProperties is the full property set of a container.
+type ContainerProperties struct {
+	Container
+	ETag                   string
+	LastModified           time.Time
+	LeaseState             string
+	LeaseStatus            string
+	DefaultEncryptionScope string
+	HasImmutabilityPolicy  bool
+	HasLegalHold           bool
+	Metadata               map[string]string
+}
+
+// BlobProperties is the full property set of a blob, including the fields a
+// listing does not return.
+type BlobProperties struct {
+	Blob
+	BlobType        string
+	ContentMD5      string
+	ContentEncoding string
+	CacheControl    string
+	LeaseState      string
+	LeaseStatus     string
+	EncryptionScope string
+	ServerEncrypted bool
+	Created         time.Time
+	Metadata        map[string]string
+	Tags            map[string]string
+	// Replication is how the blob fared under the object replication rules
+	// that copy it to another account, and ReplicationPolicyID the policy
+	// it was copied by when it is itself a copy.
+	Replication         []BlobReplication
+	ReplicationPolicyID string
+}
+
+// BlobServiceProperties are the data protection settings of an account's
+// blob service, which decide what can be restored after a delete or an
+// overwrite.
+type BlobServiceProperties struct {
+	// BlobSoftDelete and ContainerSoftDelete keep deleted blobs and
+	// containers for their retention days.
+	BlobSoftDelete      RetentionPolicy
+	ContainerSoftDelete RetentionPolicy
+	// Versioning keeps the previous version of a blob on every change.
+	Versioning bool
+	// ChangeFeed logs every change to the account's blobs; zero days keeps
+	// the log forever.
+	ChangeFeed RetentionPolicy
+	// StaticWebsite serves the $web container as a website.
+	StaticWebsite StaticWebsite
+	// CORS are the rules browsers on other origins are let in by.
+	CORS []CORSRule
+}
+
+// StaticWebsite is the static website hosting of an account.
+type StaticWebsite struct {
+	Enabled bool
+	// IndexDocument is served for the site's folders, such as index.html.
+	IndexDocument string
+	// ErrorDocument404Path is served when a page is not found.
+	ErrorDocument404Path string
+}
+
+// RetentionPolicy is whether a data protection feature is on and for how
+// many days it keeps what it saves.
+type RetentionPolicy struct {
+	Enabled bool
+	Days    int
+}
+
+func (m *MockProvider) GetBlobServiceProperties(ctx context.Context, account string) (BlobServiceProperties, error) {
+	if err := m.fault(ctx, "get blob service properties"); err != nil {
+		return BlobServiceProperties{}, err
+	}
+	return m.blobServiceProperties(account)
+}
+
+func (m *MockProvider) blobServiceProperties(account string) (BlobServiceProperties, error) {
+	switch account {
+	case "acme-dev":
+		return BlobServiceProperties{
+			BlobSoftDelete: RetentionPolicy{Enabled: true, Days: 7},
+			StaticWebsite:  StaticWebsite{Enabled: true, IndexDocument: "index.html", ErrorDocument404Path: "404.html"},
+			CORS:           m.corsRules(account),
+		}, nil
+	case "acme-prod":
+		return BlobServiceProperties{
+			BlobSoftDelete:      RetentionPolicy{Enabled: true, Days: 14},
+			ContainerSoftDelete: RetentionPolicy{Enabled: true, Days: 7},
+			Versioning:          true,
+			ChangeFeed:          RetentionPolicy{Enabled: true, Days: 90},
+			CORS:                m.corsRules(account),
+		}, nil
+	}
+	return BlobServiceProperties{}, fmt.Errorf("account %q not found", account)
+}
+
+func (m *MockProvider) GetContainerProperties(ctx context.Context, account, container string) (ContainerProperties, error) {
+	if err := m.fault(ctx, "get container properties"); err != nil {
+		return ContainerProperties{}, err
+	}
+	for _, candidate := range m.containers[account] {
+		if candidate.Name != container {
+			continue
+		}
+		modified := mockModified(m.blobs[account][container])
+		return ContainerProperties{
+			Container:              candidate,
+			ETag:                   mockETag(account + "/" + container),
+			LastModified:           modified,
+			LeaseState:             "available",
+			LeaseStatus:            "unlocked",
+			DefaultEncryptionScope: "$account-encryption-key",
+			HasImmutabilityPolicy:  m.immutabilityPolicy(account+"/"+container).State != "",
+			HasLegalHold:           len(mockLegalHolds[account+"/"+container]) > 0,
+			Metadata:               map[string]string{"owner": "platform-team"},
+		}, nil
+	}
+	return ContainerProperties{}, fmt.Errorf("container %q not found in %s", container, account)
+}
+
+func (m *MockProvider) GetBlobProperties(ctx context.Context, account, container, blob string) (BlobProperties, error) {
+	if err := m.fault(ctx, "get blob properties"); err != nil {
+		return BlobProperties{}, err
+	}
+	for _, candidate := range m.blobs[account][container] {
+		if candidate.Name != blob {
+			continue
+		}
+		content, ok := m.uploads[account+"/"+container+"/"+blob]
+		if !ok {
+			content = mockContent(candidate)
+		}
+		sum := md5.Sum([]byte(content))
+		candidate.ETag = mockBlobETag(account, container, candidate)
+		candidate.AccessTier = mockTier(container, candidate.Name)
+		props := BlobProperties{
+			Blob:            candidate,
+			BlobType:        "BlockBlob",
+			ContentMD5:      base64.StdEncoding.EncodeToString(sum[:]),
+			LeaseState:      "available",
+			LeaseStatus:     "unlocked",
+			EncryptionScope: "$account-encryption-key",
+			ServerEncrypted: true,
+			Created:         candidate.Modified.Add(-time.Hour),
+			Metadata:        map[string]string{"source": "mock"},
+			Tags:            mockTags(container, candidate),
+		}
+		props.Replication, props.ReplicationPolicyID = mockBlobReplication(account, container, blob)
+		if strings.HasPrefix(candidate.ContentType, "text/") {
+			props.ContentEncoding = "utf-8"
+		}
+		if container == "public" {
+			props.CacheControl = "public, max-age=3600"
+		}
+		return props, nil
+	}
+	return BlobProperties{}, fmt.Errorf("blob %q not found in %s/%s", blob, account, container)
+}
+
+// mockBlobETag derives a blob's ETag from its name and modified time, so it
+// changes when the blob is uploaded again.
+func mockBlobETag(account, container string, blob Blob) string {
+	return mockETag(account + "/" + container + "/" + blob.Name + blob.Modified.String())
+}
+
+// mockTier puts backups in Cool and spreads the telemetry over every tier
+// but Cold.
+func mockTier(container, blob string) string {
+	switch container {
+	case "backups":
+		return "Cool"
+	case "telemetry":
+		hash := fnv.New32a()
+		hash.Write([]byte(blob))
+		return []string{"Hot", "Cool", "Archive"}[hash.Sum32()%3]
+	}
+	return "Hot"
+}
+
+// withETags copies blobs with their ETags and tiers filled in, as a listing
+// returns them.
+func withETags(account, container string, blobs []Blob) []Blob {
+	listed := append([]Blob(nil), blobs...)
+	for i := range listed {
+		listed[i].ETag = mockBlobETag(account, container, listed[i])
+		listed[i].AccessTier = mockTier(container, listed[i].Name)
+	}
+	return listed
+}
+
+func mockETag(seed string) string {
+	hash := fnv.New64a()
+	hash.Write([]byte(seed))
+	return fmt.Sprintf("\"0x%X\"", hash.Sum64())
+}
+
+func mockModified(blobs []Blob) time.Time {
+	var latest time.Time
+	for _, blob := range blobs {
+		if blob.Modified.After(latest) {
+			latest = blob.Modified
+		}
+	}
+	if latest.IsZero() {
+		latest = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return latest
+}
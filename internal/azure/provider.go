@@ -2,6 +2,11 @@ package azure
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -11,8 +16,60 @@ type Provider interface {
 	ListAccounts(ctx context.Context, subscriptionID string) ([]Account, error)
 	ListContainers(ctx context.Context, account string) ([]Container, error)
 	ListBlobs(ctx context.Context, account, container string) ([]Blob, error)
+	ListBlobsPage(ctx context.Context, account, container string, opts ListBlobsOptions) (BlobPage, error)
+	GetContainerProperties(ctx context.Context, account, container string) (ContainerProperties, error)
+	GetBlobProperties(ctx context.Context, account, container, blob string) (BlobProperties, error)
+	// GetContainerImmutability reads the container's retention policy and
+	// legal holds.
+	GetContainerImmutability(ctx context.Context, account, container string) (ContainerImmutability, error)
+	// FindBlobsByTags finds blobs of every container in the account whose
+	// index tags match expression, e.g. "env" = 'prod' AND "tier" > '1'.
+	FindBlobsByTags(ctx context.Context, account, expression string) ([]TaggedBlob, error)
+	// GetBlobServiceProperties reads the account's data protection settings
+	// for blobs and containers.
+	GetBlobServiceProperties(ctx context.Context, account string) (BlobServiceProperties, error)
+	// GetLifecyclePolicy reads the account's lifecycle management policy.
+	GetLifecyclePolicy(ctx context.Context, account string) (LifecyclePolicy, error)
+	// GetNetworkRules reads the account's firewall and private endpoint
+	// connections.
+	GetNetworkRules(ctx context.Context, account string) (NetworkRules, error)
+	// ListRoleAssignments lists the role assignments that apply to the
+	// account, or to its container when set, including those inherited from
+	// the resource group and subscription.
+	ListRoleAssignments(ctx context.Context, account, container string) ([]RoleAssignment, error)
+	// ListChangeFeedEvents reads the account's change feed, oldest event
+	// first, failing for accounts that do not keep one.
+	ListChangeFeedEvents(ctx context.Context, account string, opts ChangeFeedOptions) ([]ChangeFeedEvent, error)
+	// ListReplicationPolicies lists the object replication policies the
+	// account is the source or the destination of.
+	ListReplicationPolicies(ctx context.Context, account string) ([]ReplicationPolicy, error)
+	// GetAccountMetrics reads the account's Azure Monitor metrics for the span
+	// ending now, one point per interval.
+	GetAccountMetrics(ctx context.Context, account string, span, interval time.Duration) (AccountMetrics, error)
+	// OpenBlob streams a blob's content. The caller closes the reader.
+	OpenBlob(ctx context.Context, account, container, blob string) (io.ReadCloser, error)
 }
 
+// ListBlobsOptions selects one page of a blob listing.
+type ListBlobsOptions struct {
+	// Marker continues a previous listing; empty starts from the beginning.
+	Marker string
+	// MaxResults caps the page size; zero uses the provider default.
+	MaxResults int
+	// Prefix lists only blobs whose names start with it.
+	Prefix string
+}
+
+// BlobPage is one page of a blob listing.
+type BlobPage struct {
+	Blobs []Blob
+	// NextMarker continues the listing; empty when this is the last page.
+	NextMarker string
+}
+
+// DefaultPageSize is used when ListBlobsOptions.MaxResults is zero.
+const DefaultPageSize = 5000
+
 type Subscription struct {
 	ID   string
 	Name string
@@ -21,6 +78,38 @@ type Subscription struct {
 type Account struct {
 	Name   string
 	Region string
+	// SKU is the pricing tier and replication, such as Standard_LRS.
+	SKU string
+	// Kind is the account kind, such as StorageV2 or BlobStorage.
+	Kind string
+	// PrimaryEndpoint and SecondaryEndpoint are the blob service URLs; the
+	// secondary is empty unless the account replicates with read access.
+	PrimaryEndpoint   string
+	SecondaryEndpoint string
+	// WebEndpoint is the URL the account's static website is served from;
+	// empty unless static website hosting is on.
+	WebEndpoint string
+	// HierarchicalNamespace is set for Data Lake Storage Gen2 accounts.
+	HierarchicalNamespace bool
+	// MinimumTLSVersion is the oldest TLS version accepted, such as TLS1_2.
+	MinimumTLSVersion string
+	// Encryption is how the account encrypts data at rest.
+	Encryption AccountEncryption
+}
+
+// AccountEncryption is the encryption at rest of an account.
+type AccountEncryption struct {
+	// KeySource is Microsoft.Storage for keys Microsoft manages, or
+	// Microsoft.Keyvault for a customer-managed key.
+	KeySource string
+	// KeyVaultURI, KeyName, and KeyVersion name the customer-managed key; an
+	// empty version follows the key's latest version.
+	KeyVaultURI string
+	KeyName     string
+	KeyVersion  string
+	// InfrastructureEncryption encrypts the data a second time, with a
+	// different key, at the infrastructure level.
+	InfrastructureEncryption bool
 }
 
 type Container struct {
@@ -33,6 +122,11 @@ type Blob struct {
 	SizeBytes   int64
 	Modified    time.Time
 	ContentType string
+	// ETag changes whenever the blob's content or properties do.
+	ETag string
+	// AccessTier is Hot, Cool, Cold, or Archive; empty when the listing
+	// does not say.
+	AccessTier string
 }
 
 // MockProvider is a placeholder data source for UI development.
@@ -41,6 +135,21 @@ type MockProvider struct {
 	accounts      map[string][]Account
 	containers    map[string][]Container
 	blobs         map[string]map[string][]Blob
+	// uploads holds the content of uploaded blobs, and of fixture blobs that
+	// give theirs, by account/container/blob.
+	uploads map[string]string
+	// mu guards the settings the browser changes while other calls read
+	// them: the CORS rules and lifecycle policies set by account, and the
+	// immutability policies by account/container; and the previous
+	// versions and soft-deleted blobs it keeps by account/container/blob.
+	mu           sync.Mutex
+	cors         map[string][]CORSRule
+	lifecycle    map[string]LifecyclePolicy
+	immutability map[string]ImmutabilityPolicy
+	versions     map[string][]mockKept
+	deleted      map[string]mockKept
+	// faults is what InjectFaults asked for; nil behaves.
+	faults *faultState
 }
 
 func NewMockProvider() *MockProvider {
@@ -51,16 +160,43 @@ func NewMockProvider() *MockProvider {
 		},
 		accounts: map[string][]Account{
 			"sub-dev": {
-				{Name: "acme-dev", Region: "westeurope"},
+				{
+					Name:              "acme-dev",
+					Region:            "westeurope",
+					SKU:               "Standard_LRS",
+					Kind:              "StorageV2",
+					PrimaryEndpoint:   "https://acme-dev.blob.core.windows.net/",
+					WebEndpoint:       "https://acme-dev.z6.web.core.windows.net/",
+					MinimumTLSVersion: "TLS1_2",
+					Encryption:        AccountEncryption{KeySource: "Microsoft.Storage"},
+				},
 			},
 			"sub-prod": {
-				{Name: "acme-prod", Region: "eastus"},
+				{
+					Name:                  "acme-prod",
+					Region:                "eastus",
+					SKU:                   "Standard_RAGRS",
+					Kind:                  "StorageV2",
+					PrimaryEndpoint:       "https://acme-prod.blob.core.windows.net/",
+					SecondaryEndpoint:     "https://acme-prod-secondary.blob.core.windows.net/",
+					HierarchicalNamespace: true,
+					MinimumTLSVersion:     "TLS1_2",
+					Encryption: AccountEncryption{
+						KeySource:                "Microsoft.Keyvault",
+						KeyVaultURI:              "https://acme-prod-kv.vault.azure.net/",
+						KeyName:                  "storage-cmk",
+						InfrastructureEncryption: true,
+					},
+				},
 			},
 		},
 		containers: map[string][]Container{
 			"acme-dev": {
+				{Name: "$web", PublicAccess: "private"},
 				{Name: "images", PublicAccess: "private"},
 				{Name: "logs", PublicAccess: "private"},
+				{Name: "site", PublicAccess: "blob"},
+				{Name: "telemetry", PublicAccess: "private"},
 			},
 			"acme-prod": {
 				{Name: "backups", PublicAccess: "private"},
@@ -69,6 +205,10 @@ func NewMockProvider() *MockProvider {
 		},
 		blobs: map[string]map[string][]Blob{
 			"acme-dev": {
+				"$web": {
+					{Name: "404.html", SizeBytes: 1210, Modified: time.Date(2024, 6, 3, 8, 30, 0, 0, time.UTC), ContentType: "text/html"},
+					{Name: "index.html", SizeBytes: 5120, Modified: time.Date(2024, 6, 3, 8, 30, 0, 0, time.UTC), ContentType: "text/html"},
+				},
 				"images": {
 					{Name: "hero.jpg", SizeBytes: 312844, Modified: time.Date(2024, 5, 12, 10, 5, 0, 0, time.UTC), ContentType: "image/jpeg"},
 					{Name: "logo.svg", SizeBytes: 4821, Modified: time.Date(2024, 4, 2, 14, 20, 0, 0, time.UTC), ContentType: "image/svg+xml"},
@@ -77,10 +217,24 @@ func NewMockProvider() *MockProvider {
 					{Name: "2024-05-10.log", SizeBytes: 982304, Modified: time.Date(2024, 5, 10, 3, 12, 0, 0, time.UTC), ContentType: "text/plain"},
 					{Name: "2024-05-11.log", SizeBytes: 1048576, Modified: time.Date(2024, 5, 11, 3, 12, 0, 0, time.UTC), ContentType: "text/plain"},
 				},
+				"site": {
+					{Name: "css/main.css", SizeBytes: 18233, Modified: time.Date(2024, 6, 3, 8, 30, 0, 0, time.UTC), ContentType: "text/css"},
+					{Name: "css/print.css", SizeBytes: 1422, Modified: time.Date(2024, 6, 3, 8, 30, 0, 0, time.UTC), ContentType: "text/css"},
+					{Name: "docs/guide/install.html", SizeBytes: 9310, Modified: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC), ContentType: "text/html"},
+					{Name: "docs/guide/usage.html", SizeBytes: 12876, Modified: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC), ContentType: "text/html"},
+					{Name: "docs/index.html", SizeBytes: 3054, Modified: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC), ContentType: "text/html"},
+					{Name: "img/banner.png", SizeBytes: 204871, Modified: time.Date(2024, 5, 28, 16, 45, 0, 0, time.UTC), ContentType: "image/png"},
+					{Name: "img/icons/favicon.ico", SizeBytes: 15086, Modified: time.Date(2024, 5, 28, 16, 45, 0, 0, time.UTC), ContentType: "image/x-icon"},
+					{Name: "img/icons/menu.svg", SizeBytes: 612, Modified: time.Date(2024, 5, 28, 16, 45, 0, 0, time.UTC), ContentType: "image/svg+xml"},
+					{Name: "index.html", SizeBytes: 5120, Modified: time.Date(2024, 6, 3, 8, 30, 0, 0, time.UTC), ContentType: "text/html"},
+				},
+				"telemetry": mockTelemetryBlobs(1200),
 			},
 			"acme-prod": {
 				"backups": {
 					{Name: "db-2024-05-01.bak", SizeBytes: 358717440, Modified: time.Date(2024, 5, 1, 1, 1, 0, 0, time.UTC), ContentType: "application/octet-stream"},
+					mockArchiveBlob("logs-2024-05.tar.gz", "application/gzip", time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC)),
+					mockArchiveBlob("site-2024-06-03.zip", "application/zip", time.Date(2024, 6, 3, 2, 0, 0, 0, time.UTC)),
 				},
 				"public": {
 					{Name: "robots.txt", SizeBytes: 58, Modified: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC), ContentType: "text/plain"},
@@ -91,13 +245,37 @@ func NewMockProvider() *MockProvider {
 	}
 }
 
+// Identity describes the credentials in use for the status bar.
+func (m *MockProvider) Identity() string {
+	return "mock data"
+}
+
+// mockTelemetryBlobs generates a container large enough to page through.
+func mockTelemetryBlobs(count int) []Blob {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	blobs := make([]Blob, 0, count)
+	for i := 0; i < count; i++ {
+		blobs = append(blobs, Blob{
+			Name:        fmt.Sprintf("events-%05d.json", i),
+			SizeBytes:   int64(2048 + (i*7919)%65536),
+			Modified:    start.Add(time.Duration(i) * time.Minute),
+			ContentType: "application/json",
+		})
+	}
+	return blobs
+}
+
 func (m *MockProvider) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
-	_ = ctx
+	if err := m.fault(ctx, "list subscriptions"); err != nil {
+		return nil, err
+	}
 	return append([]Subscription(nil), m.subscriptions...), nil
 }
 
 func (m *MockProvider) ListAccounts(ctx context.Context, subscriptionID string) ([]Account, error) {
-	_ = ctx
+	if err := m.fault(ctx, "list accounts"); err != nil {
+		return nil, err
+	}
 	if subscriptionID == "" {
 		var all []Account
 		for _, accounts := range m.accounts {
@@ -110,14 +288,56 @@ func (m *MockProvider) ListAccounts(ctx context.Context, subscriptionID string)
 }
 
 func (m *MockProvider) ListContainers(ctx context.Context, account string) ([]Container, error) {
-	_ = ctx
+	if err := m.fault(ctx, "list containers"); err != nil {
+		return nil, err
+	}
 	containers := m.containers[account]
 	return append([]Container(nil), containers...), nil
 }
 
 func (m *MockProvider) ListBlobs(ctx context.Context, account, container string) ([]Blob, error) {
-	_ = ctx
+	if err := m.fault(ctx, "list blobs"); err != nil {
+		return nil, err
+	}
 	containers := m.blobs[account]
 	blobs := containers[container]
-	return append([]Blob(nil), blobs...), nil
+	return withETags(account, container, blobs), nil
+}
+
+func (m *MockProvider) ListBlobsPage(ctx context.Context, account, container string, opts ListBlobsOptions) (BlobPage, error) {
+	if err := m.fault(ctx, "list blobs page"); err != nil {
+		return BlobPage{}, err
+	}
+	blobs := m.blobs[account][container]
+	if opts.Prefix != "" {
+		var matching []Blob
+		for _, blob := range blobs {
+			if strings.HasPrefix(blob.Name, opts.Prefix) {
+				matching = append(matching, blob)
+			}
+		}
+		blobs = matching
+	}
+	start := 0
+	if opts.Marker != "" {
+		offset, err := strconv.Atoi(opts.Marker)
+		if err != nil || offset < 0 || offset > len(blobs) {
+			return BlobPage{}, fmt.Errorf("invalid marker %q", opts.Marker)
+		}
+		start = offset
+	}
+	size := opts.MaxResults
+	if size <= 0 {
+		size = DefaultPageSize
+	}
+	end := start + size
+	if end > len(blobs) {
+		end = len(blobs)
+	}
+
+	page := BlobPage{Blobs: withETags(account, container, blobs[start:end])}
+	if end < len(blobs) {
+		page.NextMarker = strconv.Itoa(end)
+	}
+	return page, nil
 }
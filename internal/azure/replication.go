@@ -0,0 +1,105 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ReplicationPolicy copies block blobs from containers of the source account
+// to containers of the destination account, asynchronously. Both accounts
+// hold the policy under the same ID.
+type ReplicationPolicy struct {
+	ID                 string
+	SourceAccount      string
+	DestinationAccount string
+	Rules              []ReplicationRule
+}
+
+// ReplicationRule is one source container of a policy and where it goes.
+type ReplicationRule struct {
+	ID                   string
+	SourceContainer      string
+	DestinationContainer string
+	// PrefixMatch replicates only blobs whose names start with one of the
+	// prefixes; empty replicates every blob.
+	PrefixMatch []string
+	// MinCreationTime replicates only blobs created since; zero replicates
+	// those that existed when the rule was made too.
+	MinCreationTime time.Time
+}
+
+// Replication statuses of a source blob. A blob a rule applies to but that
+// has no status yet is still pending.
+const (
+	ReplicationComplete = "complete"
+	ReplicationFailed   = "failed"
+)
+
+// BlobReplication is how a source blob fared under one rule.
+type BlobReplication struct {
+	PolicyID string
+	RuleID   string
+	// Status is ReplicationComplete or ReplicationFailed.
+	Status string
+}
+
+// mockReplication copies the site's pages and the later logs of acme-dev to
+// acme-prod.
+var mockReplication = []ReplicationPolicy{{
+	ID:                 "a1b2c3d4-0000-4000-8000-5e1f0d2c9b7a",
+	SourceAccount:      "acme-dev",
+	DestinationAccount: "acme-prod",
+	Rules: []ReplicationRule{
+		{ID: "site-pages", SourceContainer: "site", DestinationContainer: "public", PrefixMatch: []string{"index.html", "css/"}},
+		{ID: "logs", SourceContainer: "logs", DestinationContainer: "backups", MinCreationTime: time.Date(2024, 5, 11, 0, 0, 0, 0, time.UTC)},
+	},
+}}
+
+// mockReplicationStatus are the statuses of the mock source blobs by
+// account/container/blob, keyed by rule ID; blobs missing from it are
+// pending if a rule applies to them.
+var mockReplicationStatus = map[string]map[string]string{
+	"acme-dev/site/index.html":    {"site-pages": ReplicationComplete},
+	"acme-dev/site/css/main.css":  {"site-pages": ReplicationComplete},
+	"acme-dev/site/css/print.css": {"site-pages": ReplicationFailed},
+}
+
+func (m *MockProvider) ListReplicationPolicies(ctx context.Context, account string) ([]ReplicationPolicy, error) {
+	if err := m.fault(ctx, "list replication policies"); err != nil {
+		return nil, err
+	}
+	if _, ok := m.containers[account]; !ok {
+		return nil, fmt.Errorf("account %q not found", account)
+	}
+	var policies []ReplicationPolicy
+	for _, policy := range mockReplication {
+		if policy.SourceAccount == account || policy.DestinationAccount == account {
+			policies = append(policies, policy)
+		}
+	}
+	return policies, nil
+}
+
+// mockBlobReplication returns the replication of a mock source blob, and the
+// policy a destination blob was replicated by.
+func mockBlobReplication(account, container, blob string) ([]BlobReplication, string) {
+	var statuses []BlobReplication
+	policyID := ""
+	for _, policy := range mockReplication {
+		for _, rule := range policy.Rules {
+			switch {
+			case policy.SourceAccount == account && rule.SourceContainer == container:
+				if status, ok := mockReplicationStatus[account+"/"+container+"/"+blob][rule.ID]; ok {
+					statuses = append(statuses, BlobReplication{PolicyID: policy.ID, RuleID: rule.ID, Status: status})
+				}
+			case policy.DestinationAccount == account && rule.DestinationContainer == container:
+				source := policy.SourceAccount + "/" + rule.SourceContainer + "/" + blob
+				if mockReplicationStatus[source][rule.ID] == ReplicationComplete {
+					policyID = policy.ID
+				}
+			}
+		}
+	}
+	return statuses, policyID
+}
@@ -0,0 +1,186 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// BlobVersion names a blob as one write left it, by the ETag it had then.
+// The audit log keeps it for the blobs an action wrote over or deleted.
+type BlobVersion struct {
+	Name string `json:"name"`
+	ETag string `json:"etag"`
+}
+
+// Restorer is implemented by providers that can bring back what a delete or
+// an overwrite replaced, as far as the account's data protection settings
+// keep it. Both name the version to bring back by its ETag, so a later
+// write is never taken for it.
+type Restorer interface {
+	// UndeleteBlob brings back the deleted blob that had etag, from soft
+	// delete or, with versioning, from its previous versions.
+	UndeleteBlob(ctx context.Context, account, container, blob, etag string) error
+	// RestoreVersion makes the previous version of the blob that had etag
+	// its current content again, keeping the content it replaces as a
+	// version; it needs versioning.
+	RestoreVersion(ctx context.Context, account, container, blob, etag string) error
+}
+
+// KeepsVersions reports whether the settings keep what an overwrite
+// replaces.
+func (p BlobServiceProperties) KeepsVersions() bool {
+	return p.Versioning
+}
+
+// KeepsDeleted reports whether the settings keep what a delete removes.
+func (p BlobServiceProperties) KeepsDeleted() bool {
+	return p.BlobSoftDelete.Enabled || p.Versioning
+}
+
+// Restore brings back the replaced blobs of a container from their previous
+// versions and the deleted ones from soft delete, each as it was before,
+// and returns the errors of those it could not.
+func Restore(ctx context.Context, provider Provider, account, container string, replaced, deleted []BlobVersion) error {
+	restorer, ok := provider.(Restorer)
+	if !ok {
+		return errors.New("this provider cannot restore blobs")
+	}
+	var failed []error
+	for _, version := range replaced {
+		if err := restorer.RestoreVersion(ctx, account, container, version.Name, version.ETag); err != nil {
+			failed = append(failed, fmt.Errorf("restore %s: %w", version.Name, err))
+		}
+	}
+	for _, version := range deleted {
+		if err := restorer.UndeleteBlob(ctx, account, container, version.Name, version.ETag); err != nil {
+			failed = append(failed, fmt.Errorf("undelete %s: %w", version.Name, err))
+		}
+	}
+	return errors.Join(failed...)
+}
+
+// mockKept is a blob as soft delete or versioning keeps it.
+type mockKept struct {
+	blob    Blob
+	content string
+}
+
+// keep saves the blob about to be deleted or replaced where the account's
+// settings would: as a previous version with versioning, and otherwise as
+// soft deleted when deleted is set. The mock keeps it for the run, whatever
+// the retention days.
+func (m *MockProvider) keep(account, container string, blob Blob, deleted bool) {
+	props, err := m.blobServiceProperties(account)
+	if err != nil {
+		return
+	}
+	content, err := m.content(account, container, blob.Name)
+	if err != nil {
+		return
+	}
+	key := account + "/" + container + "/" + blob.Name
+	kept := mockKept{blob: blob, content: content}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case props.KeepsVersions():
+		if m.versions == nil {
+			m.versions = make(map[string][]mockKept)
+		}
+		m.versions[key] = append(m.versions[key], kept)
+	case deleted && props.KeepsDeleted():
+		if m.deleted == nil {
+			m.deleted = make(map[string]mockKept)
+		}
+		m.deleted[key] = kept
+	}
+}
+
+// put makes kept the blob's current content, replacing what is there.
+func (m *MockProvider) put(account, container string, kept mockKept) {
+	blobs := m.blobs[account][container]
+	replaced := false
+	for i, candidate := range blobs {
+		if candidate.Name == kept.blob.Name {
+			blobs[i] = kept.blob
+			replaced = true
+		}
+	}
+	if !replaced {
+		blobs = append(blobs, kept.blob)
+	}
+	m.blobs[account][container] = blobs
+	if m.uploads == nil {
+		m.uploads = make(map[string]string)
+	}
+	m.uploads[account+"/"+container+"/"+kept.blob.Name] = kept.content
+}
+
+func (m *MockProvider) find(account, container, blob string) (Blob, bool) {
+	for _, candidate := range m.blobs[account][container] {
+		if candidate.Name == blob {
+			return candidate, true
+		}
+	}
+	return Blob{}, false
+}
+
+// takeVersion removes the kept version of the blob at key that had etag and
+// returns it.
+func (m *MockProvider) takeVersion(account, container, key, etag string) (mockKept, bool) {
+	versions := m.versions[key]
+	for i, kept := range versions {
+		if mockBlobETag(account, container, kept.blob) == etag {
+			m.versions[key] = append(versions[:i:i], versions[i+1:]...)
+			return kept, true
+		}
+	}
+	return mockKept{}, false
+}
+
+// UndeleteBlob puts back the blob the mock kept when it was deleted.
+func (m *MockProvider) UndeleteBlob(ctx context.Context, account, container, blob, etag string) error {
+	if err := m.fault(ctx, "undelete blob"); err != nil {
+		return err
+	}
+	if _, ok := m.find(account, container, blob); ok {
+		return fmt.Errorf("blob %q in %s/%s is not deleted", blob, account, container)
+	}
+	key := account + "/" + container + "/" + blob
+	m.mu.Lock()
+	kept, ok := m.deleted[key]
+	if ok && mockBlobETag(account, container, kept.blob) == etag {
+		delete(m.deleted, key)
+	} else {
+		kept, ok = m.takeVersion(account, container, key, etag)
+	}
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no deleted blob %q with ETag %s is kept in %s/%s", blob, etag, account, container)
+	}
+	m.put(account, container, kept)
+	return nil
+}
+
+// RestoreVersion puts back the blob's previous version that had etag and
+// keeps the current one as a version in its place, as copying a version
+// over the blob does.
+func (m *MockProvider) RestoreVersion(ctx context.Context, account, container, blob, etag string) error {
+	if err := m.fault(ctx, "restore version"); err != nil {
+		return err
+	}
+	current, exists := m.find(account, container, blob)
+	key := account + "/" + container + "/" + blob
+	m.mu.Lock()
+	previous, ok := m.takeVersion(account, container, key, etag)
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no previous version of blob %q with ETag %s is kept in %s/%s", blob, etag, account, container)
+	}
+	if exists {
+		m.keep(account, container, current, false)
+	}
+	m.put(account, container, previous)
+	return nil
+}
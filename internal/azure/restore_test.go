@@ -0,0 +1,138 @@
+package azure_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"storage-tui/internal/azure"
+)
+
+func read(t *testing.T, m *azure.MockProvider, account, container, blob string) string {
+	t.Helper()
+	reader, err := m.OpenBlob(context.Background(), account, container, blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// write uploads content to the blob and returns its ETag.
+func write(t *testing.T, m *azure.MockProvider, account, container, blob, content string) string {
+	t.Helper()
+	if _, err := m.UploadBlob(context.Background(), account, container, blob, strings.NewReader(content), ""); err != nil {
+		t.Fatal(err)
+	}
+	props, err := m.GetBlobProperties(context.Background(), account, container, blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return props.ETag
+}
+
+func TestRestoreVersion(t *testing.T) {
+	ctx := context.Background()
+	m := azure.NewMockProvider()
+	// acme-prod has versioning.
+	first := write(t, m, "acme-prod", "public", "notes.txt", "first")
+	second := write(t, m, "acme-prod", "public", "notes.txt", "second")
+	write(t, m, "acme-prod", "public", "notes.txt", "third")
+	if err := m.RestoreVersion(ctx, "acme-prod", "public", "notes.txt", first); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(t, m, "acme-prod", "public", "notes.txt"); got != "first" {
+		t.Errorf("after restoring the first version, the blob holds %q", got)
+	}
+	// The versions written since are kept, the one restored over included.
+	if err := m.RestoreVersion(ctx, "acme-prod", "public", "notes.txt", second); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(t, m, "acme-prod", "public", "notes.txt"); got != "second" {
+		t.Errorf("after restoring the second version, the blob holds %q", got)
+	}
+	if err := m.RestoreVersion(ctx, "acme-prod", "public", "notes.txt", `"0xMISSING"`); err == nil {
+		t.Error("restored a version that was never kept")
+	}
+
+	// acme-dev has soft delete only, so an overwrite is for good.
+	replaced := write(t, m, "acme-dev", "images", "notes.txt", "first")
+	write(t, m, "acme-dev", "images", "notes.txt", "second")
+	if err := m.RestoreVersion(ctx, "acme-dev", "images", "notes.txt", replaced); err == nil {
+		t.Error("restored a version of an account without versioning")
+	}
+}
+
+func TestUndeleteBlob(t *testing.T) {
+	tests := []struct {
+		name, account, container string
+	}{
+		{name: "soft delete", account: "acme-dev", container: "images"},
+		{name: "versioning", account: "acme-prod", container: "public"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			m := azure.NewMockProvider()
+			etag := write(t, m, test.account, test.container, "notes.txt", "kept")
+			if err := m.UndeleteBlob(ctx, test.account, test.container, "notes.txt", etag); err == nil {
+				t.Error("undeleted a blob that is there")
+			}
+			if err := m.DeleteBlob(ctx, test.account, test.container, "notes.txt"); err != nil {
+				t.Fatal(err)
+			}
+			if err := m.UndeleteBlob(ctx, test.account, test.container, "notes.txt", `"0xMISSING"`); err == nil {
+				t.Error("undeleted a blob by an ETag it never had")
+			}
+			if err := m.UndeleteBlob(ctx, test.account, test.container, "notes.txt", etag); err != nil {
+				t.Fatal(err)
+			}
+			if got := read(t, m, test.account, test.container, "notes.txt"); got != "kept" {
+				t.Errorf("undeleted blob holds %q", got)
+			}
+			if err := m.UndeleteBlob(ctx, test.account, test.container, "missing.txt", etag); err == nil {
+				t.Error("undeleted a blob never deleted")
+			}
+		})
+	}
+}
+
+func TestRestore(t *testing.T) {
+	ctx := context.Background()
+	m := azure.NewMockProvider()
+	oldA := write(t, m, "acme-prod", "backups", "a.txt", "old a")
+	write(t, m, "acme-prod", "backups", "a.txt", "new a")
+	oldB := write(t, m, "acme-prod", "backups", "b.txt", "old b")
+	if err := m.DeleteBlob(ctx, "acme-prod", "backups", "b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	// a.txt is written again after the change undone, which the restore
+	// must not take for the version the change replaced.
+	write(t, m, "acme-prod", "backups", "a.txt", "newer a")
+	replaced := []azure.BlobVersion{{Name: "a.txt", ETag: oldA}, {Name: "never.txt", ETag: oldA}}
+	err := azure.Restore(ctx, m, "acme-prod", "backups", replaced, []azure.BlobVersion{{Name: "b.txt", ETag: oldB}})
+	if err == nil || !strings.Contains(err.Error(), "restore never.txt") {
+		t.Errorf("Restore error = %v, want the blob with no previous version named", err)
+	}
+	if got := read(t, m, "acme-prod", "backups", "a.txt") + ", " + read(t, m, "acme-prod", "backups", "b.txt"); got != "old a, old b" {
+		t.Errorf("after Restore, the blobs hold %s", got)
+	}
+}
+
+func TestKeeps(t *testing.T) {
+	m := azure.NewMockProvider()
+	for account, want := range map[string][2]bool{"acme-dev": {false, true}, "acme-prod": {true, true}} {
+		settings, err := m.GetBlobServiceProperties(context.Background(), account)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := [2]bool{settings.KeepsVersions(), settings.KeepsDeleted()}; got != want {
+			t.Errorf("%s keeps versions, deleted = %v, want %v", account, got, want)
+		}
+	}
+}
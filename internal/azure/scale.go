@@ -0,0 +1,204 @@
+package azure
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"time"
+)
+
+// scaleAccountsPerSubscription is how many accounts each generated
+// subscription holds.
+const scaleAccountsPerSubscription = 100
+
+// scaleMaxBlobs caps the blobs of one generated container.
+const scaleMaxBlobs = 250000
+
+// scaleEnd is when the newest generated blob was written; blobs go back in
+// time from it, so the same scale makes the same data on every run.
+var scaleEnd = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+var (
+	scaleTeams   = []string{"billing", "checkout", "search", "catalog", "identity", "payments", "mobile", "analytics", "ml", "media", "support", "ledger", "growth", "infra", "security", "data"}
+	scaleEnvs    = []string{"dev", "test", "stage", "prod"}
+	scaleRegions = []string{"westeurope", "northeurope", "eastus", "eastus2", "westus2", "uksouth", "germanywestcentral", "southeastasia", "japaneast", "australiaeast"}
+	scaleSKUs    = []string{"Standard_LRS", "Standard_LRS", "Standard_ZRS", "Standard_GRS", "Standard_RAGRS", "Premium_LRS"}
+	scaleWords   = []string{"invoice", "report", "summary", "contract", "roadmap", "runbook", "budget", "forecast", "review", "handbook", "policy", "minutes"}
+	scaleTables  = []string{"orders", "customers", "sessions", "payments", "inventory", "clicks", "refunds", "shipments"}
+)
+
+// scaleKind is a kind of container the generator fills, with how its blobs
+// are named, typed, and sized.
+type scaleKind struct {
+	names       []string
+	contentType string
+	// medianSize is the typical blob size; sizes spread log-normally
+	// around it by sizeSpread.
+	medianSize float64
+	sizeSpread float64
+	// name names the i-th blob, written at modified.
+	name func(r *rand.Rand, i int, modified time.Time) string
+	// every is the typical time between two blobs being written.
+	every time.Duration
+}
+
+var scaleKinds = []scaleKind{
+	{
+		names:       []string{"app-logs", "audit-logs", "web-logs", "insights-logs"},
+		contentType: "text/plain",
+		medianSize:  256 << 10, sizeSpread: 1.2,
+		every: 10 * time.Minute,
+		name: func(r *rand.Rand, i int, modified time.Time) string {
+			return fmt.Sprintf("%s/host-%02d-%06d.log", modified.Format("2006/01/02/15"), r.IntN(12), i)
+		},
+	},
+	{
+		names:       []string{"images", "uploads", "thumbnails", "avatars"},
+		contentType: "image/jpeg",
+		medianSize:  900 << 10, sizeSpread: 0.8,
+		every: 3 * time.Minute,
+		name: func(r *rand.Rand, i int, modified time.Time) string {
+			return fmt.Sprintf("%s/%02x/IMG_%07d.jpg", []string{"products", "users", "banners", "gallery"}[r.IntN(4)], r.IntN(256), i)
+		},
+	},
+	{
+		names:       []string{"warehouse", "lake", "curated", "raw"},
+		contentType: "application/vnd.apache.parquet",
+		medianSize:  48 << 20, sizeSpread: 1.0,
+		every: time.Hour,
+		name: func(r *rand.Rand, i int, modified time.Time) string {
+			return fmt.Sprintf("%s/date=%s/part-%05d-%08x.snappy.parquet", scaleTables[r.IntN(len(scaleTables))], modified.Format("2006-01-02"), i, r.Uint32())
+		},
+	},
+	{
+		names:       []string{"events", "telemetry", "webhooks", "clickstream"},
+		contentType: "application/json",
+		medianSize:  2 << 10, sizeSpread: 1.0,
+		every: 20 * time.Second,
+		name: func(r *rand.Rand, i int, modified time.Time) string {
+			return fmt.Sprintf("%s/%08x-%07d.json", modified.Format("2006/01/02/15"), r.Uint32(), i)
+		},
+	},
+	{
+		names:       []string{"backups", "snapshots", "exports"},
+		contentType: "application/octet-stream",
+		medianSize:  2 << 30, sizeSpread: 1.5,
+		every: 6 * time.Hour,
+		name: func(r *rand.Rand, i int, modified time.Time) string {
+			db := scaleTables[r.IntN(len(scaleTables))]
+			return fmt.Sprintf("%s/%s-%s-%06d.bak", db, db, modified.Format("20060102-1504"), i)
+		},
+	},
+	{
+		names:       []string{"documents", "shared", "reports"},
+		contentType: "application/pdf",
+		medianSize:  300 << 10, sizeSpread: 1.3,
+		every: 2 * time.Hour,
+		name: func(r *rand.Rand, i int, modified time.Time) string {
+			return fmt.Sprintf("%s/%d/%s-%s-%05d.pdf", scaleTeams[r.IntN(len(scaleTeams))], modified.Year(), scaleWords[r.IntN(len(scaleWords))], scaleWords[r.IntN(len(scaleWords))], i)
+		},
+	},
+}
+
+// NewScaleProvider returns a mock provider serving generated data sized by
+// scale, for measuring how the browser pages and draws big hierarchies:
+// scale storage accounts over a subscription per hundred of them, each with
+// one to eight containers of logs, images, tables, events, backups, or
+// documents. Most containers hold tens to hundreds of blobs and a few hold
+// up to a quarter of a million, about a thousand an account all told. The
+// same scale makes the same data.
+func NewScaleProvider(scale int) (*MockProvider, error) {
+	if scale <= 0 {
+		return nil, fmt.Errorf("mock scale is a number of accounts, not %d", scale)
+	}
+	m := &MockProvider{
+		accounts:   make(map[string][]Account),
+		containers: make(map[string][]Container),
+		blobs:      make(map[string]map[string][]Blob),
+		uploads:    make(map[string]string),
+	}
+	r := rand.New(rand.NewPCG(uint64(scale), 0x5ca1e))
+	for i := 0; i < scale; i++ {
+		if i%scaleAccountsPerSubscription == 0 {
+			n := i/scaleAccountsPerSubscription + 1
+			m.subscriptions = append(m.subscriptions, Subscription{
+				ID:   fmt.Sprintf("%08x-5ca1-4e00-8000-%012x", n, n),
+				Name: fmt.Sprintf("Scale %02d", n),
+			})
+		}
+		m.addScaleAccount(r, m.subscriptions[len(m.subscriptions)-1].ID, i)
+	}
+	return m, nil
+}
+
+// addScaleAccount generates the i-th account, named like the storage
+// accounts of a team and environment, with its containers listed by name.
+func (m *MockProvider) addScaleAccount(r *rand.Rand, subscriptionID string, i int) {
+	name := fmt.Sprintf("st%s%s%04d", scaleTeams[r.IntN(len(scaleTeams))], scaleEnvs[r.IntN(len(scaleEnvs))], i)
+	m.accounts[subscriptionID] = append(m.accounts[subscriptionID], Account{
+		Name:                  name,
+		Region:                scaleRegions[r.IntN(len(scaleRegions))],
+		SKU:                   scaleSKUs[r.IntN(len(scaleSKUs))],
+		Kind:                  "StorageV2",
+		PrimaryEndpoint:       fmt.Sprintf("https://%s.blob.core.windows.net/", name),
+		HierarchicalNamespace: r.IntN(4) == 0,
+		MinimumTLSVersion:     "TLS1_2",
+		Encryption:            AccountEncryption{KeySource: "Microsoft.Storage"},
+	})
+	m.blobs[name] = make(map[string][]Blob)
+	for range 1 + r.IntN(8) {
+		kind := scaleKinds[r.IntN(len(scaleKinds))]
+		base := kind.names[r.IntN(len(kind.names))]
+		container := base
+		for n := 2; m.hasContainer(name, container); n++ {
+			container = fmt.Sprintf("%s-%d", base, n)
+		}
+		access := "private"
+		if kind.contentType == "image/jpeg" && r.IntN(3) == 0 {
+			access = "blob"
+		}
+		m.containers[name] = append(m.containers[name], Container{Name: container, PublicAccess: access})
+		m.blobs[name][container] = scaleBlobs(r, kind)
+	}
+	containers := m.containers[name]
+	sort.Slice(containers, func(i, j int) bool { return containers[i].Name < containers[j].Name })
+}
+
+// scaleBlobs generates a container's blobs, listed by name. Their count
+// follows a Pareto distribution, so that most containers are small and a
+// few are huge, as they are in real accounts.
+func scaleBlobs(r *rand.Rand, kind scaleKind) []Blob {
+	count := int(60 / math.Pow(1-r.Float64(), 1/1.3))
+	count = min(count, scaleMaxBlobs)
+	blobs := make([]Blob, count)
+	modified := scaleEnd.Add(-time.Duration(r.Int64N(int64(30 * 24 * time.Hour))))
+	for i := range blobs {
+		modified = modified.Add(-time.Duration(r.Int64N(2*int64(kind.every)) + 1))
+		size := kind.medianSize * math.Exp(kind.sizeSpread*r.NormFloat64())
+		blobs[i] = Blob{
+			Name:        kind.name(r, i, modified),
+			SizeBytes:   max(int64(size), 1),
+			Modified:    modified.Truncate(time.Second),
+			ContentType: kind.contentType,
+		}
+	}
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].Name < blobs[j].Name })
+	return blobs
+}
+
+// Size counts what m serves, such as to report how much a scale made.
+func (m *MockProvider) Size() (accounts, containers, blobs int) {
+	for _, list := range m.accounts {
+		accounts += len(list)
+	}
+	for _, list := range m.containers {
+		containers += len(list)
+	}
+	for _, byContainer := range m.blobs {
+		for _, list := range byContainer {
+			blobs += len(list)
+		}
+	}
+	return accounts, containers, blobs
+}
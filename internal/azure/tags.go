@@ -0,0 +1,133 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TaggedBlob is a blob matched by FindBlobsByTags. Like the service, it only
+// carries the blob's name, its container, and the tags the expression
+// matched; sizes and times are not returned.
+type TaggedBlob struct {
+	Container string
+	Name      string
+	Tags      map[string]string
+}
+
+// TagCondition is one comparison of a tag filter expression, such as
+// "env" = 'prod'. The key @container compares the container name.
+type TagCondition struct {
+	Key      string
+	Operator string
+	Value    string
+}
+
+var tagConditionPattern = regexp.MustCompile(`^\s*("[^"]+"|@container|[A-Za-z0-9_.:/+\-]+)\s*(=|>=|<=|>|<)\s*'([^']*)'\s*$`)
+
+var tagAndPattern = regexp.MustCompile(`(?i)\s+AND\s+`)
+
+// ParseTagExpression splits an expression in the service's filter syntax,
+// comparisons joined by AND, into its conditions.
+func ParseTagExpression(expression string) ([]TagCondition, error) {
+	if strings.TrimSpace(expression) == "" {
+		return nil, fmt.Errorf("empty tag expression")
+	}
+	var conditions []TagCondition
+	for _, part := range tagAndPattern.Split(strings.TrimSpace(expression), -1) {
+		match := tagConditionPattern.FindStringSubmatch(part)
+		if match == nil {
+			return nil, fmt.Errorf("invalid condition %q, expected \"key\" = 'value'", strings.TrimSpace(part))
+		}
+		conditions = append(conditions, TagCondition{
+			Key:      strings.Trim(match[1], `"`),
+			Operator: match[2],
+			Value:    match[3],
+		})
+	}
+	return conditions, nil
+}
+
+// Matches reports whether value satisfies the condition. Comparisons are
+// lexicographic, as in the service.
+func (c TagCondition) Matches(value string) bool {
+	switch c.Operator {
+	case "=":
+		return value == c.Value
+	case ">":
+		return value > c.Value
+	case ">=":
+		return value >= c.Value
+	case "<":
+		return value < c.Value
+	case "<=":
+		return value <= c.Value
+	}
+	return false
+}
+
+func (m *MockProvider) FindBlobsByTags(ctx context.Context, account, expression string) ([]TaggedBlob, error) {
+	if err := m.fault(ctx, "find blobs by tags"); err != nil {
+		return nil, err
+	}
+	conditions, err := ParseTagExpression(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	containers := make([]string, 0, len(m.blobs[account]))
+	for container := range m.blobs[account] {
+		containers = append(containers, container)
+	}
+	sort.Strings(containers)
+
+	var matches []TaggedBlob
+	for _, container := range containers {
+		for _, blob := range m.blobs[account][container] {
+			tags := mockTags(container, blob)
+			matched := make(map[string]string)
+			ok := true
+			for _, condition := range conditions {
+				if condition.Key == "@container" {
+					ok = condition.Matches(container)
+				} else {
+					value, present := tags[condition.Key]
+					ok = present && condition.Matches(value)
+					matched[condition.Key] = value
+				}
+				if !ok {
+					break
+				}
+			}
+			if ok {
+				matches = append(matches, TaggedBlob{Container: container, Name: blob.Name, Tags: matched})
+			}
+		}
+	}
+	return matches, nil
+}
+
+// mockTags derives index tags from where a mock blob lives.
+func mockTags(container string, blob Blob) map[string]string {
+	tags := map[string]string{}
+	switch container {
+	case "public":
+		tags["site"] = "www"
+	case "site":
+		tags["site"] = "docs"
+		if index := strings.Index(blob.Name, "/"); index > 0 {
+			tags["section"] = blob.Name[:index]
+		}
+	case "logs":
+		tags["type"] = "log"
+		tags["date"] = strings.TrimSuffix(blob.Name, ".log")
+	case "telemetry":
+		tags["type"] = "telemetry"
+		tags["day"] = blob.Modified.Format("2006-01-02")
+	case "backups":
+		tags["retention"] = "90d"
+	}
+	return tags
+}
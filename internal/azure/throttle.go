@@ -0,0 +1,22 @@
+package azure
+
+import (
+	"fmt"
+	"time"
+)
+
+// ThrottledError is returned by providers when the service turned a call
+// away for making too many requests: 429 Too Many Requests from Resource
+// Manager, or 503 Server Busy from storage. The call can be retried.
+type ThrottledError struct {
+	// RetryAfter is how long the service asked to wait; zero when it did
+	// not say.
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("throttled by the service, retry after %s", e.RetryAfter)
+	}
+	return "throttled by the service"
+}
@@ -0,0 +1,124 @@
+package bookmarks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Bookmark points at a container, or at a blob inside a container.
+type Bookmark struct {
+	SubscriptionID   string `json:"subscriptionId"`
+	SubscriptionName string `json:"subscriptionName,omitempty"`
+	Account          string `json:"account"`
+	Container        string `json:"container"`
+	Blob             string `json:"blob,omitempty"`
+}
+
+// Label renders the bookmark as a slash separated path.
+func (b Bookmark) Label() string {
+	label := fmt.Sprintf("%s/%s", b.Account, b.Container)
+	if b.Blob != "" {
+		label += "/" + b.Blob
+	}
+	if b.SubscriptionName != "" {
+		label = fmt.Sprintf("%s (%s)", label, b.SubscriptionName)
+	}
+	return label
+}
+
+func (b Bookmark) same(other Bookmark) bool {
+	return b.SubscriptionID == other.SubscriptionID &&
+		b.Account == other.Account &&
+		b.Container == other.Container &&
+		b.Blob == other.Blob
+}
+
+// Store keeps bookmarks in memory and writes them to a JSON file on change.
+type Store struct {
+	path  string
+	items []Bookmark
+}
+
+// DefaultPath returns the bookmarks file inside the user config directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "storage-tui", "bookmarks.json"), nil
+}
+
+// Load reads bookmarks from path. A missing file yields an empty store.
+func Load(path string) (*Store, error) {
+	store := &Store{path: path}
+	if path == "" {
+		return store, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return store, err
+	}
+	if err := json.Unmarshal(data, &store.items); err != nil {
+		return store, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return store, nil
+}
+
+func (s *Store) List() []Bookmark {
+	return append([]Bookmark(nil), s.items...)
+}
+
+func (s *Store) Contains(b Bookmark) bool {
+	for _, item := range s.items {
+		if item.same(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// Toggle adds the bookmark, or removes it when it already exists, and saves
+// the result. It reports whether the bookmark was added.
+func (s *Store) Toggle(b Bookmark) (bool, error) {
+	for i, item := range s.items {
+		if item.same(b) {
+			s.items = append(s.items[:i], s.items[i+1:]...)
+			return false, s.Save()
+		}
+	}
+	s.items = append(s.items, b)
+	return true, s.Save()
+}
+
+func (s *Store) Remove(index int) error {
+	if index < 0 || index >= len(s.items) {
+		return nil
+	}
+	s.items = append(s.items[:index], s.items[index+1:]...)
+	return s.Save()
+}
+
+// Save writes the bookmarks file, replacing it atomically.
+func (s *Store) Save() error {
+	if s.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s.items, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
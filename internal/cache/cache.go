@@ -0,0 +1,146 @@
+// Package cache keeps what was listed and previewed on disk between runs, so
+// containers seen before show at once and can be browsed offline. Callers key
+// entries so that changed content misses, such as previews by ETag.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Cache is a directory of JSON entries, one file each, grouped by kind.
+type Cache struct {
+	dir string
+}
+
+type entry struct {
+	Key   string          `json:"key"`
+	Saved time.Time       `json:"saved"`
+	Value json.RawMessage `json:"value"`
+}
+
+// DefaultDir returns the cache directory inside the user cache directory.
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "storage-tui"), nil
+}
+
+// Open opens the cache in dir, creating it, and drops the least recently used
+// entries until the rest fit in maxBytes.
+func Open(dir string, maxBytes int64) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	c := &Cache{dir: dir}
+	if err := c.prune(maxBytes); err != nil {
+		return nil, fmt.Errorf("prune %s: %w", dir, err)
+	}
+	return c, nil
+}
+
+// Load reads the value stored under kind and key into v, and reports when it
+// was stored. A missing or unreadable entry is a miss.
+func (c *Cache) Load(kind, key string, v any) (time.Time, bool) {
+	path := c.path(kind, key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var stored entry
+	if json.Unmarshal(data, &stored) != nil || stored.Key != key || json.Unmarshal(stored.Value, v) != nil {
+		return time.Time{}, false
+	}
+	// The modification time marks the entry as used, so pruning keeps it.
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return stored.Saved, true
+}
+
+// Store writes v under kind and key, replacing the entry atomically.
+func (c *Cache) Store(kind, key string, v any) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry{Key: key, Saved: time.Now(), Value: value})
+	if err != nil {
+		return err
+	}
+	path := c.path(kind, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// path names the entry by a hash of its key, which may hold any characters.
+func (c *Cache) path(kind, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, kind, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *Cache) prune(maxBytes int64) error {
+	type file struct {
+		path string
+		size int64
+		used time.Time
+	}
+	var files []file
+	var total int64
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files = append(files, file{path: path, size: info.Size(), used: info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].used.Before(files[j].used) })
+	for _, f := range files {
+		if total <= maxBytes {
+			break
+		}
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		total -= f.size
+	}
+	return nil
+}
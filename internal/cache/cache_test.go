@@ -0,0 +1,200 @@
+package cache_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"storage-tui/internal/cache"
+)
+
+func open(t *testing.T, dir string, maxBytes int64) *cache.Cache {
+	t.Helper()
+	c, err := cache.Open(dir, maxBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// entries lists the entry files of kind.
+func entries(t *testing.T, dir, kind string) []string {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(dir, kind, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return paths
+}
+
+func TestStoreAndLoad(t *testing.T) {
+	c := open(t, t.TempDir(), 1<<20)
+	before := time.Now()
+	if err := c.Store("listing", "acme-dev/site", []string{"index.html", "404.html"}); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	saved, ok := c.Load("listing", "acme-dev/site", &got)
+	if !ok || strings.Join(got, ",") != "index.html,404.html" {
+		t.Fatalf("Load = %q, %v", got, ok)
+	}
+	if saved.Before(before) || saved.After(time.Now()) {
+		t.Errorf("saved %v, want the time of Store", saved)
+	}
+	if _, ok := c.Load("listing", "acme-dev/logs", &got); ok {
+		t.Error("Load of a key never stored hit")
+	}
+	if _, ok := c.Load("preview", "acme-dev/site", &got); ok {
+		t.Error("Load of a key stored under another kind hit")
+	}
+}
+
+func TestMisses(t *testing.T) {
+	tests := []struct {
+		name string
+		// spoil changes the entry of key a, given the entry of key b.
+		spoil func(t *testing.T, a, b string)
+	}{
+		{
+			name: "entry of another key",
+			spoil: func(t *testing.T, a, b string) {
+				data, err := os.ReadFile(b)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(a, data, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "unreadable entry",
+			spoil: func(t *testing.T, a, b string) {
+				if err := os.WriteFile(a, []byte(`{"key": "a", "val`), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "value of another type",
+			spoil: func(t *testing.T, a, b string) {
+				if err := os.WriteFile(a, []byte(`{"key": "a", "value": "not a number"}`), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			c := open(t, dir, 1<<20)
+			if err := c.Store("kind", "a", 1); err != nil {
+				t.Fatal(err)
+			}
+			a := entries(t, dir, "kind")[0]
+			if err := c.Store("kind", "b", 2); err != nil {
+				t.Fatal(err)
+			}
+			b := ""
+			for _, path := range entries(t, dir, "kind") {
+				if path != a {
+					b = path
+				}
+			}
+			test.spoil(t, a, b)
+			var n int
+			if _, ok := c.Load("kind", "a", &n); ok {
+				t.Errorf("Load hit %d", n)
+			}
+			if _, ok := c.Load("kind", "b", &n); !ok || n != 2 {
+				t.Errorf("Load of the other key = %d, %v", n, ok)
+			}
+		})
+	}
+}
+
+// TestStoreIsAtomic checks that loads racing stores of the same key see
+// one whole value or another, and that no temporary file is left.
+func TestStoreIsAtomic(t *testing.T) {
+	dir := t.TempDir()
+	c := open(t, dir, 1<<30)
+	values := []string{strings.Repeat("a", 256<<10), strings.Repeat("b", 128<<10)}
+	if err := c.Store("preview", "key", values[0]); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 50 {
+			if err := c.Store("preview", "key", values[i%2]); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for range 200 {
+		var got string
+		if _, ok := c.Load("preview", "key", &got); !ok || (got != values[0] && got != values[1]) {
+			t.Fatalf("Load during Store = %d bytes, %v", len(got), ok)
+		}
+	}
+	wg.Wait()
+	if paths := entries(t, dir, "preview"); len(paths) != 1 {
+		t.Errorf("files after storing one key: %q", paths)
+	}
+}
+
+func TestOpenPrunesLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	c := open(t, dir, 1<<20)
+	keys := []string{"oldest", "older", "newest"}
+	seen := make(map[string]bool)
+	var total int64
+	for i, key := range keys {
+		if err := c.Store("kind", key, strings.Repeat("x", 1000)); err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range entries(t, dir, "kind") {
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+			used := time.Now().Add(-time.Duration(len(keys)-i) * time.Hour)
+			if err := os.Chtimes(path, used, used); err != nil {
+				t.Fatal(err)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			total += info.Size()
+		}
+	}
+	// Loading marks the oldest as used, so the next oldest goes first.
+	var value string
+	if _, ok := c.Load("kind", "oldest", &value); !ok {
+		t.Fatal("Load missed")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), make([]byte, 1<<20), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c = open(t, dir, total-1)
+	for key, want := range map[string]bool{"oldest": true, "older": false, "newest": true} {
+		if _, ok := c.Load("kind", key, &value); ok != want {
+			t.Errorf("after pruning, Load(%q) hit = %v, want %v", key, ok, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Errorf("pruning touched a file that is no entry: %v", err)
+	}
+
+	open(t, dir, 0)
+	if paths := entries(t, dir, "kind"); len(paths) != 0 {
+		t.Errorf("entries left with no room: %q", paths)
+	}
+}
@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"storage-tui/internal/audit"
+	"storage-tui/internal/azure"
+	"storage-tui/internal/ignore"
+)
+
+// defaultAzCopy is the azcopy binary looked up on PATH when the config file
+// names none.
+const defaultAzCopy = "azcopy"
+
+// azcopyExpiry is how long the signature handed to azcopy stays valid; a
+// transfer that outlasts it fails part way, and azcopy can resume it.
+const azcopyExpiry = 8 * time.Hour
+
+// azcopyTransfer is one azcopy copy or sync between a local path and a
+// container, or a blob or prefix in it.
+type azcopyTransfer struct {
+	// Command is azcopy's: copy or sync.
+	Command string
+	Local   string
+	Remote  remote
+	// Upload copies from Local to Remote; otherwise from Remote to Local.
+	Upload    bool
+	Recursive bool
+	// Delete removes what the source does not have from the destination of
+	// a sync.
+	Delete bool
+	// Checksum compares a sync's files by MD5, and DryRun only prints what
+	// a sync would do.
+	Checksum    bool
+	DryRun      bool
+	ContentType string
+	// Exclude are regular expressions of the relative paths azcopy leaves
+	// alone, from the directory's ignore file.
+	Exclude []string
+}
+
+// permissions are what the signature grants azcopy: reading and listing to
+// download, and creating and writing, plus deleting for a sync that
+// deletes blobs, to upload.
+func (t azcopyTransfer) permissions() string {
+	switch {
+	case !t.Upload:
+		return "rl"
+	case t.Delete:
+		return "cwdl"
+	}
+	return "cwl"
+}
+
+// args builds azcopy's command line from signed, a URL signed for the whole
+// container.
+func (t azcopyTransfer) args(signed string) ([]string, error) {
+	target, err := url.Parse(signed)
+	if err != nil {
+		return nil, fmt.Errorf("signed URL: %w", err)
+	}
+	if t.Remote.Blob != "" {
+		target.Path = strings.TrimSuffix(target.Path, "/") + "/" + t.Remote.Blob
+		target.RawPath = ""
+	}
+	args := []string{t.Command, target.String(), t.Local}
+	if t.Upload {
+		args[1], args[2] = t.Local, target.String()
+	}
+	if t.Recursive {
+		args = append(args, "--recursive")
+	}
+	if t.Delete {
+		args = append(args, "--delete-destination=true")
+	}
+	if t.Checksum {
+		args = append(args, "--compare-hash=MD5")
+	}
+	if t.DryRun {
+		args = append(args, "--dry-run")
+	}
+	if t.ContentType != "" {
+		args = append(args, "--content-type="+t.ContentType)
+	}
+	if len(t.Exclude) > 0 {
+		args = append(args, "--exclude-regex="+strings.Join(t.Exclude, ";"))
+	}
+	return append(args, "--output-type=text"), nil
+}
+
+// describe sums up the transfer for the audit log, without the signature.
+func (t azcopyTransfer) describe(opts azure.SASOptions) string {
+	direction := "from " + t.Local
+	if !t.Upload {
+		direction = "to " + t.Local
+	}
+	detail := fmt.Sprintf("azcopy %s %s", t.Command, direction)
+	if t.Delete {
+		detail += ", deleting what the source lacks"
+	}
+	return fmt.Sprintf("%s, permissions %s, expires %s", detail, opts.Permissions, opts.Expiry.UTC().Format(time.RFC3339))
+}
+
+// runAzCopy signs the container for the transfer and hands it to azcopy,
+// whose progress goes to stderr. As the signature is a credential, the run
+// is recorded in the audit log, and refused without it.
+func runAzCopy(ctx context.Context, env Env, t azcopyTransfer) error {
+	if t.Remote.Container == "" {
+		return fmt.Errorf("%w: azcopy transfers to or from %saccount/container[/blob]", ErrUsage, remotePrefix)
+	}
+	if t.Local == "-" {
+		return fmt.Errorf("%w: azcopy copies files and directories, not stdin or stdout", ErrUsage)
+	}
+	signer, ok := env.Provider.(azure.Signer)
+	if !ok {
+		return errors.New("this provider cannot sign URLs for azcopy")
+	}
+	if info, err := os.Stat(t.Local); err == nil && info.IsDir() {
+		ignored, err := ignore.Load(t.Local)
+		if err != nil {
+			return err
+		}
+		if t.Exclude, err = ignored.Regexps(); err != nil {
+			return err
+		}
+		// The ignore file is the directory's own, as for the built-in sync.
+		if !ignored.Empty() {
+			t.Exclude = append(t.Exclude, "^"+regexp.QuoteMeta(ignore.FileName)+"$")
+		}
+	}
+	binary := env.AzCopy
+	if binary == "" {
+		binary = defaultAzCopy
+	}
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return fmt.Errorf("azcopy not found; install it or set azcopy in the config file: %w", err)
+	}
+
+	opts := azure.SASOptions{Permissions: t.permissions(), Expiry: time.Now().Add(azcopyExpiry)}
+	return audited(env, "azcopy", t.Remote, func(entry *audit.Entry) error {
+		entry.Detail = t.describe(opts)
+		signed, err := signer.SignURL(ctx, t.Remote.Account, t.Remote.Container, "", opts)
+		if err != nil {
+			return err
+		}
+		args, err := t.args(signed)
+		if err != nil {
+			return err
+		}
+		cmd := exec.CommandContext(ctx, path, args...)
+		cmd.Stdout, cmd.Stderr = env.Stderr, env.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("azcopy: %w", err)
+		}
+		return nil
+	})
+}
+
+// copyWithAzCopy hands a cp to azcopy, copying directories, containers, and
+// prefixes whole.
+func copyWithAzCopy(ctx context.Context, env Env, source, destination, contentType string) error {
+	if strings.HasPrefix(source, remotePrefix) {
+		from := parseRemote(source)
+		recursive := from.Blob == "" || strings.HasSuffix(from.Blob, "/")
+		return runAzCopy(ctx, env, azcopyTransfer{Command: "copy", Local: destination, Remote: from, Recursive: recursive})
+	}
+	info, err := os.Stat(source)
+	if err != nil && source != "-" {
+		return err
+	}
+	recursive := info != nil && info.IsDir()
+	return runAzCopy(ctx, env, azcopyTransfer{Command: "copy", Local: source, Remote: parseRemote(destination), Upload: true, Recursive: recursive, ContentType: contentType})
+}
@@ -0,0 +1,539 @@
+// Package cli runs the non-interactive subcommands, which reach the same
+// provider as the TUI so scripts can list, copy, and share blobs.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"storage-tui/internal/audit"
+	"storage-tui/internal/azure"
+	"storage-tui/internal/export"
+	"storage-tui/internal/keychain"
+	"storage-tui/internal/patterns"
+	"storage-tui/internal/syncjobs"
+)
+
+// ErrUsage marks errors in how a subcommand was called.
+var ErrUsage = errors.New("usage")
+
+// remotePrefix marks the storage side of a cp. ls and sas take it too, but
+// do not need it.
+const remotePrefix = "az://"
+
+// Usage describes the subcommands for the program's help.
+const Usage = `Commands:
+  ls [-l] [-format csv|json|ndjson] [[az://]account[/container[/prefix]]]
+        list the accounts, the containers of an account, or the blobs of a
+        container; with no argument, the accounts of every subscription.
+        -format writes the blobs with their tiers and tags for reports
+  cp [-content-type type] [-azcopy] az://account/container/blob file|-
+  cp [-content-type type] [-azcopy] file|- az://account/container/[blob]
+        download a blob to a file or stdout, or upload a file or stdin;
+        -azcopy hands large files, directories, and prefixes to azcopy
+  sync [-delete] [-dry-run] [-plan file] [-checksum] [-azcopy] directory az://account/container/[prefix]
+  sync [-delete] [-dry-run] [-plan file] [-checksum] [-azcopy] az://account/container/[prefix] directory
+        make the destination match the source, copying what is new or
+        newer; -delete removes what the source does not have, -dry-run
+        prints the plan instead, -plan saves it as csv, json, or ndjson
+        first, -checksum compares by MD5, and -azcopy hands the sync to
+        azcopy
+  syncd [-once] [-dry-run]
+        run the sync jobs of the config file on their schedules until
+        interrupted, a line per run on stderr; -once runs each one once,
+        and -dry-run prints each one's plan instead
+  sas [-permissions racwdl] [-expiry duration] [-save name] [az://]account/container[/blob]
+        print a shared access signature URL for a container or blob; -save
+        keeps it in the OS keychain under name instead
+  secret set|get|rm name
+        keep a secret, such as a connection string, read from stdin in the
+        OS keychain, print it, or remove it
+  iac [-format terraform|bicep] [az://]account[/container]
+        print the account, or one container of it, with its blob service
+        settings and lifecycle rules as Terraform or Bicep
+  completion bash|zsh|fish
+        print a script that completes commands, flags, and storage names
+`
+
+// Env is what the subcommands run against.
+type Env struct {
+	Provider azure.Provider
+	// Subscriptions limits ls to these subscription IDs or names; empty
+	// lists every subscription.
+	Subscriptions []string
+	// SubscriptionPatterns and AccountPatterns hide the subscriptions and
+	// accounts the config file leaves out of the tree.
+	SubscriptionPatterns patterns.Set
+	AccountPatterns      patterns.Set
+	// Profiles names the profiles of the config file, and Flags holds the
+	// program's own flags; both are only used for completion.
+	Profiles []string
+	Flags    *flag.FlagSet
+	// AzCopy is the azcopy binary for cp -azcopy and sync -azcopy; empty looks up
+	// azcopy on PATH.
+	AzCopy string
+	// SyncJobs are the config file's sync jobs, which syncd runs.
+	SyncJobs []syncjobs.Job
+	// Audit records uploads and signed URLs; without it they are refused.
+	Audit  *audit.Log
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// IsCommand reports whether name is one of the subcommands.
+func IsCommand(name string) bool {
+	switch name {
+	case "ls", "cp", "sync", "syncd", "sas", "secret", "iac", "completion", completeCommand:
+		return true
+	}
+	return false
+}
+
+// Run runs the subcommand named by args[0] with the rest of args.
+func Run(ctx context.Context, env Env, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: no command given", ErrUsage)
+	}
+	switch args[0] {
+	case "ls":
+		return runList(ctx, env, args[1:])
+	case "cp":
+		return runCopy(ctx, env, args[1:])
+	case "sync":
+		return runSync(ctx, env, args[1:])
+	case "syncd":
+		return runSyncd(ctx, env, args[1:])
+	case "sas":
+		return runSign(ctx, env, args[1:])
+	case "secret":
+		return runSecret(ctx, env, args[1:])
+	case "iac":
+		return runIaC(ctx, env, args[1:])
+	case "completion":
+		return runCompletion(env, args[1:])
+	case completeCommand:
+		return runComplete(ctx, env, args[1:])
+	}
+	return fmt.Errorf("%w: unknown command %q (available: ls, cp, sync, syncd, sas, secret, iac, completion)", ErrUsage, args[0])
+}
+
+// remote is a storage location as written on the command line.
+type remote struct {
+	Account   string
+	Container string
+	// Blob is the blob name, or the prefix ls lists.
+	Blob string
+}
+
+func (r remote) String() string {
+	return strings.TrimRight(remotePrefix+r.Account+"/"+r.Container+"/"+r.Blob, "/")
+}
+
+func parseRemote(arg string) remote {
+	parts := strings.SplitN(strings.TrimPrefix(arg, remotePrefix), "/", 3)
+	var target remote
+	target.Account = parts[0]
+	if len(parts) > 1 {
+		target.Container = parts[1]
+	}
+	if len(parts) > 2 {
+		target.Blob = parts[2]
+	}
+	return target
+}
+
+func newFlagSet(env Env, name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(env.Stderr)
+	flags.Usage = func() {
+		fmt.Fprint(env.Stderr, Usage)
+		fmt.Fprintf(env.Stderr, "\nFlags of %s:\n", name)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseFlags parses the flags of a subcommand. -h is passed on as
+// flag.ErrHelp once the usage is printed.
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrUsage, err)
+	}
+	return nil
+}
+
+func runList(ctx context.Context, env Env, args []string) error {
+	flags := newFlagSet(env, "ls")
+	long := flags.Bool("l", false, "show sizes, times, and types")
+	format := flags.String("format", "", "write the blobs as csv, json, or ndjson, with their tiers and tags")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return fmt.Errorf("%w: ls lists one location", ErrUsage)
+	}
+	if *format != "" && !slices.Contains(export.Formats, *format) {
+		return fmt.Errorf("%w: unknown format %q (available: %s)", ErrUsage, *format, strings.Join(export.Formats, ", "))
+	}
+	if *format != "" && (flags.NArg() == 0 || parseRemote(flags.Arg(0)).Container == "") {
+		return fmt.Errorf("%w: -format exports the blobs of a container", ErrUsage)
+	}
+	out := tabwriter.NewWriter(env.Stdout, 0, 4, 2, ' ', 0)
+	defer out.Flush()
+
+	if flags.NArg() == 0 {
+		return listAccounts(ctx, env, out, *long)
+	}
+	target := parseRemote(flags.Arg(0))
+	if target.Container == "" {
+		containers, err := env.Provider.ListContainers(ctx, target.Account)
+		if err != nil {
+			return err
+		}
+		for _, container := range containers {
+			if *long {
+				fmt.Fprintf(out, "%s\t%s\n", container.Name, container.PublicAccess)
+			} else {
+				fmt.Fprintln(out, container.Name)
+			}
+		}
+		return nil
+	}
+
+	if *format != "" {
+		return exportBlobs(ctx, env, target, *format)
+	}
+	marker := ""
+	for {
+		page, err := env.Provider.ListBlobsPage(ctx, target.Account, target.Container, azure.ListBlobsOptions{
+			Marker: marker,
+			Prefix: target.Blob,
+		})
+		if err != nil {
+			return err
+		}
+		for _, blob := range page.Blobs {
+			if *long {
+				fmt.Fprintf(out, "%d\t%s\t%s\t%s\n", blob.SizeBytes, blob.Modified.UTC().Format(time.RFC3339), blob.ContentType, blob.Name)
+			} else {
+				fmt.Fprintln(out, blob.Name)
+			}
+		}
+		if page.NextMarker == "" {
+			return nil
+		}
+		marker = page.NextMarker
+	}
+}
+
+// exportBlobs writes the blobs with the properties a listing leaves out, for
+// reports.
+func exportBlobs(ctx context.Context, env Env, target remote, format string) error {
+	var rows []export.Blob
+	marker := ""
+	for {
+		page, err := env.Provider.ListBlobsPage(ctx, target.Account, target.Container, azure.ListBlobsOptions{
+			Marker: marker,
+			Prefix: target.Blob,
+		})
+		if err != nil {
+			return err
+		}
+		for _, blob := range page.Blobs {
+			props, err := env.Provider.GetBlobProperties(ctx, target.Account, target.Container, blob.Name)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, export.Blob{
+				Account:     target.Account,
+				Container:   target.Container,
+				Name:        blob.Name,
+				SizeBytes:   blob.SizeBytes,
+				Modified:    blob.Modified,
+				ContentType: blob.ContentType,
+				Tier:        props.AccessTier,
+				Tags:        props.Tags,
+			})
+		}
+		if page.NextMarker == "" {
+			return export.Write(env.Stdout, format, rows)
+		}
+		marker = page.NextMarker
+	}
+}
+
+func listAccounts(ctx context.Context, env Env, out io.Writer, long bool) error {
+	subscriptions, err := env.Provider.ListSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, subscription := range subscriptions {
+		if !showsSubscription(env, subscription) {
+			continue
+		}
+		accounts, err := env.Provider.ListAccounts(ctx, subscription.ID)
+		if err != nil {
+			return err
+		}
+		for _, account := range accounts {
+			if !env.AccountPatterns.Shows(account.Name) {
+				continue
+			}
+			if long {
+				fmt.Fprintf(out, "%s\t%s\t%s\n", account.Name, account.Region, subscription.Name)
+			} else {
+				fmt.Fprintln(out, account.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func showsSubscription(env Env, subscription azure.Subscription) bool {
+	if !env.SubscriptionPatterns.Shows(subscription.ID, subscription.Name) {
+		return false
+	}
+	if len(env.Subscriptions) == 0 {
+		return true
+	}
+	for _, name := range env.Subscriptions {
+		if name == subscription.ID || strings.EqualFold(name, subscription.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func runCopy(ctx context.Context, env Env, args []string) error {
+	flags := newFlagSet(env, "cp")
+	contentType := flags.String("content-type", "", "content type of an upload (default: guessed from the file name)")
+	useAzCopy := flags.Bool("azcopy", false, "hand the transfer to azcopy, for large files, directories, and prefixes")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return fmt.Errorf("%w: cp takes a source and a destination", ErrUsage)
+	}
+	source, destination := flags.Arg(0), flags.Arg(1)
+	sourceRemote := strings.HasPrefix(source, remotePrefix)
+	destinationRemote := strings.HasPrefix(destination, remotePrefix)
+	switch {
+	case sourceRemote != destinationRemote && *useAzCopy:
+		return copyWithAzCopy(ctx, env, source, destination, *contentType)
+	case sourceRemote && !destinationRemote:
+		return download(ctx, env, parseRemote(source), destination)
+	case !sourceRemote && destinationRemote:
+		return upload(ctx, env, source, parseRemote(destination), *contentType)
+	}
+	return fmt.Errorf("%w: cp copies between a file and a blob; write the blob as %saccount/container/blob", ErrUsage, remotePrefix)
+}
+
+func download(ctx context.Context, env Env, source remote, destination string) error {
+	if source.Container == "" || source.Blob == "" || strings.HasSuffix(source.Blob, "/") {
+		return fmt.Errorf("%w: download a single blob, %saccount/container/blob", ErrUsage, remotePrefix)
+	}
+	reader, err := env.Provider.OpenBlob(ctx, source.Account, source.Container, source.Blob)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if destination == "-" {
+		_, err := io.Copy(env.Stdout, reader)
+		return err
+	}
+	if info, err := os.Stat(destination); (err == nil && info.IsDir()) || strings.HasSuffix(destination, string(os.PathSeparator)) {
+		destination = filepath.Join(destination, filepath.Base(filepath.FromSlash(source.Blob)))
+	}
+	// Write next to the destination and rename, so a failed download does
+	// not leave a truncated file behind.
+	tmp := destination + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, reader); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, destination)
+}
+
+func upload(ctx context.Context, env Env, source string, destination remote, contentType string) error {
+	uploader, ok := env.Provider.(azure.Uploader)
+	if !ok {
+		return errors.New("this provider cannot upload blobs")
+	}
+	if destination.Container == "" {
+		return fmt.Errorf("%w: upload to %saccount/container/blob", ErrUsage, remotePrefix)
+	}
+	if destination.Blob == "" || strings.HasSuffix(destination.Blob, "/") {
+		if source == "-" {
+			return fmt.Errorf("%w: name the blob to upload stdin to", ErrUsage)
+		}
+		destination.Blob += filepath.Base(source)
+	}
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(destination.Blob))
+	}
+
+	return audited(env, "upload", destination, func(entry *audit.Entry) error {
+		reader := env.Stdin
+		entry.Detail = "from stdin"
+		if source != "-" {
+			entry.Detail = "from " + source
+			file, err := os.Open(source)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			reader = file
+		}
+		// A blob written over is named in the entry with its ETag, so just
+		// that version can be restored from the audit log in the browser.
+		previous, err := env.Provider.GetBlobProperties(ctx, destination.Account, destination.Container, destination.Blob)
+		replaces := err == nil
+		blob, err := uploader.UploadBlob(ctx, destination.Account, destination.Container, destination.Blob, reader, contentType)
+		if err != nil {
+			return err
+		}
+		entry.Detail += fmt.Sprintf(", %d bytes, %s", blob.SizeBytes, blob.ContentType)
+		if replaces {
+			entry.Detail += ", replacing the blob"
+			entry.Replaced = []azure.BlobVersion{{Name: destination.Blob, ETag: previous.ETag}}
+		}
+		return nil
+	})
+}
+
+func runSign(ctx context.Context, env Env, args []string) error {
+	flags := newFlagSet(env, "sas")
+	permissions := flags.String("permissions", "r", "what the URL grants: any of r(ead), a(dd), c(reate), w(rite), d(elete), l(ist)")
+	expiry := flags.Duration("expiry", time.Hour, "how long the URL stays valid")
+	save := flags.String("save", "", "keep the URL in the OS keychain under this name instead of printing it")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *save != "" {
+		if err := keychain.CheckName(*save); err != nil {
+			return fmt.Errorf("%w: %v", ErrUsage, err)
+		}
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("%w: sas signs one container or blob", ErrUsage)
+	}
+	signer, ok := env.Provider.(azure.Signer)
+	if !ok {
+		return errors.New("this provider cannot sign URLs")
+	}
+	target := parseRemote(flags.Arg(0))
+	if target.Container == "" {
+		return fmt.Errorf("%w: sign %saccount/container or %saccount/container/blob", ErrUsage, remotePrefix, remotePrefix)
+	}
+	if *permissions == "" || strings.Trim(*permissions, "racwdl") != "" {
+		return fmt.Errorf("%w: permissions %q are not a combination of r, a, c, w, d, and l", ErrUsage, *permissions)
+	}
+	if *expiry <= 0 {
+		return fmt.Errorf("%w: expiry must be positive", ErrUsage)
+	}
+	opts := azure.SASOptions{Permissions: *permissions, Expiry: time.Now().Add(*expiry)}
+	// The URL is a credential, so the audit log keeps what it grants only.
+	var signed string
+	err := audited(env, "sas", target, func(entry *audit.Entry) error {
+		var err error
+		entry.Detail = fmt.Sprintf("permissions %s, expires %s", opts.Permissions, opts.Expiry.UTC().Format(time.RFC3339))
+		signed, err = signer.SignURL(ctx, target.Account, target.Container, target.Blob, opts)
+		if err != nil || *save == "" {
+			return err
+		}
+		entry.Detail += ", saved to the keychain as " + *save
+		return keychain.Set(ctx, *save, signed)
+	})
+	switch {
+	case err != nil:
+		return err
+	case *save != "":
+		fmt.Fprintf(env.Stderr, "Saved to the keychain as %s; print it with: storage-tui secret get %s\n", *save, *save)
+	default:
+		fmt.Fprintln(env.Stdout, signed)
+	}
+	return nil
+}
+
+// runSecret keeps, prints, and removes secrets in the OS keychain, so
+// connection strings and signed URLs need not sit in plain-text files.
+func runSecret(ctx context.Context, env Env, args []string) error {
+	flags := newFlagSet(env, "secret")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return fmt.Errorf("%w: secret takes set, get, or rm and a name", ErrUsage)
+	}
+	name := flags.Arg(1)
+	if err := keychain.CheckName(name); err != nil {
+		return fmt.Errorf("%w: %v", ErrUsage, err)
+	}
+	switch flags.Arg(0) {
+	case "set":
+		data, err := io.ReadAll(env.Stdin)
+		if err != nil {
+			return err
+		}
+		secret := strings.TrimRight(string(data), "\r\n")
+		if secret == "" {
+			return fmt.Errorf("%w: pipe the secret to set on stdin", ErrUsage)
+		}
+		return keychain.Set(ctx, name, secret)
+	case "get":
+		secret, err := keychain.Get(ctx, name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(env.Stdout, secret)
+		return nil
+	case "rm":
+		return keychain.Remove(ctx, name)
+	}
+	return fmt.Errorf("%w: unknown secret action %q (available: set, get, rm)", ErrUsage, flags.Arg(0))
+}
+
+// audited runs action on target once the audit log is there to record it,
+// then records how it ended with the detail, and the blobs changed, that run
+// fills in.
+func audited(env Env, action string, target remote, run func(entry *audit.Entry) error) error {
+	if env.Audit == nil {
+		return fmt.Errorf("%s is refused: the audit log could not be opened", action)
+	}
+	entry := audit.Entry{Action: action, Target: target.String(), Result: audit.ResultOK}
+	err := run(&entry)
+	if identity, ok := env.Provider.(interface{ Identity() string }); ok {
+		entry.Identity = identity.Identity()
+	}
+	if err != nil {
+		entry.Result, entry.Error = audit.ResultFailed, err.Error()
+	}
+	if recordErr := env.Audit.Record(entry); recordErr != nil {
+		return errors.Join(err, recordErr)
+	}
+	return err
+}
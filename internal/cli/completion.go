@@ -0,0 +1,369 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"storage-tui/internal/azure"
+	"storage-tui/internal/export"
+	"storage-tui/internal/iac"
+	"storage-tui/internal/logging"
+)
+
+// completeCommand is the hidden command the completion scripts call with the
+// words after the program name, the word being completed last. It prints one
+// candidate per line; none lets the shell fall back to file names.
+const completeCommand = "__complete"
+
+// completionLimit caps the blobs listed to complete a name prefix.
+const completionLimit = 1000
+
+// commandFlags are the flags of each command, for completion.
+var commandFlags = map[string][]string{
+	"ls":         {"-l", "-format"},
+	"cp":         {"-content-type", "-azcopy"},
+	"sync":       {"-delete", "-dry-run", "-plan", "-checksum", "-azcopy"},
+	"syncd":      {"-once", "-dry-run"},
+	"sas":        {"-permissions", "-expiry", "-save"},
+	"secret":     nil,
+	"iac":        {"-format"},
+	"completion": nil,
+}
+
+// valueFlags are the command flags followed by a value.
+var valueFlags = map[string]bool{
+	"-format":       true,
+	"-content-type": true,
+	"-permissions":  true,
+	"-expiry":       true,
+	"-save":         true,
+	"-plan":         true,
+}
+
+// flagValues are the fixed choices of the program's flags.
+var flagValues = map[string][]string{
+	"provider":  {"mock"},
+	"theme":     {"dark", "light", "solarized"},
+	"time":      {"utc", "local", "relative"},
+	"icons":     {"nerd", "ascii", "off"},
+	"layout":    {"stacked", "columns"},
+	"log-level": logging.Levels,
+}
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for storage-tui; load it with
+#   source <(storage-tui completion bash)
+_storage_tui() {
+	local line=${COMP_LINE:0:COMP_POINT} words
+	read -ra words <<<"$line"
+	[[ $line == *[[:space:]] ]] && words+=("")
+	local IFS=$'\n'
+	COMPREPLY=($(storage-tui __complete "${words[@]:1}" 2>/dev/null))
+	# bash splits words at colons, so drop the part of az:// it already has.
+	local word=${words[${#words[@]}-1]}
+	if [[ $word == *:* ]]; then
+		local colon=${word%"${word##*:}"}
+		COMPREPLY=("${COMPREPLY[@]#"$colon"}")
+	fi
+	if [[ ${#COMPREPLY[@]} -eq 1 && ${COMPREPLY[0]} == */ ]]; then
+		compopt -o nospace
+	fi
+}
+complete -o default -F _storage_tui storage-tui
+`,
+	"zsh": `#compdef storage-tui
+# zsh completion for storage-tui; load it with
+#   source <(storage-tui completion zsh)
+_storage_tui() {
+	local -a candidates folders
+	candidates=("${(@f)$(storage-tui __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	candidates=(${candidates:#})
+	if (( ${#candidates} == 0 )); then
+		_files
+		return
+	fi
+	folders=(${(M)candidates:#*/})
+	candidates=(${candidates:#*/})
+	(( ${#folders} )) && compadd -S '' -- $folders
+	(( ${#candidates} )) && compadd -- $candidates
+}
+compdef _storage_tui storage-tui
+`,
+	"fish": `# fish completion for storage-tui; load it with
+#   storage-tui completion fish | source
+function __storage_tui_complete
+	set -l words (commandline -opc) (commandline -ct)
+	set -l candidates (storage-tui __complete $words[2..-1] 2>/dev/null)
+	if test (count $candidates) -eq 0
+		__fish_complete_path (commandline -ct)
+		return
+	end
+	printf '%s\n' $candidates
+end
+complete -c storage-tui -f -a '(__storage_tui_complete)'
+`,
+}
+
+func runCompletion(env Env, args []string) error {
+	flags := newFlagSet(env, "completion")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	script, ok := completionScripts[flags.Arg(0)]
+	if flags.NArg() != 1 || !ok {
+		return fmt.Errorf("%w: completion takes a shell: bash, zsh, or fish", ErrUsage)
+	}
+	fmt.Fprint(env.Stdout, script)
+	return nil
+}
+
+func runComplete(ctx context.Context, env Env, args []string) error {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	for _, candidate := range complete(ctx, env, args[:len(args)-1], args[len(args)-1]) {
+		fmt.Fprintln(env.Stdout, candidate)
+	}
+	return nil
+}
+
+// complete returns the candidates for word, given the words before it.
+func complete(ctx context.Context, env Env, before []string, word string) []string {
+	command := ""
+	// valueOf is the program flag whose value comes next; skip is set when a
+	// command flag's value does.
+	valueOf := ""
+	skip := false
+	values := make(map[string]string)
+	for _, arg := range before {
+		switch {
+		case skip:
+			skip = false
+		case valueOf != "":
+			values[valueOf] = arg
+			valueOf = ""
+		case command == "" && strings.HasPrefix(arg, "-"):
+			name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			if hasValue {
+				values[name] = value
+			} else if takesValue(env.Flags, name) {
+				valueOf = name
+			}
+		case command == "":
+			command = arg
+		default:
+			skip = valueFlags[arg]
+		}
+	}
+	if skip {
+		if before[len(before)-1] == "-format" && command == "iac" {
+			return matching(iac.Formats, word)
+		}
+		if before[len(before)-1] == "-format" {
+			return matching(export.Formats, word)
+		}
+		return nil
+	}
+	if valueOf != "" {
+		return completeValue(ctx, env, valueOf, word, values)
+	}
+
+	if strings.HasPrefix(word, "-") {
+		if command == "" {
+			name, value, hasValue := strings.Cut(strings.TrimLeft(word, "-"), "=")
+			if hasValue {
+				dashes := word[:strings.Index(word, name)]
+				return prefixed(dashes+name+"=", completeValue(ctx, env, name, value, values))
+			}
+			return matching(globalFlags(env.Flags), word)
+		}
+		return matching(commandFlags[command], word)
+	}
+
+	switch command {
+	case "":
+		var commands []string
+		for name := range commandFlags {
+			commands = append(commands, name)
+		}
+		sort.Strings(commands)
+		return matching(commands, word)
+	case "completion":
+		return matching([]string{"bash", "fish", "zsh"}, word)
+	case "secret":
+		if before[len(before)-1] == "secret" {
+			return matching([]string{"get", "rm", "set"}, word)
+		}
+		return nil
+	case "syncd":
+		return nil
+	case "cp", "sync":
+		// Local paths are left to the shell.
+		if !strings.HasPrefix(word, "az:") {
+			return nil
+		}
+		if !strings.HasPrefix(word, remotePrefix) {
+			return []string{remotePrefix}
+		}
+	}
+	return completeLocation(ctx, env, word)
+}
+
+// completeValue completes the value of a program flag.
+func completeValue(ctx context.Context, env Env, name, word string, values map[string]string) []string {
+	switch name {
+	case "profile":
+		return matching(env.Profiles, word)
+	case "subscription":
+		var names []string
+		for _, subscription := range shownSubscriptions(ctx, env) {
+			names = append(names, subscription.Name)
+		}
+		return matching(names, word)
+	case "account":
+		return matching(accountNames(ctx, env), word)
+	case "container":
+		account := values["account"]
+		if account == "" {
+			return nil
+		}
+		containers, err := env.Provider.ListContainers(ctx, account)
+		if err != nil {
+			return nil
+		}
+		var names []string
+		for _, container := range containers {
+			names = append(names, container.Name)
+		}
+		return matching(names, word)
+	}
+	return matching(flagValues[name], word)
+}
+
+// completeLocation completes [az://]account/container/blob one segment at a
+// time; blob names stop at the next slash, like folders.
+func completeLocation(ctx context.Context, env Env, word string) []string {
+	scheme := ""
+	if strings.HasPrefix(word, remotePrefix) {
+		scheme = remotePrefix
+	}
+	target := parseRemote(word)
+	switch strings.Count(strings.TrimPrefix(word, scheme), "/") {
+	case 0:
+		return prefixed(scheme, suffixed(matching(accountNames(ctx, env), target.Account), "/"))
+	case 1:
+		containers, err := env.Provider.ListContainers(ctx, target.Account)
+		if err != nil {
+			return nil
+		}
+		var names []string
+		for _, container := range containers {
+			names = append(names, container.Name)
+		}
+		return prefixed(scheme+target.Account+"/", suffixed(matching(names, target.Container), "/"))
+	}
+
+	page, err := env.Provider.ListBlobsPage(ctx, target.Account, target.Container, azure.ListBlobsOptions{
+		MaxResults: completionLimit,
+		Prefix:     target.Blob,
+	})
+	if err != nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var names []string
+	for _, blob := range page.Blobs {
+		name := blob.Name
+		if slash := strings.Index(name[len(target.Blob):], "/"); slash >= 0 {
+			name = name[:len(target.Blob)+slash+1]
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return prefixed(scheme+target.Account+"/"+target.Container+"/", names)
+}
+
+func shownSubscriptions(ctx context.Context, env Env) []azure.Subscription {
+	subscriptions, err := env.Provider.ListSubscriptions(ctx)
+	if err != nil {
+		return nil
+	}
+	var shown []azure.Subscription
+	for _, subscription := range subscriptions {
+		if showsSubscription(env, subscription) {
+			shown = append(shown, subscription)
+		}
+	}
+	return shown
+}
+
+func accountNames(ctx context.Context, env Env) []string {
+	var names []string
+	for _, subscription := range shownSubscriptions(ctx, env) {
+		accounts, err := env.Provider.ListAccounts(ctx, subscription.ID)
+		if err != nil {
+			continue
+		}
+		for _, account := range accounts {
+			if env.AccountPatterns.Shows(account.Name) {
+				names = append(names, account.Name)
+			}
+		}
+	}
+	return names
+}
+
+// takesValue reports whether the program flag name needs a value, unlike a
+// boolean flag.
+func takesValue(flags *flag.FlagSet, name string) bool {
+	if flags == nil {
+		return false
+	}
+	f := flags.Lookup(name)
+	if f == nil {
+		return false
+	}
+	if boolean, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && boolean.IsBoolFlag() {
+		return false
+	}
+	return true
+}
+
+func globalFlags(flags *flag.FlagSet) []string {
+	var names []string
+	if flags != nil {
+		flags.VisitAll(func(f *flag.Flag) {
+			names = append(names, "-"+f.Name)
+		})
+	}
+	return names
+}
+
+func matching(candidates []string, word string) []string {
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches
+}
+
+func prefixed(prefix string, candidates []string) []string {
+	for i := range candidates {
+		candidates[i] = prefix + candidates[i]
+	}
+	return candidates
+}
+
+func suffixed(candidates []string, suffix string) []string {
+	for i := range candidates {
+		candidates[i] += suffix
+	}
+	return candidates
+}
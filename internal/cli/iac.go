@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"storage-tui/internal/azure"
+	"storage-tui/internal/iac"
+)
+
+// runIaC prints the account, or one of its containers, as Terraform or
+// Bicep.
+func runIaC(ctx context.Context, env Env, args []string) error {
+	flags := newFlagSet(env, "iac")
+	format := flags.String("format", iac.Formats[0], "terraform or bicep")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("%w: iac describes one account or container", ErrUsage)
+	}
+	if !slices.Contains(iac.Formats, *format) {
+		return fmt.Errorf("%w: unknown format %q (available: %s)", ErrUsage, *format, strings.Join(iac.Formats, ", "))
+	}
+	target := parseRemote(flags.Arg(0))
+	if target.Blob != "" {
+		return fmt.Errorf("%w: iac describes an account or a container, not blobs", ErrUsage)
+	}
+	account, err := findAccount(ctx, env, target.Account)
+	if err != nil {
+		return err
+	}
+	described, err := iac.Collect(ctx, env.Provider, account, target.Container)
+	if err != nil {
+		return err
+	}
+	return iac.Write(env.Stdout, *format, described)
+}
+
+// findAccount looks the account up in the subscriptions ls shows.
+func findAccount(ctx context.Context, env Env, name string) (azure.Account, error) {
+	subscriptions, err := env.Provider.ListSubscriptions(ctx)
+	if err != nil {
+		return azure.Account{}, err
+	}
+	for _, subscription := range subscriptions {
+		if !showsSubscription(env, subscription) {
+			continue
+		}
+		accounts, err := env.Provider.ListAccounts(ctx, subscription.ID)
+		if err != nil {
+			return azure.Account{}, err
+		}
+		for _, account := range accounts {
+			if account.Name == name {
+				return account, nil
+			}
+		}
+	}
+	return azure.Account{}, fmt.Errorf("account %q not found", name)
+}
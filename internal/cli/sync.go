@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"storage-tui/internal/audit"
+	"storage-tui/internal/dirsync"
+	"storage-tui/internal/syncjobs"
+)
+
+func runSync(ctx context.Context, env Env, args []string) error {
+	flags := newFlagSet(env, "sync")
+	deleteExtra := flags.Bool("delete", false, "delete what the source does not have from the destination")
+	dryRun := flags.Bool("dry-run", false, "print the plan without carrying it out")
+	planFile := flags.String("plan", "", "write the plan to `file`, as csv, json, or ndjson by its extension, before carrying it out")
+	checksum := flags.Bool("checksum", false, "compare files and blobs of the same size by MD5 instead of modified time")
+	useAzCopy := flags.Bool("azcopy", false, "hand the sync to azcopy")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return fmt.Errorf("%w: sync takes a source and a destination", ErrUsage)
+	}
+	source, destination := flags.Arg(0), flags.Arg(1)
+	t := azcopyTransfer{Command: "sync", Delete: *deleteExtra, Checksum: *checksum, DryRun: *dryRun}
+	switch sourceRemote, destinationRemote := strings.HasPrefix(source, remotePrefix), strings.HasPrefix(destination, remotePrefix); {
+	case sourceRemote && !destinationRemote:
+		t.Local, t.Remote = destination, parseRemote(source)
+	case !sourceRemote && destinationRemote:
+		t.Local, t.Remote, t.Upload = source, parseRemote(destination), true
+	default:
+		return fmt.Errorf("%w: sync syncs a directory with a container or prefix, written %saccount/container/[prefix]", ErrUsage, remotePrefix)
+	}
+	if *useAzCopy {
+		if *planFile != "" {
+			return fmt.Errorf("%w: -plan needs the built-in sync; azcopy makes its own plan", ErrUsage)
+		}
+		return runAzCopy(ctx, env, t)
+	}
+	if t.Remote.Container == "" || t.Local == "-" {
+		return fmt.Errorf("%w: sync syncs a directory with %saccount/container/[prefix]", ErrUsage, remotePrefix)
+	}
+
+	plan, err := dirsync.Build(ctx, env.Provider, dirsync.Options{
+		Local:     t.Local,
+		Account:   t.Remote.Account,
+		Container: t.Remote.Container,
+		Prefix:    t.Remote.Blob,
+		Upload:    t.Upload,
+		Delete:    t.Delete,
+		Checksum:  t.Checksum,
+	})
+	if err != nil {
+		return err
+	}
+	if *planFile != "" {
+		if err := plan.Save(*planFile); err != nil {
+			return err
+		}
+		fmt.Fprintf(env.Stderr, "Plan written to %s.\n", *planFile)
+	}
+	if *dryRun {
+		return printPlan(env, plan)
+	}
+	fmt.Fprintln(env.Stderr, plan.Summary())
+	if len(plan.Actions) == 0 {
+		return nil
+	}
+	if !t.Upload {
+		return plan.Execute(ctx, env.Provider, progressPrinter(env))
+	}
+	// Writing and deleting blobs is recorded in the audit log, as uploads
+	// are.
+	return audited(env, "sync", t.Remote, func(entry *audit.Entry) error {
+		entry.Detail = fmt.Sprintf("from %s, planned %s", t.Local, strings.ToLower(strings.TrimSuffix(plan.Summary(), ".")))
+		var changed dirsync.Changed
+		err := plan.Execute(ctx, env.Provider, plan.Track(&changed, progressPrinter(env)))
+		entry.SetChanged(changed.Replaced, changed.Deleted)
+		return err
+	})
+}
+
+// printPlan writes the plan one action a line, as kind, reason, size, and
+// name, with its summary last.
+func printPlan(env Env, plan dirsync.Plan) error {
+	out := tabwriter.NewWriter(env.Stdout, 0, 0, 2, ' ', 0)
+	for _, action := range plan.Actions {
+		fmt.Fprintf(out, "%s\t%s\t%d\t%s\n", action.Kind, action.Reason, action.Size, action.Name)
+	}
+	if err := out.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(env.Stdout, plan.Summary())
+	return err
+}
+
+// progressPrinter writes a line to stderr as each action is done, with how
+// far through the plan's bytes the sync is. The errors of failed
+// actions come with the one Execute returns.
+func progressPrinter(env Env) func(dirsync.Progress) {
+	return func(progress dirsync.Progress) {
+		percent := 100
+		if progress.TotalBytes > 0 {
+			percent = int(progress.DoneBytes * 100 / progress.TotalBytes)
+		}
+		line := fmt.Sprintf("[%d/%d %3d%%] %s %s", progress.Done, progress.Total, percent, progress.Action.Kind, progress.Action.Name)
+		if progress.Err != nil {
+			line += " failed"
+		}
+		fmt.Fprintln(env.Stderr, line)
+	}
+}
+
+// runSyncd runs the config file's sync jobs on their schedules until ctx is
+// done, or each of them once with -once.
+func runSyncd(ctx context.Context, env Env, args []string) error {
+	flags := newFlagSet(env, "syncd")
+	once := flags.Bool("once", false, "run each job once, one after the other, and exit")
+	dryRun := flags.Bool("dry-run", false, "print each job's plan without carrying it out, and exit")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("%w: syncd takes no arguments; its jobs are the syncJobs of the config file", ErrUsage)
+	}
+	if len(env.SyncJobs) == 0 {
+		return errors.New("no sync jobs: add them under syncJobs in the config file")
+	}
+	scheduler := syncjobs.New(env.Provider, env.SyncJobs, syncjobs.Options{
+		Audit:  env.Audit,
+		Notify: runPrinter(env),
+	})
+	if *dryRun {
+		for i, job := range env.SyncJobs {
+			plan, err := scheduler.DryRun(ctx, job.Name)
+			if err != nil {
+				return fmt.Errorf("%s: %w", job.Name, err)
+			}
+			if i > 0 {
+				fmt.Fprintln(env.Stdout)
+			}
+			fmt.Fprintf(env.Stdout, "%s: %s %s\n", job.Name, job.Direction(), job.Remote())
+			if err := printPlan(env, plan); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if *once {
+		return scheduler.RunAll(ctx)
+	}
+	fmt.Fprintf(env.Stderr, "Running %d sync jobs; interrupt to stop.\n", len(env.SyncJobs))
+	scheduler.Start(ctx)
+	<-ctx.Done()
+	return nil
+}
+
+// runPrinter writes a line to stderr as each run of a job starts and ends.
+func runPrinter(env Env) func(syncjobs.Run) {
+	return func(run syncjobs.Run) {
+		switch {
+		case run.Running():
+			fmt.Fprintf(env.Stderr, "%s %s: started\n", run.Started.Format(time.RFC3339), run.Job)
+		case run.Err != nil:
+			fmt.Fprintf(env.Stderr, "%s %s: failed: %v\n", run.Finished.Format(time.RFC3339), run.Job, run.Err)
+		default:
+			fmt.Fprintf(env.Stderr, "%s %s: %s\n", run.Finished.Format(time.RFC3339), run.Job, run.Summary)
+		}
+	}
+}
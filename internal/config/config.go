@@ -0,0 +1,209 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Ratios are the relative sizes of the tree, contents, and preview panes.
+// Zero keeps the built-in sizes of the layout.
+type Ratios struct {
+	Tree     int `yaml:"tree,omitempty"`
+	Contents int `yaml:"contents,omitempty"`
+	Preview  int `yaml:"preview,omitempty"`
+}
+
+// Profile is a named environment with its own sign-in and subscriptions, such
+// as dev, test, or prod.
+type Profile struct {
+	Tenant string `yaml:"tenant,omitempty"`
+	// Cloud names the cloud to sign in to, e.g. AzurePublicCloud.
+	Cloud string `yaml:"cloud,omitempty"`
+	// Credentials selects how to sign in, e.g. cli, env, or a managed
+	// identity.
+	Credentials string `yaml:"credentials,omitempty"`
+	// Subscriptions lists the subscription IDs or names to show; empty shows
+	// them all.
+	Subscriptions []string `yaml:"subscriptions,omitempty"`
+	// Proxy is the proxy URL to connect through; empty uses HTTPS_PROXY,
+	// HTTP_PROXY, and NO_PROXY.
+	Proxy string `yaml:"proxy,omitempty"`
+	// CABundle is a PEM file of extra certificates to trust, such as the
+	// root of a proxy that inspects TLS.
+	CABundle           string `yaml:"caBundle,omitempty"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify,omitempty"`
+	// Remote reaches the storage endpoints through a daemon instead, for
+	// workstations that cannot reach them directly.
+	Remote *Remote `yaml:"remote,omitempty"`
+}
+
+// Remote is a storage-tui daemon started with -serve, such as on a jump
+// host.
+type Remote struct {
+	// Address is the daemon's host:port.
+	Address string `yaml:"address"`
+	// TokenSecret names the keychain secret holding the daemon's token;
+	// empty takes it from STORAGE_TUI_TOKEN.
+	TokenSecret string `yaml:"tokenSecret,omitempty"`
+	// CABundle is a PEM file to verify the daemon's certificate with; empty
+	// uses the system's roots.
+	CABundle string `yaml:"caBundle,omitempty"`
+	// Plaintext connects without TLS, to a loopback address only, such as
+	// an ssh tunnel to the daemon.
+	Plaintext bool `yaml:"plaintext,omitempty"`
+}
+
+// Patterns pick names by glob, such as prod-*, or by regular expression after
+// re:, ignoring case. Include empty keeps every name the excludes leave.
+type Patterns struct {
+	Include []string `yaml:"include,omitempty"`
+	Exclude []string `yaml:"exclude,omitempty"`
+}
+
+// Tree hides noise from the tree: subscriptions by ID or name, and storage
+// accounts by name.
+type Tree struct {
+	Subscriptions Patterns `yaml:"subscriptions,omitempty"`
+	Accounts      Patterns `yaml:"accounts,omitempty"`
+}
+
+// Cache keeps listings, blob properties, and previews on disk between runs.
+type Cache struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Dir is where the entries are kept; empty uses storage-tui in the user
+	// cache directory.
+	Dir string `yaml:"dir,omitempty"`
+	// MaxSize caps the cache on disk, e.g. 512 MB.
+	MaxSize string `yaml:"maxSize,omitempty"`
+}
+
+// MockFaults makes the mock provider misbehave, to try out the browser's
+// spinners, error paths, and paging.
+type MockFaults struct {
+	// Latency is added to every call, with up to Jitter more on top.
+	Latency time.Duration `yaml:"latency,omitempty"`
+	Jitter  time.Duration `yaml:"jitter,omitempty"`
+	// ErrorRate and ThrottleRate are the fractions of calls, from 0 to 1,
+	// that fail or are throttled.
+	ErrorRate    float64 `yaml:"errorRate,omitempty"`
+	ThrottleRate float64 `yaml:"throttleRate,omitempty"`
+	// RetryAfter is how long throttled calls ask to wait.
+	RetryAfter time.Duration `yaml:"retryAfter,omitempty"`
+	// HugeListing adds a container named huge of this many blobs.
+	HugeListing int `yaml:"hugeListing,omitempty"`
+	// Seed picks which calls fail, the same ones on every run.
+	Seed uint64 `yaml:"seed,omitempty"`
+}
+
+// SyncJob is a sync that the browser, or the syncd command, repeats on a
+// timer.
+type SyncJob struct {
+	Name string `yaml:"name"`
+	// Source and Destination are a directory and az://account/container/
+	// [prefix], in either order, as for the sync command.
+	Source      string `yaml:"source"`
+	Destination string `yaml:"destination"`
+	// Every is how long to wait between runs, e.g. 1h.
+	Every    time.Duration `yaml:"every"`
+	Delete   bool          `yaml:"delete,omitempty"`
+	Checksum bool          `yaml:"checksum,omitempty"`
+}
+
+// Config holds the defaults read from the config file. Command-line flags
+// override them.
+type Config struct {
+	// Provider selects where the data comes from. Only "mock" is available.
+	Provider string `yaml:"provider,omitempty"`
+	// MockFixture is a YAML or JSON file of subscriptions, accounts,
+	// containers, and blobs for the mock provider to serve instead of its
+	// built-in data.
+	MockFixture string `yaml:"mockFixture,omitempty"`
+	// MockScale has the mock provider serve this many generated accounts,
+	// with about a thousand blobs each, instead of its built-in data.
+	MockScale int `yaml:"mockScale,omitempty"`
+	// MockFaults makes the mock provider slow or unreliable on purpose.
+	MockFaults    *MockFaults   `yaml:"mockFaults,omitempty"`
+	Theme         string        `yaml:"theme,omitempty"`
+	Icons         string        `yaml:"icons,omitempty"`
+	Time          string        `yaml:"time,omitempty"`
+	Layout        string        `yaml:"layout,omitempty"`
+	Ratios        Ratios        `yaml:"ratios,omitempty"`
+	Mouse         *bool         `yaml:"mouse,omitempty"`
+	ExactSizes    bool          `yaml:"exactSizes,omitempty"`
+	WatchInterval time.Duration `yaml:"watchInterval,omitempty"`
+	WatchEvents   bool          `yaml:"watchEvents,omitempty"`
+	Restore       bool          `yaml:"restore,omitempty"`
+	// PreviewMemory caps the previewed content kept in memory, e.g. 32 MB.
+	PreviewMemory string `yaml:"previewMemory,omitempty"`
+	Cache         Cache  `yaml:"cache,omitempty"`
+	// LogLevel is the least severe level written to the log file: debug,
+	// info, warn, or error.
+	LogLevel string `yaml:"logLevel,omitempty"`
+	// UsageEndpoint is where usage metrics are reported once the user turns
+	// them on (U), instead of the endpoint the build sets, if any.
+	UsageEndpoint string `yaml:"usageEndpoint,omitempty"`
+	// Keys moves bindings to other keys, from the key shown in the help to
+	// the new one, such as "f: ctrl-f".
+	Keys map[string]string `yaml:"keys,omitempty"`
+	Tree Tree              `yaml:"tree,omitempty"`
+	// AzCopy is the azcopy binary that cp -azcopy and sync run; empty looks
+	// it up on PATH.
+	AzCopy string `yaml:"azcopy,omitempty"`
+	// SyncJobs are the syncs to repeat while the browser, or syncd, runs.
+	SyncJobs []SyncJob `yaml:"syncJobs,omitempty"`
+	// Profile names the profile to start with.
+	Profile  string             `yaml:"profile,omitempty"`
+	Profiles map[string]Profile `yaml:"profiles,omitempty"`
+}
+
+// DefaultPath returns the config file inside the user config directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "storage-tui", "config.yaml"), nil
+}
+
+// Load reads the config file at path. A missing file yields the defaults;
+// unknown settings are an error, so typos do not go unnoticed.
+func Load(path string) (Config, error) {
+	var config Config
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Save writes config to path as YAML, leaving out unset settings.
+func Save(path string, config Config) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
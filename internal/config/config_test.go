@@ -0,0 +1,157 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"storage-tui/internal/config"
+)
+
+func load(t *testing.T, yaml string) (config.Config, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return config.Load(path)
+}
+
+func TestLoad(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name string
+		yaml string
+		want config.Config
+	}{
+		{name: "empty file", yaml: "", want: config.Config{}},
+		{name: "mouse unset", yaml: "theme: light\n", want: config.Config{Theme: "light"}},
+		{name: "mouse off", yaml: "mouse: false\n", want: config.Config{Mouse: &no}},
+		{name: "mouse on", yaml: "mouse: true\n", want: config.Config{Mouse: &yes}},
+		{
+			name: "durations",
+			yaml: `
+watchInterval: 30s
+mockFaults:
+  latency: 250ms
+  jitter: 1m30s
+  retryAfter: 2s
+  throttleRate: 0.25
+syncJobs:
+  - name: logs
+    source: ./logs
+    destination: az://acme-dev/logs/2024
+    every: 1h
+    delete: true
+`,
+			want: config.Config{
+				WatchInterval: 30 * time.Second,
+				MockFaults:    &config.MockFaults{Latency: 250 * time.Millisecond, Jitter: 90 * time.Second, RetryAfter: 2 * time.Second, ThrottleRate: 0.25},
+				SyncJobs:      []config.SyncJob{{Name: "logs", Source: "./logs", Destination: "az://acme-dev/logs/2024", Every: time.Hour, Delete: true}},
+			},
+		},
+		{
+			name: "profiles and patterns",
+			yaml: `
+profile: prod
+profiles:
+  prod:
+    tenant: contoso.onmicrosoft.com
+    subscriptions: [Production]
+    remote:
+      address: jump:7443
+      plaintext: true
+tree:
+  accounts:
+    include: ["acme-*"]
+    exclude: ["re:-dev$"]
+keys:
+  f: ctrl-f
+cache:
+  enabled: true
+  maxSize: 512 MB
+`,
+			want: config.Config{
+				Profile: "prod",
+				Profiles: map[string]config.Profile{
+					"prod": {Tenant: "contoso.onmicrosoft.com", Subscriptions: []string{"Production"}, Remote: &config.Remote{Address: "jump:7443", Plaintext: true}},
+				},
+				Tree:  config.Tree{Accounts: config.Patterns{Include: []string{"acme-*"}, Exclude: []string{"re:-dev$"}}},
+				Keys:  map[string]string{"f": "ctrl-f"},
+				Cache: config.Cache{Enabled: true, MaxSize: "512 MB"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := load(t, test.yaml)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Load = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{name: "unknown setting", yaml: "colour: dark\n", want: "field colour not found"},
+		{name: "misspelled nested setting", yaml: "cache:\n  enable: true\n", want: "field enable not found"},
+		{name: "bad duration", yaml: "watchInterval: soon\n", want: "soon"},
+		{name: "duration without unit", yaml: "mockFaults:\n  latency: 250\n", want: "250"},
+		{name: "mouse not a boolean", yaml: "mouse: sometimes\n", want: "sometimes"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := load(t, test.yaml)
+			if err == nil || !strings.Contains(err.Error(), "parse ") || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("Load error = %v, want one naming %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	got, err := config.Load(filepath.Join(t.TempDir(), "none.yaml"))
+	if err != nil || !reflect.DeepEqual(got, config.Config{}) {
+		t.Errorf("Load = %+v, %v, want the defaults", got, err)
+	}
+}
+
+func TestSaveRoundTrips(t *testing.T) {
+	no := false
+	want := config.Config{
+		Theme:         "light",
+		Mouse:         &no,
+		WatchInterval: 45 * time.Second,
+		SyncJobs:      []config.SyncJob{{Name: "site", Source: "az://acme-dev/site", Destination: "./site", Every: 15 * time.Minute}},
+	}
+	path := filepath.Join(t.TempDir(), "nested", "config.yaml")
+	if err := config.Save(path, want); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Unset settings are left out, and durations are written as typed.
+	if text := string(data); strings.Contains(text, "icons") || !strings.Contains(text, "watchInterval: 45s") {
+		t.Errorf("saved:\n%s", text)
+	}
+	got, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load after Save = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,311 @@
+// Package dirsync brings a local directory and a container prefix in line,
+// in either direction, the way rsync does: it compares the two by size and
+// modified time, or by MD5, plans the uploads, downloads, and deletes that
+// make the destination match the source, and carries the plan out. What the
+// directory's .storageignore names is left alone on both sides.
+package dirsync
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"storage-tui/internal/azure"
+	"storage-tui/internal/ignore"
+)
+
+// Options says what to sync.
+type Options struct {
+	// Local is the directory, and Account, Container, and Prefix the
+	// blobs, it is synced with; the prefix is treated as a folder.
+	Local     string
+	Account   string
+	Container string
+	Prefix    string
+	// Upload makes the blobs match the directory; otherwise the directory
+	// is made to match the blobs.
+	Upload bool
+	// Delete also removes what the source does not have from the
+	// destination.
+	Delete bool
+	// Checksum compares files and blobs of the same size by MD5 instead of
+	// by modified time; blobs without a stored MD5 are still compared by
+	// time.
+	Checksum bool
+}
+
+// Kind is what an action does.
+type Kind string
+
+const (
+	KindUpload   Kind = "upload"
+	KindDownload Kind = "download"
+	KindDelete   Kind = "delete"
+)
+
+// Action is one step of a plan.
+type Action struct {
+	Kind Kind
+	// Name is the path relative to the directory and the prefix, with
+	// slashes.
+	Name string
+	// Size is what is copied; zero for a delete.
+	Size int64
+	// Modified is the source's modified time, which a download gives the
+	// file.
+	Modified time.Time
+	// Reason says why the action is needed, such as "new" or "newer".
+	Reason string
+	// ETag is that of the blob an upload writes over or a delete removes,
+	// as listed; empty otherwise.
+	ETag string
+}
+
+// Plan is what a sync does, copies first and deletes after, each in name
+// order.
+type Plan struct {
+	Options
+	Actions []Action
+	// UpToDate counts what the destination already has.
+	UpToDate int
+	// Ignored counts the files and blobs the ignore file leaves out; an
+	// ignored directory counts once.
+	Ignored int
+}
+
+// entry is a file or a blob as the comparison sees it.
+type entry struct {
+	size     int64
+	modified time.Time
+	// etag is a blob's; files have none.
+	etag string
+}
+
+// Build compares the directory with the blobs and plans the sync.
+func Build(ctx context.Context, provider azure.Provider, opts Options) (Plan, error) {
+	if opts.Prefix != "" && !strings.HasSuffix(opts.Prefix, "/") {
+		opts.Prefix += "/"
+	}
+	plan := Plan{Options: opts}
+	ignored, err := ignore.Load(opts.Local)
+	if err != nil {
+		return plan, err
+	}
+	files, err := plan.localFiles(ignored)
+	if err != nil {
+		return plan, err
+	}
+	blobs, err := plan.remoteBlobs(ctx, provider, ignored)
+	if err != nil {
+		return plan, err
+	}
+
+	source, destination, copyKind := files, blobs, KindUpload
+	if !opts.Upload {
+		source, destination, copyKind = blobs, files, KindDownload
+	}
+	var deletes []Action
+	for _, name := range sortedNames(source) {
+		from, to := source[name], destination[name]
+		reason := ""
+		if _, ok := destination[name]; !ok {
+			reason = "new"
+		} else if reason, err = plan.differs(ctx, provider, name, from, to); err != nil {
+			return plan, err
+		}
+		if reason == "" {
+			plan.UpToDate++
+			continue
+		}
+		plan.Actions = append(plan.Actions, Action{Kind: copyKind, Name: name, Size: from.size, Modified: from.modified, Reason: reason, ETag: to.etag})
+	}
+	if opts.Delete {
+		for _, name := range sortedNames(destination) {
+			if _, ok := source[name]; !ok {
+				deletes = append(deletes, Action{Kind: KindDelete, Name: name, Reason: "not in source", ETag: destination[name].etag})
+			}
+		}
+	}
+	plan.Actions = append(plan.Actions, deletes...)
+	return plan, nil
+}
+
+// differs says why the destination's copy of name needs replacing, or
+// returns empty when it does not.
+func (p Plan) differs(ctx context.Context, provider azure.Provider, name string, from, to entry) (string, error) {
+	if from.size != to.size {
+		return "size differs", nil
+	}
+	if p.Checksum {
+		props, err := provider.GetBlobProperties(ctx, p.Account, p.Container, p.Prefix+name)
+		if err != nil {
+			return "", err
+		}
+		if props.ContentMD5 != "" {
+			sum, err := fileMD5(filepath.Join(p.Local, filepath.FromSlash(name)))
+			if err != nil {
+				return "", err
+			}
+			if sum != props.ContentMD5 {
+				return "content differs", nil
+			}
+			return "", nil
+		}
+	}
+	// Blob times are kept to the second; a download gives the file the
+	// blob's, and an upload leaves the blob newer than the file.
+	if from.modified.Truncate(time.Second).After(to.modified.Truncate(time.Second)) {
+		return "newer", nil
+	}
+	return "", nil
+}
+
+// localFiles lists the regular files under the directory by their
+// slash-separated relative names, skipping the ignored ones and not
+// descending into ignored directories. A directory that does not exist yet
+// is empty, unless it is the source of an upload.
+func (p *Plan) localFiles(ignored *ignore.Matcher) (map[string]entry, error) {
+	dir := p.Local
+	files := make(map[string]entry)
+	if _, err := os.Stat(dir); !p.Upload && os.IsNotExist(err) {
+		return files, nil
+	}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if name == ignore.FileName {
+			return nil
+		}
+		if ignored.Match(name, d.IsDir()) {
+			p.Ignored++
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files[name] = entry{size: info.Size(), modified: info.ModTime()}
+		return nil
+	})
+	return files, err
+}
+
+// remoteBlobs lists the blobs under the prefix by their names past it,
+// leaving out folder markers and the names the ignore file does. A
+// download refuses names that would land outside the directory.
+func (p *Plan) remoteBlobs(ctx context.Context, provider azure.Provider, ignored *ignore.Matcher) (map[string]entry, error) {
+	opts := p.Options
+	blobs := make(map[string]entry)
+	marker := ""
+	for {
+		page, err := provider.ListBlobsPage(ctx, opts.Account, opts.Container, azure.ListBlobsOptions{Marker: marker, Prefix: opts.Prefix})
+		if err != nil {
+			return nil, err
+		}
+		for _, blob := range page.Blobs {
+			name := strings.TrimPrefix(blob.Name, opts.Prefix)
+			// The ignore file is the directory's own, and is not synced.
+			if name == "" || strings.HasSuffix(name, "/") || name == ignore.FileName {
+				continue
+			}
+			if ignored.Match(name, false) {
+				p.Ignored++
+				continue
+			}
+			if !opts.Upload && !filepath.IsLocal(filepath.FromSlash(name)) {
+				return nil, fmt.Errorf("blob %q would be written outside %s", blob.Name, opts.Local)
+			}
+			blobs[name] = entry{size: blob.SizeBytes, modified: blob.Modified, etag: blob.ETag}
+		}
+		if page.NextMarker == "" {
+			return blobs, nil
+		}
+		marker = page.NextMarker
+	}
+}
+
+func sortedNames(entries map[string]entry) []string {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fileMD5 returns the MD5 of the file base64-encoded, as blobs store it.
+func fileMD5(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := md5.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Counts sums the plan up by kind, with the bytes it copies.
+func (p Plan) Counts() (uploads, downloads, deletes int, bytes int64) {
+	for _, action := range p.Actions {
+		switch action.Kind {
+		case KindUpload:
+			uploads++
+		case KindDownload:
+			downloads++
+		case KindDelete:
+			deletes++
+		}
+		bytes += action.Size
+	}
+	return uploads, downloads, deletes, bytes
+}
+
+// Summary sums the plan up in a sentence, such as "3 to upload (1200
+// bytes), 1 to delete, 5 up to date, 2 ignored."
+func (p Plan) Summary() string {
+	uploads, downloads, deletes, bytes := p.Counts()
+	var parts []string
+	if uploads > 0 {
+		parts = append(parts, fmt.Sprintf("%d to upload", uploads))
+	}
+	if downloads > 0 {
+		parts = append(parts, fmt.Sprintf("%d to download", downloads))
+	}
+	if len(parts) > 0 {
+		parts[len(parts)-1] += fmt.Sprintf(" (%d bytes)", bytes)
+	}
+	if deletes > 0 {
+		parts = append(parts, fmt.Sprintf("%d to delete", deletes))
+	}
+	ignored := ""
+	if p.Ignored > 0 {
+		ignored = fmt.Sprintf(", %d ignored", p.Ignored)
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("Nothing to do, %d up to date%s.", p.UpToDate, ignored)
+	}
+	return fmt.Sprintf("%s, %d up to date%s.", strings.Join(parts, ", "), p.UpToDate, ignored)
+}
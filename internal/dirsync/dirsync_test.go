@@ -0,0 +1,480 @@
+package dirsync_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"storage-tui/internal/azure"
+	"storage-tui/internal/dirsync"
+)
+
+const (
+	account   = "acme-dev"
+	container = "images"
+	prefix    = "sync/"
+)
+
+// file is a local file of a test, modified age before the blobs were
+// uploaded, or after when negative.
+type file struct {
+	content string
+	age     time.Duration
+}
+
+const (
+	older = time.Hour
+	newer = -time.Hour
+)
+
+func TestSync(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  dirsync.Options
+		local map[string]file
+		blobs map[string]string
+		// want is the plan, as "kind name (reason)".
+		want []string
+		// wantLocal and wantBlobs are the contents after the plan is
+		// carried out.
+		wantLocal map[string]string
+		wantBlobs map[string]string
+	}{
+		{
+			name:      "upload new and newer files",
+			opts:      dirsync.Options{Upload: true},
+			local:     map[string]file{"a.txt": {"a", newer}, "dir/b.txt": {"b", 0}, "same.txt": {"same", older}},
+			blobs:     map[string]string{"a.txt": "A", "same.txt": "same", "extra.txt": "extra"},
+			want:      []string{"upload a.txt (newer)", "upload dir/b.txt (new)"},
+			wantLocal: map[string]string{"a.txt": "a", "dir/b.txt": "b", "same.txt": "same"},
+			wantBlobs: map[string]string{"a.txt": "a", "dir/b.txt": "b", "same.txt": "same", "extra.txt": "extra"},
+		},
+		{
+			name:      "upload with delete removes blobs the directory lacks",
+			opts:      dirsync.Options{Upload: true, Delete: true},
+			local:     map[string]file{"a.txt": {"a", older}},
+			blobs:     map[string]string{"a.txt": "a", "gone/b.txt": "b"},
+			want:      []string{"delete gone/b.txt (not in source)"},
+			wantLocal: map[string]string{"a.txt": "a"},
+			wantBlobs: map[string]string{"a.txt": "a"},
+		},
+		{
+			name:      "download new blobs and blobs newer than their files",
+			opts:      dirsync.Options{},
+			local:     map[string]file{"a.txt": {"A", older}, "b.txt": {"b", newer}, "extra.txt": {"extra", 0}},
+			blobs:     map[string]string{"a.txt": "a", "b.txt": "B", "dir/c.txt": "c"},
+			want:      []string{"download a.txt (newer)", "download dir/c.txt (new)"},
+			wantLocal: map[string]string{"a.txt": "a", "b.txt": "b", "dir/c.txt": "c", "extra.txt": "extra"},
+			wantBlobs: map[string]string{"a.txt": "a", "b.txt": "B", "dir/c.txt": "c"},
+		},
+		{
+			name:      "download with delete removes files the blobs lack",
+			opts:      dirsync.Options{Delete: true},
+			local:     map[string]file{"a.txt": {"a", newer}, "dir/gone.txt": {"gone", 0}},
+			blobs:     map[string]string{"a.txt": "a"},
+			want:      []string{"delete dir/gone.txt (not in source)"},
+			wantLocal: map[string]string{"a.txt": "a"},
+			wantBlobs: map[string]string{"a.txt": "a"},
+		},
+		{
+			name:      "size differs whatever the times",
+			opts:      dirsync.Options{Upload: true},
+			local:     map[string]file{"a.txt": {"longer", older}},
+			blobs:     map[string]string{"a.txt": "a"},
+			want:      []string{"upload a.txt (size differs)"},
+			wantLocal: map[string]string{"a.txt": "longer"},
+			wantBlobs: map[string]string{"a.txt": "longer"},
+		},
+		{
+			name:      "same size and older is up to date by time",
+			opts:      dirsync.Options{Upload: true},
+			local:     map[string]file{"a.txt": {"x", older}},
+			blobs:     map[string]string{"a.txt": "y"},
+			wantLocal: map[string]string{"a.txt": "x"},
+			wantBlobs: map[string]string{"a.txt": "y"},
+		},
+		{
+			name:      "checksum finds same size older content that differs",
+			opts:      dirsync.Options{Upload: true, Checksum: true},
+			local:     map[string]file{"a.txt": {"x", older}},
+			blobs:     map[string]string{"a.txt": "y"},
+			want:      []string{"upload a.txt (content differs)"},
+			wantLocal: map[string]string{"a.txt": "x"},
+			wantBlobs: map[string]string{"a.txt": "x"},
+		},
+		{
+			name:      "checksum skips newer files with the same content",
+			opts:      dirsync.Options{Upload: true, Checksum: true},
+			local:     map[string]file{"a.txt": {"x", newer}},
+			blobs:     map[string]string{"a.txt": "x"},
+			wantLocal: map[string]string{"a.txt": "x"},
+			wantBlobs: map[string]string{"a.txt": "x"},
+		},
+		{
+			name:      "newer file with the same content is copied by time",
+			opts:      dirsync.Options{Upload: true},
+			local:     map[string]file{"a.txt": {"x", newer}},
+			blobs:     map[string]string{"a.txt": "x"},
+			want:      []string{"upload a.txt (newer)"},
+			wantLocal: map[string]string{"a.txt": "x"},
+			wantBlobs: map[string]string{"a.txt": "x"},
+		},
+		{
+			name: "ignored paths are left alone on both sides",
+			opts: dirsync.Options{Upload: true, Delete: true},
+			local: map[string]file{
+				".storageignore": {"*.log\ncache/\n", 0},
+				"a.txt":          {"a", 0},
+				"app.log":        {"log", 0},
+				"cache/x.bin":    {"x", 0},
+			},
+			blobs:     map[string]string{"old.log": "old"},
+			want:      []string{"upload a.txt (new)"},
+			wantLocal: map[string]string{".storageignore": "*.log\ncache/\n", "a.txt": "a", "app.log": "log", "cache/x.bin": "x"},
+			wantBlobs: map[string]string{"a.txt": "a", "old.log": "old"},
+		},
+		{
+			name:      "download next to a file named like its temporary file",
+			opts:      dirsync.Options{},
+			local:     map[string]file{"x": {"stale", older}, "x.tmp": {"keep", newer}},
+			blobs:     map[string]string{"x": "new", "x.tmp": "keep"},
+			want:      []string{"download x (size differs)"},
+			wantLocal: map[string]string{"x": "new", "x.tmp": "keep"},
+			wantBlobs: map[string]string{"x": "new", "x.tmp": "keep"},
+		},
+		{
+			name:      "download a blob named like the temporary file of another",
+			opts:      dirsync.Options{},
+			blobs:     map[string]string{"x": "new", "x.tmp": "other"},
+			want:      []string{"download x (new)", "download x.tmp (new)"},
+			wantLocal: map[string]string{"x": "new", "x.tmp": "other"},
+			wantBlobs: map[string]string{"x": "new", "x.tmp": "other"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			provider := azure.NewMockProvider()
+			uploaded := upload(t, provider, test.blobs)
+			opts := test.opts
+			opts.Local, opts.Account, opts.Container, opts.Prefix = writeLocal(t, test.local, uploaded), account, container, prefix
+
+			plan, err := dirsync.Build(ctx, provider, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := describe(plan); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("plan = %q, want %q", got, test.want)
+			}
+			if err := plan.Execute(ctx, provider, func(dirsync.Progress) {}); err != nil {
+				t.Fatal(err)
+			}
+			if got := readLocal(t, opts.Local); !reflect.DeepEqual(got, test.wantLocal) {
+				t.Errorf("local after = %q, want %q", got, test.wantLocal)
+			}
+			if got := readBlobs(t, provider); !reflect.DeepEqual(got, test.wantBlobs) {
+				t.Errorf("blobs after = %q, want %q", got, test.wantBlobs)
+			}
+		})
+	}
+}
+
+// TestDownloadKeepsModifiedTime checks that the next sync sees a downloaded
+// file up to date.
+func TestDownloadKeepsModifiedTime(t *testing.T) {
+	ctx := context.Background()
+	provider := azure.NewMockProvider()
+	upload(t, provider, map[string]string{"a.txt": "a"})
+	dir := t.TempDir()
+	plan, err := dirsync.Build(ctx, provider, dirsync.Options{Local: dir, Account: account, Container: container, Prefix: prefix})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Execute(ctx, provider, func(dirsync.Progress) {}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := plan.Actions[0].Modified; !info.ModTime().Equal(want) {
+		t.Errorf("file modified %v, want the blob's %v", info.ModTime(), want)
+	}
+	again, err := dirsync.Build(ctx, provider, plan.Options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Actions) > 0 {
+		t.Errorf("plan after sync = %q, want nothing to do", describe(again))
+	}
+}
+
+// failingReads fails every blob read partway through.
+type failingReads struct {
+	azure.Provider
+}
+
+var errRead = errors.New("connection reset")
+
+func (p failingReads) OpenBlob(ctx context.Context, account, container, blob string) (io.ReadCloser, error) {
+	return io.NopCloser(io.MultiReader(strings.NewReader("partial"), errReader{})), nil
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errRead }
+
+func TestFailedDownloadLeavesTheOldFile(t *testing.T) {
+	ctx := context.Background()
+	provider := azure.NewMockProvider()
+	uploaded := upload(t, provider, map[string]string{"a.txt": "new content"})
+	dir := writeLocal(t, map[string]file{"a.txt": {"old", older}}, uploaded)
+	plan, err := dirsync.Build(ctx, provider, dirsync.Options{Local: dir, Account: account, Container: container, Prefix: prefix})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reported []error
+	err = plan.Execute(ctx, failingReads{provider}, func(progress dirsync.Progress) {
+		reported = append(reported, progress.Err)
+	})
+	if !errors.Is(err, errRead) || len(reported) != 1 || !errors.Is(reported[0], errRead) {
+		t.Fatalf("Execute = %v, reported %v, want the read error", err, reported)
+	}
+	if got, want := readLocal(t, dir), map[string]string{"a.txt": "old"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("local after = %q, want %q", got, want)
+	}
+}
+
+func TestTrack(t *testing.T) {
+	ctx := context.Background()
+	provider := azure.NewMockProvider()
+	uploaded := upload(t, provider, map[string]string{"a.txt": "A", "same.txt": "same", "extra.txt": "extra"})
+	dir := writeLocal(t, map[string]file{"a.txt": {"a", newer}, "b.txt": {"b", 0}, "same.txt": {"same", older}}, uploaded)
+	plan, err := dirsync.Build(ctx, provider, dirsync.Options{Local: dir, Account: account, Container: container, Prefix: prefix, Upload: true, Delete: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each blob is named with the ETag it had before the sync.
+	version := func(name string) azure.BlobVersion {
+		props, err := provider.GetBlobProperties(ctx, account, container, prefix+name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return azure.BlobVersion{Name: prefix + name, ETag: props.ETag}
+	}
+	want := dirsync.Changed{Replaced: []azure.BlobVersion{version("a.txt")}, Deleted: []azure.BlobVersion{version("extra.txt")}}
+	var changed dirsync.Changed
+	reported := 0
+	if err := plan.Execute(ctx, provider, plan.Track(&changed, func(dirsync.Progress) { reported++ })); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(changed, want) || reported != 3 {
+		t.Errorf("changed %+v with %d reported, want %+v with 3", changed, reported, want)
+	}
+
+	// A sync down changes no blob.
+	down, err := dirsync.Build(ctx, provider, dirsync.Options{Local: t.TempDir(), Account: account, Container: container, Prefix: prefix, Delete: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed = dirsync.Changed{}
+	if err := down.Execute(ctx, provider, down.Track(&changed, func(dirsync.Progress) {})); err != nil {
+		t.Fatal(err)
+	}
+	if len(changed.Replaced)+len(changed.Deleted) != 0 {
+		t.Errorf("a sync down changed %+v", changed)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	plan := dirsync.Plan{
+		Actions: []dirsync.Action{
+			{Kind: dirsync.KindUpload, Name: "a", Size: 1000},
+			{Kind: dirsync.KindUpload, Name: "b", Size: 200},
+			{Kind: dirsync.KindDelete, Name: "c"},
+		},
+		UpToDate: 5,
+		Ignored:  2,
+	}
+	if got, want := plan.Summary(), "2 to upload (1200 bytes), 1 to delete, 5 up to date, 2 ignored."; got != want {
+		t.Errorf("Summary = %q, want %q", got, want)
+	}
+	if got, want := (dirsync.Plan{UpToDate: 3}).Summary(), "Nothing to do, 3 up to date."; got != want {
+		t.Errorf("Summary = %q, want %q", got, want)
+	}
+}
+
+func TestExport(t *testing.T) {
+	modified := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	plan := dirsync.Plan{
+		Options: dirsync.Options{
+			Local:     "./site",
+			Account:   account,
+			Container: container,
+			Prefix:    prefix,
+			Upload:    true,
+			Delete:    true,
+		},
+		Actions: []dirsync.Action{
+			{Kind: dirsync.KindUpload, Name: "docs/index.html", Size: 120, Modified: modified, Reason: "new"},
+			{Kind: dirsync.KindDelete, Name: "old, \"quoted\".html", Reason: "not in source"},
+		},
+		UpToDate: 4,
+	}
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{format: "csv", want: []string{
+			"kind,name,size,modified,reason\n",
+			"upload,docs/index.html,120,2024-06-01T12:00:00Z,new\n",
+			`delete,"old, ""quoted"".html",0,,not in source` + "\n",
+		}},
+		{format: "json", want: []string{
+			`"direction": "upload"`,
+			`"delete": true`,
+			`"summary": "1 to upload (120 bytes), 1 to delete, 4 up to date."`,
+			`"name": "docs/index.html"`,
+		}},
+		{format: "ndjson", want: []string{
+			`{"kind":"upload","name":"docs/index.html","size":120,"modified":"2024-06-01T12:00:00Z","reason":"new"}` + "\n",
+			`{"kind":"delete","name":"old, \"quoted\".html","size":0,"reason":"not in source"}` + "\n",
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			var out strings.Builder
+			if err := plan.Export(&out, test.format); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("Export lacks %q:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+	if err := plan.Export(io.Discard, "xml"); err == nil {
+		t.Error("Export to an unknown format succeeded")
+	}
+}
+
+func TestSave(t *testing.T) {
+	dir := t.TempDir()
+	plan := dirsync.Plan{Actions: []dirsync.Action{{Kind: dirsync.KindDownload, Name: "a.txt", Size: 3, Reason: "new"}}}
+	path := filepath.Join(dir, "plan.ndjson")
+	if err := plan.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), `{"kind":"download","name":"a.txt","size":3,"reason":"new"}`+"\n"; got != want {
+		t.Errorf("saved %q, want %q", got, want)
+	}
+	if err := plan.Save(filepath.Join(dir, "plan.txt")); err == nil || !strings.Contains(err.Error(), ".csv, .json, .ndjson") {
+		t.Errorf("Save to a .txt file: %v", err)
+	}
+	if paths, _ := filepath.Glob(filepath.Join(dir, "*")); len(paths) != 1 {
+		t.Errorf("files after saving: %q", paths)
+	}
+}
+
+// upload puts blobs under the prefix and returns when they were uploaded.
+func upload(t *testing.T, provider *azure.MockProvider, blobs map[string]string) time.Time {
+	t.Helper()
+	var uploaded time.Time
+	for name, content := range blobs {
+		blob, err := provider.UploadBlob(context.Background(), account, container, prefix+name, strings.NewReader(content), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		uploaded = blob.Modified
+	}
+	if uploaded.IsZero() {
+		uploaded = time.Now()
+	}
+	return uploaded
+}
+
+// writeLocal writes files to a new directory, modified their age before
+// uploaded.
+func writeLocal(t *testing.T, files map[string]file, uploaded time.Time) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(f.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		modified := uploaded.Add(-f.age)
+		if err := os.Chtimes(path, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// readLocal returns every file under dir, by slash-separated name.
+func readLocal(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		files[filepath.ToSlash(rel)] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// readBlobs returns every blob under the prefix, by name past it.
+func readBlobs(t *testing.T, provider *azure.MockProvider) map[string]string {
+	t.Helper()
+	ctx := context.Background()
+	page, err := provider.ListBlobsPage(ctx, account, container, azure.ListBlobsOptions{Prefix: prefix})
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobs := make(map[string]string)
+	for _, blob := range page.Blobs {
+		reader, err := provider.OpenBlob(ctx, account, container, blob.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		blobs[strings.TrimPrefix(blob.Name, prefix)] = string(data)
+	}
+	return blobs
+}
+
+func describe(plan dirsync.Plan) []string {
+	var actions []string
+	for _, action := range plan.Actions {
+		actions = append(actions, string(action.Kind)+" "+action.Name+" ("+action.Reason+")")
+	}
+	return actions
+}
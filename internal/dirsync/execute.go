@@ -0,0 +1,146 @@
+package dirsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+
+	"storage-tui/internal/azure"
+)
+
+// Progress is reported after each action of a plan is carried out.
+type Progress struct {
+	Action Action
+	// Err is why the action failed; the plan goes on with the next one.
+	Err error
+	// Done of Total actions, and DoneBytes of TotalBytes, are behind.
+	Done, Total           int
+	DoneBytes, TotalBytes int64
+}
+
+// Execute carries the plan out, reporting each action to report, and
+// returns the errors of those that failed. Deleting files leaves their
+// directories in place.
+func (p Plan) Execute(ctx context.Context, provider azure.Provider, report func(Progress)) error {
+	uploads, _, deletes, totalBytes := p.Counts()
+	uploader, canUpload := provider.(azure.Uploader)
+	deleter, canDelete := provider.(azure.Deleter)
+	switch {
+	case uploads > 0 && !canUpload:
+		return errors.New("this provider cannot upload blobs")
+	case p.Upload && deletes > 0 && !canDelete:
+		return errors.New("this provider cannot delete blobs")
+	}
+
+	progress := Progress{Total: len(p.Actions), TotalBytes: totalBytes}
+	var failed []error
+	for _, action := range p.Actions {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(failed, err)...)
+		}
+		var err error
+		switch {
+		case action.Kind == KindUpload:
+			err = p.upload(ctx, uploader, action)
+		case action.Kind == KindDownload:
+			err = p.download(ctx, provider, action)
+		case p.Upload:
+			err = deleter.DeleteBlob(ctx, p.Account, p.Container, p.Prefix+action.Name)
+		default:
+			err = os.Remove(p.localPath(action.Name))
+		}
+		if err != nil {
+			failed = append(failed, fmt.Errorf("%s %s: %w", action.Kind, action.Name, err))
+		}
+		progress.Action, progress.Err = action, err
+		progress.Done++
+		progress.DoneBytes += action.Size
+		report(progress)
+	}
+	return errors.Join(failed...)
+}
+
+// Changed names the blobs a sync up wrote over or deleted, by their names in
+// the container and the ETags they had, which versioning and soft delete can
+// bring back.
+type Changed struct {
+	Replaced []azure.BlobVersion
+	Deleted  []azure.BlobVersion
+}
+
+// Track wraps report to add each blob the plan writes over or deletes to
+// changed once it has; a download changes no blob.
+func (p Plan) Track(changed *Changed, report func(Progress)) func(Progress) {
+	return func(progress Progress) {
+		action := progress.Action
+		version := azure.BlobVersion{Name: p.Prefix + action.Name, ETag: action.ETag}
+		switch {
+		case !p.Upload || progress.Err != nil:
+		case action.Kind == KindDelete:
+			changed.Deleted = append(changed.Deleted, version)
+		case action.Reason != "new":
+			changed.Replaced = append(changed.Replaced, version)
+		}
+		report(progress)
+	}
+}
+
+func (p Plan) localPath(name string) string {
+	return filepath.Join(p.Local, filepath.FromSlash(name))
+}
+
+func (p Plan) upload(ctx context.Context, uploader azure.Uploader, action Action) error {
+	file, err := os.Open(p.localPath(action.Name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = uploader.UploadBlob(ctx, p.Account, p.Container, p.Prefix+action.Name, file, mime.TypeByExtension(filepath.Ext(action.Name)))
+	return err
+}
+
+// download writes the blob to a temporary file next to its file and renames
+// it, so a failed download leaves the old file, and gives it the blob's
+// modified time, so the next sync sees it up to date. The temporary file's
+// name is unique, so it never clobbers a file synced alongside, such as one
+// named like the file with .tmp appended.
+func (p Plan) download(ctx context.Context, provider azure.Provider, action Action) error {
+	path := p.localPath(action.Name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	reader, err := provider.OpenBlob(ctx, p.Account, p.Container, p.Prefix+action.Name)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := file.Name()
+	if err := file.Chmod(0o644); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if _, err := io.Copy(file, reader); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chtimes(tmp, action.Modified, action.Modified); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
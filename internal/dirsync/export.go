@@ -0,0 +1,116 @@
+package dirsync
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"storage-tui/internal/export"
+)
+
+// planned is an action as a plan is exported.
+type planned struct {
+	Kind     Kind      `json:"kind"`
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified,omitzero"`
+	Reason   string    `json:"reason"`
+}
+
+// exported is the whole plan as JSON.
+type exported struct {
+	Local     string    `json:"local"`
+	Account   string    `json:"account"`
+	Container string    `json:"container"`
+	Prefix    string    `json:"prefix,omitempty"`
+	Direction string    `json:"direction"`
+	Delete    bool      `json:"delete,omitempty"`
+	Checksum  bool      `json:"checksum,omitempty"`
+	Summary   string    `json:"summary"`
+	UpToDate  int       `json:"upToDate"`
+	Ignored   int       `json:"ignored"`
+	Actions   []planned `json:"actions"`
+}
+
+// Export writes the plan's actions to w in one of export.Formats, so they
+// can be reviewed before the plan is carried out: a CSV row or a JSON line
+// each, or, as json, one object with what is synced, the summary, and the
+// actions.
+func (p Plan) Export(w io.Writer, format string) error {
+	actions := make([]planned, len(p.Actions))
+	for i, action := range p.Actions {
+		actions[i] = planned{Kind: action.Kind, Name: action.Name, Size: action.Size, Modified: action.Modified, Reason: action.Reason}
+	}
+	switch format {
+	case "csv":
+		out := csv.NewWriter(w)
+		out.Write([]string{"kind", "name", "size", "modified", "reason"})
+		for _, action := range actions {
+			modified := ""
+			if !action.Modified.IsZero() {
+				modified = action.Modified.UTC().Format(time.RFC3339)
+			}
+			out.Write([]string{string(action.Kind), action.Name, strconv.FormatInt(action.Size, 10), modified, action.Reason})
+		}
+		out.Flush()
+		return out.Error()
+	case "json":
+		direction := string(KindDownload)
+		if p.Upload {
+			direction = string(KindUpload)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(exported{
+			Local:     p.Local,
+			Account:   p.Account,
+			Container: p.Container,
+			Prefix:    p.Prefix,
+			Direction: direction,
+			Delete:    p.Delete,
+			Checksum:  p.Checksum,
+			Summary:   p.Summary(),
+			UpToDate:  p.UpToDate,
+			Ignored:   p.Ignored,
+			Actions:   actions,
+		})
+	case "ndjson":
+		encoder := json.NewEncoder(w)
+		for _, action := range actions {
+			if err := encoder.Encode(action); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown plan format %q (available: %s)", format, strings.Join(export.Formats, ", "))
+}
+
+// Save writes the plan to path in the format its extension names, replacing
+// the file atomically.
+func (p Plan) Save(path string) error {
+	format := export.FormatForPath(path)
+	if format == "" {
+		return fmt.Errorf("%s: a plan file ends in .%s", path, strings.Join(export.Formats, ", ."))
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if err := p.Export(file, format); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
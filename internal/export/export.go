@@ -0,0 +1,99 @@
+// Package export writes blob listings for reports, as CSV, a JSON array, or
+// newline-delimited JSON.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats lists the formats Write knows, the default first.
+var Formats = []string{"csv", "json", "ndjson"}
+
+// Blob is one exported row.
+type Blob struct {
+	Account     string            `json:"account"`
+	Container   string            `json:"container"`
+	Name        string            `json:"name"`
+	SizeBytes   int64             `json:"size"`
+	Modified    time.Time         `json:"modified"`
+	ContentType string            `json:"contentType"`
+	Tier        string            `json:"tier,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+}
+
+// FormatForPath returns the format a file extension names, or "" for none.
+func FormatForPath(path string) string {
+	extension := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	for _, format := range Formats {
+		if format == extension {
+			return format
+		}
+	}
+	return ""
+}
+
+// Write writes blobs to w in format.
+func Write(w io.Writer, format string, blobs []Blob) error {
+	switch format {
+	case "csv":
+		return writeCSV(w, blobs)
+	case "json":
+		if blobs == nil {
+			blobs = []Blob{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(blobs)
+	case "ndjson":
+		encoder := json.NewEncoder(w)
+		for _, blob := range blobs {
+			if err := encoder.Encode(blob); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown export format %q (available: %s)", format, strings.Join(Formats, ", "))
+}
+
+func writeCSV(w io.Writer, blobs []Blob) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"account", "container", "name", "size", "modified", "content_type", "tier", "tags"})
+	for _, blob := range blobs {
+		out.Write([]string{
+			blob.Account,
+			blob.Container,
+			blob.Name,
+			strconv.FormatInt(blob.SizeBytes, 10),
+			blob.Modified.UTC().Format(time.RFC3339),
+			blob.ContentType,
+			blob.Tier,
+			joinTags(blob.Tags),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// joinTags writes tags as key=value pairs separated by semicolons, sorted by
+// key.
+func joinTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + tags[key]
+	}
+	return strings.Join(pairs, ";")
+}
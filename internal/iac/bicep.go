@@ -0,0 +1,228 @@
+package iac
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"storage-tui/internal/azure"
+)
+
+// bicepAPIVersion is the Microsoft.Storage API version the resources are
+// declared with.
+const bicepAPIVersion = "2023-05-01"
+
+// bicepSince names the time lifecycle days count from in the management
+// policy schema.
+var bicepSince = map[string]string{
+	"modification":     "daysAfterModificationGreaterThan",
+	"creation":         "daysAfterCreationGreaterThan",
+	"last access":      "daysAfterLastAccessTimeGreaterThan",
+	"last tier change": "daysAfterLastTierChangeGreaterThan",
+}
+
+// bicepAccess is the publicAccess value of each container access level.
+var bicepAccess = map[string]string{
+	"private":   "None",
+	"blob":      "Blob",
+	"container": "Container",
+}
+
+// writeBicep describes the account for a deployment to its resource group.
+func writeBicep(w *writer, account Account) {
+	name := bicepName(account.Name)
+	service := name + "BlobService"
+
+	w.line("// %s, as storage-tui read it; review before deploying.", account.Name)
+	w.gap()
+	w.open("resource %s 'Microsoft.Storage/storageAccounts@%s' = {", name, bicepAPIVersion)
+	w.attributes(": ", false,
+		attribute{"name", bicepString(account.Name)},
+		attribute{"location", bicepString(account.Region)},
+		attribute{"kind", bicepString(account.Kind)},
+	)
+	w.open("sku: {")
+	w.line("name: %s", bicepString(account.SKU))
+	w.close("}")
+	w.open("properties: {")
+	if account.HierarchicalNamespace {
+		w.line("isHnsEnabled: true")
+	}
+	if account.MinimumTLSVersion != "" {
+		w.line("minimumTlsVersion: %s", bicepString(account.MinimumTLSVersion))
+	}
+	w.close("}")
+	w.close("}")
+
+	w.gap()
+	w.open("resource %s 'Microsoft.Storage/storageAccounts/blobServices@%s' = {", service, bicepAPIVersion)
+	w.line("parent: %s", name)
+	w.line("name: 'default'")
+	writeBicepServiceProperties(w, account.Service)
+	w.close("}")
+
+	if website := account.Service.StaticWebsite; website.Enabled {
+		w.gap()
+		w.line("// The static website is served from $web with %s and %s; Bicep cannot", orNone(website.IndexDocument), orNone(website.ErrorDocument404Path))
+		w.line("// turn it on, so that is left to a deployment script or the portal.")
+	}
+
+	for _, container := range describedContainers(account) {
+		w.gap()
+		w.open("resource %s 'Microsoft.Storage/storageAccounts/blobServices/containers@%s' = {", bicepName(account.Name, container.Name), bicepAPIVersion)
+		w.line("parent: %s", service)
+		w.line("name: %s", bicepString(container.Name))
+		w.open("properties: {")
+		w.line("publicAccess: %s", bicepString(bicepAccess[container.PublicAccess]))
+		w.close("}")
+		w.close("}")
+	}
+
+	if len(account.Lifecycle) > 0 {
+		w.gap()
+		w.open("resource %sLifecycle 'Microsoft.Storage/storageAccounts/managementPolicies@%s' = {", name, bicepAPIVersion)
+		w.line("parent: %s", name)
+		w.line("name: 'default'")
+		w.open("properties: {")
+		w.open("policy: {")
+		w.open("rules: [")
+		for _, rule := range account.Lifecycle {
+			writeBicepRule(w, rule)
+		}
+		w.close("]")
+		w.close("}")
+		w.close("}")
+		w.close("}")
+	}
+}
+
+func writeBicepServiceProperties(w *writer, service azure.BlobServiceProperties) {
+	w.open("properties: {")
+	w.line("isVersioningEnabled: %t", service.Versioning)
+	w.open("changeFeed: {")
+	w.line("enabled: %t", service.ChangeFeed.Enabled)
+	if service.ChangeFeed.Enabled && service.ChangeFeed.Days > 0 {
+		w.line("retentionInDays: %d", service.ChangeFeed.Days)
+	}
+	w.close("}")
+	for _, retention := range []struct {
+		name   string
+		policy azure.RetentionPolicy
+	}{
+		{"deleteRetentionPolicy", service.BlobSoftDelete},
+		{"containerDeleteRetentionPolicy", service.ContainerSoftDelete},
+	} {
+		w.open("%s: {", retention.name)
+		w.line("enabled: %t", retention.policy.Enabled)
+		if retention.policy.Enabled {
+			w.line("days: %d", retention.policy.Days)
+		}
+		w.close("}")
+	}
+	if len(service.CORS) > 0 {
+		w.open("cors: {")
+		w.open("corsRules: [")
+		for _, rule := range service.CORS {
+			w.open("{")
+			w.attributes(": ", false,
+				attribute{"allowedOrigins", bicepList(rule.AllowedOrigins)},
+				attribute{"allowedMethods", bicepList(rule.AllowedMethods)},
+				attribute{"allowedHeaders", bicepList(rule.AllowedHeaders)},
+				attribute{"exposedHeaders", bicepList(rule.ExposedHeaders)},
+				attribute{"maxAgeInSeconds", strconv.Itoa(rule.MaxAgeSeconds)},
+			)
+			w.close("}")
+		}
+		w.close("]")
+		w.close("}")
+	}
+	w.close("}")
+}
+
+func writeBicepRule(w *writer, rule azure.LifecycleRule) {
+	w.open("{")
+	w.attributes(": ", false,
+		attribute{"name", bicepString(rule.Name)},
+		attribute{"enabled", strconv.FormatBool(rule.Enabled)},
+		attribute{"type", "'Lifecycle'"},
+	)
+	w.open("definition: {")
+	w.open("filters: {")
+	w.line("blobTypes: %s", bicepList(rule.BlobTypes))
+	if len(rule.PrefixMatch) > 0 {
+		w.line("prefixMatch: %s", bicepList(rule.PrefixMatch))
+	}
+	if len(rule.TagMatch) > 0 {
+		w.open("blobIndexMatch: [")
+		for _, condition := range rule.TagMatch {
+			w.line("{ name: %s, op: %s, value: %s }", bicepString(condition.Key), bicepString(armOperator(condition.Operator)), bicepString(condition.Value))
+		}
+		w.close("]")
+	}
+	w.close("}")
+	w.open("actions: {")
+	for _, target := range []struct{ name, key string }{
+		{"base blob", "baseBlob"},
+		{"snapshot", "snapshot"},
+		{"version", "version"},
+	} {
+		var attributes []attribute
+		for _, action := range rule.Actions {
+			if action.Target == target.name {
+				attributes = append(attributes, attribute{action.Action, fmt.Sprintf("{ %s: %d }", bicepSince[action.Since], action.Days)})
+			}
+		}
+		if len(attributes) == 0 {
+			continue
+		}
+		w.open("%s: {", target.key)
+		w.attributes(": ", false, attributes...)
+		w.close("}")
+	}
+	w.close("}")
+	w.close("}")
+	w.close("}")
+}
+
+// bicepName makes a symbolic name of names: their letters and digits in
+// camel case, starting with a letter.
+func bicepName(names ...string) string {
+	var name strings.Builder
+	for i, word := range words(names...) {
+		if i == 0 {
+			name.WriteString(strings.ToLower(word))
+			continue
+		}
+		first, size := utf8.DecodeRuneInString(word)
+		name.WriteRune(unicode.ToUpper(first))
+		name.WriteString(word[size:])
+	}
+	if name.Len() == 0 || unicode.IsDigit(rune(name.String()[0])) {
+		return "storage" + name.String()
+	}
+	return name.String()
+}
+
+// bicepString quotes s as a Bicep string, escaping the sequence that would
+// start an interpolation.
+func bicepString(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "${", `\${`).Replace(s)
+	return "'" + s + "'"
+}
+
+func bicepList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = bicepString(value)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
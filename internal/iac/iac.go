@@ -0,0 +1,170 @@
+// Package iac describes a storage account as infrastructure as code, as a
+// Terraform (azurerm) configuration or a Bicep file, so an account made by
+// hand can start being managed that way.
+package iac
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"storage-tui/internal/azure"
+)
+
+// Formats lists the formats Write knows, the default first.
+var Formats = []string{"terraform", "bicep"}
+
+// Extension returns the file extension of format, such as .tf.
+func Extension(format string) string {
+	if format == "bicep" {
+		return ".bicep"
+	}
+	return ".tf"
+}
+
+// Account is what is described of an account: its settings, containers,
+// blob service, and lifecycle rules.
+type Account struct {
+	azure.Account
+	Containers []azure.Container
+	Service    azure.BlobServiceProperties
+	Lifecycle  []azure.LifecycleRule
+}
+
+// Collect reads what describes account from provider; with container set,
+// only that one of its containers is described.
+func Collect(ctx context.Context, provider azure.Provider, account azure.Account, container string) (Account, error) {
+	described := Account{Account: account}
+	containers, err := provider.ListContainers(ctx, account.Name)
+	if err != nil {
+		return described, err
+	}
+	for _, candidate := range containers {
+		if container == "" || candidate.Name == container {
+			described.Containers = append(described.Containers, candidate)
+		}
+	}
+	if container != "" && len(described.Containers) == 0 {
+		return described, fmt.Errorf("container %s/%s not found", account.Name, container)
+	}
+	if described.Service, err = provider.GetBlobServiceProperties(ctx, account.Name); err != nil {
+		return described, err
+	}
+	policy, err := provider.GetLifecyclePolicy(ctx, account.Name)
+	if err != nil {
+		return described, err
+	}
+	described.Lifecycle = policy.Rules
+	return described, nil
+}
+
+// Write writes account to w in format.
+func Write(w io.Writer, format string, account Account) error {
+	out := &writer{w: w}
+	switch format {
+	case "terraform":
+		writeTerraform(out, account)
+	case "bicep":
+		writeBicep(out, account)
+	default:
+		return fmt.Errorf("unknown format %q (available: %s)", format, strings.Join(Formats, ", "))
+	}
+	return out.err
+}
+
+// sku splits an account SKU, such as Standard_RAGRS, into its tier and
+// replication.
+func sku(account azure.Account) (tier, replication string) {
+	tier, replication, _ = strings.Cut(account.SKU, "_")
+	return tier, replication
+}
+
+// describedContainers leaves out $web while the static website, which
+// makes it, is described too.
+func describedContainers(account Account) []azure.Container {
+	var containers []azure.Container
+	for _, container := range account.Containers {
+		if container.Name != "$web" || !account.Service.StaticWebsite.Enabled {
+			containers = append(containers, container)
+		}
+	}
+	return containers
+}
+
+// words splits a name into the runs of letters and digits the resource
+// names are made of.
+func words(names ...string) []string {
+	var all []string
+	for _, name := range names {
+		all = append(all, strings.FieldsFunc(name, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})...)
+	}
+	return all
+}
+
+// writer writes indented lines, keeping the first error.
+type writer struct {
+	w     io.Writer
+	depth int
+	// opened is set right after a block is opened.
+	opened bool
+	err    error
+}
+
+func (w *writer) line(format string, args ...any) {
+	if w.err != nil {
+		return
+	}
+	text := fmt.Sprintf(format, args...)
+	if text != "" {
+		text = strings.Repeat("  ", w.depth) + text
+	}
+	_, w.err = fmt.Fprintln(w.w, text)
+	w.opened = false
+}
+
+// gap writes the blank line that sets a block apart from what came before
+// it in its parent.
+func (w *writer) gap() {
+	if !w.opened {
+		w.line("")
+	}
+}
+
+// open writes a line that opens a block, and indents the lines after it.
+func (w *writer) open(format string, args ...any) {
+	w.line(format, args...)
+	w.depth++
+	w.opened = true
+}
+
+// close writes the line that closes the block opened last.
+func (w *writer) close(text string) {
+	w.depth--
+	w.line("%s", text)
+}
+
+// attribute is a name and a value already written in the format's syntax.
+type attribute struct {
+	name  string
+	value string
+}
+
+// attributes writes the attributes one per line, name and value apart by
+// separator; with align, the separators line up as terraform fmt has them.
+func (w *writer) attributes(separator string, align bool, attributes ...attribute) {
+	width := 0
+	for _, attribute := range attributes {
+		width = max(width, len(attribute.name))
+	}
+	for _, attribute := range attributes {
+		name := attribute.name
+		if align {
+			name += strings.Repeat(" ", width-len(name))
+		}
+		w.line("%s%s%s", name, separator, attribute.value)
+	}
+}
@@ -0,0 +1,241 @@
+package iac
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"storage-tui/internal/azure"
+)
+
+// terraformSince names the time lifecycle days count from in azurerm's
+// attributes.
+var terraformSince = map[string]string{
+	"modification":     "modification",
+	"creation":         "creation",
+	"last access":      "last_access_time",
+	"last tier change": "last_tier_change",
+}
+
+// writeTerraform describes the account for the azurerm provider, 4.0 or
+// later, in the resource group var.resource_group_name names.
+func writeTerraform(w *writer, account Account) {
+	name := terraformName(account.Name)
+	ref := "azurerm_storage_account." + name + ".id"
+	tier, replication := sku(account.Account)
+
+	w.line("# %s, as storage-tui read it; review before applying.", account.Name)
+	w.gap()
+	w.open(`variable "resource_group_name" {`)
+	w.line("type = string")
+	w.close("}")
+
+	w.gap()
+	w.open(`resource "azurerm_storage_account" %s {`, strconv.Quote(name))
+	attributes := []attribute{
+		{"name", terraformString(account.Name)},
+		{"resource_group_name", "var.resource_group_name"},
+		{"location", terraformString(account.Region)},
+		{"account_kind", terraformString(account.Kind)},
+		{"account_tier", terraformString(tier)},
+		{"account_replication_type", terraformString(replication)},
+	}
+	if account.HierarchicalNamespace {
+		attributes = append(attributes, attribute{"is_hns_enabled", "true"})
+	}
+	if account.MinimumTLSVersion != "" {
+		attributes = append(attributes, attribute{"min_tls_version", terraformString(account.MinimumTLSVersion)})
+	}
+	w.attributes(" = ", true, attributes...)
+	writeTerraformBlobProperties(w, account.Service)
+	w.close("}")
+
+	if website := account.Service.StaticWebsite; website.Enabled {
+		w.gap()
+		w.open(`resource "azurerm_storage_account_static_website" %s {`, strconv.Quote(name))
+		attributes := []attribute{{"storage_account_id", ref}}
+		if website.IndexDocument != "" {
+			attributes = append(attributes, attribute{"index_document", terraformString(website.IndexDocument)})
+		}
+		if website.ErrorDocument404Path != "" {
+			attributes = append(attributes, attribute{"error_404_document", terraformString(website.ErrorDocument404Path)})
+		}
+		w.attributes(" = ", true, attributes...)
+		w.close("}")
+	}
+
+	for _, container := range describedContainers(account) {
+		w.gap()
+		w.open(`resource "azurerm_storage_container" %s {`, strconv.Quote(terraformName(account.Name, container.Name)))
+		w.attributes(" = ", true,
+			attribute{"name", terraformString(container.Name)},
+			attribute{"storage_account_id", ref},
+			attribute{"container_access_type", terraformString(container.PublicAccess)},
+		)
+		w.close("}")
+	}
+
+	if len(account.Lifecycle) > 0 {
+		w.gap()
+		w.open(`resource "azurerm_storage_management_policy" %s {`, strconv.Quote(name))
+		w.line("storage_account_id = %s", ref)
+		for _, rule := range account.Lifecycle {
+			writeTerraformRule(w, rule)
+		}
+		w.close("}")
+	}
+}
+
+func writeTerraformBlobProperties(w *writer, service azure.BlobServiceProperties) {
+	var attributes []attribute
+	if service.Versioning {
+		attributes = append(attributes, attribute{"versioning_enabled", "true"})
+	}
+	if service.ChangeFeed.Enabled {
+		attributes = append(attributes, attribute{"change_feed_enabled", "true"})
+		if service.ChangeFeed.Days > 0 {
+			attributes = append(attributes, attribute{"change_feed_retention_in_days", strconv.Itoa(service.ChangeFeed.Days)})
+		}
+	}
+	if len(attributes) == 0 && !service.BlobSoftDelete.Enabled && !service.ContainerSoftDelete.Enabled && len(service.CORS) == 0 {
+		return
+	}
+	w.gap()
+	w.open("blob_properties {")
+	w.attributes(" = ", true, attributes...)
+	for _, retention := range []struct {
+		block  string
+		policy azure.RetentionPolicy
+	}{
+		{"delete_retention_policy", service.BlobSoftDelete},
+		{"container_delete_retention_policy", service.ContainerSoftDelete},
+	} {
+		if retention.policy.Enabled {
+			w.gap()
+			w.open("%s {", retention.block)
+			w.line("days = %d", retention.policy.Days)
+			w.close("}")
+		}
+	}
+	for _, rule := range service.CORS {
+		w.gap()
+		w.open("cors_rule {")
+		w.attributes(" = ", true,
+			attribute{"allowed_headers", terraformList(rule.AllowedHeaders)},
+			attribute{"allowed_methods", terraformList(rule.AllowedMethods)},
+			attribute{"allowed_origins", terraformList(rule.AllowedOrigins)},
+			attribute{"exposed_headers", terraformList(rule.ExposedHeaders)},
+			attribute{"max_age_in_seconds", strconv.Itoa(rule.MaxAgeSeconds)},
+		)
+		w.close("}")
+	}
+	w.close("}")
+}
+
+func writeTerraformRule(w *writer, rule azure.LifecycleRule) {
+	w.gap()
+	w.open("rule {")
+	w.attributes(" = ", true,
+		attribute{"name", terraformString(rule.Name)},
+		attribute{"enabled", strconv.FormatBool(rule.Enabled)},
+	)
+	w.gap()
+	w.open("filters {")
+	filters := []attribute{{"blob_types", terraformList(rule.BlobTypes)}}
+	if len(rule.PrefixMatch) > 0 {
+		filters = append(filters, attribute{"prefix_match", terraformList(rule.PrefixMatch)})
+	}
+	w.attributes(" = ", true, filters...)
+	for _, condition := range rule.TagMatch {
+		w.gap()
+		w.open("match_blob_index_tag {")
+		w.attributes(" = ", true,
+			attribute{"name", terraformString(condition.Key)},
+			attribute{"operation", terraformString(armOperator(condition.Operator))},
+			attribute{"value", terraformString(condition.Value)},
+		)
+		w.close("}")
+	}
+	w.close("}")
+
+	w.gap()
+	w.open("actions {")
+	for _, target := range []struct{ name, block string }{
+		{"base blob", "base_blob"},
+		{"snapshot", "snapshot"},
+		{"version", "version"},
+	} {
+		var attributes []attribute
+		for _, action := range rule.Actions {
+			if action.Target == target.name {
+				attributes = append(attributes, attribute{terraformAction(action), strconv.Itoa(action.Days)})
+			}
+		}
+		if len(attributes) == 0 {
+			continue
+		}
+		w.gap()
+		w.open("%s {", target.block)
+		w.attributes(" = ", true, attributes...)
+		w.close("}")
+	}
+	w.close("}")
+	w.close("}")
+}
+
+// terraformAction names the azurerm attribute of a lifecycle action, whose
+// names differ between base blobs and their snapshots and versions.
+func terraformAction(action azure.LifecycleAction) string {
+	verb := strings.ToLower(strings.TrimPrefix(action.Action, "tierTo"))
+	since := terraformSince[action.Since]
+	if action.Target == "base blob" || since == "last_tier_change" {
+		if verb == "delete" {
+			return fmt.Sprintf("delete_after_days_since_%s_greater_than", since)
+		}
+		return fmt.Sprintf("tier_to_%s_after_days_since_%s_greater_than", verb, since)
+	}
+	switch {
+	case verb == "delete" && action.Target == "version":
+		return "delete_after_days_since_creation"
+	case verb == "delete":
+		return "delete_after_days_since_creation_greater_than"
+	case verb == "cold":
+		return "tier_to_cold_after_days_since_creation_greater_than"
+	}
+	return fmt.Sprintf("change_tier_to_%s_after_days_since_creation", verb)
+}
+
+// armOperator is the operator of a tag condition as lifecycle rules write
+// it, == for equality.
+func armOperator(operator string) string {
+	if operator == "=" {
+		return "=="
+	}
+	return operator
+}
+
+// terraformName makes a resource name of names: their letters and digits
+// joined by underscores, starting with a letter.
+func terraformName(names ...string) string {
+	name := strings.ToLower(strings.Join(words(names...), "_"))
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "storage_" + name
+	}
+	return name
+}
+
+// terraformString quotes s as a Terraform string, escaping the sequences
+// that would start a template.
+func terraformString(s string) string {
+	quoted := strconv.Quote(s)
+	quoted = strings.ReplaceAll(quoted, "${", "$${")
+	return strings.ReplaceAll(quoted, "%{", "%%{")
+}
+
+func terraformList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = terraformString(value)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
@@ -0,0 +1,232 @@
+// Package ignore reads .storageignore files, which name in gitignore syntax
+// the files that uploads and syncs leave alone, such as build output, .git
+// directories, and secrets.
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FileName is the ignore file looked for at the top of a directory.
+const FileName = ".storageignore"
+
+// rule is one pattern of an ignore file.
+type rule struct {
+	pattern string
+	re      *regexp.Regexp
+	// negate re-includes what earlier rules ignored, as !pattern does.
+	negate bool
+	// dirOnly matches directories only, as a trailing slash does.
+	dirOnly bool
+}
+
+// Matcher says which names an ignore file leaves out. The zero Matcher, and
+// a nil one, ignore nothing.
+type Matcher struct {
+	rules []rule
+}
+
+// Load reads the ignore file at the top of dir. A directory without one
+// ignores nothing.
+func Load(dir string) (*Matcher, error) {
+	path := filepath.Join(dir, FileName)
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Matcher{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	m, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Parse reads patterns in gitignore syntax, one a line: # starts a comment,
+// ! re-includes, a trailing / matches directories only, a slash anywhere
+// else anchors the pattern to the top, and *, ?, [...], and ** match as
+// they do for git.
+func Parse(r io.Reader) (*Matcher, error) {
+	m := &Matcher{}
+	scanner := bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		line := trimTrailingSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var rule rule
+		rule.pattern = line
+		if strings.HasPrefix(line, "!") {
+			rule.negate, line = true, line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly, line = true, strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		re, err := regexp.Compile(compile(line))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %q: %w", number, rule.pattern, err)
+		}
+		rule.re = re
+		m.rules = append(m.rules, rule)
+	}
+	return m, scanner.Err()
+}
+
+// trimTrailingSpace drops trailing spaces unless a backslash escapes them.
+func trimTrailingSpace(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+// compile turns a pattern, without its ! and trailing slash, into a
+// regular expression matching whole slash-separated names.
+func compile(pattern string) string {
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		switch {
+		case segment == "**" && last:
+			b.WriteString(".*")
+		case segment == "**":
+			b.WriteString("(?:.*/)?")
+		default:
+			b.WriteString(globSegment(segment))
+			if !last {
+				b.WriteString("/")
+			}
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// globSegment turns the glob of one path segment into a regular
+// expression.
+func globSegment(glob string) string {
+	var b strings.Builder
+	runes := []rune(glob)
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; c {
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(runes[i])))
+			}
+		case '*':
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := i + 1
+			if end < len(runes) && (runes[end] == '!' || runes[end] == '^') {
+				end++
+			}
+			if end < len(runes) && runes[end] == ']' {
+				end++
+			}
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			if end >= len(runes) {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := runes[i+1 : end]
+			b.WriteString("[")
+			if len(class) > 0 && (class[0] == '!' || class[0] == '^') {
+				b.WriteString("^")
+				class = class[1:]
+			}
+			for _, r := range class {
+				if r == '\\' || r == '[' || r == ']' {
+					b.WriteString(`\`)
+				}
+				b.WriteRune(r)
+			}
+			b.WriteString("]")
+			i = end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// Empty reports whether the matcher ignores nothing.
+func (m *Matcher) Empty() bool {
+	return m == nil || len(m.rules) == 0
+}
+
+// Match reports whether name, slash-separated and relative to the
+// directory of the ignore file, is ignored. As for git, a name inside an
+// ignored directory is ignored whatever later rules say.
+func (m *Matcher) Match(name string, isDir bool) bool {
+	if m.Empty() {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if name[i] == '/' && m.match(name[:i], true) {
+			return true
+		}
+	}
+	return m.match(name, isDir)
+}
+
+// match applies the rules to name alone; the last one that matches wins.
+func (m *Matcher) match(name string, isDir bool) bool {
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(name) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// Regexps returns the rules as regular expressions matching the relative
+// paths of the files they ignore, for tools such as azcopy that take
+// those. Rules that re-include cannot be expressed that way and are an
+// error.
+func (m *Matcher) Regexps() ([]string, error) {
+	if m.Empty() {
+		return nil, nil
+	}
+	var exprs []string
+	for _, rule := range m.rules {
+		if rule.negate {
+			return nil, fmt.Errorf("%s: %q re-includes files, which only the built-in sync can follow", FileName, rule.pattern)
+		}
+		expr := strings.TrimSuffix(rule.re.String(), "$")
+		if rule.dirOnly {
+			exprs = append(exprs, expr+"/")
+		} else {
+			exprs = append(exprs, expr+"(?:/|$)")
+		}
+	}
+	return exprs, nil
+}
@@ -0,0 +1,140 @@
+package ignore_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"storage-tui/internal/ignore"
+)
+
+func parse(t *testing.T, lines ...string) *ignore.Matcher {
+	t.Helper()
+	m, err := ignore.Parse(strings.NewReader(strings.Join(lines, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		isDir    bool
+		want     bool
+	}{
+		{name: "unanchored matches at the top", patterns: []string{"*.log"}, path: "app.log", want: true},
+		{name: "unanchored matches at any depth", patterns: []string{"*.log"}, path: "logs/2024/app.log", want: true},
+		{name: "star stays within a segment", patterns: []string{"logs/*.log"}, path: "logs/2024/app.log", want: false},
+		{name: "question mark is one character", patterns: []string{"app?.log"}, path: "app1.log", want: true},
+		{name: "question mark is not a slash", patterns: []string{"a?b"}, path: "a/b", want: false},
+		{name: "leading slash anchors", patterns: []string{"/build"}, path: "src/build", want: false},
+		{name: "leading slash matches at the top", patterns: []string{"/build"}, path: "build", want: true},
+		{name: "inner slash anchors", patterns: []string{"docs/draft"}, path: "site/docs/draft", want: false},
+		{name: "inner slash matches at the top", patterns: []string{"docs/draft"}, path: "docs/draft", want: true},
+		{name: "ignored directory ignores what is inside", patterns: []string{"node_modules"}, path: "web/node_modules/lib/index.js", want: true},
+		{name: "directory-only skips files", patterns: []string{"cache/"}, path: "cache", want: false},
+		{name: "directory-only matches directories", patterns: []string{"cache/"}, path: "cache", isDir: true, want: true},
+		{name: "directory-only covers files inside", patterns: []string{"cache/"}, path: "a/cache/data.bin", want: true},
+		{name: "leading double star matches at any depth", patterns: []string{"**/secrets"}, path: "a/b/secrets", want: true},
+		{name: "leading double star matches at the top", patterns: []string{"**/secrets"}, path: "secrets", want: true},
+		{name: "trailing double star matches everything inside", patterns: []string{"tmp/**"}, path: "tmp/a/b.txt", want: true},
+		{name: "trailing double star is not the directory itself", patterns: []string{"tmp/**"}, path: "tmp", isDir: true, want: false},
+		{name: "inner double star matches no directories", patterns: []string{"a/**/b"}, path: "a/b", want: true},
+		{name: "inner double star matches several directories", patterns: []string{"a/**/b"}, path: "a/x/y/b", want: true},
+		{name: "class", patterns: []string{"file[0-9].txt"}, path: "file7.txt", want: true},
+		{name: "negated class", patterns: []string{"file[!0-9].txt"}, path: "file7.txt", want: false},
+		{name: "unclosed bracket is literal", patterns: []string{"file[1"}, path: "file[1", want: true},
+		{name: "escaped star is literal", patterns: []string{`\*.txt`}, path: "*.txt", want: true},
+		{name: "escaped star matches nothing else", patterns: []string{`\*.txt`}, path: "a.txt", want: false},
+		{name: "escaped hash is a pattern", patterns: []string{`\#notes`}, path: "#notes", want: true},
+		{name: "comment is no pattern", patterns: []string{"#notes"}, path: "#notes", want: false},
+		{name: "escaped trailing space is kept", patterns: []string{`name\ `}, path: "name ", want: true},
+		{name: "trailing spaces are dropped", patterns: []string{"name   "}, path: "name", want: true},
+		{name: "regexp characters are literal", patterns: []string{"a+b(c).txt"}, path: "a+b(c).txt", want: true},
+		{name: "negation re-includes", patterns: []string{"*.log", "!keep.log"}, path: "keep.log", want: false},
+		{name: "negation leaves the rest ignored", patterns: []string{"*.log", "!keep.log"}, path: "drop.log", want: true},
+		{name: "last matching rule wins", patterns: []string{"!keep.log", "*.log"}, path: "keep.log", want: true},
+		{name: "negation cannot re-include inside an ignored directory", patterns: []string{"build/", "!build/keep.txt"}, path: "build/keep.txt", want: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := parse(t, test.patterns...)
+			if got := m.Match(test.path, test.isDir); got != test.want {
+				t.Errorf("patterns %q: Match(%q, %v) = %v, want %v", test.patterns, test.path, test.isDir, got, test.want)
+			}
+		})
+	}
+}
+
+func TestEmptyMatcher(t *testing.T) {
+	var nilMatcher *ignore.Matcher
+	for _, m := range []*ignore.Matcher{nilMatcher, {}, parse(t, "# only a comment", "")} {
+		if !m.Empty() || m.Match("anything", false) {
+			t.Errorf("%#v ignores something", m)
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	_, err := ignore.Parse(strings.NewReader("ok\n[z-a]\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Parse error = %v, want one naming line 2", err)
+	}
+}
+
+// TestRegexpsAgreeWithMatch checks that what azcopy is told to exclude is
+// what the built-in sync ignores, for the files of a tree.
+func TestRegexpsAgreeWithMatch(t *testing.T) {
+	files := []string{
+		"app.log", "logs/2024/app.log", "logs/readme.md", "build", "build/out.bin", "src/build/x.go",
+		"docs/draft", "docs/draft/a.md", "site/docs/draft", "cache", "cache/data.bin", "a/cache/data.bin",
+		"secrets", "a/b/secrets", "tmp/a/b.txt", "tmp", "a/b", "a/x/y/b", "file7.txt", "filex.txt",
+		"*.txt", "a.txt", "name ", "a+b(c).txt", "node_modules/lib/index.js",
+	}
+	sets := [][]string{
+		{"*.log"},
+		{"/build"},
+		{"docs/draft"},
+		{"cache/"},
+		{"**/secrets", "tmp/**"},
+		{"a/**/b"},
+		{"file[0-9].txt"},
+		{`\*.txt`, `name\ `, "a+b(c).txt"},
+		{"node_modules", "*.md"},
+	}
+	for _, patterns := range sets {
+		m := parse(t, patterns...)
+		exprs, err := m.Regexps()
+		if err != nil {
+			t.Fatalf("patterns %q: %v", patterns, err)
+		}
+		var res []*regexp.Regexp
+		for _, expr := range exprs {
+			res = append(res, regexp.MustCompile(expr))
+		}
+		for _, file := range files {
+			excluded := false
+			for _, re := range res {
+				if re.MatchString(file) {
+					excluded = true
+				}
+			}
+			if want := m.Match(file, false); excluded != want {
+				t.Errorf("patterns %q, file %q: Regexps exclude it = %v, Match = %v (%q)", patterns, file, excluded, want, exprs)
+			}
+		}
+	}
+}
+
+func TestRegexpsRefuseNegation(t *testing.T) {
+	_, err := parse(t, "*.log", "!keep.log").Regexps()
+	if err == nil || !strings.Contains(err.Error(), `"!keep.log"`) {
+		t.Errorf("Regexps error = %v, want one naming !keep.log", err)
+	}
+	if exprs, err := (&ignore.Matcher{}).Regexps(); exprs != nil || err != nil {
+		t.Errorf("empty matcher Regexps = %q, %v", exprs, err)
+	}
+}
@@ -0,0 +1,176 @@
+// Package keychain keeps secrets, such as signed URLs and connection strings,
+// in the operating system's credential store rather than in plain-text
+// files. It drives the store's own command-line tool: security on macOS and
+// secret-tool (libsecret) on Linux. The Windows Credential Manager is not
+// supported.
+package keychain
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// service groups the secrets of this program in the store.
+const service = "storage-tui"
+
+// ErrNotFound is returned for a secret the store does not hold.
+var ErrNotFound = errors.New("no such secret in the keychain")
+
+// ErrUnavailable is returned when no credential store can be reached.
+var ErrUnavailable = errors.New("no keychain available: needs security on macOS or secret-tool on Linux")
+
+// ErrUnsupported is returned on Windows, whose Credential Manager has no
+// command-line tool to drive. It wraps ErrUnavailable.
+var ErrUnsupported = fmt.Errorf("%w: the keychain is not supported on windows", ErrUnavailable)
+
+// CheckName reports whether name can name a secret: letters, digits, dots,
+// dashes, and underscores, which every store's tool takes as they are.
+func CheckName(name string) error {
+	if name == "" || strings.Trim(name, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789._-") != "" {
+		return fmt.Errorf("secret name %q is not letters, digits, dots, dashes, and underscores", name)
+	}
+	return nil
+}
+
+// store is one credential store's tool.
+type store interface {
+	set(ctx context.Context, name, secret string) error
+	get(ctx context.Context, name string) (string, error)
+	remove(ctx context.Context, name string) error
+}
+
+// Set keeps secret under name, replacing what name held.
+func Set(ctx context.Context, name, secret string) error {
+	s, err := open(name)
+	if err != nil {
+		return err
+	}
+	return s.set(ctx, name, secret)
+}
+
+// Get returns the secret kept under name.
+func Get(ctx context.Context, name string) (string, error) {
+	s, err := open(name)
+	if err != nil {
+		return "", err
+	}
+	return s.get(ctx, name)
+}
+
+// Remove deletes the secret kept under name.
+func Remove(ctx context.Context, name string) error {
+	s, err := open(name)
+	if err != nil {
+		return err
+	}
+	return s.remove(ctx, name)
+}
+
+func open(name string) (store, error) {
+	if err := CheckName(name); err != nil {
+		return nil, err
+	}
+	tool := "secret-tool"
+	switch runtime.GOOS {
+	case "darwin":
+		tool = "security"
+	case "windows":
+		return nil, ErrUnsupported
+	}
+	path, err := exec.LookPath(tool)
+	if err != nil {
+		return nil, ErrUnavailable
+	}
+	if tool == "security" {
+		return macKeychain{path}, nil
+	}
+	return secretService{path}, nil
+}
+
+// run runs the tool with stdin and returns what it printed, or its error
+// output as the error.
+func run(ctx context.Context, path string, stdin string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("keychain: %s: %w", message, err)
+		}
+		return "", fmt.Errorf("keychain: %w", err)
+	}
+	return stdout.String(), nil
+}
+
+// macKeychain keeps secrets as generic passwords in the login keychain.
+type macKeychain struct{ path string }
+
+// set passes the secret on security's standard input, hex-encoded, so it
+// never shows in the process list.
+func (k macKeychain) set(ctx context.Context, name, secret string) error {
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n", service, name, hex.EncodeToString([]byte(secret)))
+	_, err := run(ctx, k.path, command, "-i")
+	return err
+}
+
+func (k macKeychain) get(ctx context.Context, name string) (string, error) {
+	out, err := run(ctx, k.path, "", "find-generic-password", "-s", service, "-a", name, "-w")
+	if err != nil {
+		if exitCode(err) == 44 {
+			return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+		}
+		return "", err
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+func (k macKeychain) remove(ctx context.Context, name string) error {
+	_, err := run(ctx, k.path, "", "delete-generic-password", "-s", service, "-a", name)
+	if exitCode(err) == 44 {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return err
+}
+
+// secretService keeps secrets in the Secret Service, such as GNOME Keyring
+// or KWallet, by the attributes service and name.
+type secretService struct{ path string }
+
+func (s secretService) set(ctx context.Context, name, secret string) error {
+	_, err := run(ctx, s.path, secret, "store", "--label="+service+": "+name, "service", service, "name", name)
+	return err
+}
+
+// get reads the secret; secret-tool prints nothing, and may fail, for one it
+// does not hold.
+func (s secretService) get(ctx context.Context, name string) (string, error) {
+	out, err := run(ctx, s.path, "", "lookup", "service", service, "name", name)
+	if out == "" && (err == nil || exitCode(err) == 1) {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return strings.TrimSuffix(out, "\n"), err
+}
+
+func (s secretService) remove(ctx context.Context, name string) error {
+	if _, err := s.get(ctx, name); err != nil {
+		return err
+	}
+	_, err := run(ctx, s.path, "", "clear", "service", service, "name", name)
+	return err
+}
+
+// exitCode returns the exit status of the tool err reports, or -1.
+func exitCode(err error) int {
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		return exit.ExitCode()
+	}
+	return -1
+}
@@ -0,0 +1,263 @@
+// Package logging writes leveled, structured log records to a file that
+// rotates by size, and keeps the latest records for the in-app log viewer.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Levels lists the level names ParseLevel knows, most verbose first.
+var Levels = []string{"debug", "info", "warn", "error"}
+
+// keepRecords is how many records the viewer can show.
+const keepRecords = 500
+
+// ParseLevel reads a level name; empty selects info.
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q (available: %s)", name, strings.Join(Levels, ", "))
+}
+
+// DefaultPath returns the log file inside the user cache directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "storage-tui", "storage-tui.log"), nil
+}
+
+// Record is one logged message as the viewer shows it.
+type Record struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	// Attrs are the record's attributes as key=value pairs.
+	Attrs string
+}
+
+// Log is a logger that also keeps its latest records.
+type Log struct {
+	*slog.Logger
+	recent *recent
+}
+
+// New logs the records at level or above to w as logfmt lines. A nil w only
+// keeps the records for the viewer.
+func New(w io.Writer, level slog.Level) *Log {
+	if w == nil {
+		w = io.Discard
+	}
+	recent := &recent{}
+	handler := &handler{
+		next:   slog.NewTextHandler(w, &slog.HandlerOptions{Level: level}),
+		level:  level,
+		recent: recent,
+	}
+	return &Log{Logger: slog.New(handler), recent: recent}
+}
+
+// Recent returns the latest records, oldest first.
+func (l *Log) Recent() []Record {
+	l.recent.mu.Lock()
+	defer l.recent.mu.Unlock()
+	return append([]Record(nil), l.recent.records...)
+}
+
+// Notify calls changed, on the logging goroutine, after each kept record. Nil
+// stops the calls.
+func (l *Log) Notify(changed func()) {
+	l.recent.mu.Lock()
+	l.recent.changed = changed
+	l.recent.mu.Unlock()
+}
+
+type recent struct {
+	mu      sync.Mutex
+	records []Record
+	changed func()
+}
+
+func (r *recent) add(record Record) {
+	r.mu.Lock()
+	if len(r.records) == keepRecords {
+		r.records = append(r.records[:0], r.records[1:]...)
+	}
+	r.records = append(r.records, record)
+	changed := r.changed
+	r.mu.Unlock()
+	if changed != nil {
+		changed()
+	}
+}
+
+// handler passes records on to the file and keeps them for the viewer.
+type handler struct {
+	next   slog.Handler
+	level  slog.Level
+	recent *recent
+	// attrs and group are what WithAttrs and WithGroup added, for the
+	// kept records.
+	attrs []string
+	group string
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *handler) Handle(ctx context.Context, record slog.Record) error {
+	attrs := append([]string(nil), h.attrs...)
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = appendAttr(attrs, h.group, attr)
+		return true
+	})
+	h.recent.add(Record{
+		Time:    record.Time,
+		Level:   record.Level,
+		Message: record.Message,
+		Attrs:   strings.Join(attrs, " "),
+	})
+	return h.next.Handle(ctx, record)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.next = h.next.WithAttrs(attrs)
+	next.attrs = append([]string(nil), h.attrs...)
+	for _, attr := range attrs {
+		next.attrs = appendAttr(next.attrs, h.group, attr)
+	}
+	return &next
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	next := *h
+	next.next = h.next.WithGroup(name)
+	next.group = h.group + name + "."
+	return &next
+}
+
+func appendAttr(pairs []string, group string, attr slog.Attr) []string {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		for _, member := range value.Group() {
+			pairs = appendAttr(pairs, group+attr.Key+".", member)
+		}
+		return pairs
+	}
+	text := value.String()
+	if strings.ContainsAny(text, " \"=") || text == "" {
+		text = fmt.Sprintf("%q", text)
+	}
+	return append(pairs, group+attr.Key+"="+text)
+}
+
+// RotatingFile is a log file that is moved aside once it grows past MaxSize,
+// keeping the Keep latest old files as path.1, path.2, and so on.
+type RotatingFile struct {
+	path    string
+	maxSize int64
+	keep    int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// Default rotation limits for OpenFile.
+const (
+	MaxSize = 5 << 20
+	Keep    = 3
+)
+
+// OpenFile opens or creates the log file at path, creating its directory.
+func OpenFile(path string) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f := &RotatingFile{path: path, maxSize: MaxSize, keep: Keep}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// Write appends p, rotating first when p would take the file past its limit.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+	for i := f.keep - 1; i > 0; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+	}
+	if f.keep > 0 {
+		if err := os.Rename(f.path, f.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(f.path); err != nil {
+		return err
+	}
+	return f.open()
+}
+
+// Close closes the file.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
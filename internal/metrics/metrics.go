@@ -0,0 +1,176 @@
+// Package metrics counts what the browser spends its time on: provider calls
+// and their latencies, cache hits, and screen draws. The counts are served on
+// the -pprof address to help diagnose slowness.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// keepSamples is how many recent durations each series keeps for its
+// percentiles.
+const keepSamples = 512
+
+// Registry collects the metrics. A nil Registry ignores everything, so
+// callers need not check whether metrics are on.
+type Registry struct {
+	mu      sync.Mutex
+	started time.Time
+	calls   map[string]*series
+	caches  map[string]*ratio
+	draws   series
+}
+
+type series struct {
+	count   int64
+	errors  int64
+	total   time.Duration
+	max     time.Duration
+	samples []time.Duration
+	next    int
+}
+
+type ratio struct {
+	hits   int64
+	misses int64
+}
+
+// New returns a Registry with nothing recorded yet.
+func New() *Registry {
+	return &Registry{started: time.Now(), calls: make(map[string]*series), caches: make(map[string]*ratio)}
+}
+
+// ObserveCall records a provider call that took d, failed when err is set.
+func (r *Registry) ObserveCall(name string, d time.Duration, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.calls[name]
+	if s == nil {
+		s = &series{}
+		r.calls[name] = s
+	}
+	s.observe(d)
+	if err != nil {
+		s.errors++
+	}
+}
+
+// ObserveCache records a lookup in the named cache.
+func (r *Registry) ObserveCache(name string, hit bool) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := r.caches[name]
+	if c == nil {
+		c = &ratio{}
+		r.caches[name] = c
+	}
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+}
+
+// ObserveDraw records a screen draw that took d.
+func (r *Registry) ObserveDraw(d time.Duration) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.draws.observe(d)
+	r.mu.Unlock()
+}
+
+func (s *series) observe(d time.Duration) {
+	s.count++
+	s.total += d
+	s.max = max(s.max, d)
+	if len(s.samples) < keepSamples {
+		s.samples = append(s.samples, d)
+		return
+	}
+	s.samples[s.next] = d
+	s.next = (s.next + 1) % keepSamples
+}
+
+// percentile returns the p-th percentile of the recent samples.
+func (s *series) percentile(p float64) time.Duration {
+	if len(s.samples) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), s.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[max(int(math.Ceil(p*float64(len(sorted))))-1, 0)]
+}
+
+func (s *series) line(name string) string {
+	mean := time.Duration(0)
+	if s.count > 0 {
+		mean = s.total / time.Duration(s.count)
+	}
+	return fmt.Sprintf("%-28s %8d %7d %10s %10s %10s %10s\n", name, s.count, s.errors,
+		round(mean), round(s.percentile(0.5)), round(s.percentile(0.95)), round(s.max))
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(10 * time.Microsecond)
+}
+
+// WriteText writes the metrics as a plain-text report. Percentiles cover the
+// latest samples of each series only.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fmt.Fprintf(w, "storage-tui metrics, up %s\n\n", time.Since(r.started).Round(time.Second))
+	header := fmt.Sprintf("%-28s %8s %7s %10s %10s %10s %10s\n", "", "count", "errors", "mean", "p50", "p95", "max")
+	fmt.Fprint(w, "Provider calls\n"+header)
+	names := make([]string, 0, len(r.calls))
+	for name := range r.calls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprint(w, r.calls[name].line(name))
+	}
+
+	fmt.Fprint(w, "\nDraws\n"+header)
+	fmt.Fprint(w, r.draws.line("draw"))
+
+	fmt.Fprintf(w, "\nCaches\n%-28s %8s %8s %8s\n", "", "hits", "misses", "hit rate")
+	names = names[:0]
+	for name := range r.caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c := r.caches[name]
+		rate := 0.0
+		if total := c.hits + c.misses; total > 0 {
+			rate = float64(c.hits) / float64(total) * 100
+		}
+		fmt.Fprintf(w, "%-28s %8d %8d %7.1f%%\n", name, c.hits, c.misses, rate)
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// Handler serves the report.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		r.WriteText(w)
+	})
+}
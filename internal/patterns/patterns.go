@@ -0,0 +1,91 @@
+// Package patterns decides which subscriptions and accounts are shown from
+// the include and exclude patterns of the config file.
+package patterns
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Set holds compiled include and exclude patterns. The zero Set shows
+// everything.
+type Set struct {
+	include []func(string) bool
+	exclude []func(string) bool
+}
+
+// Compile compiles the patterns: a glob such as prod-* matched against the
+// whole name, or re: and a regular expression matched anywhere in it, both
+// ignoring case.
+func Compile(include, exclude []string) (Set, error) {
+	var set Set
+	var err error
+	if set.include, err = compileAll(include); err != nil {
+		return Set{}, fmt.Errorf("include: %w", err)
+	}
+	if set.exclude, err = compileAll(exclude); err != nil {
+		return Set{}, fmt.Errorf("exclude: %w", err)
+	}
+	return set, nil
+}
+
+func compileAll(patterns []string) ([]func(string) bool, error) {
+	var matchers []func(string) bool
+	for _, pattern := range patterns {
+		match, err := compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, match)
+	}
+	return matchers, nil
+}
+
+func compile(pattern string) (func(string) bool, error) {
+	pattern = strings.TrimSpace(pattern)
+	if expression, ok := strings.CutPrefix(pattern, "re:"); ok {
+		if expression == "" {
+			return nil, errors.New("empty regular expression after re:")
+		}
+		re, err := regexp.Compile("(?i)" + expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q", expression)
+		}
+		return re.MatchString, nil
+	}
+	if pattern == "" {
+		return nil, errors.New("empty pattern")
+	}
+	pattern = strings.ToLower(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q", pattern)
+	}
+	return func(name string) bool {
+		ok, _ := path.Match(pattern, strings.ToLower(name))
+		return ok
+	}, nil
+}
+
+// Shows reports whether an item known by any of names, such as a
+// subscription's ID and name, is shown: one of them matches an include
+// pattern, or there are none, and none matches an exclude pattern.
+func (s Set) Shows(names ...string) bool {
+	if matchesAny(s.exclude, names) {
+		return false
+	}
+	return len(s.include) == 0 || matchesAny(s.include, names)
+}
+
+func matchesAny(matchers []func(string) bool, names []string) bool {
+	for _, match := range matchers {
+		for _, name := range names {
+			if match(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
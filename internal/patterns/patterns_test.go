@@ -0,0 +1,72 @@
+package patterns_test
+
+import (
+	"strings"
+	"testing"
+
+	"storage-tui/internal/patterns"
+)
+
+func TestShows(t *testing.T) {
+	tests := []struct {
+		name             string
+		include, exclude []string
+		names            []string
+		want             bool
+	}{
+		{name: "no patterns show everything", names: []string{"acme-prod"}, want: true},
+		{name: "empty include list shows what is not excluded", exclude: []string{"*-dev"}, names: []string{"acme-prod"}, want: true},
+		{name: "glob matches the whole name", include: []string{"acme-*"}, names: []string{"acme-prod"}, want: true},
+		{name: "glob does not match part of the name", include: []string{"prod"}, names: []string{"acme-prod"}, want: false},
+		{name: "glob ignores case", include: []string{"ACME-*"}, names: []string{"Acme-Prod"}, want: true},
+		{name: "glob with spaces around it", include: []string{"  acme-?rod "}, names: []string{"acme-prod"}, want: true},
+		{name: "re: matches anywhere in the name", include: []string{"re:prod"}, names: []string{"acme-prod-eu"}, want: true},
+		{name: "re: ignores case", include: []string{"re:^ACME"}, names: []string{"acme-prod"}, want: true},
+		{name: "re: anchored does not match", include: []string{"re:^prod"}, names: []string{"acme-prod"}, want: false},
+		{name: "re: is not a glob", include: []string{"re:acme-*"}, names: []string{"acmeprod"}, want: true},
+		{name: "any of the names may match", include: []string{"Production"}, names: []string{"0000-1111", "production"}, want: true},
+		{name: "exclude wins over include", include: []string{"acme-*"}, exclude: []string{"*-prod"}, names: []string{"acme-prod"}, want: false},
+		{name: "exclude wins whichever name it matches", include: []string{"Production"}, exclude: []string{"0000-*"}, names: []string{"0000-1111", "Production"}, want: false},
+		{name: "unexcluded included name shows", include: []string{"acme-*"}, exclude: []string{"*-prod"}, names: []string{"acme-dev"}, want: true},
+		{name: "not included hides", include: []string{"acme-*"}, names: []string{"contoso"}, want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			set, err := patterns.Compile(test.include, test.exclude)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := set.Shows(test.names...); got != test.want {
+				t.Errorf("Shows(%q) = %v, want %v", test.names, got, test.want)
+			}
+		})
+	}
+}
+
+func TestZeroSetShowsEverything(t *testing.T) {
+	var set patterns.Set
+	if !set.Shows("anything") {
+		t.Error("zero Set hides a name")
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name             string
+		include, exclude []string
+		want             string
+	}{
+		{name: "bad glob", include: []string{"acme-["}, want: `include: invalid pattern "acme-["`},
+		{name: "bad regular expression", exclude: []string{"re:(prod"}, want: `exclude: invalid regular expression "(prod"`},
+		{name: "empty regular expression", include: []string{"re:"}, want: "include: empty regular expression after re:"},
+		{name: "empty pattern", exclude: []string{"  "}, want: "exclude: empty pattern"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := patterns.Compile(test.include, test.exclude)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("Compile error = %v, want %q", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package prefs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// PreviewSearch holds the options of the preview search dialog.
+type PreviewSearch struct {
+	CaseSensitive bool `json:"caseSensitive,omitempty"`
+	WholeWord     bool `json:"wholeWord,omitempty"`
+}
+
+// Prefs are settings changed inside the app that later launches start with.
+type Prefs struct {
+	PreviewSearch PreviewSearch `json:"previewSearch"`
+	// UsageMetrics is whether the user turned usage metrics on.
+	UsageMetrics bool `json:"usageMetrics,omitempty"`
+}
+
+// DefaultPath returns the preferences file inside the user config directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "storage-tui", "preferences.json"), nil
+}
+
+// Load reads the preferences saved at path. A missing file yields the
+// defaults.
+func Load(path string) (Prefs, error) {
+	var prefs Prefs
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return prefs, nil
+	}
+	if err != nil {
+		return prefs, err
+	}
+	if err := json.Unmarshal(data, &prefs); err != nil {
+		return Prefs{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return prefs, nil
+}
+
+// Save writes the preferences to path, replacing the file atomically.
+func Save(path string, prefs Prefs) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(prefs, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
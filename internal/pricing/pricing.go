@@ -0,0 +1,76 @@
+// Package pricing estimates what storing blobs costs a month from a bundled
+// table of pay-as-you-go list prices. The prices are approximate and change
+// over time; they are meant to show where the money goes, not to replace
+// the bill.
+package pricing
+
+import "strings"
+
+// DefaultRegion is priced for regions the table does not list.
+const DefaultRegion = "eastus"
+
+// gigabyte is the unit blob storage is billed by.
+const gigabyte = 1 << 30
+
+// listPrices are US dollars per GB a month for locally redundant block blob
+// storage in the first 50 TB, by region and access tier.
+var listPrices = map[string]map[string]float64{
+	"eastus":        {"Hot": 0.0184, "Cool": 0.0100, "Cold": 0.0036, "Archive": 0.00099},
+	"eastus2":       {"Hot": 0.0184, "Cool": 0.0100, "Cold": 0.0036, "Archive": 0.00099},
+	"westus2":       {"Hot": 0.0184, "Cool": 0.0100, "Cold": 0.0036, "Archive": 0.00099},
+	"centralus":     {"Hot": 0.0208, "Cool": 0.0115, "Cold": 0.0041, "Archive": 0.0011},
+	"westeurope":    {"Hot": 0.0196, "Cool": 0.0109, "Cold": 0.0039, "Archive": 0.0011},
+	"northeurope":   {"Hot": 0.0191, "Cool": 0.0104, "Cold": 0.0037, "Archive": 0.00099},
+	"uksouth":       {"Hot": 0.0196, "Cool": 0.0109, "Cold": 0.0039, "Archive": 0.0011},
+	"southeastasia": {"Hot": 0.0225, "Cool": 0.0125, "Cold": 0.0045, "Archive": 0.0012},
+	"australiaeast": {"Hot": 0.0240, "Cool": 0.0130, "Cold": 0.0047, "Archive": 0.0013},
+}
+
+// premiumPrice is what premium block blob storage costs per GB a month,
+// whatever the tier.
+const premiumPrice = 0.15
+
+// redundancy multiplies the locally redundant price for the other
+// replication options.
+var redundancy = map[string]float64{
+	"LRS":    1,
+	"ZRS":    1.25,
+	"GRS":    2,
+	"RAGRS":  2.5,
+	"GZRS":   2.25,
+	"RAGZRS": 2.8,
+}
+
+// Rate returns the estimated price per GB a month of storing a blob in tier,
+// Hot when empty, in an account in region with sku, such as Standard_GRS.
+// listed reports whether the table knows the region, tier, and replication;
+// when it does not, the default region and locally redundant prices are used.
+func Rate(region, sku, tier string) (price float64, listed bool) {
+	listed = true
+	edition, replication, _ := strings.Cut(sku, "_")
+	factor, ok := redundancy[strings.ToUpper(replication)]
+	if !ok {
+		factor, listed = 1, false
+	}
+	if strings.EqualFold(edition, "Premium") {
+		return premiumPrice * factor, listed
+	}
+
+	prices, ok := listPrices[strings.ToLower(region)]
+	if !ok {
+		prices, listed = listPrices[DefaultRegion], false
+	}
+	if tier == "" {
+		tier = "Hot"
+	}
+	price, ok = prices[strings.ToUpper(tier[:1])+strings.ToLower(tier[1:])]
+	if !ok {
+		price, listed = prices["Hot"], false
+	}
+	return price * factor, listed
+}
+
+// MonthlyCost returns the estimated monthly cost of storing bytes at rate.
+func MonthlyCost(rate float64, bytes int64) float64 {
+	return rate * float64(bytes) / gigabyte
+}
@@ -0,0 +1,65 @@
+package pricing_test
+
+import (
+	"math"
+	"testing"
+
+	"storage-tui/internal/pricing"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestRate(t *testing.T) {
+	tests := []struct {
+		name              string
+		region, sku, tier string
+		want              float64
+		wantListed        bool
+	}{
+		{name: "hot locally redundant", region: "eastus", sku: "Standard_LRS", tier: "Hot", want: 0.0184, wantListed: true},
+		{name: "empty tier is hot", region: "westeurope", sku: "Standard_LRS", want: 0.0196, wantListed: true},
+		{name: "tier and region in any case", region: "WestEurope", sku: "standard_lrs", tier: "cOOL", want: 0.0109, wantListed: true},
+		{name: "archive", region: "northeurope", sku: "Standard_LRS", tier: "Archive", want: 0.00099, wantListed: true},
+		{name: "geo-redundant doubles", region: "eastus", sku: "Standard_GRS", tier: "Cool", want: 0.0200, wantListed: true},
+		{name: "read-access geo-redundant", region: "eastus", sku: "Standard_RAGRS", tier: "Hot", want: 0.0184 * 2.5, wantListed: true},
+		{name: "zone-redundant", region: "uksouth", sku: "Standard_ZRS", tier: "Cold", want: 0.0039 * 1.25, wantListed: true},
+		{name: "premium ignores region and tier", region: "nowhere", sku: "Premium_LRS", tier: "Archive", want: 0.15, wantListed: true},
+		{name: "premium zone-redundant", region: "eastus", sku: "Premium_ZRS", want: 0.15 * 1.25, wantListed: true},
+		{name: "unknown region is priced as the default", region: "brazilsouth", sku: "Standard_LRS", tier: "Hot", want: 0.0184, wantListed: false},
+		{name: "unknown tier is priced as hot", region: "centralus", sku: "Standard_LRS", tier: "Frozen", want: 0.0208, wantListed: false},
+		{name: "unknown replication is priced as locally redundant", region: "eastus", sku: "Standard_XRS", tier: "Hot", want: 0.0184, wantListed: false},
+		{name: "sku without replication", region: "eastus", sku: "Standard", tier: "Hot", want: 0.0184, wantListed: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, listed := pricing.Rate(test.region, test.sku, test.tier)
+			if !near(got, test.want) || listed != test.wantListed {
+				t.Errorf("Rate(%q, %q, %q) = %v, %v, want %v, %v", test.region, test.sku, test.tier, got, listed, test.want, test.wantListed)
+			}
+		})
+	}
+}
+
+func TestMonthlyCost(t *testing.T) {
+	tests := []struct {
+		name  string
+		rate  float64
+		bytes int64
+		want  float64
+	}{
+		{name: "nothing stored", rate: 0.0184, bytes: 0, want: 0},
+		{name: "one gigabyte", rate: 0.0184, bytes: 1 << 30, want: 0.0184},
+		{name: "half a gigabyte", rate: 0.02, bytes: 1 << 29, want: 0.01},
+		{name: "a terabyte", rate: 0.0184, bytes: 1 << 40, want: 0.0184 * 1024},
+		{name: "a blob of the mock data", rate: 0.0196, bytes: 358717440, want: 0.0196 * 358717440 / (1 << 30)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := pricing.MonthlyCost(test.rate, test.bytes); !near(got, test.want) {
+				t.Errorf("MonthlyCost(%v, %d) = %v, want %v", test.rate, test.bytes, got, test.want)
+			}
+		})
+	}
+}
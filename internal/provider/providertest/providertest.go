@@ -0,0 +1,459 @@
+// Package providertest checks that a provider behaves as the browser counts
+// on every backend to: how listings are ordered and paged, what errors say
+// and wrap, and that calls stop when their context is done. A backend's
+// tests run it with Run, so a new backend, or a change to one, cannot break
+// the browser unnoticed.
+package providertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"storage-tui/internal/azure"
+)
+
+// Backend is what Run needs of the backend under test.
+type Backend struct {
+	// New returns the provider under test. It serves at least one
+	// container with blobs, and answers the same on every call while the
+	// suite runs.
+	New func(t *testing.T) azure.Provider
+	// Throttled, when set, returns the provider under test with the service
+	// throttling every call, asking to wait retryAfter.
+	Throttled func(t *testing.T, retryAfter time.Duration) azure.Provider
+	// Failing, when set, returns the provider under test with the service
+	// failing every call with a server error.
+	Failing func(t *testing.T) azure.Provider
+}
+
+// minBlobs is how many blobs the container the suite pages through should
+// hold, for pages of one to have something to continue from.
+const minBlobs = 3
+
+// maxPages caps how many pages the pagination checks read of a listing, so
+// that containers of any size are quick to check.
+const maxPages = 50
+
+// Faulty returns the built-in mock data with faults injected, for backends
+// that wrap the mock provider to check their Throttled and Failing errors.
+func Faulty(t *testing.T, faults azure.Faults) *azure.MockProvider {
+	t.Helper()
+	m := azure.NewMockProvider()
+	if err := m.InjectFaults(faults); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// Run checks the provider of b, each check a subtest. Checks that need one
+// of b's optional functions are skipped without it.
+func Run(t *testing.T, b Backend) {
+	t.Run("Listing", func(t *testing.T) { testListing(t, b) })
+	t.Run("ListingIsCopy", func(t *testing.T) { testListingIsCopy(t, b) })
+	t.Run("Pagination", func(t *testing.T) { testPagination(t, b) })
+	t.Run("Prefix", func(t *testing.T) { testPrefix(t, b) })
+	t.Run("Properties", func(t *testing.T) { testProperties(t, b) })
+	t.Run("Content", func(t *testing.T) { testContent(t, b) })
+	t.Run("NotFound", func(t *testing.T) { testNotFound(t, b) })
+	t.Run("Canceled", func(t *testing.T) { testContextDone(t, b, context.Canceled) })
+	t.Run("DeadlineExceeded", func(t *testing.T) { testContextDone(t, b, context.DeadlineExceeded) })
+	t.Run("Throttled", func(t *testing.T) { testThrottled(t, b) })
+	t.Run("Failing", func(t *testing.T) { testFailing(t, b) })
+}
+
+// sample is a container of the provider under test, with its listing.
+type sample struct {
+	account   string
+	container string
+	blobs     []azure.Blob
+}
+
+// walk lists the provider's hierarchy down to containers, checking each
+// listing, and returns the first container holding minBlobs or more, else
+// the one holding the most.
+func walk(t *testing.T, p azure.Provider) sample {
+	t.Helper()
+	ctx := t.Context()
+	subscriptions, err := p.ListSubscriptions(ctx)
+	if err != nil {
+		t.Fatalf("ListSubscriptions: %v", err)
+	}
+	if len(subscriptions) == 0 {
+		t.Fatal("ListSubscriptions: no subscriptions; the suite needs a container with blobs")
+	}
+	subscriptionIDs := make(map[string]bool)
+	accountsSeen := make(map[string]string)
+	var found sample
+	for _, subscription := range subscriptions {
+		if subscription.ID == "" || subscriptionIDs[subscription.ID] {
+			t.Errorf("ListSubscriptions: subscription %q has no ID or one listed twice", subscription.Name)
+		}
+		subscriptionIDs[subscription.ID] = true
+		accounts, err := p.ListAccounts(ctx, subscription.ID)
+		if err != nil {
+			t.Fatalf("ListAccounts(%s): %v", subscription.ID, err)
+		}
+		for _, account := range accounts {
+			if other, ok := accountsSeen[account.Name]; ok || account.Name == "" {
+				t.Errorf("ListAccounts(%s): account %q has no name, or is listed in %s too", subscription.ID, account.Name, other)
+			}
+			accountsSeen[account.Name] = subscription.ID
+			if len(found.blobs) >= minBlobs {
+				continue
+			}
+			containers, err := p.ListContainers(ctx, account.Name)
+			if err != nil {
+				t.Fatalf("ListContainers(%s): %v", account.Name, err)
+			}
+			names := make(map[string]bool)
+			for _, container := range containers {
+				if container.Name == "" || names[container.Name] {
+					t.Errorf("ListContainers(%s): container %q has no name or is listed twice", account.Name, container.Name)
+				}
+				names[container.Name] = true
+				if len(found.blobs) >= minBlobs {
+					continue
+				}
+				blobs, err := p.ListBlobs(ctx, account.Name, container.Name)
+				if err != nil {
+					t.Fatalf("ListBlobs(%s/%s): %v", account.Name, container.Name, err)
+				}
+				checkBlobs(t, fmt.Sprintf("ListBlobs(%s/%s)", account.Name, container.Name), blobs)
+				if len(blobs) > len(found.blobs) {
+					found = sample{account: account.Name, container: container.Name, blobs: blobs}
+				}
+			}
+		}
+	}
+
+	all, err := p.ListAccounts(ctx, "")
+	if err != nil {
+		t.Fatalf("ListAccounts of every subscription: %v", err)
+	}
+	if len(all) != len(accountsSeen) {
+		t.Errorf("ListAccounts of every subscription: %d accounts, want the %d of the subscriptions", len(all), len(accountsSeen))
+	}
+	for _, account := range all {
+		if _, ok := accountsSeen[account.Name]; !ok {
+			t.Errorf("ListAccounts of every subscription: account %s is in none of the subscriptions", account.Name)
+		}
+	}
+	if len(found.blobs) == 0 {
+		t.Fatal("no container holds blobs; the suite needs one")
+	}
+	return found
+}
+
+// checkBlobs checks that a listing has names, each once, in ascending
+// order, as Azure lists them; paging continues after the last name listed.
+func checkBlobs(t *testing.T, call string, blobs []azure.Blob) {
+	t.Helper()
+	for i, blob := range blobs {
+		switch {
+		case blob.Name == "":
+			t.Errorf("%s: blob %d has no name", call, i)
+		case i > 0 && blob.Name <= blobs[i-1].Name:
+			t.Errorf("%s: %q is listed after %q; blobs are listed by name, each once", call, blob.Name, blobs[i-1].Name)
+		case blob.SizeBytes < 0:
+			t.Errorf("%s: %s is %d bytes", call, blob.Name, blob.SizeBytes)
+		}
+	}
+}
+
+func testListing(t *testing.T, b Backend) {
+	walk(t, b.New(t))
+}
+
+// testListingIsCopy checks that a listing is the caller's: the browser
+// sorts and filters listings in place.
+func testListingIsCopy(t *testing.T, b Backend) {
+	p := b.New(t)
+	s := walk(t, p)
+	ctx := t.Context()
+
+	s.blobs[0].Name = "changed by the caller"
+	blobs, err := p.ListBlobs(ctx, s.account, s.container)
+	if err != nil {
+		t.Fatalf("ListBlobs: %v", err)
+	}
+	if blobs[0].Name == s.blobs[0].Name {
+		t.Error("ListBlobs: changing a listing changed the next one")
+	}
+
+	subscriptions, err := p.ListSubscriptions(ctx)
+	if err != nil {
+		t.Fatalf("ListSubscriptions: %v", err)
+	}
+	subscriptions[0].ID = "changed by the caller"
+	again, err := p.ListSubscriptions(ctx)
+	if err != nil {
+		t.Fatalf("ListSubscriptions: %v", err)
+	}
+	if again[0].ID == subscriptions[0].ID {
+		t.Error("ListSubscriptions: changing a listing changed the next one")
+	}
+}
+
+// testPagination checks that the pages of a listing, whatever their size,
+// add up to ListBlobs.
+func testPagination(t *testing.T, b Backend) {
+	p := b.New(t)
+	s := walk(t, p)
+	sizes := []int{len(s.blobs)/maxPages + 1, len(s.blobs), len(s.blobs) + 1, 0}
+	if len(s.blobs) <= maxPages {
+		sizes = append(sizes, 1)
+	}
+	for _, size := range sizes {
+		t.Run(fmt.Sprintf("MaxResults=%d", size), func(t *testing.T) {
+			checkPages(t, p, s, azure.ListBlobsOptions{MaxResults: size}, names(s.blobs))
+		})
+	}
+}
+
+// testPrefix checks that a prefix lists the blobs starting with it, page by
+// page, such as those of a folder.
+func testPrefix(t *testing.T, b Backend) {
+	p := b.New(t)
+	s := walk(t, p)
+	name := s.blobs[len(s.blobs)/2].Name
+	prefix := name[:1]
+	if slash := strings.LastIndex(name, "/"); slash >= 0 {
+		prefix = name[:slash+1]
+	}
+	var want []string
+	for _, blob := range s.blobs {
+		if strings.HasPrefix(blob.Name, prefix) {
+			want = append(want, blob.Name)
+		}
+	}
+	for _, opts := range []azure.ListBlobsOptions{
+		{Prefix: prefix},
+		{Prefix: prefix, MaxResults: len(want)/maxPages + 1},
+	} {
+		checkPages(t, p, s, opts, want)
+	}
+	checkPages(t, p, s, azure.ListBlobsOptions{Prefix: "\x7fno blob starts with this"}, nil)
+}
+
+// checkPages pages through a listing with opts, checking each page and that
+// they add up to want.
+func checkPages(t *testing.T, p azure.Provider, s sample, opts azure.ListBlobsOptions, want []string) {
+	t.Helper()
+	call := fmt.Sprintf("ListBlobsPage(%s/%s, %+v)", s.account, s.container, opts)
+	limit := opts.MaxResults
+	if limit <= 0 {
+		limit = azure.DefaultPageSize
+	}
+	var got []azure.Blob
+	for pages := 1; ; pages++ {
+		page, err := p.ListBlobsPage(t.Context(), s.account, s.container, opts)
+		if err != nil {
+			t.Fatalf("%s: %v", call, err)
+		}
+		if len(page.Blobs) > limit {
+			t.Fatalf("%s: page %d holds %d blobs, more than %d", call, pages, len(page.Blobs), limit)
+		}
+		got = append(got, page.Blobs...)
+		if page.NextMarker == "" {
+			break
+		}
+		if page.NextMarker == opts.Marker || len(page.Blobs) == 0 {
+			t.Fatalf("%s: page %d continues at %q without listing anything", call, pages, page.NextMarker)
+		}
+		if pages > maxPages+1 {
+			t.Fatalf("%s: more than %d pages", call, maxPages+1)
+		}
+		opts.Marker = page.NextMarker
+	}
+	checkBlobs(t, call, got)
+	if g := names(got); !slices.Equal(g, want) {
+		t.Errorf("%s: the pages list %d blobs from %q, want %d from %q", call, len(g), first(g), len(want), first(want))
+	}
+}
+
+// testProperties checks that a blob's properties agree with its listing,
+// which the browser shows side by side and compares to spot changes.
+func testProperties(t *testing.T, b Backend) {
+	p := b.New(t)
+	s := walk(t, p)
+	ctx := t.Context()
+	if _, err := p.GetContainerProperties(ctx, s.account, s.container); err != nil {
+		t.Errorf("GetContainerProperties(%s/%s): %v", s.account, s.container, err)
+	}
+	for _, listed := range []azure.Blob{s.blobs[0], s.blobs[len(s.blobs)-1]} {
+		props, err := p.GetBlobProperties(ctx, s.account, s.container, listed.Name)
+		if err != nil {
+			t.Errorf("GetBlobProperties(%s): %v", listed.Name, err)
+			continue
+		}
+		if props.Name != listed.Name || props.SizeBytes != listed.SizeBytes {
+			t.Errorf("GetBlobProperties(%s): %s of %d bytes, listed as %s of %d", listed.Name, props.Name, props.SizeBytes, listed.Name, listed.SizeBytes)
+		}
+		if listed.ETag != "" && props.ETag != listed.ETag {
+			t.Errorf("GetBlobProperties(%s): ETag %s, listed as %s", listed.Name, props.ETag, listed.ETag)
+		}
+	}
+}
+
+// testContent checks that a blob can be read to its end, and that a range
+// of it reads as that part of it where the provider reads ranges.
+func testContent(t *testing.T, b Backend) {
+	p := b.New(t)
+	s := walk(t, p)
+	blob := s.blobs[0].Name
+	r, err := p.OpenBlob(t.Context(), s.account, s.container, blob)
+	if err != nil {
+		t.Fatalf("OpenBlob(%s): %v", blob, err)
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		t.Errorf("OpenBlob(%s): reading: %v", blob, err)
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("OpenBlob(%s): closing: %v", blob, err)
+	}
+
+	ranges, ok := p.(azure.RangeReader)
+	if !ok || len(content) < 2 {
+		return
+	}
+	offset, length := int64(1), int64(len(content)/2)
+	r, err = ranges.OpenBlobRange(t.Context(), s.account, s.container, blob, offset, length)
+	if err != nil {
+		t.Fatalf("OpenBlobRange(%s, %d, %d): %v", blob, offset, length, err)
+	}
+	defer r.Close()
+	part, err := io.ReadAll(r)
+	if err != nil {
+		t.Errorf("OpenBlobRange(%s, %d, %d): reading: %v", blob, offset, length, err)
+	}
+	if string(part) != string(content[offset:offset+length]) {
+		t.Errorf("OpenBlobRange(%s, %d, %d): read %q, want %q", blob, offset, length, part, content[offset:offset+length])
+	}
+}
+
+// testNotFound checks that a blob that is not there fails to be read, with
+// an error naming it, as the browser shows the error as it is.
+func testNotFound(t *testing.T, b Backend) {
+	p := b.New(t)
+	s := walk(t, p)
+	ctx := t.Context()
+	missing := s.blobs[len(s.blobs)-1].Name + "-missing"
+	if _, err := p.GetBlobProperties(ctx, s.account, s.container, missing); err == nil || !strings.Contains(err.Error(), missing) {
+		t.Errorf("GetBlobProperties of a missing blob: %v, want an error naming %s", err, missing)
+	}
+	r, err := p.OpenBlob(ctx, s.account, s.container, missing)
+	if err == nil {
+		r.Close()
+	}
+	if err == nil || !strings.Contains(err.Error(), missing) {
+		t.Errorf("OpenBlob of a missing blob: %v, want an error naming %s", err, missing)
+	}
+}
+
+// testContextDone checks that calls whose context is done fail with an
+// error wrapping why, which the browser checks for to tell a call it gave
+// up on from one that failed.
+func testContextDone(t *testing.T, b Backend, want error) {
+	p := b.New(t)
+	s := walk(t, p)
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if want == context.DeadlineExceeded {
+		ctx, cancel = context.WithDeadline(t.Context(), time.Now().Add(-time.Second))
+	} else {
+		ctx, cancel = context.WithCancel(t.Context())
+		cancel()
+	}
+	defer cancel()
+	forEachCall(ctx, p, s, func(call string, err error) {
+		if !errors.Is(err, want) {
+			t.Errorf("%s: %v, want an error wrapping %v", call, err, want)
+		}
+	})
+}
+
+// testThrottled checks that throttling comes out as a ThrottledError with
+// the wait the service asked for, which the browser retries after.
+func testThrottled(t *testing.T, b Backend) {
+	if b.Throttled == nil {
+		t.Skip("the backend cannot be made throttled")
+	}
+	s := walk(t, b.New(t))
+	const retryAfter = 3 * time.Second
+	forEachCall(t.Context(), b.Throttled(t, retryAfter), s, func(call string, err error) {
+		var throttled *azure.ThrottledError
+		switch {
+		case !errors.As(err, &throttled):
+			t.Errorf("%s: %v, want a *azure.ThrottledError", call, err)
+		case throttled.RetryAfter != retryAfter:
+			t.Errorf("%s: throttled, retry after %s, want %s", call, throttled.RetryAfter, retryAfter)
+		}
+	})
+}
+
+// testFailing checks that a failed call is neither mistaken for throttling,
+// which would be retried, nor for cancellation, which would be hidden.
+func testFailing(t *testing.T, b Backend) {
+	if b.Failing == nil {
+		t.Skip("the backend cannot be made to fail")
+	}
+	s := walk(t, b.New(t))
+	forEachCall(t.Context(), b.Failing(t), s, func(call string, err error) {
+		var throttled *azure.ThrottledError
+		switch {
+		case err == nil:
+			t.Errorf("%s: no error", call)
+		case err.Error() == "":
+			t.Errorf("%s: an error without a message", call)
+		case errors.As(err, &throttled), errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+			t.Errorf("%s: %v, want a failure, not throttling or cancellation", call, err)
+		}
+	})
+}
+
+// forEachCall makes the calls the browser makes of a container with ctx,
+// and checks the error of each.
+func forEachCall(ctx context.Context, p azure.Provider, s sample, check func(call string, err error)) {
+	blob := s.blobs[0].Name
+	_, err := p.ListSubscriptions(ctx)
+	check("ListSubscriptions", err)
+	_, err = p.ListContainers(ctx, s.account)
+	check("ListContainers", err)
+	_, err = p.ListBlobs(ctx, s.account, s.container)
+	check("ListBlobs", err)
+	_, err = p.ListBlobsPage(ctx, s.account, s.container, azure.ListBlobsOptions{MaxResults: 1})
+	check("ListBlobsPage", err)
+	_, err = p.GetContainerProperties(ctx, s.account, s.container)
+	check("GetContainerProperties", err)
+	_, err = p.GetBlobProperties(ctx, s.account, s.container, blob)
+	check("GetBlobProperties", err)
+	r, err := p.OpenBlob(ctx, s.account, s.container, blob)
+	if err == nil {
+		// A reader may only find out when it is read.
+		_, err = io.ReadAll(r)
+		r.Close()
+	}
+	check("OpenBlob", err)
+}
+
+func names(blobs []azure.Blob) []string {
+	list := make([]string, len(blobs))
+	for i, blob := range blobs {
+		list[i] = blob.Name
+	}
+	return list
+}
+
+func first(list []string) string {
+	if len(list) == 0 {
+		return ""
+	}
+	return list[0]
+}
@@ -0,0 +1,393 @@
+// Package recording writes the answers a provider gives to a file and plays
+// them back later, for offline demos and for bug reports that carry the
+// exact data that set off a problem. A recording holds one JSON object a
+// line: the call, its arguments, and the answer, whether a result or an
+// error.
+package recording
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"storage-tui/internal/azure"
+)
+
+// maxContent caps the blob content recorded from one read; the rest is left
+// out, and playback fails past it.
+const maxContent = 16 << 20
+
+// entry is one line of a recording.
+type entry struct {
+	Time time.Time `json:"time"`
+	Call string    `json:"call"`
+	Args args      `json:"args,omitzero"`
+	// Result is the call's result as JSON; blob content is a byte string.
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	// Throttled marks an error the service throttled, with the wait it
+	// asked for.
+	Throttled  bool          `json:"throttled,omitempty"`
+	RetryAfter time.Duration `json:"retryAfter,omitempty"`
+	// Complete is set on blob content that was read to its end.
+	Complete bool `json:"complete,omitempty"`
+}
+
+// args holds the arguments of every call; each uses those of its provider
+// method.
+type args struct {
+	SubscriptionID string                  `json:"subscriptionID,omitempty"`
+	Account        string                  `json:"account,omitempty"`
+	Container      string                  `json:"container,omitempty"`
+	Blob           string                  `json:"blob,omitempty"`
+	Expression     string                  `json:"expression,omitempty"`
+	Page           azure.ListBlobsOptions  `json:"page,omitzero"`
+	ChangeFeed     azure.ChangeFeedOptions `json:"changeFeed,omitzero"`
+	Span           time.Duration           `json:"span,omitempty"`
+	Interval       time.Duration           `json:"interval,omitempty"`
+	Offset         int64                   `json:"offset,omitempty"`
+	Length         int64                   `json:"length,omitempty"`
+}
+
+// key identifies the answer to a call in playback. The change feed's time
+// window is left out, as it moves with the clock, so a replayed change feed
+// view gets the events it was recorded with.
+func key(call string, a args) string {
+	a.ChangeFeed.Start, a.ChangeFeed.End = time.Time{}, time.Time{}
+	data, _ := json.Marshal(a)
+	return call + " " + string(data)
+}
+
+// Recording is a file that recorders write to.
+type Recording struct {
+	mu   sync.Mutex
+	file *os.File
+	err  error
+}
+
+// Create starts a recording at path, replacing any file there. It is only
+// readable by its owner, as it holds what the provider returned.
+func Create(path string) (*Recording, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &Recording{file: file}, nil
+}
+
+// Close ends the recording, returning the first error writing it met.
+func (r *Recording) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return errors.Join(r.err, r.file.Close())
+}
+
+// write adds e as a line of its own, so a recording cut short by a crash
+// keeps every answer before it.
+func (r *Recording) write(e entry) {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.file.Write(append(line, '\n')); err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+// Record returns a provider answering from provider, writing each answer to
+// the recording. Calls that change storage, and storage events, go to
+// provider as well but are not recorded, so playback only reads.
+func (r *Recording) Record(provider azure.Provider) *Recorder {
+	recorder := &Recorder{provider: provider, recording: r}
+	identity := ""
+	if p, ok := provider.(interface{ Identity() string }); ok {
+		identity = p.Identity()
+	}
+	recorder.record("Identity", args{}, identity, nil)
+	return recorder
+}
+
+// Recorder is a provider that records the answers of another. It has every
+// optional capability; those the other provider lacks fail when called.
+type Recorder struct {
+	provider  azure.Provider
+	recording *Recording
+}
+
+var (
+	_ azure.Provider           = (*Recorder)(nil)
+	_ azure.Uploader           = (*Recorder)(nil)
+	_ azure.Deleter            = (*Recorder)(nil)
+	_ azure.Restorer           = (*Recorder)(nil)
+	_ azure.RangeReader        = (*Recorder)(nil)
+	_ azure.Signer             = (*Recorder)(nil)
+	_ azure.EventSubscriber    = (*Recorder)(nil)
+	_ azure.CORSEditor         = (*Recorder)(nil)
+	_ azure.LifecycleEditor    = (*Recorder)(nil)
+	_ azure.ImmutabilityEditor = (*Recorder)(nil)
+)
+
+// record writes the answer to a call, unless the call was canceled, and
+// passes it on.
+func record[T any](r *Recorder, call string, a args, result T, err error) (T, error) {
+	r.record(call, a, result, err)
+	return result, err
+}
+
+func (r *Recorder) record(call string, a args, result any, err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	e := entry{Time: time.Now().UTC(), Call: call, Args: a}
+	if err != nil {
+		e.Error = err.Error()
+		var throttled *azure.ThrottledError
+		if errors.As(err, &throttled) {
+			e.Throttled = true
+			e.RetryAfter = throttled.RetryAfter
+		}
+	} else {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return
+		}
+		e.Result = data
+	}
+	r.recording.write(e)
+}
+
+// Identity describes the recorded provider's credentials, and that they are
+// being recorded.
+func (r *Recorder) Identity() string {
+	if p, ok := r.provider.(interface{ Identity() string }); ok {
+		return p.Identity() + " (recording)"
+	}
+	return "recording"
+}
+
+func (r *Recorder) ListSubscriptions(ctx context.Context) ([]azure.Subscription, error) {
+	result, err := r.provider.ListSubscriptions(ctx)
+	return record(r, "ListSubscriptions", args{}, result, err)
+}
+
+func (r *Recorder) ListAccounts(ctx context.Context, subscriptionID string) ([]azure.Account, error) {
+	result, err := r.provider.ListAccounts(ctx, subscriptionID)
+	return record(r, "ListAccounts", args{SubscriptionID: subscriptionID}, result, err)
+}
+
+func (r *Recorder) ListContainers(ctx context.Context, account string) ([]azure.Container, error) {
+	result, err := r.provider.ListContainers(ctx, account)
+	return record(r, "ListContainers", args{Account: account}, result, err)
+}
+
+func (r *Recorder) ListBlobs(ctx context.Context, account, container string) ([]azure.Blob, error) {
+	result, err := r.provider.ListBlobs(ctx, account, container)
+	return record(r, "ListBlobs", args{Account: account, Container: container}, result, err)
+}
+
+func (r *Recorder) ListBlobsPage(ctx context.Context, account, container string, opts azure.ListBlobsOptions) (azure.BlobPage, error) {
+	result, err := r.provider.ListBlobsPage(ctx, account, container, opts)
+	return record(r, "ListBlobsPage", args{Account: account, Container: container, Page: opts}, result, err)
+}
+
+func (r *Recorder) GetContainerProperties(ctx context.Context, account, container string) (azure.ContainerProperties, error) {
+	result, err := r.provider.GetContainerProperties(ctx, account, container)
+	return record(r, "GetContainerProperties", args{Account: account, Container: container}, result, err)
+}
+
+func (r *Recorder) GetBlobProperties(ctx context.Context, account, container, blob string) (azure.BlobProperties, error) {
+	result, err := r.provider.GetBlobProperties(ctx, account, container, blob)
+	return record(r, "GetBlobProperties", args{Account: account, Container: container, Blob: blob}, result, err)
+}
+
+func (r *Recorder) GetContainerImmutability(ctx context.Context, account, container string) (azure.ContainerImmutability, error) {
+	result, err := r.provider.GetContainerImmutability(ctx, account, container)
+	return record(r, "GetContainerImmutability", args{Account: account, Container: container}, result, err)
+}
+
+func (r *Recorder) FindBlobsByTags(ctx context.Context, account, expression string) ([]azure.TaggedBlob, error) {
+	result, err := r.provider.FindBlobsByTags(ctx, account, expression)
+	return record(r, "FindBlobsByTags", args{Account: account, Expression: expression}, result, err)
+}
+
+func (r *Recorder) GetBlobServiceProperties(ctx context.Context, account string) (azure.BlobServiceProperties, error) {
+	result, err := r.provider.GetBlobServiceProperties(ctx, account)
+	return record(r, "GetBlobServiceProperties", args{Account: account}, result, err)
+}
+
+func (r *Recorder) GetLifecyclePolicy(ctx context.Context, account string) (azure.LifecyclePolicy, error) {
+	result, err := r.provider.GetLifecyclePolicy(ctx, account)
+	return record(r, "GetLifecyclePolicy", args{Account: account}, result, err)
+}
+
+func (r *Recorder) GetNetworkRules(ctx context.Context, account string) (azure.NetworkRules, error) {
+	result, err := r.provider.GetNetworkRules(ctx, account)
+	return record(r, "GetNetworkRules", args{Account: account}, result, err)
+}
+
+func (r *Recorder) ListRoleAssignments(ctx context.Context, account, container string) ([]azure.RoleAssignment, error) {
+	result, err := r.provider.ListRoleAssignments(ctx, account, container)
+	return record(r, "ListRoleAssignments", args{Account: account, Container: container}, result, err)
+}
+
+func (r *Recorder) ListChangeFeedEvents(ctx context.Context, account string, opts azure.ChangeFeedOptions) ([]azure.ChangeFeedEvent, error) {
+	result, err := r.provider.ListChangeFeedEvents(ctx, account, opts)
+	return record(r, "ListChangeFeedEvents", args{Account: account, ChangeFeed: opts}, result, err)
+}
+
+func (r *Recorder) ListReplicationPolicies(ctx context.Context, account string) ([]azure.ReplicationPolicy, error) {
+	result, err := r.provider.ListReplicationPolicies(ctx, account)
+	return record(r, "ListReplicationPolicies", args{Account: account}, result, err)
+}
+
+func (r *Recorder) GetAccountMetrics(ctx context.Context, account string, span, interval time.Duration) (azure.AccountMetrics, error) {
+	result, err := r.provider.GetAccountMetrics(ctx, account, span, interval)
+	return record(r, "GetAccountMetrics", args{Account: account, Span: span, Interval: interval}, result, err)
+}
+
+// OpenBlob records the content as it is read, when the reader is closed.
+func (r *Recorder) OpenBlob(ctx context.Context, account, container, blob string) (io.ReadCloser, error) {
+	a := args{Account: account, Container: container, Blob: blob}
+	reader, err := r.provider.OpenBlob(ctx, account, container, blob)
+	if err != nil {
+		r.record("OpenBlob", a, nil, err)
+		return nil, err
+	}
+	return &contentRecorder{ReadCloser: reader, recorder: r, call: "OpenBlob", args: a}, nil
+}
+
+func (r *Recorder) OpenBlobRange(ctx context.Context, account, container, blob string, offset, length int64) (io.ReadCloser, error) {
+	a := args{Account: account, Container: container, Blob: blob, Offset: offset, Length: length}
+	reader, err := azure.OpenRange(ctx, r.provider, account, container, blob, offset, length)
+	if err != nil {
+		r.record("OpenBlobRange", a, nil, err)
+		return nil, err
+	}
+	return &contentRecorder{ReadCloser: reader, recorder: r, call: "OpenBlobRange", args: a}, nil
+}
+
+// contentRecorder keeps what is read of a blob, up to maxContent, and
+// records it on Close.
+type contentRecorder struct {
+	io.ReadCloser
+	recorder *Recorder
+	call     string
+	args     args
+	data     bytes.Buffer
+	complete bool
+	closed   bool
+}
+
+func (c *contentRecorder) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	if room := maxContent - c.data.Len(); room > 0 {
+		c.data.Write(p[:min(n, room)])
+	}
+	if errors.Is(err, io.EOF) && c.data.Len() < maxContent {
+		c.complete = true
+	}
+	return n, err
+}
+
+func (c *contentRecorder) Close() error {
+	if !c.closed && (c.complete || c.data.Len() > 0) {
+		c.closed = true
+		data, _ := json.Marshal(c.data.Bytes())
+		c.recorder.recording.write(entry{Time: time.Now().UTC(), Call: c.call, Args: c.args, Result: data, Complete: c.complete})
+	}
+	return c.ReadCloser.Close()
+}
+
+// unsupported is the error of a capability the recorded provider lacks.
+func unsupported(what string) error {
+	return errors.New("this provider cannot " + what)
+}
+
+func (r *Recorder) UploadBlob(ctx context.Context, account, container, blob string, reader io.Reader, contentType string) (azure.Blob, error) {
+	uploader, ok := r.provider.(azure.Uploader)
+	if !ok {
+		return azure.Blob{}, unsupported("upload blobs")
+	}
+	return uploader.UploadBlob(ctx, account, container, blob, reader, contentType)
+}
+
+func (r *Recorder) DeleteBlob(ctx context.Context, account, container, blob string) error {
+	deleter, ok := r.provider.(azure.Deleter)
+	if !ok {
+		return unsupported("delete blobs")
+	}
+	return deleter.DeleteBlob(ctx, account, container, blob)
+}
+
+func (r *Recorder) UndeleteBlob(ctx context.Context, account, container, blob, etag string) error {
+	restorer, ok := r.provider.(azure.Restorer)
+	if !ok {
+		return unsupported("restore blobs")
+	}
+	return restorer.UndeleteBlob(ctx, account, container, blob, etag)
+}
+
+func (r *Recorder) RestoreVersion(ctx context.Context, account, container, blob, etag string) error {
+	restorer, ok := r.provider.(azure.Restorer)
+	if !ok {
+		return unsupported("restore blobs")
+	}
+	return restorer.RestoreVersion(ctx, account, container, blob, etag)
+}
+
+// SignURL signs through the recorded provider; signatures are never
+// recorded.
+func (r *Recorder) SignURL(ctx context.Context, account, container, blob string, opts azure.SASOptions) (string, error) {
+	signer, ok := r.provider.(azure.Signer)
+	if !ok {
+		return "", unsupported("sign URLs")
+	}
+	return signer.SignURL(ctx, account, container, blob, opts)
+}
+
+func (r *Recorder) WatchBlobEvents(ctx context.Context, account, container string, send func(azure.BlobEvent)) error {
+	subscriber, ok := r.provider.(azure.EventSubscriber)
+	if !ok {
+		return unsupported("watch storage events")
+	}
+	return subscriber.WatchBlobEvents(ctx, account, container, send)
+}
+
+func (r *Recorder) SetCORSRules(ctx context.Context, account string, rules []azure.CORSRule) error {
+	editor, ok := r.provider.(azure.CORSEditor)
+	if !ok {
+		return unsupported("change CORS rules")
+	}
+	return editor.SetCORSRules(ctx, account, rules)
+}
+
+func (r *Recorder) SetLifecyclePolicy(ctx context.Context, account string, rules []azure.LifecycleRule) error {
+	editor, ok := r.provider.(azure.LifecycleEditor)
+	if !ok {
+		return unsupported("change lifecycle policies")
+	}
+	return editor.SetLifecyclePolicy(ctx, account, rules)
+}
+
+func (r *Recorder) SetImmutabilityPolicy(ctx context.Context, account, container string, days int, allowAppend bool) error {
+	editor, ok := r.provider.(azure.ImmutabilityEditor)
+	if !ok {
+		return unsupported("change immutability policies")
+	}
+	return editor.SetImmutabilityPolicy(ctx, account, container, days, allowAppend)
+}
+
+func (r *Recorder) LockImmutabilityPolicy(ctx context.Context, account, container string) error {
+	editor, ok := r.provider.(azure.ImmutabilityEditor)
+	if !ok {
+		return unsupported("change immutability policies")
+	}
+	return editor.LockImmutabilityPolicy(ctx, account, container)
+}
@@ -0,0 +1,66 @@
+package recording_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"storage-tui/internal/azure"
+	"storage-tui/internal/provider/providertest"
+	"storage-tui/internal/recording"
+)
+
+// TestRecordingConforms runs the suite on recorders of the mock provider,
+// then again on players of what they recorded, which make the same calls.
+func TestRecordingConforms(t *testing.T) {
+	dir := t.TempDir()
+	paths := map[string]string{
+		"answers":   filepath.Join(dir, "answers.jsonl"),
+		"throttled": filepath.Join(dir, "throttled.jsonl"),
+		"failing":   filepath.Join(dir, "failing.jsonl"),
+	}
+	recordings := make(map[string]*recording.Recording)
+	for name, path := range paths {
+		r, err := recording.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recordings[name] = r
+	}
+
+	t.Run("Recorder", func(t *testing.T) {
+		providertest.Run(t, providertest.Backend{
+			New: func(t *testing.T) azure.Provider {
+				return recordings["answers"].Record(azure.NewMockProvider())
+			},
+			Throttled: func(t *testing.T, retryAfter time.Duration) azure.Provider {
+				return recordings["throttled"].Record(providertest.Faulty(t, azure.Faults{ThrottleRate: 1, RetryAfter: retryAfter}))
+			},
+			Failing: func(t *testing.T) azure.Provider {
+				return recordings["failing"].Record(providertest.Faulty(t, azure.Faults{ErrorRate: 1}))
+			},
+		})
+	})
+	for _, r := range recordings {
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	replay := func(t *testing.T, name string) azure.Provider {
+		p, err := recording.Replay(paths[name])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	t.Run("Player", func(t *testing.T) {
+		providertest.Run(t, providertest.Backend{
+			New: func(t *testing.T) azure.Provider { return replay(t, "answers") },
+			Throttled: func(t *testing.T, retryAfter time.Duration) azure.Provider {
+				return replay(t, "throttled")
+			},
+			Failing: func(t *testing.T) azure.Provider { return replay(t, "failing") },
+		})
+	})
+}
@@ -0,0 +1,261 @@
+package recording
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"storage-tui/internal/azure"
+)
+
+// ErrNotRecorded is returned for calls a recording holds no answer to.
+var ErrNotRecorded = errors.New("not in the recording")
+
+// Player is a provider answering from a recording, without a network. It
+// reads only: the recording holds no writes to play back.
+type Player struct {
+	path     string
+	identity string
+	answers  map[string]entry
+}
+
+var (
+	_ azure.Provider    = (*Player)(nil)
+	_ azure.RangeReader = (*Player)(nil)
+)
+
+// Replay reads the recording at path. Of calls answered more than once, the
+// latest answer is played, except that content read further is kept over
+// content read less far.
+func Replay(path string) (*Player, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	p := &Player{path: path, answers: make(map[string]entry)}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 4*maxContent)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if e.Call == "Identity" {
+			json.Unmarshal(e.Result, &p.identity)
+			continue
+		}
+		k := key(e.Call, e.Args)
+		if old, ok := p.answers[k]; ok && isContent(e.Call) && !readFurther(e, old) {
+			continue
+		}
+		p.answers[k] = e
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+func isContent(call string) bool {
+	return call == "OpenBlob" || call == "OpenBlobRange"
+}
+
+// readFurther reports whether content e holds more than old does.
+func readFurther(e, old entry) bool {
+	if old.Complete {
+		return false
+	}
+	return e.Complete || len(e.Result) >= len(old.Result)
+}
+
+// play returns the recorded answer to a call.
+func play[T any](ctx context.Context, p *Player, call string, a args) (T, error) {
+	var result T
+	e, err := p.answer(ctx, call, a)
+	if err != nil {
+		return result, err
+	}
+	if err := json.Unmarshal(e.Result, &result); err != nil {
+		return result, fmt.Errorf("%s: %s: %w", p.path, call, err)
+	}
+	return result, nil
+}
+
+// answer finds the entry answering a call, and turns a recorded error back
+// into one. Calls whose context is done fail with its error, as they do
+// against the provider; the recording holds none of them.
+func (p *Player) answer(ctx context.Context, call string, a args) (entry, error) {
+	if err := ctx.Err(); err != nil {
+		return entry{}, err
+	}
+	e, ok := p.answers[key(call, a)]
+	if !ok {
+		return entry{}, fmt.Errorf("%s%s: %w", call, describe(a), ErrNotRecorded)
+	}
+	switch {
+	case e.Throttled:
+		return e, &azure.ThrottledError{RetryAfter: e.RetryAfter}
+	case e.Error != "":
+		return e, errors.New(e.Error)
+	}
+	return e, nil
+}
+
+// describe names what a call was about, for its not-recorded error.
+func describe(a args) string {
+	var parts []string
+	for _, part := range []string{a.SubscriptionID, a.Account, a.Container, a.Blob} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " of " + strings.Join(parts, "/")
+}
+
+// Identity names the recorded credentials, and that they are played back.
+func (p *Player) Identity() string {
+	if p.identity == "" {
+		return "replay"
+	}
+	return p.identity + " (replay)"
+}
+
+func (p *Player) ListSubscriptions(ctx context.Context) ([]azure.Subscription, error) {
+	return play[[]azure.Subscription](ctx, p, "ListSubscriptions", args{})
+}
+
+func (p *Player) ListAccounts(ctx context.Context, subscriptionID string) ([]azure.Account, error) {
+	return play[[]azure.Account](ctx, p, "ListAccounts", args{SubscriptionID: subscriptionID})
+}
+
+func (p *Player) ListContainers(ctx context.Context, account string) ([]azure.Container, error) {
+	return play[[]azure.Container](ctx, p, "ListContainers", args{Account: account})
+}
+
+func (p *Player) ListBlobs(ctx context.Context, account, container string) ([]azure.Blob, error) {
+	return play[[]azure.Blob](ctx, p, "ListBlobs", args{Account: account, Container: container})
+}
+
+func (p *Player) ListBlobsPage(ctx context.Context, account, container string, opts azure.ListBlobsOptions) (azure.BlobPage, error) {
+	return play[azure.BlobPage](ctx, p, "ListBlobsPage", args{Account: account, Container: container, Page: opts})
+}
+
+func (p *Player) GetContainerProperties(ctx context.Context, account, container string) (azure.ContainerProperties, error) {
+	return play[azure.ContainerProperties](ctx, p, "GetContainerProperties", args{Account: account, Container: container})
+}
+
+func (p *Player) GetBlobProperties(ctx context.Context, account, container, blob string) (azure.BlobProperties, error) {
+	return play[azure.BlobProperties](ctx, p, "GetBlobProperties", args{Account: account, Container: container, Blob: blob})
+}
+
+func (p *Player) GetContainerImmutability(ctx context.Context, account, container string) (azure.ContainerImmutability, error) {
+	return play[azure.ContainerImmutability](ctx, p, "GetContainerImmutability", args{Account: account, Container: container})
+}
+
+func (p *Player) FindBlobsByTags(ctx context.Context, account, expression string) ([]azure.TaggedBlob, error) {
+	return play[[]azure.TaggedBlob](ctx, p, "FindBlobsByTags", args{Account: account, Expression: expression})
+}
+
+func (p *Player) GetBlobServiceProperties(ctx context.Context, account string) (azure.BlobServiceProperties, error) {
+	return play[azure.BlobServiceProperties](ctx, p, "GetBlobServiceProperties", args{Account: account})
+}
+
+func (p *Player) GetLifecyclePolicy(ctx context.Context, account string) (azure.LifecyclePolicy, error) {
+	return play[azure.LifecyclePolicy](ctx, p, "GetLifecyclePolicy", args{Account: account})
+}
+
+func (p *Player) GetNetworkRules(ctx context.Context, account string) (azure.NetworkRules, error) {
+	return play[azure.NetworkRules](ctx, p, "GetNetworkRules", args{Account: account})
+}
+
+func (p *Player) ListRoleAssignments(ctx context.Context, account, container string) ([]azure.RoleAssignment, error) {
+	return play[[]azure.RoleAssignment](ctx, p, "ListRoleAssignments", args{Account: account, Container: container})
+}
+
+func (p *Player) ListChangeFeedEvents(ctx context.Context, account string, opts azure.ChangeFeedOptions) ([]azure.ChangeFeedEvent, error) {
+	return play[[]azure.ChangeFeedEvent](ctx, p, "ListChangeFeedEvents", args{Account: account, ChangeFeed: opts})
+}
+
+func (p *Player) ListReplicationPolicies(ctx context.Context, account string) ([]azure.ReplicationPolicy, error) {
+	return play[[]azure.ReplicationPolicy](ctx, p, "ListReplicationPolicies", args{Account: account})
+}
+
+func (p *Player) GetAccountMetrics(ctx context.Context, account string, span, interval time.Duration) (azure.AccountMetrics, error) {
+	return play[azure.AccountMetrics](ctx, p, "GetAccountMetrics", args{Account: account, Span: span, Interval: interval})
+}
+
+// OpenBlob plays back the content read while recording; reading past what
+// was read then fails.
+func (p *Player) OpenBlob(ctx context.Context, account, container, blob string) (io.ReadCloser, error) {
+	e, err := p.answer(ctx, "OpenBlob", args{Account: account, Container: container, Blob: blob})
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	if err := json.Unmarshal(e.Result, &data); err != nil {
+		return nil, fmt.Errorf("%s: OpenBlob: %w", p.path, err)
+	}
+	return contentReader(data, e.Complete, blob), nil
+}
+
+// OpenBlobRange plays back the same range read while recording, or cuts it
+// from the blob's content when enough of it was read.
+func (p *Player) OpenBlobRange(ctx context.Context, account, container, blob string, offset, length int64) (io.ReadCloser, error) {
+	e, err := p.answer(ctx, "OpenBlobRange", args{Account: account, Container: container, Blob: blob, Offset: offset, Length: length})
+	if err == nil {
+		var data []byte
+		if err := json.Unmarshal(e.Result, &data); err != nil {
+			return nil, fmt.Errorf("%s: OpenBlobRange: %w", p.path, err)
+		}
+		return contentReader(data, e.Complete, blob), nil
+	}
+	if !errors.Is(err, ErrNotRecorded) {
+		return nil, err
+	}
+	whole, wholeErr := p.answer(ctx, "OpenBlob", args{Account: account, Container: container, Blob: blob})
+	if wholeErr != nil {
+		return nil, err
+	}
+	var data []byte
+	if err := json.Unmarshal(whole.Result, &data); err != nil {
+		return nil, fmt.Errorf("%s: OpenBlob: %w", p.path, err)
+	}
+	end := offset + length
+	if !whole.Complete && end > int64(len(data)) {
+		return nil, err
+	}
+	offset, end = min(offset, int64(len(data))), min(end, int64(len(data)))
+	return contentReader(data[offset:end], true, blob), nil
+}
+
+// contentReader reads data, then fails unless it is the whole content.
+func contentReader(data []byte, complete bool, blob string) io.ReadCloser {
+	if complete {
+		return io.NopCloser(bytes.NewReader(data))
+	}
+	rest := &failingReader{fmt.Errorf("the rest of %s, after %d bytes: %w", blob, len(data), ErrNotRecorded)}
+	return io.NopCloser(io.MultiReader(bytes.NewReader(data), rest))
+}
+
+type failingReader struct {
+	err error
+}
+
+func (r *failingReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,389 @@
+package remote
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"storage-tui/internal/azure"
+)
+
+// dialTimeout bounds the first call, which checks the daemon is there and
+// takes the token.
+const dialTimeout = 15 * time.Second
+
+// Options reach a daemon.
+type Options struct {
+	// Address is the daemon's host:port.
+	Address string
+	Token   string
+	// CABundle is a PEM file to verify the daemon's certificate with; empty
+	// uses the system's roots.
+	CABundle string
+	// Plaintext connects without TLS, which is only allowed to loopback
+	// addresses, for tunnels such as ssh -L.
+	Plaintext bool
+}
+
+// Client is a provider whose calls the daemon answers. It has every
+// optional capability; those the daemon's provider lacks fail when called.
+type Client struct {
+	conn     *grpc.ClientConn
+	token    string
+	address  string
+	identity string
+}
+
+var (
+	_ azure.Provider           = (*Client)(nil)
+	_ azure.Uploader           = (*Client)(nil)
+	_ azure.Deleter            = (*Client)(nil)
+	_ azure.Restorer           = (*Client)(nil)
+	_ azure.RangeReader        = (*Client)(nil)
+	_ azure.Signer             = (*Client)(nil)
+	_ azure.CORSEditor         = (*Client)(nil)
+	_ azure.LifecycleEditor    = (*Client)(nil)
+	_ azure.ImmutabilityEditor = (*Client)(nil)
+)
+
+// Dial connects to the daemon and checks it takes the token.
+func Dial(ctx context.Context, opts Options) (*Client, error) {
+	if opts.Address == "" {
+		return nil, errors.New("remote: no daemon address")
+	}
+	if opts.Token == "" {
+		return nil, errors.New("remote: no token for " + opts.Address)
+	}
+	creds, err := transportCredentials(opts)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.NewClient(opts.Address,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype(codecName), grpc.MaxCallRecvMsgSize(maxMessageBytes)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("remote: %w", err)
+	}
+	c := &Client{conn: conn, token: opts.Token, address: opts.Address}
+	ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+	identity, err := call[string](ctx, c, "Identity", request{})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("remote: %s: %w", opts.Address, err)
+	}
+	c.identity = identity
+	return c, nil
+}
+
+func transportCredentials(opts Options) (credentials.TransportCredentials, error) {
+	if opts.Plaintext {
+		host, _, err := net.SplitHostPort(opts.Address)
+		if err != nil {
+			return nil, fmt.Errorf("remote: %w", err)
+		}
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return nil, errors.New("remote: plaintext is only allowed to a loopback address, such as an ssh tunnel")
+		}
+		return insecure.NewCredentials(), nil
+	}
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if opts.CABundle != "" {
+		pem, err := os.ReadFile(opts.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("remote: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("remote: no certificates in %s", opts.CABundle)
+		}
+	}
+	return credentials.NewTLS(config), nil
+}
+
+// Close drops the connection to the daemon.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Identity describes the daemon's credentials and where it runs.
+func (c *Client) Identity() string {
+	if c.identity == "" {
+		return "via " + c.address
+	}
+	return c.identity + " via " + c.address
+}
+
+func (c *Client) outgoing(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, tokenHeader, "Bearer "+c.token)
+}
+
+// call makes a unary call and returns its result.
+func call[T any](ctx context.Context, c *Client, method string, req request) (T, error) {
+	var result T
+	var trailer metadata.MD
+	err := c.conn.Invoke(c.outgoing(ctx), "/"+serviceName+"/"+method, &req, &result, grpc.Trailer(&trailer))
+	return result, statusError(err, trailer)
+}
+
+func (c *Client) ListSubscriptions(ctx context.Context) ([]azure.Subscription, error) {
+	return call[[]azure.Subscription](ctx, c, "ListSubscriptions", request{})
+}
+
+func (c *Client) ListAccounts(ctx context.Context, subscriptionID string) ([]azure.Account, error) {
+	return call[[]azure.Account](ctx, c, "ListAccounts", request{SubscriptionID: subscriptionID})
+}
+
+func (c *Client) ListContainers(ctx context.Context, account string) ([]azure.Container, error) {
+	return call[[]azure.Container](ctx, c, "ListContainers", request{Account: account})
+}
+
+func (c *Client) ListBlobs(ctx context.Context, account, container string) ([]azure.Blob, error) {
+	return call[[]azure.Blob](ctx, c, "ListBlobs", request{Account: account, Container: container})
+}
+
+func (c *Client) ListBlobsPage(ctx context.Context, account, container string, opts azure.ListBlobsOptions) (azure.BlobPage, error) {
+	return call[azure.BlobPage](ctx, c, "ListBlobsPage", request{Account: account, Container: container, Page: opts})
+}
+
+func (c *Client) GetContainerProperties(ctx context.Context, account, container string) (azure.ContainerProperties, error) {
+	return call[azure.ContainerProperties](ctx, c, "GetContainerProperties", request{Account: account, Container: container})
+}
+
+func (c *Client) GetBlobProperties(ctx context.Context, account, container, blob string) (azure.BlobProperties, error) {
+	return call[azure.BlobProperties](ctx, c, "GetBlobProperties", request{Account: account, Container: container, Blob: blob})
+}
+
+func (c *Client) GetContainerImmutability(ctx context.Context, account, container string) (azure.ContainerImmutability, error) {
+	return call[azure.ContainerImmutability](ctx, c, "GetContainerImmutability", request{Account: account, Container: container})
+}
+
+func (c *Client) FindBlobsByTags(ctx context.Context, account, expression string) ([]azure.TaggedBlob, error) {
+	return call[[]azure.TaggedBlob](ctx, c, "FindBlobsByTags", request{Account: account, Expression: expression})
+}
+
+func (c *Client) GetBlobServiceProperties(ctx context.Context, account string) (azure.BlobServiceProperties, error) {
+	return call[azure.BlobServiceProperties](ctx, c, "GetBlobServiceProperties", request{Account: account})
+}
+
+func (c *Client) GetLifecyclePolicy(ctx context.Context, account string) (azure.LifecyclePolicy, error) {
+	return call[azure.LifecyclePolicy](ctx, c, "GetLifecyclePolicy", request{Account: account})
+}
+
+func (c *Client) GetNetworkRules(ctx context.Context, account string) (azure.NetworkRules, error) {
+	return call[azure.NetworkRules](ctx, c, "GetNetworkRules", request{Account: account})
+}
+
+func (c *Client) ListRoleAssignments(ctx context.Context, account, container string) ([]azure.RoleAssignment, error) {
+	return call[[]azure.RoleAssignment](ctx, c, "ListRoleAssignments", request{Account: account, Container: container})
+}
+
+func (c *Client) ListChangeFeedEvents(ctx context.Context, account string, opts azure.ChangeFeedOptions) ([]azure.ChangeFeedEvent, error) {
+	return call[[]azure.ChangeFeedEvent](ctx, c, "ListChangeFeedEvents", request{Account: account, ChangeFeed: opts})
+}
+
+func (c *Client) ListReplicationPolicies(ctx context.Context, account string) ([]azure.ReplicationPolicy, error) {
+	return call[[]azure.ReplicationPolicy](ctx, c, "ListReplicationPolicies", request{Account: account})
+}
+
+func (c *Client) GetAccountMetrics(ctx context.Context, account string, span, interval time.Duration) (azure.AccountMetrics, error) {
+	return call[azure.AccountMetrics](ctx, c, "GetAccountMetrics", request{Account: account, Span: span, Interval: interval})
+}
+
+func (c *Client) SetCORSRules(ctx context.Context, account string, rules []azure.CORSRule) error {
+	_, err := call[empty](ctx, c, "SetCORSRules", request{Account: account, CORSRules: rules})
+	return err
+}
+
+func (c *Client) SetLifecyclePolicy(ctx context.Context, account string, rules []azure.LifecycleRule) error {
+	_, err := call[empty](ctx, c, "SetLifecyclePolicy", request{Account: account, LifecycleRules: rules})
+	return err
+}
+
+func (c *Client) SetImmutabilityPolicy(ctx context.Context, account, container string, days int, allowAppend bool) error {
+	_, err := call[empty](ctx, c, "SetImmutabilityPolicy", request{Account: account, Container: container, Days: days, AllowAppend: allowAppend})
+	return err
+}
+
+func (c *Client) LockImmutabilityPolicy(ctx context.Context, account, container string) error {
+	_, err := call[empty](ctx, c, "LockImmutabilityPolicy", request{Account: account, Container: container})
+	return err
+}
+
+func (c *Client) SignURL(ctx context.Context, account, container, blob string, opts azure.SASOptions) (string, error) {
+	return call[string](ctx, c, "SignURL", request{Account: account, Container: container, Blob: blob, SAS: opts})
+}
+
+func (c *Client) DeleteBlob(ctx context.Context, account, container, blob string) error {
+	_, err := call[empty](ctx, c, "DeleteBlob", request{Account: account, Container: container, Blob: blob})
+	return err
+}
+
+func (c *Client) UndeleteBlob(ctx context.Context, account, container, blob, etag string) error {
+	_, err := call[empty](ctx, c, "UndeleteBlob", request{Account: account, Container: container, Blob: blob, ETag: etag})
+	return err
+}
+
+func (c *Client) RestoreVersion(ctx context.Context, account, container, blob, etag string) error {
+	_, err := call[empty](ctx, c, "RestoreVersion", request{Account: account, Container: container, Blob: blob, ETag: etag})
+	return err
+}
+
+// OpenBlob streams the blob from the daemon as the reader is read.
+func (c *Client) OpenBlob(ctx context.Context, account, container, blob string) (io.ReadCloser, error) {
+	return c.openStream(ctx, &serviceDesc.Streams[0], request{Account: account, Container: container, Blob: blob})
+}
+
+// OpenBlobRange streams part of the blob from the daemon, which reads only
+// that part where its provider can.
+func (c *Client) OpenBlobRange(ctx context.Context, account, container, blob string, offset, length int64) (io.ReadCloser, error) {
+	return c.openStream(ctx, &serviceDesc.Streams[3], request{Account: account, Container: container, Blob: blob, Offset: offset, Length: length})
+}
+
+// openStream calls one of the methods that stream blob content down.
+func (c *Client) openStream(ctx context.Context, desc *grpc.StreamDesc, req request) (io.ReadCloser, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := c.conn.NewStream(c.outgoing(ctx), desc, "/"+serviceName+"/"+desc.StreamName)
+	if err == nil {
+		err = stream.SendMsg(&req)
+	}
+	if err == nil {
+		err = stream.CloseSend()
+	}
+	if err != nil {
+		cancel()
+		return nil, statusError(err, nil)
+	}
+	// The daemon's error, such as a missing blob, comes with the first
+	// message, so it is read now rather than on the first Read.
+	reader := &blobReader{stream: stream, cancel: cancel}
+	if err := reader.next(); err != nil && !errors.Is(err, io.EOF) {
+		cancel()
+		return nil, err
+	}
+	return reader, nil
+}
+
+// blobReader reads the chunks of an OpenBlob or OpenBlobRange stream.
+type blobReader struct {
+	stream  grpc.ClientStream
+	cancel  context.CancelFunc
+	pending []byte
+	err     error
+}
+
+func (r *blobReader) next() error {
+	var next chunk
+	if err := r.stream.RecvMsg(&next); err != nil {
+		if !errors.Is(err, io.EOF) {
+			err = statusError(err, r.stream.Trailer())
+		}
+		r.err = err
+		return err
+	}
+	r.pending = next.Data
+	return nil
+}
+
+func (r *blobReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.next()
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+func (r *blobReader) Close() error {
+	r.cancel()
+	return nil
+}
+
+// UploadBlob streams r up to the daemon, which writes it to the blob.
+func (c *Client) UploadBlob(ctx context.Context, account, container, blob string, r io.Reader, contentType string) (azure.Blob, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.conn.NewStream(c.outgoing(ctx), &serviceDesc.Streams[1], "/"+serviceName+"/UploadBlob")
+	if err != nil {
+		return azure.Blob{}, statusError(err, nil)
+	}
+	req := request{Account: account, Container: container, Blob: blob, ContentType: contentType}
+	if err := stream.SendMsg(&chunk{Request: &req}); err != nil {
+		return azure.Blob{}, receiveError(stream)
+	}
+	buffer := make([]byte, chunkSize)
+	for {
+		n, err := r.Read(buffer)
+		if n > 0 {
+			if err := stream.SendMsg(&chunk{Data: buffer[:n]}); err != nil {
+				return azure.Blob{}, receiveError(stream)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return azure.Blob{}, err
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		return azure.Blob{}, statusError(err, nil)
+	}
+	var uploaded azure.Blob
+	if err := stream.RecvMsg(&uploaded); err != nil {
+		return azure.Blob{}, statusError(err, stream.Trailer())
+	}
+	return uploaded, nil
+}
+
+// receiveError returns why the daemon ended a stream that could not be
+// sent on.
+func receiveError(stream grpc.ClientStream) error {
+	var discard azure.Blob
+	err := stream.RecvMsg(&discard)
+	if err == nil || errors.Is(err, io.EOF) {
+		return errors.New("remote: the daemon closed the upload")
+	}
+	return statusError(err, stream.Trailer())
+}
+
+// WatchBlobEvents passes on the events the daemon's provider pushes.
+func (c *Client) WatchBlobEvents(ctx context.Context, account, container string, send func(azure.BlobEvent)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.conn.NewStream(c.outgoing(ctx), &serviceDesc.Streams[2], "/"+serviceName+"/WatchBlobEvents")
+	if err == nil {
+		err = stream.SendMsg(&request{Account: account, Container: container})
+	}
+	if err == nil {
+		err = stream.CloseSend()
+	}
+	if err != nil {
+		return statusError(err, nil)
+	}
+	for {
+		var event azure.BlobEvent
+		if err := stream.RecvMsg(&event); err != nil {
+			if errors.Is(err, io.EOF) {
+				return ctx.Err()
+			}
+			return statusError(err, stream.Trailer())
+		}
+		send(event)
+	}
+}
@@ -0,0 +1,132 @@
+// Package remote runs the provider as a daemon on a host that can reach the
+// storage endpoints, such as a jump host, and connects the browser and the
+// commands to it over gRPC. Messages are JSON-encoded provider types, so
+// there is no protobuf schema to keep in step with the azure package.
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"storage-tui/internal/azure"
+)
+
+// serviceName is the gRPC service the daemon registers.
+const serviceName = "storagetui.Provider"
+
+// codecName selects the JSON codec as the gRPC content subtype.
+const codecName = "json"
+
+// chunkSize is how much blob content each streamed message carries.
+const chunkSize = 256 << 10
+
+// maxMessageBytes caps a message the client takes, so a whole listing of a
+// large container still fits where gRPC's default of 4 MB would not.
+const maxMessageBytes = 256 << 20
+
+// tokenHeader carries the daemon's token with every call.
+const tokenHeader = "authorization"
+
+// retryAfterTrailer tells the client how long a throttled call asked to
+// wait.
+const retryAfterTrailer = "retry-after"
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Name() string                       { return codecName }
+
+// request holds the arguments of every call; each uses those of its
+// provider method.
+type request struct {
+	SubscriptionID string                  `json:",omitempty"`
+	Account        string                  `json:",omitempty"`
+	Container      string                  `json:",omitempty"`
+	Blob           string                  `json:",omitempty"`
+	ETag           string                  `json:",omitempty"`
+	Expression     string                  `json:",omitempty"`
+	Page           azure.ListBlobsOptions  `json:",omitzero"`
+	ChangeFeed     azure.ChangeFeedOptions `json:",omitzero"`
+	Span           time.Duration           `json:",omitempty"`
+	Interval       time.Duration           `json:",omitempty"`
+	CORSRules      []azure.CORSRule        `json:",omitempty"`
+	LifecycleRules []azure.LifecycleRule   `json:",omitempty"`
+	Days           int                     `json:",omitempty"`
+	AllowAppend    bool                    `json:",omitempty"`
+	SAS            azure.SASOptions        `json:",omitzero"`
+	ContentType    string                  `json:",omitempty"`
+	Offset         int64                   `json:",omitempty"`
+	Length         int64                   `json:",omitempty"`
+}
+
+// chunk is a piece of blob content on its way up or down; an upload sends
+// its request in the first one.
+type chunk struct {
+	Request *request `json:",omitempty"`
+	Data    []byte   `json:",omitempty"`
+}
+
+// empty answers the calls that return nothing but an error.
+type empty struct{}
+
+// errorStatus turns a provider error into the status sent to the client,
+// keeping what the client needs to tell it apart: cancellation, deadlines,
+// and throttling with its wait.
+func errorStatus(ctx context.Context, err error) error {
+	var throttled *azure.ThrottledError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.As(err, &throttled):
+		grpc.SetTrailer(ctx, metadata.Pairs(retryAfterTrailer, throttled.RetryAfter.String()))
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
+
+// statusError turns a status from the daemon back into the error the
+// provider returned, as far as callers look into it.
+func statusError(err error, trailer metadata.MD) error {
+	if err == nil {
+		return nil
+	}
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	switch s.Code() {
+	case codes.Canceled:
+		return context.Canceled
+	case codes.DeadlineExceeded:
+		return context.DeadlineExceeded
+	case codes.ResourceExhausted:
+		throttled := &azure.ThrottledError{}
+		if values := trailer.Get(retryAfterTrailer); len(values) > 0 {
+			throttled.RetryAfter, _ = time.ParseDuration(values[0])
+		}
+		return throttled
+	case codes.Unavailable:
+		return errors.New("daemon unavailable: " + s.Message())
+	}
+	return errors.New(s.Message())
+}
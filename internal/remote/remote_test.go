@@ -0,0 +1,50 @@
+package remote_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"storage-tui/internal/azure"
+	"storage-tui/internal/provider/providertest"
+	"storage-tui/internal/remote"
+)
+
+// serve runs a daemon answering from provider on a loopback port until the
+// test ends, and returns a client of it.
+func serve(t *testing.T, provider azure.Provider) azure.Provider {
+	listener, err := remote.Listen("127.0.0.1:0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- remote.Serve(ctx, listener, provider, remote.ServeOptions{Token: "test-token"})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("serve: %v", err)
+		}
+	})
+
+	client, err := remote.Dial(t.Context(), remote.Options{Address: listener.Addr().String(), Token: "test-token", Plaintext: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestClientConforms(t *testing.T) {
+	providertest.Run(t, providertest.Backend{
+		New: func(t *testing.T) azure.Provider { return serve(t, azure.NewMockProvider()) },
+		Throttled: func(t *testing.T, retryAfter time.Duration) azure.Provider {
+			return serve(t, providertest.Faulty(t, azure.Faults{ThrottleRate: 1, RetryAfter: retryAfter}))
+		},
+		Failing: func(t *testing.T) azure.Provider {
+			return serve(t, providertest.Faulty(t, azure.Faults{ErrorRate: 1}))
+		},
+	})
+}
@@ -0,0 +1,389 @@
+package remote
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"storage-tui/internal/azure"
+)
+
+// ServeOptions configure the daemon.
+type ServeOptions struct {
+	// Token must come with every call; it cannot be empty.
+	Token string
+	// TLS secures the connections; nil serves plain text, which is only
+	// allowed on loopback addresses, for tunnels such as ssh -L.
+	TLS *tls.Config
+	Log *slog.Logger
+}
+
+type server struct {
+	provider azure.Provider
+	opts     ServeOptions
+}
+
+// Listen listens on addr for Serve, refusing to serve plain text on any but
+// a loopback address.
+func Listen(addr string, tlsConfig *tls.Config) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("remote: %w", err)
+	}
+	if tcp, ok := listener.Addr().(*net.TCPAddr); tlsConfig == nil && (!ok || !tcp.IP.IsLoopback()) {
+		listener.Close()
+		return nil, errors.New("remote: serving without TLS is only allowed on a loopback address")
+	}
+	return listener, nil
+}
+
+// Serve answers the provider calls of remote clients on listener, from
+// Listen, until it fails or ctx is done.
+func Serve(ctx context.Context, listener net.Listener, provider azure.Provider, opts ServeOptions) error {
+	if opts.Token == "" {
+		return errors.New("remote: the daemon needs a token")
+	}
+	if opts.Log == nil {
+		opts.Log = slog.New(slog.DiscardHandler)
+	}
+	s := &server{provider: provider, opts: opts}
+	serverOpts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(s.unaryInterceptor),
+		grpc.StreamInterceptor(s.streamInterceptor),
+	}
+	if opts.TLS != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(opts.TLS)))
+	}
+	grpcServer := grpc.NewServer(serverOpts...)
+	grpcServer.RegisterService(&serviceDesc, s)
+	go func() {
+		<-ctx.Done()
+		grpcServer.GracefulStop()
+	}()
+	return grpcServer.Serve(listener)
+}
+
+// authorize checks the token of the call against the daemon's, in constant
+// time.
+func (s *server) authorize(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(tokenHeader)
+	if len(values) == 1 {
+		token := strings.TrimPrefix(values[0], "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "the daemon refused the token")
+}
+
+func (s *server) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := s.authorize(ctx); err != nil {
+		s.opts.Log.Warn("remote call refused", "method", info.FullMethod)
+		return nil, err
+	}
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	s.logCall(info.FullMethod, start, err)
+	return resp, errorStatus(ctx, err)
+}
+
+func (s *server) streamInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authorize(stream.Context()); err != nil {
+		s.opts.Log.Warn("remote call refused", "method", info.FullMethod)
+		return err
+	}
+	start := time.Now()
+	err := handler(srv, stream)
+	s.logCall(info.FullMethod, start, err)
+	return errorStatus(stream.Context(), err)
+}
+
+func (s *server) logCall(method string, start time.Time, err error) {
+	if err != nil {
+		s.opts.Log.Warn("remote call failed", "method", method, "duration", time.Since(start), "error", err)
+		return
+	}
+	s.opts.Log.Debug("remote call", "method", method, "duration", time.Since(start))
+}
+
+// unary describes a call answered by call with the provider.
+func unary[T any](name string, call func(ctx context.Context, p azure.Provider, req request) (T, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			var req request
+			if err := dec(&req); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, req any) (any, error) {
+				result, err := call(ctx, srv.(*server).provider, *req.(*request))
+				if err != nil {
+					return nil, err
+				}
+				return &result, nil
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/" + name}
+			return interceptor(ctx, &req, info, handler)
+		},
+	}
+}
+
+// editor returns the provider as E, or an error saying what it cannot do.
+func editor[E any](p azure.Provider, what string) (E, error) {
+	e, ok := p.(E)
+	if !ok {
+		return e, status.Error(codes.Unimplemented, "this provider cannot "+what)
+	}
+	return e, nil
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{
+		unary("Identity", func(ctx context.Context, p azure.Provider, req request) (string, error) {
+			if identity, ok := p.(interface{ Identity() string }); ok {
+				return identity.Identity(), nil
+			}
+			return "", nil
+		}),
+		unary("ListSubscriptions", func(ctx context.Context, p azure.Provider, req request) ([]azure.Subscription, error) {
+			return p.ListSubscriptions(ctx)
+		}),
+		unary("ListAccounts", func(ctx context.Context, p azure.Provider, req request) ([]azure.Account, error) {
+			return p.ListAccounts(ctx, req.SubscriptionID)
+		}),
+		unary("ListContainers", func(ctx context.Context, p azure.Provider, req request) ([]azure.Container, error) {
+			return p.ListContainers(ctx, req.Account)
+		}),
+		unary("ListBlobs", func(ctx context.Context, p azure.Provider, req request) ([]azure.Blob, error) {
+			return p.ListBlobs(ctx, req.Account, req.Container)
+		}),
+		unary("ListBlobsPage", func(ctx context.Context, p azure.Provider, req request) (azure.BlobPage, error) {
+			return p.ListBlobsPage(ctx, req.Account, req.Container, req.Page)
+		}),
+		unary("GetContainerProperties", func(ctx context.Context, p azure.Provider, req request) (azure.ContainerProperties, error) {
+			return p.GetContainerProperties(ctx, req.Account, req.Container)
+		}),
+		unary("GetBlobProperties", func(ctx context.Context, p azure.Provider, req request) (azure.BlobProperties, error) {
+			return p.GetBlobProperties(ctx, req.Account, req.Container, req.Blob)
+		}),
+		unary("GetContainerImmutability", func(ctx context.Context, p azure.Provider, req request) (azure.ContainerImmutability, error) {
+			return p.GetContainerImmutability(ctx, req.Account, req.Container)
+		}),
+		unary("FindBlobsByTags", func(ctx context.Context, p azure.Provider, req request) ([]azure.TaggedBlob, error) {
+			return p.FindBlobsByTags(ctx, req.Account, req.Expression)
+		}),
+		unary("GetBlobServiceProperties", func(ctx context.Context, p azure.Provider, req request) (azure.BlobServiceProperties, error) {
+			return p.GetBlobServiceProperties(ctx, req.Account)
+		}),
+		unary("GetLifecyclePolicy", func(ctx context.Context, p azure.Provider, req request) (azure.LifecyclePolicy, error) {
+			return p.GetLifecyclePolicy(ctx, req.Account)
+		}),
+		unary("GetNetworkRules", func(ctx context.Context, p azure.Provider, req request) (azure.NetworkRules, error) {
+			return p.GetNetworkRules(ctx, req.Account)
+		}),
+		unary("ListRoleAssignments", func(ctx context.Context, p azure.Provider, req request) ([]azure.RoleAssignment, error) {
+			return p.ListRoleAssignments(ctx, req.Account, req.Container)
+		}),
+		unary("ListChangeFeedEvents", func(ctx context.Context, p azure.Provider, req request) ([]azure.ChangeFeedEvent, error) {
+			return p.ListChangeFeedEvents(ctx, req.Account, req.ChangeFeed)
+		}),
+		unary("ListReplicationPolicies", func(ctx context.Context, p azure.Provider, req request) ([]azure.ReplicationPolicy, error) {
+			return p.ListReplicationPolicies(ctx, req.Account)
+		}),
+		unary("GetAccountMetrics", func(ctx context.Context, p azure.Provider, req request) (azure.AccountMetrics, error) {
+			return p.GetAccountMetrics(ctx, req.Account, req.Span, req.Interval)
+		}),
+		unary("SetCORSRules", func(ctx context.Context, p azure.Provider, req request) (empty, error) {
+			e, err := editor[azure.CORSEditor](p, "change CORS rules")
+			if err != nil {
+				return empty{}, err
+			}
+			return empty{}, e.SetCORSRules(ctx, req.Account, req.CORSRules)
+		}),
+		unary("SetLifecyclePolicy", func(ctx context.Context, p azure.Provider, req request) (empty, error) {
+			e, err := editor[azure.LifecycleEditor](p, "change lifecycle policies")
+			if err != nil {
+				return empty{}, err
+			}
+			return empty{}, e.SetLifecyclePolicy(ctx, req.Account, req.LifecycleRules)
+		}),
+		unary("SetImmutabilityPolicy", func(ctx context.Context, p azure.Provider, req request) (empty, error) {
+			e, err := editor[azure.ImmutabilityEditor](p, "change immutability policies")
+			if err != nil {
+				return empty{}, err
+			}
+			return empty{}, e.SetImmutabilityPolicy(ctx, req.Account, req.Container, req.Days, req.AllowAppend)
+		}),
+		unary("LockImmutabilityPolicy", func(ctx context.Context, p azure.Provider, req request) (empty, error) {
+			e, err := editor[azure.ImmutabilityEditor](p, "change immutability policies")
+			if err != nil {
+				return empty{}, err
+			}
+			return empty{}, e.LockImmutabilityPolicy(ctx, req.Account, req.Container)
+		}),
+		unary("SignURL", func(ctx context.Context, p azure.Provider, req request) (string, error) {
+			e, err := editor[azure.Signer](p, "sign URLs")
+			if err != nil {
+				return "", err
+			}
+			return e.SignURL(ctx, req.Account, req.Container, req.Blob, req.SAS)
+		}),
+		unary("DeleteBlob", func(ctx context.Context, p azure.Provider, req request) (empty, error) {
+			e, err := editor[azure.Deleter](p, "delete blobs")
+			if err != nil {
+				return empty{}, err
+			}
+			return empty{}, e.DeleteBlob(ctx, req.Account, req.Container, req.Blob)
+		}),
+		unary("UndeleteBlob", func(ctx context.Context, p azure.Provider, req request) (empty, error) {
+			e, err := editor[azure.Restorer](p, "restore blobs")
+			if err != nil {
+				return empty{}, err
+			}
+			return empty{}, e.UndeleteBlob(ctx, req.Account, req.Container, req.Blob, req.ETag)
+		}),
+		unary("RestoreVersion", func(ctx context.Context, p azure.Provider, req request) (empty, error) {
+			e, err := editor[azure.Restorer](p, "restore blobs")
+			if err != nil {
+				return empty{}, err
+			}
+			return empty{}, e.RestoreVersion(ctx, req.Account, req.Container, req.Blob, req.ETag)
+		}),
+	},
+	Streams: []grpc.StreamDesc{
+		{StreamName: "OpenBlob", Handler: openBlob, ServerStreams: true},
+		{StreamName: "UploadBlob", Handler: uploadBlob, ClientStreams: true},
+		{StreamName: "WatchBlobEvents", Handler: watchBlobEvents, ServerStreams: true},
+		{StreamName: "OpenBlobRange", Handler: openBlobRange, ServerStreams: true},
+	},
+}
+
+// openBlob streams the blob down in chunks.
+func openBlob(srv any, stream grpc.ServerStream) error {
+	var req request
+	if err := stream.RecvMsg(&req); err != nil {
+		return err
+	}
+	reader, err := srv.(*server).provider.OpenBlob(stream.Context(), req.Account, req.Container, req.Blob)
+	if err != nil {
+		return err
+	}
+	return sendBlob(stream, reader)
+}
+
+// openBlobRange streams part of the blob down in chunks, with a ranged read
+// where the daemon's provider can make one.
+func openBlobRange(srv any, stream grpc.ServerStream) error {
+	var req request
+	if err := stream.RecvMsg(&req); err != nil {
+		return err
+	}
+	reader, err := azure.OpenRange(stream.Context(), srv.(*server).provider, req.Account, req.Container, req.Blob, req.Offset, req.Length)
+	if err != nil {
+		return err
+	}
+	return sendBlob(stream, reader)
+}
+
+// sendBlob sends what reader reads in chunks, and closes it.
+func sendBlob(stream grpc.ServerStream, reader io.ReadCloser) error {
+	defer reader.Close()
+	buffer := make([]byte, chunkSize)
+	for {
+		n, err := reader.Read(buffer)
+		if n > 0 {
+			if err := stream.SendMsg(&chunk{Data: buffer[:n]}); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// uploadBlob writes the chunks that follow the request to the blob.
+func uploadBlob(srv any, stream grpc.ServerStream) error {
+	uploader, err := editor[azure.Uploader](srv.(*server).provider, "upload blobs")
+	if err != nil {
+		return err
+	}
+	var first chunk
+	if err := stream.RecvMsg(&first); err != nil {
+		return err
+	}
+	if first.Request == nil {
+		return status.Error(codes.InvalidArgument, "the upload did not start with its request")
+	}
+	req := *first.Request
+	reader, writer := io.Pipe()
+	go func() {
+		for {
+			var next chunk
+			err := stream.RecvMsg(&next)
+			if errors.Is(err, io.EOF) {
+				writer.Close()
+				return
+			}
+			if err != nil {
+				writer.CloseWithError(err)
+				return
+			}
+			if _, err := writer.Write(next.Data); err != nil {
+				return
+			}
+		}
+	}()
+	blob, err := uploader.UploadBlob(stream.Context(), req.Account, req.Container, req.Blob, reader, req.ContentType)
+	reader.CloseWithError(io.ErrClosedPipe)
+	if err != nil {
+		return err
+	}
+	return stream.SendMsg(&blob)
+}
+
+// watchBlobEvents streams the container's events down until the client
+// hangs up.
+func watchBlobEvents(srv any, stream grpc.ServerStream) error {
+	subscriber, err := editor[azure.EventSubscriber](srv.(*server).provider, "push storage events")
+	if err != nil {
+		return err
+	}
+	var req request
+	if err := stream.RecvMsg(&req); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	var sendErr error
+	err = subscriber.WatchBlobEvents(ctx, req.Account, req.Container, func(event azure.BlobEvent) {
+		if sendErr == nil {
+			if sendErr = stream.SendMsg(&event); sendErr != nil {
+				cancel()
+			}
+		}
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	return err
+}
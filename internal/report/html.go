@@ -0,0 +1,102 @@
+package report
+
+import (
+	"html/template"
+	"io"
+
+	"storage-tui/internal/export"
+)
+
+// page is the HTML report; it carries its own styles so it opens anywhere
+// as a single file.
+var page = template.Must(template.New("report").Funcs(template.FuncMap{
+	"tags": joinTags,
+	"groups": func(title string, groups []group, r Report) any {
+		return struct {
+			Title  string
+			Groups []group
+			Report Report
+		}{title, groups, r}
+	},
+}).Parse(`<!doctype html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Report.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #222; }
+h1 { font-size: 1.5rem; }
+h2 { font-size: 1.15rem; margin-top: 2rem; }
+table { border-collapse: collapse; margin: 0.5rem 0; }
+th, td { border: 1px solid #ccc; padding: 0.25rem 0.6rem; text-align: left; vertical-align: top; }
+th { background: #f3f3f3; }
+td.number { text-align: right; white-space: nowrap; }
+.note { color: #8a5a00; }
+.generated { color: #666; }
+</style>
+</head>
+<body>
+<h1>{{.Report.Title}}</h1>
+<table>
+{{- range .Report.Location}}
+<tr><th>{{.Name}}</th><td>{{.Value}}</td></tr>
+{{- end}}
+</table>
+<p class="generated">Generated {{call .Report.FormatTime .Report.Generated}} by storage-tui.</p>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Blobs</th><td class="number">{{.Stats.Count}}</td></tr>
+<tr><th>Total size</th><td class="number">{{call .Report.FormatSize .Stats.Bytes}}</td></tr>
+{{- if .Stats.Count}}
+<tr><th>Oldest</th><td>{{call .Report.FormatTime .Stats.Oldest}}</td></tr>
+<tr><th>Newest</th><td>{{call .Report.FormatTime .Stats.Newest}}</td></tr>
+{{- end}}
+</table>
+{{- range .Notes}}
+<p class="note">{{.}}</p>
+{{- end}}
+{{- if .Stats.Count}}
+{{template "groups" (groups "By folder" .Stats.Folders .Report)}}
+{{template "groups" (groups "By content type" .Stats.Types .Report)}}
+{{- if .Stats.Tiers}}
+{{template "groups" (groups "By access tier" .Stats.Tiers .Report)}}
+{{- end}}
+
+<h2>Largest blobs</h2>
+<table>
+<tr><th>Name</th><th>Size</th><th>Modified</th></tr>
+{{- range .Stats.Largest}}
+<tr><td>{{.Name}}</td><td class="number">{{call $.Report.FormatSize .SizeBytes}}</td><td>{{call $.Report.FormatTime .Modified}}</td></tr>
+{{- end}}
+</table>
+
+<h2>Listing</h2>
+<table>
+<tr><th>Name</th><th>Size</th><th>Modified</th><th>Content type</th><th>Tier</th><th>Tags</th></tr>
+{{- range .Rows}}
+<tr><td>{{.Name}}</td><td class="number">{{call $.Report.FormatSize .SizeBytes}}</td><td>{{call $.Report.FormatTime .Modified}}</td><td>{{.ContentType}}</td><td>{{.Tier}}</td><td>{{tags .Tags}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+{{define "groups"}}
+<h2>{{.Title}}</h2>
+<table>
+<tr><th></th><th>Blobs</th><th>Size</th></tr>
+{{- range .Groups}}
+<tr><td>{{.Name}}</td><td class="number">{{.Count}}</td><td class="number">{{call $.Report.FormatSize .Bytes}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+`))
+
+func writeHTML(w io.Writer, r Report, s stats) error {
+	return page.Execute(w, struct {
+		Report Report
+		Stats  stats
+		Notes  []string
+		Rows   []export.Blob
+	}{r, s, notes(r, s), r.Blobs[:s.Shown]})
+}
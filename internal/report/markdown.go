@@ -0,0 +1,73 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// cellEscaper keeps names from breaking a Markdown table or turning into
+// formatting.
+var cellEscaper = strings.NewReplacer("|", `\|`, "\n", " ", "\r", " ", "`", "\\`", "*", `\*`, "_", `\_`, "<", "&lt;", ">", "&gt;")
+
+func cell(text string) string {
+	return cellEscaper.Replace(text)
+}
+
+func writeMarkdown(w io.Writer, r Report, s stats) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "# %s\n\n", cell(r.Title))
+	fmt.Fprintln(out, "| | |")
+	fmt.Fprintln(out, "|---|---|")
+	for _, field := range r.Location {
+		fmt.Fprintf(out, "| **%s** | %s |\n", cell(field.Name), cell(field.Value))
+	}
+	fmt.Fprintf(out, "\n_Generated %s by storage-tui._\n", cell(r.FormatTime(r.Generated)))
+
+	fmt.Fprint(out, "\n## Summary\n\n")
+	fmt.Fprintln(out, "| | |")
+	fmt.Fprintln(out, "|---|--:|")
+	fmt.Fprintf(out, "| Blobs | %d |\n", s.Count)
+	fmt.Fprintf(out, "| Total size | %s |\n", r.FormatSize(s.Bytes))
+	if s.Count > 0 {
+		fmt.Fprintf(out, "| Oldest | %s |\n", r.FormatTime(s.Oldest))
+		fmt.Fprintf(out, "| Newest | %s |\n", r.FormatTime(s.Newest))
+	}
+	for _, note := range notes(r, s) {
+		fmt.Fprintf(out, "\n> %s\n", note)
+	}
+	if s.Count == 0 {
+		return out.Flush()
+	}
+
+	markdownGroups(out, r, "By folder", s.Folders)
+	markdownGroups(out, r, "By content type", s.Types)
+	if len(s.Tiers) > 0 {
+		markdownGroups(out, r, "By access tier", s.Tiers)
+	}
+
+	fmt.Fprint(out, "\n## Largest blobs\n\n")
+	fmt.Fprintln(out, "| Name | Size | Modified |")
+	fmt.Fprintln(out, "|---|--:|---|")
+	for _, blob := range s.Largest {
+		fmt.Fprintf(out, "| %s | %s | %s |\n", cell(blob.Name), r.FormatSize(blob.SizeBytes), r.FormatTime(blob.Modified))
+	}
+
+	fmt.Fprint(out, "\n## Listing\n\n")
+	fmt.Fprintln(out, "| Name | Size | Modified | Content type | Tier | Tags |")
+	fmt.Fprintln(out, "|---|--:|---|---|---|---|")
+	for _, blob := range r.Blobs[:s.Shown] {
+		fmt.Fprintf(out, "| %s | %s | %s | %s | %s | %s |\n", cell(blob.Name), r.FormatSize(blob.SizeBytes), r.FormatTime(blob.Modified), cell(blob.ContentType), cell(blob.Tier), cell(joinTags(blob.Tags)))
+	}
+	return out.Flush()
+}
+
+func markdownGroups(out io.Writer, r Report, title string, groups []group) {
+	fmt.Fprintf(out, "\n## %s\n\n", title)
+	fmt.Fprintln(out, "| | Blobs | Size |")
+	fmt.Fprintln(out, "|---|--:|--:|")
+	for _, g := range groups {
+		fmt.Fprintf(out, "| %s | %d | %s |\n", cell(g.Name), g.Count, r.FormatSize(g.Bytes))
+	}
+}
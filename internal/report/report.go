@@ -0,0 +1,204 @@
+// Package report renders a blob listing, with where it was taken and what
+// it adds up to, as a standalone HTML page or a Markdown document to attach
+// to tickets.
+package report
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"storage-tui/internal/export"
+)
+
+// Formats lists the formats Write knows.
+var Formats = []string{"html", "md"}
+
+// MaxRows caps the blobs a report lists; its statistics still cover them
+// all.
+const MaxRows = 1000
+
+// topGroups is how many folders, content types, and largest blobs the
+// statistics show.
+const topGroups = 10
+
+// FormatForPath returns the format a file extension names, or "" for none.
+func FormatForPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		return "html"
+	case ".md", ".markdown":
+		return "md"
+	}
+	return ""
+}
+
+// Field is a named value of the report's location.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Report is a listing to render.
+type Report struct {
+	Title string
+	// Location says where the listing comes from, top down: the
+	// subscription, account, and container, then the prefix, folder, and
+	// filter that narrow it.
+	Location  []Field
+	Generated time.Time
+	// Scope is the name prefix the blobs share, such as the open folder;
+	// folders are counted below it.
+	Scope string
+	Blobs []export.Blob
+	// Partial is set when the container holds blobs that were not listed
+	// yet, so the statistics cover only those that were.
+	Partial bool
+	// FormatSize and FormatTime render sizes and times as the browser shows
+	// them; without them, sizes are byte counts and times RFC 3339 in UTC.
+	FormatSize func(int64) string
+	FormatTime func(time.Time) string
+}
+
+// Write renders the report to w in format.
+func Write(w io.Writer, format string, r Report) error {
+	if r.FormatSize == nil {
+		r.FormatSize = func(size int64) string { return strconv.FormatInt(size, 10) + " B" }
+	}
+	if r.FormatTime == nil {
+		r.FormatTime = func(t time.Time) string { return t.UTC().Format(time.RFC3339) }
+	}
+	switch format {
+	case "html":
+		return writeHTML(w, r, summarize(r))
+	case "md":
+		return writeMarkdown(w, r, summarize(r))
+	}
+	return fmt.Errorf("unknown report format %q (available: %s)", format, strings.Join(Formats, ", "))
+}
+
+// group is the blobs sharing a folder, content type, or tier.
+type group struct {
+	Name  string
+	Count int
+	Bytes int64
+}
+
+// stats is what the listing adds up to.
+type stats struct {
+	Count   int
+	Bytes   int64
+	Oldest  time.Time
+	Newest  time.Time
+	Folders []group
+	Types   []group
+	Tiers   []group
+	Largest []export.Blob
+	// Shown is how many blobs the listing table holds.
+	Shown int
+}
+
+func summarize(r Report) stats {
+	s := stats{Count: len(r.Blobs), Shown: min(len(r.Blobs), MaxRows)}
+	folders := make(map[string]*group)
+	types := make(map[string]*group)
+	tiers := make(map[string]*group)
+	add := func(groups map[string]*group, name string, blob export.Blob) {
+		g := groups[name]
+		if g == nil {
+			g = &group{Name: name}
+			groups[name] = g
+		}
+		g.Count++
+		g.Bytes += blob.SizeBytes
+	}
+	for _, blob := range r.Blobs {
+		s.Bytes += blob.SizeBytes
+		if s.Oldest.IsZero() || blob.Modified.Before(s.Oldest) {
+			s.Oldest = blob.Modified
+		}
+		if blob.Modified.After(s.Newest) {
+			s.Newest = blob.Modified
+		}
+		// Blobs right in the scope count under it.
+		folder := r.Scope
+		if folder == "" {
+			folder = "(top level)"
+		}
+		rest := strings.TrimPrefix(blob.Name, r.Scope)
+		if index := strings.Index(rest, "/"); index >= 0 {
+			folder = r.Scope + rest[:index+1]
+		}
+		add(folders, folder, blob)
+		contentType := blob.ContentType
+		if contentType == "" {
+			contentType = "(none)"
+		}
+		add(types, contentType, blob)
+		if blob.Tier != "" {
+			add(tiers, blob.Tier, blob)
+		}
+	}
+	s.Folders = ranked(folders)
+	s.Types = ranked(types)
+	s.Tiers = ranked(tiers)
+
+	s.Largest = append([]export.Blob(nil), r.Blobs...)
+	sort.SliceStable(s.Largest, func(i, j int) bool { return s.Largest[i].SizeBytes > s.Largest[j].SizeBytes })
+	s.Largest = s.Largest[:min(len(s.Largest), topGroups)]
+	return s
+}
+
+// ranked orders groups by size, largest first, folding those past
+// topGroups into one.
+func ranked(groups map[string]*group) []group {
+	list := make([]group, 0, len(groups))
+	for _, g := range groups {
+		list = append(list, *g)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Bytes != list[j].Bytes {
+			return list[i].Bytes > list[j].Bytes
+		}
+		return list[i].Name < list[j].Name
+	})
+	if len(list) <= topGroups {
+		return list
+	}
+	rest := group{Name: fmt.Sprintf("(%d more)", len(list)-topGroups+1)}
+	for _, g := range list[topGroups-1:] {
+		rest.Count += g.Count
+		rest.Bytes += g.Bytes
+	}
+	return append(list[:topGroups-1], rest)
+}
+
+// notes are the caveats printed under the summary.
+func notes(r Report, s stats) []string {
+	var notes []string
+	if r.Partial {
+		notes = append(notes, "The container holds more blobs than were listed; these figures cover only the listed ones.")
+	}
+	if s.Shown < s.Count {
+		notes = append(notes, fmt.Sprintf("The listing below shows the first %d of %d blobs.", s.Shown, s.Count))
+	}
+	return notes
+}
+
+// joinTags writes tags as key=value pairs, sorted by key.
+func joinTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + tags[key]
+	}
+	return strings.Join(pairs, ", ")
+}
@@ -0,0 +1,135 @@
+package searches
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Kinds of saved searches.
+const (
+	KindFilter = "filter"
+	KindFind   = "find"
+	KindGrep   = "grep"
+	KindTags   = "tags"
+)
+
+// Filter holds the contents filter fields as typed, so relative ages such as
+// 1d count back from the time the search runs.
+type Filter struct {
+	Name        string `json:"name,omitempty"`
+	After       string `json:"after,omitempty"`
+	Before      string `json:"before,omitempty"`
+	MinSize     string `json:"minSize,omitempty"`
+	MaxSize     string `json:"maxSize,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+// Search is a named search or filter definition: what to look for and where.
+// Find searches run over the subscriptions selected at the time; the other
+// kinds keep the account, container, or folder they were saved for.
+type Search struct {
+	Name             string  `json:"name"`
+	Kind             string  `json:"kind"`
+	Pattern          string  `json:"pattern,omitempty"`
+	SubscriptionID   string  `json:"subscriptionId,omitempty"`
+	SubscriptionName string  `json:"subscriptionName,omitempty"`
+	Account          string  `json:"account,omitempty"`
+	Container        string  `json:"container,omitempty"`
+	Prefix           string  `json:"prefix,omitempty"`
+	Filter           *Filter `json:"filter,omitempty"`
+}
+
+// Scope renders where the search runs as a slash separated path.
+func (s Search) Scope() string {
+	switch {
+	case s.Kind == KindFind:
+		return "selected subscriptions"
+	case s.Container == "":
+		return s.Account
+	}
+	return strings.TrimSuffix(fmt.Sprintf("%s/%s/%s", s.Account, s.Container, s.Prefix), "/")
+}
+
+// Store keeps saved searches in memory and writes them to a JSON file on
+// change.
+type Store struct {
+	path  string
+	items []Search
+}
+
+// DefaultPath returns the saved searches file inside the user config
+// directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "storage-tui", "searches.json"), nil
+}
+
+// Load reads saved searches from path. A missing file yields an empty store.
+func Load(path string) (*Store, error) {
+	store := &Store{path: path}
+	if path == "" {
+		return store, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return store, err
+	}
+	if err := json.Unmarshal(data, &store.items); err != nil {
+		return store, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return store, nil
+}
+
+func (s *Store) List() []Search {
+	return append([]Search(nil), s.items...)
+}
+
+// Put adds the search, replacing a saved search of the same name, and saves
+// the result. It reports whether one was replaced.
+func (s *Store) Put(search Search) (bool, error) {
+	for i, item := range s.items {
+		if strings.EqualFold(item.Name, search.Name) {
+			s.items[i] = search
+			return true, s.Save()
+		}
+	}
+	s.items = append(s.items, search)
+	return false, s.Save()
+}
+
+func (s *Store) Remove(index int) error {
+	if index < 0 || index >= len(s.items) {
+		return nil
+	}
+	s.items = append(s.items[:index], s.items[index+1:]...)
+	return s.Save()
+}
+
+// Save writes the saved searches file, replacing it atomically.
+func (s *Store) Save() error {
+	if s.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s.items, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,92 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"storage-tui/internal/searches"
+)
+
+// Node identifies a subscription, account, container, or blob in the tree.
+type Node struct {
+	Kind           string `json:"kind"`
+	Name           string `json:"name"`
+	SubscriptionID string `json:"subscriptionId,omitempty"`
+	Account        string `json:"account,omitempty"`
+	Container      string `json:"container,omitempty"`
+}
+
+// State is the view saved on exit and restored on the next launch.
+type State struct {
+	// Expanded lists the expanded tree nodes, parents before children.
+	Expanded []Node `json:"expanded,omitempty"`
+	// Current is the selected tree node.
+	Current *Node `json:"current,omitempty"`
+	// Blob is the blob selected in the contents table, if any.
+	Blob string `json:"blob,omitempty"`
+	// Prefix is the name prefix the current container was listed by.
+	Prefix string `json:"prefix,omitempty"`
+	// FolderView is set when the contents showed virtual folders, with
+	// Folder open.
+	FolderView bool   `json:"folderView,omitempty"`
+	Folder     string `json:"folder,omitempty"`
+	// Filter is the contents filter as typed, so ages such as 1d count back
+	// from the time the session is restored.
+	Filter *searches.Filter `json:"filter,omitempty"`
+}
+
+// Empty reports whether the state has nothing to restore.
+func (s State) Empty() bool {
+	return len(s.Expanded) == 0 && s.Current == nil
+}
+
+// DefaultPath returns the session file of profile inside the user config
+// directory. Each profile keeps its own session; the empty profile uses
+// session.json.
+func DefaultPath(profile string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	name := "session.json"
+	if profile != "" {
+		name = "session-" + url.PathEscape(profile) + ".json"
+	}
+	return filepath.Join(dir, "storage-tui", name), nil
+}
+
+// Load reads the state saved at path. A missing file yields an empty state.
+func Load(path string) (State, error) {
+	var state State
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// Save writes the state to path, replacing the file atomically.
+func Save(path string, state State) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
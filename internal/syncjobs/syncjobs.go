@@ -0,0 +1,378 @@
+// Package syncjobs repeats the sync jobs of the config file on their
+// schedules, for the browser and the syncd command, and keeps the history of
+// their runs.
+package syncjobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"storage-tui/internal/audit"
+	"storage-tui/internal/azure"
+	"storage-tui/internal/dirsync"
+)
+
+// remotePrefix marks the container side of a job, as it does for the sync
+// command.
+const remotePrefix = "az://"
+
+// MinEvery is the shortest time allowed between the runs of a job.
+const MinEvery = time.Minute
+
+// maxHistory caps how many runs the history keeps; the oldest go first.
+const maxHistory = 200
+
+// Job is a sync repeated on a timer.
+type Job struct {
+	Name string
+	// Source and Destination are a directory and a container or prefix,
+	// written az://account/container/[prefix], in either order; which of
+	// them is the container sets the direction.
+	Source      string
+	Destination string
+	// Every is how long to wait between the start of one run and the next.
+	Every time.Duration
+	// Delete and Checksum are the sync command's -delete and -checksum.
+	Delete   bool
+	Checksum bool
+}
+
+// Upload reports whether the job makes the blobs match the directory.
+func (j Job) Upload() bool {
+	return strings.HasPrefix(j.Destination, remotePrefix)
+}
+
+// Direction names the way the job copies: upload or download.
+func (j Job) Direction() string {
+	if j.Upload() {
+		return "upload"
+	}
+	return "download"
+}
+
+// Remote returns the container side of the job, such as
+// az://acme-dev/logs/2024.
+func (j Job) Remote() string {
+	if j.Upload() {
+		return j.Destination
+	}
+	return j.Source
+}
+
+// Options returns what a run of the job syncs.
+func (j Job) Options() (dirsync.Options, error) {
+	sourceRemote, destinationRemote := strings.HasPrefix(j.Source, remotePrefix), strings.HasPrefix(j.Destination, remotePrefix)
+	if sourceRemote == destinationRemote {
+		return dirsync.Options{}, fmt.Errorf("job %q syncs a directory with a container or prefix, written %saccount/container/[prefix]", j.Name, remotePrefix)
+	}
+	local := j.Source
+	if sourceRemote {
+		local = j.Destination
+	}
+	parts := strings.SplitN(strings.TrimPrefix(j.Remote(), remotePrefix), "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" || local == "" {
+		return dirsync.Options{}, fmt.Errorf("job %q syncs a directory with %saccount/container/[prefix]", j.Name, remotePrefix)
+	}
+	opts := dirsync.Options{
+		Local:     local,
+		Account:   parts[0],
+		Container: parts[1],
+		Upload:    j.Upload(),
+		Delete:    j.Delete,
+		Checksum:  j.Checksum,
+	}
+	if len(parts) > 2 {
+		opts.Prefix = parts[2]
+	}
+	return opts, nil
+}
+
+// Validate checks that every job has a name of its own, a directory and a
+// container, and runs at most once a minute.
+func Validate(jobs []Job) error {
+	var problems []error
+	seen := make(map[string]bool)
+	for _, job := range jobs {
+		switch {
+		case job.Name == "":
+			problems = append(problems, errors.New("a job has no name"))
+			continue
+		case seen[job.Name]:
+			problems = append(problems, fmt.Errorf("job %q is defined twice", job.Name))
+		case job.Every < MinEvery:
+			problems = append(problems, fmt.Errorf("job %q runs every %s; the shortest interval is %s", job.Name, job.Every, MinEvery))
+		}
+		seen[job.Name] = true
+		if _, err := job.Options(); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// Run is one run of a job.
+type Run struct {
+	Job      string
+	Started  time.Time
+	Finished time.Time
+	// Summary is the summary of the run's plan, empty when it could not be
+	// made.
+	Summary string
+	// Err is why the plan could not be made, or the errors of the actions
+	// that failed.
+	Err error
+	// Changed names the blobs a sync up wrote over or deleted.
+	Changed dirsync.Changed
+}
+
+// Running reports whether the run has not finished yet.
+func (r Run) Running() bool {
+	return r.Finished.IsZero()
+}
+
+// Status is a job with when it last ran and when it runs next.
+type Status struct {
+	Job Job
+	// Last is the latest run, which may still be going; its Started is zero
+	// before the first.
+	Last Run
+	// Plan is what the latest run planned, set before the run carries it
+	// out; it has no actions when the run could not plan.
+	Plan dirsync.Plan
+	Next time.Time
+}
+
+// Options configures a Scheduler.
+type Options struct {
+	// Audit records the runs that write or delete blobs, as the sync
+	// command does; without it, those runs are refused.
+	Audit *audit.Log
+	// Notify is called, on the job's goroutine, as each run starts and
+	// again once it has finished.
+	Notify func(Run)
+	// Clock times the runs; nil uses the system clock.
+	Clock Clock
+}
+
+// Clock tells the time and waits for it, so tests can move it by hand.
+type Clock interface {
+	Now() time.Time
+	// After sends the time once d has passed, at once when d is not
+	// positive, as time.After does.
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Scheduler runs jobs on their timers against a provider.
+type Scheduler struct {
+	provider azure.Provider
+	opts     Options
+
+	mu      sync.Mutex
+	jobs    []*scheduled
+	history []Run
+}
+
+// scheduled is a job with its state.
+type scheduled struct {
+	job     Job
+	last    Run
+	plan    dirsync.Plan
+	next    time.Time
+	trigger chan struct{}
+}
+
+// New returns a scheduler of jobs, which must be valid. Nothing runs until
+// Start.
+func New(provider azure.Provider, jobs []Job, opts Options) *Scheduler {
+	if opts.Clock == nil {
+		opts.Clock = systemClock{}
+	}
+	s := &Scheduler{provider: provider, opts: opts}
+	for _, job := range jobs {
+		s.jobs = append(s.jobs, &scheduled{job: job, trigger: make(chan struct{}, 1)})
+	}
+	return s
+}
+
+// Start runs every job right away and then every Every, each on its own
+// goroutine, until ctx is done. Runs of a job never overlap; a run that
+// takes longer than Every is followed by the next at once.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		go s.loop(ctx, job)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, job *scheduled) {
+	clock := s.opts.Clock
+	wait := time.Duration(0)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-clock.After(wait):
+		case <-job.trigger:
+		}
+		started := clock.Now()
+		s.mu.Lock()
+		job.next = started.Add(job.job.Every)
+		s.mu.Unlock()
+		s.run(ctx, job)
+		wait = started.Add(job.job.Every).Sub(clock.Now())
+	}
+}
+
+// RunNow starts the named job ahead of its schedule, and reports whether it
+// could: not when it is unknown or already running.
+func (s *Scheduler) RunNow(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		if job.job.Name != name {
+			continue
+		}
+		if job.last.Running() && !job.last.Started.IsZero() {
+			return false
+		}
+		select {
+		case job.trigger <- struct{}{}:
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// RunAll runs every job once, one after the other, and returns the errors
+// of those that failed.
+func (s *Scheduler) RunAll(ctx context.Context) error {
+	var failed []error
+	for _, job := range s.jobs {
+		if err := s.run(ctx, job); err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", job.job.Name, err))
+		}
+	}
+	return errors.Join(failed...)
+}
+
+// Statuses returns the jobs in the order they were given.
+func (s *Scheduler) Statuses() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]Status, len(s.jobs))
+	for i, job := range s.jobs {
+		statuses[i] = Status{Job: job.job, Last: job.last, Plan: job.plan, Next: job.next}
+	}
+	return statuses
+}
+
+// History returns the finished runs, oldest first.
+func (s *Scheduler) History() []Run {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Run(nil), s.history...)
+}
+
+// run makes the job's plan and carries it out, keeping the run in the
+// history.
+func (s *Scheduler) run(ctx context.Context, job *scheduled) error {
+	run := Run{Job: job.job.Name, Started: s.opts.Clock.Now()}
+	s.mu.Lock()
+	job.plan = dirsync.Plan{}
+	s.mu.Unlock()
+	s.update(job, run)
+
+	run.Summary, run.Changed, run.Err = s.sync(ctx, job)
+	run.Finished = s.opts.Clock.Now()
+	s.update(job, run)
+	return run.Err
+}
+
+func (s *Scheduler) update(job *scheduled, run Run) {
+	s.mu.Lock()
+	job.last = run
+	if !run.Running() {
+		s.history = append(s.history, run)
+		if len(s.history) > maxHistory {
+			s.history = s.history[len(s.history)-maxHistory:]
+		}
+	}
+	s.mu.Unlock()
+	if s.opts.Notify != nil {
+		s.opts.Notify(run)
+	}
+}
+
+// DryRun plans the named job as its next run would, without carrying the
+// plan out.
+func (s *Scheduler) DryRun(ctx context.Context, name string) (dirsync.Plan, error) {
+	for _, job := range s.jobs {
+		if job.job.Name != name {
+			continue
+		}
+		opts, err := job.job.Options()
+		if err != nil {
+			return dirsync.Plan{}, err
+		}
+		return dirsync.Build(ctx, s.provider, opts)
+	}
+	return dirsync.Plan{}, fmt.Errorf("no sync job %q", name)
+}
+
+// sync runs the job once, keeping its plan before carrying it out, and
+// returns the plan's summary and the blobs it changed.
+func (s *Scheduler) sync(ctx context.Context, scheduled *scheduled) (string, dirsync.Changed, error) {
+	var changed dirsync.Changed
+	job := scheduled.job
+	opts, err := job.Options()
+	if err != nil {
+		return "", changed, err
+	}
+	if opts.Upload && s.opts.Audit == nil {
+		return "", changed, errors.New("sync is refused: the audit log could not be opened")
+	}
+	plan, err := dirsync.Build(ctx, s.provider, opts)
+	if err != nil {
+		return "", changed, err
+	}
+	s.mu.Lock()
+	scheduled.plan = plan
+	s.mu.Unlock()
+	summary := plan.Summary()
+	if len(plan.Actions) == 0 {
+		return summary, changed, nil
+	}
+	err = plan.Execute(ctx, s.provider, plan.Track(&changed, func(dirsync.Progress) {}))
+	if !opts.Upload {
+		return summary, changed, err
+	}
+	// Writing and deleting blobs is recorded in the audit log, as the sync
+	// command does.
+	entry := audit.Entry{
+		Action: "sync",
+		Target: strings.TrimRight(job.Remote(), "/"),
+		Detail: fmt.Sprintf("job %s from %s, planned %s", job.Name, opts.Local, strings.ToLower(strings.TrimSuffix(summary, "."))),
+		Result: audit.ResultOK,
+	}
+	entry.SetChanged(changed.Replaced, changed.Deleted)
+	if identity, ok := s.provider.(interface{ Identity() string }); ok {
+		entry.Identity = identity.Identity()
+	}
+	if err != nil {
+		entry.Result, entry.Error = audit.ResultFailed, err.Error()
+	}
+	if recordErr := s.opts.Audit.Record(entry); recordErr != nil {
+		return summary, changed, errors.Join(err, recordErr)
+	}
+	return summary, changed, err
+}
@@ -0,0 +1,263 @@
+package syncjobs_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"storage-tui/internal/azure"
+	"storage-tui/internal/dirsync"
+	"storage-tui/internal/syncjobs"
+)
+
+// fakeClock only moves when Advance is called, and reports each wait the
+// scheduler starts on waits.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []fakeTimer
+	waits  chan time.Duration
+}
+
+type fakeTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, waits: make(chan time.Duration, 16)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+	} else {
+		c.timers = append(c.timers, fakeTimer{at: c.now.Add(d), c: ch})
+	}
+	c.mu.Unlock()
+	c.waits <- d
+	return ch
+}
+
+// Advance moves the clock on by d, firing the timers it reaches.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.timers[:0]
+	for _, timer := range c.timers {
+		if timer.at.After(c.now) {
+			pending = append(pending, timer)
+			continue
+		}
+		timer.c <- c.now
+	}
+	c.timers = pending
+}
+
+// gated holds every listing until it is let through, and counts how many
+// are held at once.
+type gated struct {
+	azure.Provider
+	gate chan struct{}
+
+	mu              sync.Mutex
+	active, maxSeen int
+}
+
+func (g *gated) ListBlobsPage(ctx context.Context, account, container string, opts azure.ListBlobsOptions) (azure.BlobPage, error) {
+	g.mu.Lock()
+	g.active++
+	g.maxSeen = max(g.maxSeen, g.active)
+	g.mu.Unlock()
+	defer func() {
+		g.mu.Lock()
+		g.active--
+		g.mu.Unlock()
+	}()
+	select {
+	case <-g.gate:
+	case <-ctx.Done():
+		return azure.BlobPage{}, ctx.Err()
+	}
+	return g.Provider.ListBlobsPage(ctx, account, container, opts)
+}
+
+const every = 10 * time.Minute
+
+var start = time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC)
+
+// startScheduler starts a download job on clock and returns the runs as it
+// notifies them.
+func startScheduler(t *testing.T, provider azure.Provider, clock *fakeClock) (*syncjobs.Scheduler, <-chan syncjobs.Run) {
+	t.Helper()
+	job := syncjobs.Job{Name: "pull", Source: "az://acme-dev/images/sync", Destination: t.TempDir(), Every: every}
+	if err := syncjobs.Validate([]syncjobs.Job{job}); err != nil {
+		t.Fatal(err)
+	}
+	runs := make(chan syncjobs.Run, 16)
+	scheduler := syncjobs.New(provider, []syncjobs.Job{job}, syncjobs.Options{
+		Notify: func(run syncjobs.Run) { runs <- run },
+		Clock:  clock,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	scheduler.Start(ctx)
+	return scheduler, runs
+}
+
+func receive[T any](t *testing.T, c <-chan T, what string) T {
+	t.Helper()
+	select {
+	case v := <-c:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no %s", what)
+		panic("unreachable")
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	clock := newFakeClock(start)
+	scheduler, runs := startScheduler(t, azure.NewMockProvider(), clock)
+
+	if wait := receive(t, clock.waits, "first wait"); wait != 0 {
+		t.Errorf("first run waits %s, want it at once", wait)
+	}
+	for i, want := range []time.Time{start, start.Add(every), start.Add(2 * every)} {
+		if run := receive(t, runs, "run start"); !run.Running() || !run.Started.Equal(want) {
+			t.Fatalf("run %d started %v (running %v), want %v", i, run.Started, run.Running(), want)
+		}
+		run := receive(t, runs, "run finish")
+		if run.Running() || run.Err != nil {
+			t.Fatalf("run %d = %+v, want it finished", i, run)
+		}
+		if wait := receive(t, clock.waits, "wait"); wait != every {
+			t.Errorf("run %d is followed by a wait of %s, want %s", i, wait, every)
+		}
+		status := scheduler.Statuses()[0]
+		if next := want.Add(every); !status.Next.Equal(next) || !status.Last.Started.Equal(want) {
+			t.Errorf("run %d: status last %v next %v, want %v and %v", i, status.Last.Started, status.Next, want, next)
+		}
+		// Short of the next run, nothing happens.
+		clock.Advance(every - time.Second)
+		select {
+		case run := <-runs:
+			t.Fatalf("run started %v, before it was due", run.Started)
+		case <-time.After(20 * time.Millisecond):
+		}
+		clock.Advance(time.Second)
+	}
+	if got := len(scheduler.History()); got != 3 {
+		t.Errorf("history has %d runs, want 3", got)
+	}
+}
+
+func TestSlowRunDoesNotOverlap(t *testing.T) {
+	clock := newFakeClock(start)
+	provider := &gated{Provider: azure.NewMockProvider(), gate: make(chan struct{})}
+	scheduler, runs := startScheduler(t, provider, clock)
+
+	receive(t, clock.waits, "first wait")
+	receive(t, runs, "run start")
+	// The run takes two and a half intervals; the runs it misses are not
+	// started alongside it, and the job cannot be run now.
+	clock.Advance(every*5/2 + time.Second)
+	if scheduler.RunNow("pull") {
+		t.Error("RunNow started a job that is running")
+	}
+	select {
+	case run := <-runs:
+		t.Fatalf("run %+v notified while the first is held", run)
+	case <-time.After(20 * time.Millisecond):
+	}
+	provider.gate <- struct{}{}
+	receive(t, runs, "run finish")
+
+	// The next run follows at once, and is timed from when it started.
+	if wait := receive(t, clock.waits, "wait"); wait > 0 {
+		t.Errorf("late run is followed by a wait of %s, want none", wait)
+	}
+	late := start.Add(every*5/2 + time.Second)
+	if run := receive(t, runs, "run start"); !run.Started.Equal(late) {
+		t.Errorf("next run started %v, want %v", run.Started, late)
+	}
+	provider.gate <- struct{}{}
+	receive(t, runs, "run finish")
+	receive(t, clock.waits, "wait")
+	if next := scheduler.Statuses()[0].Next; !next.Equal(late.Add(every)) {
+		t.Errorf("next run %v, want %v", next, late.Add(every))
+	}
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	if provider.maxSeen != 1 {
+		t.Errorf("%d runs went at once, want 1", provider.maxSeen)
+	}
+}
+
+func TestRunNow(t *testing.T) {
+	clock := newFakeClock(start)
+	scheduler, runs := startScheduler(t, azure.NewMockProvider(), clock)
+	receive(t, clock.waits, "first wait")
+	receive(t, runs, "run start")
+	receive(t, runs, "run finish")
+	receive(t, clock.waits, "wait")
+
+	clock.Advance(time.Minute)
+	if !scheduler.RunNow("pull") {
+		t.Fatal("RunNow refused an idle job")
+	}
+	if run := receive(t, runs, "run start"); !run.Started.Equal(start.Add(time.Minute)) {
+		t.Errorf("run started %v, want it at once", run.Started)
+	}
+	receive(t, runs, "run finish")
+	// The schedule starts over from the run.
+	if wait := receive(t, clock.waits, "wait"); wait != every {
+		t.Errorf("run now is followed by a wait of %s, want %s", wait, every)
+	}
+	if scheduler.RunNow("push") {
+		t.Error("RunNow started an unknown job")
+	}
+}
+
+func TestPlans(t *testing.T) {
+	provider := azure.NewMockProvider()
+	if _, err := provider.UploadBlob(context.Background(), "acme-dev", "images", "sync/a.txt", strings.NewReader("a"), ""); err != nil {
+		t.Fatal(err)
+	}
+	clock := newFakeClock(start)
+	scheduler, runs := startScheduler(t, provider, clock)
+	receive(t, runs, "run start")
+	receive(t, runs, "run finish")
+
+	// The status keeps the plan the run carried out.
+	plan := scheduler.Statuses()[0].Plan
+	if len(plan.Actions) != 1 || plan.Actions[0].Name != "a.txt" || plan.Actions[0].Kind != dirsync.KindDownload {
+		t.Errorf("last run's plan = %+v, want the download of a.txt", plan.Actions)
+	}
+
+	// A dry run plans against what the run left, and carries nothing out.
+	plan, err := scheduler.DryRun(context.Background(), "pull")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Actions) != 0 || plan.UpToDate != 1 {
+		t.Errorf("dry run = %s, want a.txt up to date", plan.Summary())
+	}
+	if history := scheduler.History(); len(history) != 1 {
+		t.Errorf("history after a dry run has %d runs, want 1", len(history))
+	}
+	if _, err := scheduler.DryRun(context.Background(), "push"); err == nil {
+		t.Error("DryRun of an unknown job succeeded")
+	}
+}
@@ -0,0 +1,192 @@
+// Package usage counts which features of the browser are used, for users who
+// turn usage metrics on: the panes focused, the actions run, and the kinds of
+// provider browsed. It counts nothing that names storage, such as accounts,
+// containers, or blobs, and nothing that identifies the user or machine.
+// Counts add up on disk across launches and are reported, when an endpoint is
+// set, about once a day.
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// DefaultEndpoint is where reports are sent when the config sets none. It is
+// empty, which keeps the counts on the machine, unless a build sets it with
+// -ldflags "-X storage-tui/internal/usage.DefaultEndpoint=https://...".
+var DefaultEndpoint string
+
+// ReportEvery is how long counts add up before they are reported.
+const ReportEvery = 24 * time.Hour
+
+// day is how Since is written: by the day, as a report needs no more.
+const day = "2006-01-02"
+
+// Report is what is counted and sent: the day counting started, the
+// operating system and architecture, and how often each provider kind, pane,
+// and action was used since. Actions are named as the help describes them.
+type Report struct {
+	Since     string           `json:"since"`
+	OS        string           `json:"os"`
+	Arch      string           `json:"arch"`
+	Providers map[string]int64 `json:"providers,omitempty"`
+	Panes     map[string]int64 `json:"panes,omitempty"`
+	Actions   map[string]int64 `json:"actions,omitempty"`
+}
+
+// Empty reports whether nothing has been counted.
+func (r Report) Empty() bool {
+	return len(r.Providers) == 0 && len(r.Panes) == 0 && len(r.Actions) == 0
+}
+
+// Due reports whether the counts have added up long enough to be sent.
+func (r Report) Due(now time.Time) bool {
+	since, err := time.Parse(day, r.Since)
+	return err != nil || now.Sub(since) >= ReportEvery
+}
+
+// Counter counts usage on top of the counts not yet reported. A nil Counter
+// counts nothing, so callers need not check whether metrics are on.
+type Counter struct {
+	mu     sync.Mutex
+	report Report
+}
+
+// New returns a Counter adding to pending, as loaded from the last launches.
+func New(pending Report) *Counter {
+	if pending.Since == "" || pending.Empty() {
+		pending.Since = time.Now().UTC().Format(day)
+	}
+	pending.OS, pending.Arch = runtime.GOOS, runtime.GOARCH
+	return &Counter{report: pending}
+}
+
+// Provider counts a launch or profile switch onto a kind of provider, such
+// as mock or remote.
+func (c *Counter) Provider(kind string) {
+	if c != nil {
+		c.add(&c.report.Providers, kind)
+	}
+}
+
+// Pane counts focusing a pane.
+func (c *Counter) Pane(name string) {
+	if c != nil {
+		c.add(&c.report.Panes, name)
+	}
+}
+
+// Action counts running an action, named as the help describes it.
+func (c *Counter) Action(name string) {
+	if c != nil {
+		c.add(&c.report.Actions, name)
+	}
+}
+
+func (c *Counter) add(counts *map[string]int64, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if *counts == nil {
+		*counts = make(map[string]int64)
+	}
+	(*counts)[name]++
+}
+
+// Report returns a copy of the counts.
+func (c *Counter) Report() Report {
+	if c == nil {
+		return Report{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	report := c.report
+	for _, counts := range []*map[string]int64{&report.Providers, &report.Panes, &report.Actions} {
+		copied := make(map[string]int64, len(*counts))
+		for name, n := range *counts {
+			copied[name] = n
+		}
+		*counts = copied
+	}
+	return report
+}
+
+// DisabledByEnv reports whether DO_NOT_TRACK asks for no usage metrics,
+// whatever the preferences say.
+func DisabledByEnv() bool {
+	value := os.Getenv("DO_NOT_TRACK")
+	return value != "" && value != "0" && value != "false"
+}
+
+// DefaultPath returns the file of counts not yet reported, inside the user
+// cache directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "storage-tui", "usage.json"), nil
+}
+
+// Load reads the counts saved at path. A missing file yields none.
+func Load(path string) (Report, error) {
+	var report Report
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return report, nil
+	}
+	if err != nil {
+		return report, err
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return Report{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return report, nil
+}
+
+// Save writes report to path, replacing the file atomically. The file is
+// readable by its owner only.
+func Save(path string, report Report) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Send posts report to endpoint as JSON.
+func Send(ctx context.Context, endpoint string, report Report) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("usage report: %s", resp.Status)
+	}
+	return nil
+}